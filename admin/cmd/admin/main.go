@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"time"
 
@@ -31,6 +32,7 @@ func run() error {
 	clickhouseUsernameFlag := flag.String("clickhouse-username", "default", "ClickHouse username (or set CLICKHOUSE_USERNAME env var)")
 	clickhousePasswordFlag := flag.String("clickhouse-password", "", "ClickHouse password (or set CLICKHOUSE_PASSWORD env var)")
 	clickhouseSecureFlag := flag.Bool("clickhouse-secure", false, "Enable TLS for ClickHouse Cloud (or set CLICKHOUSE_SECURE=true env var)")
+	clickhouseHTTPAddrFlag := flag.String("clickhouse-http-addr", "", "ClickHouse HTTP address (host:port) for snapshots (default: --clickhouse-addr host on 8123, or 8443 with --clickhouse-secure) (or set CLICKHOUSE_ADDR_HTTP env var)")
 
 	// Neo4j configuration
 	neo4jURIFlag := flag.String("neo4j-uri", "", "Neo4j URI (e.g., bolt://localhost:7687) (or set NEO4J_URI env var)")
@@ -54,6 +56,7 @@ func run() error {
 	clickhouseMigrateResetFlag := flag.Bool("clickhouse-migrate-reset", false, "Roll back all ClickHouse migrations (dangerous!)")
 	neo4jMigrateFlag := flag.Bool("neo4j-migrate", false, "Run Neo4j database migrations")
 	neo4jMigrateStatusFlag := flag.Bool("neo4j-migrate-status", false, "Show Neo4j database migration status")
+	dimSchemaMigrateFlag := flag.Bool("dim-schema-migrate", false, "Rehash dimension history after payload column changes (use --dry-run to report affected rows)")
 	resetDBFlag := flag.Bool("reset-db", false, "Drop all database tables (dim_*, stg_*, fact_*) and views")
 	dryRunFlag := flag.Bool("dry-run", false, "Dry run mode - show what would be done without actually executing")
 	yesFlag := flag.Bool("yes", false, "Skip confirmation prompt (use with caution)")
@@ -63,6 +66,13 @@ func run() error {
 	backfillInternetMetroLatencyFlag := flag.Bool("backfill-internet-metro-latency", false, "Backfill internet metro latency fact table from on-chain data")
 	backfillDeviceInterfaceCountersFlag := flag.Bool("backfill-device-interface-counters", false, "Backfill device interface counters fact table from InfluxDB")
 
+	// Snapshot commands
+	exportSnapshotFlag := flag.String("export-snapshot", "", "Export dim history and fact tables to a snapshot directory (filter with --start-time/--end-time)")
+	importSnapshotFlag := flag.String("import-snapshot", "", "Import a snapshot directory, replacing its tables, and rebuild the Neo4j graph if --neo4j-uri is set")
+	snapshotTablesFlag := flag.StringSlice("snapshot-tables", nil, "Tables to export (default: all dim_*_history, dim_change_log, dim_schema_versions and fact_* tables)")
+	snapshotFormatFlag := flag.String("snapshot-format", admin.SnapshotFormatNative, "Snapshot file format: native or parquet")
+	snapshotPseudonymizeFlag := flag.Bool("snapshot-pseudonymize", false, "Pseudonymize user IPs, owner pubkeys and validator gossip IPs in the exported snapshot")
+
 	// Backfill options (latency - epoch-based)
 	dzEnvFlag := flag.String("dz-env", config.EnvMainnetBeta, "DZ ledger environment (devnet, testnet, mainnet-beta)")
 	startEpochFlag := flag.Int64("start-epoch", -1, "Start epoch for latency backfill (-1 = auto-calculate: end-epoch - 9)")
@@ -108,6 +118,9 @@ func run() error {
 	if os.Getenv("CLICKHOUSE_SECURE") == "true" {
 		*clickhouseSecureFlag = true
 	}
+	if envClickhouseHTTPAddr := os.Getenv("CLICKHOUSE_ADDR_HTTP"); envClickhouseHTTPAddr != "" {
+		*clickhouseHTTPAddrFlag = envClickhouseHTTPAddr
+	}
 
 	// Override Neo4j flags with environment variables if set
 	if envNeo4jURI := os.Getenv("NEO4J_URI"); envNeo4jURI != "" {
@@ -258,6 +271,81 @@ func run() error {
 		return admin.ResetDB(log, *clickhouseAddrFlag, *clickhouseDatabaseFlag, *clickhouseUsernameFlag, *clickhousePasswordFlag, *clickhouseSecureFlag, *dryRunFlag, *yesFlag)
 	}
 
+	if *dimSchemaMigrateFlag {
+		if *clickhouseAddrFlag == "" {
+			return fmt.Errorf("--clickhouse-addr is required for --dim-schema-migrate")
+		}
+		return admin.MigrateDimSchemas(log, *clickhouseAddrFlag, *clickhouseDatabaseFlag, *clickhouseUsernameFlag, *clickhousePasswordFlag, *clickhouseSecureFlag, *dryRunFlag)
+	}
+
+	if *exportSnapshotFlag != "" || *importSnapshotFlag != "" {
+		if *clickhouseAddrFlag == "" {
+			return fmt.Errorf("--clickhouse-addr is required for --export-snapshot and --import-snapshot")
+		}
+		httpAddr := *clickhouseHTTPAddrFlag
+		if httpAddr == "" {
+			host, _, err := net.SplitHostPort(*clickhouseAddrFlag)
+			if err != nil {
+				return fmt.Errorf("invalid --clickhouse-addr: %w", err)
+			}
+			port := "8123"
+			if *clickhouseSecureFlag {
+				port = "8443"
+			}
+			httpAddr = net.JoinHostPort(host, port)
+		}
+
+		if *importSnapshotFlag != "" {
+			return admin.ImportSnapshot(
+				log,
+				*clickhouseAddrFlag, *clickhouseDatabaseFlag, *clickhouseUsernameFlag, *clickhousePasswordFlag,
+				*clickhouseSecureFlag,
+				admin.ImportSnapshotConfig{
+					Dir:           *importSnapshotFlag,
+					HTTPAddr:      httpAddr,
+					DryRun:        *dryRunFlag,
+					SkipConfirm:   *yesFlag,
+					Neo4jURI:      *neo4jURIFlag,
+					Neo4jDatabase: *neo4jDatabaseFlag,
+					Neo4jUsername: *neo4jUsernameFlag,
+					Neo4jPassword: *neo4jPasswordFlag,
+				},
+			)
+		}
+
+		var startTime, endTime time.Time
+		if *startTimeFlag != "" {
+			var err error
+			startTime, err = time.Parse(time.RFC3339, *startTimeFlag)
+			if err != nil {
+				return fmt.Errorf("invalid start-time format (use RFC3339, e.g. 2024-01-01T00:00:00Z): %w", err)
+			}
+		}
+		if *endTimeFlag != "" {
+			var err error
+			endTime, err = time.Parse(time.RFC3339, *endTimeFlag)
+			if err != nil {
+				return fmt.Errorf("invalid end-time format (use RFC3339, e.g. 2024-01-01T00:00:00Z): %w", err)
+			}
+		}
+
+		return admin.ExportSnapshot(
+			log,
+			*clickhouseAddrFlag, *clickhouseDatabaseFlag, *clickhouseUsernameFlag, *clickhousePasswordFlag,
+			*clickhouseSecureFlag,
+			admin.ExportSnapshotConfig{
+				Dir:          *exportSnapshotFlag,
+				HTTPAddr:     httpAddr,
+				Format:       *snapshotFormatFlag,
+				Tables:       *snapshotTablesFlag,
+				StartTime:    startTime,
+				EndTime:      endTime,
+				Pseudonymize: *snapshotPseudonymizeFlag,
+				DryRun:       *dryRunFlag,
+			},
+		)
+	}
+
 	if *backfillDeviceLinkLatencyFlag {
 		if *clickhouseAddrFlag == "" {
 			return fmt.Errorf("--clickhouse-addr is required for --backfill-device-link-latency")
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/indexer"
+)
+
+// MigrateDimSchemas rehashes dimension history after payload columns were added or removed.
+// In dry-run mode it only reports, per dataset, how many history rows the schema change would
+// create on the next snapshot.
+func MigrateDimSchemas(log *slog.Logger, addr, database, username, password string, secure, dryRun bool) error {
+	ctx := context.Background()
+
+	// Connect to ClickHouse
+	chDB, err := clickhouse.NewClient(ctx, log, addr, database, username, password, secure)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer chDB.Close()
+
+	conn, err := chDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	plans, err := indexer.MigrateDimensionSchemas(ctx, log, conn, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Println("[DRY RUN] Dimension schema changes (nothing modified):")
+	} else {
+		fmt.Println("Dimension schema migration:")
+	}
+	fmt.Println()
+
+	pending := 0
+	for _, p := range plans {
+		from := "none"
+		if p.From != nil {
+			from = fmt.Sprintf("v%d", p.From.Version)
+		}
+		status := "up to date"
+		if p.NeedsMigration() {
+			pending++
+			status = "migrated"
+			if dryRun {
+				status = "needs migration"
+			}
+		}
+		fmt.Printf("  %s (recorded: %s): %s\n", p.Dataset, from, status)
+		if len(p.AddedColumns) > 0 {
+			fmt.Printf("    added columns:     %s\n", strings.Join(p.AddedColumns, ", "))
+		}
+		if len(p.RemovedColumns) > 0 {
+			fmt.Printf("    removed columns:   %s\n", strings.Join(p.RemovedColumns, ", "))
+		}
+		if p.StaleRows > 0 {
+			fmt.Printf("    stale history rows: %d\n", p.StaleRows)
+			fmt.Printf("    spurious versions:  %d (history rows the next snapshot would write without migrating)\n", p.SpuriousVersions)
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\n%d of %d dataset(s) need migration\n", pending, len(plans))
+	} else {
+		fmt.Printf("\n%d of %d dataset(s) migrated\n", pending, len(plans))
+	}
+	return nil
+}
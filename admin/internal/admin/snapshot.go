@@ -0,0 +1,732 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	dzgraph "github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/indexer"
+	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
+)
+
+const (
+	SnapshotFormatNative  = "native"
+	SnapshotFormatParquet = "parquet"
+
+	snapshotManifestFile = "manifest.json"
+)
+
+// Pseudonyms are keyed with a random salt generated per export and never written out, so
+// they are stable within a snapshot (joins on the column still work) but can't be reversed
+// or correlated across snapshots. IPs map into 10.0.0.0/8 so they still parse as addresses.
+const (
+	pseudonymIPExpr     = "if(%[1]s = '', '', IPv4NumToString(toUInt32(167772160 + sipHash64({salt:String}, %[1]s) %% 16777216)))"
+	pseudonymPubkeyExpr = "if(%[1]s = '', '', lower(hex(sipHash128({salt:String}, %[1]s))))"
+)
+
+// pseudonymizedColumns are replaced in every exported table that has them. Validator gossip
+// IPs are included because users are matched to validators by dz_ip = gossip_ip.
+var pseudonymizedColumns = map[string]string{
+	"owner_pubkey": pseudonymPubkeyExpr,
+	"client_ip":    pseudonymIPExpr,
+	"dz_ip":        pseudonymIPExpr,
+	"gossip_ip":    pseudonymIPExpr,
+	"tpuquic_ip":   pseudonymIPExpr,
+}
+
+// surrogateKeyExpr computes dataset.NaturalKey.ToSurrogate for a single string key in SQL, so
+// entity_id can be rederived from a pseudonymized primary key.
+const surrogateKeyExpr = "lower(hex(SHA256(concat('string:', toString(length(%[1]s)), ':', %[1]s))))"
+
+// pseudonymizedGeoIPEntityIDExpr is the entity_id of a GeoIP record keyed by the pseudonym of
+// its IP, read from the column (or expression) it is formatted with.
+var pseudonymizedGeoIPEntityIDExpr = strings.ReplaceAll(surrogateKeyExpr, "%[1]s", "("+pseudonymIPExpr+")")
+
+// pseudonymizedTableColumns are replacements specific to one table, taking precedence over
+// pseudonymizedColumns. GeoIP records are keyed by IP, so their entity_id is rederived from the
+// pseudonymized IP (in the change log, from the IP in attrs) and still matches the ip column.
+// The change log carries the before/after attributes of every dataset as JSON, so it is
+// emptied for the affected ones.
+var pseudonymizedTableColumns = map[string]map[string]string{
+	"dim_geoip_records_history": {
+		"entity_id": strings.ReplaceAll(pseudonymizedGeoIPEntityIDExpr, "%[1]s", "dim_geoip_records_history.ip"),
+		"ip":        pseudonymIPExpr,
+	},
+	"dim_change_log": {
+		"entity_id": "if(dataset = 'geoip_records', " + strings.ReplaceAll(pseudonymizedGeoIPEntityIDExpr, "%[1]s", "JSONExtractString(dim_change_log.attrs, 'ip')") + ", %[1]s)",
+		"changes":   "if(dataset IN ('dz_users', 'dz_multicast_groups', 'geoip_records', 'solana_gossip_nodes', 'solana_validator_dz_matches'), '[]', %[1]s)",
+		"attrs":     "if(dataset IN ('dz_users', 'dz_multicast_groups', 'geoip_records', 'solana_gossip_nodes', 'solana_validator_dz_matches'), '{}', %[1]s)",
+	},
+}
+
+// snapshotTimeColumns are checked in order to find the column a table's time range filters on.
+// Tables with none of them (e.g. dim_schema_versions) are always exported in full.
+var snapshotTimeColumns = []string{"event_ts", "snapshot_ts"}
+
+// SnapshotManifest describes an exported snapshot directory.
+type SnapshotManifest struct {
+	CreatedAt        time.Time       `json:"created_at"`
+	Database         string          `json:"database"`
+	MigrationVersion int64           `json:"migration_version"`
+	Format           string          `json:"format"`
+	StartTime        *time.Time      `json:"start_time,omitempty"`
+	EndTime          *time.Time      `json:"end_time,omitempty"`
+	Pseudonymized    bool            `json:"pseudonymized"`
+	Tables           []SnapshotTable `json:"tables"`
+}
+
+// SnapshotTable describes one exported table.
+type SnapshotTable struct {
+	Name       string           `json:"name"`
+	File       string           `json:"file"`
+	TimeColumn string           `json:"time_column,omitempty"`
+	Rows       uint64           `json:"rows"`
+	Columns    []SnapshotColumn `json:"columns"`
+}
+
+// SnapshotColumn is a column name and ClickHouse type, used to check the import target schema.
+type SnapshotColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type ExportSnapshotConfig struct {
+	Dir          string
+	HTTPAddr     string
+	Format       string
+	Tables       []string // empty = all dim history, change log and fact tables
+	StartTime    time.Time
+	EndTime      time.Time
+	Pseudonymize bool
+	DryRun       bool
+}
+
+type ImportSnapshotConfig struct {
+	Dir         string
+	HTTPAddr    string
+	DryRun      bool
+	SkipConfirm bool
+
+	// Neo4j is optional; when URI is set the graph is rebuilt from the imported data.
+	Neo4jURI      string
+	Neo4jDatabase string
+	Neo4jUsername string
+	Neo4jPassword string
+}
+
+// ExportSnapshot dumps dimension history and fact tables to a directory of Native or Parquet
+// files plus a manifest recording the migration version they were written at.
+func ExportSnapshot(log *slog.Logger, addr, database, username, password string, secure bool, cfg ExportSnapshotConfig) error {
+	ctx := context.Background()
+
+	format, err := snapshotFormat(cfg.Format)
+	if err != nil {
+		return err
+	}
+	if !cfg.EndTime.IsZero() && !cfg.StartTime.IsZero() && !cfg.EndTime.After(cfg.StartTime) {
+		return fmt.Errorf("end time must be after start time")
+	}
+
+	chDB, err := clickhouse.NewClient(ctx, log, addr, database, username, password, secure)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer chDB.Close()
+
+	conn, err := chDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	version, err := clickhouse.DBVersion(ctx, clickhouse.MigrationConfig{
+		Addr:     addr,
+		Database: database,
+		Username: username,
+		Password: password,
+		Secure:   secure,
+	})
+	if err != nil {
+		return err
+	}
+
+	tables, err := snapshotTables(ctx, conn, database, cfg.Tables)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		fmt.Println("No tables found to export")
+		return nil
+	}
+
+	manifest := SnapshotManifest{
+		CreatedAt:        time.Now().UTC(),
+		Database:         database,
+		MigrationVersion: version,
+		Format:           format,
+		Pseudonymized:    cfg.Pseudonymize,
+	}
+	if !cfg.StartTime.IsZero() {
+		manifest.StartTime = &cfg.StartTime
+	}
+	if !cfg.EndTime.IsZero() {
+		manifest.EndTime = &cfg.EndTime
+	}
+
+	for _, name := range tables {
+		columns, err := tableColumns(ctx, conn, database, name)
+		if err != nil {
+			return err
+		}
+		table := SnapshotTable{
+			Name:    name,
+			File:    name + "." + format,
+			Columns: columns,
+		}
+		for _, c := range snapshotTimeColumns {
+			if slices.ContainsFunc(columns, func(col SnapshotColumn) bool { return col.Name == c }) {
+				table.TimeColumn = c
+				break
+			}
+		}
+
+		where, args := snapshotTimeFilter(table.TimeColumn, cfg.StartTime, cfg.EndTime)
+		rows, err := conn.Query(ctx, fmt.Sprintf("SELECT count() FROM %s%s", name, where), args...)
+		if err != nil {
+			return fmt.Errorf("failed to count rows in %s: %w", name, err)
+		}
+		if rows.Next() {
+			if err := rows.Scan(&table.Rows); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row count for %s: %w", name, err)
+			}
+		}
+		rows.Close()
+
+		manifest.Tables = append(manifest.Tables, table)
+	}
+
+	fmt.Printf("Exporting %d table(s) from '%s' at migration version %d to %s (%s)\n\n", len(manifest.Tables), database, version, cfg.Dir, format)
+	for _, t := range manifest.Tables {
+		filter := "all rows"
+		if t.TimeColumn != "" && (!cfg.StartTime.IsZero() || !cfg.EndTime.IsZero()) {
+			filter = "filtered on " + t.TimeColumn
+		}
+		fmt.Printf("  - %s: %d rows (%s)\n", t.Name, t.Rows, filter)
+	}
+	if cfg.Pseudonymize {
+		fmt.Println("\nUser IPs, owner pubkeys and validator gossip IPs will be pseudonymized")
+	}
+
+	if cfg.DryRun {
+		fmt.Println("\n[DRY RUN] Would export the above tables")
+		return nil
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	salt, err := pseudonymSalt()
+	if err != nil {
+		return err
+	}
+	payloadColumns, err := dimensionPayloadColumns(log)
+	if err != nil {
+		return err
+	}
+	httpClient := newClickHouseHTTP(cfg.HTTPAddr, database, username, password, secure)
+
+	fmt.Println()
+	for _, t := range manifest.Tables {
+		query := fmt.Sprintf("SELECT %s FROM %s%s FORMAT %s",
+			snapshotSelectList(t, cfg.Pseudonymize, payloadColumns[t.Name]),
+			t.Name,
+			snapshotTimeFilterParams(t.TimeColumn, cfg.StartTime, cfg.EndTime),
+			clickhouseFormatName(format),
+		)
+		params := map[string]string{"salt": salt}
+		if !cfg.StartTime.IsZero() {
+			params["start_ms"] = fmt.Sprint(cfg.StartTime.UnixMilli())
+		}
+		if !cfg.EndTime.IsZero() {
+			params["end_ms"] = fmt.Sprint(cfg.EndTime.UnixMilli())
+		}
+
+		start := time.Now()
+		n, err := httpClient.exportToFile(ctx, query, params, filepath.Join(cfg.Dir, t.File))
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", t.Name, err)
+		}
+		fmt.Printf("  ✓ %s: %d rows, %d bytes in %s\n", t.Name, t.Rows, n, time.Since(start).Round(time.Millisecond))
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.Dir, snapshotManifestFile), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	fmt.Printf("\nSnapshot written to %s\n", cfg.Dir)
+	return nil
+}
+
+// ImportSnapshot restores a snapshot written by ExportSnapshot, replacing the contents of its
+// tables. The database must be at the snapshot's migration version and each table's columns
+// must match. If Neo4j is configured, the graph is rebuilt from the imported data.
+func ImportSnapshot(log *slog.Logger, addr, database, username, password string, secure bool, cfg ImportSnapshotConfig) error {
+	ctx := context.Background()
+
+	manifest, err := ReadSnapshotManifest(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	format, err := snapshotFormat(manifest.Format)
+	if err != nil {
+		return err
+	}
+
+	chDB, err := clickhouse.NewClient(ctx, log, addr, database, username, password, secure)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer chDB.Close()
+
+	conn, err := chDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	version, err := clickhouse.DBVersion(ctx, clickhouse.MigrationConfig{
+		Addr:     addr,
+		Database: database,
+		Username: username,
+		Password: password,
+		Secure:   secure,
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range manifest.Tables {
+		if _, err := os.Stat(filepath.Join(cfg.Dir, t.File)); err != nil {
+			return fmt.Errorf("snapshot file for %s: %w", t.Name, err)
+		}
+	}
+	if err := checkSnapshotTarget(database, manifest, version, func(table string) ([]SnapshotColumn, error) {
+		return tableColumns(ctx, conn, database, table)
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Importing snapshot from %s (exported %s from '%s' at migration version %d)\n",
+		cfg.Dir, manifest.CreatedAt.Format(time.RFC3339), manifest.Database, manifest.MigrationVersion)
+	if manifest.Pseudonymized {
+		fmt.Println("Snapshot is pseudonymized")
+	}
+	fmt.Printf("\n⚠️  WARNING: This will REPLACE the contents of %d table(s) in database '%s':\n\n", len(manifest.Tables), database)
+	for _, t := range manifest.Tables {
+		fmt.Printf("  - %s: %d rows\n", t.Name, t.Rows)
+	}
+	if cfg.Neo4jURI != "" {
+		fmt.Println("\nThe Neo4j graph will be rebuilt from the imported data")
+	}
+
+	if cfg.DryRun {
+		fmt.Println("\n[DRY RUN] Would import the above tables")
+		return nil
+	}
+
+	// Prompt for confirmation unless --yes flag is set
+	if !cfg.SkipConfirm {
+		fmt.Printf("\nType 'yes' to confirm: ")
+
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" {
+			fmt.Printf("\nConfirmation failed. Operation cancelled.\n")
+			return nil
+		}
+	}
+
+	httpClient := newClickHouseHTTP(cfg.HTTPAddr, database, username, password, secure)
+
+	fmt.Println()
+	for _, t := range manifest.Tables {
+		start := time.Now()
+		if err := conn.Exec(ctx, fmt.Sprintf("TRUNCATE TABLE %s", t.Name)); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", t.Name, err)
+		}
+		query := fmt.Sprintf("INSERT INTO %s FORMAT %s", t.Name, clickhouseFormatName(format))
+		if err := httpClient.importFromFile(ctx, query, filepath.Join(cfg.Dir, t.File)); err != nil {
+			return fmt.Errorf("failed to import %s: %w", t.Name, err)
+		}
+		fmt.Printf("  ✓ %s: %d rows in %s\n", t.Name, t.Rows, time.Since(start).Round(time.Millisecond))
+	}
+
+	if cfg.Neo4jURI != "" {
+		fmt.Println("\nRebuilding Neo4j graph...")
+		neo4jClient, err := neo4j.NewClient(ctx, log, cfg.Neo4jURI, cfg.Neo4jDatabase, cfg.Neo4jUsername, cfg.Neo4jPassword)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Neo4j: %w", err)
+		}
+		defer neo4jClient.Close(ctx)
+
+		store, err := dzgraph.NewStore(dzgraph.StoreConfig{
+			Logger:     log,
+			Neo4j:      neo4jClient,
+			ClickHouse: chDB,
+			Database:   cfg.Neo4jDatabase,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create graph store: %w", err)
+		}
+		if err := store.Sync(ctx); err != nil {
+			return fmt.Errorf("failed to rebuild graph: %w", err)
+		}
+		fmt.Println("  ✓ Graph rebuilt")
+	}
+
+	fmt.Printf("\nSnapshot imported into '%s'\n", database)
+	return nil
+}
+
+// checkSnapshotTarget rejects importing the snapshot into a database at another migration
+// version than it was exported at, or whose tables are missing or have other columns.
+func checkSnapshotTarget(database string, manifest *SnapshotManifest, version int64, tableColumns func(table string) ([]SnapshotColumn, error)) error {
+	if version != manifest.MigrationVersion {
+		return fmt.Errorf("database '%s' is at migration version %d but the snapshot was exported at %d (use --clickhouse-migrate-up-to %d on a fresh database)",
+			database, version, manifest.MigrationVersion, manifest.MigrationVersion)
+	}
+	for _, t := range manifest.Tables {
+		columns, err := tableColumns(t.Name)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("table %s does not exist in database '%s'", t.Name, database)
+		}
+		if !slices.Equal(columns, t.Columns) {
+			return fmt.Errorf("columns of %s in database '%s' do not match the snapshot", t.Name, database)
+		}
+	}
+	return nil
+}
+
+// ReadSnapshotManifest reads the manifest of a snapshot directory.
+func ReadSnapshotManifest(dir string) (*SnapshotManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest SnapshotManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// snapshotTables returns the requested tables, or by default every dimension history, change
+// log, schema version and fact table.
+func snapshotTables(ctx context.Context, conn clickhouse.Connection, database string, requested []string) ([]string, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT name
+		FROM system.tables
+		WHERE database = ?
+		  AND engine NOT IN ('View', 'MaterializedView')
+		  AND ((startsWith(name, 'dim_') AND endsWith(name, '_history'))
+		    OR name IN ('dim_change_log', 'dim_schema_versions')
+		    OR startsWith(name, 'fact_'))
+		ORDER BY name
+	`, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	if len(requested) == 0 {
+		return tables, nil
+	}
+
+	for _, name := range requested {
+		if !slices.Contains(tables, name) {
+			return nil, fmt.Errorf("table %s is not a dimension history or fact table in database '%s'", name, database)
+		}
+	}
+	return requested, nil
+}
+
+func tableColumns(ctx context.Context, conn clickhouse.Connection, database, table string) ([]SnapshotColumn, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT name, type
+		FROM system.columns
+		WHERE database = ? AND table = ?
+		ORDER BY position
+	`, database, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []SnapshotColumn
+	for rows.Next() {
+		var c SnapshotColumn
+		if err := rows.Scan(&c.Name, &c.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// snapshotSelectList returns the column list to export, with pseudonymized columns replaced.
+// payloadColumns are the columns hashed into attrs_hash if t is a dimension history table; when
+// any of them is pseudonymized, attrs_hash is recomputed from the replaced values so SCD2 change
+// detection against the snapshot does not see every row as changed.
+func snapshotSelectList(t SnapshotTable, pseudonymize bool, payloadColumns []string) string {
+	exprs := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		exprs[i] = c.Name
+		if !pseudonymize {
+			continue
+		}
+		if expr, ok := pseudonymizedColumnExpr(t.Name, c.Name); ok {
+			exprs[i] = expr + " AS " + c.Name
+		}
+	}
+	if !pseudonymize || !slices.ContainsFunc(payloadColumns, func(col string) bool {
+		_, ok := pseudonymizedColumnExpr(t.Name, col)
+		return ok
+	}) {
+		return strings.Join(exprs, ", ")
+	}
+
+	// Same shape as DimensionType2Dataset.AttrsHashExpression
+	parts := make([]string, 0, len(payloadColumns)+1)
+	for _, col := range payloadColumns {
+		expr, ok := pseudonymizedColumnExpr(t.Name, col)
+		if !ok {
+			expr = t.Name + "." + col
+		}
+		parts = append(parts, fmt.Sprintf("toString(%s)", expr))
+	}
+	parts = append(parts, fmt.Sprintf("toString(%s.is_deleted)", t.Name))
+	for i, c := range t.Columns {
+		if c.Name == "attrs_hash" {
+			exprs[i] = fmt.Sprintf("cityHash64(tuple(%s)) AS attrs_hash", strings.Join(parts, ", "))
+		}
+	}
+	return strings.Join(exprs, ", ")
+}
+
+// pseudonymizedColumnExpr returns the expression that replaces a column of table on export, if
+// it is pseudonymized. Columns are qualified with the table name: ClickHouse resolves a bare name
+// to a select-list alias first, which would otherwise feed already-pseudonymized values into
+// expressions derived from them.
+func pseudonymizedColumnExpr(table, column string) (string, bool) {
+	expr, ok := pseudonymizedTableColumns[table][column]
+	if !ok {
+		expr, ok = pseudonymizedColumns[column]
+	}
+	if !ok {
+		return "", false
+	}
+	if !strings.Contains(expr, "%[1]s") {
+		// Derived from other columns (e.g. a GeoIP entity_id from ip); only unescape it.
+		return strings.ReplaceAll(expr, "%%", "%"), true
+	}
+	return fmt.Sprintf(expr, table+"."+column), true
+}
+
+// dimensionPayloadColumns returns the attrs_hash columns of each indexer dimension dataset,
+// keyed by history table name.
+func dimensionPayloadColumns(log *slog.Logger) (map[string][]string, error) {
+	datasets, err := indexer.DimensionDatasets(log)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string][]string, len(datasets))
+	for _, ds := range datasets {
+		columns[ds.HistoryTableName()] = ds.PayloadColumns()
+	}
+	return columns, nil
+}
+
+// snapshotTimeFilter returns a WHERE clause and args for a native protocol query.
+func snapshotTimeFilter(column string, start, end time.Time) (string, []any) {
+	if column == "" {
+		return "", nil
+	}
+	var conds []string
+	var args []any
+	if !start.IsZero() {
+		conds = append(conds, column+" >= ?")
+		args = append(args, start)
+	}
+	if !end.IsZero() {
+		conds = append(conds, column+" < ?")
+		args = append(args, end)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// snapshotTimeFilterParams returns the same WHERE clause as snapshotTimeFilter using HTTP
+// query parameters, which are passed as Unix milliseconds to avoid timezone ambiguity.
+func snapshotTimeFilterParams(column string, start, end time.Time) string {
+	if column == "" {
+		return ""
+	}
+	var conds []string
+	if !start.IsZero() {
+		conds = append(conds, column+" >= fromUnixTimestamp64Milli({start_ms:Int64})")
+	}
+	if !end.IsZero() {
+		conds = append(conds, column+" < fromUnixTimestamp64Milli({end_ms:Int64})")
+	}
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+func snapshotFormat(format string) (string, error) {
+	switch f := strings.ToLower(format); f {
+	case "", SnapshotFormatNative:
+		return SnapshotFormatNative, nil
+	case SnapshotFormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported snapshot format %q (use %s or %s)", format, SnapshotFormatNative, SnapshotFormatParquet)
+	}
+}
+
+func clickhouseFormatName(format string) string {
+	if format == SnapshotFormatParquet {
+		return "Parquet"
+	}
+	return "Native"
+}
+
+func pseudonymSalt() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate pseudonym salt: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// clickHouseHTTP streams query results and inserts through the ClickHouse HTTP interface,
+// which unlike the native protocol client passes Native and Parquet data through unparsed.
+type clickHouseHTTP struct {
+	baseURL  string
+	database string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newClickHouseHTTP(addr, database, username, password string, secure bool) *clickHouseHTTP {
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	return &clickHouseHTTP{
+		baseURL:  scheme + "://" + addr + "/",
+		database: database,
+		username: username,
+		password: password,
+		client:   &http.Client{},
+	}
+}
+
+func (c *clickHouseHTTP) do(ctx context.Context, query string, params map[string]string, body io.Reader) (io.ReadCloser, error) {
+	values := url.Values{}
+	values.Set("database", c.database)
+	values.Set("query", query)
+	for k, v := range params {
+		values.Set("param_"+k, v)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"?"+values.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-ClickHouse-User", c.username)
+	req.Header.Set("X-ClickHouse-Key", c.password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("clickhouse returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
+
+func (c *clickHouseHTTP) exportToFile(ctx context.Context, query string, params map[string]string, path string) (int64, error) {
+	body, err := c.do(ctx, query, params, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, body)
+	if err != nil {
+		f.Close()
+		return n, err
+	}
+	return n, f.Close()
+}
+
+func (c *clickHouseHTTP) importFromFile(ctx context.Context, query, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	body, err := c.do(ctx, query, nil, f)
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
@@ -0,0 +1,317 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotColumns(names ...string) []SnapshotColumn {
+	columns := make([]SnapshotColumn, len(names))
+	for i, name := range names {
+		columns[i] = SnapshotColumn{Name: name, Type: "String"}
+	}
+	return columns
+}
+
+func TestSnapshotSelectList(t *testing.T) {
+	t.Parallel()
+
+	usersIP := fmt.Sprintf(pseudonymIPExpr, "dim_dz_users_history.client_ip")
+	usersOwner := fmt.Sprintf(pseudonymPubkeyExpr, "dim_dz_users_history.owner_pubkey")
+	geoipIP := fmt.Sprintf(pseudonymIPExpr, "dim_geoip_records_history.ip")
+
+	tests := []struct {
+		name           string
+		table          SnapshotTable
+		pseudonymize   bool
+		payloadColumns []string
+		want           string
+	}{
+		{
+			name: "columns are selected as is without pseudonymization",
+			table: SnapshotTable{
+				Name:    "dim_dz_users_history",
+				Columns: snapshotColumns("entity_id", "attrs_hash", "owner_pubkey", "client_ip"),
+			},
+			payloadColumns: []string{"owner_pubkey", "client_ip"},
+			want:           "entity_id, attrs_hash, owner_pubkey, client_ip",
+		},
+		{
+			name: "attrs_hash is recomputed over the pseudonymized payload",
+			table: SnapshotTable{
+				Name:    "dim_dz_users_history",
+				Columns: snapshotColumns("entity_id", "attrs_hash", "is_deleted", "owner_pubkey", "status", "client_ip"),
+			},
+			pseudonymize:   true,
+			payloadColumns: []string{"owner_pubkey", "status", "client_ip"},
+			want: "entity_id, " +
+				"cityHash64(tuple(toString(" + usersOwner + "), toString(dim_dz_users_history.status), toString(" + usersIP + "), toString(dim_dz_users_history.is_deleted))) AS attrs_hash, " +
+				"is_deleted, " + usersOwner + " AS owner_pubkey, status, " + usersIP + " AS client_ip",
+		},
+		{
+			name: "attrs_hash is kept when no payload column is pseudonymized",
+			table: SnapshotTable{
+				Name:    "dim_dz_metros_history",
+				Columns: snapshotColumns("entity_id", "attrs_hash", "is_deleted", "code", "name"),
+			},
+			pseudonymize:   true,
+			payloadColumns: []string{"code", "name"},
+			want:           "entity_id, attrs_hash, is_deleted, code, name",
+		},
+		{
+			name: "fact table columns are pseudonymized without a hash",
+			table: SnapshotTable{
+				Name:    "fact_dz_users",
+				Columns: snapshotColumns("event_ts", "client_ip"),
+			},
+			pseudonymize: true,
+			want:         "event_ts, " + fmt.Sprintf(pseudonymIPExpr, "fact_dz_users.client_ip") + " AS client_ip",
+		},
+		{
+			name: "geoip entity_id is derived from the pseudonymized ip",
+			table: SnapshotTable{
+				Name:    "dim_geoip_records_history",
+				Columns: snapshotColumns("entity_id", "ip"),
+			},
+			pseudonymize: true,
+			want: strings.ReplaceAll(surrogateKeyExpr, "%[1]s", "("+geoipIP+")") + " AS entity_id, " +
+				geoipIP + " AS ip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, snapshotSelectList(tt.table, tt.pseudonymize, tt.payloadColumns))
+		})
+	}
+}
+
+func TestPseudonymizedColumnExpr(t *testing.T) {
+	t.Parallel()
+
+	blanked := "'dz_users', 'dz_multicast_groups', 'geoip_records', 'solana_gossip_nodes', 'solana_validator_dz_matches'"
+
+	tests := []struct {
+		name   string
+		table  string
+		column string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "shared ip column",
+			table:  "dim_solana_gossip_nodes_history",
+			column: "gossip_ip",
+			want:   "if(dim_solana_gossip_nodes_history.gossip_ip = '', '', IPv4NumToString(toUInt32(167772160 + sipHash64({salt:String}, dim_solana_gossip_nodes_history.gossip_ip) % 16777216)))",
+			wantOK: true,
+		},
+		{
+			name:   "shared pubkey column",
+			table:  "dim_dz_users_history",
+			column: "owner_pubkey",
+			want:   "if(dim_dz_users_history.owner_pubkey = '', '', lower(hex(sipHash128({salt:String}, dim_dz_users_history.owner_pubkey))))",
+			wantOK: true,
+		},
+		{
+			name:   "column that is not pseudonymized",
+			table:  "dim_dz_users_history",
+			column: "status",
+		},
+		{
+			name:   "geoip entity_id is rederived from the pseudonymized ip",
+			table:  "dim_geoip_records_history",
+			column: "entity_id",
+			want:   strings.ReplaceAll(surrogateKeyExpr, "%[1]s", "("+fmt.Sprintf(pseudonymIPExpr, "dim_geoip_records_history.ip")+")"),
+			wantOK: true,
+		},
+		{
+			name:   "other entity_ids are kept",
+			table:  "dim_dz_users_history",
+			column: "entity_id",
+		},
+		{
+			name:   "change log geoip entity_id is rederived from the ip in attrs",
+			table:  "dim_change_log",
+			column: "entity_id",
+			want: "if(dataset = 'geoip_records', " +
+				strings.ReplaceAll(surrogateKeyExpr, "%[1]s", "("+fmt.Sprintf(pseudonymIPExpr, "JSONExtractString(dim_change_log.attrs, 'ip')")+")") +
+				", dim_change_log.entity_id)",
+			wantOK: true,
+		},
+		{
+			name:   "change log changes are blanked for pseudonymized datasets",
+			table:  "dim_change_log",
+			column: "changes",
+			want:   "if(dataset IN (" + blanked + "), '[]', dim_change_log.changes)",
+			wantOK: true,
+		},
+		{
+			name:   "change log attrs are blanked for pseudonymized datasets",
+			table:  "dim_change_log",
+			column: "attrs",
+			want:   "if(dataset IN (" + blanked + "), '{}', dim_change_log.attrs)",
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := pseudonymizedColumnExpr(tt.table, tt.column)
+			require.Equal(t, tt.wantOK, ok)
+			require.Equal(t, tt.want, got)
+			require.NotContains(t, got, "%%")
+		})
+	}
+}
+
+func TestSnapshotTimeFilter(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		column     string
+		start, end time.Time
+		wantClause string
+		wantArgs   []any
+		wantParams string
+	}{
+		{
+			name:  "table without a time column is exported in full",
+			start: start,
+			end:   end,
+		},
+		{
+			name:   "no time range",
+			column: "event_ts",
+		},
+		{
+			name:       "start only",
+			column:     "event_ts",
+			start:      start,
+			wantClause: " WHERE event_ts >= ?",
+			wantArgs:   []any{start},
+			wantParams: " WHERE event_ts >= fromUnixTimestamp64Milli({start_ms:Int64})",
+		},
+		{
+			name:       "end only",
+			column:     "snapshot_ts",
+			end:        end,
+			wantClause: " WHERE snapshot_ts < ?",
+			wantArgs:   []any{end},
+			wantParams: " WHERE snapshot_ts < fromUnixTimestamp64Milli({end_ms:Int64})",
+		},
+		{
+			name:       "start and end",
+			column:     "event_ts",
+			start:      start,
+			end:        end,
+			wantClause: " WHERE event_ts >= ? AND event_ts < ?",
+			wantArgs:   []any{start, end},
+			wantParams: " WHERE event_ts >= fromUnixTimestamp64Milli({start_ms:Int64}) AND event_ts < fromUnixTimestamp64Milli({end_ms:Int64})",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clause, args := snapshotTimeFilter(tt.column, tt.start, tt.end)
+			require.Equal(t, tt.wantClause, clause)
+			require.Equal(t, tt.wantArgs, args)
+			require.Equal(t, tt.wantParams, snapshotTimeFilterParams(tt.column, tt.start, tt.end))
+		})
+	}
+}
+
+func TestCheckSnapshotTarget(t *testing.T) {
+	t.Parallel()
+
+	manifest := &SnapshotManifest{
+		MigrationVersion: 20250401000004,
+		Tables: []SnapshotTable{
+			{Name: "dim_dz_users_history", Columns: snapshotColumns("entity_id", "client_ip")},
+			{Name: "fact_dz_users", Columns: snapshotColumns("event_ts", "client_ip")},
+		},
+	}
+	database := map[string][]SnapshotColumn{
+		"dim_dz_users_history": snapshotColumns("entity_id", "client_ip"),
+		"fact_dz_users":        snapshotColumns("event_ts", "client_ip"),
+	}
+
+	tests := []struct {
+		name    string
+		version int64
+		tables  func(tables map[string][]SnapshotColumn)
+		readErr error
+		wantErr string
+	}{
+		{
+			name:    "matching database",
+			version: 20250401000004,
+		},
+		{
+			name:    "database at another migration version",
+			version: 20250401000003,
+			wantErr: "database 'lake' is at migration version 20250401000003 but the snapshot was exported at 20250401000004",
+		},
+		{
+			name:    "missing table",
+			version: 20250401000004,
+			tables: func(tables map[string][]SnapshotColumn) {
+				delete(tables, "fact_dz_users")
+			},
+			wantErr: "table fact_dz_users does not exist in database 'lake'",
+		},
+		{
+			name:    "extra column",
+			version: 20250401000004,
+			tables: func(tables map[string][]SnapshotColumn) {
+				tables["fact_dz_users"] = snapshotColumns("event_ts", "client_ip", "dz_ip")
+			},
+			wantErr: "columns of fact_dz_users in database 'lake' do not match the snapshot",
+		},
+		{
+			name:    "column of another type",
+			version: 20250401000004,
+			tables: func(tables map[string][]SnapshotColumn) {
+				tables["dim_dz_users_history"] = []SnapshotColumn{{Name: "entity_id", Type: "String"}, {Name: "client_ip", Type: "IPv4"}}
+			},
+			wantErr: "columns of dim_dz_users_history in database 'lake' do not match the snapshot",
+		},
+		{
+			name:    "columns can't be read",
+			version: 20250401000004,
+			readErr: errors.New("connection refused"),
+			wantErr: "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tables := maps.Clone(database)
+			if tt.tables != nil {
+				tt.tables(tables)
+			}
+
+			err := checkSnapshotTarget("lake", manifest, tt.version, func(table string) ([]SnapshotColumn, error) {
+				return tables[table], tt.readErr
+			})
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
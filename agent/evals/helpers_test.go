@@ -283,6 +283,7 @@ func setupWorkflowWithNeo4j(t *testing.T, ctx context.Context, clientInfo *laket
 		SchemaFetcher: schemaFetcher,
 		MaxTokens:     4096,
 		MaxRetries:    4,
+		SQLValidator:  workflow.NewSchemaSQLValidator(schemaFetcher, baseQuerier),
 	}
 
 	// Add Neo4j support if available
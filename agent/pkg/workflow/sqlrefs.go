@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TableRef is a table referenced in a FROM or JOIN clause of a SQL query.
+type TableRef struct {
+	Database string // Empty when the table name is unqualified
+	Table    string
+}
+
+// String returns the reference as written, e.g. "lake_devnet.dim_devices_current".
+func (r TableRef) String() string {
+	if r.Database == "" {
+		return r.Table
+	}
+	return r.Database + "." + r.Table
+}
+
+// sqlToken is a lexical token of a SQL query. Identifiers are unquoted; string
+// literals, numbers and comments are dropped since they never name tables.
+type sqlToken struct {
+	text  string
+	ident bool
+}
+
+// sqlKeywords are keywords that can precede an opening parenthesis without it
+// being a function call (e.g. "IN (SELECT ...)", "FROM (SELECT ...)").
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "JOIN": true, "ON": true,
+	"IN": true, "AS": true, "AND": true, "OR": true, "NOT": true,
+	"EXISTS": true, "WITH": true, "UNION": true, "ALL": true, "ANY": true,
+	"USING": true, "HAVING": true, "BY": true, "LIMIT": true, "ARRAY": true,
+	"INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true,
+	"CROSS": true, "GLOBAL": true, "SEMI": true, "ANTI": true, "ASOF": true,
+	"DISTINCT": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true,
+	"INTERSECT": true, "EXCEPT": true, "FINAL": true, "SAMPLE": true,
+	"PREWHERE": true, "GROUP": true, "ORDER": true, "WINDOW": true,
+	"QUALIFY": true, "SETTINGS": true,
+}
+
+// ExtractTableRefs returns the distinct tables referenced in FROM and JOIN
+// clauses of a SQL query, in order of first appearance. Names defined by WITH
+// clauses (CTEs), subqueries and table functions (e.g. numbers(10)) are excluded.
+// This is a lightweight scanner rather than a full parser: it is intended for
+// validating agent-generated queries, not for rejecting malicious input.
+func ExtractTableRefs(sql string) []TableRef {
+	refs, _ := scanTableRefs(tokenizeSQL(sql))
+	return refs
+}
+
+// scanTableRefs returns the distinct tables referenced in FROM and JOIN clauses
+// and the aliases given to them. An alias that is bound to different tables in
+// different scopes maps to an empty TableRef, since it cannot be resolved
+// without a full parser.
+func scanTableRefs(tokens []sqlToken) ([]TableRef, map[string]TableRef) {
+	// Collect CTE names: "WITH name AS (" and ", name AS ("
+	ctes := make(map[string]bool)
+	for i := 0; i+3 < len(tokens); i++ {
+		prev := strings.ToUpper(tokens[i].text)
+		if (prev == "WITH" || prev == ",") && tokens[i+1].ident &&
+			strings.EqualFold(tokens[i+2].text, "AS") && tokens[i+3].text == "(" {
+			ctes[strings.ToLower(tokens[i+1].text)] = true
+		}
+	}
+
+	var refs []TableRef
+	seen := make(map[TableRef]bool)
+	aliases := make(map[string]TableRef)
+	add := func(ref TableRef) bool {
+		if ref.Database == "" && ctes[strings.ToLower(ref.Table)] {
+			return false
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+		return true
+	}
+	addAlias := func(alias string, ref TableRef) {
+		if existing, ok := aliases[alias]; ok && existing != ref {
+			aliases[alias] = TableRef{}
+			return
+		}
+		aliases[alias] = ref
+	}
+
+	// Track whether each open parenthesis belongs to a function call, so that
+	// "extract(day FROM ts)" is not mistaken for a table reference.
+	var funcParens []bool
+	inFunc := func() bool { return len(funcParens) > 0 && funcParens[len(funcParens)-1] }
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.text {
+		case "(":
+			isFunc := i > 0 && tokens[i-1].ident && !sqlKeywords[strings.ToUpper(tokens[i-1].text)]
+			funcParens = append(funcParens, isFunc)
+			continue
+		case ")":
+			if len(funcParens) > 0 {
+				funcParens = funcParens[:len(funcParens)-1]
+			}
+			continue
+		}
+		if !tok.ident || inFunc() {
+			continue
+		}
+
+		kw := strings.ToUpper(tok.text)
+		if kw != "FROM" && kw != "JOIN" {
+			continue
+		}
+		if kw == "JOIN" && i > 0 && strings.EqualFold(tokens[i-1].text, "ARRAY") {
+			// ARRAY JOIN unnests an array expression, not a table
+			continue
+		}
+
+		// FROM a [AS x], b [y], ... ; JOIN takes a single table
+		j := i + 1
+		for {
+			ref, next, ok := parseTableName(tokens, j)
+			if !ok {
+				break
+			}
+			isTable := add(ref)
+			j = next
+			// Optional alias
+			if j < len(tokens) && strings.EqualFold(tokens[j].text, "AS") {
+				j++
+			}
+			if j < len(tokens) && tokens[j].ident && !sqlKeywords[strings.ToUpper(tokens[j].text)] {
+				if isTable {
+					addAlias(tokens[j].text, ref)
+				}
+				j++
+			}
+			if kw != "FROM" || j >= len(tokens) || tokens[j].text != "," {
+				break
+			}
+			j++
+		}
+	}
+
+	return refs, aliases
+}
+
+// parseTableName parses "table" or "database.table" starting at tokens[i].
+// Returns ok=false for subqueries and table functions.
+func parseTableName(tokens []sqlToken, i int) (TableRef, int, bool) {
+	if i >= len(tokens) || !tokens[i].ident || sqlKeywords[strings.ToUpper(tokens[i].text)] {
+		return TableRef{}, i, false
+	}
+	ref := TableRef{Table: tokens[i].text}
+	i++
+	if i+1 < len(tokens) && tokens[i].text == "." && tokens[i+1].ident {
+		ref = TableRef{Database: ref.Table, Table: tokens[i+1].text}
+		i += 2
+	}
+	if i < len(tokens) && tokens[i].text == "(" {
+		// Table function, e.g. numbers(10) or remote(...)
+		return TableRef{}, i, false
+	}
+	return ref, i, true
+}
+
+// tokenizeSQL splits a SQL query into identifiers and punctuation, skipping
+// string literals, numbers and comments.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	runes := []rune(sql)
+	n := len(runes)
+
+	for i := 0; i < n; {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '-' && i+1 < n && runes[i+1] == '-', c == '#':
+			for i < n && runes[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < n && runes[i+1] == '*':
+			i += 2
+			for i+1 < n && (runes[i] != '*' || runes[i+1] != '/') {
+				i++
+			}
+			i += 2
+
+		case c == '\'':
+			i = skipQuoted(runes, i, '\'')
+
+		case c == '`' || c == '"':
+			end := skipQuoted(runes, i, c)
+			text := string(runes[i+1 : max(i+1, end-1)])
+			tokens = append(tokens, sqlToken{text: text, ident: true})
+			i = end
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < n && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{text: string(runes[start:i]), ident: true})
+
+		case unicode.IsDigit(c):
+			for i < n && (unicode.IsDigit(runes[i]) || unicode.IsLetter(runes[i]) || runes[i] == '.' || runes[i] == '_') {
+				i++
+			}
+
+		default:
+			tokens = append(tokens, sqlToken{text: string(c)})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// skipQuoted returns the index just past the closing quote of a quoted section
+// starting at runes[start], honoring backslash escapes and doubled quotes.
+func skipQuoted(runes []rune, start int, quote rune) int {
+	i := start + 1
+	for i < len(runes) {
+		switch runes[i] {
+		case '\\':
+			i += 2
+			continue
+		case quote:
+			if i+1 < len(runes) && runes[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return len(runes)
+}
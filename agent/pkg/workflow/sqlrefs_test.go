@@ -0,0 +1,93 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractTableRefs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []TableRef
+	}{
+		{
+			name:     "simple select",
+			sql:      "SELECT * FROM dim_devices_current",
+			expected: []TableRef{{Table: "dim_devices_current"}},
+		},
+		{
+			name:     "qualified table",
+			sql:      "SELECT count() FROM lake_devnet.dim_links_current",
+			expected: []TableRef{{Database: "lake_devnet", Table: "dim_links_current"}},
+		},
+		{
+			name: "joins with aliases",
+			sql: `SELECT d.code, m.code
+				FROM dim_devices_current d
+				LEFT JOIN dim_metros_current AS m ON d.metro_pk = m.pk
+				JOIN dim_devices_current d2 ON d2.pk = d.pk`,
+			expected: []TableRef{{Table: "dim_devices_current"}, {Table: "dim_metros_current"}},
+		},
+		{
+			name:     "comma join",
+			sql:      "SELECT * FROM dim_devices_current d, dim_metros_current AS m WHERE d.metro_pk = m.pk",
+			expected: []TableRef{{Table: "dim_devices_current"}, {Table: "dim_metros_current"}},
+		},
+		{
+			name: "cte names are excluded",
+			sql: `WITH recent AS (SELECT * FROM fact_dz_device_link_latency WHERE event_ts > now() - INTERVAL 1 HOUR),
+				links AS (SELECT pk FROM dim_links_current)
+				SELECT * FROM recent JOIN links ON recent.link_pk = links.pk`,
+			expected: []TableRef{{Table: "fact_dz_device_link_latency"}, {Table: "dim_links_current"}},
+		},
+		{
+			name:     "subquery",
+			sql:      "SELECT * FROM (SELECT pk FROM dim_users_current) WHERE pk IN (SELECT user_pk FROM dim_multicast_groups_current)",
+			expected: []TableRef{{Table: "dim_users_current"}, {Table: "dim_multicast_groups_current"}},
+		},
+		{
+			name:     "function FROM is not a table",
+			sql:      "SELECT extract(day FROM event_ts), trim(BOTH ' ' FROM code) FROM fact_dz_device_link_latency",
+			expected: []TableRef{{Table: "fact_dz_device_link_latency"}},
+		},
+		{
+			name:     "table function",
+			sql:      "SELECT number FROM numbers(10)",
+			expected: nil,
+		},
+		{
+			name:     "array join",
+			sql:      "SELECT * FROM dim_devices_current ARRAY JOIN interfaces AS iface",
+			expected: []TableRef{{Table: "dim_devices_current"}},
+		},
+		{
+			name:     "quoted identifiers",
+			sql:      "SELECT * FROM `lake_devnet`.`dim_devices_current`",
+			expected: []TableRef{{Database: "lake_devnet", Table: "dim_devices_current"}},
+		},
+		{
+			name: "strings and comments are ignored",
+			sql: `-- FROM fake_table
+				SELECT 'FROM other_table' AS s /* JOIN hidden */ FROM dim_metros_current`,
+			expected: []TableRef{{Table: "dim_metros_current"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, ExtractTableRefs(tt.sql))
+		})
+	}
+}
+
+func TestTableRef_String(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "dim_devices_current", TableRef{Table: "dim_devices_current"}.String())
+	require.Equal(t, "lake_devnet.dim_devices_current", TableRef{Database: "lake_devnet", Table: "dim_devices_current"}.String())
+}
@@ -0,0 +1,372 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SQLValidator checks generated SQL before it is executed, so that mistakes
+// such as unknown columns are reported to the model with suggestions instead
+// of costing a failed execution round-trip.
+type SQLValidator interface {
+	// ValidateSQL returns diagnostics for problems found in the query. An
+	// empty result means the query can be executed. An error means validation
+	// itself failed, in which case the query should be executed unvalidated.
+	ValidateSQL(ctx context.Context, sql string) ([]SQLDiagnostic, error)
+}
+
+// SQLDiagnosticKind identifies the kind of problem found by validation.
+type SQLDiagnosticKind string
+
+const (
+	SQLDiagnosticUnknownTable  SQLDiagnosticKind = "unknown_table"
+	SQLDiagnosticUnknownColumn SQLDiagnosticKind = "unknown_column"
+	SQLDiagnosticExplain       SQLDiagnosticKind = "explain"
+)
+
+// SQLDiagnostic is a problem found in a query before execution.
+type SQLDiagnostic struct {
+	Kind        SQLDiagnosticKind
+	Message     string
+	Suggestions []string // Replacements as they could be written in the query, e.g. "l.side_a_pk"
+}
+
+// String formats the diagnostic for the model, e.g.
+// "column `l.side_a` does not exist in dz_links_current (did you mean `l.side_a_pk`?)".
+func (d SQLDiagnostic) String() string {
+	if len(d.Suggestions) == 0 {
+		return d.Message
+	}
+	quoted := make([]string, len(d.Suggestions))
+	for i, s := range d.Suggestions {
+		quoted[i] = "`" + s + "`"
+	}
+	return fmt.Sprintf("%s (did you mean %s?)", d.Message, strings.Join(quoted, " or "))
+}
+
+// FormatSQLDiagnostics formats diagnostics as a query error for the model.
+func FormatSQLDiagnostics(diags []SQLDiagnostic) string {
+	var sb strings.Builder
+	sb.WriteString("Query failed validation and was not executed:")
+	for _, d := range diags {
+		sb.WriteString("\n- " + d.String())
+	}
+	return sb.String()
+}
+
+// SchemaColumns maps table names to their column names, in schema order.
+type SchemaColumns map[string][]string
+
+// HasColumn returns true if the table has the named column.
+func (s SchemaColumns) HasColumn(table, column string) bool {
+	return slices.Contains(s[table], column)
+}
+
+// schemaTableLine matches a table header in the TABLE DETAILS section of a
+// formatted schema, e.g. "dz_links_current (VIEW):".
+var schemaTableLine = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?: \(VIEW\))?:$`)
+
+// schemaColumnLine matches a column line under a table header, e.g.
+// "  - side_a_pk (String) [FK]".
+var schemaColumnLine = regexp.MustCompile(`^  - (\S+) \(`)
+
+// ParseSchemaColumns extracts table columns from a schema formatted by a
+// SchemaFetcher (one "table:" header per table followed by "  - column (Type)"
+// lines). Returns an empty map if the schema is in another format.
+func ParseSchemaColumns(schema string) SchemaColumns {
+	columns := make(SchemaColumns)
+	table := ""
+	for _, line := range strings.Split(schema, "\n") {
+		if m := schemaTableLine.FindStringSubmatch(line); m != nil {
+			table = m[1]
+			continue
+		}
+		if table == "" {
+			continue
+		}
+		if m := schemaColumnLine.FindStringSubmatch(line); m != nil {
+			columns[table] = append(columns[table], m[1])
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			table = ""
+		}
+	}
+	return columns
+}
+
+// CheckSQLReferences checks the tables in FROM and JOIN clauses and the
+// qualified column references (e.g. "l.side_a_pk") of a query against the
+// schema. Unqualified columns are not checked, since telling them apart from
+// select aliases and lambda parameters needs a full parser; EXPLAIN catches
+// those. Database-qualified tables are not checked either, as the schema only
+// covers the default database.
+func CheckSQLReferences(sql string, schema SchemaColumns) []SQLDiagnostic {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	tokens := tokenizeSQL(sql)
+	refs, aliases := scanTableRefs(tokens)
+
+	var diags []SQLDiagnostic
+	for _, ref := range refs {
+		if ref.Database != "" {
+			continue
+		}
+		if _, ok := schema[ref.Table]; !ok {
+			diags = append(diags, SQLDiagnostic{
+				Kind:        SQLDiagnosticUnknownTable,
+				Message:     fmt.Sprintf("table `%s` does not exist", ref.Table),
+				Suggestions: similarNames(ref.Table, schemaTables(schema), 3),
+			})
+		}
+	}
+
+	// Qualifiers that can be resolved to a known table: aliases and the table
+	// names themselves.
+	qualifiers := make(map[string]string)
+	for _, ref := range refs {
+		if ref.Database == "" {
+			qualifiers[ref.Table] = ref.Table
+		}
+	}
+	aliased := make(map[string]bool)
+	for alias, ref := range aliases {
+		if ref.Database == "" && ref.Table != "" {
+			qualifiers[alias] = ref.Table
+			aliased[ref.Table] = true
+		} else {
+			delete(qualifiers, alias)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i+2 < len(tokens); i++ {
+		if !tokens[i].ident || tokens[i+1].text != "." || !tokens[i+2].ident {
+			continue
+		}
+		// Skip database.table.column and database.table references
+		if i > 0 && tokens[i-1].text == "." {
+			continue
+		}
+		if i+3 < len(tokens) && tokens[i+3].text == "." {
+			continue
+		}
+
+		qualifier, column := tokens[i].text, tokens[i+2].text
+		table, ok := qualifiers[qualifier]
+		if !ok {
+			continue
+		}
+		if _, known := schema[table]; !known || schema.HasColumn(table, column) {
+			continue
+		}
+		ref := qualifier + "." + column
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+
+		var suggestions []string
+		for _, c := range similarNames(column, schema[table], 3) {
+			suggestions = append(suggestions, qualifier+"."+c)
+		}
+		if len(suggestions) == 0 {
+			// The column may belong to another table in the query
+			for q, t := range qualifiers {
+				// Suggest the alias rather than the table name when there is one
+				if q == qualifier || (q == t && aliased[t]) {
+					continue
+				}
+				if schema.HasColumn(t, column) {
+					suggestions = append(suggestions, q+"."+column)
+				}
+			}
+			sort.Strings(suggestions)
+		}
+
+		diags = append(diags, SQLDiagnostic{
+			Kind:        SQLDiagnosticUnknownColumn,
+			Message:     fmt.Sprintf("column `%s` does not exist in %s", ref, table),
+			Suggestions: suggestions,
+		})
+	}
+
+	return diags
+}
+
+// unknownIdentifierPatterns extract the offending identifier from ClickHouse
+// errors for unknown columns (old and new analyzer wording).
+var unknownIdentifierPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`Missing columns: '([^']+)'`),
+	regexp.MustCompile("identifier [`']([^`']+)[`']"),
+	regexp.MustCompile(`no column '([^']+)'`),
+}
+
+// maxExplainErrorLen caps the ClickHouse error included in an EXPLAIN diagnostic.
+const maxExplainErrorLen = 500
+
+// ExplainDiagnostic converts an EXPLAIN error into a diagnostic. If the error
+// names an unknown identifier, similar columns from the query's tables are
+// suggested.
+func ExplainDiagnostic(sql, explainErr string, schema SchemaColumns) SQLDiagnostic {
+	msg := strings.TrimSpace(explainErr)
+	if len(msg) > maxExplainErrorLen {
+		msg = msg[:maxExplainErrorLen] + "..."
+	}
+	diag := SQLDiagnostic{
+		Kind:    SQLDiagnosticExplain,
+		Message: "EXPLAIN failed: " + msg,
+	}
+
+	var ident string
+	for _, re := range unknownIdentifierPatterns {
+		if m := re.FindStringSubmatch(explainErr); m != nil {
+			ident = m[1]
+			break
+		}
+	}
+	if ident == "" {
+		return diag
+	}
+	if idx := strings.LastIndex(ident, "."); idx >= 0 {
+		ident = ident[idx+1:]
+	}
+
+	for _, ref := range ExtractTableRefs(sql) {
+		if ref.Database != "" {
+			continue
+		}
+		for _, c := range similarNames(ident, schema[ref.Table], 3) {
+			diag.Suggestions = append(diag.Suggestions, ref.Table+"."+c)
+		}
+	}
+	if len(diag.Suggestions) > 3 {
+		diag.Suggestions = diag.Suggestions[:3]
+	}
+	return diag
+}
+
+// SchemaSQLValidator validates queries against the schema from a SchemaFetcher
+// and, if a querier is set, with EXPLAIN to catch type errors and unknown
+// unqualified columns.
+type SchemaSQLValidator struct {
+	schema  SchemaFetcher
+	querier Querier
+}
+
+// NewSchemaSQLValidator creates a new SchemaSQLValidator. The querier is
+// optional; when nil, queries are only checked against the schema.
+func NewSchemaSQLValidator(schema SchemaFetcher, querier Querier) *SchemaSQLValidator {
+	return &SchemaSQLValidator{schema: schema, querier: querier}
+}
+
+// ValidateSQL checks table and column references against the schema, then
+// runs EXPLAIN if the references are valid.
+func (v *SchemaSQLValidator) ValidateSQL(ctx context.Context, sql string) ([]SQLDiagnostic, error) {
+	schemaText, err := v.schema.FetchSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	schema := ParseSchemaColumns(schemaText)
+
+	diags := CheckSQLReferences(sql, schema)
+	if len(diags) > 0 || v.querier == nil || !isSelectQuery(sql) {
+		return diags, nil
+	}
+
+	result, err := v.querier.Query(ctx, "EXPLAIN "+sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	if result.Error != "" {
+		diags = append(diags, ExplainDiagnostic(sql, result.Error, schema))
+	}
+	return diags, nil
+}
+
+// isSelectQuery returns true if the query is a SELECT (optionally with CTEs),
+// the only statements EXPLAIN accepts.
+func isSelectQuery(sql string) bool {
+	tokens := tokenizeSQL(sql)
+	for _, tok := range tokens {
+		if tok.text == "(" {
+			continue
+		}
+		kw := strings.ToUpper(tok.text)
+		return kw == "SELECT" || kw == "WITH"
+	}
+	return false
+}
+
+// schemaTables returns the table names in the schema, sorted.
+func schemaTables(schema SchemaColumns) []string {
+	tables := make([]string, 0, len(schema))
+	for table := range schema {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// similarNames returns up to limit candidates similar to name, closest first.
+func similarNames(name string, candidates []string, limit int) []string {
+	type match struct {
+		name string
+		dist int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if isSimilarName(name, c) {
+			matches = append(matches, match{c, editDistance(strings.ToLower(name), strings.ToLower(c))})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].dist < matches[j].dist })
+
+	var out []string
+	for i := 0; i < len(matches) && i < limit; i++ {
+		out = append(out, matches[i].name)
+	}
+	return out
+}
+
+// isSimilarName returns true if candidate is a plausible correction of name:
+// a small edit distance, or one containing the other (e.g. "side_a" for
+// "side_a_pk", or "links_current" for "dz_links_current").
+func isSimilarName(name, candidate string) bool {
+	a, b := strings.ToLower(name), strings.ToLower(candidate)
+	if a == b {
+		return true
+	}
+	if len(a) >= 4 && len(b) >= 4 && (strings.Contains(a, b) || strings.Contains(b, a)) {
+		return true
+	}
+	return editDistance(a, b) <= min(3, max(1, len(a)/3))
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
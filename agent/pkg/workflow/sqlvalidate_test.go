@@ -0,0 +1,203 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `## AVAILABLE TABLES (use ONLY these exact names)
+
+Current state views (for current/live data):
+  - dz_devices_current
+  - dz_links_current
+
+---
+
+## TABLE DETAILS
+
+dz_devices_current (VIEW):
+  - pk (String)
+  - code (String) values: chi-dzd1, nyc-dzd1
+  - metro_pk (String) [FK]
+  Definition: SELECT * FROM dim_dz_devices_history
+
+dz_links_current (VIEW):
+  - pk (String)
+  - code (String)
+  - side_a_pk (String) [FK]
+  - side_z_pk (String) [FK]
+  - committed_rtt_ns (Int64)
+`
+
+func TestParseSchemaColumns(t *testing.T) {
+	t.Parallel()
+
+	columns := ParseSchemaColumns(testSchema)
+	require.Equal(t, SchemaColumns{
+		"dz_devices_current": {"pk", "code", "metro_pk"},
+		"dz_links_current":   {"pk", "code", "side_a_pk", "side_z_pk", "committed_rtt_ns"},
+	}, columns)
+
+	require.Empty(t, ParseSchemaColumns("Tables: foo, bar"))
+}
+
+func TestCheckSQLReferences(t *testing.T) {
+	t.Parallel()
+
+	schema := ParseSchemaColumns(testSchema)
+
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name: "valid query",
+			sql: `SELECT l.code, d.code FROM dz_links_current l
+				JOIN dz_devices_current AS d ON l.side_a_pk = d.pk`,
+		},
+		{
+			name:     "unknown table",
+			sql:      "SELECT * FROM links_current",
+			expected: []string{"table `links_current` does not exist (did you mean `dz_links_current`?)"},
+		},
+		{
+			name:     "unknown column via alias",
+			sql:      "SELECT l.side_a FROM dz_links_current l",
+			expected: []string{"column `l.side_a` does not exist in dz_links_current (did you mean `l.side_a_pk`?)"},
+		},
+		{
+			name:     "unknown column via table name",
+			sql:      "SELECT dz_links_current.side_a FROM dz_links_current",
+			expected: []string{"column `dz_links_current.side_a` does not exist in dz_links_current (did you mean `dz_links_current.side_a_pk`?)"},
+		},
+		{
+			name: "column on the wrong table",
+			sql: `SELECT d.side_a_pk FROM dz_links_current l
+				JOIN dz_devices_current d ON l.side_a_pk = d.pk`,
+			expected: []string{"column `d.side_a_pk` does not exist in dz_devices_current (did you mean `l.side_a_pk`?)"},
+		},
+		{
+			name:     "reported once",
+			sql:      "SELECT l.rtt FROM dz_links_current l WHERE l.rtt > 0",
+			expected: []string{"column `l.rtt` does not exist in dz_links_current"},
+		},
+		{
+			name: "cte and subquery qualifiers are not checked",
+			sql: `WITH x AS (SELECT pk AS link FROM dz_links_current)
+				SELECT x.link, s.n FROM x, (SELECT count() AS n FROM dz_devices_current) s`,
+		},
+		{
+			name: "ambiguous alias is not checked",
+			sql: `SELECT t.code FROM dz_links_current t
+				WHERE t.pk IN (SELECT t.pk FROM dz_devices_current t WHERE t.metro_pk != '')`,
+		},
+		{
+			name: "database-qualified tables are not checked",
+			sql:  "SELECT d.anything FROM lake_devnet.dz_devices_current d JOIN lake_devnet.other o ON o.pk = d.pk",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got []string
+			for _, d := range CheckSQLReferences(tt.sql, schema) {
+				got = append(got, d.String())
+			}
+			require.Equal(t, tt.expected, got)
+		})
+	}
+
+	t.Run("no schema", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, CheckSQLReferences("SELECT * FROM anything", SchemaColumns{}))
+	})
+}
+
+func TestExplainDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	schema := ParseSchemaColumns(testSchema)
+	sql := "SELECT side_a FROM dz_links_current"
+
+	d := ExplainDiagnostic(sql, "code: 47, message: Missing columns: 'side_a' while processing query", schema)
+	require.Equal(t, SQLDiagnosticExplain, d.Kind)
+	require.Equal(t, []string{"dz_links_current.side_a_pk"}, d.Suggestions)
+
+	d = ExplainDiagnostic(sql, "code: 47, message: Unknown expression identifier `side_a` in scope SELECT side_a FROM dz_links_current", schema)
+	require.Equal(t, []string{"dz_links_current.side_a_pk"}, d.Suggestions)
+
+	d = ExplainDiagnostic(sql, "code: 43, message: Illegal type String of argument of function plus", schema)
+	require.Empty(t, d.Suggestions)
+	require.Contains(t, d.Message, "Illegal type String")
+}
+
+type stubSchemaFetcher string
+
+func (s stubSchemaFetcher) FetchSchema(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+type stubQuerier struct {
+	queries []string
+	err     string
+}
+
+func (q *stubQuerier) Query(ctx context.Context, sql string) (QueryResult, error) {
+	q.queries = append(q.queries, sql)
+	return QueryResult{SQL: sql, Error: q.err}, nil
+}
+
+func TestSchemaSQLValidator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("valid query is explained", func(t *testing.T) {
+		t.Parallel()
+		querier := &stubQuerier{}
+		v := NewSchemaSQLValidator(stubSchemaFetcher(testSchema), querier)
+
+		diags, err := v.ValidateSQL(ctx, "SELECT code FROM dz_links_current")
+		require.NoError(t, err)
+		require.Empty(t, diags)
+		require.Equal(t, []string{"EXPLAIN SELECT code FROM dz_links_current"}, querier.queries)
+	})
+
+	t.Run("reference errors skip explain", func(t *testing.T) {
+		t.Parallel()
+		querier := &stubQuerier{}
+		v := NewSchemaSQLValidator(stubSchemaFetcher(testSchema), querier)
+
+		diags, err := v.ValidateSQL(ctx, "SELECT l.side_a FROM dz_links_current l")
+		require.NoError(t, err)
+		require.Len(t, diags, 1)
+		require.Equal(t, SQLDiagnosticUnknownColumn, diags[0].Kind)
+		require.Empty(t, querier.queries)
+	})
+
+	t.Run("explain errors are reported", func(t *testing.T) {
+		t.Parallel()
+		querier := &stubQuerier{err: "Missing columns: 'side_a'"}
+		v := NewSchemaSQLValidator(stubSchemaFetcher(testSchema), querier)
+
+		diags, err := v.ValidateSQL(ctx, "SELECT side_a FROM dz_links_current")
+		require.NoError(t, err)
+		require.Len(t, diags, 1)
+		require.Contains(t, FormatSQLDiagnostics(diags), "did you mean `dz_links_current.side_a_pk`?")
+	})
+
+	t.Run("non-select statements are not explained", func(t *testing.T) {
+		t.Parallel()
+		querier := &stubQuerier{}
+		v := NewSchemaSQLValidator(stubSchemaFetcher(testSchema), querier)
+
+		diags, err := v.ValidateSQL(ctx, "SHOW TABLES")
+		require.NoError(t, err)
+		require.Empty(t, diags)
+		require.Empty(t, querier.queries)
+	})
+}
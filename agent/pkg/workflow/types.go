@@ -44,6 +44,15 @@ type Config struct {
 	// Graph database support (optional)
 	GraphQuerier       Querier       // Optional Neo4j querier for execute_cypher tool
 	GraphSchemaFetcher SchemaFetcher // Optional Neo4j schema fetcher
+
+	// Cross-environment support (optional)
+	EnvQuerier EnvQuerier // Optional env-targeted querier for execute_sql_env tool
+
+	// Pre-execution SQL validation (optional)
+	SQLValidator SQLValidator // Optional validator run before each execute_sql query
+
+	// Curated analytics support (optional)
+	Analytics AnalyticsProvider // Optional provider for the typed analytics tools
 }
 
 // CompleteOptions holds options for LLM completion.
@@ -170,6 +179,52 @@ type Querier interface {
 	Query(ctx context.Context, sql string) (QueryResult, error)
 }
 
+// EnvQuerier executes SQL queries against a specific DZ environment's database.
+// Used for comparing environments (e.g. devnet vs mainnet-beta) without relying
+// on the model to prefix table names correctly.
+type EnvQuerier interface {
+	// Envs returns the environments that can be queried.
+	Envs() []string
+
+	// QueryEnv executes a SQL query against the given environment. Table
+	// references are validated against that environment's database.
+	QueryEnv(ctx context.Context, env, sql string) (QueryResult, error)
+}
+
+// AnalyticsProvider exposes the curated network analytics behind the web UI so
+// the agent can answer with the same numbers the dashboards show instead of
+// re-deriving them with ad-hoc SQL. Results must be JSON-serializable.
+type AnalyticsProvider interface {
+	// NetworkStatus returns the overall network health summary.
+	NetworkStatus(ctx context.Context) (any, error)
+
+	// LinkOutages returns discrete link outage events.
+	LinkOutages(ctx context.Context, params LinkOutagesParams) (any, error)
+
+	// StakeOverview returns DZ's share of Solana stake with 24h/7d deltas.
+	StakeOverview(ctx context.Context) (any, error)
+
+	// FailureImpact returns what becomes unreachable or rerouted if a device
+	// fails. The device may be identified by pk or code. Requires the graph.
+	FailureImpact(ctx context.Context, device string) (any, error)
+
+	// MetroPathLatency returns path-based latency between all metro pairs,
+	// optimized by "latency", "hops", or "bandwidth". Requires the graph.
+	MetroPathLatency(ctx context.Context, optimize string) (any, error)
+
+	// WhatIfRemoval returns the combined impact of removing devices and links,
+	// identified by pk or code. Requires the graph.
+	WhatIfRemoval(ctx context.Context, devices, links []string) (any, error)
+}
+
+// LinkOutagesParams holds the parameters for AnalyticsProvider.LinkOutages.
+type LinkOutagesParams struct {
+	Range     string  // Time range: 3h, 6h, 12h, 24h, 3d, 7d, 30d
+	Threshold float64 // Packet loss threshold percentage (1 or 10)
+	Type      string  // all, status, loss, no_data
+	Filter    string  // Comma-separated type:value filters, e.g. "metro:SAO,link:WAN-LAX-01"
+}
+
 // SchemaFetcher retrieves database schema information.
 type SchemaFetcher interface {
 	// FetchSchema returns a formatted string describing the database schema.
@@ -212,6 +267,7 @@ type GeneratedQuery struct {
 	DataQuestion DataQuestion
 	SQL          string // The SQL query text (empty for Cypher queries)
 	Cypher       string // The Cypher query text (empty for SQL queries)
+	Env          string // Target DZ environment for env-targeted SQL (empty for the default)
 	Explanation  string // Brief explanation of what the query does
 }
 
@@ -291,6 +347,10 @@ const (
 	StageReadDocsStarted  ProgressStage = "read_docs_started" // Reading docs started
 	StageReadDocsComplete ProgressStage = "read_docs_done"    // Reading docs completed
 
+	// v3 analytics tool stages
+	StageAnalyticsStarted  ProgressStage = "analytics_started" // Analytics tool started
+	StageAnalyticsComplete ProgressStage = "analytics_done"    // Analytics tool completed
+
 	// Legacy v3 stages (for backwards compatibility during transition)
 	StageQueryStarted  ProgressStage = "query_started" // Individual query started
 	StageQueryComplete ProgressStage = "query_done"    // Individual query completed
@@ -325,6 +385,10 @@ type Progress struct {
 	DocsContent string // For StageReadDocsComplete: content (truncated for progress)
 	DocsError   string // For StageReadDocsComplete: error if failed
 
+	// Analytics tool call fields
+	AnalyticsTool  string // For StageAnalyticsStarted/StageAnalyticsComplete: tool name
+	AnalyticsError string // For StageAnalyticsComplete: error if failed
+
 	// Legacy fields (for backwards compatibility)
 	QueryQuestion string // For StageQueryStarted/StageQueryComplete: the query question
 	QuerySQL      string // For StageQueryStarted/StageQueryComplete: the SQL
@@ -0,0 +1,296 @@
+package v3
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/malbeclabs/lake/agent/pkg/workflow"
+)
+
+// maxAnalyticsResultLen caps the JSON returned to the model from an analytics tool.
+const maxAnalyticsResultLen = 20000
+
+// Analytics tool definitions. These wrap the same computations that back the
+// web dashboards, so answers match the UI and need no SQL round-trips.
+var (
+	// GetNetworkStatusTool returns the network health summary shown on the status page.
+	GetNetworkStatusTool = Tool{
+		Name:        "get_network_status",
+		Description: "Get the current network health summary exactly as shown on the status page: overall status, device/link counts by health, links with packet loss or high utilization, and interface issues. Prefer this over SQL for 'is the network healthy?' or 'what's wrong right now?' questions.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {}
+		}`),
+	}
+
+	// GetLinkOutagesTool returns link outage events as shown on the outages page.
+	GetLinkOutagesTool = Tool{
+		Name:        "get_link_outages",
+		Description: "Get discrete link outage events (drained status, packet loss, or missing telemetry) with start/end times and durations, exactly as shown on the outages page. Prefer this over SQL for questions about outages, link downtime, or recent incidents.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"range": {
+					"type": "string",
+					"enum": ["3h", "6h", "12h", "24h", "3d", "7d", "30d"],
+					"description": "Time range to look back over. Defaults to 24h."
+				},
+				"threshold": {
+					"type": "number",
+					"enum": [1, 10],
+					"description": "Packet loss percentage that counts as an outage. Defaults to 10."
+				},
+				"type": {
+					"type": "string",
+					"enum": ["all", "status", "loss", "no_data"],
+					"description": "Outage type to include. Defaults to all."
+				},
+				"filter": {
+					"type": "string",
+					"description": "Optional comma-separated filters of the form type:value, where type is device, link, metro, or contributor (e.g. 'metro:SAO,link:WAN-LAX-01')."
+				}
+			}
+		}`),
+	}
+
+	// GetStakeOverviewTool returns DZ stake share as shown on the dashboard.
+	GetStakeOverviewTool = Tool{
+		Name:        "get_stake_overview",
+		Description: "Get DZ's current connected Solana stake, share of total network stake, validator count, and 24h/7d changes, exactly as shown on the dashboard. Prefer this over SQL for stake share questions.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {}
+		}`),
+	}
+
+	// GetFailureImpactTool returns the impact of a single device failing.
+	GetFailureImpactTool = Tool{
+		Name:        "get_failure_impact",
+		Description: "Get the impact of a device going down: devices that become unreachable, IS-IS paths that reroute (with before/after hops and metrics), and affected metros. Prefer this over Cypher for 'what happens if X fails?' questions.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"device": {
+					"type": "string",
+					"description": "Device pk or code, e.g. 'chi-dzd1'."
+				}
+			},
+			"required": ["device"]
+		}`),
+	}
+
+	// GetMetroPathLatencyTool returns path latency between all metro pairs.
+	GetMetroPathLatencyTool = Tool{
+		Name:        "get_metro_path_latency",
+		Description: "Get end-to-end path latency, hop count, and bottleneck bandwidth between every pair of metros over the DZ network, exactly as shown on the metro latency matrix, with the internet latency comparison where available. Prefer this over Cypher or SQL for metro-to-metro latency questions.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"optimize": {
+					"type": "string",
+					"enum": ["latency", "hops", "bandwidth"],
+					"description": "Path selection strategy. Defaults to latency."
+				}
+			}
+		}`),
+	}
+
+	// SimulateRemovalTool returns the impact of removing devices and links.
+	SimulateRemovalTool = Tool{
+		Name:        "simulate_removal",
+		Description: "Simulate removing one or more devices and/or links (e.g. for maintenance) and get the combined impact: affected paths with before/after hops and metrics, disconnected devices, and whether the network partitions. Prefer this over Cypher for what-if and maintenance planning questions.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"devices": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Device pks or codes to remove."
+				},
+				"links": {
+					"type": "array",
+					"items": {"type": "string"},
+					"description": "Link pks or codes to remove."
+				}
+			}
+		}`),
+	}
+)
+
+// AnalyticsTools returns the analytics tools. Graph-backed tools are only
+// included when a graph database is available.
+func AnalyticsTools(withGraph bool) []Tool {
+	tools := []Tool{GetNetworkStatusTool, GetLinkOutagesTool, GetStakeOverviewTool}
+	if withGraph {
+		tools = append(tools, GetFailureImpactTool, GetMetroPathLatencyTool, SimulateRemovalTool)
+	}
+	return tools
+}
+
+// isAnalyticsTool returns true if the tool name is one of the analytics tools.
+func isAnalyticsTool(name string) bool {
+	for _, t := range AnalyticsTools(true) {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLinkOutagesInput extracts LinkOutagesParams from get_link_outages parameters,
+// applying the same defaults as the outages page.
+func ParseLinkOutagesInput(params map[string]any) workflow.LinkOutagesParams {
+	p := workflow.LinkOutagesParams{
+		Range:     "24h",
+		Threshold: 10,
+		Type:      "all",
+	}
+	if v, ok := params["range"].(string); ok && strings.TrimSpace(v) != "" {
+		p.Range = strings.TrimSpace(v)
+	}
+	switch v := params["threshold"].(type) {
+	case float64:
+		p.Threshold = v
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			p.Threshold = f
+		}
+	}
+	if v, ok := params["type"].(string); ok && strings.TrimSpace(v) != "" {
+		p.Type = strings.TrimSpace(v)
+	}
+	if v, ok := params["filter"].(string); ok {
+		p.Filter = strings.TrimSpace(v)
+	}
+	return p
+}
+
+// ParseStringList extracts a list of strings from a tool parameter. Models
+// sometimes send arrays as JSON strings or comma-separated strings, so all
+// three forms are accepted.
+func ParseStringList(params map[string]any, key string) []string {
+	var raw []any
+	switch v := params[key].(type) {
+	case []any:
+		raw = v
+	case []string:
+		for _, s := range v {
+			raw = append(raw, s)
+		}
+	case string:
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(v), &raw); err != nil {
+			for _, s := range strings.Split(v, ",") {
+				raw = append(raw, s)
+			}
+		}
+	}
+
+	var out []string
+	for _, item := range raw {
+		s, ok := item.(string)
+		if !ok {
+			continue
+		}
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// executeAnalytics handles the analytics tools by calling the configured provider.
+func (p *Workflow) executeAnalytics(ctx context.Context, name string, params map[string]any, state *LoopState, onProgress workflow.ProgressCallback) (string, error) {
+	if p.cfg.Analytics == nil {
+		return "", fmt.Errorf("analytics tools are not available")
+	}
+
+	p.logInfo("workflow: running analytics", "tool", name, "params", params)
+
+	if onProgress != nil {
+		onProgress(workflow.Progress{
+			Stage:         workflow.StageAnalyticsStarted,
+			AnalyticsTool: name,
+		})
+	}
+
+	result, err := p.runAnalytics(ctx, name, params)
+	var content string
+	if err == nil {
+		content, err = formatAnalyticsResult(name, result)
+	}
+
+	state.AnalyticsCalls = append(state.AnalyticsCalls, name)
+
+	if onProgress != nil {
+		progress := workflow.Progress{
+			Stage:         workflow.StageAnalyticsComplete,
+			AnalyticsTool: name,
+		}
+		if err != nil {
+			progress.AnalyticsError = err.Error()
+		}
+		onProgress(progress)
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return content, nil
+}
+
+// runAnalytics dispatches an analytics tool call to the provider.
+func (p *Workflow) runAnalytics(ctx context.Context, name string, params map[string]any) (any, error) {
+	a := p.cfg.Analytics
+	switch name {
+	case GetNetworkStatusTool.Name:
+		return a.NetworkStatus(ctx)
+	case GetLinkOutagesTool.Name:
+		return a.LinkOutages(ctx, ParseLinkOutagesInput(params))
+	case GetStakeOverviewTool.Name:
+		return a.StakeOverview(ctx)
+	case GetFailureImpactTool.Name:
+		device, _ := params["device"].(string)
+		device = strings.TrimSpace(device)
+		if device == "" {
+			return nil, fmt.Errorf("missing or invalid 'device' parameter")
+		}
+		return a.FailureImpact(ctx, device)
+	case GetMetroPathLatencyTool.Name:
+		optimize, _ := params["optimize"].(string)
+		optimize = strings.TrimSpace(optimize)
+		if optimize == "" {
+			optimize = "latency"
+		}
+		return a.MetroPathLatency(ctx, optimize)
+	case SimulateRemovalTool.Name:
+		devices := ParseStringList(params, "devices")
+		links := ParseStringList(params, "links")
+		if len(devices) == 0 && len(links) == 0 {
+			return nil, fmt.Errorf("at least one device or link is required")
+		}
+		return a.WhatIfRemoval(ctx, devices, links)
+	default:
+		return nil, fmt.Errorf("unknown analytics tool: %s", name)
+	}
+}
+
+// formatAnalyticsResult renders an analytics result as JSON for the model,
+// truncating very large results.
+func formatAnalyticsResult(name string, result any) (string, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s result: %w", name, err)
+	}
+	content := string(data)
+	if len(content) > maxAnalyticsResultLen {
+		content = content[:maxAnalyticsResultLen] + "\n\n... (truncated)"
+	}
+	return fmt.Sprintf("# %s\n\n%s", name, content), nil
+}
@@ -0,0 +1,209 @@
+package v3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/malbeclabs/lake/agent/pkg/workflow"
+)
+
+// fakeAnalytics records the arguments it was called with.
+type fakeAnalytics struct {
+	outages  workflow.LinkOutagesParams
+	device   string
+	optimize string
+	devices  []string
+	links    []string
+}
+
+func (f *fakeAnalytics) NetworkStatus(ctx context.Context) (any, error) {
+	return map[string]string{"status": "healthy"}, nil
+}
+
+func (f *fakeAnalytics) LinkOutages(ctx context.Context, params workflow.LinkOutagesParams) (any, error) {
+	f.outages = params
+	return map[string]int{"total": 2}, nil
+}
+
+func (f *fakeAnalytics) StakeOverview(ctx context.Context) (any, error) {
+	return nil, errors.New("stake data is only available on mainnet-beta")
+}
+
+func (f *fakeAnalytics) FailureImpact(ctx context.Context, device string) (any, error) {
+	f.device = device
+	return map[string]int{"unreachableCount": 0}, nil
+}
+
+func (f *fakeAnalytics) MetroPathLatency(ctx context.Context, optimize string) (any, error) {
+	f.optimize = optimize
+	return map[string]string{"optimize": optimize}, nil
+}
+
+func (f *fakeAnalytics) WhatIfRemoval(ctx context.Context, devices, links []string) (any, error) {
+	f.devices = devices
+	f.links = links
+	return map[string]int{"totalAffectedPaths": 3}, nil
+}
+
+func TestAnalyticsTools(t *testing.T) {
+	names := func(tools []Tool) []string {
+		var out []string
+		for _, tool := range tools {
+			out = append(out, tool.Name)
+		}
+		return out
+	}
+
+	withoutGraph := names(AnalyticsTools(false))
+	want := []string{"get_network_status", "get_link_outages", "get_stake_overview"}
+	if !reflect.DeepEqual(withoutGraph, want) {
+		t.Errorf("AnalyticsTools(false) = %v, want %v", withoutGraph, want)
+	}
+
+	withGraph := names(AnalyticsTools(true))
+	want = append(want, "get_failure_impact", "get_metro_path_latency", "simulate_removal")
+	if !reflect.DeepEqual(withGraph, want) {
+		t.Errorf("AnalyticsTools(true) = %v, want %v", withGraph, want)
+	}
+
+	for _, tool := range AnalyticsTools(true) {
+		var schema map[string]any
+		if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+			t.Errorf("tool %s has invalid schema: %v", tool.Name, err)
+		}
+	}
+}
+
+func TestParseLinkOutagesInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   workflow.LinkOutagesParams
+	}{
+		{
+			name:   "defaults",
+			params: map[string]any{},
+			want:   workflow.LinkOutagesParams{Range: "24h", Threshold: 10, Type: "all"},
+		},
+		{
+			name:   "all params",
+			params: map[string]any{"range": "7d", "threshold": float64(1), "type": "loss", "filter": " metro:SAO "},
+			want:   workflow.LinkOutagesParams{Range: "7d", Threshold: 1, Type: "loss", Filter: "metro:SAO"},
+		},
+		{
+			name:   "threshold as string",
+			params: map[string]any{"threshold": "1"},
+			want:   workflow.LinkOutagesParams{Range: "24h", Threshold: 1, Type: "all"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseLinkOutagesInput(tt.params)
+			if got != tt.want {
+				t.Errorf("ParseLinkOutagesInput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStringList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"array", []any{"chi-dzd1", " nyc-dzd1 ", ""}, []string{"chi-dzd1", "nyc-dzd1"}},
+		{"json string", `["chi-dzd1", "nyc-dzd1"]`, []string{"chi-dzd1", "nyc-dzd1"}},
+		{"comma string", "chi-dzd1, nyc-dzd1", []string{"chi-dzd1", "nyc-dzd1"}},
+		{"missing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseStringList(map[string]any{"devices": tt.value}, "devices")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseStringList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteAnalytics(t *testing.T) {
+	fake := &fakeAnalytics{}
+	p := &Workflow{cfg: &workflow.Config{Analytics: fake}}
+	ctx := context.Background()
+
+	t.Run("passes parsed params and records the call", func(t *testing.T) {
+		state := &LoopState{}
+		var stages []workflow.ProgressStage
+		onProgress := func(progress workflow.Progress) { stages = append(stages, progress.Stage) }
+
+		result, err := p.executeAnalytics(ctx, "get_link_outages", map[string]any{"range": "3d"}, state, onProgress)
+		if err != nil {
+			t.Fatalf("executeAnalytics() unexpected error: %v", err)
+		}
+		if !strings.Contains(result, `{"total":2}`) {
+			t.Errorf("executeAnalytics() result = %q, want JSON result", result)
+		}
+		if fake.outages.Range != "3d" || fake.outages.Type != "all" {
+			t.Errorf("LinkOutages called with %+v", fake.outages)
+		}
+		if !reflect.DeepEqual(stages, []workflow.ProgressStage{workflow.StageAnalyticsStarted, workflow.StageAnalyticsComplete}) {
+			t.Errorf("progress stages = %v", stages)
+		}
+		if state.InferClassification() != workflow.ClassificationDataAnalysis {
+			t.Errorf("InferClassification() = %v, want data analysis", state.InferClassification())
+		}
+	})
+
+	t.Run("defaults metro path optimization", func(t *testing.T) {
+		if _, err := p.executeAnalytics(ctx, "get_metro_path_latency", map[string]any{}, &LoopState{}, nil); err != nil {
+			t.Fatalf("executeAnalytics() unexpected error: %v", err)
+		}
+		if fake.optimize != "latency" {
+			t.Errorf("MetroPathLatency called with %q, want latency", fake.optimize)
+		}
+	})
+
+	t.Run("simulate removal requires a target", func(t *testing.T) {
+		_, err := p.executeAnalytics(ctx, "simulate_removal", map[string]any{}, &LoopState{}, nil)
+		if err == nil {
+			t.Fatal("executeAnalytics() expected error for empty removal")
+		}
+
+		if _, err := p.executeAnalytics(ctx, "simulate_removal", map[string]any{"links": []any{"WAN-LAX-01"}}, &LoopState{}, nil); err != nil {
+			t.Fatalf("executeAnalytics() unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(fake.links, []string{"WAN-LAX-01"}) || fake.devices != nil {
+			t.Errorf("WhatIfRemoval called with devices=%v links=%v", fake.devices, fake.links)
+		}
+	})
+
+	t.Run("provider errors are reported", func(t *testing.T) {
+		var progressErr string
+		onProgress := func(progress workflow.Progress) {
+			if progress.Stage == workflow.StageAnalyticsComplete {
+				progressErr = progress.AnalyticsError
+			}
+		}
+		_, err := p.executeAnalytics(ctx, "get_stake_overview", map[string]any{}, &LoopState{}, onProgress)
+		if err == nil || !strings.Contains(err.Error(), "mainnet-beta") {
+			t.Errorf("executeAnalytics() error = %v, want provider error", err)
+		}
+		if progressErr == "" {
+			t.Error("expected analytics error in progress")
+		}
+	})
+
+	t.Run("fails without provider", func(t *testing.T) {
+		p := &Workflow{cfg: &workflow.Config{}}
+		if _, err := p.executeAnalytics(ctx, "get_network_status", nil, &LoopState{}, nil); err == nil {
+			t.Error("executeAnalytics() expected error without provider")
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -74,6 +75,12 @@ func New(cfg *workflow.Config) (*Workflow, error) {
 	} else {
 		v3Tools = DefaultTools()
 	}
+	if cfg.EnvQuerier != nil {
+		v3Tools = append(v3Tools, ExecuteSQLEnvTool)
+	}
+	if cfg.Analytics != nil {
+		v3Tools = append(v3Tools, AnalyticsTools(cfg.GraphQuerier != nil)...)
+	}
 	tools := make([]workflow.ToolDefinition, len(v3Tools))
 	for i, t := range v3Tools {
 		var schema any
@@ -423,6 +430,12 @@ func (p *Workflow) executeTool(ctx context.Context, call workflow.ToolCallInfo,
 			p.logInfo("workflow: execute_sql failed", "error", err, "params", call.Parameters)
 		}
 		return result, err
+	case "execute_sql_env":
+		result, err := p.executeSQLEnv(ctx, call.Parameters, state, onProgress)
+		if err != nil {
+			p.logInfo("workflow: execute_sql_env failed", "error", err, "params", call.Parameters)
+		}
+		return result, err
 	case "execute_cypher":
 		result, err := p.executeCypher(ctx, call.Parameters, state, onProgress)
 		if err != nil {
@@ -436,6 +449,13 @@ func (p *Workflow) executeTool(ctx context.Context, call workflow.ToolCallInfo,
 		}
 		return result, err
 	default:
+		if isAnalyticsTool(call.Name) {
+			result, err := p.executeAnalytics(ctx, call.Name, call.Parameters, state, onProgress)
+			if err != nil {
+				p.logInfo("workflow: analytics tool failed", "name", call.Name, "error", err, "params", call.Parameters)
+			}
+			return result, err
+		}
 		p.logInfo("workflow: unknown tool called", "name", call.Name)
 		return "", fmt.Errorf("unknown tool: %s", call.Name)
 	}
@@ -451,6 +471,80 @@ func (p *Workflow) executeSQL(ctx context.Context, params map[string]any, state
 		return "", fmt.Errorf("no valid queries provided")
 	}
 
+	// execute_sql always runs against the default database
+	for i := range queries {
+		queries[i].Env = ""
+	}
+
+	return p.runSQLQueries(ctx, queries, state, onProgress, func(ctx context.Context, q QueryInput) (workflow.QueryResult, error) {
+		if result, ok := p.validateSQL(ctx, q.SQL); !ok {
+			return result, nil
+		}
+		return p.cfg.Querier.Query(ctx, q.SQL)
+	})
+}
+
+// validateSQL runs the configured SQL validator on a query. If the query has
+// problems, it returns a failed result carrying the diagnostics and ok=false,
+// so the model can fix the query without it being executed. Validation
+// failures are logged and the query is executed as usual.
+func (p *Workflow) validateSQL(ctx context.Context, sql string) (workflow.QueryResult, bool) {
+	if p.cfg.SQLValidator == nil {
+		return workflow.QueryResult{}, true
+	}
+
+	diags, err := p.cfg.SQLValidator.ValidateSQL(ctx, sql)
+	if err != nil {
+		p.logInfo("workflow: SQL validation failed, executing unvalidated", "error", err)
+		return workflow.QueryResult{}, true
+	}
+	if len(diags) == 0 {
+		return workflow.QueryResult{}, true
+	}
+
+	p.logInfo("workflow: SQL rejected by validation", "diagnostics", len(diags), "sql", truncate(sql, 200))
+	return workflow.QueryResult{SQL: sql, Error: workflow.FormatSQLDiagnostics(diags)}, false
+}
+
+// executeSQLEnv handles the execute_sql_env tool - runs queries in parallel, each
+// against its target environment's database.
+func (p *Workflow) executeSQLEnv(ctx context.Context, params map[string]any, state *LoopState, onProgress workflow.ProgressCallback) (string, error) {
+	if p.cfg.EnvQuerier == nil {
+		return "", fmt.Errorf("environment-targeted queries are not configured")
+	}
+
+	queries, err := ParseQueries(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse queries: %w", err)
+	}
+	if len(queries) == 0 {
+		return "", fmt.Errorf("no valid queries provided")
+	}
+
+	envs := p.cfg.EnvQuerier.Envs()
+	for _, q := range queries {
+		if q.Env == "" {
+			return "", fmt.Errorf("query %q is missing 'env' (available: %s)", q.Question, strings.Join(envs, ", "))
+		}
+		if !slices.Contains(envs, q.Env) {
+			return "", fmt.Errorf("unknown env %q for query %q (available: %s)", q.Env, q.Question, strings.Join(envs, ", "))
+		}
+	}
+
+	return p.runSQLQueries(ctx, queries, state, onProgress, func(ctx context.Context, q QueryInput) (workflow.QueryResult, error) {
+		return p.cfg.EnvQuerier.QueryEnv(ctx, q.Env, q.SQL)
+	})
+}
+
+// runSQLQueries executes SQL queries in parallel using run, records them in the
+// loop state, and formats the results for the model.
+func (p *Workflow) runSQLQueries(
+	ctx context.Context,
+	queries []QueryInput,
+	state *LoopState,
+	onProgress workflow.ProgressCallback,
+	run func(ctx context.Context, q QueryInput) (workflow.QueryResult, error),
+) (string, error) {
 	// Log each query question and SQL for debugging
 	// Clean up SQL before emitting events so started/completed events use the same string
 	for i := range queries {
@@ -463,6 +557,7 @@ func (p *Workflow) executeSQL(ctx context.Context, params map[string]any, state
 		p.logInfo("workflow: query",
 			"q", qNum,
 			"question", q.Question,
+			"env", q.Env,
 			"sql", truncate(q.SQL, 200))
 	}
 
@@ -490,7 +585,7 @@ func (p *Workflow) executeSQL(ctx context.Context, params map[string]any, state
 			sql := query.SQL
 
 			// Execute query
-			queryResult, err := p.cfg.Querier.Query(ctx, sql)
+			queryResult, err := run(ctx, query)
 			if err != nil {
 				state.Metrics.QueryErrors++
 				results[idx] = workflow.ExecutedQuery{
@@ -499,6 +594,7 @@ func (p *Workflow) executeSQL(ctx context.Context, params map[string]any, state
 							Question: query.Question,
 						},
 						SQL: sql,
+						Env: query.Env,
 					},
 					Result: workflow.QueryResult{
 						SQL:   sql,
@@ -523,6 +619,7 @@ func (p *Workflow) executeSQL(ctx context.Context, params map[string]any, state
 						Question: query.Question,
 					},
 					SQL: sql,
+					Env: query.Env,
 				},
 				Result: queryResult,
 			}
@@ -578,6 +675,9 @@ func formatQueryResults(queries []QueryInput, results []workflow.ExecutedQuery,
 	var sb strings.Builder
 	for i, q := range queries {
 		sb.WriteString(fmt.Sprintf("## Q%d: %s\n\n", startNum+i+1, q.Question))
+		if q.Env != "" {
+			sb.WriteString(fmt.Sprintf("**Env:** %s\n\n", q.Env))
+		}
 		result := results[i].Result
 		if result.Error != "" {
 			sb.WriteString(fmt.Sprintf("**Error:** %s\n\n", result.Error))
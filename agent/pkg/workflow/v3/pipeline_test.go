@@ -1,6 +1,9 @@
 package v3
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/malbeclabs/lake/agent/pkg/workflow"
@@ -103,3 +106,63 @@ func TestBuildMessages_SkipsEmptyContent(t *testing.T) {
 		})
 	}
 }
+
+// recordingQuerier records executed SQL and returns an empty result.
+type recordingQuerier struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (q *recordingQuerier) Query(ctx context.Context, sql string) (workflow.QueryResult, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queries = append(q.queries, sql)
+	return workflow.QueryResult{SQL: sql}, nil
+}
+
+// rejectingValidator rejects queries containing "bad_column".
+type rejectingValidator struct{}
+
+func (rejectingValidator) ValidateSQL(ctx context.Context, sql string) ([]workflow.SQLDiagnostic, error) {
+	if strings.Contains(sql, "bad_column") {
+		return []workflow.SQLDiagnostic{{
+			Kind:        workflow.SQLDiagnosticUnknownColumn,
+			Message:     "column `l.bad_column` does not exist in dz_links_current",
+			Suggestions: []string{"l.side_a_pk"},
+		}}, nil
+	}
+	return nil, nil
+}
+
+func TestExecuteSQL_Validation(t *testing.T) {
+	querier := &recordingQuerier{}
+	p := &Workflow{cfg: &workflow.Config{Querier: querier, SQLValidator: rejectingValidator{}}}
+	state := &LoopState{Metrics: &WorkflowMetrics{}}
+
+	params := map[string]any{
+		"queries": []any{
+			map[string]any{"question": "good", "sql": "SELECT l.code FROM dz_links_current l"},
+			map[string]any{"question": "bad", "sql": "SELECT l.bad_column FROM dz_links_current l"},
+		},
+	}
+	result, err := p.executeSQL(context.Background(), params, state, nil)
+	if err != nil {
+		t.Fatalf("executeSQL() unexpected error: %v", err)
+	}
+
+	if len(querier.queries) != 1 || querier.queries[0] != "SELECT l.code FROM dz_links_current l" {
+		t.Errorf("executed queries = %v, want only the valid query", querier.queries)
+	}
+	if len(state.ExecutedQueries) != 2 {
+		t.Fatalf("len(ExecutedQueries) = %d, want 2", len(state.ExecutedQueries))
+	}
+	if state.ExecutedQueries[0].Result.Error != "" {
+		t.Errorf("valid query has error %q", state.ExecutedQueries[0].Result.Error)
+	}
+	if !strings.Contains(state.ExecutedQueries[1].Result.Error, "not executed") {
+		t.Errorf("rejected query error = %q", state.ExecutedQueries[1].Result.Error)
+	}
+	if !strings.Contains(result, "did you mean `l.side_a_pk`?") {
+		t.Errorf("result does not include suggestion:\n%s", result)
+	}
+}
@@ -39,6 +39,42 @@ var (
 		}`),
 	}
 
+	// ExecuteSQLEnvTool allows the model to execute SQL queries against an explicit
+	// DZ environment. Used for cross-environment comparisons.
+	ExecuteSQLEnvTool = Tool{
+		Name:        "execute_sql_env",
+		Description: "Execute one or more SQL queries against a specific DZ environment's ClickHouse database (mainnet-beta, devnet, testnet). Use unqualified table names; each query runs in its target environment's database. Use this for cross-environment comparisons, e.g. the same query against devnet and mainnet-beta. Queries run in parallel.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"queries": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"properties": {
+							"question": {
+								"type": "string",
+								"description": "The data question this query answers, including the environment, e.g. 'How many links are activated on devnet?'"
+							},
+							"env": {
+								"type": "string",
+								"enum": ["mainnet-beta", "devnet", "testnet"],
+								"description": "The DZ environment to query."
+							},
+							"sql": {
+								"type": "string",
+								"description": "The SQL query to execute, using unqualified table names. Must be a single JSON string - do NOT use string concatenation with + operators."
+							}
+						},
+						"required": ["question", "env", "sql"]
+					},
+					"description": "List of queries to execute. Must be valid JSON array - do NOT use string concatenation."
+				}
+			},
+			"required": ["queries"]
+		}`),
+	}
+
 	// ExecuteCypherTool allows the model to execute Cypher queries against Neo4j.
 	ExecuteCypherTool = Tool{
 		Name:        "execute_cypher",
@@ -180,11 +216,13 @@ func ParseQueries(params map[string]any) ([]QueryInput, error) {
 
 		question, _ := qMap["question"].(string)
 		sql, _ := qMap["sql"].(string)
+		env, _ := qMap["env"].(string)
 
 		if question != "" && sql != "" {
 			queries = append(queries, QueryInput{
 				Question: question,
 				SQL:      sql,
+				Env:      strings.TrimSpace(env),
 			})
 		}
 	}
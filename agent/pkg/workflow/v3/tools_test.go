@@ -434,3 +434,24 @@ func TestParseCypherQueries(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQueries_Env(t *testing.T) {
+	queries, err := ParseQueries(map[string]any{
+		"queries": []any{
+			map[string]any{"question": "Links on devnet?", "env": " devnet ", "sql": "SELECT count() FROM dim_links_current"},
+			map[string]any{"question": "Links on mainnet?", "env": "mainnet-beta", "sql": "SELECT count() FROM dim_links_current"},
+			map[string]any{"question": "No env", "sql": "SELECT 1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseQueries() unexpected error: %v", err)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("ParseQueries() returned %d queries, want 3", len(queries))
+	}
+	for i, want := range []string{"devnet", "mainnet-beta", ""} {
+		if queries[i].Env != want {
+			t.Errorf("ParseQueries() query %d env = %q, want %q", i, queries[i].Env, want)
+		}
+	}
+}
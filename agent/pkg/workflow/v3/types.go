@@ -31,10 +31,11 @@ type ToolCall struct {
 	Parameters map[string]any
 }
 
-// QueryInput represents a single query in an execute_sql tool call.
+// QueryInput represents a single query in an execute_sql or execute_sql_env tool call.
 type QueryInput struct {
 	Question string `json:"question"`
 	SQL      string `json:"sql"`
+	Env      string `json:"env,omitempty"` // Target environment (execute_sql_env only)
 }
 
 // StreamEvent represents an event to be streamed to the client.
@@ -50,6 +51,7 @@ type StreamCallback func(event StreamEvent)
 type LoopState struct {
 	ThinkingSteps     []string                 // Content from think() calls
 	ExecutedQueries   []workflow.ExecutedQuery // All SQL executed
+	AnalyticsCalls    []string                 // Analytics tools called
 	FinalAnswer       string                   // Last assistant text (non-tool response)
 	FollowUpQuestions []string                 // Suggested follow-up questions
 	Metrics           *WorkflowMetrics         // Metrics collected during execution
@@ -57,8 +59,8 @@ type LoopState struct {
 
 // InferClassification determines classification based on tool usage behavior.
 func (state *LoopState) InferClassification() workflow.Classification {
-	// If model executed SQL queries, it's data analysis
-	if len(state.ExecutedQueries) > 0 {
+	// If model executed SQL queries or analytics tools, it's data analysis
+	if len(state.ExecutedQueries) > 0 || len(state.AnalyticsCalls) > 0 {
 		return workflow.ClassificationDataAnalysis
 	}
 
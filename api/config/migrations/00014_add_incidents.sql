@@ -0,0 +1,51 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    env VARCHAR(20) NOT NULL DEFAULT 'mainnet-beta',
+    status VARCHAR(20) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'resolved')),
+    severity VARCHAR(20) NOT NULL DEFAULT 'warning'
+        CHECK (severity IN ('warning', 'critical')),
+    title TEXT NOT NULL,
+
+    -- Correlated signals and the topology they touch
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ,
+    impacted_entities JSONB NOT NULL DEFAULT '[]',
+    events JSONB NOT NULL DEFAULT '[]',
+    stake_affected_sol DOUBLE PRECISION NOT NULL DEFAULT 0,
+
+    -- Root cause analysis
+    rca_url TEXT,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_env_started ON incidents(env, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_incidents_open ON incidents(env) WHERE status = 'open';
+
+DROP TRIGGER IF EXISTS update_incidents_updated_at ON incidents;
+CREATE TRIGGER update_incidents_updated_at
+    BEFORE UPDATE ON incidents
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS incident_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    incident_id UUID NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+    author VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incident_notes_incident ON incident_notes(incident_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_incident_notes_incident;
+DROP TABLE IF EXISTS incident_notes;
+DROP TRIGGER IF EXISTS update_incidents_updated_at ON incidents;
+DROP INDEX IF EXISTS idx_incidents_open;
+DROP INDEX IF EXISTS idx_incidents_env_started;
+DROP TABLE IF EXISTS incidents;
@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    env VARCHAR(20) NOT NULL DEFAULT 'mainnet-beta',
+    title TEXT NOT NULL,
+
+    -- Devices and links taken out of service during the window
+    entities JSONB NOT NULL DEFAULT '[]',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+
+    owner VARCHAR(255) NOT NULL,
+    account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+    notes TEXT NOT NULL DEFAULT '',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_env_time ON maintenance_windows(env, starts_at, ends_at);
+
+DROP TRIGGER IF EXISTS update_maintenance_windows_updated_at ON maintenance_windows;
+CREATE TRIGGER update_maintenance_windows_updated_at
+    BEFORE UPDATE ON maintenance_windows
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS update_maintenance_windows_updated_at ON maintenance_windows;
+DROP INDEX IF EXISTS idx_maintenance_windows_env_time;
+DROP TABLE IF EXISTS maintenance_windows;
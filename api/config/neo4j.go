@@ -10,23 +10,27 @@ import (
 	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 )
 
-// Neo4jClient is the global read-only Neo4j client
-var Neo4jClient neo4j.Client
+// Neo4jClients maps environment names to read-only Neo4j clients. Each
+// environment's graph lives in its own Neo4j database.
+var Neo4jClients map[string]neo4j.Client
 
-// Neo4jDatabase is the configured database name
-var Neo4jDatabase string
+// Neo4jDatabases maps environment names to their Neo4j database names.
+var Neo4jDatabases map[string]string
 
-// LoadNeo4j initializes the Neo4j client from environment variables.
-// The client is read-only to prevent accidental writes from the API layer.
+// LoadNeo4j initializes the Neo4j clients from environment variables.
+// The clients are read-only to prevent accidental writes from the API layer.
+// The mainnet-beta client is required; devnet and testnet clients are created
+// when NEO4J_DATABASE_DEVNET / NEO4J_DATABASE_TESTNET are set, and skipped with
+// a warning if they cannot connect.
 func LoadNeo4j() error {
 	uri := os.Getenv("NEO4J_URI")
 	if uri == "" {
 		uri = "bolt://localhost:7687"
 	}
 
-	Neo4jDatabase = os.Getenv("NEO4J_DATABASE")
-	if Neo4jDatabase == "" {
-		Neo4jDatabase = neo4j.DefaultDatabase
+	database := os.Getenv("NEO4J_DATABASE")
+	if database == "" {
+		database = neo4j.DefaultDatabase
 	}
 
 	username := os.Getenv("NEO4J_USERNAME")
@@ -36,34 +40,78 @@ func LoadNeo4j() error {
 
 	password := os.Getenv("NEO4J_PASSWORD")
 
-	log.Printf("Connecting to Neo4j (read-only): uri=%s, database=%s, username=%s", uri, Neo4jDatabase, username)
+	// Build env -> database mapping
+	databases := map[string]string{
+		"mainnet-beta": database,
+	}
+	if db := os.Getenv("NEO4J_DATABASE_DEVNET"); db != "" {
+		databases["devnet"] = db
+	}
+	if db := os.Getenv("NEO4J_DATABASE_TESTNET"); db != "" {
+		databases["testnet"] = db
+	}
+
+	log.Printf("Connecting to Neo4j (read-only): uri=%s, database=%s, username=%s", uri, database, username)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	client, err := neo4j.NewReadOnlyClient(ctx, slog.Default(), uri, Neo4jDatabase, username, password)
+	client, err := neo4j.NewReadOnlyClient(ctx, slog.Default(), uri, database, username, password)
 	if err != nil {
 		return err
 	}
 
-	Neo4jClient = client
+	Neo4jClients = map[string]neo4j.Client{
+		"mainnet-beta": client,
+	}
+	Neo4jDatabases = map[string]string{
+		"mainnet-beta": database,
+	}
 	log.Printf("Connected to Neo4j successfully (read-only)")
 
+	for env, dbName := range databases {
+		if env == "mainnet-beta" {
+			continue
+		}
+		envCtx, envCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		envClient, err := neo4j.NewReadOnlyClient(envCtx, slog.Default(), uri, dbName, username, password)
+		envCancel()
+		if err != nil {
+			log.Printf("Warning: Neo4j not available for %s (database=%s): %v", env, dbName, err)
+			continue
+		}
+		Neo4jClients[env] = envClient
+		Neo4jDatabases[env] = dbName
+		log.Printf("Connected to Neo4j for %s (database=%s)", env, dbName)
+	}
+
 	return nil
 }
 
-// CloseNeo4j closes the Neo4j client
+// CloseNeo4j closes all Neo4j clients
 func CloseNeo4j() error {
-	if Neo4jClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		return Neo4jClient.Close(ctx)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var firstErr error
+	for _, client := range Neo4jClients {
+		if err := client.Close(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return nil
+	return firstErr
+}
+
+// Neo4jClientForEnv returns the Neo4j client for the given environment, or nil
+// if the environment has no graph configured. Unlike DBForEnv there is no
+// fallback, since mainnet topology must never be served for another env.
+func Neo4jClientForEnv(env string) neo4j.Client {
+	return Neo4jClients[env]
 }
 
-// Neo4jSession creates a new Neo4j session
-func Neo4jSession(ctx context.Context) neo4j.Session {
-	session, _ := Neo4jClient.Session(ctx)
+// Neo4jSessionForEnv creates a new Neo4j session for the given environment.
+// Callers must check Neo4jClientForEnv first.
+func Neo4jSessionForEnv(ctx context.Context, env string) neo4j.Session {
+	session, _ := Neo4jClientForEnv(env).Session(ctx)
 	return session
 }
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/malbeclabs/lake/agent/pkg/workflow"
+)
+
+// errNoGraph is returned by graph-backed analytics when the env has no Neo4j graph.
+var errNoGraph = errors.New("graph data is not available for this environment")
+
+// AnalyticsProvider implements workflow.AnalyticsProvider using the same fetch
+// functions (and status cache) that back the web UI.
+type AnalyticsProvider struct{}
+
+// NewAnalyticsProvider creates a new AnalyticsProvider.
+func NewAnalyticsProvider() *AnalyticsProvider {
+	return &AnalyticsProvider{}
+}
+
+// NetworkStatus returns the status page summary.
+func (a *AnalyticsProvider) NetworkStatus(ctx context.Context) (any, error) {
+	if isMainnet(ctx) && statusCache != nil {
+		if cached := statusCache.GetStatus(); cached != nil {
+			return cached, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	return fetchStatusData(ctx), nil
+}
+
+// LinkOutages returns link outage events, matching GET /api/outages/links.
+func (a *AnalyticsProvider) LinkOutages(ctx context.Context, params workflow.LinkOutagesParams) (any, error) {
+	switch params.Type {
+	case "all", "status", "loss", "no_data":
+	default:
+		return nil, fmt.Errorf("type must be 'all', 'status', 'loss', or 'no_data'")
+	}
+	threshold := parseThreshold(strconv.FormatFloat(params.Threshold, 'f', -1, 64))
+
+	// Serve the default view from the cache, same as the outages page
+	if isMainnet(ctx) && statusCache != nil && params.Range == "24h" && threshold == 10 && params.Type == "all" && params.Filter == "" {
+		if cached := statusCache.GetOutages(); cached != nil {
+			return cached, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return fetchLinkOutagesData(ctx, parseTimeRange(params.Range), threshold, params.Type, parseOutageFilters(params.Filter))
+}
+
+// StakeOverview returns DZ stake share, matching GET /api/stake/overview.
+func (a *AnalyticsProvider) StakeOverview(ctx context.Context) (any, error) {
+	if !isMainnet(ctx) {
+		return nil, errors.New("stake data is only available on mainnet-beta")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	overview := fetchStakeOverviewData(ctx)
+	if overview.Error != "" {
+		return nil, errors.New(overview.Error)
+	}
+	return overview, nil
+}
+
+// FailureImpact returns the impact of a device failing, matching
+// GET /api/topology/impact/{pk}.
+func (a *AnalyticsProvider) FailureImpact(ctx context.Context, device string) (any, error) {
+	if envNeo4jClient(ctx) == nil {
+		return nil, errNoGraph
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	devicePK, err := resolveEntityPK(ctx, "dz_devices_current", device)
+	if err != nil {
+		return nil, err
+	}
+
+	response := fetchFailureImpactData(ctx, devicePK)
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response, nil
+}
+
+// MetroPathLatency returns path latency between all metro pairs, matching
+// GET /api/topology/metro-path-latency.
+func (a *AnalyticsProvider) MetroPathLatency(ctx context.Context, optimize string) (any, error) {
+	if optimize != "hops" && optimize != "latency" && optimize != "bandwidth" {
+		return nil, errors.New("optimize must be 'hops', 'latency', or 'bandwidth'")
+	}
+	if envNeo4jClient(ctx) == nil {
+		return nil, errNoGraph
+	}
+
+	if isMainnet(ctx) && statusCache != nil {
+		if cached := statusCache.GetMetroPathLatency(optimize); cached != nil {
+			return cached, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return fetchMetroPathLatencyData(ctx, optimize)
+}
+
+// WhatIfRemoval returns the impact of removing devices and links, matching
+// POST /api/topology/whatif-removal.
+func (a *AnalyticsProvider) WhatIfRemoval(ctx context.Context, devices, links []string) (any, error) {
+	if envNeo4jClient(ctx) == nil {
+		return nil, errNoGraph
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var req WhatIfRemovalRequest
+	for _, device := range devices {
+		pk, err := resolveEntityPK(ctx, "dz_devices_current", device)
+		if err != nil {
+			return nil, err
+		}
+		req.Devices = append(req.Devices, pk)
+	}
+	for _, link := range links {
+		pk, err := resolveEntityPK(ctx, "dz_links_current", link)
+		if err != nil {
+			return nil, err
+		}
+		req.Links = append(req.Links, pk)
+	}
+
+	response := fetchWhatIfRemovalData(ctx, req)
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response, nil
+}
+
+// resolveEntityPK resolves a device or link identified by pk or code to its pk.
+func resolveEntityPK(ctx context.Context, table, pkOrCode string) (string, error) {
+	var pk string
+	query := `SELECT pk FROM ` + table + ` WHERE pk = ? OR code = ? LIMIT 1`
+	if err := envDB(ctx).QueryRow(ctx, query, pkOrCode, pkOrCode).Scan(&pk); err != nil {
+		return "", fmt.Errorf("%q not found", pkOrCode)
+	}
+	return pk, nil
+}
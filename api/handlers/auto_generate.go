@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
-	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/metrics"
 )
 
@@ -60,7 +59,7 @@ func AutoGenerateStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if Neo4j is available - if not, default to SQL
-	neo4jAvailable := config.Neo4jClient != nil
+	neo4jAvailable := envNeo4jClient(r.Context()) != nil
 
 	// Classify the question
 	var mode string
@@ -215,7 +214,7 @@ func streamSQLGeneration(ctx context.Context, req AutoGenerateRequest, sendEvent
 // streamCypherGeneration handles the Cypher generation portion of auto-generate.
 func streamCypherGeneration(ctx context.Context, req AutoGenerateRequest, sendEvent func(string, string)) {
 	// Check if Neo4j is available
-	if config.Neo4jClient == nil {
+	if envNeo4jClient(ctx) == nil {
 		sendEvent("error", "Neo4j is not available")
 		return
 	}
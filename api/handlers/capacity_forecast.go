@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+const (
+	// minForecastHistoryDays is how many days of history an entity needs to be forecast.
+	minForecastHistoryDays = 7
+	// minSeasonalHistoryDays is how many days of history a weekly pattern needs; shorter
+	// histories are fit with a trend only.
+	minSeasonalHistoryDays = 14
+	// forecastCurrentDays is how many recent days the current p95 utilization averages.
+	forecastCurrentDays = 7
+)
+
+// --- Forecast endpoint ---
+
+// BuildForecastQuery builds the ClickHouse query for the capacity forecast endpoint. It returns
+// the daily p95 throughput of every link interface over the last historyDays complete days,
+// with the link's bandwidth and the dimensions of the device it was measured on.
+func BuildForecastQuery(historyDays int, filterSQL string) string {
+	return fmt.Sprintf(`
+		WITH daily AS (
+			SELECT
+				f.device_pk,
+				f.link_pk,
+				toDate(f.event_ts) AS day,
+				quantile(0.95)(greatest(f.in_octets_delta, f.out_octets_delta) * 8 / f.delta_duration) AS p95_bps
+			FROM fact_dz_device_interface_counters f
+			WHERE f.event_ts >= toStartOfDay(now()) - INTERVAL %d DAY
+				AND f.event_ts < toStartOfDay(now())
+				AND f.link_pk != ''
+				AND f.delta_duration > 0
+				AND f.in_octets_delta >= 0
+				AND f.out_octets_delta >= 0
+			GROUP BY f.device_pk, f.link_pk, day
+		)
+		SELECT
+			daily.device_pk,
+			d.code AS device_code,
+			COALESCE(m.code, '') AS metro_code,
+			COALESCE(co.code, '') AS contributor_code,
+			daily.link_pk,
+			l.code AS link_code,
+			COALESCE(l.link_type, '') AS link_type,
+			COALESCE(lc.code, '') AS link_contributor_code,
+			toFloat64(l.bandwidth_bps) AS bandwidth_bps,
+			toString(daily.day) AS day,
+			daily.p95_bps
+		FROM daily
+		INNER JOIN dz_devices_current d ON daily.device_pk = d.pk
+		INNER JOIN dz_links_current l ON daily.link_pk = l.pk
+		LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+		LEFT JOIN dz_contributors_current co ON d.contributor_pk = co.pk
+		LEFT JOIN dz_contributors_current lc ON l.contributor_pk = lc.pk
+		WHERE COALESCE(l.bandwidth_bps, 0) > 0 %s
+		ORDER BY daily.device_pk, daily.link_pk, day`,
+		historyDays, filterSQL)
+}
+
+// CapacityForecastPoint is one day of an entity's utilization history or forecast.
+type CapacityForecastPoint struct {
+	Date         string   `json:"date"`               // YYYY-MM-DD
+	P95Util      *float64 `json:"p95_util,omitempty"` // Observed, absent for forecast days
+	ForecastUtil float64  `json:"forecast_util"`      // Fitted trend and weekly pattern
+}
+
+// CapacityForecastEntity is a link or device's utilization trend and when it saturates.
+type CapacityForecastEntity struct {
+	PK                  string                  `json:"pk"`
+	Code                string                  `json:"code"`
+	LinkType            string                  `json:"link_type,omitempty"`
+	MetroCodes          []string                `json:"metro_codes"`
+	ContributorCode     string                  `json:"contributor_code"`
+	BandwidthBps        float64                 `json:"bandwidth_bps"`
+	HistoryDays         int                     `json:"history_days"`
+	CurrentP95Util      float64                 `json:"current_p95_util"`   // Mean daily p95 over the last week
+	TrendPerDay         float64                 `json:"trend_per_day"`      // Change in p95 utilization per day
+	SeasonalAmplitude   float64                 `json:"seasonal_amplitude"` // Spread between the busiest and quietest weekday
+	ProjectedP95Util    float64                 `json:"projected_p95_util"` // At the end of the horizon
+	DaysUntilSaturation *int                    `json:"days_until_saturation,omitempty"`
+	SaturationDate      *string                 `json:"saturation_date,omitempty"`
+	Series              []CapacityForecastPoint `json:"series"`
+}
+
+type CapacityForecastResponse struct {
+	Entity      string                   `json:"entity"` // "link" or "device"
+	Threshold   float64                  `json:"threshold"`
+	HistoryDays int                      `json:"history_days"`
+	HorizonDays int                      `json:"horizon_days"`
+	Entities    []CapacityForecastEntity `json:"entities"`
+}
+
+func GetTrafficDashboardForecast(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	entity := r.URL.Query().Get("entity")
+	if entity == "" {
+		entity = "link"
+	}
+	if entity != "link" && entity != "device" {
+		http.Error(w, "entity must be 'link' or 'device'", http.StatusBadRequest)
+		return
+	}
+
+	historyDays := 30
+	if h := r.URL.Query().Get("history_days"); h != "" {
+		if v, err := strconv.Atoi(h); err == nil && v >= minForecastHistoryDays && v <= 90 {
+			historyDays = v
+		}
+	}
+
+	horizonDays := 90
+	if h := r.URL.Query().Get("horizon_days"); h != "" {
+		if v, err := strconv.Atoi(h); err == nil && v > 0 && v <= 365 {
+			horizonDays = v
+		}
+	}
+
+	threshold := 0.8
+	if t := r.URL.Query().Get("threshold"); t != "" {
+		if v, err := strconv.ParseFloat(t, 64); err == nil && v > 0 && v <= 1 {
+			threshold = v
+		}
+	}
+
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
+			limit = v
+		}
+	}
+
+	filterSQL, _, _, _, _, _, _, _, _ := buildDimensionFilters(r)
+
+	query := BuildForecastQuery(historyDays, filterSQL)
+
+	start := time.Now()
+	rows, err := envDB(ctx).Query(ctx, query)
+	duration := time.Since(start)
+	metrics.RecordClickHouseQuery(duration, err)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Traffic dashboard forecast query error: %v\nQuery: %s", err, query)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var samples []forecastSample
+	for rows.Next() {
+		var s forecastSample
+		var day string
+		if err := rows.Scan(&s.devicePK, &s.deviceCode, &s.metroCode, &s.contributorCode,
+			&s.linkPK, &s.linkCode, &s.linkType, &s.linkContributorCode,
+			&s.bandwidthBps, &day, &s.p95Bps); err != nil {
+			log.Printf("Traffic dashboard forecast row scan error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if s.day, err = time.Parse(time.DateOnly, day); err != nil {
+			log.Printf("Traffic dashboard forecast day parse error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		samples = append(samples, s)
+	}
+
+	var series []utilizationSeries
+	if entity == "device" {
+		series = deviceUtilizationSeries(samples)
+	} else {
+		series = linkUtilizationSeries(samples)
+	}
+	entities := forecastCapacity(series, threshold, horizonDays)
+	if len(entities) > limit {
+		entities = entities[:limit]
+	}
+
+	resp := CapacityForecastResponse{
+		Entity:      entity,
+		Threshold:   threshold,
+		HistoryDays: historyDays,
+		HorizonDays: horizonDays,
+		Entities:    entities,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// forecastSample is one link interface's p95 throughput on one day.
+type forecastSample struct {
+	devicePK, deviceCode, metroCode, contributorCode string
+	linkPK, linkCode, linkType, linkContributorCode  string
+	bandwidthBps                                     float64
+	day                                              time.Time
+	p95Bps                                           float64
+}
+
+// utilizationSeries is an entity's daily p95 utilization, in day order.
+type utilizationSeries struct {
+	entity CapacityForecastEntity // Identity and dimensions only
+	days   []time.Time
+	util   []float64
+}
+
+// linkUtilizationSeries builds each link's daily p95 utilization from the busier of its sides.
+func linkUtilizationSeries(samples []forecastSample) []utilizationSeries {
+	byLink := make(map[string]*utilizationSeries)
+	daily := make(map[string]map[time.Time]float64)
+	var order []string
+	for _, s := range samples {
+		series, ok := byLink[s.linkPK]
+		if !ok {
+			series = &utilizationSeries{entity: CapacityForecastEntity{
+				PK:              s.linkPK,
+				Code:            s.linkCode,
+				LinkType:        s.linkType,
+				ContributorCode: s.linkContributorCode,
+				BandwidthBps:    s.bandwidthBps,
+			}}
+			byLink[s.linkPK] = series
+			daily[s.linkPK] = make(map[time.Time]float64)
+			order = append(order, s.linkPK)
+		}
+		series.entity.MetroCodes = append(series.entity.MetroCodes, s.metroCode)
+		daily[s.linkPK][s.day] = max(daily[s.linkPK][s.day], s.p95Bps/s.bandwidthBps)
+	}
+
+	result := make([]utilizationSeries, 0, len(order))
+	for _, pk := range order {
+		series := byLink[pk]
+		series.entity.MetroCodes = uniqueStrings(series.entity.MetroCodes)
+		series.days, series.util = sortedDaily(daily[pk])
+		result = append(result, *series)
+	}
+	return result
+}
+
+// deviceUtilizationSeries builds each device's daily p95 utilization as the traffic across its
+// link interfaces over their combined bandwidth.
+func deviceUtilizationSeries(samples []forecastSample) []utilizationSeries {
+	type dayTotals struct{ bps, bandwidth float64 }
+	byDevice := make(map[string]*utilizationSeries)
+	totals := make(map[string]map[time.Time]dayTotals)
+	links := make(map[string]map[string]float64)
+	var order []string
+	for _, s := range samples {
+		series, ok := byDevice[s.devicePK]
+		if !ok {
+			series = &utilizationSeries{entity: CapacityForecastEntity{
+				PK:              s.devicePK,
+				Code:            s.deviceCode,
+				MetroCodes:      uniqueStrings([]string{s.metroCode}),
+				ContributorCode: s.contributorCode,
+			}}
+			byDevice[s.devicePK] = series
+			totals[s.devicePK] = make(map[time.Time]dayTotals)
+			links[s.devicePK] = make(map[string]float64)
+			order = append(order, s.devicePK)
+		}
+		links[s.devicePK][s.linkPK] = s.bandwidthBps
+		t := totals[s.devicePK][s.day]
+		t.bps += s.p95Bps
+		t.bandwidth += s.bandwidthBps
+		totals[s.devicePK][s.day] = t
+	}
+
+	result := make([]utilizationSeries, 0, len(order))
+	for _, pk := range order {
+		series := byDevice[pk]
+		for _, bandwidth := range links[pk] {
+			series.entity.BandwidthBps += bandwidth
+		}
+		daily := make(map[time.Time]float64, len(totals[pk]))
+		for day, t := range totals[pk] {
+			daily[day] = t.bps / t.bandwidth
+		}
+		series.days, series.util = sortedDaily(daily)
+		result = append(result, *series)
+	}
+	return result
+}
+
+func sortedDaily(daily map[time.Time]float64) ([]time.Time, []float64) {
+	days := make([]time.Time, 0, len(daily))
+	for day := range daily {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	util := make([]float64, len(days))
+	for i, day := range days {
+		util[i] = daily[day]
+	}
+	return days, util
+}
+
+// forecastCapacity fits every series with enough history and projects it over the horizon,
+// soonest to saturate first.
+func forecastCapacity(series []utilizationSeries, threshold float64, horizonDays int) []CapacityForecastEntity {
+	entities := []CapacityForecastEntity{}
+	for _, s := range series {
+		if len(s.days) < minForecastHistoryDays {
+			continue
+		}
+		model := fitSeasonalTrend(s.days, s.util)
+		e := s.entity
+		e.HistoryDays = len(s.days)
+		e.TrendPerDay = model.slope
+		e.SeasonalAmplitude = model.amplitude()
+		if e.MetroCodes == nil {
+			e.MetroCodes = []string{}
+		}
+
+		recent := s.util[max(len(s.util)-forecastCurrentDays, 0):]
+		for _, u := range recent {
+			e.CurrentP95Util += u
+		}
+		e.CurrentP95Util /= float64(len(recent))
+
+		for i, day := range s.days {
+			observed := s.util[i]
+			e.Series = append(e.Series, CapacityForecastPoint{
+				Date:         day.Format(time.DateOnly),
+				P95Util:      &observed,
+				ForecastUtil: model.at(day),
+			})
+		}
+
+		last := s.days[len(s.days)-1]
+		if s.util[len(s.util)-1] >= threshold {
+			saturated := 0
+			e.DaysUntilSaturation = &saturated
+			date := last.Format(time.DateOnly)
+			e.SaturationDate = &date
+		}
+		for d := 1; d <= horizonDays; d++ {
+			day := last.AddDate(0, 0, d)
+			forecast := model.at(day)
+			e.Series = append(e.Series, CapacityForecastPoint{Date: day.Format(time.DateOnly), ForecastUtil: forecast})
+			e.ProjectedP95Util = forecast
+			if e.DaysUntilSaturation == nil && forecast >= threshold {
+				days := d
+				e.DaysUntilSaturation = &days
+				date := day.Format(time.DateOnly)
+				e.SaturationDate = &date
+			}
+		}
+		entities = append(entities, e)
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		a, b := entities[i], entities[j]
+		if (a.DaysUntilSaturation == nil) != (b.DaysUntilSaturation == nil) {
+			return a.DaysUntilSaturation != nil
+		}
+		if a.DaysUntilSaturation != nil && *a.DaysUntilSaturation != *b.DaysUntilSaturation {
+			return *a.DaysUntilSaturation < *b.DaysUntilSaturation
+		}
+		if a.ProjectedP95Util != b.ProjectedP95Util {
+			return a.ProjectedP95Util > b.ProjectedP95Util
+		}
+		return a.Code < b.Code
+	})
+	return entities
+}
+
+// seasonalTrend is a linear trend plus a weekly pattern fitted to daily values.
+type seasonalTrend struct {
+	origin           time.Time
+	intercept, slope float64    // Per day since origin
+	weekly           [7]float64 // Offset per weekday, averaging zero
+}
+
+// fitSeasonalTrend fits, by least squares, a linear trend with its own level on each weekday.
+// Histories shorter than two weeks share one level across weekdays.
+func fitSeasonalTrend(days []time.Time, values []float64) seasonalTrend {
+	m := seasonalTrend{origin: days[0]}
+	x := make([]float64, len(days))
+	for i, day := range days {
+		x[i] = day.Sub(m.origin).Hours() / 24
+	}
+	seasonal := x[len(x)-1]+1 >= minSeasonalHistoryDays
+	group := func(i int) int {
+		if seasonal {
+			return int(days[i].Weekday())
+		}
+		return 0
+	}
+
+	var sumX, sumY [7]float64
+	var counts [7]int
+	for i := range x {
+		g := group(i)
+		sumX[g] += x[i]
+		sumY[g] += values[i]
+		counts[g]++
+	}
+	var meanX, meanY [7]float64
+	for g := range counts {
+		if counts[g] > 0 {
+			meanX[g] = sumX[g] / float64(counts[g])
+			meanY[g] = sumY[g] / float64(counts[g])
+		}
+	}
+
+	// The slope comes from the variation within each weekday, so the weekly pattern cannot
+	// bias it
+	var sxy, sxx float64
+	for i := range x {
+		g := group(i)
+		dx := x[i] - meanX[g]
+		sxy += dx * (values[i] - meanY[g])
+		sxx += dx * dx
+	}
+	if sxx > 0 {
+		m.slope = sxy / sxx
+	}
+
+	var levels [7]float64
+	groups := 0
+	for g := range counts {
+		if counts[g] > 0 {
+			levels[g] = meanY[g] - m.slope*meanX[g]
+			m.intercept += levels[g]
+			groups++
+		}
+	}
+	m.intercept /= float64(groups)
+	if seasonal {
+		for g := range counts {
+			if counts[g] > 0 {
+				m.weekly[g] = levels[g] - m.intercept
+			}
+		}
+	}
+	return m
+}
+
+// at returns the fitted value for a day.
+func (m seasonalTrend) at(day time.Time) float64 {
+	x := day.Sub(m.origin).Hours() / 24
+	return m.intercept + m.slope*x + m.weekly[day.Weekday()]
+}
+
+// amplitude returns the spread between the largest and smallest weekday offsets.
+func (m seasonalTrend) amplitude() float64 {
+	lo, hi := m.weekly[0], m.weekly[0]
+	for _, w := range m.weekly[1:] {
+		lo, hi = min(lo, w), max(hi, w)
+	}
+	return hi - lo
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func forecastDays(start time.Time, n int) []time.Time {
+	days := make([]time.Time, n)
+	for i := range days {
+		days[i] = start.AddDate(0, 0, i)
+	}
+	return days
+}
+
+func TestFitSeasonalTrend(t *testing.T) {
+	t.Parallel()
+
+	// 2026-01-05 is a Monday; weekends run 0.1 below the weekday level
+	days := forecastDays(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 28)
+	values := make([]float64, len(days))
+	for i, day := range days {
+		values[i] = 0.2 + 0.01*float64(i)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			values[i] -= 0.1
+		}
+	}
+
+	m := fitSeasonalTrend(days, values)
+	assert.InDelta(t, 0.01, m.slope, 1e-9)
+	assert.InDelta(t, 0.1, m.amplitude(), 1e-9)
+	for i, day := range days {
+		assert.InDelta(t, values[i], m.at(day), 1e-9)
+	}
+	// The pattern carries into the future
+	saturday := days[len(days)-1].AddDate(0, 0, 6)
+	require.Equal(t, time.Saturday, saturday.Weekday())
+	assert.InDelta(t, 0.2+0.01*33-0.1, m.at(saturday), 1e-9)
+
+	// Under two weeks of history only the trend is fit
+	short := fitSeasonalTrend(days[:10], values[:10])
+	assert.Zero(t, short.amplitude())
+}
+
+func TestForecastCapacity(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	linear := func(code string, n int, first, perDay float64) utilizationSeries {
+		s := utilizationSeries{entity: CapacityForecastEntity{PK: code, Code: code}, days: forecastDays(start, n)}
+		for i := range s.days {
+			s.util = append(s.util, first+perDay*float64(i))
+		}
+		return s
+	}
+
+	entities := forecastCapacity([]utilizationSeries{
+		linear("flat", 14, 0.5, 0),
+		linear("growing", 14, 0.505, 0.01), // Last day 0.635, crosses 0.8 17 days later
+		linear("fast", 14, 0.605, 0.01),    // Last day 0.735, crosses 0.8 7 days later
+		linear("saturated", 14, 0.9, 0),    // Already above the threshold
+		linear("too-short", 5, 0.9, 0.05),  // Not enough history
+	}, 0.8, 60)
+
+	require.Len(t, entities, 4)
+	var codes []string
+	for _, e := range entities {
+		codes = append(codes, e.Code)
+	}
+	assert.Equal(t, []string{"saturated", "fast", "growing", "flat"}, codes)
+
+	assert.Equal(t, 0, *entities[0].DaysUntilSaturation)
+	assert.Equal(t, "2026-01-18", *entities[0].SaturationDate)
+
+	fast := entities[1]
+	assert.Equal(t, 7, *fast.DaysUntilSaturation)
+	assert.Equal(t, "2026-01-25", *fast.SaturationDate)
+	assert.InDelta(t, 0.01, fast.TrendPerDay, 1e-9)
+	assert.InDelta(t, 0.705, fast.CurrentP95Util, 1e-9)
+	assert.InDelta(t, 0.735+0.6, fast.ProjectedP95Util, 1e-9)
+	require.Len(t, fast.Series, 14+60)
+	assert.NotNil(t, fast.Series[13].P95Util)
+	assert.Nil(t, fast.Series[14].P95Util)
+
+	assert.Equal(t, 17, *entities[2].DaysUntilSaturation)
+	assert.Nil(t, entities[3].DaysUntilSaturation)
+	assert.Equal(t, []string{}, entities[3].MetroCodes)
+}
+
+func TestUtilizationSeries(t *testing.T) {
+	t.Parallel()
+
+	day1 := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	samples := []forecastSample{
+		{devicePK: "d1", deviceCode: "dz1", metroCode: "FRA", linkPK: "l1", linkCode: "l1", bandwidthBps: 100, day: day2, p95Bps: 40},
+		{devicePK: "d1", deviceCode: "dz1", metroCode: "FRA", linkPK: "l1", linkCode: "l1", bandwidthBps: 100, day: day1, p95Bps: 20},
+		{devicePK: "d2", deviceCode: "dz2", metroCode: "AMS", linkPK: "l1", linkCode: "l1", bandwidthBps: 100, day: day1, p95Bps: 30},
+		{devicePK: "d1", deviceCode: "dz1", metroCode: "FRA", linkPK: "l2", linkCode: "l2", bandwidthBps: 300, day: day1, p95Bps: 60},
+	}
+
+	// A link takes the busier side each day
+	links := linkUtilizationSeries(samples)
+	require.Len(t, links, 2)
+	assert.Equal(t, "l1", links[0].entity.PK)
+	assert.Equal(t, []string{"FRA", "AMS"}, links[0].entity.MetroCodes)
+	assert.Equal(t, []time.Time{day1, day2}, links[0].days)
+	assert.Equal(t, []float64{0.3, 0.4}, links[0].util)
+
+	// A device adds up its links against their combined bandwidth
+	devices := deviceUtilizationSeries(samples)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "d1", devices[0].entity.PK)
+	assert.Equal(t, 400.0, devices[0].entity.BandwidthBps)
+	assert.Equal(t, []float64{0.2, 0.4}, devices[0].util)
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+)
+
+const (
+	defaultChangesLimit = 500
+	maxChangesLimit     = 5000
+	defaultChangesWait  = 25 * time.Second
+	maxChangesWait      = 60 * time.Second
+	changesHeartbeat    = 15 * time.Second
+)
+
+// changesPollInterval is how often the stream checks ClickHouse for new changes.
+var changesPollInterval = 2 * time.Second
+
+// ChangesResponse is the long-poll response for GET /api/changes/stream
+type ChangesResponse struct {
+	Events []dataset.ChangeEvent `json:"events"`
+	// Cursor resumes the stream after the last returned event (or the requested position if
+	// there were none)
+	Cursor string `json:"cursor"`
+}
+
+// GetChangesStream serves dimension changes and recorded fact writes in cursor order.
+// With "Accept: text/event-stream" it streams server-sent events (event id = cursor, so
+// Last-Event-ID resumes a dropped connection); otherwise it long-polls, returning as soon as
+// there is at least one change or the wait expires.
+// Query params: since (cursor, or RFC3339 timestamp; default now), datasets (comma-separated),
+// limit (max events per response), wait (long-poll duration, e.g. 30s).
+func GetChangesStream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	since := q.Get("since")
+	if since == "" {
+		since = r.Header.Get("Last-Event-ID")
+	}
+	cursor, err := parseChangesSince(since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var datasets []string
+	for _, d := range strings.Split(q.Get("datasets"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			datasets = append(datasets, d)
+		}
+	}
+
+	limit := defaultChangesLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxChangesLimit)
+	}
+
+	wait := defaultChangesWait
+	if s := q.Get("wait"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			http.Error(w, "invalid wait: expected a duration such as 30s", http.StatusBadRequest)
+			return
+		}
+		wait = min(d, maxChangesWait)
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		streamChanges(w, r, cursor, datasets, limit)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait+10*time.Second)
+	defer cancel()
+
+	deadline := time.Now().Add(wait)
+	for {
+		events, err := readChanges(ctx, cursor, datasets, limit)
+		if err != nil {
+			log.Printf("Changes query error: %v", err)
+			http.Error(w, "failed to query changes", http.StatusInternalServerError)
+			return
+		}
+		if len(events) > 0 || !time.Now().Before(deadline) {
+			resp := ChangesResponse{Events: events, Cursor: cursor.String()}
+			if len(events) > 0 {
+				resp.Cursor = events[len(events)-1].Cursor
+			} else {
+				resp.Events = []dataset.ChangeEvent{}
+			}
+			writeJSON(w, resp)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(min(changesPollInterval, time.Until(deadline))):
+		}
+	}
+}
+
+// streamChanges writes changes as server-sent events until the client disconnects.
+func streamChanges(w http.ResponseWriter, r *http.Request, cursor dataset.ChangeCursor, datasets []string, limit int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher.Flush()
+
+	ctx := r.Context()
+	lastWrite := time.Now()
+	for {
+		events, err := readChanges(ctx, cursor, datasets, limit)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Changes stream query error: %v", err)
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", `{"error":"failed to query changes"}`)
+			flusher.Flush()
+			return
+		}
+
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Printf("Changes stream encoding error: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: change\ndata: %s\n\n", e.Cursor, data)
+			cursor, _ = dataset.ParseChangeCursor(e.Cursor)
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+			lastWrite = time.Now()
+			if len(events) == limit {
+				continue // more pending, don't wait
+			}
+		} else if time.Since(lastWrite) >= changesHeartbeat {
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(changesPollInterval):
+		}
+	}
+}
+
+func readChanges(ctx context.Context, cursor dataset.ChangeCursor, datasets []string, limit int) ([]dataset.ChangeEvent, error) {
+	start := time.Now()
+	events, err := dataset.ReadChanges(ctx, datasetConn{envDB(ctx)}, cursor, datasets, limit)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	return events, err
+}
+
+// parseChangesSince accepts a cursor from a previous response or an RFC3339 commit timestamp.
+// Empty means now, so only changes from here on are returned; it starts ChangeCommitLag back
+// since changes inside the lag have not been served to anyone yet.
+func parseChangesSince(s string) (dataset.ChangeCursor, error) {
+	if s == "" {
+		return dataset.ChangeCursor{CommittedAt: time.Now().UTC().Add(-dataset.ChangeCommitLag)}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		// Start just before t so changes at exactly t are included
+		return dataset.ChangeCursor{CommittedAt: t.UTC().Add(-time.Millisecond)}, nil
+	}
+	cursor, err := dataset.ParseChangeCursor(s)
+	if err != nil {
+		return dataset.ChangeCursor{}, fmt.Errorf("invalid since: expected a cursor or RFC3339 timestamp")
+	}
+	return cursor, nil
+}
@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/handlers"
+	apitesting "github.com/malbeclabs/lake/api/testing"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getChangesStream(t *testing.T, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/changes/stream"+query, nil)
+	rr := httptest.NewRecorder()
+	handlers.GetChangesStream(rr, req)
+	return rr
+}
+
+func TestGetChangesStream(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+
+	t1 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2025, 6, 1, 1, 0, 0, 0, time.UTC)
+	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
+		`INSERT INTO dim_change_log (dataset, entity_id, op_id, snapshot_ts, ingested_at, change_type, changed_fields, changes, attrs) VALUES ('dz_devices', 'dev-1', '%s', '%s', '%s', 'created', [], '[]', '{"code":"dev1"}')`,
+		uuid.New().String(), tsFormat(t1), tsFormat(t1))))
+	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
+		`INSERT INTO fact_change_log (dataset, op_id, snapshot_ts, row_count, min_time, max_time) VALUES ('fact_dz_device_link_latency', '%s', '%s', 42, '%s', '%s')`,
+		uuid.New().String(), tsFormat(t2), tsFormat(t1), tsFormat(t2))))
+
+	since := "?since=" + t1.Add(-time.Hour).Format(time.RFC3339)
+
+	t.Run("returns dimension and fact changes in order", func(t *testing.T) {
+		rr := getChangesStream(t, since)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.ChangesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		require.Len(t, resp.Events, 2)
+
+		assert.Equal(t, dataset.ChangeKindDimension, resp.Events[0].Kind)
+		assert.Equal(t, "dz_devices", resp.Events[0].Dataset)
+		assert.Equal(t, "created", resp.Events[0].ChangeType)
+		assert.Equal(t, "dev1", resp.Events[0].Attrs["code"])
+
+		assert.Equal(t, dataset.ChangeKindFact, resp.Events[1].Kind)
+		assert.Equal(t, uint64(42), resp.Events[1].Rows)
+		assert.Equal(t, resp.Events[1].Cursor, resp.Cursor)
+	})
+
+	t.Run("resumes after cursor", func(t *testing.T) {
+		rr := getChangesStream(t, since+"&limit=1")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var first handlers.ChangesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&first))
+		require.Len(t, first.Events, 1)
+
+		rr = getChangesStream(t, "?since="+first.Cursor)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var next handlers.ChangesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&next))
+		require.Len(t, next.Events, 1)
+		assert.Equal(t, dataset.ChangeKindFact, next.Events[0].Kind)
+	})
+
+	t.Run("filters datasets", func(t *testing.T) {
+		rr := getChangesStream(t, since+"&datasets=fact_dz_device_link_latency")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp handlers.ChangesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, "fact_dz_device_link_latency", resp.Events[0].Dataset)
+	})
+
+	t.Run("empty result returns input cursor", func(t *testing.T) {
+		rr := getChangesStream(t, "?since="+t2.Add(time.Hour).Format(time.RFC3339)+"&wait=0s")
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp handlers.ChangesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Empty(t, resp.Events)
+		assert.NotEmpty(t, resp.Cursor)
+	})
+
+	t.Run("invalid since", func(t *testing.T) {
+		rr := getChangesStream(t, "?since=not-a-cursor")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -157,9 +157,18 @@ func Chat(w http.ResponseWriter, r *http.Request) {
 		MaxTokens:     4096,
 	}
 
-	// Add Neo4j support if available (mainnet only)
+	// Add cross-environment query support if more than one env is configured
+	cfg.EnvQuerier = NewEnvQuerier()
+
+	// Validate SQL against the schema and with EXPLAIN before executing it
+	cfg.SQLValidator = workflow.NewSchemaSQLValidator(schemaFetcher, querier)
+
+	// Add curated analytics tools
+	cfg.Analytics = NewAnalyticsProvider()
+
+	// Add Neo4j support if the env has a graph
 	env := EnvFromContext(r.Context())
-	if config.Neo4jClient != nil && env == EnvMainnet {
+	if config.Neo4jClientForEnv(string(env)) != nil {
 		cfg.GraphQuerier = NewNeo4jQuerier()
 		cfg.GraphSchemaFetcher = NewNeo4jSchemaFetcher()
 	}
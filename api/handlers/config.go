@@ -36,7 +36,7 @@ func GetConfig(w http.ResponseWriter, r *http.Request) {
 	// Feature flags based on environment
 	// Mainnet gets all features; non-mainnet environments have restricted features
 	features := map[string]bool{
-		"neo4j":  appconfig.Neo4jClient != nil && env == EnvMainnet,
+		"neo4j":  appconfig.Neo4jClientForEnv(string(env)) != nil,
 		"solana": env == EnvMainnet,
 		"geoip":  env == EnvMainnet,
 	}
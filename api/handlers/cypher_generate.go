@@ -13,7 +13,6 @@ import (
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/malbeclabs/lake/agent/pkg/workflow/prompts"
-	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/metrics"
 	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 )
@@ -69,7 +68,7 @@ func GenerateCypher(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if Neo4j is available for schema fetching
-	if config.Neo4jClient == nil {
+	if envNeo4jClient(r.Context()) == nil {
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(GenerateResponse{Error: "Neo4j is not available"})
 		return
@@ -175,7 +174,7 @@ func GenerateCypherStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if Neo4j is available
-	if config.Neo4jClient == nil {
+	if envNeo4jClient(r.Context()) == nil {
 		sendEvent("error", "Neo4j is not available")
 		return
 	}
@@ -278,14 +277,14 @@ func cleanCypher(response string) string {
 }
 
 func validateCypherQuery(ctx context.Context, cypher string) string {
-	if config.Neo4jClient == nil {
+	if envNeo4jClient(ctx) == nil {
 		return "Neo4j is not available"
 	}
 
 	// Use EXPLAIN to validate the query syntax
 	explainQuery := "EXPLAIN " + cypher
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	_, err := session.ExecuteRead(ctx, func(tx neo4j.Transaction) (any, error) {
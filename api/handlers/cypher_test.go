@@ -127,9 +127,9 @@ func TestExecuteCypher_InvalidRequestBody(t *testing.T) {
 
 func TestExecuteCypher_NoNeo4j(t *testing.T) {
 	// Don't set up Neo4j - test graceful fallback
-	oldClient := config.Neo4jClient
-	config.Neo4jClient = nil
-	defer func() { config.Neo4jClient = oldClient }()
+	oldClients := config.Neo4jClients
+	config.Neo4jClients = nil
+	defer func() { config.Neo4jClients = oldClients }()
 
 	reqBody := handlers.CypherQueryRequest{
 		Query: "MATCH (n) RETURN n",
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/malbeclabs/lake/agent/pkg/workflow"
 	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/metrics"
@@ -33,10 +34,16 @@ func NewDBQuerier() *DBQuerier {
 // Agent queries always run against the mainnet database. To query other
 // environments, use fully-qualified table names (e.g., lake_devnet.dim_devices_current).
 func (q *DBQuerier) Query(ctx context.Context, sql string) (workflow.QueryResult, error) {
+	return queryConn(ctx, config.DB, sql)
+}
+
+// queryConn executes a SQL query on the given connection pool and collects the
+// rows into a workflow.QueryResult.
+func queryConn(ctx context.Context, conn driver.Conn, sql string) (workflow.QueryResult, error) {
 	sql = strings.TrimSuffix(strings.TrimSpace(sql), ";")
 
 	start := time.Now()
-	rows, err := config.DB.Query(ctx, sql)
+	rows, err := conn.Query(ctx, sql)
 	duration := time.Since(start)
 	if err != nil {
 		metrics.RecordClickHouseQuery(duration, err)
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/go-chi/chi/v5"
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+)
+
+// historyDatasets maps the {entity} path segment of the history endpoint to its dataset.
+var historyDatasets = map[string]func(*slog.Logger) (*dataset.DimensionType2Dataset, error){
+	"devices":          dzsvc.NewDeviceDataset,
+	"links":            dzsvc.NewLinkDataset,
+	"metros":           dzsvc.NewMetroDataset,
+	"contributors":     dzsvc.NewContributorDataset,
+	"users":            dzsvc.NewUserDataset,
+	"multicast-groups": dzsvc.NewMulticastGroupDataset,
+}
+
+// EntityVersion is one version of an entity in its SCD2 history
+type EntityVersion struct {
+	SnapshotTS string                `json:"snapshot_ts"`
+	IngestedAt string                `json:"ingested_at"`
+	OpID       string                `json:"op_id"`
+	ChangeType string                `json:"change_type"` // "created", "updated", "deleted"
+	Changes    []dataset.FieldChange `json:"changes,omitempty"`
+	Attrs      map[string]any        `json:"attrs"`
+}
+
+// EntityHistoryResponse is the response for GET /api/dz/{entity}/{pk}/history
+type EntityHistoryResponse struct {
+	Entity   string          `json:"entity"`
+	PK       string          `json:"pk"`
+	From     string          `json:"from,omitempty"`
+	To       string          `json:"to,omitempty"`
+	Versions []EntityVersion `json:"versions"`
+}
+
+// datasetConn adapts the API's ClickHouse pool to the connection interface used by the
+// indexer's dataset package. Close is a no-op since the pool is owned by config.
+type datasetConn struct {
+	driver.Conn
+}
+
+func (c datasetConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	return c.Conn.PrepareBatch(ctx, query)
+}
+
+func (c datasetConn) Close() error {
+	return nil
+}
+
+// GetEntityHistory returns the versions of a serviceability entity, oldest first, each with
+// the fields that changed from the version before it.
+// Query params: from, to (RFC3339, optional).
+func GetEntityHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	entity := chi.URLParam(r, "entity")
+	newDataset, ok := historyDatasets[entity]
+	if !ok {
+		http.Error(w, "unknown entity type: "+entity, http.StatusNotFound)
+		return
+	}
+
+	pk := chi.URLParam(r, "pk")
+	if pk == "" {
+		http.Error(w, "missing pk", http.StatusBadRequest)
+		return
+	}
+
+	var from, to time.Time
+	if s := r.URL.Query().Get("from"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "invalid from: expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if s := r.URL.Query().Get("to"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "invalid to: expected RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+
+	ds, err := newDataset(slog.Default())
+	if err != nil {
+		log.Printf("Entity history dataset error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	versions, err := queryEntityHistory(ctx, ds, datasetConn{envDB(ctx)}, dataset.NewNaturalKey(pk).ToSurrogate(), from, to)
+	if err != nil {
+		log.Printf("Entity history query error: %v", err)
+		http.Error(w, "failed to query entity history", http.StatusInternalServerError)
+		return
+	}
+
+	resp := EntityHistoryResponse{
+		Entity:   entity,
+		PK:       pk,
+		Versions: versions,
+	}
+	if !from.IsZero() {
+		resp.From = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		resp.To = to.UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, resp)
+}
+
+// queryEntityHistory loads the versions of an entity in [from, to] and diffs each against the
+// version before it. The version in effect just before from is used as the baseline, so the
+// first version in the range is reported as an update rather than a creation when appropriate.
+func queryEntityHistory(ctx context.Context, ds *dataset.DimensionType2Dataset, conn datasetConn, entityID dataset.SurrogateKey, from, to time.Time) ([]EntityVersion, error) {
+	start := time.Now()
+	rows, err := ds.GetHistory(ctx, conn, entityID, from, to)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev map[string]any
+	if !from.IsZero() && len(rows) > 0 {
+		start := time.Now()
+		prev, err = ds.GetAsOfRow(ctx, conn, entityID, from.Add(-time.Millisecond))
+		metrics.RecordClickHouseQuery(time.Since(start), err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	versions := make([]EntityVersion, 0, len(rows))
+	for _, row := range rows {
+		entry, ok := ds.DiffVersions(prev, row)
+		prev = row
+		if !ok {
+			continue
+		}
+		versions = append(versions, EntityVersion{
+			SnapshotTS: entry.SnapshotTS.UTC().Format(time.RFC3339),
+			IngestedAt: entry.IngestedAt.UTC().Format(time.RFC3339),
+			OpID:       entry.OpID.String(),
+			ChangeType: entry.ChangeType,
+			Changes:    entry.Changes,
+			Attrs:      entry.Attrs,
+		})
+	}
+	return versions, nil
+}
@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/handlers"
+	apitesting "github.com/malbeclabs/lake/api/testing"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertMetroHistory(t *testing.T, pk, name string, isDeleted int, ts time.Time) {
+	entityID := dataset.NewNaturalKey(pk).ToSurrogate()
+	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
+		`INSERT INTO dim_dz_metros_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pk, code, name, longitude, latitude) VALUES ('%s', '%s', '%s', '%s', %d, 0, '%s', 'ams', '%s', 4.9, 52.4)`,
+		entityID, tsFormat(ts), tsFormat(ts), uuid.New().String(), isDeleted, pk, name)))
+}
+
+func getEntityHistory(t *testing.T, entity, pk, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/dz/"+entity+"/"+pk+"/history"+query, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("entity", entity)
+	rctx.URLParams.Add("pk", pk)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handlers.GetEntityHistory(rr, req)
+	return rr
+}
+
+func TestGetEntityHistory(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+
+	t1 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2025, 6, 1, 1, 0, 0, 0, time.UTC)
+	t3 := time.Date(2025, 6, 1, 2, 0, 0, 0, time.UTC)
+	insertMetroHistory(t, "metro-1", "Amsterdam", 0, t1)
+	insertMetroHistory(t, "metro-1", "Amsterdam NL", 0, t2)
+	insertMetroHistory(t, "metro-1", "Amsterdam NL", 1, t3)
+
+	t.Run("full history", func(t *testing.T) {
+		rr := getEntityHistory(t, "metros", "metro-1", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.EntityHistoryResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		require.Len(t, resp.Versions, 3)
+
+		assert.Equal(t, "created", resp.Versions[0].ChangeType)
+		assert.Equal(t, "Amsterdam", resp.Versions[0].Attrs["name"])
+
+		assert.Equal(t, "updated", resp.Versions[1].ChangeType)
+		require.Len(t, resp.Versions[1].Changes, 1)
+		assert.Equal(t, dataset.FieldChange{Field: "name", OldValue: "Amsterdam", NewValue: "Amsterdam NL"}, resp.Versions[1].Changes[0])
+
+		assert.Equal(t, "deleted", resp.Versions[2].ChangeType)
+		assert.Equal(t, t3.Format(time.RFC3339), resp.Versions[2].SnapshotTS)
+	})
+
+	t.Run("range uses prior version as baseline", func(t *testing.T) {
+		rr := getEntityHistory(t, "metros", "metro-1", "?from="+t2.Format(time.RFC3339)+"&to="+t2.Format(time.RFC3339))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.EntityHistoryResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		require.Len(t, resp.Versions, 1)
+		assert.Equal(t, "updated", resp.Versions[0].ChangeType)
+	})
+
+	t.Run("unknown entity type", func(t *testing.T) {
+		rr := getEntityHistory(t, "widgets", "metro-1", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		rr := getEntityHistory(t, "metros", "metro-1", "?from=yesterday")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = getEntityHistory(t, "metros", "metro-1", "?from="+t2.Format(time.RFC3339)+"&to="+t1.Format(time.RFC3339))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 )
 
 // DZEnv represents a DoubleZero network environment.
@@ -45,6 +46,18 @@ func envDB(ctx context.Context) driver.Conn {
 	return config.DBForEnv(string(EnvFromContext(ctx)))
 }
 
+// envNeo4jClient returns the Neo4j client for the environment in the context,
+// or nil if that environment has no graph configured.
+func envNeo4jClient(ctx context.Context) neo4j.Client {
+	return config.Neo4jClientForEnv(string(EnvFromContext(ctx)))
+}
+
+// envNeo4jSession creates a Neo4j session for the environment in the context.
+// Callers must check envNeo4jClient first.
+func envNeo4jSession(ctx context.Context) neo4j.Session {
+	return config.Neo4jSessionForEnv(ctx, string(EnvFromContext(ctx)))
+}
+
 // DatabaseForEnvFromContext returns the database name for the environment in the context.
 func DatabaseForEnvFromContext(ctx context.Context) string {
 	env := EnvFromContext(ctx)
@@ -62,7 +75,7 @@ func BuildEnvContext(env DZEnv) string {
 	mainnetDB := config.Database()
 
 	if env == EnvMainnet {
-		return fmt.Sprintf("You are querying the mainnet-beta environment (database: `%s`). Other DZ environments are available: devnet (`lake_devnet`), testnet (`lake_testnet`). To query these, use fully-qualified `database.table` syntax (e.g., `lake_devnet.dim_devices_current`).", mainnetDB)
+		return fmt.Sprintf("You are querying the mainnet-beta environment (database: `%s`). Other DZ environments are available: devnet (`lake_devnet`), testnet (`lake_testnet`). To query these, use fully-qualified `database.table` syntax (e.g., `lake_devnet.dim_devices_current`). To compare environments side by side, use the execute_sql_env tool if available.", mainnetDB)
 	}
 
 	// For non-mainnet envs, tell the agent to USE the environment's database
@@ -73,7 +86,9 @@ Example: Instead of "SELECT * FROM dim_devices_current", write "SELECT * FROM %s
 
 Queries without the "%s." prefix will return mainnet-beta data. This is incorrect UNLESS the user explicitly asks for mainnet or mainnet-beta data.
 
-Note: Neo4j graph queries, Solana validator data, and GeoIP location data are only available on mainnet-beta.`, string(env), envDB, string(env), envDB, envDB)
+To compare environments side by side, use the execute_sql_env tool if available.
+
+Note: Neo4j graph queries run against the %s graph when it is available. Solana validator data and GeoIP location data are only available on mainnet-beta.`, string(env), envDB, string(env), envDB, envDB, string(env))
 }
 
 // isMainnet returns true if the request context is for the mainnet-beta environment.
@@ -81,14 +96,14 @@ func isMainnet(ctx context.Context) bool {
 	return EnvFromContext(ctx) == EnvMainnet
 }
 
-// RequireNeo4jMiddleware returns 503 for non-mainnet requests on Neo4j-dependent
-// endpoints, since Neo4j only contains mainnet data.
+// RequireNeo4jMiddleware returns 503 on Neo4j-dependent endpoints when the
+// request's environment has no graph database configured.
 func RequireNeo4jMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !isMainnet(r.Context()) || config.Neo4jClient == nil {
+		if envNeo4jClient(r.Context()) == nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write([]byte(`{"error":"This feature is only available on mainnet-beta"}`))
+			_, _ = w.Write([]byte(`{"error":"Graph data is not available for this environment"}`))
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/malbeclabs/lake/agent/pkg/workflow"
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+// envOrder is the display order for side-by-side environment results.
+var envOrder = []DZEnv{EnvMainnet, EnvDevnet, EnvTestnet}
+
+// configuredEnvs returns the environments with a configured database, in display order.
+func configuredEnvs() []DZEnv {
+	var envs []DZEnv
+	for _, env := range envOrder {
+		if _, ok := config.DatabaseForEnv(string(env)); ok {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// ParseEnvsParam parses the "envs" query parameter, a comma-separated list of
+// environments or "all" for every configured environment. Returns nil if the
+// parameter is not set.
+func ParseEnvsParam(r *http.Request) ([]DZEnv, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("envs"))
+	if raw == "" {
+		return nil, nil
+	}
+	if raw == "all" {
+		return configuredEnvs(), nil
+	}
+
+	var envs []DZEnv
+	for _, part := range strings.Split(raw, ",") {
+		env := DZEnv(strings.TrimSpace(part))
+		if env == "" || slices.Contains(envs, env) {
+			continue
+		}
+		if !ValidEnvs[env] {
+			return nil, fmt.Errorf("invalid env %q", env)
+		}
+		if _, ok := config.DatabaseForEnv(string(env)); !ok {
+			return nil, fmt.Errorf("env %q is not configured", env)
+		}
+		envs = append(envs, env)
+	}
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("no envs specified")
+	}
+	return envs, nil
+}
+
+// EnvResult is one environment's response within a multi-env response.
+type EnvResult struct {
+	Env    DZEnv           `json:"env"`
+	Status int             `json:"status"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// MultiEnvResponse holds per-environment responses side by side.
+type MultiEnvResponse struct {
+	Results []EnvResult `json:"results"`
+}
+
+// MultiEnvMiddleware runs the wrapped handler once per environment when the
+// request has an "envs" query parameter (e.g. ?envs=mainnet-beta,devnet or
+// ?envs=all) and returns the per-environment JSON responses side by side.
+// Requests without the parameter are passed through unchanged.
+func MultiEnvMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		envs, err := ParseEnvsParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if envs == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Strip the envs parameter so the wrapped handler sees a normal request
+		q := r.URL.Query()
+		q.Del("envs")
+
+		results := make([]EnvResult, len(envs))
+		var wg sync.WaitGroup
+		for i, env := range envs {
+			wg.Add(1)
+			go func(idx int, env DZEnv) {
+				defer wg.Done()
+
+				req := r.Clone(ContextWithEnv(r.Context(), env))
+				req.URL.RawQuery = q.Encode()
+				req.Header.Set("X-DZ-Env", string(env))
+
+				rec := newBufferedResponseWriter()
+				next.ServeHTTP(rec, req)
+
+				result := EnvResult{Env: env, Status: rec.status}
+				body := bytes.TrimSpace(rec.body.Bytes())
+				if rec.status >= 400 {
+					result.Error = string(body)
+				} else if json.Valid(body) {
+					result.Data = body
+				} else {
+					result.Error = "response is not JSON"
+				}
+				results[idx] = result
+			}(i, env)
+		}
+		wg.Wait()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(MultiEnvResponse{Results: results})
+	})
+}
+
+// bufferedResponseWriter captures a handler's response in memory.
+type bufferedResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponseWriter) Header() http.Header { return b.header }
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *bufferedResponseWriter) WriteHeader(status int) { b.status = status }
+
+// Cached table names per environment database, used to validate env-targeted agent queries.
+var (
+	envTablesCache   = make(map[string]envTablesEntry)
+	envTablesCacheMu sync.Mutex
+)
+
+type envTablesEntry struct {
+	tables map[string]bool
+	at     time.Time
+}
+
+// DBEnvQuerier implements workflow.EnvQuerier using the per-environment
+// connection pools in config.EnvDBs.
+type DBEnvQuerier struct{}
+
+// NewDBEnvQuerier creates a new DBEnvQuerier.
+func NewDBEnvQuerier() *DBEnvQuerier {
+	return &DBEnvQuerier{}
+}
+
+// NewEnvQuerier returns the querier for the agent's cross-environment queries, or nil when
+// only one environment is configured and there is nothing to query across.
+func NewEnvQuerier() workflow.EnvQuerier {
+	if len(config.AvailableEnvs()) <= 1 {
+		return nil
+	}
+	return NewDBEnvQuerier()
+}
+
+// Envs returns the configured environments.
+func (q *DBEnvQuerier) Envs() []string {
+	envs := configuredEnvs()
+	names := make([]string, len(envs))
+	for i, env := range envs {
+		names[i] = string(env)
+	}
+	return names
+}
+
+// QueryEnv validates the query's table references against the environment's
+// database and executes it there. Validation failures are returned as query
+// errors so the model can correct the query.
+func (q *DBEnvQuerier) QueryEnv(ctx context.Context, env, sql string) (workflow.QueryResult, error) {
+	database, ok := config.DatabaseForEnv(env)
+	if !ok {
+		return workflow.QueryResult{SQL: sql, Error: fmt.Sprintf("env %q is not configured (available: %s)", env, strings.Join(q.Envs(), ", "))}, nil
+	}
+
+	tables, err := envTables(ctx, env, database)
+	if err != nil {
+		return workflow.QueryResult{}, err
+	}
+	if err := validateEnvTableRefs(workflow.ExtractTableRefs(sql), env, database, tables); err != nil {
+		return workflow.QueryResult{SQL: sql, Error: err.Error()}, nil
+	}
+
+	return queryConn(ctx, config.DBForEnv(env), sql)
+}
+
+// validateEnvTableRefs checks that every referenced table belongs to the
+// environment's database and exists there.
+func validateEnvTableRefs(refs []workflow.TableRef, env, database string, tables map[string]bool) error {
+	for _, ref := range refs {
+		if ref.Database != "" && ref.Database != database {
+			return fmt.Errorf("table %s is not in the %s database (%s); use unqualified table names with execute_sql_env", ref, env, database)
+		}
+		if !tables[ref.Table] {
+			return fmt.Errorf("table %s does not exist in the %s database (%s)", ref.Table, env, database)
+		}
+	}
+	return nil
+}
+
+// envTables returns the table names in an environment's database, cached for
+// the same TTL as the agent schema.
+func envTables(ctx context.Context, env, database string) (map[string]bool, error) {
+	envTablesCacheMu.Lock()
+	entry, ok := envTablesCache[env]
+	envTablesCacheMu.Unlock()
+	if ok && time.Since(entry.at) < schemaCacheTTL {
+		return entry.tables, nil
+	}
+
+	start := time.Now()
+	rows, err := config.DBForEnv(env).Query(ctx, `
+		SELECT name
+		FROM system.tables
+		WHERE database = $1
+		  AND name NOT LIKE 'stg_%'
+		  AND name != '_env_lock'
+	`, database)
+	duration := time.Since(start)
+	metrics.RecordClickHouseQuery(duration, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tables for %s: %w", env, err)
+	}
+	defer rows.Close()
+
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	envTablesCacheMu.Lock()
+	envTablesCache[env] = envTablesEntry{tables: tables, at: time.Now()}
+	envTablesCacheMu.Unlock()
+
+	return tables, nil
+}
@@ -2,12 +2,14 @@ package handlers_test
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/handlers"
+	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -84,7 +86,7 @@ func TestBuildEnvContext(t *testing.T) {
 		assert.Contains(t, result, "devnet")
 		assert.Contains(t, result, "lake_devnet.")
 		assert.Contains(t, result, "MUST prefix")
-		assert.Contains(t, result, "Neo4j graph queries")
+		assert.Contains(t, result, "Neo4j graph queries run against the devnet graph")
 	})
 
 	t.Run("different envs produce different context", func(t *testing.T) {
@@ -134,39 +136,52 @@ func TestEnvMiddleware(t *testing.T) {
 	}
 }
 
+// stubNeo4jClient satisfies neo4j.Client for tests that only check availability.
+type stubNeo4jClient struct {
+	neo4j.Client
+}
+
 func TestRequireNeo4jMiddleware(t *testing.T) {
-	t.Parallel()
+	// Not parallel: modifies global config.Neo4jClients
+	origClients := config.Neo4jClients
+	t.Cleanup(func() { config.Neo4jClients = origClients })
 
 	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 	handler := handlers.RequireNeo4jMiddleware(inner)
 
-	t.Run("returns 503 for non-mainnet", func(t *testing.T) {
-		t.Parallel()
+	serve := func(env handlers.DZEnv) *httptest.ResponseRecorder {
 		req := httptest.NewRequest("GET", "/test", nil)
-		ctx := handlers.ContextWithEnv(req.Context(), handlers.EnvDevnet)
-		req = req.WithContext(ctx)
+		req = req.WithContext(handlers.ContextWithEnv(req.Context(), env))
 		rr := httptest.NewRecorder()
 		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("returns 503 for env without a graph", func(t *testing.T) {
+		config.Neo4jClients = map[string]neo4j.Client{"mainnet-beta": stubNeo4jClient{}}
 
+		rr := serve(handlers.EnvDevnet)
 		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
-		assert.Contains(t, rr.Body.String(), "only available on mainnet-beta")
+		assert.Contains(t, rr.Body.String(), "not available for this environment")
 	})
 
-	t.Run("returns 503 when Neo4jClient is nil", func(t *testing.T) {
-		t.Parallel()
-		origClient := config.Neo4jClient
-		config.Neo4jClient = nil
-		t.Cleanup(func() { config.Neo4jClient = origClient })
+	t.Run("passes through for env with a graph", func(t *testing.T) {
+		config.Neo4jClients = map[string]neo4j.Client{
+			"mainnet-beta": stubNeo4jClient{},
+			"devnet":       stubNeo4jClient{},
+		}
 
-		req := httptest.NewRequest("GET", "/test", nil)
-		ctx := handlers.ContextWithEnv(req.Context(), handlers.EnvMainnet)
-		req = req.WithContext(ctx)
-		rr := httptest.NewRecorder()
-		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, serve(handlers.EnvDevnet).Code)
+		assert.Equal(t, http.StatusOK, serve(handlers.EnvMainnet).Code)
+		assert.Equal(t, http.StatusServiceUnavailable, serve(handlers.EnvTestnet).Code)
+	})
 
-		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	t.Run("returns 503 when no clients are configured", func(t *testing.T) {
+		config.Neo4jClients = nil
+
+		assert.Equal(t, http.StatusServiceUnavailable, serve(handlers.EnvMainnet).Code)
 	})
 }
 
@@ -187,3 +202,85 @@ func TestValidEnvs(t *testing.T) {
 		assert.False(t, handlers.ValidEnvs["production"])
 	})
 }
+
+func TestMultiEnvMiddleware(t *testing.T) {
+	// Not parallel: modifies global config.EnvDatabases
+	origEnvDatabases := config.EnvDatabases
+	config.EnvDatabases = map[string]string{
+		"mainnet-beta": "lake_mainnet",
+		"devnet":       "lake_devnet",
+	}
+	t.Cleanup(func() {
+		config.EnvDatabases = origEnvDatabases
+	})
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env := handlers.EnvFromContext(r.Context())
+		if env == handlers.EnvDevnet && r.URL.Query().Get("fail") != "" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"env": string(env), "envs": r.URL.Query().Get("envs")})
+	})
+	handler := handlers.MultiEnvMiddleware(inner)
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("passes through without envs param", func(t *testing.T) {
+		rr := serve("/test")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"env":"mainnet-beta","envs":""}`, rr.Body.String())
+	})
+
+	t.Run("runs handler per env", func(t *testing.T) {
+		rr := serve("/test?envs=devnet,mainnet-beta")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.MultiEnvResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, handlers.EnvDevnet, resp.Results[0].Env)
+		assert.JSONEq(t, `{"env":"devnet","envs":""}`, string(resp.Results[0].Data))
+		assert.Equal(t, handlers.EnvMainnet, resp.Results[1].Env)
+		assert.JSONEq(t, `{"env":"mainnet-beta","envs":""}`, string(resp.Results[1].Data))
+	})
+
+	t.Run("all expands to configured envs", func(t *testing.T) {
+		rr := serve("/test?envs=all")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.MultiEnvResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, handlers.EnvMainnet, resp.Results[0].Env)
+		assert.Equal(t, handlers.EnvDevnet, resp.Results[1].Env)
+	})
+
+	t.Run("per-env errors are reported", func(t *testing.T) {
+		rr := serve("/test?envs=all&fail=1")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.MultiEnvResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 2)
+		assert.Equal(t, http.StatusOK, resp.Results[0].Status)
+		assert.Equal(t, http.StatusInternalServerError, resp.Results[1].Status)
+		assert.Equal(t, "boom", resp.Results[1].Error)
+	})
+
+	t.Run("rejects invalid env", func(t *testing.T) {
+		rr := serve("/test?envs=devnet,production")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("rejects unconfigured env", func(t *testing.T) {
+		rr := serve("/test?envs=testnet")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,793 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
+	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// incidentLookback is how far back signals are read on each correlation pass.
+	incidentLookback = 6 * time.Hour
+	// incidentCorrelationWindow is the largest gap between two signals that still correlate.
+	incidentCorrelationWindow = 15 * time.Minute
+	// incidentQuietPeriod is how long every signal must have been over before an incident resolves.
+	incidentQuietPeriod = 30 * time.Minute
+	// incidentCorrelateInterval is how often the correlator runs.
+	incidentCorrelateInterval = 2 * time.Minute
+)
+
+// incidentDevice is the topology and stake known for a device
+type incidentDevice struct {
+	code      string
+	metroCode string
+	stakeSol  float64
+}
+
+// incidentTopology maps links to the devices they connect and devices to their metro
+type incidentTopology struct {
+	linkDevices map[string][]string
+	devices     map[string]incidentDevice
+}
+
+func newIncidentTopology() *incidentTopology {
+	return &incidentTopology{
+		linkDevices: make(map[string][]string),
+		devices:     make(map[string]incidentDevice),
+	}
+}
+
+// place fills in the devices and metros an event touches from its entity.
+func (t *incidentTopology) place(e *IncidentEvent) {
+	devices := e.DevicePKs
+	switch e.EntityType {
+	case "link":
+		devices = append(devices, t.linkDevices[e.EntityPK]...)
+	case "device":
+		devices = append(devices, e.EntityPK)
+	}
+	e.DevicePKs = uniqueStrings(devices)
+
+	metros := e.MetroCodes
+	for _, pk := range e.DevicePKs {
+		if d, ok := t.devices[pk]; ok && d.metroCode != "" {
+			metros = append(metros, d.metroCode)
+		}
+	}
+	e.MetroCodes = uniqueStrings(metros)
+}
+
+// StartIncidentCorrelator periodically correlates recent outages and events into incidents
+// for every configured environment until ctx is cancelled.
+func StartIncidentCorrelator(ctx context.Context) {
+	ticker := time.NewTicker(incidentCorrelateInterval)
+	go func() {
+		runIncidentCorrelation(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+				runIncidentCorrelation(ctx)
+			}
+		}
+	}()
+}
+
+func runIncidentCorrelation(ctx context.Context) {
+	for _, env := range configuredEnvs() {
+		if ctx.Err() != nil {
+			return
+		}
+		envCtx, cancel := context.WithTimeout(ContextWithEnv(ctx, env), 60*time.Second)
+		if err := correlateIncidents(envCtx, env); err != nil {
+			log.Printf("Incident correlation error (env=%s): %v", env, err)
+		}
+		cancel()
+	}
+}
+
+// correlateIncidents runs one correlation pass: recent signals are grouped, matched to the
+// incidents that already hold any of their events, and the created or changed incidents are
+// written back. Every replica runs the correlator, so a pass holds a transaction-scoped
+// advisory lock for its env, and is skipped while another replica's pass holds it; otherwise
+// two passes could each find no incident for the same signals and both insert one.
+func correlateIncidents(ctx context.Context, env DZEnv) error {
+	start := time.Now()
+	now := start.UTC()
+
+	tx, err := config.PgPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin correlation lock: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext($1))`,
+		"incident_correlation:"+string(env)).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to take correlation lock: %w", err)
+	}
+	if !locked {
+		log.Printf("Incident correlation skipped (env=%s): another replica is correlating", env)
+		return nil
+	}
+
+	topo, err := loadIncidentTopology(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load topology: %w", err)
+	}
+
+	signals, complete := collectIncidentSignals(ctx, now)
+	for i := range signals {
+		topo.place(&signals[i])
+	}
+	tagPlannedSignals(ctx, signals, now)
+
+	existing, err := loadRecentIncidents(ctx, env, now.Add(-incidentLookback))
+	if err != nil {
+		return err
+	}
+
+	planned := planIncidents(existing, correlateIncidentSignals(signals, now), topo, now, complete)
+
+	written := 0
+	for i := range planned {
+		inc := &planned[i]
+		if inc.ID == uuid.Nil {
+			err = insertIncident(ctx, env, inc)
+		} else {
+			err = updateIncident(ctx, inc)
+		}
+		if err != nil {
+			return err
+		}
+		written++
+	}
+
+	log.Printf("Incident correlation completed in %v (env=%s, %d signals, %d incidents written)",
+		time.Since(start), env, len(signals), written)
+	return nil
+}
+
+// collectIncidentSignals reads the outages and events of the lookback window that can open an
+// incident. complete is false if any source failed, in which case incidents whose signals are
+// missing must not be resolved on the strength of this pass.
+func collectIncidentSignals(ctx context.Context, now time.Time) (signals []IncidentEvent, complete bool) {
+	from := now.Add(-incidentLookback)
+	complete = true
+
+	outages, err := fetchIncidentOutages(ctx)
+	if err != nil {
+		log.Printf("Incidents: Failed to fetch link outages: %v", err)
+		complete = false
+	}
+	for _, o := range outages {
+		signals = append(signals, outageSignal(o))
+	}
+
+	episodes, err := queryLinkAnomalyEpisodes(ctx, from, now)
+	if err != nil {
+		log.Printf("Incidents: Failed to fetch link anomalies: %v", err)
+		complete = false
+	}
+	for _, e := range episodes {
+		signals = append(signals, anomalySignal(e, now))
+	}
+
+	interfaceEvents, err := queryInterfaceEvents(ctx, from, now)
+	if err != nil {
+		log.Printf("Incidents: Failed to fetch interface events: %v", err)
+		complete = false
+	}
+	signals = append(signals, interfaceSignals(interfaceEvents)...)
+
+	validatorEvents, err := queryValidatorEvents(ctx, from, now, false)
+	if err != nil {
+		log.Printf("Incidents: Failed to fetch validator events: %v", err)
+		complete = false
+	}
+	signals = append(signals, validatorSignals(validatorEvents)...)
+
+	return signals, complete
+}
+
+// tagPlannedSignals marks events that started during maintenance of their entity or one of
+// its devices. Tagging only adds context, so a failed lookup is logged and leaves events
+// untagged.
+func tagPlannedSignals(ctx context.Context, signals []IncidentEvent, now time.Time) {
+	schedule, err := loadMaintenanceSchedule(ctx, now.Add(-incidentLookback), now)
+	if err != nil {
+		log.Printf("Incidents: Failed to load maintenance windows: %v", err)
+		return
+	}
+	for i := range signals {
+		e := &signals[i]
+		if mw := schedule.window(e.StartedAt, append([]string{e.EntityPK}, e.DevicePKs...)...); mw != nil {
+			id := mw.ID.String()
+			e.Planned = true
+			e.MaintenanceWindowID = &id
+		}
+	}
+}
+
+// fetchIncidentOutages reads status, packet loss and no-data link outages over the lookback window.
+func fetchIncidentOutages(ctx context.Context) ([]LinkOutage, error) {
+	var outages []LinkOutage
+
+	statusOutages, err := fetchStatusOutages(ctx, envDB(ctx), incidentLookback, nil)
+	if err != nil {
+		return nil, err
+	}
+	outages = append(outages, statusOutages...)
+
+	lossOutages, err := fetchPacketLossOutages(ctx, envDB(ctx), incidentLookback, 10.0, nil)
+	if err != nil {
+		return nil, err
+	}
+	outages = append(outages, lossOutages...)
+
+	noDataOutages, err := fetchNoDataOutages(ctx, envDB(ctx), incidentLookback, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(outages, noDataOutages...), nil
+}
+
+// outageSignal converts a link outage. Outage IDs are only unique within a response, so the
+// signal ID is derived from the link, start and kind instead.
+func outageSignal(o LinkOutage) IncidentEvent {
+	startedAt, _ := time.Parse(time.RFC3339, o.StartedAt)
+	var endedAt *time.Time
+	if o.EndedAt != nil {
+		if t, err := time.Parse(time.RFC3339, *o.EndedAt); err == nil {
+			endedAt = &t
+		}
+	}
+
+	kind, title := "link_"+o.OutageType, fmt.Sprintf("Outage on %s", o.LinkCode)
+	switch o.OutageType {
+	case "status":
+		kind, title = "link_drained", fmt.Sprintf("%s drained", o.LinkCode)
+	case "packet_loss":
+		title = fmt.Sprintf("Packet loss on %s", o.LinkCode)
+	case "no_data":
+		title = fmt.Sprintf("No telemetry from %s", o.LinkCode)
+	}
+
+	severity := "warning"
+	if o.Severity == "outage" {
+		severity = "critical"
+	}
+
+	return IncidentEvent{
+		ID:         generateEventID(o.LinkPK, startedAt, kind),
+		Kind:       kind,
+		Severity:   severity,
+		Title:      title,
+		EntityType: "link",
+		EntityPK:   o.LinkPK,
+		EntityCode: o.LinkCode,
+		StartedAt:  startedAt,
+		EndedAt:    endedAt,
+		MetroCodes: uniqueStrings([]string{o.SideAMetro, o.SideZMetro}),
+	}
+}
+
+// anomalySignal converts a link anomaly episode, keeping the ID of its timeline started event.
+func anomalySignal(e linkAnomalyEpisode, now time.Time) IncidentEvent {
+	label, prefix := linkAnomalyMetricLabel(e.metric)
+	var endedAt *time.Time
+	if !e.ongoing(now) {
+		end := e.end
+		endedAt = &end
+	}
+	return IncidentEvent{
+		ID:         generateEventID(e.linkPK, e.start, prefix+"_anomaly_started"),
+		Kind:       prefix + "_anomaly",
+		Severity:   e.severity,
+		Title:      fmt.Sprintf("%s anomaly on %s", label, e.linkCode),
+		EntityType: "link",
+		EntityPK:   e.linkPK,
+		EntityCode: e.linkCode,
+		StartedAt:  e.start,
+		EndedAt:    endedAt,
+		MetroCodes: uniqueStrings([]string{e.sideAMetro, e.sideZMetro}),
+	}
+}
+
+// interfaceSignals pairs interface error and carrier started events with the stopped event
+// that follows them on the same interface. Discards alone don't open incidents.
+func interfaceSignals(events []TimelineEvent) []IncidentEvent {
+	sorted := make([]TimelineEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	var signals []IncidentEvent
+	open := make(map[string]int) // device/interface/issue -> index into signals
+	for _, e := range sorted {
+		details, ok := e.Details.(InterfaceEventDetails)
+		if !ok || (details.IssueType != "errors" && details.IssueType != "carrier") {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		key := details.DevicePK + "/" + details.InterfaceName + "/" + details.IssueType
+
+		if e.Severity == "success" {
+			if i, ok := open[key]; ok {
+				signals[i].EndedAt = &ts
+				delete(open, key)
+			}
+			continue
+		}
+
+		open[key] = len(signals)
+		signals = append(signals, IncidentEvent{
+			ID:         e.ID,
+			Kind:       "interface_" + details.IssueType,
+			Severity:   e.Severity,
+			Title:      e.Title,
+			EntityType: "device",
+			EntityPK:   details.DevicePK,
+			EntityCode: details.DeviceCode,
+			StartedAt:  ts,
+			MetroCodes: uniqueStrings([]string{details.MetroCode}),
+		})
+	}
+	return signals
+}
+
+// validatorSignals keeps validators disconnecting from DZ. They are instantaneous and carry
+// the stake that left.
+func validatorSignals(events []TimelineEvent) []IncidentEvent {
+	var signals []IncidentEvent
+	for _, e := range events {
+		details, ok := e.Details.(ValidatorEventDetails)
+		if !ok || e.EventType != "validator_left_dz" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		signals = append(signals, IncidentEvent{
+			ID:         e.ID,
+			Kind:       "validator_left",
+			Severity:   e.Severity,
+			Title:      e.Title,
+			EntityType: e.EntityType,
+			EntityPK:   e.EntityPK,
+			EntityCode: e.EntityCode,
+			StartedAt:  ts,
+			EndedAt:    &ts,
+			StakeSol:   details.StakeSol,
+			DevicePKs:  uniqueStrings([]string{details.DevicePK}),
+			MetroCodes: uniqueStrings([]string{details.MetroCode}),
+		})
+	}
+	return signals
+}
+
+// loadIncidentTopology reads link endpoints and device metros from the graph when one is
+// configured, falling back to ClickHouse, and the stake connected through each device.
+func loadIncidentTopology(ctx context.Context) (*incidentTopology, error) {
+	topo := newIncidentTopology()
+
+	var err error
+	if envNeo4jClient(ctx) != nil {
+		err = loadIncidentTopologyFromGraph(ctx, topo)
+	} else {
+		err = loadIncidentTopologyFromClickHouse(ctx, topo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			u.device_pk,
+			sum(v.activated_stake_lamports) / 1e9 as stake_sol
+		FROM dz_users_current u
+		JOIN solana_gossip_nodes_current g ON u.dz_ip = g.gossip_ip
+		JOIN solana_vote_accounts_current v ON g.pubkey = v.node_pubkey
+		WHERE u.status = 'activated' AND v.epoch_vote_account = 'true'
+		GROUP BY u.device_pk
+	`
+	queryStart := time.Now()
+	rows, err := envDB(ctx).Query(ctx, query)
+	metrics.RecordClickHouseQuery(time.Since(queryStart), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query device stake: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var devicePK string
+		var stakeSol float64
+		if err := rows.Scan(&devicePK, &stakeSol); err != nil {
+			return nil, fmt.Errorf("failed to scan device stake: %w", err)
+		}
+		d := topo.devices[devicePK]
+		d.stakeSol = stakeSol
+		topo.devices[devicePK] = d
+	}
+	return topo, rows.Err()
+}
+
+func loadIncidentTopologyFromGraph(ctx context.Context, topo *incidentTopology) error {
+	session := envNeo4jSession(ctx)
+	defer session.Close(ctx)
+
+	cypher := `
+		MATCH (d:Device)
+		OPTIONAL MATCH (d)-[:LOCATED_IN]->(m:Metro)
+		OPTIONAL MATCH (l:Link)-[:CONNECTS]->(d)
+		RETURN d.pk AS device_pk, d.code AS device_code, m.code AS metro_code, collect(l.pk) AS link_pks
+	`
+	records, err := session.ExecuteRead(ctx, func(tx neo4j.Transaction) (any, error) {
+		res, err := tx.Run(ctx, cypher, nil)
+		if err != nil {
+			return nil, err
+		}
+		return res.Collect(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query graph topology: %w", err)
+	}
+
+	for _, record := range records.([]*neo4jdriver.Record) {
+		devicePK, _ := record.Get("device_pk")
+		deviceCode, _ := record.Get("device_code")
+		metroCode, _ := record.Get("metro_code")
+		linkPKs, _ := record.Get("link_pks")
+
+		pk := asString(devicePK)
+		topo.devices[pk] = incidentDevice{code: asString(deviceCode), metroCode: asString(metroCode)}
+		if links, ok := linkPKs.([]any); ok {
+			for _, l := range links {
+				topo.linkDevices[asString(l)] = append(topo.linkDevices[asString(l)], pk)
+			}
+		}
+	}
+	return nil
+}
+
+func loadIncidentTopologyFromClickHouse(ctx context.Context, topo *incidentTopology) error {
+	query := `
+		SELECT d.pk, d.code, COALESCE(m.code, '') as metro_code
+		FROM dz_devices_current d
+		LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+	`
+	queryStart := time.Now()
+	rows, err := envDB(ctx).Query(ctx, query)
+	metrics.RecordClickHouseQuery(time.Since(queryStart), err)
+	if err != nil {
+		return fmt.Errorf("failed to query devices: %w", err)
+	}
+	for rows.Next() {
+		var pk, code, metroCode string
+		if err := rows.Scan(&pk, &code, &metroCode); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan device: %w", err)
+		}
+		topo.devices[pk] = incidentDevice{code: code, metroCode: metroCode}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query = `SELECT pk, side_a_pk, side_z_pk FROM dz_links_current`
+	queryStart = time.Now()
+	rows, err = envDB(ctx).Query(ctx, query)
+	metrics.RecordClickHouseQuery(time.Since(queryStart), err)
+	if err != nil {
+		return fmt.Errorf("failed to query links: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pk, sideA, sideZ string
+		if err := rows.Scan(&pk, &sideA, &sideZ); err != nil {
+			return fmt.Errorf("failed to scan link: %w", err)
+		}
+		topo.linkDevices[pk] = uniqueStrings([]string{sideA, sideZ})
+	}
+	return rows.Err()
+}
+
+// correlateIncidentSignals groups signals that are close in time and share a device or metro.
+// Correlation is transitive, so a device outage joins the link and validator signals around
+// it into one group. Groups and the signals within them are ordered by start.
+func correlateIncidentSignals(signals []IncidentEvent, now time.Time) [][]IncidentEvent {
+	parent := make([]int, len(signals))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range signals {
+		for j := i + 1; j < len(signals); j++ {
+			if signalsOverlap(signals[i], signals[j], now) && signalsShareTopology(signals[i], signals[j]) {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	byRoot := make(map[int][]IncidentEvent)
+	var roots []int
+	for i, s := range signals {
+		root := find(i)
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], s)
+	}
+
+	groups := make([][]IncidentEvent, 0, len(roots))
+	for _, root := range roots {
+		group := byRoot[root]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].StartedAt.Before(group[j].StartedAt) })
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i][0].StartedAt.Before(groups[j][0].StartedAt) })
+	return groups
+}
+
+func signalEnd(e IncidentEvent, now time.Time) time.Time {
+	if e.EndedAt == nil {
+		return now
+	}
+	return *e.EndedAt
+}
+
+func signalsOverlap(a, b IncidentEvent, now time.Time) bool {
+	return !a.StartedAt.After(signalEnd(b, now).Add(incidentCorrelationWindow)) &&
+		!b.StartedAt.After(signalEnd(a, now).Add(incidentCorrelationWindow))
+}
+
+func signalsShareTopology(a, b IncidentEvent) bool {
+	return intersects(a.DevicePKs, b.DevicePKs) || intersects(a.MetroCodes, b.MetroCodes)
+}
+
+// planIncidents matches correlated groups to existing incidents by event ID and returns the
+// incidents to write: new ones (zero ID) and existing ones whose summary changed. Events an
+// incident already holds are kept even after they age out of the lookback. Open incidents
+// without current signals are resolved only when the signal read was complete.
+func planIncidents(existing []Incident, groups [][]IncidentEvent, topo *incidentTopology, now time.Time, complete bool) []Incident {
+	byEvent := make(map[string]int)
+	for i, inc := range existing {
+		for _, e := range inc.Events {
+			byEvent[e.ID] = i
+		}
+	}
+
+	// Merge each group into the earliest existing incident holding any of its events
+	merged := make(map[int][]IncidentEvent)
+	var created [][]IncidentEvent
+	for _, group := range groups {
+		match := -1
+		for _, e := range group {
+			if i, ok := byEvent[e.ID]; ok && (match < 0 || existing[i].StartedAt.Before(existing[match].StartedAt)) {
+				match = i
+			}
+		}
+		if match < 0 {
+			created = append(created, group)
+			continue
+		}
+		merged[match] = append(merged[match], group...)
+	}
+
+	var planned []Incident
+	for i, inc := range existing {
+		events, ok := merged[i]
+		if !ok && (inc.Status != "open" || !complete) {
+			continue
+		}
+
+		next := inc
+		next.Events = mergeIncidentEvents(inc.Events, events)
+		if !ok {
+			// Nothing current backs the incident anymore, so none of its events are ongoing
+			for j := range next.Events {
+				if next.Events[j].EndedAt == nil {
+					end := now
+					next.Events[j].EndedAt = &end
+				}
+			}
+		}
+		summarizeIncident(&next, topo, now, !ok)
+		if inc.Status == "resolved" && next.Status == "resolved" {
+			// Stake is what was affected at the time, not what the devices carry today
+			next.StakeAffectedSol = inc.StakeAffectedSol
+		}
+		if incidentChanged(inc, next) {
+			planned = append(planned, next)
+		}
+	}
+
+	for _, group := range created {
+		var inc Incident
+		inc.Events = mergeIncidentEvents(nil, group)
+		summarizeIncident(&inc, topo, now, false)
+		planned = append(planned, inc)
+	}
+	return planned
+}
+
+// mergeIncidentEvents adds or replaces events by ID, ordered by start.
+func mergeIncidentEvents(current, updates []IncidentEvent) []IncidentEvent {
+	byID := make(map[string]int, len(current)+len(updates))
+	merged := make([]IncidentEvent, 0, len(current)+len(updates))
+	for _, e := range append(append([]IncidentEvent{}, current...), updates...) {
+		if i, ok := byID[e.ID]; ok {
+			merged[i] = e
+			continue
+		}
+		byID[e.ID] = len(merged)
+		merged = append(merged, e)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].StartedAt.Before(merged[j].StartedAt) })
+	return merged
+}
+
+// summarizeIncident derives status, severity, title, time span, impacted entities and stake
+// from the incident's events. An incident stays open until every event has been over for the
+// quiet period, unless forceResolve is set.
+func summarizeIncident(inc *Incident, topo *incidentTopology, now time.Time, forceResolve bool) {
+	events := inc.Events
+	if len(events) == 0 {
+		return
+	}
+
+	inc.StartedAt = events[0].StartedAt
+	inc.Severity = "warning"
+	ongoing := false
+	var lastEnd time.Time
+	for _, e := range events {
+		if e.Severity == "critical" {
+			inc.Severity = "critical"
+		}
+		if e.EndedAt == nil {
+			ongoing = true
+		} else if e.EndedAt.After(lastEnd) {
+			lastEnd = *e.EndedAt
+		}
+	}
+
+	if !forceResolve && (ongoing || now.Sub(lastEnd) < incidentQuietPeriod) {
+		inc.Status = "open"
+		inc.EndedAt = nil
+	} else {
+		inc.Status = "resolved"
+		end := maxTime(lastEnd, inc.StartedAt)
+		inc.EndedAt = &end
+	}
+
+	inc.ImpactedEntities, inc.StakeAffectedSol = incidentImpact(events, topo)
+	inc.Title = incidentTitle(events, topo)
+}
+
+// incidentImpact lists the entities the events touch, and the stake affected: the stake
+// connected through impacted devices plus stake of validators that disconnected.
+func incidentImpact(events []IncidentEvent, topo *incidentTopology) ([]IncidentEntity, float64) {
+	entities := []IncidentEntity{}
+	seen := make(map[IncidentEntity]bool)
+	add := func(e IncidentEntity) {
+		if e.PK == "" || seen[e] {
+			return
+		}
+		seen[e] = true
+		entities = append(entities, e)
+	}
+
+	var stake float64
+	devices := make(map[string]bool)
+	for _, e := range events {
+		add(IncidentEntity{Type: e.EntityType, PK: e.EntityPK, Code: e.EntityCode})
+		stake += e.StakeSol
+		for _, pk := range e.DevicePKs {
+			add(IncidentEntity{Type: "device", PK: pk, Code: topo.devices[pk].code})
+			if !devices[pk] {
+				devices[pk] = true
+				stake += topo.devices[pk].stakeSol
+			}
+		}
+		for _, code := range e.MetroCodes {
+			add(IncidentEntity{Type: "metro", PK: code, Code: code})
+		}
+	}
+	return entities, stake
+}
+
+// incidentTitle uses the only event's title, or names the device or metro every event shares.
+func incidentTitle(events []IncidentEvent, topo *incidentTopology) string {
+	if len(events) == 1 {
+		return events[0].Title
+	}
+
+	devices, metros := events[0].DevicePKs, events[0].MetroCodes
+	for _, e := range events[1:] {
+		devices = intersection(devices, e.DevicePKs)
+		metros = intersection(metros, e.MetroCodes)
+	}
+
+	scope := "multiple locations"
+	switch {
+	case len(devices) > 0:
+		code := topo.devices[devices[0]].code
+		if code == "" {
+			code = devices[0]
+		}
+		scope = code
+	case len(metros) > 0:
+		scope = metros[0]
+	}
+	return fmt.Sprintf("%d correlated events at %s", len(events), scope)
+}
+
+// incidentChanged reports whether planning changed anything that is persisted.
+func incidentChanged(before, after Incident) bool {
+	if before.Status != after.Status || before.Severity != after.Severity || before.Title != after.Title ||
+		!before.StartedAt.Equal(after.StartedAt) || before.StakeAffectedSol != after.StakeAffectedSol ||
+		len(before.Events) != len(after.Events) || len(before.ImpactedEntities) != len(after.ImpactedEntities) {
+		return true
+	}
+	if (before.EndedAt == nil) != (after.EndedAt == nil) || (before.EndedAt != nil && !before.EndedAt.Equal(*after.EndedAt)) {
+		return true
+	}
+	for i := range before.Events {
+		b, a := before.Events[i], after.Events[i]
+		if b.ID != a.ID || b.Severity != a.Severity || (b.EndedAt == nil) != (a.EndedAt == nil) ||
+			(b.EndedAt != nil && !b.EndedAt.Equal(*a.EndedAt)) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var out []string
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+func intersects(a, b []string) bool {
+	return len(intersection(a, b)) > 0
+}
+
+func intersection(a, b []string) []string {
+	var out []string
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				out = append(out, x)
+				break
+			}
+		}
+	}
+	return out
+}
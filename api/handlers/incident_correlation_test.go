@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testIncidentTopology() *incidentTopology {
+	topo := newIncidentTopology()
+	topo.devices["dev-fra"] = incidentDevice{code: "fra-dz1", metroCode: "fra", stakeSol: 1000}
+	topo.devices["dev-ams"] = incidentDevice{code: "ams-dz1", metroCode: "ams", stakeSol: 500}
+	topo.devices["dev-nyc"] = incidentDevice{code: "nyc-dz1", metroCode: "nyc", stakeSol: 200}
+	topo.linkDevices["link-fra-ams"] = []string{"dev-fra", "dev-ams"}
+	return topo
+}
+
+func TestCorrelateIncidentSignals(t *testing.T) {
+	t.Parallel()
+
+	topo := testIncidentTopology()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(d)
+		return &ts
+	}
+
+	signals := []IncidentEvent{
+		{ID: "outage", EntityType: "link", EntityPK: "link-fra-ams", StartedAt: now.Add(-2 * time.Hour), EndedAt: at(-90 * time.Minute)},
+		// Same device, starts within the correlation window after the outage ends
+		{ID: "carrier", EntityType: "device", EntityPK: "dev-fra", StartedAt: now.Add(-80 * time.Minute), EndedAt: at(-70 * time.Minute)},
+		// Same time, unrelated metro
+		{ID: "validator", EntityType: "validator", EntityPK: "vote1", StartedAt: now.Add(-85 * time.Minute), EndedAt: at(-85 * time.Minute),
+			DevicePKs: []string{"dev-nyc"}},
+		// Same metro, but hours later and still ongoing
+		{ID: "loss", EntityType: "device", EntityPK: "dev-ams", StartedAt: now.Add(-10 * time.Minute)},
+	}
+	for i := range signals {
+		topo.place(&signals[i])
+	}
+	assert.Equal(t, []string{"dev-fra", "dev-ams"}, signals[0].DevicePKs)
+	assert.Equal(t, []string{"fra", "ams"}, signals[0].MetroCodes)
+
+	groups := correlateIncidentSignals(signals, now)
+	require.Len(t, groups, 3)
+	ids := func(group []IncidentEvent) []string {
+		var out []string
+		for _, e := range group {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"outage", "carrier"}, ids(groups[0]))
+	assert.Equal(t, []string{"validator"}, ids(groups[1]))
+	assert.Equal(t, []string{"loss"}, ids(groups[2]))
+}
+
+func TestPlanIncidents(t *testing.T) {
+	t.Parallel()
+
+	topo := testIncidentTopology()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Hour)
+
+	outage := IncidentEvent{ID: "outage", Severity: "critical", Title: "fra-ams drained", EntityType: "link", EntityPK: "link-fra-ams",
+		EntityCode: "fra-ams", StartedAt: now.Add(-2 * time.Hour), EndedAt: &ended}
+	carrier := IncidentEvent{ID: "carrier", Severity: "warning", Title: "Carrier transitions on fra-dz1", EntityType: "device",
+		EntityPK: "dev-fra", EntityCode: "fra-dz1", StartedAt: now.Add(-10 * time.Minute)}
+	validator := IncidentEvent{ID: "validator", Severity: "warning", Title: "Validator left DZ", EntityType: "validator",
+		EntityPK: "vote1", EntityCode: "vote1", StartedAt: now.Add(-5 * time.Minute), StakeSol: 50, DevicePKs: []string{"dev-nyc"}}
+	for _, e := range []*IncidentEvent{&outage, &carrier, &validator} {
+		topo.place(e)
+	}
+
+	existingID := uuid.New()
+	existing := []Incident{{ID: existingID, Status: "open", Severity: "critical", Title: outage.Title,
+		StartedAt: outage.StartedAt, Events: []IncidentEvent{outage}}}
+
+	// Groups holding no stored event open new incidents, and the stored incident no longer
+	// backed by any signal resolves at its last event's end
+	planned := planIncidents(existing, [][]IncidentEvent{{carrier}, {validator}}, topo, now, true)
+	require.Len(t, planned, 3)
+
+	assert.Equal(t, existingID, planned[0].ID)
+	assert.Equal(t, "resolved", planned[0].Status)
+	require.NotNil(t, planned[0].EndedAt)
+	assert.True(t, planned[0].EndedAt.Equal(ended))
+
+	assert.Equal(t, uuid.Nil, planned[1].ID)
+	assert.Equal(t, "open", planned[1].Status)
+	assert.Equal(t, "Carrier transitions on fra-dz1", planned[1].Title)
+	assert.Equal(t, 1000.0, planned[1].StakeAffectedSol)
+
+	// Disconnected stake counts on top of the stake still on the device
+	assert.Equal(t, 250.0, planned[2].StakeAffectedSol)
+
+	// Once a group contains a stored event, the incident absorbs the rest of the group
+	planned = planIncidents(existing, [][]IncidentEvent{{outage, carrier}}, topo, now, true)
+	require.Len(t, planned, 1)
+	inc := planned[0]
+	assert.Equal(t, existingID, inc.ID)
+	assert.Equal(t, "open", inc.Status)
+	assert.Nil(t, inc.EndedAt)
+	assert.Equal(t, "critical", inc.Severity)
+	assert.Equal(t, "2 correlated events at fra-dz1", inc.Title)
+	assert.Len(t, inc.Events, 2)
+	// fra and ams devices carry 1500 SOL between them
+	assert.Equal(t, 1500.0, inc.StakeAffectedSol)
+	assert.Contains(t, inc.ImpactedEntities, IncidentEntity{Type: "metro", PK: "ams", Code: "ams"})
+
+	// Nothing changes when the group matches what is stored
+	planned = planIncidents([]Incident{inc}, [][]IncidentEvent{{outage, carrier}}, topo, now, true)
+	assert.Empty(t, planned)
+
+	// An incomplete read never resolves an open incident
+	planned = planIncidents([]Incident{inc}, nil, topo, now, false)
+	assert.Empty(t, planned)
+	planned = planIncidents([]Incident{inc}, nil, topo, now, true)
+	require.Len(t, planned, 1)
+	assert.Equal(t, "resolved", planned[0].Status)
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/api/config"
+)
+
+// Incident groups outages and events that are correlated in time and share a device or metro
+type Incident struct {
+	ID               uuid.UUID        `json:"id"`
+	Env              string           `json:"env"`
+	Status           string           `json:"status"`   // "open" or "resolved"
+	Severity         string           `json:"severity"` // "warning" or "critical"
+	Title            string           `json:"title"`
+	StartedAt        time.Time        `json:"started_at"`
+	EndedAt          *time.Time       `json:"ended_at,omitempty"`
+	ImpactedEntities []IncidentEntity `json:"impacted_entities"`
+	Events           []IncidentEvent  `json:"events"`
+	StakeAffectedSol float64          `json:"stake_affected_sol"`
+	RCAURL           *string          `json:"rca_url,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	Notes            []IncidentNote   `json:"notes,omitempty"`
+}
+
+// IncidentEntity is a link, device, metro or validator an incident touches
+type IncidentEntity struct {
+	Type string `json:"type"`
+	PK   string `json:"pk"`
+	Code string `json:"code,omitempty"`
+}
+
+// IncidentEvent is one correlated outage or event within an incident
+type IncidentEvent struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"` // e.g. "link_drained", "packet_loss", "rtt_anomaly", "interface_carrier", "validator_left"
+	Severity   string     `json:"severity"`
+	Title      string     `json:"title"`
+	EntityType string     `json:"entity_type"`
+	EntityPK   string     `json:"entity_pk"`
+	EntityCode string     `json:"entity_code"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	StakeSol   float64    `json:"stake_sol,omitempty"`
+	DevicePKs  []string   `json:"device_pks,omitempty"`
+	MetroCodes []string   `json:"metro_codes,omitempty"`
+	// Planned is set when the event started during a maintenance window covering its entity
+	Planned             bool    `json:"planned,omitempty"`
+	MaintenanceWindowID *string `json:"maintenance_window_id,omitempty"`
+}
+
+// IncidentNote is a manual annotation on an incident
+type IncidentNote struct {
+	ID        uuid.UUID `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IncidentListResponse is the response for listing incidents
+type IncidentListResponse struct {
+	Incidents []Incident `json:"incidents"`
+	Total     int        `json:"total"`
+	HasMore   bool       `json:"has_more"`
+}
+
+// UpdateIncidentRequest is the request body for updating an incident
+type UpdateIncidentRequest struct {
+	RCAURL *string `json:"rca_url"`
+}
+
+// CreateIncidentNoteRequest is the request body for annotating an incident
+type CreateIncidentNoteRequest struct {
+	Body string `json:"body"`
+}
+
+const incidentColumns = `id, env, status, severity, title, started_at, ended_at, impacted_entities, events,
+	stake_affected_sol, rca_url, created_at, updated_at`
+
+// ListIncidents returns incidents for the environment, most recent first
+func ListIncidents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "open" && status != "resolved" {
+		http.Error(w, "status query parameter must be 'open' or 'resolved'", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	ctx := r.Context()
+	env := string(EnvFromContext(ctx))
+
+	var total int
+	err := config.PgPool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM incidents WHERE env = $1 AND ($2 = '' OR status = $2)
+	`, env, status).Scan(&total)
+	if err != nil {
+		http.Error(w, internalError("Failed to count incidents", err), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := config.PgPool.Query(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE env = $1 AND ($2 = '' OR status = $2)
+		ORDER BY started_at DESC, id ASC
+		LIMIT $3 OFFSET $4
+	`, env, status, limit, offset)
+	if err != nil {
+		http.Error(w, internalError("Failed to list incidents", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	incidents := []Incident{}
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			http.Error(w, internalError("Failed to scan incident", err), http.StatusInternalServerError)
+			return
+		}
+		incidents = append(incidents, inc)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, internalError("Failed to iterate incidents", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(IncidentListResponse{
+		Incidents: incidents,
+		Total:     total,
+		HasMore:   offset+len(incidents) < total,
+	})
+}
+
+// GetIncident returns a single incident of the environment with its notes
+func GetIncident(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid incident ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	inc, err := getIncident(ctx, EnvFromContext(ctx), id)
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			http.Error(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, internalError("Failed to get incident", err), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := config.PgPool.Query(ctx, `
+		SELECT id, author, body, created_at
+		FROM incident_notes
+		WHERE incident_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, id)
+	if err != nil {
+		http.Error(w, internalError("Failed to list incident notes", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	inc.Notes = []IncidentNote{}
+	for rows.Next() {
+		var n IncidentNote
+		if err := rows.Scan(&n.ID, &n.Author, &n.Body, &n.CreatedAt); err != nil {
+			http.Error(w, internalError("Failed to scan incident note", err), http.StatusInternalServerError)
+			return
+		}
+		inc.Notes = append(inc.Notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, internalError("Failed to iterate incident notes", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(inc)
+}
+
+// UpdateIncident sets or clears the root cause analysis link of an incident of the environment
+func UpdateIncident(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid incident ID", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RCAURL != nil {
+		trimmed := strings.TrimSpace(*req.RCAURL)
+		if trimmed == "" {
+			req.RCAURL = nil
+		} else if u, err := url.ParseRequestURI(trimmed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			http.Error(w, "rca_url must be an http or https URL", http.StatusBadRequest)
+			return
+		} else {
+			req.RCAURL = &trimmed
+		}
+	}
+
+	ctx := r.Context()
+	env := EnvFromContext(ctx)
+	tag, err := config.PgPool.Exec(ctx, `UPDATE incidents SET rca_url = $2 WHERE id = $1 AND env = $3`, id, req.RCAURL, string(env))
+	if err != nil {
+		http.Error(w, internalError("Failed to update incident", err), http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	inc, err := getIncident(ctx, env, id)
+	if err != nil {
+		http.Error(w, internalError("Failed to get incident", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(inc)
+}
+
+// CreateIncidentNote adds a note to an incident of the environment, authored by the signed-in
+// account.
+func CreateIncidentNote(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid incident ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateIncidentNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		http.Error(w, "body is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	accountID, author := accountAuthor(ctx)
+	if accountID == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if author == "" {
+		author = accountID.String()
+	}
+
+	var note IncidentNote
+	err = config.PgPool.QueryRow(ctx, `
+		INSERT INTO incident_notes (incident_id, account_id, author, body)
+		SELECT id, $2, $3, $4 FROM incidents WHERE id = $1 AND env = $5
+		RETURNING id, author, body, created_at
+	`, id, accountID, author, req.Body, string(EnvFromContext(ctx))).Scan(&note.ID, &note.Author, &note.Body, &note.CreatedAt)
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			http.Error(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, internalError("Failed to create incident note", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(note)
+}
+
+type incidentScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanIncident(row incidentScanner) (Incident, error) {
+	var inc Incident
+	var entities, events []byte
+	if err := row.Scan(&inc.ID, &inc.Env, &inc.Status, &inc.Severity, &inc.Title, &inc.StartedAt, &inc.EndedAt,
+		&entities, &events, &inc.StakeAffectedSol, &inc.RCAURL, &inc.CreatedAt, &inc.UpdatedAt); err != nil {
+		return inc, err
+	}
+	if err := json.Unmarshal(entities, &inc.ImpactedEntities); err != nil {
+		return inc, fmt.Errorf("failed to decode impacted entities: %w", err)
+	}
+	if err := json.Unmarshal(events, &inc.Events); err != nil {
+		return inc, fmt.Errorf("failed to decode events: %w", err)
+	}
+	return inc, nil
+}
+
+func getIncident(ctx context.Context, env DZEnv, id uuid.UUID) (Incident, error) {
+	return scanIncident(config.PgPool.QueryRow(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = $1 AND env = $2`, id, string(env)))
+}
+
+// loadRecentIncidents returns the environment's open incidents and those that ended since since.
+func loadRecentIncidents(ctx context.Context, env DZEnv, since time.Time) ([]Incident, error) {
+	rows, err := config.PgPool.Query(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE env = $1 AND (status = 'open' OR ended_at >= $2)
+		ORDER BY started_at ASC
+	`, string(env), since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []Incident
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, rows.Err()
+}
+
+func insertIncident(ctx context.Context, env DZEnv, inc *Incident) error {
+	entities, events, err := marshalIncidentDetails(inc)
+	if err != nil {
+		return err
+	}
+	err = config.PgPool.QueryRow(ctx, `
+		INSERT INTO incidents (env, status, severity, title, started_at, ended_at, impacted_entities, events, stake_affected_sol)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, string(env), inc.Status, inc.Severity, inc.Title, inc.StartedAt, inc.EndedAt, entities, events,
+		inc.StakeAffectedSol).Scan(&inc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert incident: %w", err)
+	}
+	return nil
+}
+
+func updateIncident(ctx context.Context, inc *Incident) error {
+	entities, events, err := marshalIncidentDetails(inc)
+	if err != nil {
+		return err
+	}
+	_, err = config.PgPool.Exec(ctx, `
+		UPDATE incidents
+		SET status = $2, severity = $3, title = $4, started_at = $5, ended_at = $6,
+		    impacted_entities = $7, events = $8, stake_affected_sol = $9
+		WHERE id = $1
+	`, inc.ID, inc.Status, inc.Severity, inc.Title, inc.StartedAt, inc.EndedAt, entities, events, inc.StakeAffectedSol)
+	if err != nil {
+		return fmt.Errorf("failed to update incident: %w", err)
+	}
+	return nil
+}
+
+func marshalIncidentDetails(inc *Incident) (entities, events json.RawMessage, err error) {
+	entities, err = json.Marshal(inc.ImpactedEntities)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode impacted entities: %w", err)
+	}
+	events, err = json.Marshal(inc.Events)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode events: %w", err)
+	}
+	return entities, events, nil
+}
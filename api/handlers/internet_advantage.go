@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/malbeclabs/lake/api/handlers/dberror"
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+const (
+	// defaultAdvantageEpochs is the number of recent epochs returned when none is requested.
+	defaultAdvantageEpochs = 30
+	// advantageTrendThresholdPct is the change in median improvement, in percentage points
+	// over the window, that counts as improving or declining.
+	advantageTrendThresholdPct = 2.0
+)
+
+// InternetAdvantageEpoch is how much faster DZ was than the public internet between two metros
+// over one epoch. Improvements are internet minus DZ, so positive means DZ is faster.
+type InternetAdvantageEpoch struct {
+	Epoch                int64     `json:"epoch"`
+	StartTs              time.Time `json:"start_ts"`
+	EndTs                time.Time `json:"end_ts"`
+	DzP50RttMs           float64   `json:"dz_p50_rtt_ms"`
+	DzP95RttMs           float64   `json:"dz_p95_rtt_ms"`
+	DzP99RttMs           float64   `json:"dz_p99_rtt_ms"`
+	DzSampleCount        uint64    `json:"dz_sample_count"`
+	InternetP50RttMs     float64   `json:"internet_p50_rtt_ms"`
+	InternetP95RttMs     float64   `json:"internet_p95_rtt_ms"`
+	InternetP99RttMs     float64   `json:"internet_p99_rtt_ms"`
+	InternetSampleCount  uint64    `json:"internet_sample_count"`
+	MedianImprovementMs  float64   `json:"median_improvement_ms"`
+	MedianImprovementPct float64   `json:"median_improvement_pct"`
+	P95ImprovementMs     float64   `json:"p95_improvement_ms"`
+	P95ImprovementPct    float64   `json:"p95_improvement_pct"`
+	P99ImprovementMs     float64   `json:"p99_improvement_ms"`
+	P99ImprovementPct    float64   `json:"p99_improvement_pct"`
+	ComparedHours        uint64    `json:"compared_hours"`
+	DzWinPct             *float64  `json:"dz_win_pct"`            // share of compared hours DZ was faster
+	ImprovementStddevMs  *float64  `json:"improvement_stddev_ms"` // spread of the hourly improvement
+}
+
+// InternetAdvantagePair is the per-epoch DZ advantage between two metros and its trend.
+type InternetAdvantagePair struct {
+	OriginMetroCode string                   `json:"origin_metro_code"`
+	TargetMetroCode string                   `json:"target_metro_code"`
+	Epochs          []InternetAdvantageEpoch `json:"epochs"`
+	// Latest epoch's median improvement, and its fitted change over the window in percentage
+	// points
+	MedianImprovementPct       float64 `json:"median_improvement_pct"`
+	MedianImprovementChangePct float64 `json:"median_improvement_change_pct"`
+	Trend                      string  `json:"trend"` // "improving", "declining" or "stable"
+}
+
+// InternetAdvantageResponse is the response for the DZ vs internet advantage history.
+type InternetAdvantageResponse struct {
+	Epochs  int                     `json:"epochs"`
+	Pairs   []InternetAdvantagePair `json:"pairs"`
+	Summary struct {
+		TotalPairs int `json:"total_pairs"`
+		Improving  int `json:"improving"`
+		Declining  int `json:"declining"`
+	} `json:"summary"`
+}
+
+// GetInternetAdvantage returns the DZ vs public internet advantage per metro pair for each of
+// the recent epochs, optionally for one pair given by its origin and target metro codes.
+func GetInternetAdvantage(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	epochs := defaultAdvantageEpochs
+	if e := r.URL.Query().Get("epochs"); e != "" {
+		if v, err := strconv.Atoi(e); err == nil && v > 0 && v <= 365 {
+			epochs = v
+		}
+	}
+	// The view orders each pair's codes, so either order matches
+	origin, target := strings.TrimSpace(r.URL.Query().Get("origin")), strings.TrimSpace(r.URL.Query().Get("target"))
+	if (origin == "") != (target == "") {
+		http.Error(w, "origin and target must be given together", http.StatusBadRequest)
+		return
+	}
+	if target < origin {
+		origin, target = target, origin
+	}
+
+	start := time.Now()
+	rows, err := queryInternetAdvantage(ctx, epochs, origin, target)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		log.Printf("Internet advantage query error: %v", err)
+		http.Error(w, dberror.UserMessage(err), http.StatusInternalServerError)
+		return
+	}
+
+	response := InternetAdvantageResponse{Epochs: epochs, Pairs: groupInternetAdvantage(rows)}
+	response.Summary.TotalPairs = len(response.Pairs)
+	for _, p := range response.Pairs {
+		switch p.Trend {
+		case "improving":
+			response.Summary.Improving++
+		case "declining":
+			response.Summary.Declining++
+		}
+	}
+	writeJSON(w, response)
+}
+
+// internetAdvantageRow is one epoch of one metro pair.
+type internetAdvantageRow struct {
+	origin, target string
+	epoch          InternetAdvantageEpoch
+}
+
+// queryInternetAdvantage reads the last epochs of dz_vs_internet_latency_by_epoch, ordered by
+// pair then epoch.
+func queryInternetAdvantage(ctx context.Context, epochs int, origin, target string) ([]internetAdvantageRow, error) {
+	filter := ""
+	args := []any{epochs}
+	if origin != "" {
+		filter = "AND origin_metro = ? AND target_metro = ?"
+		args = append(args, origin, target)
+	}
+	query := fmt.Sprintf(`
+		SELECT
+			origin_metro, target_metro, epoch, start_ts, end_ts,
+			dz_p50_rtt_ms, dz_p95_rtt_ms, dz_p99_rtt_ms, dz_sample_count,
+			internet_p50_rtt_ms, internet_p95_rtt_ms, internet_p99_rtt_ms, internet_sample_count,
+			median_improvement_ms, median_improvement_pct,
+			p95_improvement_ms, p95_improvement_pct,
+			p99_improvement_ms, p99_improvement_pct,
+			compared_hours, dz_win_pct, improvement_stddev_ms
+		FROM dz_vs_internet_latency_by_epoch
+		WHERE epoch > (SELECT max(epoch) FROM fact_dz_internet_metro_latency) - ?
+		  %s
+		ORDER BY origin_metro, target_metro, epoch
+	`, filter)
+
+	rows, err := envDB(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []internetAdvantageRow
+	for rows.Next() {
+		var row internetAdvantageRow
+		e := &row.epoch
+		if err := rows.Scan(
+			&row.origin, &row.target, &e.Epoch, &e.StartTs, &e.EndTs,
+			&e.DzP50RttMs, &e.DzP95RttMs, &e.DzP99RttMs, &e.DzSampleCount,
+			&e.InternetP50RttMs, &e.InternetP95RttMs, &e.InternetP99RttMs, &e.InternetSampleCount,
+			&e.MedianImprovementMs, &e.MedianImprovementPct,
+			&e.P95ImprovementMs, &e.P95ImprovementPct,
+			&e.P99ImprovementMs, &e.P99ImprovementPct,
+			&e.ComparedHours, &e.DzWinPct, &e.ImprovementStddevMs,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// groupInternetAdvantage collects rows ordered by pair and epoch into pairs with their trend.
+func groupInternetAdvantage(rows []internetAdvantageRow) []InternetAdvantagePair {
+	pairs := []InternetAdvantagePair{}
+	for _, row := range rows {
+		n := len(pairs)
+		if n == 0 || pairs[n-1].OriginMetroCode != row.origin || pairs[n-1].TargetMetroCode != row.target {
+			pairs = append(pairs, InternetAdvantagePair{OriginMetroCode: row.origin, TargetMetroCode: row.target})
+			n++
+		}
+		pairs[n-1].Epochs = append(pairs[n-1].Epochs, row.epoch)
+	}
+
+	for i := range pairs {
+		p := &pairs[i]
+		p.MedianImprovementPct = p.Epochs[len(p.Epochs)-1].MedianImprovementPct
+		p.MedianImprovementChangePct = advantageChange(p.Epochs)
+		switch {
+		case p.MedianImprovementChangePct >= advantageTrendThresholdPct:
+			p.Trend = "improving"
+		case p.MedianImprovementChangePct <= -advantageTrendThresholdPct:
+			p.Trend = "declining"
+		default:
+			p.Trend = "stable"
+		}
+	}
+	return pairs
+}
+
+// advantageChange fits a least-squares line to the median improvement by epoch and returns
+// its change from the first epoch to the last, so a single noisy epoch does not set the trend.
+func advantageChange(epochs []InternetAdvantageEpoch) float64 {
+	if len(epochs) < 2 {
+		return 0
+	}
+	var meanX, meanY float64
+	for _, e := range epochs {
+		meanX += float64(e.Epoch)
+		meanY += e.MedianImprovementPct
+	}
+	meanX /= float64(len(epochs))
+	meanY /= float64(len(epochs))
+
+	var sxx, sxy float64
+	for _, e := range epochs {
+		dx := float64(e.Epoch) - meanX
+		sxx += dx * dx
+		sxy += dx * (e.MedianImprovementPct - meanY)
+	}
+	if sxx == 0 {
+		return 0
+	}
+	return sxy / sxx * float64(epochs[len(epochs)-1].Epoch-epochs[0].Epoch)
+}
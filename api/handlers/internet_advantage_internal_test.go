@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupInternetAdvantage(t *testing.T) {
+	t.Parallel()
+
+	row := func(origin, target string, epoch int64, pct float64) internetAdvantageRow {
+		return internetAdvantageRow{origin: origin, target: target, epoch: InternetAdvantageEpoch{Epoch: epoch, MedianImprovementPct: pct}}
+	}
+	pairs := groupInternetAdvantage([]internetAdvantageRow{
+		// One noisy epoch does not outweigh a steady climb
+		row("AMS", "FRA", 100, 40), row("AMS", "FRA", 101, 45), row("AMS", "FRA", 102, 30), row("AMS", "FRA", 103, 55),
+		row("FRA", "LON", 100, 60), row("FRA", "LON", 101, 50),
+		row("LON", "NYC", 100, 20), row("LON", "NYC", 101, 21),
+		row("NYC", "TYO", 100, 10),
+	})
+	require.Len(t, pairs, 4)
+
+	assert.Equal(t, "improving", pairs[0].Trend)
+	assert.Len(t, pairs[0].Epochs, 4)
+	assert.Equal(t, 55.0, pairs[0].MedianImprovementPct)
+	assert.InDelta(t, 9.0, pairs[0].MedianImprovementChangePct, 0.01)
+
+	assert.Equal(t, "declining", pairs[1].Trend)
+	assert.InDelta(t, -10.0, pairs[1].MedianImprovementChangePct, 0.01)
+
+	// Changes under the threshold and single epochs are stable
+	assert.Equal(t, "stable", pairs[2].Trend)
+	assert.Equal(t, "stable", pairs[3].Trend)
+	assert.Zero(t, pairs[3].MedianImprovementChangePct)
+
+	assert.Empty(t, groupInternetAdvantage(nil))
+}
@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/handlers"
+	apitesting "github.com/malbeclabs/lake/api/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedInternetAdvantageData seeds the per-epoch aggregates of the AMS-FRA pair over two epochs.
+// DZ holds 5ms throughout, while the internet slows from 10ms to 20ms.
+func seedInternetAdvantageData(t *testing.T) {
+	ctx := t.Context()
+
+	require.NoError(t, config.DB.Exec(ctx, `INSERT INTO fact_dz_vs_internet_latency_epochs
+		(event_ts, ingested_at, epoch, origin_metro, target_metro, end_ts,
+		 dz_p50_rtt_ms, dz_p95_rtt_ms, dz_p99_rtt_ms, dz_sample_count,
+		 internet_p50_rtt_ms, internet_p95_rtt_ms, internet_p99_rtt_ms, internet_sample_count,
+		 compared_hours, dz_win_hours, improvement_stddev_ms)
+		SELECT now() - INTERVAL (4 - 2 * number) DAY, now(), 100 + number, 'AMS', 'FRA', now() - INTERVAL (3 - 2 * number) DAY,
+		       5, 5, 5, 2,
+		       10 * (number + 1), 10 * (number + 1), 10 * (number + 1), 2,
+		       2, 2, 0
+		FROM numbers(2)`))
+	// The latest epoch is taken from the raw internet samples
+	require.NoError(t, config.DB.Exec(ctx, `INSERT INTO fact_dz_internet_metro_latency
+		(event_ts, ingested_at, epoch, sample_index, origin_metro_pk, target_metro_pk, data_provider, rtt_us, ipdv_us)
+		VALUES (now() - INTERVAL 1 DAY, now(), 101, 0, 'metro-2', 'metro-1', 'ripeatlas', 20000, 500)`))
+}
+
+func TestGetInternetAdvantage(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	seedInternetAdvantageData(t)
+
+	// Codes are matched in either order
+	req := httptest.NewRequest(http.MethodGet, "/api/topology/internet-advantage?origin=FRA&target=AMS", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetInternetAdvantage(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response handlers.InternetAdvantageResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	require.Len(t, response.Pairs, 1)
+
+	pair := response.Pairs[0]
+	assert.Equal(t, "AMS", pair.OriginMetroCode)
+	assert.Equal(t, "FRA", pair.TargetMetroCode)
+	require.Len(t, pair.Epochs, 2)
+	assert.Equal(t, int64(100), pair.Epochs[0].Epoch)
+	assert.InDelta(t, 5.0, pair.Epochs[0].MedianImprovementMs, 0.01)
+	assert.InDelta(t, 50.0, pair.Epochs[0].MedianImprovementPct, 0.1)
+	assert.InDelta(t, 75.0, pair.Epochs[1].MedianImprovementPct, 0.1)
+	// Each hour with samples on both sides had DZ ahead
+	require.NotNil(t, pair.Epochs[1].DzWinPct)
+	assert.InDelta(t, 100.0, *pair.Epochs[1].DzWinPct, 0.1)
+	assert.Equal(t, "improving", pair.Trend)
+	assert.Equal(t, 1, response.Summary.Improving)
+}
+
+func TestGetInternetAdvantage_Validation(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/topology/internet-advantage?origin=FRA", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetInternetAdvantage(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -7,13 +7,14 @@ import (
 	"log"
 	"net/http"
 	"slices"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/handlers/dberror"
 	"github.com/malbeclabs/lake/api/metrics"
-	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
 	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
@@ -69,7 +70,7 @@ func GetISISTopology(w http.ResponseWriter, r *http.Request) {
 	runNeo4jQuery := func(cypher string) ([]*neo4jdriver.Record, error) {
 		cfg := dberror.DefaultRetryConfig()
 		return dberror.Retry(ctx, cfg, func() ([]*neo4jdriver.Record, error) {
-			session := config.Neo4jSession(ctx)
+			session := envNeo4jSession(ctx)
 			defer session.Close(ctx)
 
 			result, err := session.Run(ctx, cypher, nil)
@@ -274,7 +275,7 @@ func GetISISPath(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	var cypher string
@@ -393,7 +394,7 @@ func GetTopologyCompare(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := TopologyCompareResponse{
@@ -646,12 +647,18 @@ func GetFailureImpact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	writeJSON(w, fetchFailureImpactData(ctx, devicePK))
+}
+
+// fetchFailureImpactData computes the failure impact of a device.
+// Used by both the handler and the agent analytics tools.
+func fetchFailureImpactData(ctx context.Context, devicePK string) *FailureImpactResponse {
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
-	response := FailureImpactResponse{
+	response := &FailureImpactResponse{
 		DevicePK:           devicePK,
 		UnreachableDevices: []ImpactDevice{},
 		AffectedPaths:      []FailureImpactPath{},
@@ -664,8 +671,7 @@ func GetFailureImpact(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Failure impact device query error: %v", err)
 		response.Error = err.Error()
-		writeJSON(w, response)
-		return
+		return response
 	}
 	if deviceRecord, err := deviceResult.Single(ctx); err == nil {
 		code, _ := deviceRecord.Get("code")
@@ -711,16 +717,14 @@ func GetFailureImpact(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Printf("Failure impact query error: %v", err)
 		response.Error = err.Error()
-		writeJSON(w, response)
-		return
+		return response
 	}
 
 	impactRecords, err := impactResult.Collect(ctx)
 	if err != nil {
 		log.Printf("Failure impact collect error: %v", err)
 		response.Error = err.Error()
-		writeJSON(w, response)
-		return
+		return response
 	}
 
 	for _, record := range impactRecords {
@@ -891,7 +895,7 @@ func GetFailureImpact(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Failure impact: %s, unreachable=%d, affectedPaths=%d, metrosImpacted=%d in %v",
 		response.DeviceCode, response.UnreachableCount, response.AffectedPathCount, len(response.MetroImpact), duration)
 
-	writeJSON(w, response)
+	return response
 }
 
 // MultiPathHop represents a hop in a path with edge metric information
@@ -957,7 +961,7 @@ func GetISISPaths(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := MultiPathResponse{
@@ -1269,103 +1273,76 @@ type CriticalLinksResponse struct {
 	Error string         `json:"error,omitempty"`
 }
 
-// GetCriticalLinks returns links that are critical for network connectivity
-// Critical links are identified based on node degrees and connectivity patterns
+// criticalLinkCutLimit caps the edge pairs tested when looking for two-link cuts.
+const criticalLinkCutLimit = 200000
+
+// GetCriticalLinks returns every IS-IS adjacency classified by how its loss affects
+// connectivity: critical links are single points of failure, important links split the
+// network together with one other link, and redundant links do neither
 func GetCriticalLinks(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
 	defer cancel()
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
-	defer session.Close(ctx)
-
 	response := CriticalLinksResponse{
 		Links: []CriticalLink{},
 	}
 
-	// Efficient approach: for each edge, check the degree of both endpoints
-	// - If either endpoint has degree 1, this is a critical link (leaf edge)
-	// - If min(degreeA, degreeB) == 2, it's important (limited redundancy)
-	// - Otherwise it's redundant (well-connected)
-	cypher := `
-		MATCH (a:Device)-[r:ISIS_ADJACENT]-(b:Device)
-		WHERE a.isis_system_id IS NOT NULL
-		  AND b.isis_system_id IS NOT NULL
-		  AND id(a) < id(b)
-		WITH a, b, min(r.metric) AS metric  // Deduplicate multiple edges between same nodes
-		// Count neighbors for each endpoint
-		OPTIONAL MATCH (a)-[:ISIS_ADJACENT]-(na:Device)
-		WHERE na.isis_system_id IS NOT NULL
-		WITH a, b, metric, count(DISTINCT na) AS degreeA
-		OPTIONAL MATCH (b)-[:ISIS_ADJACENT]-(nb:Device)
-		WHERE nb.isis_system_id IS NOT NULL
-		WITH a, b, metric, degreeA, count(DISTINCT nb) AS degreeB
-		RETURN a.pk AS sourcePK,
-		       a.code AS sourceCode,
-		       b.pk AS targetPK,
-		       b.code AS targetCode,
-		       metric,
-		       degreeA,
-		       degreeB
-		ORDER BY CASE
-		  WHEN degreeA = 1 OR degreeB = 1 THEN 0
-		  WHEN degreeA = 2 OR degreeB = 2 THEN 1
-		  ELSE 2
-		END, metric DESC
-	`
-
-	result, err := session.Run(ctx, cypher, nil)
+	topo, err := loadRoutingTopology(ctx)
 	if err != nil {
-		log.Printf("Critical links query error: %v", err)
+		log.Printf("Critical links topology error: %v", err)
 		response.Error = err.Error()
 		writeJSON(w, response)
 		return
 	}
 
-	records, err := result.Collect(ctx)
+	// Minimal cuts of one edge are the bridges; those of two are edges with exactly one backup
+	cuts, err := topo.MinimalEdgeCuts(2, criticalLinkCutLimit)
 	if err != nil {
-		log.Printf("Critical links collect error: %v", err)
-		response.Error = err.Error()
-		writeJSON(w, response)
-		return
+		log.Printf("Critical links cut search skipped: %v", err)
+		cuts = nil
+		for _, e := range topo.Bridges() {
+			cuts = append(cuts, []routing.Edge{e})
+		}
+	}
+	type edgeKey struct{ a, z string }
+	criticality := make(map[edgeKey]string)
+	key := func(e routing.Edge) edgeKey { return edgeKey{e.A, e.Z} }
+	for _, cut := range cuts {
+		for _, e := range cut {
+			switch {
+			case len(cut) == 1 && len(e.LinkPKs) <= 1:
+				criticality[key(e)] = "critical"
+			case criticality[key(e)] == "":
+				// Parallel links keep a bridge up through any single link failure
+				criticality[key(e)] = "important"
+			}
+		}
 	}
 
-	for _, record := range records {
-		sourcePK, _ := record.Get("sourcePK")
-		sourceCode, _ := record.Get("sourceCode")
-		targetPK, _ := record.Get("targetPK")
-		targetCode, _ := record.Get("targetCode")
-		metric, _ := record.Get("metric")
-		degreeA, _ := record.Get("degreeA")
-		degreeB, _ := record.Get("degreeB")
-
-		dA := asInt64(degreeA)
-		dB := asInt64(degreeB)
-		minDegree := dA
-		if dB < dA {
-			minDegree = dB
-		}
-
-		// Determine criticality based on minimum degree
-		var criticality string
-		if minDegree <= 1 {
-			criticality = "critical" // At least one endpoint has only this connection
-		} else if minDegree == 2 {
-			criticality = "important" // Limited redundancy
-		} else {
-			criticality = "redundant" // Well-connected endpoints
+	rank := map[string]int{"critical": 0, "important": 1, "redundant": 2}
+	for _, e := range topo.Edges() {
+		c := criticality[key(e)]
+		if c == "" {
+			c = "redundant"
 		}
-
 		response.Links = append(response.Links, CriticalLink{
-			SourcePK:    asString(sourcePK),
-			SourceCode:  asString(sourceCode),
-			TargetPK:    asString(targetPK),
-			TargetCode:  asString(targetCode),
-			Metric:      uint32(asInt64(metric)),
-			Criticality: criticality,
+			SourcePK:    e.A,
+			SourceCode:  deviceCode(topo, e.A),
+			TargetPK:    e.Z,
+			TargetCode:  deviceCode(topo, e.Z),
+			Metric:      e.Metric,
+			Criticality: c,
 		})
 	}
+	sort.SliceStable(response.Links, func(i, j int) bool {
+		a, b := response.Links[i], response.Links[j]
+		if rank[a.Criticality] != rank[b.Criticality] {
+			return rank[a.Criticality] < rank[b.Criticality]
+		}
+		return a.Metric > b.Metric
+	})
 
 	duration := time.Since(start)
 	metrics.RecordClickHouseQuery(duration, nil)
@@ -1387,7 +1364,7 @@ func GetCriticalLinks(w http.ResponseWriter, r *http.Request) {
 
 // RedundancyIssue represents a single redundancy issue in the network
 type RedundancyIssue struct {
-	Type        string `json:"type"`        // "leaf_device", "critical_link", "single_exit_metro", "no_backup_device"
+	Type        string `json:"type"`        // "leaf_device", "critical_link", "critical_device", "single_exit_metro", "no_backup_device"
 	Severity    string `json:"severity"`    // "critical", "warning", "info"
 	EntityPK    string `json:"entityPK"`    // PK of affected entity
 	EntityCode  string `json:"entityCode"`  // Code/name of affected entity
@@ -1416,6 +1393,7 @@ type RedundancySummary struct {
 	InfoCount        int `json:"infoCount"`
 	LeafDevices      int `json:"leafDevices"`
 	CriticalLinks    int `json:"criticalLinks"`
+	CriticalDevices  int `json:"criticalDevices"`
 	SingleExitMetros int `json:"singleExitMetros"`
 }
 
@@ -1426,123 +1404,92 @@ func GetRedundancyReport(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := RedundancyReportResponse{
 		Issues: []RedundancyIssue{},
 	}
 
-	// 1. Find leaf devices (devices with only 1 ISIS neighbor)
-	leafCypher := `
-		MATCH (d:Device)
-		WHERE d.isis_system_id IS NOT NULL
-		OPTIONAL MATCH (d)-[:ISIS_ADJACENT]-(n:Device)
-		WHERE n.isis_system_id IS NOT NULL
-		WITH d, count(DISTINCT n) AS neighborCount
-		WHERE neighborCount = 1
-		OPTIONAL MATCH (d)-[:LOCATED_IN]->(m:Metro)
-		RETURN d.pk AS pk,
-		       d.code AS code,
-		       m.pk AS metroPK,
-		       m.code AS metroCode
-		ORDER BY d.code
-	`
-
-	leafResult, err := session.Run(ctx, leafCypher, nil)
+	topo, err := loadRoutingTopology(ctx)
 	if err != nil {
-		log.Printf("Redundancy report leaf devices query error: %v", err)
+		log.Printf("Redundancy report topology error: %v", err)
 		response.Error = err.Error()
 		writeJSON(w, response)
 		return
 	}
+	metros := loadDeviceMetros(ctx)
 
-	leafRecords, err := leafResult.Collect(ctx)
-	if err != nil {
-		log.Printf("Redundancy report leaf devices collect error: %v", err)
-		response.Error = err.Error()
-		writeJSON(w, response)
-		return
+	// 1. Find leaf devices (devices with only 1 ISIS neighbor)
+	degree := make(map[string]int)
+	for _, e := range topo.Edges() {
+		degree[e.A]++
+		degree[e.Z]++
 	}
-
-	for _, record := range leafRecords {
-		pk, _ := record.Get("pk")
-		code, _ := record.Get("code")
-		metroPK, _ := record.Get("metroPK")
-		metroCode, _ := record.Get("metroCode")
-
+	leaves := []string{}
+	for _, pk := range topo.DevicePKs() {
+		if degree[pk] == 1 {
+			leaves = append(leaves, pk)
+		}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool { return deviceCode(topo, leaves[i]) < deviceCode(topo, leaves[j]) })
+	for _, pk := range leaves {
 		response.Issues = append(response.Issues, RedundancyIssue{
 			Type:        "leaf_device",
 			Severity:    "critical",
-			EntityPK:    asString(pk),
-			EntityCode:  asString(code),
+			EntityPK:    pk,
+			EntityCode:  deviceCode(topo, pk),
 			EntityType:  "device",
 			Description: "Device has only one ISIS neighbor",
 			Impact:      "If the single neighbor fails, this device loses connectivity to the network",
-			MetroPK:     asString(metroPK),
-			MetroCode:   asString(metroCode),
+			MetroPK:     metros[pk].PK,
+			MetroCode:   metros[pk].Code,
 		})
 	}
 
-	// 2. Find critical links (links where at least one endpoint has only this connection)
-	criticalLinksCypher := `
-		MATCH (a:Device)-[r:ISIS_ADJACENT]-(b:Device)
-		WHERE a.isis_system_id IS NOT NULL
-		  AND b.isis_system_id IS NOT NULL
-		  AND id(a) < id(b)
-		WITH a, b, min(r.metric) AS metric
-		OPTIONAL MATCH (a)-[:ISIS_ADJACENT]-(na:Device)
-		WHERE na.isis_system_id IS NOT NULL
-		WITH a, b, metric, count(DISTINCT na) AS degreeA
-		OPTIONAL MATCH (b)-[:ISIS_ADJACENT]-(nb:Device)
-		WHERE nb.isis_system_id IS NOT NULL
-		WITH a, b, metric, degreeA, count(DISTINCT nb) AS degreeB
-		WHERE degreeA = 1 OR degreeB = 1
-		RETURN a.pk AS sourcePK,
-		       a.code AS sourceCode,
-		       b.pk AS targetPK,
-		       b.code AS targetCode,
-		       degreeA,
-		       degreeB
-		ORDER BY sourceCode
-	`
-
-	criticalResult, err := session.Run(ctx, criticalLinksCypher, nil)
-	if err != nil {
-		log.Printf("Redundancy report critical links query error: %v", err)
-		response.Error = err.Error()
-		writeJSON(w, response)
-		return
-	}
-
-	criticalRecords, err := criticalResult.Collect(ctx)
-	if err != nil {
-		log.Printf("Redundancy report critical links collect error: %v", err)
-		response.Error = err.Error()
-		writeJSON(w, response)
-		return
+	// 2. Find critical links (adjacencies whose loss splits the network)
+	bridges := topo.Bridges()
+	sort.SliceStable(bridges, func(i, j int) bool { return deviceCode(topo, bridges[i].A) < deviceCode(topo, bridges[j].A) })
+	for _, e := range bridges {
+		cutOff := len(topo.Disconnected(routing.Failures{Edges: []routing.Edge{e}}))
+		issue := RedundancyIssue{
+			Type:        "critical_link",
+			Severity:    "critical",
+			EntityPK:    e.A,
+			EntityCode:  deviceCode(topo, e.A),
+			EntityType:  "link",
+			TargetPK:    e.Z,
+			TargetCode:  deviceCode(topo, e.Z),
+			Description: "Link is the only connection between two parts of the network",
+			Impact:      fmt.Sprintf("If this link fails, %d device(s) lose connectivity to the rest of the network", cutOff),
+		}
+		if len(e.LinkPKs) > 1 {
+			issue.Severity = "warning"
+			issue.Description = fmt.Sprintf("Adjacency is the only connection between two parts of the network, carried by %d parallel links", len(e.LinkPKs))
+			issue.Impact = fmt.Sprintf("If all %d links fail, %d device(s) lose connectivity to the rest of the network", len(e.LinkPKs), cutOff)
+		}
+		response.Issues = append(response.Issues, issue)
 	}
 
-	for _, record := range criticalRecords {
-		sourcePK, _ := record.Get("sourcePK")
-		sourceCode, _ := record.Get("sourceCode")
-		targetPK, _ := record.Get("targetPK")
-		targetCode, _ := record.Get("targetCode")
-
+	// 3. Find critical devices (devices whose failure splits the rest of the network)
+	points := topo.ArticulationPoints()
+	sort.SliceStable(points, func(i, j int) bool { return deviceCode(topo, points[i]) < deviceCode(topo, points[j]) })
+	for _, pk := range points {
+		cutOff := len(topo.Disconnected(routing.Failures{Devices: []string{pk}}))
 		response.Issues = append(response.Issues, RedundancyIssue{
-			Type:        "critical_link",
+			Type:        "critical_device",
 			Severity:    "critical",
-			EntityPK:    asString(sourcePK),
-			EntityCode:  asString(sourceCode),
-			EntityType:  "link",
-			TargetPK:    asString(targetPK),
-			TargetCode:  asString(targetCode),
-			Description: "Link connects a leaf device to the network",
-			Impact:      "If this link fails, one or both devices lose network connectivity",
+			EntityPK:    pk,
+			EntityCode:  deviceCode(topo, pk),
+			EntityType:  "device",
+			Description: "Device is the only connection between two parts of the network",
+			Impact:      fmt.Sprintf("If this device fails, %d other device(s) lose connectivity to the rest of the network", cutOff),
+			MetroPK:     metros[pk].PK,
+			MetroCode:   metros[pk].Code,
 		})
 	}
 
-	// 3. Find single-exit metros (metros where only one device has external connections)
+	// 4. Find single-exit metros (metros where only one device has external connections)
 	singleExitCypher := `
 		MATCH (m:Metro)<-[:LOCATED_IN]-(d:Device)
 		WHERE d.isis_system_id IS NOT NULL
@@ -1602,6 +1549,7 @@ func GetRedundancyReport(w http.ResponseWriter, r *http.Request) {
 	infoCount := 0
 	leafDeviceCount := 0
 	criticalLinkCount := 0
+	criticalDeviceCount := 0
 	singleExitMetroCount := 0
 
 	for _, issue := range response.Issues {
@@ -1619,6 +1567,8 @@ func GetRedundancyReport(w http.ResponseWriter, r *http.Request) {
 			leafDeviceCount++
 		case "critical_link":
 			criticalLinkCount++
+		case "critical_device":
+			criticalDeviceCount++
 		case "single_exit_metro":
 			singleExitMetroCount++
 		}
@@ -1631,6 +1581,7 @@ func GetRedundancyReport(w http.ResponseWriter, r *http.Request) {
 		InfoCount:        infoCount,
 		LeafDevices:      leafDeviceCount,
 		CriticalLinks:    criticalLinkCount,
+		CriticalDevices:  criticalDeviceCount,
 		SingleExitMetros: singleExitMetroCount,
 	}
 
@@ -1687,7 +1638,7 @@ func GetMetroConnectivity(w http.ResponseWriter, r *http.Request) {
 	runNeo4jQuery := func(cypher string) ([]*neo4jdriver.Record, error) {
 		cfg := dberror.DefaultRetryConfig()
 		return dberror.Retry(ctx, cfg, func() ([]*neo4jdriver.Record, error) {
-			session := config.Neo4jSession(ctx)
+			session := envNeo4jSession(ctx)
 			defer session.Close(ctx)
 
 			result, err := session.Run(ctx, cypher, nil)
@@ -1914,7 +1865,7 @@ func GetMetroPathLatency(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := MetroPathLatencyResponse{
@@ -2143,7 +2094,7 @@ func GetMetroPathLatency(w http.ResponseWriter, r *http.Request) {
 func fetchMetroPathLatencyData(ctx context.Context, optimize string) (*MetroPathLatencyResponse, error) {
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := &MetroPathLatencyResponse{
@@ -2395,7 +2346,7 @@ func GetMetroPathDetail(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := MetroPathDetailResponse{
@@ -2582,7 +2533,7 @@ func GetMetroPaths(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := MetroPathsResponse{
@@ -2691,6 +2642,8 @@ func GetMetroPaths(w http.ResponseWriter, r *http.Request) {
 type MaintenanceImpactRequest struct {
 	Devices []string `json:"devices"` // Device PKs to take offline
 	Links   []string `json:"links"`   // Link PKs to take offline (as "sourcePK:targetPK")
+	// UtilizationThreshold flags links projected above this percent of bandwidth (default 80)
+	UtilizationThreshold float64 `json:"utilizationThreshold,omitempty"`
 }
 
 // MaintenanceItem represents a device or link being taken offline
@@ -2715,7 +2668,11 @@ type MaintenanceAffectedPath struct {
 	HopsAfter    int    `json:"hopsAfter"`    // Hops after maintenance (-1 = disconnected)
 	MetricBefore int    `json:"metricBefore"` // Total ISIS metric before
 	MetricAfter  int    `json:"metricAfter"`  // Total ISIS metric after (-1 = disconnected)
-	Status       string `json:"status"`       // "rerouted", "degraded", or "disconnected"
+	MetricDelta  int    `json:"metricDelta,omitempty"`
+	// Device codes along the first equal-cost path before and after maintenance
+	BeforePath []string `json:"beforePath,omitempty"`
+	AfterPath  []string `json:"afterPath,omitempty"`
+	Status     string   `json:"status"` // "rerouted", "degraded", or "disconnected"
 }
 
 // AffectedLink represents a specific link affected by maintenance
@@ -2742,9 +2699,13 @@ type MaintenanceImpactResponse struct {
 	AffectedPaths     []MaintenanceAffectedPath `json:"affectedPaths,omitempty"`    // Sample of affected paths
 	AffectedMetros    []AffectedMetroPair       `json:"affectedMetros,omitempty"`   // Affected metro pairs
 	DisconnectedList  []string                  `json:"disconnectedList,omitempty"` // All devices that would be disconnected
+	Traffic           *TrafficProjection        `json:"traffic,omitempty"`          // Projected link load during maintenance
 	Error             string                    `json:"error,omitempty"`
 }
 
+// maintenanceItemPathLimit caps the affected paths listed for each maintenance item.
+const maintenanceItemPathLimit = 10
+
 // PostMaintenanceImpact analyzes the impact of taking multiple devices/links offline
 func PostMaintenanceImpact(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
@@ -2764,9 +2725,6 @@ func PostMaintenanceImpact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := config.Neo4jSession(ctx)
-	defer session.Close(ctx)
-
 	response := MaintenanceImpactResponse{
 		Items:            []MaintenanceItem{},
 		RecommendedOrder: []string{},
@@ -2775,77 +2733,68 @@ func PostMaintenanceImpact(w http.ResponseWriter, r *http.Request) {
 		DisconnectedList: []string{},
 	}
 
-	// Collect all device PKs and link endpoints being taken offline
-	offlineDevicePKs := make(map[string]bool)
-	offlineLinkEndpoints := make(map[string]bool) // "sourcePK:targetPK" format
-
-	for _, pk := range req.Devices {
-		offlineDevicePKs[pk] = true
-	}
-
-	// Batch analyze all devices in a single query
-	if len(req.Devices) > 0 {
-		deviceItems := analyzeDevicesImpactBatch(ctx, session, req.Devices)
-		for _, item := range deviceItems {
-			response.Items = append(response.Items, item)
-			for _, dc := range item.DisconnectedDevices {
-				response.DisconnectedList = append(response.DisconnectedList, dc)
-			}
-		}
+	topo, err := loadRoutingTopology(ctx)
+	if err != nil {
+		log.Printf("Maintenance impact topology error: %v", err)
+		response.Error = err.Error()
+		writeJSON(w, response)
+		return
 	}
+	metros := loadDeviceMetros(ctx)
 
-	// Batch analyze all links
-	if len(req.Links) > 0 {
-		linkItems, err := analyzeLinksImpactBatch(ctx, session, req.Links)
+	// Analyze each item on its own
+	var all []routing.Failures
+	for _, pk := range req.Devices {
+		item := MaintenanceItem{Type: "device", PK: pk, Code: "Unknown device"}
+		if _, ok := topo.Device(pk); ok {
+			f := routing.Failures{Devices: []string{pk}}
+			item.Code = deviceCode(topo, pk)
+			fillMaintenanceItem(&item, analyzeRoutingImpact(topo, metros, f))
+			all = append(all, f)
+		}
+		response.Items = append(response.Items, item)
+	}
+	for _, pk := range req.Links {
+		item := MaintenanceItem{Type: "link", PK: pk}
+		f, code, err := resolveLinkFailure(ctx, topo, pk)
 		if err != nil {
-			response.Error = fmt.Sprintf("failed to analyze links impact: %v", err)
-			writeJSON(w, response)
-			return
-		}
-		for _, item := range linkItems {
-			response.Items = append(response.Items, item)
-			for _, dc := range item.DisconnectedDevices {
-				response.DisconnectedList = append(response.DisconnectedList, dc)
-			}
-		}
-		// Track link endpoints for path analysis
-		for _, linkPK := range req.Links {
-			endpoints := getLinkEndpoints(ctx, linkPK)
-			if endpoints != "" {
-				offlineLinkEndpoints[endpoints] = true
-			}
+			log.Printf("Maintenance impact link lookup error: %v", err)
+			item.Code = "Link not found"
+		} else {
+			item.Code = code
+			fillMaintenanceItem(&item, analyzeRoutingImpact(topo, metros, f))
+			all = append(all, f)
 		}
+		response.Items = append(response.Items, item)
 	}
 
-	// Sort items by impact (ascending) for recommended order
+	// Least impactful first
 	sortedItems := make([]MaintenanceItem, len(response.Items))
 	copy(sortedItems, response.Items)
-
-	// Simple bubble sort by impact (least impactful first)
-	for i := 0; i < len(sortedItems)-1; i++ {
-		for j := 0; j < len(sortedItems)-i-1; j++ {
-			if sortedItems[j].Impact > sortedItems[j+1].Impact {
-				sortedItems[j], sortedItems[j+1] = sortedItems[j+1], sortedItems[j]
-			}
+	sort.SliceStable(sortedItems, func(i, j int) bool {
+		if sortedItems[i].Disconnected != sortedItems[j].Disconnected {
+			return sortedItems[i].Disconnected < sortedItems[j].Disconnected
 		}
-	}
-
-	// Build recommended order
+		return sortedItems[i].Impact < sortedItems[j].Impact
+	})
 	for _, item := range sortedItems {
 		response.RecommendedOrder = append(response.RecommendedOrder, item.PK)
 	}
 
-	// Calculate total impact
-	for _, item := range response.Items {
-		response.TotalImpact += item.Impact
-		response.TotalDisconnected += item.Disconnected
+	// Analyze everything down at once
+	failures := mergeFailures(all...)
+	combined := analyzeRoutingImpact(topo, metros, failures)
+	response.TotalImpact = len(combined.changes)
+	response.TotalDisconnected = len(combined.disconnected)
+	response.DisconnectedList = combined.disconnected
+	for _, c := range combined.changes {
+		if len(response.AffectedPaths) >= 50 {
+			break
+		}
+		response.AffectedPaths = append(response.AffectedPaths, c.maintenancePath())
 	}
-
-	// Compute affected paths with before/after routing metrics
-	response.AffectedPaths = computeAffectedPathsFast(ctx, session, offlineDevicePKs, 50)
-
-	// Compute affected metro pairs - simplified
-	response.AffectedMetros = computeAffectedMetrosFast(ctx, session, offlineDevicePKs)
+	response.AffectedMetros = maintenanceAffectedMetros(topo, metros, failures, combined)
+	response.Traffic = projectTraffic(ctx, topo, failures, req.UtilizationThreshold)
 
 	duration := time.Since(start)
 	metrics.RecordClickHouseQuery(duration, nil)
@@ -2856,623 +2805,83 @@ func PostMaintenanceImpact(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, response)
 }
 
-// getLinkEndpoints returns "sourcePK:targetPK" for a link PK
-func getLinkEndpoints(ctx context.Context, linkPK string) string {
-	query := `SELECT side_a_pk, side_z_pk FROM dz_links_current WHERE pk = $1`
-	var sideA, sideZ string
-	if err := envDB(ctx).QueryRow(ctx, query, linkPK).Scan(&sideA, &sideZ); err != nil {
-		return ""
-	}
-	if sideA == "" || sideZ == "" {
-		return ""
-	}
-	return sideA + ":" + sideZ
-}
-
-// analyzeDevicesImpactBatch computes the impact of taking multiple devices offline in a single query
-func analyzeDevicesImpactBatch(ctx context.Context, session neo4j.Session, devicePKs []string) []MaintenanceItem {
-	items := make([]MaintenanceItem, 0, len(devicePKs))
-
-	// Single query to get all device info, neighbor counts, and leaf neighbors
-	cypher := `
-		UNWIND $devicePKs AS devicePK
-		MATCH (d:Device {pk: devicePK})
-		WHERE d.isis_system_id IS NOT NULL
-
-		// Get device code
-		WITH d, devicePK
-
-		// Count neighbors (for impact estimate)
-		OPTIONAL MATCH (d)-[:ISIS_ADJACENT]-(neighbor:Device)
-		WHERE neighbor.isis_system_id IS NOT NULL
-		WITH d, devicePK, count(DISTINCT neighbor) AS neighborCount
-
-		// Find leaf neighbors (degree 1) that would be disconnected
-		OPTIONAL MATCH (d)-[:ISIS_ADJACENT]-(leafNeighbor:Device)
-		WHERE leafNeighbor.isis_system_id IS NOT NULL
-		WITH d, devicePK, neighborCount, leafNeighbor
-		OPTIONAL MATCH (leafNeighbor)-[:ISIS_ADJACENT]-(leafNeighborNeighbor:Device)
-		WHERE leafNeighborNeighbor.isis_system_id IS NOT NULL
-		WITH d, devicePK, neighborCount, leafNeighbor, count(DISTINCT leafNeighborNeighbor) AS leafNeighborDegree
-		WITH d, devicePK, neighborCount,
-		     CASE WHEN leafNeighborDegree = 1 THEN leafNeighbor.code ELSE null END AS disconnectedCode
-
-		WITH d.pk AS pk, d.code AS code, neighborCount,
-		     collect(disconnectedCode) AS disconnectedCodes
-
-		RETURN pk, code, neighborCount,
-		       [x IN disconnectedCodes WHERE x IS NOT NULL] AS disconnectedDevices
-	`
-
-	result, err := session.Run(ctx, cypher, map[string]any{
-		"devicePKs": devicePKs,
-	})
-	if err != nil {
-		log.Printf("Batch device impact query error: %v", err)
-		// Fallback to individual queries
-		for _, pk := range devicePKs {
-			items = append(items, analyzeDeviceImpact(ctx, session, pk))
-		}
-		return items
-	}
-
-	resultMap := make(map[string]MaintenanceItem)
-	for result.Next(ctx) {
-		record := result.Record()
-		pk, _ := record.Get("pk")
-		code, _ := record.Get("code")
-		neighborCount, _ := record.Get("neighborCount")
-		disconnectedDevices, _ := record.Get("disconnectedDevices")
-
-		disconnectedList := []string{}
-		if arr, ok := disconnectedDevices.([]any); ok {
-			for _, v := range arr {
-				if s := asString(v); s != "" {
-					disconnectedList = append(disconnectedList, s)
-				}
-			}
-		}
-
-		// Impact estimate: neighbor count squared (rough approximation of paths through this device)
-		nc := int(asInt64(neighborCount))
-		impact := nc * nc
-
-		item := MaintenanceItem{
-			Type:                "device",
-			PK:                  asString(pk),
-			Code:                asString(code),
-			Impact:              impact,
-			Disconnected:        len(disconnectedList),
-			CausesPartition:     len(disconnectedList) > 0,
-			DisconnectedDevices: disconnectedList,
-		}
-		resultMap[asString(pk)] = item
-	}
-
-	// Compute affected paths for each device (limit to 10 per device for performance)
-	for pk, item := range resultMap {
-		if item.Impact > 0 {
-			offlineSet := map[string]bool{pk: true}
-			paths := computeAffectedPathsFast(ctx, session, offlineSet, 10)
-			item.AffectedPaths = paths
-			item.Impact = len(paths) // Use actual count instead of estimate
-			resultMap[pk] = item
-		}
-	}
-
-	// Return items in the same order as input
-	for _, pk := range devicePKs {
-		if item, ok := resultMap[pk]; ok {
-			items = append(items, item)
-		} else {
-			// Device not found in graph
-			items = append(items, MaintenanceItem{
-				Type: "device",
-				PK:   pk,
-				Code: "Unknown device",
-			})
+// fillMaintenanceItem records an item's impact, keeping its worst affected paths.
+func fillMaintenanceItem(item *MaintenanceItem, impact routingImpact) {
+	item.Impact = len(impact.changes)
+	item.Disconnected = len(impact.disconnected)
+	item.CausesPartition = item.Disconnected > 0
+	item.DisconnectedDevices = impact.disconnected
+	for _, c := range impact.changes {
+		if len(item.AffectedPaths) >= maintenanceItemPathLimit {
+			break
 		}
+		item.AffectedPaths = append(item.AffectedPaths, c.maintenancePath())
 	}
-
-	return items
 }
 
-// analyzeLinksImpactBatch computes the impact of taking multiple links offline
-func analyzeLinksImpactBatch(ctx context.Context, session neo4j.Session, linkPKs []string) ([]MaintenanceItem, error) {
-	items := make([]MaintenanceItem, 0, len(linkPKs))
+// maintenanceAffectedMetros groups the adjacencies lost to maintenance by metro pair. A pair
+// is disconnected or degraded if any route between its metros is.
+func maintenanceAffectedMetros(topo *routing.Topology, metros map[string]routingMetro,
+	failures routing.Failures, impact routingImpact) []AffectedMetroPair {
 
-	// First, batch lookup links from ClickHouse
-	if len(linkPKs) == 0 {
-		return items, nil
-	}
-
-	// Build placeholders for ClickHouse query
-	linkQuery := `
-		SELECT
-			l.pk,
-			l.code,
-			COALESCE(l.side_a_pk, '') as side_a_pk,
-			COALESCE(l.side_z_pk, '') as side_z_pk,
-			COALESCE(da.code, '') as side_a_code,
-			COALESCE(dz.code, '') as side_z_code
-		FROM dz_links_current l
-		LEFT JOIN dz_devices_current da ON l.side_a_pk = da.pk
-		LEFT JOIN dz_devices_current dz ON l.side_z_pk = dz.pk
-		WHERE l.pk IN ($1)
-	`
-
-	// For ClickHouse we need to pass as a tuple
-	rows, err := envDB(ctx).Query(ctx, linkQuery, linkPKs)
-	if err != nil {
-		return nil, fmt.Errorf("batch link lookup error: %w", err)
-	}
-	defer rows.Close()
-
-	type linkInfo struct {
-		pk        string
-		code      string
-		sideAPK   string
-		sideZPK   string
-		sideACode string
-		sideZCode string
-	}
-	linkMap := make(map[string]linkInfo)
-
-	for rows.Next() {
-		var li linkInfo
-		if err := rows.Scan(&li.pk, &li.code, &li.sideAPK, &li.sideZPK, &li.sideACode, &li.sideZCode); err != nil {
-			return nil, fmt.Errorf("failed to scan link info row: %w", err)
-		}
-		linkMap[li.pk] = li
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate link info rows: %w", err)
-	}
-
-	// Now batch query Neo4j for degree information
-	var linkEndpoints []map[string]string
-	for _, pk := range linkPKs {
-		if li, ok := linkMap[pk]; ok && li.sideAPK != "" && li.sideZPK != "" {
-			linkEndpoints = append(linkEndpoints, map[string]string{
-				"pk":      pk,
-				"sourceA": li.sideAPK,
-				"sourceZ": li.sideZPK,
-			})
-		}
-	}
-
-	// Single Neo4j query for all link endpoints
-	degreeCypher := `
-		UNWIND $links AS link
-		MATCH (s:Device {pk: link.sourceA}), (t:Device {pk: link.sourceZ})
-		WHERE s.isis_system_id IS NOT NULL AND t.isis_system_id IS NOT NULL
-
-		OPTIONAL MATCH (s)-[:ISIS_ADJACENT]-(sn:Device) WHERE sn.isis_system_id IS NOT NULL
-		WITH link, s, t, count(DISTINCT sn) AS sourceDegree
-		OPTIONAL MATCH (t)-[:ISIS_ADJACENT]-(tn:Device) WHERE tn.isis_system_id IS NOT NULL
-		WITH link, s, t, sourceDegree, count(DISTINCT tn) AS targetDegree
-
-		RETURN link.pk AS pk, s.code AS sourceCode, t.code AS targetCode, sourceDegree, targetDegree
-	`
-
-	degreeMap := make(map[string]struct {
-		sourceCode   string
-		targetCode   string
-		sourceDegree int
-		targetDegree int
-	})
-
-	if len(linkEndpoints) > 0 {
-		result, err := session.Run(ctx, degreeCypher, map[string]any{
-			"links": linkEndpoints,
-		})
-		if err == nil {
-			for result.Next(ctx) {
-				record := result.Record()
-				pk, _ := record.Get("pk")
-				sourceCode, _ := record.Get("sourceCode")
-				targetCode, _ := record.Get("targetCode")
-				sourceDegree, _ := record.Get("sourceDegree")
-				targetDegree, _ := record.Get("targetDegree")
-
-				degreeMap[asString(pk)] = struct {
-					sourceCode   string
-					targetCode   string
-					sourceDegree int
-					targetDegree int
-				}{
-					sourceCode:   asString(sourceCode),
-					targetCode:   asString(targetCode),
-					sourceDegree: int(asInt64(sourceDegree)),
-					targetDegree: int(asInt64(targetDegree)),
-				}
-			}
-		}
+	type metroPairKey struct {
+		metro1, metro2 string
 	}
-
-	// Build items in order
-	for _, pk := range linkPKs {
-		li, hasLink := linkMap[pk]
-		if !hasLink {
-			items = append(items, MaintenanceItem{
-				Type: "link",
-				PK:   pk,
-				Code: "Link not found",
-			})
-			continue
+	keyOf := func(m1, m2 string) metroPairKey {
+		if m1 == "" {
+			m1 = "unknown"
 		}
-
-		if li.sideAPK == "" || li.sideZPK == "" {
-			items = append(items, MaintenanceItem{
-				Type: "link",
-				PK:   pk,
-				Code: li.code + " (missing endpoints)",
-			})
-			continue
+		if m2 == "" {
+			m2 = "unknown"
 		}
-
-		item := MaintenanceItem{
-			Type: "link",
-			PK:   pk,
-			Code: li.sideACode + " - " + li.sideZCode,
-		}
-
-		if deg, ok := degreeMap[pk]; ok {
-			// If either has degree 1, this link is critical
-			if deg.sourceDegree == 1 || deg.targetDegree == 1 {
-				item.CausesPartition = true
-				if deg.sourceDegree == 1 {
-					item.DisconnectedDevices = append(item.DisconnectedDevices, deg.sourceCode)
-					item.Disconnected++
-				}
-				if deg.targetDegree == 1 {
-					item.DisconnectedDevices = append(item.DisconnectedDevices, deg.targetCode)
-					item.Disconnected++
-				}
-			}
-			// Impact estimate: product of (degree-1) for each side (paths that would need rerouting)
-			srcNeighbors := deg.sourceDegree - 1
-			tgtNeighbors := deg.targetDegree - 1
-			if srcNeighbors < 0 {
-				srcNeighbors = 0
-			}
-			if tgtNeighbors < 0 {
-				tgtNeighbors = 0
-			}
-			item.Impact = srcNeighbors * tgtNeighbors
+		if m1 > m2 {
+			m1, m2 = m2, m1
 		}
-
-		items = append(items, item)
-	}
-
-	return items, nil
-}
-
-// computeAffectedPathsFast finds all paths affected by taking devices offline
-// Returns paths that will be rerouted (with before/after metrics) and paths that will be disconnected
-func computeAffectedPathsFast(ctx context.Context, session neo4j.Session,
-	offlineDevices map[string]bool, limit int) []MaintenanceAffectedPath {
-
-	result := []MaintenanceAffectedPath{}
-
-	offlineDevicePKs := make([]string, 0, len(offlineDevices))
-	for pk := range offlineDevices {
-		offlineDevicePKs = append(offlineDevicePKs, pk)
-	}
-
-	if len(offlineDevicePKs) == 0 {
-		return result
-	}
-
-	// Step 1: Find all neighbors of offline devices (these are the directly affected connections)
-	// For each neighbor pair (neighbor of offline device A, neighbor of offline device B or other device),
-	// compute the current shortest path and the alternate path avoiding offline devices
-	cypher := `
-		// Find all ISIS neighbors of offline devices
-		MATCH (offline:Device)-[:ISIS_ADJACENT]-(neighbor:Device)
-		WHERE offline.pk IN $offlineDevicePKs
-		  AND offline.isis_system_id IS NOT NULL
-		  AND neighbor.isis_system_id IS NOT NULL
-		  AND NOT neighbor.pk IN $offlineDevicePKs
-
-		WITH DISTINCT neighbor
-
-		// For each neighbor, find paths to other devices that currently go through an offline device
-		MATCH (neighbor)-[:ISIS_ADJACENT*1..2]-(other:Device)
-		WHERE other.isis_system_id IS NOT NULL
-		  AND other.pk <> neighbor.pk
-		  AND NOT other.pk IN $offlineDevicePKs
-
-		WITH DISTINCT neighbor, other
-		WHERE neighbor.pk < other.pk  // Avoid duplicates
-
-		// Get current shortest path
-		MATCH currentPath = shortestPath((neighbor)-[:ISIS_ADJACENT*]-(other))
-		WITH neighbor, other, currentPath,
-		     length(currentPath) AS currentHops,
-		     reduce(m = 0, r IN relationships(currentPath) | m + coalesce(r.metric, 10)) AS currentMetric,
-		     any(n IN nodes(currentPath) WHERE n.pk IN $offlineDevicePKs) AS goesThruOffline
-
-		WHERE goesThruOffline = true
-
-		// Get metro info
-		OPTIONAL MATCH (neighbor)-[:LOCATED_IN]->(nm:Metro)
-		OPTIONAL MATCH (other)-[:LOCATED_IN]->(om:Metro)
-
-		RETURN neighbor.pk AS sourcePK, neighbor.code AS sourceCode,
-		       other.pk AS targetPK, other.code AS targetCode,
-		       COALESCE(nm.code, 'unknown') AS sourceMetro,
-		       COALESCE(om.code, 'unknown') AS targetMetro,
-		       currentHops, currentMetric
-		LIMIT $limit
-	`
-
-	records, err := session.Run(ctx, cypher, map[string]any{
-		"offlineDevicePKs": offlineDevicePKs,
-		"limit":            limit * 2, // Get more candidates, we'll filter
-	})
-	if err != nil {
-		log.Printf("Error computing affected paths: %v", err)
-		return result
-	}
-
-	// Collect paths that need alternate route computation
-	type pathCandidate struct {
-		sourcePK      string
-		sourceCode    string
-		targetPK      string
-		targetCode    string
-		sourceMetro   string
-		targetMetro   string
-		currentHops   int
-		currentMetric int
-	}
-	candidates := []pathCandidate{}
-
-	for records.Next(ctx) {
-		record := records.Record()
-		sourcePK, _ := record.Get("sourcePK")
-		sourceCode, _ := record.Get("sourceCode")
-		targetPK, _ := record.Get("targetPK")
-		targetCode, _ := record.Get("targetCode")
-		sourceMetro, _ := record.Get("sourceMetro")
-		targetMetro, _ := record.Get("targetMetro")
-		currentHops, _ := record.Get("currentHops")
-		currentMetric, _ := record.Get("currentMetric")
-
-		candidates = append(candidates, pathCandidate{
-			sourcePK:      asString(sourcePK),
-			sourceCode:    asString(sourceCode),
-			targetPK:      asString(targetPK),
-			targetCode:    asString(targetCode),
-			sourceMetro:   asString(sourceMetro),
-			targetMetro:   asString(targetMetro),
-			currentHops:   int(asInt64(currentHops)),
-			currentMetric: int(asInt64(currentMetric)),
-		})
+		return metroPairKey{m1, m2}
 	}
 
-	// Step 2: For each candidate, find alternate path avoiding offline devices
-	for _, c := range candidates {
-		if len(result) >= limit {
-			break
-		}
-
-		path := MaintenanceAffectedPath{
-			Source:       c.sourceCode,
-			Target:       c.targetCode,
-			SourceMetro:  c.sourceMetro,
-			TargetMetro:  c.targetMetro,
-			HopsBefore:   c.currentHops,
-			MetricBefore: c.currentMetric,
-			HopsAfter:    -1,
-			MetricAfter:  -1,
-			Status:       "disconnected",
-		}
-
-		// Try to find alternate path
-		altCypher := `
-			MATCH (source:Device {pk: $sourcePK}), (target:Device {pk: $targetPK})
-			MATCH altPath = shortestPath((source)-[:ISIS_ADJACENT*]-(target))
-			WHERE none(n IN nodes(altPath) WHERE n.pk IN $offlineDevicePKs)
-			WITH altPath, length(altPath) AS altHops,
-			     reduce(m = 0, r IN relationships(altPath) | m + coalesce(r.metric, 10)) AS altMetric
-			RETURN altHops, altMetric
-			LIMIT 1
-		`
-
-		altResult, err := session.Run(ctx, altCypher, map[string]any{
-			"sourcePK":         c.sourcePK,
-			"targetPK":         c.targetPK,
-			"offlineDevicePKs": offlineDevicePKs,
-		})
-		if err == nil && altResult.Next(ctx) {
-			record := altResult.Record()
-			altHops, _ := record.Get("altHops")
-			altMetric, _ := record.Get("altMetric")
-
-			path.HopsAfter = int(asInt64(altHops))
-			path.MetricAfter = int(asInt64(altMetric))
-
-			// Classify based on degradation
-			hopIncrease := path.HopsAfter - path.HopsBefore
-			metricIncrease := path.MetricAfter - path.MetricBefore
-			if hopIncrease > 2 || metricIncrease > 50 {
-				path.Status = "degraded"
-			} else {
-				path.Status = "rerouted"
-			}
-		}
-
-		result = append(result, path)
+	type edgeKey struct{ a, z string }
+	remaining := make(map[edgeKey]bool)
+	for _, e := range topo.Without(failures).Edges() {
+		remaining[edgeKey{e.A, e.Z}] = true
 	}
 
-	return result
-}
-
-// computeAffectedMetrosFast computes affected metro pairs with specific link details
-func computeAffectedMetrosFast(ctx context.Context, session neo4j.Session,
-	offlineDevices map[string]bool) []AffectedMetroPair {
-
 	result := []AffectedMetroPair{}
-
-	offlineDevicePKs := make([]string, 0, len(offlineDevices))
-	for pk := range offlineDevices {
-		offlineDevicePKs = append(offlineDevicePKs, pk)
-	}
-
-	if len(offlineDevicePKs) == 0 {
-		return result
-	}
-
-	// Query: find ISIS adjacencies that involve offline devices, grouped by metro pair
-	// Returns the specific device pairs affected
-	cypher := `
-		MATCH (d1:Device)-[:ISIS_ADJACENT]-(d2:Device)
-		WHERE d1.pk IN $offlineDevicePKs
-		  AND d1.isis_system_id IS NOT NULL
-		  AND d2.isis_system_id IS NOT NULL
-		  AND NOT d2.pk IN $offlineDevicePKs
-
-		// Get metro info for both devices
-		OPTIONAL MATCH (d1)-[:LOCATED_IN]->(m1:Metro)
-		OPTIONAL MATCH (d2)-[:LOCATED_IN]->(m2:Metro)
-
-		WITH COALESCE(m1.code, 'unknown') AS metro1,
-		     COALESCE(m2.code, 'unknown') AS metro2,
-		     d1.code AS device1,
-		     d2.code AS device2,
-		     d1.pk AS d1pk
-
-		// Return individual links grouped by metro pair
-		RETURN metro1, metro2, device1, device2, d1pk
-		ORDER BY metro1, metro2, device1
-		LIMIT 50
-	`
-
-	records, err := session.Run(ctx, cypher, map[string]any{
-		"offlineDevicePKs": offlineDevicePKs,
-	})
-	if err != nil {
-		log.Printf("Error computing affected metros fast: %v", err)
-		return result
-	}
-
-	// Group links by metro pair
-	type metroPairKey struct {
-		metro1, metro2 string
-	}
-	metroPairs := make(map[metroPairKey]*AffectedMetroPair)
-
-	for records.Next(ctx) {
-		record := records.Record()
-		metro1, _ := record.Get("metro1")
-		metro2, _ := record.Get("metro2")
-		device1, _ := record.Get("device1")
-		device2, _ := record.Get("device2")
-
-		m1 := asString(metro1)
-		m2 := asString(metro2)
-
-		// Normalize key so we don't duplicate metro pairs in different order
-		key := metroPairKey{m1, m2}
-		if m1 > m2 {
-			key = metroPairKey{m2, m1}
+	pairs := make(map[metroPairKey]int)
+	for _, e := range topo.Edges() {
+		if remaining[edgeKey{e.A, e.Z}] {
+			continue
 		}
-
-		pair, exists := metroPairs[key]
-		if !exists {
-			pair = &AffectedMetroPair{
+		key := keyOf(metros[e.A].Code, metros[e.Z].Code)
+		i, ok := pairs[key]
+		if !ok {
+			i = len(result)
+			pairs[key] = i
+			result = append(result, AffectedMetroPair{
 				SourceMetro:   key.metro1,
 				TargetMetro:   key.metro2,
 				AffectedLinks: []AffectedLink{},
 				Status:        "reduced",
-			}
-			metroPairs[key] = pair
-		}
-
-		// Add the affected link
-		link := AffectedLink{
-			SourceDevice: asString(device1),
-			TargetDevice: asString(device2),
-			Status:       "offline", // The source device is going offline
-		}
-		pair.AffectedLinks = append(pair.AffectedLinks, link)
-	}
-
-	// Convert map to slice
-	for _, pair := range metroPairs {
-		result = append(result, *pair)
-	}
-
-	return result
-}
-
-// analyzeDeviceImpact computes the impact of taking a single device offline
-func analyzeDeviceImpact(ctx context.Context, session neo4j.Session, devicePK string) MaintenanceItem {
-	item := MaintenanceItem{
-		Type: "device",
-		PK:   devicePK,
-	}
-
-	// Get device code
-	codeCypher := `
-		MATCH (d:Device {pk: $pk})
-		RETURN d.code AS code
-	`
-	codeResult, err := session.Run(ctx, codeCypher, map[string]any{"pk": devicePK})
-	if err == nil {
-		if record, err := codeResult.Single(ctx); err == nil {
-			if code, ok := record.Get("code"); ok {
-				item.Code = asString(code)
-			}
+			})
 		}
+		result[i].AffectedLinks = append(result[i].AffectedLinks, AffectedLink{
+			SourceDevice: deviceCode(topo, e.A),
+			TargetDevice: deviceCode(topo, e.Z),
+			Status:       "offline",
+		})
 	}
 
-	// Count paths that go through this device
-	pathsCypher := `
-		MATCH (d:Device {pk: $pk})
-		WHERE d.isis_system_id IS NOT NULL
-		OPTIONAL MATCH (other:Device)
-		WHERE other.isis_system_id IS NOT NULL AND other.pk <> d.pk
-		OPTIONAL MATCH path = shortestPath((other)-[:ISIS_ADJACENT*]-(d))
-		WITH d, count(path) AS pathCount
-		RETURN pathCount
-	`
-	pathsResult, err := session.Run(ctx, pathsCypher, map[string]any{"pk": devicePK})
-	if err == nil {
-		if record, err := pathsResult.Single(ctx); err == nil {
-			if pathCount, ok := record.Get("pathCount"); ok {
-				item.Impact = int(asInt64(pathCount))
-			}
+	for _, c := range impact.changes {
+		i, ok := pairs[keyOf(c.sourceMetro, c.targetMetro)]
+		if !ok || result[i].Status == "disconnected" {
+			continue
 		}
-	}
-
-	// Check if this device is critical (would disconnect others)
-	// A device is critical if any of its neighbors have degree 1 (only connected to this device)
-	criticalCypher := `
-		MATCH (d:Device {pk: $pk})-[:ISIS_ADJACENT]-(neighbor:Device)
-		WHERE d.isis_system_id IS NOT NULL AND neighbor.isis_system_id IS NOT NULL
-		WITH neighbor
-		MATCH (neighbor)-[:ISIS_ADJACENT]-(any:Device)
-		WHERE any.isis_system_id IS NOT NULL
-		WITH neighbor, count(DISTINCT any) AS degree
-		WHERE degree = 1
-		RETURN neighbor.code AS disconnectedCode
-	`
-	criticalResult, err := session.Run(ctx, criticalCypher, map[string]any{"pk": devicePK})
-	if err == nil {
-		for criticalResult.Next(ctx) {
-			record := criticalResult.Record()
-			if code, ok := record.Get("disconnectedCode"); ok {
-				item.DisconnectedDevices = append(item.DisconnectedDevices, asString(code))
-				item.Disconnected++
-			}
+		switch c.status {
+		case "disconnected", "degraded":
+			result[i].Status = c.status
 		}
-		item.CausesPartition = item.Disconnected > 0
 	}
-
-	return item
+	return result
 }
 
 // MetroDevicePairPath represents the best path between a device pair across two metros
@@ -3533,7 +2942,7 @@ func GetMetroDevicePaths(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := MetroDevicePathsResponse{
@@ -3643,7 +3052,7 @@ func GetMetroDevicePaths(w http.ResponseWriter, r *http.Request) {
 				queryCtx, queryCancel := context.WithTimeout(ctx, 5*time.Second)
 				defer queryCancel()
 
-				querySession := config.Neo4jSession(queryCtx)
+				querySession := envNeo4jSession(queryCtx)
 				defer querySession.Close(queryCtx)
 
 				var cypher string
@@ -155,3 +155,92 @@ func TestGetMetroConnectivity_Empty(t *testing.T) {
 	assert.Empty(t, response.Metros)
 	assert.Empty(t, response.Connectivity)
 }
+
+// seedSRTriangle seeds a triangle where a reaches c through b, with a direct a-c adjacency
+// that costs more. c shares a's node SID.
+func seedSRTriangle(t *testing.T) {
+	seedFunc := func(ctx context.Context, session neo4j.Session) error {
+		_, err := session.Run(ctx, `
+			CREATE (a:Device {pk: 'dev-a', code: 'A-DZ01', isis_system_id: '0000.0000.0001', isis_router_id: '10.0.0.1',
+			        isis_srgb_base: 16000, isis_srgb_range: 8000, isis_node_sid: 1,
+			        isis_prefix_sid_prefixes: ['10.0.0.1/32'], isis_prefix_sid_indexes: [1]})
+			CREATE (b:Device {pk: 'dev-b', code: 'B-DZ01', isis_system_id: '0000.0000.0002', isis_router_id: '10.0.0.2',
+			        isis_srgb_base: 16000, isis_srgb_range: 8000, isis_node_sid: 2,
+			        isis_prefix_sid_prefixes: ['10.0.0.2/32'], isis_prefix_sid_indexes: [2]})
+			CREATE (c:Device {pk: 'dev-c', code: 'C-DZ01', isis_system_id: '0000.0000.0003', isis_router_id: '10.0.0.3',
+			        isis_srgb_base: 16000, isis_srgb_range: 8000, isis_node_sid: 1,
+			        isis_prefix_sid_prefixes: ['10.0.0.3/32'], isis_prefix_sid_indexes: [1]})
+			CREATE (a)-[:ISIS_ADJACENT {metric: 10, adj_sids: [100000]}]->(b)
+			CREATE (b)-[:ISIS_ADJACENT {metric: 10, adj_sids: [100001]}]->(a)
+			CREATE (b)-[:ISIS_ADJACENT {metric: 10, adj_sids: [100002]}]->(c)
+			CREATE (c)-[:ISIS_ADJACENT {metric: 10, adj_sids: [100003]}]->(b)
+			CREATE (a)-[:ISIS_ADJACENT {metric: 50, adj_sids: [100004]}]->(c)
+			CREATE (c)-[:ISIS_ADJACENT {metric: 50, adj_sids: [100005]}]->(a)
+		`, nil)
+		return err
+	}
+	apitesting.SetupTestNeo4jWithData(t, testNeo4jDB, seedFunc)
+}
+
+func TestGetSRPaths(t *testing.T) {
+	seedSRTriangle(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/topology/sr-paths?from=dev-a&to=dev-b&via=dev-c", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetSRPaths(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response handlers.SRPathsResponse
+	err := json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Empty(t, response.Error)
+
+	// The IGP shortest path is a single node segment
+	require.NotNil(t, response.Shortest)
+	assert.Equal(t, []string{"A-DZ01", "B-DZ01"}, response.Shortest.Path)
+	assert.Equal(t, []uint32{16002}, response.Shortest.Labels)
+
+	// Through c: a reaches c by b, then c comes back to b
+	require.NotNil(t, response.Explicit)
+	assert.Equal(t, []string{"A-DZ01", "B-DZ01", "C-DZ01", "B-DZ01"}, response.Explicit.Path)
+	assert.Equal(t, []uint32{16001, 16002}, response.Explicit.Labels)
+
+	// The alternate over the direct link needs its adjacency SID
+	require.Len(t, response.Alternates, 1)
+	assert.Equal(t, []string{"A-DZ01", "C-DZ01", "B-DZ01"}, response.Alternates[0].Path)
+	assert.Equal(t, []uint32{100004, 16002}, response.Alternates[0].Labels)
+	assert.Equal(t, uint64(60), response.Alternates[0].Metric)
+}
+
+func TestGetSRPaths_MissingParams(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/topology/sr-paths?from=dev-a", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetSRPaths(rr, req)
+
+	var response handlers.SRPathsResponse
+	err := json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Equal(t, "from and to parameters are required", response.Error)
+}
+
+func TestGetSRValidation(t *testing.T) {
+	seedSRTriangle(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/topology/sr-validation", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetSRValidation(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response handlers.SRValidationResponse
+	err := json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+	assert.Empty(t, response.Error)
+	assert.Equal(t, 3, response.DeviceCount)
+
+	require.Equal(t, 1, response.IssueCount)
+	assert.Equal(t, "duplicate_prefix_sid", response.Issues[0].Type)
+	assert.Equal(t, []string{"A-DZ01", "C-DZ01"}, response.Issues[0].DeviceCodes)
+	assert.Equal(t, uint32(1), response.Issues[0].SID)
+}
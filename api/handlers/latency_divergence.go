@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+const (
+	// minDivergenceSamples is the fewest latency samples a link needs before its metric is
+	// judged against them.
+	minDivergenceSamples = 10
+	// minStaleMetricDeltaUs ignores metric drift below this, so short links don't flag on noise.
+	minStaleMetricDeltaUs = 100
+)
+
+// LatencyDivergencePair compares the path IS-IS picks between two metros with the fastest
+// measured path.
+type LatencyDivergencePair struct {
+	FromMetroCode  string   `json:"fromMetroCode"`
+	ToMetroCode    string   `json:"toMetroCode"`
+	IGPPath        []string `json:"igpPath"` // device codes
+	IGPMetric      uint64   `json:"igpMetric"`
+	IGPMeasuredMs  float64  `json:"igpMeasuredMs"`
+	BestPath       []string `json:"bestPath"`
+	BestMeasuredMs float64  `json:"bestMeasuredMs"`
+	DeltaMs        float64  `json:"deltaMs"`
+	DeltaPct       float64  `json:"deltaPct"`
+	// Hops along either path with no recent latency samples, counted at their metric
+	UnmeasuredHops int  `json:"unmeasuredHops"`
+	Divergent      bool `json:"divergent"`
+}
+
+// LatencyDivergenceLink is a link whose IS-IS metric no longer matches its measured RTT.
+type LatencyDivergenceLink struct {
+	LinkPK          string  `json:"linkPK"`
+	LinkCode        string  `json:"linkCode"`
+	SideACode       string  `json:"sideACode"`
+	SideZCode       string  `json:"sideZCode"`
+	ISISMetric      uint32  `json:"isisMetric"`
+	DelayOverrideNs int64   `json:"delayOverrideNs"`
+	MeasuredRttUs   float64 `json:"measuredRttUs"`
+	SampleCount     uint64  `json:"sampleCount"`
+	DeltaPct        float64 `json:"deltaPct"` // positive when the metric overstates the latency
+	// Source is what sets the stale metric: "delay_override" when an override is configured,
+	// otherwise "metric"
+	Source          string `json:"source"`
+	SuggestedMetric uint32 `json:"suggestedMetric"`
+	// DivergentPairCount is the number of divergent metro pairs whose IGP path uses the link
+	DivergentPairCount int `json:"divergentPairCount"`
+}
+
+// LatencyDivergenceResponse is the response for the latency divergence report.
+type LatencyDivergenceResponse struct {
+	Hours   int                     `json:"hours"`
+	Pairs   []LatencyDivergencePair `json:"pairs"`
+	Links   []LatencyDivergenceLink `json:"links"`
+	Summary struct {
+		TotalPairs     int     `json:"totalPairs"`
+		DivergentPairs int     `json:"divergentPairs"`
+		AvgDeltaMs     float64 `json:"avgDeltaMs"` // over divergent pairs
+		MaxDeltaMs     float64 `json:"maxDeltaMs"`
+		StaleLinks     int     `json:"staleLinks"`
+	} `json:"summary"`
+	Error string `json:"error,omitempty"`
+}
+
+// GetLatencyDivergence compares, for every metro pair, the measured latency of the IS-IS
+// shortest path with the fastest path by measured link RTTs, and lists the links whose metric
+// or delay override is stale against their measured RTT with a suggested metric.
+func GetLatencyDivergence(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	hours := 3
+	if h := r.URL.Query().Get("hours"); h != "" {
+		if v, err := strconv.Atoi(h); err == nil && v > 0 && v <= 168 {
+			hours = v
+		}
+	}
+	minDeltaMs := 1.0
+	if d := r.URL.Query().Get("min_delta_ms"); d != "" {
+		if v, err := strconv.ParseFloat(d, 64); err == nil && v >= 0 {
+			minDeltaMs = v
+		}
+	}
+	tolerance := 0.25
+	if t := r.URL.Query().Get("tolerance"); t != "" {
+		if v, err := strconv.ParseFloat(t, 64); err == nil && v > 0 {
+			tolerance = v
+		}
+	}
+
+	start := time.Now()
+	response := LatencyDivergenceResponse{
+		Hours: hours,
+		Pairs: []LatencyDivergencePair{},
+		Links: []LatencyDivergenceLink{},
+	}
+
+	topo, err := loadRoutingTopology(ctx)
+	if err != nil {
+		log.Printf("Latency divergence topology error: %v", err)
+		response.Error = err.Error()
+		writeJSON(w, response)
+		return
+	}
+	measured, err := queryLinkLatencySamples(ctx, hours)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		log.Printf("Latency divergence query error: %v", err)
+		response.Error = err.Error()
+		writeJSON(w, response)
+		return
+	}
+
+	d := newLatencyDivergence(topo, loadDeviceMetros(ctx), measured)
+	pairs, onDivergent := d.pairs(minDeltaMs)
+	response.Pairs = pairs
+	response.Links = d.staleLinks(tolerance, onDivergent)
+
+	response.Summary.TotalPairs = len(pairs)
+	var totalDelta float64
+	for _, p := range pairs {
+		if p.Divergent {
+			response.Summary.DivergentPairs++
+			totalDelta += p.DeltaMs
+			response.Summary.MaxDeltaMs = max(response.Summary.MaxDeltaMs, p.DeltaMs)
+		}
+	}
+	if response.Summary.DivergentPairs > 0 {
+		response.Summary.AvgDeltaMs = totalDelta / float64(response.Summary.DivergentPairs)
+	}
+	response.Summary.StaleLinks = len(response.Links)
+
+	writeJSON(w, response)
+}
+
+// linkLatencySample is a link's average measured RTT over the report window.
+type linkLatencySample struct {
+	code            string
+	delayOverrideNs int64
+	rttUs           float64
+	samples         uint64
+}
+
+// queryLinkLatencySamples returns the average RTT of every link with successful probes in the
+// last hours, by link PK.
+func queryLinkLatencySamples(ctx context.Context, hours int) (map[string]linkLatencySample, error) {
+	query := fmt.Sprintf(`
+		SELECT
+			l.pk,
+			l.code,
+			COALESCE(l.isis_delay_override_ns, 0),
+			avg(lat.rtt_us),
+			count(*)
+		FROM dz_links_current l
+		JOIN fact_dz_device_link_latency lat ON l.pk = lat.link_pk
+		WHERE lat.event_ts > now() - INTERVAL %d HOUR
+		  AND NOT lat.loss
+		  AND lat.rtt_us > 0
+		GROUP BY l.pk, l.code, l.isis_delay_override_ns
+	`, hours)
+	rows, err := envDB(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("link latency query error: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make(map[string]linkLatencySample)
+	for rows.Next() {
+		var pk string
+		var s linkLatencySample
+		if err := rows.Scan(&pk, &s.code, &s.delayOverrideNs, &s.rttUs, &s.samples); err != nil {
+			return nil, fmt.Errorf("link latency scan error: %w", err)
+		}
+		samples[pk] = s
+	}
+	return samples, rows.Err()
+}
+
+// latencyDivergence routes the topology twice, by IS-IS metric and by measured RTT.
+type latencyDivergence struct {
+	topo     *routing.Topology
+	fastest  *routing.Topology // weighted by measured RTT in microseconds
+	metros   map[string]routingMetro
+	measured map[string]linkLatencySample
+}
+
+func newLatencyDivergence(topo *routing.Topology, metros map[string]routingMetro, measured map[string]linkLatencySample) *latencyDivergence {
+	d := &latencyDivergence{topo: topo, metros: metros, measured: measured}
+	d.fastest = topo.Reweighted(func(adj routing.Adjacency) uint32 {
+		rttUs, _ := d.adjacencyRTT(adj)
+		return uint32(math.Round(rttUs))
+	})
+	return d
+}
+
+// adjacencyRTT returns the RTT of the fastest measured link carrying an adjacency, falling back
+// to its metric, which IS-IS sets from RTT in microseconds, when none has samples.
+func (d *latencyDivergence) adjacencyRTT(adj routing.Adjacency) (float64, bool) {
+	best, ok := 0.0, false
+	for _, pk := range adj.LinkPKs {
+		if s, found := d.measured[pk]; found && (!ok || s.rttUs < best) {
+			best, ok = s.rttUs, true
+		}
+	}
+	if !ok {
+		return float64(adj.Metric), false
+	}
+	return best, true
+}
+
+// pathRTT returns the measured RTT of a path in milliseconds, the links it crosses and the
+// number of hops without samples.
+func (d *latencyDivergence) pathRTT(devices []string) (float64, []string, int) {
+	var totalUs float64
+	var links []string
+	unmeasured := 0
+	for i := 0; i+1 < len(devices); i++ {
+		for _, adj := range d.topo.Adjacencies(devices[i]) {
+			if adj.To != devices[i+1] {
+				continue
+			}
+			rttUs, ok := d.adjacencyRTT(adj)
+			totalUs += rttUs
+			if !ok {
+				unmeasured++
+			}
+			links = append(links, adj.LinkPKs...)
+			break
+		}
+	}
+	return totalUs / 1000, links, unmeasured
+}
+
+// pairs compares the IGP and fastest paths between every pair of metros, from the metro with
+// the lower code. The IGP path is the one between the metros' devices with the lowest metric.
+// It also returns, per link, how many divergent pairs route over it.
+func (d *latencyDivergence) pairs(minDeltaMs float64) ([]LatencyDivergencePair, map[string]int) {
+	byMetro := make(map[string][]string)
+	for _, pk := range d.topo.DevicePKs() {
+		if m := d.metros[pk]; m.Code != "" {
+			byMetro[m.Code] = append(byMetro[m.Code], pk)
+		}
+	}
+	codes := make([]string, 0, len(byMetro))
+	for code := range byMetro {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	type tree struct{ igp, fastest *routing.Tree }
+	trees := make(map[string]tree)
+	for _, pk := range d.topo.DevicePKs() {
+		trees[pk] = tree{igp: d.topo.SPF(pk), fastest: d.fastest.SPF(pk)}
+	}
+
+	var pairs []LatencyDivergencePair
+	onDivergent := make(map[string]int)
+	for i, from := range codes {
+		for _, to := range codes[i+1:] {
+			var igpPath, bestPath []string
+			var igpMetric, bestMetric uint64
+			for _, src := range byMetro[from] {
+				t := trees[src]
+				for _, dst := range byMetro[to] {
+					if metric, ok := t.igp.Metric(dst); ok && (igpPath == nil || metric < igpMetric) {
+						igpPath, igpMetric = t.igp.Paths(dst, 1)[0].Devices, metric
+					}
+					if metric, ok := t.fastest.Metric(dst); ok && (bestPath == nil || metric < bestMetric) {
+						bestPath, bestMetric = t.fastest.Paths(dst, 1)[0].Devices, metric
+					}
+				}
+			}
+			if igpPath == nil {
+				continue
+			}
+
+			igpMs, igpLinks, igpUnmeasured := d.pathRTT(igpPath)
+			bestMs, _, bestUnmeasured := d.pathRTT(bestPath)
+			p := LatencyDivergencePair{
+				FromMetroCode:  from,
+				ToMetroCode:    to,
+				IGPPath:        d.codes(igpPath),
+				IGPMetric:      igpMetric,
+				IGPMeasuredMs:  igpMs,
+				BestPath:       d.codes(bestPath),
+				BestMeasuredMs: bestMs,
+				DeltaMs:        max(igpMs-bestMs, 0),
+				UnmeasuredHops: igpUnmeasured + bestUnmeasured,
+			}
+			if bestMs > 0 {
+				p.DeltaPct = p.DeltaMs / bestMs * 100
+			}
+			p.Divergent = p.DeltaMs > 0 && p.DeltaMs >= minDeltaMs
+			if p.Divergent {
+				for _, pk := range igpLinks {
+					onDivergent[pk]++
+				}
+			}
+			pairs = append(pairs, p)
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].Divergent != pairs[j].Divergent {
+			return pairs[i].Divergent
+		}
+		return pairs[i].DeltaMs > pairs[j].DeltaMs
+	})
+	return pairs, onDivergent
+}
+
+// staleLinks returns the links whose metric differs from their measured RTT by more than
+// tolerance, as a fraction of the RTT, suggesting the RTT as the metric. Soft-drained links
+// are skipped as their override is deliberate. Links on the most divergent pairs come first.
+func (d *latencyDivergence) staleLinks(tolerance float64, onDivergent map[string]int) []LatencyDivergenceLink {
+	// 1000ms delay override in nanoseconds indicates soft-drained
+	const delayOverrideSoftDrainedNs = 1_000_000_000
+
+	links := []LatencyDivergenceLink{}
+	for pk, s := range d.measured {
+		l, ok := d.topo.Link(pk)
+		if !ok || l.Metric == 0 || s.samples < minDivergenceSamples || s.delayOverrideNs == delayOverrideSoftDrainedNs {
+			continue
+		}
+		deltaUs := float64(l.Metric) - s.rttUs
+		if math.Abs(deltaUs) < minStaleMetricDeltaUs || math.Abs(deltaUs) <= tolerance*s.rttUs {
+			continue
+		}
+		source := "metric"
+		if s.delayOverrideNs > 0 {
+			source = "delay_override"
+		}
+		links = append(links, LatencyDivergenceLink{
+			LinkPK:             pk,
+			LinkCode:           s.code,
+			SideACode:          deviceCode(d.topo, l.SideAPK),
+			SideZCode:          deviceCode(d.topo, l.SideZPK),
+			ISISMetric:         l.Metric,
+			DelayOverrideNs:    s.delayOverrideNs,
+			MeasuredRttUs:      s.rttUs,
+			SampleCount:        s.samples,
+			DeltaPct:           deltaUs / s.rttUs * 100,
+			Source:             source,
+			SuggestedMetric:    uint32(max(math.Round(s.rttUs), 1)),
+			DivergentPairCount: onDivergent[pk],
+		})
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].DivergentPairCount != links[j].DivergentPairCount {
+			return links[i].DivergentPairCount > links[j].DivergentPairCount
+		}
+		if a, b := math.Abs(links[i].DeltaPct), math.Abs(links[j].DeltaPct); a != b {
+			return a > b
+		}
+		return links[i].LinkCode < links[j].LinkCode
+	})
+	return links
+}
+
+func (d *latencyDivergence) codes(pks []string) []string {
+	codes := make([]string, len(pks))
+	for i, pk := range pks {
+		codes[i] = deviceCode(d.topo, pk)
+	}
+	return codes
+}
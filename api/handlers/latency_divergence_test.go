@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyDivergence(t *testing.T) {
+	t.Parallel()
+
+	// IS-IS prefers a-b-c, but both of its links measure slower than their metrics claim and
+	// the direct a-c link is faster
+	var adjacencies []graph.ISISAdjacency
+	var links []graph.ISISLink
+	for _, l := range []struct {
+		a, z   string
+		metric uint32
+	}{{"a", "b", 1000}, {"b", "c", 1000}, {"a", "c", 3000}, {"c", "d", 500}} {
+		pk := l.a + "-" + l.z
+		adjacencies = append(adjacencies,
+			graph.ISISAdjacency{FromDevicePK: l.a, ToDevicePK: l.z, Metric: l.metric},
+			graph.ISISAdjacency{FromDevicePK: l.z, ToDevicePK: l.a, Metric: l.metric},
+		)
+		links = append(links, graph.ISISLink{PK: pk, Code: pk, SideAPK: l.a, SideZPK: l.z, ISISMetric: l.metric})
+	}
+	topo := routing.NewTopology([]graph.ISISDevice{
+		{PK: "a", Code: "a-dz1"}, {PK: "b", Code: "b-dz1"}, {PK: "c", Code: "c-dz1"}, {PK: "d", Code: "d-dz1"}, {PK: "e", Code: "e-dz1"},
+	}, adjacencies, links)
+
+	metros := map[string]routingMetro{
+		"a": {Code: "AMS"},
+		"c": {Code: "FRA"},
+		"e": {Code: "LON"}, // Unreachable, so it pairs with nothing
+	}
+	measured := map[string]linkLatencySample{
+		"a-b": {code: "a-b", rttUs: 2500, samples: 100},
+		"b-c": {code: "b-c", rttUs: 2500, samples: 100, delayOverrideNs: 1_000_000},
+		"a-c": {code: "a-c", rttUs: 3000, samples: 100},
+		// Soft-drained, so its metric is deliberately off
+		"c-d": {code: "c-d", rttUs: 5000, samples: 100, delayOverrideNs: 1_000_000_000},
+	}
+
+	d := newLatencyDivergence(topo, metros, measured)
+	pairs, onDivergent := d.pairs(1)
+	require.Len(t, pairs, 1)
+	p := pairs[0]
+	assert.Equal(t, "AMS", p.FromMetroCode)
+	assert.Equal(t, "FRA", p.ToMetroCode)
+	assert.Equal(t, []string{"a-dz1", "b-dz1", "c-dz1"}, p.IGPPath)
+	assert.Equal(t, uint64(2000), p.IGPMetric)
+	assert.InDelta(t, 5.0, p.IGPMeasuredMs, 1e-9)
+	assert.Equal(t, []string{"a-dz1", "c-dz1"}, p.BestPath)
+	assert.InDelta(t, 3.0, p.BestMeasuredMs, 1e-9)
+	assert.InDelta(t, 2.0, p.DeltaMs, 1e-9)
+	assert.True(t, p.Divergent)
+	assert.Equal(t, map[string]int{"a-b": 1, "b-c": 1}, onDivergent)
+
+	// A larger threshold keeps the pair but no longer flags it
+	pairs, onDivergent = d.pairs(5)
+	assert.False(t, pairs[0].Divergent)
+	assert.Empty(t, onDivergent)
+
+	stale := d.staleLinks(0.25, map[string]int{"a-b": 1, "b-c": 1})
+	require.Len(t, stale, 2)
+	assert.Equal(t, "a-b", stale[0].LinkCode)
+	assert.Equal(t, "metric", stale[0].Source)
+	assert.Equal(t, uint32(2500), stale[0].SuggestedMetric)
+	assert.InDelta(t, -60.0, stale[0].DeltaPct, 1e-9)
+	assert.Equal(t, 1, stale[0].DivergentPairCount)
+	assert.Equal(t, "b-c", stale[1].LinkCode)
+	assert.Equal(t, "delay_override", stale[1].Source)
+
+	// Too few samples to judge
+	measured["a-b"] = linkLatencySample{code: "a-b", rttUs: 2500, samples: 3}
+	assert.Len(t, d.staleLinks(0.25, nil), 1)
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+// LinkAnomalyEventDetails contains details for link latency/jitter/loss anomaly events
+type LinkAnomalyEventDetails struct {
+	LinkPK          string  `json:"link_pk"`
+	LinkCode        string  `json:"link_code"`
+	LinkType        string  `json:"link_type"`
+	SideAMetro      string  `json:"side_a_metro"`
+	SideZMetro      string  `json:"side_z_metro"`
+	ContributorCode string  `json:"contributor_code,omitempty"`
+	Metric          string  `json:"metric"` // "rtt", "jitter" or "loss"
+	PeakValue       float64 `json:"peak_value"`
+	BaselineMedian  float64 `json:"baseline_median"`
+	PeakScore       float64 `json:"peak_score"`
+	Windows         int     `json:"windows"`
+	Start           string  `json:"start"`
+	End             string  `json:"end"`
+}
+
+// LinkAnomalyAlert is a link whose latency, jitter or loss is currently deviating from its
+// baseline
+type LinkAnomalyAlert struct {
+	PK             string  `json:"pk"`
+	Code           string  `json:"code"`
+	LinkType       string  `json:"link_type"`
+	Contributor    string  `json:"contributor"`
+	SideAMetro     string  `json:"side_a_metro"`
+	SideZMetro     string  `json:"side_z_metro"`
+	Metric         string  `json:"metric"`
+	Severity       string  `json:"severity"`
+	Value          float64 `json:"value"`           // Latest anomalous window
+	BaselineMedian float64 `json:"baseline_median"` // Baseline of the latest anomalous window
+	Score          float64 `json:"score"`
+	Since          string  `json:"since"` // ISO timestamp when the anomaly started
+	// Planned is set when the anomaly started during a maintenance window covering the link or its devices
+	Planned             bool    `json:"planned"`
+	MaintenanceWindowID *string `json:"maintenance_window_id,omitempty"`
+}
+
+// linkAnomalyEpisode is a run of consecutive anomalous windows for one link and metric.
+type linkAnomalyEpisode struct {
+	linkPK          string
+	linkCode        string
+	linkType        string
+	sideAMetro      string
+	sideZMetro      string
+	contributorCode string
+	metric          string
+	start           time.Time
+	end             time.Time // end of the last window
+	window          time.Duration
+	windows         int
+	severity        string
+	peakValue       float64
+	peakBaseline    float64
+	peakScore       float64
+	lastValue       float64
+	lastBaseline    float64
+	lastScore       float64
+}
+
+// linkAnomalyEpisodeLookback is how far before a requested range anomalies are read, so episodes
+// that began earlier are reported with their true start.
+const linkAnomalyEpisodeLookback = 6 * time.Hour
+
+// queryLinkAnomalyEpisodes reads the anomalous windows in [startTime, endTime] and groups
+// consecutive windows of the same link and metric into episodes.
+func queryLinkAnomalyEpisodes(ctx context.Context, startTime, endTime time.Time) ([]linkAnomalyEpisode, error) {
+	query := `
+		SELECT
+			a.link_pk,
+			a.metric,
+			a.event_ts,
+			a.window_seconds,
+			a.value,
+			a.baseline_median,
+			a.score,
+			a.severity,
+			COALESCE(l.code, '') as link_code,
+			COALESCE(l.link_type, '') as link_type,
+			COALESCE(ma.code, '') as side_a_metro,
+			COALESCE(mz.code, '') as side_z_metro,
+			COALESCE(c.code, '') as contributor_code
+		FROM fact_dz_link_anomalies AS a FINAL
+		LEFT JOIN dz_links_current l ON a.link_pk = l.pk
+		LEFT JOIN dz_contributors_current c ON l.contributor_pk = c.pk
+		LEFT JOIN dz_devices_current da ON l.side_a_pk = da.pk
+		LEFT JOIN dz_devices_current dz ON l.side_z_pk = dz.pk
+		LEFT JOIN dz_metros_current ma ON da.metro_pk = ma.pk
+		LEFT JOIN dz_metros_current mz ON dz.metro_pk = mz.pk
+		WHERE a.event_ts >= ? AND a.event_ts <= ?
+		  AND a.severity != 'cleared'
+		ORDER BY a.link_pk, a.metric, a.event_ts
+	`
+
+	start := time.Now()
+	rows, err := envDB(ctx).Query(ctx, query, startTime, endTime)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var episodes []linkAnomalyEpisode
+	for rows.Next() {
+		var (
+			e             linkAnomalyEpisode
+			eventTS       time.Time
+			windowSeconds int32
+			value         float64
+			baseline      float64
+			score         float64
+			severity      string
+		)
+		if err := rows.Scan(&e.linkPK, &e.metric, &eventTS, &windowSeconds, &value, &baseline, &score, &severity,
+			&e.linkCode, &e.linkType, &e.sideAMetro, &e.sideZMetro, &e.contributorCode); err != nil {
+			return nil, fmt.Errorf("link anomaly scan error: %w", err)
+		}
+		if e.linkCode == "" {
+			e.linkCode = e.linkPK
+		}
+		eventTS = eventTS.UTC()
+		window := time.Duration(windowSeconds) * time.Second
+
+		// Extend the current episode if this window directly follows it
+		if n := len(episodes); n > 0 {
+			last := &episodes[n-1]
+			if last.linkPK == e.linkPK && last.metric == e.metric && !eventTS.After(last.end) {
+				last.end = eventTS.Add(window)
+				last.windows++
+				if severity == "critical" {
+					last.severity = severity
+				}
+				if score > last.peakScore {
+					last.peakValue, last.peakBaseline, last.peakScore = value, baseline, score
+				}
+				last.lastValue, last.lastBaseline, last.lastScore = value, baseline, score
+				continue
+			}
+		}
+
+		e.start = eventTS
+		e.end = eventTS.Add(window)
+		e.window = window
+		e.windows = 1
+		e.severity = severity
+		e.peakValue, e.peakBaseline, e.peakScore = value, baseline, score
+		e.lastValue, e.lastBaseline, e.lastScore = value, baseline, score
+		episodes = append(episodes, e)
+	}
+	return episodes, rows.Err()
+}
+
+// ongoing reports whether the episode may still be in progress at now. The window after an
+// episode is only scored once it completes, so an episode counts as ongoing until then.
+func (e linkAnomalyEpisode) ongoing(now time.Time) bool {
+	return now.Before(e.end.Add(2 * e.window))
+}
+
+func linkAnomalyMetricLabel(metric string) (label, eventPrefix string) {
+	switch metric {
+	case "rtt":
+		return "Latency", "latency"
+	case "jitter":
+		return "Jitter", "jitter"
+	default:
+		return "Packet loss", "loss"
+	}
+}
+
+func formatLinkAnomalyValue(metric string, v float64) string {
+	if metric == "loss" {
+		return fmt.Sprintf("%.1f%%", v)
+	}
+	return fmt.Sprintf("%.2fms", v/1000)
+}
+
+// queryLinkAnomalyEvents returns a started event for each anomaly episode that began in the
+// range and a recovered event for each that ended in it.
+func queryLinkAnomalyEvents(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
+	episodes, err := queryLinkAnomalyEpisodes(ctx, startTime.Add(-linkAnomalyEpisodeLookback), endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	var events []TimelineEvent
+	for _, e := range episodes {
+		label, prefix := linkAnomalyMetricLabel(e.metric)
+		details := LinkAnomalyEventDetails{
+			LinkPK:          e.linkPK,
+			LinkCode:        e.linkCode,
+			LinkType:        e.linkType,
+			SideAMetro:      e.sideAMetro,
+			SideZMetro:      e.sideZMetro,
+			ContributorCode: e.contributorCode,
+			Metric:          e.metric,
+			PeakValue:       e.peakValue,
+			BaselineMedian:  e.peakBaseline,
+			PeakScore:       e.peakScore,
+			Windows:         e.windows,
+			Start:           e.start.Format(time.RFC3339),
+			End:             e.end.Format(time.RFC3339),
+		}
+		description := fmt.Sprintf("%s %s vs baseline %s (%.1f× normal spread)", label,
+			formatLinkAnomalyValue(e.metric, e.peakValue), formatLinkAnomalyValue(e.metric, e.peakBaseline), e.peakScore)
+
+		if !e.start.Before(startTime) && !e.start.After(endTime) {
+			eventType := prefix + "_anomaly_started"
+			events = append(events, TimelineEvent{
+				ID:          generateEventID(e.linkPK, e.start, eventType),
+				EventType:   eventType,
+				Timestamp:   e.start.Format(time.RFC3339),
+				Category:    "link_anomaly",
+				Severity:    e.severity,
+				Title:       fmt.Sprintf("%s anomaly on %s", label, e.linkCode),
+				Description: description,
+				EntityType:  "link",
+				EntityPK:    e.linkPK,
+				EntityCode:  e.linkCode,
+				Details:     details,
+			})
+		}
+
+		if !e.ongoing(now) && !e.end.Before(startTime) && !e.end.After(endTime) {
+			eventType := prefix + "_anomaly_recovered"
+			events = append(events, TimelineEvent{
+				ID:          generateEventID(e.linkPK, e.end, eventType),
+				EventType:   eventType,
+				Timestamp:   e.end.Format(time.RFC3339),
+				Category:    "link_anomaly",
+				Severity:    "success",
+				Title:       fmt.Sprintf("%s anomaly recovered on %s", label, e.linkCode),
+				Description: description,
+				EntityType:  "link",
+				EntityPK:    e.linkPK,
+				EntityCode:  e.linkCode,
+				Details:     details,
+			})
+		}
+	}
+	return events, nil
+}
+
+// queryActiveLinkAnomalies returns the anomaly episodes still in progress, most severe first.
+func queryActiveLinkAnomalies(ctx context.Context) ([]LinkAnomalyAlert, error) {
+	now := time.Now().UTC()
+	episodes, err := queryLinkAnomalyEpisodes(ctx, now.Add(-linkAnomalyEpisodeLookback), now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tagging only adds context, so a failed lookup leaves alerts untagged
+	schedule, err := loadMaintenanceSchedule(ctx, now.Add(-linkAnomalyEpisodeLookback), now)
+	if err != nil {
+		log.Printf("Planned anomaly tagging error: %v", err)
+		schedule = &maintenanceSchedule{}
+	}
+
+	alerts := []LinkAnomalyAlert{}
+	for _, e := range episodes {
+		if !e.ongoing(now) {
+			continue
+		}
+		var windowID *string
+		if mw := schedule.linkWindow(e.linkPK, e.start); mw != nil {
+			id := mw.ID.String()
+			windowID = &id
+		}
+		alerts = append(alerts, LinkAnomalyAlert{
+			PK:                  e.linkPK,
+			Code:                e.linkCode,
+			LinkType:            e.linkType,
+			Contributor:         e.contributorCode,
+			SideAMetro:          e.sideAMetro,
+			SideZMetro:          e.sideZMetro,
+			Metric:              e.metric,
+			Severity:            e.severity,
+			Value:               e.lastValue,
+			BaselineMedian:      e.lastBaseline,
+			Score:               e.lastScore,
+			Since:               e.start.Format(time.RFC3339),
+			Planned:             windowID != nil,
+			MaintenanceWindowID: windowID,
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if (alerts[i].Severity == "critical") != (alerts[j].Severity == "critical") {
+			return alerts[i].Severity == "critical"
+		}
+		return alerts[i].Score > alerts[j].Score
+	})
+	return alerts, nil
+}
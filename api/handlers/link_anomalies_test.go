@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/handlers"
+	apitesting "github.com/malbeclabs/lake/api/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertLinkAnomaly(t *testing.T, linkPK, metric string, windowStart time.Time, value, baseline, score float64, severity string) {
+	t.Helper()
+	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
+		`INSERT INTO fact_dz_link_anomalies (event_ts, ingested_at, link_pk, metric, window_seconds, value, baseline_median, baseline_mad, score, severity, samples, baseline_windows, seasonal)
+		 VALUES ('%s', now64(3), '%s', '%s', 300, %f, %f, 10, %f, '%s', 30, 100, false)`,
+		tsFormat(windowStart), linkPK, metric, value, baseline, score, severity)))
+}
+
+func TestLinkAnomalies(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+
+	now := time.Now().UTC().Truncate(5 * time.Minute)
+	past := now.Add(-2 * time.Hour)
+
+	// A finished latency episode of three windows, escalating to critical
+	insertLinkAnomaly(t, "link-1", "rtt", past, 12000, 10000, 6, "warning")
+	insertLinkAnomaly(t, "link-1", "rtt", past.Add(5*time.Minute), 16000, 10000, 20, "critical")
+	insertLinkAnomaly(t, "link-1", "rtt", past.Add(10*time.Minute), 13000, 10000, 8, "warning")
+	// An ongoing loss episode
+	insertLinkAnomaly(t, "link-2", "loss", now.Add(-5*time.Minute), 12.5, 0, 25, "critical")
+
+	t.Run("timeline reports episode start and recovery", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/timeline?start=%s&end=%s&category=link_anomaly",
+			now.Add(-3*time.Hour).Format(time.RFC3339), now.Add(time.Minute).Format(time.RFC3339)), nil)
+		rr := httptest.NewRecorder()
+		handlers.GetTimeline(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.TimelineResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+		byType := make(map[string]handlers.TimelineEvent)
+		for _, e := range resp.Events {
+			assert.Equal(t, "link_anomaly", e.Category)
+			byType[e.EntityPK+"/"+e.EventType] = e
+		}
+		require.Len(t, byType, 3)
+
+		started := byType["link-1/latency_anomaly_started"]
+		assert.Equal(t, past.Format(time.RFC3339), started.Timestamp)
+		assert.Equal(t, "critical", started.Severity)
+
+		recovered := byType["link-1/latency_anomaly_recovered"]
+		assert.Equal(t, past.Add(15*time.Minute).Format(time.RFC3339), recovered.Timestamp)
+		assert.Equal(t, "success", recovered.Severity)
+
+		lossStarted, ok := byType["link-2/loss_anomaly_started"]
+		require.True(t, ok)
+		assert.Equal(t, "critical", lossStarted.Severity)
+		_, ok = byType["link-2/loss_anomaly_recovered"]
+		assert.False(t, ok, "ongoing episode should not be reported as recovered")
+	})
+
+	t.Run("status alerts include ongoing anomalies", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		rr := httptest.NewRecorder()
+		handlers.GetStatus(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp handlers.StatusResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		require.Len(t, resp.Alerts.Anomalies, 1)
+		alert := resp.Alerts.Anomalies[0]
+		assert.Equal(t, "link-2", alert.PK)
+		assert.Equal(t, "loss", alert.Metric)
+		assert.Equal(t, 12.5, alert.Value)
+		assert.Equal(t, now.Add(-5*time.Minute).Format(time.RFC3339), alert.Since)
+	})
+}
@@ -0,0 +1,641 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/api/config"
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+// MaintenanceWindow is scheduled work that takes devices and links out of service
+type MaintenanceWindow struct {
+	ID        uuid.UUID             `json:"id"`
+	Env       string                `json:"env"`
+	Title     string                `json:"title"`
+	Entities  []MaintenanceEntity   `json:"entities"`
+	StartsAt  time.Time             `json:"starts_at"`
+	EndsAt    time.Time             `json:"ends_at"`
+	Owner     string                `json:"owner"`
+	Notes     string                `json:"notes"`
+	Status    string                `json:"status"` // "upcoming", "active" or "completed"
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	Conflicts []MaintenanceConflict `json:"conflicts,omitempty"`
+}
+
+// MaintenanceEntity is a device or link under maintenance
+type MaintenanceEntity struct {
+	Type string `json:"type"` // "device" or "link"
+	PK   string `json:"pk"`
+	Code string `json:"code,omitempty"`
+}
+
+// MaintenanceConflict is another window that overlaps in time and, together with this one,
+// cuts devices off from the rest of the network
+type MaintenanceConflict struct {
+	WindowID            uuid.UUID `json:"window_id"`
+	Title               string    `json:"title"`
+	Owner               string    `json:"owner"`
+	OverlapStart        time.Time `json:"overlap_start"`
+	OverlapEnd          time.Time `json:"overlap_end"`
+	DisconnectedDevices []string  `json:"disconnected_devices"`
+}
+
+// MaintenanceWindowListResponse is the response for listing maintenance windows
+type MaintenanceWindowListResponse struct {
+	Windows []MaintenanceWindow `json:"windows"`
+	Total   int                 `json:"total"`
+	HasMore bool                `json:"has_more"`
+}
+
+// MaintenanceWindowRequest is the request body for creating or replacing a maintenance window.
+// Owner defaults to the signed-in user.
+type MaintenanceWindowRequest struct {
+	Title    string              `json:"title"`
+	Entities []MaintenanceEntity `json:"entities"`
+	StartsAt time.Time           `json:"starts_at"`
+	EndsAt   time.Time           `json:"ends_at"`
+	Owner    string              `json:"owner"`
+	Notes    string              `json:"notes"`
+}
+
+const maintenanceWindowColumns = `id, env, title, entities, starts_at, ends_at, owner, notes, created_at, updated_at`
+
+// ListMaintenanceWindows returns maintenance windows for the environment, latest start first
+func ListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "upcoming" && status != "active" && status != "completed" {
+		http.Error(w, "status query parameter must be 'upcoming', 'active' or 'completed'", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	ctx := r.Context()
+	env := string(EnvFromContext(ctx))
+	filter := `env = $1 AND (
+		$2 = ''
+		OR ($2 = 'upcoming' AND starts_at > NOW())
+		OR ($2 = 'active' AND starts_at <= NOW() AND ends_at > NOW())
+		OR ($2 = 'completed' AND ends_at <= NOW())
+	)`
+
+	var total int
+	err := config.PgPool.QueryRow(ctx, `SELECT COUNT(*) FROM maintenance_windows WHERE `+filter, env, status).Scan(&total)
+	if err != nil {
+		http.Error(w, internalError("Failed to count maintenance windows", err), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := config.PgPool.Query(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM maintenance_windows
+		WHERE `+filter+`
+		ORDER BY starts_at DESC, id ASC
+		LIMIT $3 OFFSET $4
+	`, env, status, limit, offset)
+	if err != nil {
+		http.Error(w, internalError("Failed to list maintenance windows", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	windows := []MaintenanceWindow{}
+	for rows.Next() {
+		mw, err := scanMaintenanceWindow(rows, now)
+		if err != nil {
+			http.Error(w, internalError("Failed to scan maintenance window", err), http.StatusInternalServerError)
+			return
+		}
+		windows = append(windows, mw)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, internalError("Failed to iterate maintenance windows", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(MaintenanceWindowListResponse{
+		Windows: windows,
+		Total:   total,
+		HasMore: offset+len(windows) < total,
+	})
+}
+
+// GetMaintenanceWindow returns a single maintenance window of the environment with its conflicts
+func GetMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	mw, err := getMaintenanceWindow(ctx, string(EnvFromContext(ctx)), id)
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			http.Error(w, "Maintenance window not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, internalError("Failed to get maintenance window", err), http.StatusInternalServerError)
+		return
+	}
+	mw.Conflicts = maintenanceWindowConflicts(ctx, mw)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(mw)
+}
+
+// CreateMaintenanceWindow schedules a maintenance window and reports the windows it conflicts with
+func CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	req, accountID, ok := decodeMaintenanceWindowRequest(w, r)
+	if !ok {
+		return
+	}
+	entities, err := json.Marshal(req.Entities)
+	if err != nil {
+		http.Error(w, internalError("Failed to encode entities", err), http.StatusInternalServerError)
+		return
+	}
+
+	mw, err := scanMaintenanceWindow(config.PgPool.QueryRow(ctx, `
+		INSERT INTO maintenance_windows (env, title, entities, starts_at, ends_at, owner, account_id, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+maintenanceWindowColumns+`
+	`, string(EnvFromContext(ctx)), req.Title, entities, req.StartsAt, req.EndsAt, req.Owner, accountID, req.Notes), time.Now())
+	if err != nil {
+		http.Error(w, internalError("Failed to create maintenance window", err), http.StatusInternalServerError)
+		return
+	}
+	mw.Conflicts = maintenanceWindowConflicts(ctx, mw)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(mw)
+}
+
+// UpdateMaintenanceWindow replaces a maintenance window of the environment and reports the windows
+// it conflicts with
+func UpdateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	req, _, ok := decodeMaintenanceWindowRequest(w, r)
+	if !ok {
+		return
+	}
+	entities, err := json.Marshal(req.Entities)
+	if err != nil {
+		http.Error(w, internalError("Failed to encode entities", err), http.StatusInternalServerError)
+		return
+	}
+
+	mw, err := scanMaintenanceWindow(config.PgPool.QueryRow(ctx, `
+		UPDATE maintenance_windows
+		SET title = $2, entities = $3, starts_at = $4, ends_at = $5, owner = $6, notes = $7
+		WHERE id = $1 AND env = $8
+		RETURNING `+maintenanceWindowColumns+`
+	`, id, req.Title, entities, req.StartsAt, req.EndsAt, req.Owner, req.Notes, string(EnvFromContext(ctx))), time.Now())
+	if err != nil {
+		if err.Error() == "no rows in result set" {
+			http.Error(w, "Maintenance window not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, internalError("Failed to update maintenance window", err), http.StatusInternalServerError)
+		return
+	}
+	mw.Conflicts = maintenanceWindowConflicts(ctx, mw)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(mw)
+}
+
+// DeleteMaintenanceWindow removes a maintenance window of the environment
+func DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid maintenance window ID", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	tag, err := config.PgPool.Exec(ctx, `DELETE FROM maintenance_windows WHERE id = $1 AND env = $2`, id, string(EnvFromContext(ctx)))
+	if err != nil {
+		http.Error(w, internalError("Failed to delete maintenance window", err), http.StatusInternalServerError)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeMaintenanceWindowRequest reads and validates a window, filling in the owner and entity
+// codes. It writes the error response and returns false if the request is invalid.
+func decodeMaintenanceWindowRequest(w http.ResponseWriter, r *http.Request) (MaintenanceWindowRequest, *uuid.UUID, bool) {
+	var req MaintenanceWindowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, nil, false
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		http.Error(w, "title is required", http.StatusBadRequest)
+		return req, nil, false
+	}
+	if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+		http.Error(w, "starts_at and ends_at are required", http.StatusBadRequest)
+		return req, nil, false
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		http.Error(w, "ends_at must be after starts_at", http.StatusBadRequest)
+		return req, nil, false
+	}
+
+	entities, err := normalizeMaintenanceEntities(req.Entities)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, nil, false
+	}
+	req.Entities = entities
+	resolveMaintenanceEntityCodes(r.Context(), req.Entities)
+
+	accountID, author := accountAuthor(r.Context())
+	req.Owner = strings.TrimSpace(req.Owner)
+	if req.Owner == "" {
+		req.Owner = author
+	}
+	if req.Owner == "" {
+		http.Error(w, "owner is required", http.StatusBadRequest)
+		return req, nil, false
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	return req, accountID, true
+}
+
+// normalizeMaintenanceEntities validates entities and drops duplicates.
+func normalizeMaintenanceEntities(entities []MaintenanceEntity) ([]MaintenanceEntity, error) {
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("at least one device or link is required")
+	}
+	seen := make(map[string]bool, len(entities))
+	var out []MaintenanceEntity
+	for _, e := range entities {
+		e.PK = strings.TrimSpace(e.PK)
+		if e.Type != "device" && e.Type != "link" {
+			return nil, fmt.Errorf("entity type must be 'device' or 'link'")
+		}
+		if e.PK == "" {
+			return nil, fmt.Errorf("entity pk is required")
+		}
+		if seen[e.Type+":"+e.PK] {
+			continue
+		}
+		seen[e.Type+":"+e.PK] = true
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// resolveMaintenanceEntityCodes fills in missing device and link codes. Codes only label
+// windows, so a failed lookup is logged and leaves them blank.
+func resolveMaintenanceEntityCodes(ctx context.Context, entities []MaintenanceEntity) {
+	missing := map[string][]string{}
+	for _, e := range entities {
+		if e.Code == "" {
+			missing[e.Type] = append(missing[e.Type], e.PK)
+		}
+	}
+
+	codes := make(map[string]string)
+	for typ, table := range map[string]string{"device": "dz_devices_current", "link": "dz_links_current"} {
+		if len(missing[typ]) == 0 {
+			continue
+		}
+		start := time.Now()
+		rows, err := envDB(ctx).Query(ctx, `SELECT pk, code FROM `+table+` WHERE pk IN (?)`, missing[typ])
+		metrics.RecordClickHouseQuery(time.Since(start), err)
+		if err != nil {
+			log.Printf("Maintenance entity codes query error: %v", err)
+			return
+		}
+		for rows.Next() {
+			var pk, code string
+			if err := rows.Scan(&pk, &code); err != nil {
+				rows.Close()
+				log.Printf("Maintenance entity codes scan error: %v", err)
+				return
+			}
+			codes[typ+":"+pk] = code
+		}
+		rows.Close()
+	}
+	for i := range entities {
+		if entities[i].Code == "" {
+			entities[i].Code = codes[entities[i].Type+":"+entities[i].PK]
+		}
+	}
+}
+
+// accountAuthor returns the signed-in account and the name to record for it, if any.
+func accountAuthor(ctx context.Context) (*uuid.UUID, string) {
+	account := GetAccountFromContext(ctx)
+	if account == nil {
+		return nil, ""
+	}
+	switch {
+	case account.DisplayName != nil && *account.DisplayName != "":
+		return &account.ID, *account.DisplayName
+	case account.Email != nil:
+		return &account.ID, *account.Email
+	case account.WalletAddress != nil:
+		return &account.ID, *account.WalletAddress
+	}
+	return &account.ID, ""
+}
+
+type maintenanceWindowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMaintenanceWindow(row maintenanceWindowScanner, now time.Time) (MaintenanceWindow, error) {
+	var mw MaintenanceWindow
+	var entities []byte
+	if err := row.Scan(&mw.ID, &mw.Env, &mw.Title, &entities, &mw.StartsAt, &mw.EndsAt, &mw.Owner, &mw.Notes,
+		&mw.CreatedAt, &mw.UpdatedAt); err != nil {
+		return mw, err
+	}
+	if err := json.Unmarshal(entities, &mw.Entities); err != nil {
+		return mw, fmt.Errorf("failed to decode entities: %w", err)
+	}
+	switch {
+	case now.Before(mw.StartsAt):
+		mw.Status = "upcoming"
+	case now.Before(mw.EndsAt):
+		mw.Status = "active"
+	default:
+		mw.Status = "completed"
+	}
+	return mw, nil
+}
+
+func getMaintenanceWindow(ctx context.Context, env string, id uuid.UUID) (MaintenanceWindow, error) {
+	return scanMaintenanceWindow(config.PgPool.QueryRow(ctx,
+		`SELECT `+maintenanceWindowColumns+` FROM maintenance_windows WHERE id = $1 AND env = $2`, id, env), time.Now())
+}
+
+// loadMaintenanceWindows returns the environment's windows that overlap [from, to).
+func loadMaintenanceWindows(ctx context.Context, env string, from, to time.Time) ([]MaintenanceWindow, error) {
+	rows, err := config.PgPool.Query(ctx, `
+		SELECT `+maintenanceWindowColumns+`
+		FROM maintenance_windows
+		WHERE env = $1 AND starts_at < $3 AND ends_at > $2
+		ORDER BY starts_at ASC, id ASC
+	`, env, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var windows []MaintenanceWindow
+	for rows.Next() {
+		mw, err := scanMaintenanceWindow(rows, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance window: %w", err)
+		}
+		windows = append(windows, mw)
+	}
+	return windows, rows.Err()
+}
+
+// maintenanceWindowConflicts checks a window against the others that overlap it. Conflicts
+// only add detail to a window, so a failed check is logged and yields nil.
+func maintenanceWindowConflicts(ctx context.Context, mw MaintenanceWindow) []MaintenanceConflict {
+	overlapping, err := loadMaintenanceWindows(ctx, mw.Env, mw.StartsAt, mw.EndsAt)
+	if err != nil {
+		log.Printf("Maintenance conflicts error: %v", err)
+		return nil
+	}
+	var others []MaintenanceWindow
+	for _, o := range overlapping {
+		if o.ID != mw.ID {
+			others = append(others, o)
+		}
+	}
+	if len(others) == 0 {
+		return []MaintenanceConflict{}
+	}
+
+	topo, err := loadRoutingTopology(ctx)
+	if err != nil {
+		log.Printf("Maintenance conflicts topology error: %v", err)
+		return nil
+	}
+	failures := map[uuid.UUID]routing.Failures{mw.ID: maintenanceFailures(ctx, topo, mw)}
+	for _, o := range others {
+		failures[o.ID] = maintenanceFailures(ctx, topo, o)
+	}
+	return findMaintenanceConflicts(topo, mw, others, failures)
+}
+
+// maintenanceFailures resolves a window's entities to routing failures. Links that cannot be
+// resolved are logged and skipped.
+func maintenanceFailures(ctx context.Context, topo *routing.Topology, mw MaintenanceWindow) routing.Failures {
+	var sets []routing.Failures
+	for _, e := range mw.Entities {
+		if e.Type == "device" {
+			sets = append(sets, routing.Failures{Devices: []string{e.PK}})
+			continue
+		}
+		f, _, err := resolveLinkFailure(ctx, topo, e.PK)
+		if err != nil {
+			log.Printf("Maintenance window %s: %v", mw.ID, err)
+			continue
+		}
+		sets = append(sets, f)
+	}
+	return mergeFailures(sets...)
+}
+
+// findMaintenanceConflicts returns the windows among others that overlap mw in time and,
+// taken down together with it, cut off devices that neither window cuts off alone.
+func findMaintenanceConflicts(topo *routing.Topology, mw MaintenanceWindow, others []MaintenanceWindow, failures map[uuid.UUID]routing.Failures) []MaintenanceConflict {
+	own := topo.Disconnected(failures[mw.ID])
+	conflicts := []MaintenanceConflict{}
+	for _, o := range others {
+		if o.ID == mw.ID || !o.StartsAt.Before(mw.EndsAt) || !mw.StartsAt.Before(o.EndsAt) {
+			continue
+		}
+
+		alone := make(map[string]bool)
+		for _, pk := range own {
+			alone[pk] = true
+		}
+		for _, pk := range topo.Disconnected(failures[o.ID]) {
+			alone[pk] = true
+		}
+		var disconnected []string
+		for _, pk := range topo.Disconnected(mergeFailures(failures[mw.ID], failures[o.ID])) {
+			if !alone[pk] {
+				disconnected = append(disconnected, deviceCode(topo, pk))
+			}
+		}
+		if len(disconnected) == 0 {
+			continue
+		}
+		sort.Strings(disconnected)
+
+		conflict := MaintenanceConflict{
+			WindowID:            o.ID,
+			Title:               o.Title,
+			Owner:               o.Owner,
+			OverlapStart:        mw.StartsAt,
+			OverlapEnd:          mw.EndsAt,
+			DisconnectedDevices: disconnected,
+		}
+		if o.StartsAt.After(conflict.OverlapStart) {
+			conflict.OverlapStart = o.StartsAt
+		}
+		if o.EndsAt.Before(conflict.OverlapEnd) {
+			conflict.OverlapEnd = o.EndsAt
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// maintenanceSchedule tells whether devices and links were under planned maintenance.
+type maintenanceSchedule struct {
+	windows []MaintenanceWindow
+	// Endpoint devices of each link, so a device's maintenance covers its links
+	linkDevices map[string][]string
+}
+
+// loadMaintenanceSchedule loads the environment's windows that overlap [from, to). Without a
+// Postgres pool the schedule is empty.
+func loadMaintenanceSchedule(ctx context.Context, from, to time.Time) (*maintenanceSchedule, error) {
+	s := &maintenanceSchedule{linkDevices: make(map[string][]string)}
+	if config.PgPool == nil {
+		return s, nil
+	}
+	windows, err := loadMaintenanceWindows(ctx, string(EnvFromContext(ctx)), from, to)
+	if err != nil {
+		return nil, err
+	}
+	s.windows = windows
+
+	hasDevices := false
+	for _, mw := range windows {
+		for _, e := range mw.Entities {
+			hasDevices = hasDevices || e.Type == "device"
+		}
+	}
+	if !hasDevices {
+		return s, nil
+	}
+
+	start := time.Now()
+	query := `SELECT pk, COALESCE(side_a_pk, ''), COALESCE(side_z_pk, '') FROM dz_links_current`
+	rows, err := envDB(ctx).Query(ctx, query)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query link endpoints: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var pk, sideAPK, sideZPK string
+		if err := rows.Scan(&pk, &sideAPK, &sideZPK); err != nil {
+			return nil, fmt.Errorf("failed to scan link endpoints: %w", err)
+		}
+		s.linkDevices[pk] = []string{sideAPK, sideZPK}
+	}
+	return s, rows.Err()
+}
+
+// window returns the window under which any of the devices or links was in maintenance at t,
+// or nil.
+func (s *maintenanceSchedule) window(at time.Time, pks ...string) *MaintenanceWindow {
+	for i := range s.windows {
+		mw := &s.windows[i]
+		if at.Before(mw.StartsAt) || !at.Before(mw.EndsAt) {
+			continue
+		}
+		for _, e := range mw.Entities {
+			for _, pk := range pks {
+				if pk != "" && e.PK == pk {
+					return mw
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// linkWindow returns the window under which the link or either of its devices was in
+// maintenance at t, or nil.
+func (s *maintenanceSchedule) linkWindow(linkPK string, at time.Time) *MaintenanceWindow {
+	return s.window(at, append([]string{linkPK}, s.linkDevices[linkPK]...)...)
+}
+
+// tagPlannedOutages marks outages that started during maintenance of their link or its
+// devices. Tagging only adds context, so a failed lookup is logged and leaves outages untagged.
+func tagPlannedOutages(ctx context.Context, outages []LinkOutage) {
+	starts := make([]time.Time, len(outages))
+	var from time.Time
+	for i, o := range outages {
+		t, err := time.Parse(time.RFC3339, o.StartedAt)
+		if err != nil {
+			continue
+		}
+		starts[i] = t
+		if from.IsZero() || t.Before(from) {
+			from = t
+		}
+	}
+	if from.IsZero() {
+		return
+	}
+
+	schedule, err := loadMaintenanceSchedule(ctx, from, time.Now())
+	if err != nil {
+		log.Printf("Planned outage tagging error: %v", err)
+		return
+	}
+	for i := range outages {
+		if starts[i].IsZero() {
+			continue
+		}
+		if mw := schedule.linkWindow(outages[i].LinkPK, starts[i]); mw != nil {
+			id := mw.ID.String()
+			outages[i].Planned = true
+			outages[i].MaintenanceWindowID = &id
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeMaintenanceEntities(t *testing.T) {
+	t.Parallel()
+
+	entities, err := normalizeMaintenanceEntities([]MaintenanceEntity{
+		{Type: "device", PK: " d1 "},
+		{Type: "link", PK: "l1"},
+		{Type: "device", PK: "d1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []MaintenanceEntity{{Type: "device", PK: "d1"}, {Type: "link", PK: "l1"}}, entities)
+
+	_, err = normalizeMaintenanceEntities(nil)
+	assert.Error(t, err)
+	_, err = normalizeMaintenanceEntities([]MaintenanceEntity{{Type: "metro", PK: "m1"}})
+	assert.Error(t, err)
+	_, err = normalizeMaintenanceEntities([]MaintenanceEntity{{Type: "link"}})
+	assert.Error(t, err)
+}
+
+func TestFindMaintenanceConflicts(t *testing.T) {
+	t.Parallel()
+
+	// A ring a-b-c-d: any single link can go down, but two together cut the ring in half
+	var adjacencies []graph.ISISAdjacency
+	var links []graph.ISISLink
+	for _, pair := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "d"}, {"d", "a"}} {
+		adjacencies = append(adjacencies,
+			graph.ISISAdjacency{FromDevicePK: pair[0], ToDevicePK: pair[1], Metric: 10},
+			graph.ISISAdjacency{FromDevicePK: pair[1], ToDevicePK: pair[0], Metric: 10},
+		)
+		links = append(links, graph.ISISLink{PK: pair[0] + "-" + pair[1], SideAPK: pair[0], SideZPK: pair[1]})
+	}
+	topo := routing.NewTopology([]graph.ISISDevice{
+		{PK: "a", Code: "a-dz1"}, {PK: "b", Code: "b-dz1"}, {PK: "c", Code: "c-dz1"}, {PK: "d", Code: "d-dz1"},
+	}, adjacencies, links)
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	window := func(title string, start, end int) MaintenanceWindow {
+		return MaintenanceWindow{
+			ID:       uuid.New(),
+			Title:    title,
+			StartsAt: base.Add(time.Duration(start) * time.Hour),
+			EndsAt:   base.Add(time.Duration(end) * time.Hour),
+		}
+	}
+	mw := window("a-b", 0, 4)
+	overlapping := window("c-d", 2, 6)
+	later := window("c-d later", 4, 8)
+	parallel := window("b-c", 1, 3)
+	failures := map[uuid.UUID]routing.Failures{
+		mw.ID:          {Links: []string{"a-b"}},
+		overlapping.ID: {Links: []string{"c-d"}},
+		later.ID:       {Links: []string{"c-d"}},
+		// Together with a-b this isolates b
+		parallel.ID: {Links: []string{"b-c"}},
+	}
+
+	// The window itself and windows that only touch it at the boundary are skipped
+	conflicts := findMaintenanceConflicts(topo, mw, []MaintenanceWindow{mw, overlapping, later, parallel}, failures)
+	require.Len(t, conflicts, 2)
+
+	assert.Equal(t, overlapping.ID, conflicts[0].WindowID)
+	assert.Equal(t, base.Add(2*time.Hour), conflicts[0].OverlapStart)
+	assert.Equal(t, base.Add(4*time.Hour), conflicts[0].OverlapEnd)
+	assert.Len(t, conflicts[0].DisconnectedDevices, 2)
+
+	assert.Equal(t, parallel.ID, conflicts[1].WindowID)
+	assert.Equal(t, []string{"b-dz1"}, conflicts[1].DisconnectedDevices)
+
+	// A window that cuts b off by itself only conflicts over the devices it cuts off with c-d
+	failures[mw.ID] = routing.Failures{Links: []string{"a-b", "b-c"}}
+	conflicts = findMaintenanceConflicts(topo, mw, []MaintenanceWindow{overlapping}, failures)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, []string{"c-dz1"}, conflicts[0].DisconnectedDevices)
+
+	failures[overlapping.ID] = routing.Failures{}
+	assert.Empty(t, findMaintenanceConflicts(topo, mw, []MaintenanceWindow{overlapping}, failures))
+}
+
+func TestMaintenanceSchedule(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	deviceWindow := MaintenanceWindow{
+		ID:       uuid.New(),
+		Entities: []MaintenanceEntity{{Type: "device", PK: "d1"}},
+		StartsAt: base,
+		EndsAt:   base.Add(time.Hour),
+	}
+	linkWindow := MaintenanceWindow{
+		ID:       uuid.New(),
+		Entities: []MaintenanceEntity{{Type: "link", PK: "l2"}},
+		StartsAt: base.Add(2 * time.Hour),
+		EndsAt:   base.Add(3 * time.Hour),
+	}
+	s := &maintenanceSchedule{
+		windows:     []MaintenanceWindow{deviceWindow, linkWindow},
+		linkDevices: map[string][]string{"l1": {"d1", "d2"}, "l2": {"d3", "d4"}},
+	}
+
+	// Device maintenance covers the device's links
+	require.NotNil(t, s.linkWindow("l1", base.Add(30*time.Minute)))
+	assert.Equal(t, deviceWindow.ID, s.linkWindow("l1", base.Add(30*time.Minute)).ID)
+	assert.Equal(t, deviceWindow.ID, s.window(base, "d1").ID)
+
+	// Windows end exclusively
+	assert.Nil(t, s.linkWindow("l1", base.Add(time.Hour)))
+	assert.Nil(t, s.linkWindow("l1", base.Add(-time.Minute)))
+
+	// Link maintenance does not cover the link's devices
+	assert.Equal(t, linkWindow.ID, s.linkWindow("l2", base.Add(150*time.Minute)).ID)
+	assert.Nil(t, s.window(base.Add(150*time.Minute), "d3"))
+	assert.Nil(t, s.window(base, ""))
+}
@@ -57,8 +57,9 @@ func createMCPServer(r *http.Request) *mcp.Server {
 	registerReadDocsTool(server)
 	registerGetSchemaTool(server, r)
 
-	// Only add Cypher tool for mainnet-beta (where Neo4j is available)
-	if config.Neo4jClient != nil && env == EnvMainnet {
+	// Only add Cypher tool for envs with a Neo4j graph
+	cypherAvailable := config.Neo4jClientForEnv(string(env)) != nil
+	if cypherAvailable {
 		registerExecuteCypherTool(server, r)
 	}
 
@@ -68,7 +69,6 @@ func createMCPServer(r *http.Request) *mcp.Server {
 	registerCypherContextResource(server)
 
 	// Register prompts
-	cypherAvailable := config.Neo4jClient != nil && env == EnvMainnet
 	registerAnalyzeDataPrompt(server, cypherAvailable)
 
 	return server
@@ -197,13 +197,14 @@ type ExecuteCypherOutput struct {
 }
 
 func registerExecuteCypherTool(server *mcp.Server, r *http.Request) {
-	// Capture IP from original request for rate limiting
+	// Capture env and IP from original request for use in handler
+	env := EnvFromContext(r.Context())
 	ip := GetIPFromRequest(r)
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "execute_cypher",
 		Title:       "Execute Cypher",
-		Description: "Execute a Cypher query against the Neo4j graph database. Use this for topology questions, path finding, reachability analysis, relationship traversal, and latency between metros (finds the network path since SQL only has directly-connected pairs). Only available on mainnet-beta. Always provide a brief 'description' parameter summarizing what the query does.",
+		Description: "Execute a Cypher query against the Neo4j graph database. Use this for topology questions, path finding, reachability analysis, relationship traversal, and latency between metros (finds the network path since SQL only has directly-connected pairs). Always provide a brief 'description' parameter summarizing what the query does.",
 		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
 	}, func(ctx context.Context, req *mcp.CallToolRequest, input ExecuteCypherInput) (*mcp.CallToolResult, ExecuteCypherOutput, error) {
 		// Check rate limit
@@ -216,7 +217,10 @@ func registerExecuteCypherTool(server *mcp.Server, r *http.Request) {
 			return nil, ExecuteCypherOutput{}, errors.New("query is required")
 		}
 
-		if config.Neo4jClient == nil {
+		// Transfer env to handler context (r.Context() may be canceled in streamable HTTP)
+		ctx = ContextWithEnv(ctx, env)
+
+		if envNeo4jClient(ctx) == nil {
 			return nil, ExecuteCypherOutput{}, errors.New("Neo4j is not available in this environment")
 		}
 
@@ -225,7 +229,7 @@ func registerExecuteCypherTool(server *mcp.Server, r *http.Request) {
 		queryCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 		defer cancel()
 
-		session := config.Neo4jSession(queryCtx)
+		session := envNeo4jSession(queryCtx)
 		defer session.Close(queryCtx)
 
 		result, err := session.Run(queryCtx, query, nil)
@@ -484,7 +488,7 @@ func registerCypherContextResource(server *mcp.Server) {
 	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
 		content, err := commonprompts.PromptsFS.ReadFile("CYPHER_CONTEXT.md")
 		if err != nil {
-			// Cypher context is optional (only available on mainnet)
+			// Cypher context is optional (only used when the env has a graph)
 			return &mcp.ReadResourceResult{
 				Contents: []*mcp.ResourceContents{
 					{
@@ -162,7 +162,7 @@ func TestMCPHandler_ListTools(t *testing.T) {
 	assert.True(t, toolNames["execute_sql"], "should have execute_sql tool")
 	assert.True(t, toolNames["read_docs"], "should have read_docs tool")
 	assert.True(t, toolNames["get_schema"], "should have get_schema tool")
-	// execute_cypher only available on mainnet with Neo4j
+	// execute_cypher only available when the env has a Neo4j graph
 }
 
 func TestMCPHandler_ListResources(t *testing.T) {
@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/metrics"
 )
 
@@ -165,6 +164,9 @@ type MulticastMember struct {
 	LastLeaderSlot *int64  `json:"last_leader_slot"` // most recent past leader slot
 	NextLeaderSlot *int64  `json:"next_leader_slot"` // next upcoming leader slot
 	CurrentSlot    int64   `json:"current_slot"`     // current cluster slot
+	// Delivery is the subscriber's latest delivery of the group's traffic, nil for publishers
+	// and subscribers not yet evaluated
+	Delivery *MulticastMemberDelivery `json:"delivery"`
 }
 
 type MulticastGroupDetail struct {
@@ -176,6 +178,9 @@ type MulticastGroupDetail struct {
 	PublisherCount  uint32            `json:"publisher_count"`
 	SubscriberCount uint32            `json:"subscriber_count"`
 	Members         []MulticastMember `json:"members"`
+	// Delivery rolls up the subscribers' latest deliveries, nil if none were evaluated in the
+	// last hour
+	Delivery *MulticastDeliverySummary `json:"delivery"`
 }
 
 func GetMulticastGroup(w http.ResponseWriter, r *http.Request) {
@@ -477,6 +482,19 @@ func GetMulticastGroup(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Enrich subscribers with their latest delivery; best effort so the group still loads
+	// without it
+	if deliveries, err := queryMulticastDelivery(ctx, group.PK); err != nil {
+		log.Printf("MulticastGroup delivery query error (non-fatal): %v", err)
+	} else {
+		for i, m := range members {
+			if d, ok := deliveries[m.UserPK]; ok && (m.Mode == "S" || m.Mode == "P+S") {
+				members[i].Delivery = &d
+			}
+		}
+		group.Delivery = summarizeMulticastDelivery(deliveries)
+	}
+
 	group.Members = members
 
 	w.Header().Set("Content-Type", "application/json")
@@ -815,6 +833,12 @@ func GetMulticastTreePaths(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if envNeo4jClient(ctx) == nil {
+		response.Error = "graph data is not available for this environment"
+		writeJSON(w, response)
+		return
+	}
+
 	// Find paths from each publisher to each subscriber using Neo4j
 	type pathResult struct {
 		path MulticastTreePath
@@ -839,7 +863,7 @@ func GetMulticastTreePaths(w http.ResponseWriter, r *http.Request) {
 				queryCtx, queryCancel := context.WithTimeout(ctx, 5*time.Second)
 				defer queryCancel()
 
-				session := config.Neo4jSession(queryCtx)
+				session := envNeo4jSession(queryCtx)
 				defer session.Close(queryCtx)
 
 				// Use Dijkstra to find lowest latency path from publisher to subscriber
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+// MulticastDeliveryHop is the lossiest hop of the multicast tree toward a subscriber.
+type MulticastDeliveryHop struct {
+	LinkPK         string  `json:"link_pk"`
+	LinkCode       string  `json:"link_code"`
+	FromDevicePK   string  `json:"from_device_pk"`
+	FromDeviceCode string  `json:"from_device_code"`
+	ToDevicePK     string  `json:"to_device_pk"`
+	ToDeviceCode   string  `json:"to_device_code"`
+	LossPct        float64 `json:"loss_pct"`
+}
+
+// MulticastMemberDelivery is a subscriber's latest delivery, from fact_dz_multicast_delivery.
+type MulticastMemberDelivery struct {
+	EvaluatedAt   string  `json:"evaluated_at"` // start of the evaluated window
+	Status        string  `json:"status"`       // delivered, loss_in_tree, loss_at_edge, loss_unlocated, idle or no_data
+	ExpectedPkts  int64   `json:"expected_pkts"`
+	ReceivedPkts  int64   `json:"received_pkts"`
+	DeliveryRatio float64 `json:"delivery_ratio"`
+	LossPct       float64 `json:"loss_pct"`
+	// Exclusive is true when no tunnel involved carries another group, so the ratio is exact
+	Exclusive bool                  `json:"exclusive"`
+	WorstHop  *MulticastDeliveryHop `json:"worst_hop"`
+	PathHops  int32                 `json:"path_hops"`
+	LagMs     float64               `json:"lag_ms"` // one-way latency of the slowest publisher path
+}
+
+// MulticastDeliverySummary rolls up the latest delivery of a group's subscribers.
+type MulticastDeliverySummary struct {
+	EvaluatedAt  string  `json:"evaluated_at"`
+	Subscribers  int     `json:"subscribers"` // subscribers with a delivery in the last hour
+	Delivered    int     `json:"delivered"`
+	Lossy        int     `json:"lossy"`
+	AvgLossPct   float64 `json:"avg_loss_pct"` // over subscribers whose publishers sent traffic
+	WorstLossPct float64 `json:"worst_loss_pct"`
+	MaxLagMs     float64 `json:"max_lag_ms"`
+}
+
+// isLossyDelivery reports whether a delivery status is one of the loss statuses.
+func isLossyDelivery(status string) bool {
+	return status == "loss_in_tree" || status == "loss_at_edge" || status == "loss_unlocated"
+}
+
+// summarizeMulticastDelivery rolls up subscriber deliveries, or returns nil if there are none.
+func summarizeMulticastDelivery(deliveries map[string]MulticastMemberDelivery) *MulticastDeliverySummary {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	s := &MulticastDeliverySummary{Subscribers: len(deliveries)}
+	measured := 0
+	for _, d := range deliveries {
+		if d.EvaluatedAt > s.EvaluatedAt {
+			s.EvaluatedAt = d.EvaluatedAt
+		}
+		switch {
+		case d.Status == "delivered":
+			s.Delivered++
+		case isLossyDelivery(d.Status):
+			s.Lossy++
+		}
+		if d.Status != "idle" && d.Status != "no_data" {
+			measured++
+			s.AvgLossPct += d.LossPct
+			s.WorstLossPct = max(s.WorstLossPct, d.LossPct)
+		}
+		s.MaxLagMs = max(s.MaxLagMs, d.LagMs)
+	}
+	if measured > 0 {
+		s.AvgLossPct = math.Round(s.AvgLossPct/float64(measured)*1000) / 1000
+	}
+	return s
+}
+
+// queryMulticastDelivery returns the latest delivery of each of a group's subscribers,
+// keyed by user PK.
+func queryMulticastDelivery(ctx context.Context, groupPK string) (map[string]MulticastMemberDelivery, error) {
+	rows, err := envDB(ctx).Query(ctx, `
+		SELECT
+			dc.subscriber_pk,
+			formatDateTime(dc.event_ts, '%Y-%m-%dT%H:%i:%sZ', 'UTC') as evaluated_at,
+			dc.status,
+			dc.expected_pkts,
+			dc.received_pkts,
+			dc.delivery_ratio,
+			dc.loss_pct,
+			dc.exclusive,
+			dc.worst_hop_link_pk,
+			COALESCE(l.code, '') as link_code,
+			dc.worst_hop_from_device_pk,
+			COALESCE(df.code, '') as from_device_code,
+			dc.worst_hop_to_device_pk,
+			COALESCE(dt.code, '') as to_device_code,
+			dc.worst_hop_loss_pct,
+			dc.path_hops,
+			dc.lag_us / 1000.0 as lag_ms
+		FROM dz_multicast_delivery_current dc
+		LEFT JOIN dz_links_current l ON dc.worst_hop_link_pk = l.pk
+		LEFT JOIN dz_devices_current df ON dc.worst_hop_from_device_pk = df.pk
+		LEFT JOIN dz_devices_current dt ON dc.worst_hop_to_device_pk = dt.pk
+		WHERE dc.group_pk = ?
+	`, groupPK)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make(map[string]MulticastMemberDelivery)
+	for rows.Next() {
+		var (
+			userPK string
+			d      MulticastMemberDelivery
+			hop    MulticastDeliveryHop
+		)
+		if err := rows.Scan(&userPK, &d.EvaluatedAt, &d.Status, &d.ExpectedPkts, &d.ReceivedPkts,
+			&d.DeliveryRatio, &d.LossPct, &d.Exclusive, &hop.LinkPK, &hop.LinkCode,
+			&hop.FromDevicePK, &hop.FromDeviceCode, &hop.ToDevicePK, &hop.ToDeviceCode,
+			&hop.LossPct, &d.PathHops, &d.LagMs); err != nil {
+			return nil, err
+		}
+		if hop.LinkPK != "" {
+			d.WorstHop = &hop
+		}
+		deliveries[userPK] = d
+	}
+	return deliveries, rows.Err()
+}
+
+type MulticastDeliveryPoint struct {
+	Time          string  `json:"time"`
+	SubscriberPK  string  `json:"subscriber_pk"`
+	Status        string  `json:"status"`
+	DeliveryRatio float64 `json:"delivery_ratio"`
+	LossPct       float64 `json:"loss_pct"`
+	WorstHopLink  string  `json:"worst_hop_link_pk"`
+	WorstHopLoss  float64 `json:"worst_hop_loss_pct"`
+	LagMs         float64 `json:"lag_ms"`
+}
+
+// GetMulticastGroupDelivery returns the delivery of a group's subscribers per window over the
+// last hours (default 24, max 168).
+func GetMulticastGroupDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	pkOrCode := chi.URLParam(r, "pk")
+	if pkOrCode == "" {
+		http.Error(w, "missing multicast group pk", http.StatusBadRequest)
+		return
+	}
+	hours := 24
+	if h := r.URL.Query().Get("hours"); h != "" {
+		if v, err := strconv.Atoi(h); err == nil && v > 0 && v <= 168 {
+			hours = v
+		}
+	}
+
+	start := time.Now()
+
+	var groupPK string
+	err := envDB(ctx).QueryRow(ctx,
+		`SELECT pk FROM dz_multicast_groups_current WHERE pk = ? OR code = ?`, pkOrCode, pkOrCode).Scan(&groupPK)
+	if err != nil {
+		log.Printf("MulticastGroupDelivery group query error: %v", err)
+		http.Error(w, "multicast group not found", http.StatusNotFound)
+		return
+	}
+
+	query := `
+		SELECT
+			formatDateTime(event_ts, '%Y-%m-%dT%H:%i:%sZ', 'UTC') as time,
+			subscriber_pk,
+			status,
+			delivery_ratio,
+			loss_pct,
+			worst_hop_link_pk,
+			worst_hop_loss_pct,
+			lag_us / 1000.0 as lag_ms
+		FROM fact_dz_multicast_delivery FINAL
+		WHERE group_pk = ?
+			AND event_ts > now() - INTERVAL ? HOUR
+		ORDER BY event_ts, subscriber_pk
+	`
+
+	rows, err := envDB(ctx).Query(ctx, query, groupPK, hours)
+	duration := time.Since(start)
+	metrics.RecordClickHouseQuery(duration, err)
+
+	if err != nil {
+		log.Printf("MulticastGroupDelivery query error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	points := []MulticastDeliveryPoint{}
+	for rows.Next() {
+		var p MulticastDeliveryPoint
+		if err := rows.Scan(&p.Time, &p.SubscriberPK, &p.Status, &p.DeliveryRatio, &p.LossPct,
+			&p.WorstHopLink, &p.WorstHopLoss, &p.LagMs); err != nil {
+			log.Printf("MulticastGroupDelivery scan error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("MulticastGroupDelivery rows error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(points); err != nil {
+		log.Printf("JSON encoding error: %v", err)
+	}
+}
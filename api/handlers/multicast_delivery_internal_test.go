@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeMulticastDelivery(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, summarizeMulticastDelivery(nil))
+
+	s := summarizeMulticastDelivery(map[string]MulticastMemberDelivery{
+		"user-1": {EvaluatedAt: "2025-03-01T12:00:00Z", Status: "delivered", LossPct: 0, LagMs: 30},
+		"user-2": {EvaluatedAt: "2025-03-01T12:05:00Z", Status: "loss_in_tree", LossPct: 10, LagMs: 45},
+		"user-3": {EvaluatedAt: "2025-03-01T12:05:00Z", Status: "loss_at_edge", LossPct: 2},
+		// Idle subscribers don't count toward loss
+		"user-4": {EvaluatedAt: "2025-03-01T12:05:00Z", Status: "idle"},
+	})
+	require.NotNil(t, s)
+	assert.Equal(t, "2025-03-01T12:05:00Z", s.EvaluatedAt)
+	assert.Equal(t, 4, s.Subscribers)
+	assert.Equal(t, 1, s.Delivered)
+	assert.Equal(t, 2, s.Lossy)
+	assert.Equal(t, 4.0, s.AvgLossPct)
+	assert.Equal(t, 10.0, s.WorstLossPct)
+	assert.Equal(t, 45.0, s.MaxLagMs)
+}
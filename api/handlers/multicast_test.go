@@ -512,3 +512,96 @@ func TestGetMulticastGroup_ValidatorEnrichment(t *testing.T) {
 	assert.Equal(t, "vote-pub-1", pub.VotePubkey, "should resolve vote_pubkey from vote accounts")
 	assert.Equal(t, float64(5000), pub.StakeSol, "should resolve stake from vote accounts")
 }
+
+func insertMulticastDeliveryTestData(t *testing.T) {
+	ctx := t.Context()
+
+	// A clean window followed by one losing a tenth on the link into the subscriber's device
+	err := config.DB.Exec(ctx, `
+		INSERT INTO fact_dz_multicast_delivery
+			(event_ts, ingested_at, group_pk, subscriber_pk, subscriber_device_pk, subscriber_tunnel_id,
+			 window_seconds, publishers, expected_pkts, received_pkts, delivery_ratio, loss_pct, exclusive,
+			 status, worst_hop_from_device_pk, worst_hop_to_device_pk, worst_hop_link_pk, worst_hop_loss_pct,
+			 path_hops, unmeasured_hops, lag_us)
+		VALUES
+			(toStartOfFiveMinutes(now()) - INTERVAL 10 MINUTE, now(), 'group-1', 'user-sub', 'dev-nyc1', 502,
+			 300, 1, 10000, 10000, 1, 0, true, 'delivered', 'dev-ams1', 'dev-nyc1', 'link-1', 0, 1, 0, 35000),
+			(toStartOfFiveMinutes(now()) - INTERVAL 5 MINUTE, now(), 'group-1', 'user-sub', 'dev-nyc1', 502,
+			 300, 1, 10000, 9000, 0.9, 10, true, 'loss_in_tree', 'dev-ams1', 'dev-nyc1', 'link-1', 10, 1, 0, 35000)
+	`)
+	require.NoError(t, err)
+}
+
+func TestGetMulticastGroup_IncludesDelivery(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	insertMulticastTestData(t)
+	insertMulticastDeliveryTestData(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dz/multicast-groups/test-group", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("pk", "test-group")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handlers.GetMulticastGroup(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var detail handlers.MulticastGroupDetail
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&detail))
+
+	require.NotNil(t, detail.Delivery)
+	assert.Equal(t, 1, detail.Delivery.Subscribers)
+	assert.Equal(t, 1, detail.Delivery.Lossy)
+	assert.Equal(t, 10.0, detail.Delivery.WorstLossPct)
+	assert.Equal(t, 35.0, detail.Delivery.MaxLagMs)
+
+	for _, m := range detail.Members {
+		if m.Mode == "P" {
+			assert.Nil(t, m.Delivery, "publishers have no delivery")
+			continue
+		}
+		require.NotNil(t, m.Delivery)
+		assert.Equal(t, "loss_in_tree", m.Delivery.Status)
+		assert.Equal(t, 0.9, m.Delivery.DeliveryRatio)
+		require.NotNil(t, m.Delivery.WorstHop)
+		assert.Equal(t, "link-1", m.Delivery.WorstHop.LinkPK)
+		assert.Equal(t, "ams001-dz001", m.Delivery.WorstHop.FromDeviceCode)
+		assert.Equal(t, "nyc001-dz001", m.Delivery.WorstHop.ToDeviceCode)
+	}
+}
+
+func TestGetMulticastGroupDelivery_ReturnsHistory(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	insertMulticastTestData(t)
+	insertMulticastDeliveryTestData(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dz/multicast-groups/test-group/delivery?hours=1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("pk", "test-group")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handlers.GetMulticastGroupDelivery(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var points []handlers.MulticastDeliveryPoint
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&points))
+	require.Len(t, points, 2)
+	assert.Equal(t, "delivered", points[0].Status)
+	assert.Equal(t, "loss_in_tree", points[1].Status)
+	assert.Equal(t, "link-1", points[1].WorstHopLink)
+	assert.Equal(t, 10.0, points[1].LossPct)
+}
+
+func TestGetMulticastGroupDelivery_NotFound(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dz/multicast-groups/nonexistent/delivery", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("pk", "nonexistent")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handlers.GetMulticastGroupDelivery(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/malbeclabs/lake/agent/pkg/workflow"
-	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
@@ -22,7 +21,7 @@ func NewNeo4jQuerier() *Neo4jQuerier {
 
 // Query executes a Cypher query and returns formatted results.
 func (q *Neo4jQuerier) Query(ctx context.Context, cypher string) (workflow.QueryResult, error) {
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.Transaction) (any, error) {
@@ -399,7 +398,7 @@ func NewNeo4jSchemaFetcher() *Neo4jSchemaFetcher {
 
 // FetchSchema returns a formatted string describing the Neo4j graph schema.
 func (f *Neo4jSchemaFetcher) FetchSchema(ctx context.Context) (string, error) {
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	var sb strings.Builder
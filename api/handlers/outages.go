@@ -33,12 +33,16 @@ type LinkOutage struct {
 	DurationSeconds *int64   `json:"duration_seconds,omitempty"`
 	IsOngoing       bool     `json:"is_ongoing"`
 	Severity        string   `json:"severity"` // "degraded" or "outage"
+	// Planned is set when the outage started during a maintenance window covering the link or its devices
+	Planned             bool    `json:"planned"`
+	MaintenanceWindowID *string `json:"maintenance_window_id,omitempty"`
 }
 
 // LinkOutagesSummary contains aggregate counts for outages
 type LinkOutagesSummary struct {
 	Total   int            `json:"total"`
 	Ongoing int            `json:"ongoing"`
+	Planned int            `json:"planned"`
 	ByType  map[string]int `json:"by_type"`
 }
 
@@ -181,14 +185,27 @@ func GetLinkOutages(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
+	response, err := fetchLinkOutagesData(ctx, duration, threshold, outageType, filters)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// fetchLinkOutagesData fetches link outages of the given type ("all", "status",
+// "loss", or "no_data") over the duration. Used by both the handler and the
+// agent analytics tools.
+func fetchLinkOutagesData(ctx context.Context, duration time.Duration, threshold float64, outageType string, filters []OutageFilter) (*LinkOutagesResponse, error) {
 	var outages []LinkOutage
 
 	// Fetch status-based outages (drained states)
 	if outageType == "all" || outageType == "status" {
 		statusOutages, err := fetchStatusOutages(ctx, envDB(ctx), duration, filters)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to fetch status outages: %v", err), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("status outages: %w", err)
 		}
 		outages = append(outages, statusOutages...)
 	}
@@ -197,8 +214,7 @@ func GetLinkOutages(w http.ResponseWriter, r *http.Request) {
 	if outageType == "all" || outageType == "loss" {
 		lossOutages, err := fetchPacketLossOutages(ctx, envDB(ctx), duration, threshold, filters)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to fetch packet loss outages: %v", err), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("packet loss outages: %w", err)
 		}
 		outages = append(outages, lossOutages...)
 	}
@@ -207,12 +223,13 @@ func GetLinkOutages(w http.ResponseWriter, r *http.Request) {
 	if outageType == "all" || outageType == "no_data" {
 		noDataOutages, err := fetchNoDataOutages(ctx, envDB(ctx), duration, filters)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Failed to fetch no-data outages: %v", err), http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("no-data outages: %w", err)
 		}
 		outages = append(outages, noDataOutages...)
 	}
 
+	tagPlannedOutages(ctx, outages)
+
 	// Sort by start time (most recent first)
 	sort.Slice(outages, func(i, j int) bool {
 		return outages[i].StartedAt > outages[j].StartedAt
@@ -228,16 +245,16 @@ func GetLinkOutages(w http.ResponseWriter, r *http.Request) {
 		if o.IsOngoing {
 			summary.Ongoing++
 		}
+		if o.Planned {
+			summary.Planned++
+		}
 		summary.ByType[o.OutageType]++
 	}
 
-	response := LinkOutagesResponse{
+	return &LinkOutagesResponse{
 		Outages: outages,
 		Summary: summary,
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(response)
+	}, nil
 }
 
 // statusChange represents a link status change event
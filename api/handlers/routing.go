@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+// loadRoutingTopology loads the IS-IS topology of the environment in the context for
+// in-process SPF.
+func loadRoutingTopology(ctx context.Context) (*routing.Topology, error) {
+	client := envNeo4jClient(ctx)
+	if client == nil {
+		return nil, errors.New("graph database not configured")
+	}
+	store, err := graph.NewQueryStore(slog.Default(), client, "")
+	if err != nil {
+		return nil, err
+	}
+	return routing.Load(ctx, store)
+}
+
+// routingMetro is the metro a device sits in.
+type routingMetro struct {
+	PK   string
+	Code string
+}
+
+// loadDeviceMetros maps device PKs to their metros. Metros only label results, so a failed
+// lookup is logged and leaves them blank.
+func loadDeviceMetros(ctx context.Context) map[string]routingMetro {
+	start := time.Now()
+	query := `
+		SELECT d.pk, COALESCE(d.metro_pk, ''), COALESCE(m.code, '')
+		FROM dz_devices_current d
+		LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+	`
+	metros := make(map[string]routingMetro)
+	rows, err := envDB(ctx).Query(ctx, query)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		log.Printf("Device metros query error: %v", err)
+		return metros
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pk string
+		var metro routingMetro
+		if err := rows.Scan(&pk, &metro.PK, &metro.Code); err != nil {
+			log.Printf("Device metros scan error: %v", err)
+			return metros
+		}
+		metros[pk] = metro
+	}
+	return metros
+}
+
+// resolveLinkFailure returns the failure of a link and its "sideA - sideZ" display code. Links
+// the IS-IS topology does not know fail the adjacency between their endpoints instead.
+func resolveLinkFailure(ctx context.Context, topo *routing.Topology, linkPK string) (routing.Failures, string, error) {
+	if l, ok := topo.Link(linkPK); ok {
+		return routing.Failures{Links: []string{linkPK}}, deviceCode(topo, l.SideAPK) + " - " + deviceCode(topo, l.SideZPK), nil
+	}
+
+	query := `SELECT COALESCE(side_a_pk, ''), COALESCE(side_z_pk, '') FROM dz_links_current WHERE pk = ?`
+	var sideAPK, sideZPK string
+	if err := envDB(ctx).QueryRow(ctx, query, linkPK).Scan(&sideAPK, &sideZPK); err != nil {
+		return routing.Failures{}, "", fmt.Errorf("link %s not found: %w", linkPK, err)
+	}
+	if sideAPK == "" || sideZPK == "" {
+		return routing.Failures{}, "", fmt.Errorf("link %s is missing endpoints", linkPK)
+	}
+	return routing.Failures{Edges: []routing.Edge{routing.NewEdge(sideAPK, sideZPK)}},
+		deviceCode(topo, sideAPK) + " - " + deviceCode(topo, sideZPK), nil
+}
+
+// mergeFailures combines failure sets.
+func mergeFailures(sets ...routing.Failures) routing.Failures {
+	var f routing.Failures
+	for _, s := range sets {
+		f.Devices = append(f.Devices, s.Devices...)
+		f.Links = append(f.Links, s.Links...)
+		f.Edges = append(f.Edges, s.Edges...)
+	}
+	return f
+}
+
+// deviceCode returns a device's code, falling back to its PK.
+func deviceCode(topo *routing.Topology, pk string) string {
+	if d, ok := topo.Device(pk); ok && d.Code != "" {
+		return d.Code
+	}
+	return pk
+}
+
+// routePathCodes returns the device codes along the first of a route's equal-cost paths.
+func routePathCodes(topo *routing.Topology, r routing.Route) []string {
+	if len(r.Paths) == 0 {
+		return nil
+	}
+	codes := make([]string, len(r.Paths[0].Devices))
+	for i, pk := range r.Paths[0].Devices {
+		codes[i] = deviceCode(topo, pk)
+	}
+	return codes
+}
+
+// routeChangeView is a route change resolved to device codes and metros for responses.
+type routeChangeView struct {
+	source, target           string
+	sourceMetro, targetMetro string
+	hopsBefore, hopsAfter    int // after is -1 when disconnected
+	metricBefore             int
+	metricAfter              int // -1 when disconnected
+	metricDelta              int
+	beforePath, afterPath    []string
+	status                   string
+}
+
+// viewRouteChange resolves a route change for responses.
+func viewRouteChange(topo *routing.Topology, metros map[string]routingMetro, c routing.RouteChange) routeChangeView {
+	v := routeChangeView{
+		source:       deviceCode(topo, c.Source),
+		target:       deviceCode(topo, c.Target),
+		sourceMetro:  metros[c.Source].Code,
+		targetMetro:  metros[c.Target].Code,
+		hopsBefore:   c.Before.Hops,
+		hopsAfter:    -1,
+		metricBefore: int(c.Before.Metric),
+		metricAfter:  -1,
+		beforePath:   routePathCodes(topo, c.Before),
+		status:       routeChangeStatus(c),
+	}
+	if c.After.Reachable {
+		v.hopsAfter = c.After.Hops
+		v.metricAfter = int(c.After.Metric)
+		v.metricDelta = int(c.MetricDelta())
+		v.afterPath = routePathCodes(topo, c.After)
+	}
+	return v
+}
+
+// routeChangeStatus classifies a route change: "disconnected" when no path is left,
+// "degraded" when the new path costs more than 2 extra hops or 50 extra metric, otherwise
+// "rerouted".
+func routeChangeStatus(c routing.RouteChange) string {
+	if !c.After.Reachable {
+		return "disconnected"
+	}
+	if c.After.Hops-c.Before.Hops > 2 || c.MetricDelta() > 50 {
+		return "degraded"
+	}
+	return "rerouted"
+}
+
+// routingImpact is the impact of a set of failures resolved for responses.
+type routingImpact struct {
+	disconnected []string // device codes
+	changes      []routeChangeView
+}
+
+// analyzeRoutingImpact runs SPF with and without the failures and resolves every route change.
+func analyzeRoutingImpact(topo *routing.Topology, metros map[string]routingMetro, f routing.Failures) routingImpact {
+	impact := topo.Impact(f, 1)
+	result := routingImpact{
+		disconnected: make([]string, 0, len(impact.Disconnected)),
+		changes:      make([]routeChangeView, 0, len(impact.Changes)),
+	}
+	for _, pk := range impact.Disconnected {
+		result.disconnected = append(result.disconnected, deviceCode(topo, pk))
+	}
+	for _, c := range impact.Changes {
+		result.changes = append(result.changes, viewRouteChange(topo, metros, c))
+	}
+	return result
+}
+
+func (v routeChangeView) whatIfPath() WhatIfAffectedPath {
+	return WhatIfAffectedPath{
+		Source:       v.source,
+		Target:       v.target,
+		SourceMetro:  v.sourceMetro,
+		TargetMetro:  v.targetMetro,
+		HopsBefore:   v.hopsBefore,
+		MetricBefore: v.metricBefore,
+		HopsAfter:    v.hopsAfter,
+		MetricAfter:  v.metricAfter,
+		MetricDelta:  v.metricDelta,
+		BeforePath:   v.beforePath,
+		AfterPath:    v.afterPath,
+		Status:       v.status,
+	}
+}
+
+func (v routeChangeView) maintenancePath() MaintenanceAffectedPath {
+	return MaintenanceAffectedPath{
+		Source:       v.source,
+		Target:       v.target,
+		SourceMetro:  v.sourceMetro,
+		TargetMetro:  v.targetMetro,
+		HopsBefore:   v.hopsBefore,
+		HopsAfter:    v.hopsAfter,
+		MetricBefore: v.metricBefore,
+		MetricAfter:  v.metricAfter,
+		MetricDelta:  v.metricDelta,
+		BeforePath:   v.beforePath,
+		AfterPath:    v.afterPath,
+		Status:       v.status,
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeRoutingImpact(t *testing.T) {
+	t.Parallel()
+
+	// fra and ams are joined directly and the long way round through lon; nyc hangs off lon
+	var adjacencies []graph.ISISAdjacency
+	for _, e := range []struct {
+		a, z   string
+		metric uint32
+	}{{"fra", "ams", 10}, {"fra", "lon", 40}, {"lon", "ams", 40}, {"lon", "nyc", 100}} {
+		adjacencies = append(adjacencies,
+			graph.ISISAdjacency{FromDevicePK: e.a, FromCode: e.a + "-dz1", ToDevicePK: e.z, ToCode: e.z + "-dz1", Metric: e.metric},
+			graph.ISISAdjacency{FromDevicePK: e.z, FromCode: e.z + "-dz1", ToDevicePK: e.a, ToCode: e.a + "-dz1", Metric: e.metric},
+		)
+	}
+	topo := routing.NewTopology(nil, adjacencies, nil)
+	metros := map[string]routingMetro{
+		"fra": {PK: "m-fra", Code: "fra"},
+		"ams": {PK: "m-ams", Code: "ams"},
+		"lon": {PK: "m-lon", Code: "lon"},
+		"nyc": {PK: "m-nyc", Code: "nyc"},
+	}
+
+	failures := routing.Failures{Edges: []routing.Edge{routing.NewEdge("fra", "ams")}}
+	impact := analyzeRoutingImpact(topo, metros, failures)
+	assert.Empty(t, impact.disconnected)
+	require.Len(t, impact.changes, 1)
+
+	path := impact.changes[0].maintenancePath()
+	assert.Equal(t, "ams-dz1", path.Source)
+	assert.Equal(t, "fra-dz1", path.Target)
+	assert.Equal(t, 10, path.MetricBefore)
+	assert.Equal(t, 80, path.MetricAfter)
+	assert.Equal(t, 70, path.MetricDelta)
+	assert.Equal(t, []string{"ams-dz1", "lon-dz1", "fra-dz1"}, path.AfterPath)
+	assert.Equal(t, "degraded", path.Status)
+
+	pairs := maintenanceAffectedMetros(topo, metros, failures, impact)
+	require.Len(t, pairs, 1)
+	assert.Equal(t, "ams", pairs[0].SourceMetro)
+	assert.Equal(t, "fra", pairs[0].TargetMetro)
+	assert.Equal(t, "degraded", pairs[0].Status)
+
+	impact = analyzeRoutingImpact(topo, metros, routing.Failures{Devices: []string{"lon"}})
+	assert.Equal(t, []string{"nyc-dz1"}, impact.disconnected)
+	for _, c := range impact.changes {
+		assert.Equal(t, "disconnected", c.status)
+		assert.Equal(t, -1, c.whatIfPath().HopsAfter)
+	}
+}
@@ -334,8 +334,10 @@ func searchUsers(ctx context.Context, term string, limit int) ([]SearchSuggestio
 		SELECT
 			u.pk,
 			u.kind,
-			COALESCE(u.dz_ip, '') as dz_ip
+			COALESCE(u.dz_ip, '') as dz_ip,
+			COALESCE(h.status, '') as health_status
 		FROM dz_users_current u
+		LEFT JOIN dz_user_health_current h ON u.pk = h.user_pk
 		WHERE ` + mainCondition + `
 		ORDER BY u.pk
 		LIMIT ?
@@ -349,8 +351,8 @@ func searchUsers(ctx context.Context, term string, limit int) ([]SearchSuggestio
 
 	var suggestions []SearchSuggestion
 	for rows.Next() {
-		var pk, kind, dzIP string
-		if err := rows.Scan(&pk, &kind, &dzIP); err != nil {
+		var pk, kind, dzIP, healthStatus string
+		if err := rows.Scan(&pk, &kind, &dzIP, &healthStatus); err != nil {
 			return nil, 0, err
 		}
 		// Truncate pk for display
@@ -362,6 +364,10 @@ func searchUsers(ctx context.Context, term string, limit int) ([]SearchSuggestio
 		if dzIP != "" {
 			sublabel = fmt.Sprintf("%s - %s", kind, dzIP)
 		}
+		// Flag unhealthy tunnels so they stand out in results
+		if healthStatus != "" && healthStatus != "healthy" {
+			sublabel = fmt.Sprintf("%s (%s)", sublabel, strings.ReplaceAll(healthStatus, "_", " "))
+		}
 		suggestions = append(suggestions, SearchSuggestion{
 			Type:     string(entityUser),
 			ID:       pk,
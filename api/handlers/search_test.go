@@ -70,6 +70,15 @@ func setupSearchTables(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
+	// Create user health table
+	err = config.DB.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS dz_user_health_current (
+			user_pk String,
+			status String
+		) ENGINE = Memory
+	`)
+	require.NoError(t, err)
+
 	// Create validators table
 	err = config.DB.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS solana_vote_accounts_current (
@@ -129,6 +138,20 @@ func insertSearchTestData(t *testing.T) {
 	`)
 	require.NoError(t, err)
 
+	// Insert users, one with an at-risk tunnel
+	err = config.DB.Exec(ctx, `
+		INSERT INTO dz_users_current (pk, kind, owner_pubkey, dz_ip) VALUES
+		('userhealthy1234567890', 'ibrl', 'owner1', '100.64.0.1'),
+		('userrisky1234567890', 'ibrl', 'owner2', '100.64.0.2')
+	`)
+	require.NoError(t, err)
+	err = config.DB.Exec(ctx, `
+		INSERT INTO dz_user_health_current (user_pk, status) VALUES
+		('userhealthy1234567890', 'healthy'),
+		('userrisky1234567890', 'at_risk')
+	`)
+	require.NoError(t, err)
+
 	// Insert validators
 	err = config.DB.Exec(ctx, `
 		INSERT INTO solana_vote_accounts_current (vote_pubkey, node_pubkey, activated_stake_lamports, epoch_vote_account) VALUES
@@ -309,6 +332,30 @@ func TestSearchAutocomplete_IPPrefix(t *testing.T) {
 	assert.True(t, foundDevice)
 }
 
+func TestSearchAutocomplete_UserHealth(t *testing.T) {
+	apitesting.SetupTestClickHouse(t, testChDB)
+	setupSearchTables(t)
+	insertSearchTestData(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search/autocomplete?q=user:user", nil)
+	rr := httptest.NewRecorder()
+	handlers.SearchAutocomplete(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response handlers.AutocompleteResponse
+	err := json.NewDecoder(rr.Body).Decode(&response)
+	require.NoError(t, err)
+
+	// Only unhealthy tunnels are flagged
+	sublabels := map[string]string{}
+	for _, s := range response.Suggestions {
+		sublabels[s.ID] = s.Sublabel
+	}
+	assert.Equal(t, "ibrl - 100.64.0.1", sublabels["userhealthy1234567890"])
+	assert.Equal(t, "ibrl - 100.64.0.2 (at risk)", sublabels["userrisky1234567890"])
+}
+
 func TestSearch_EmptyQuery(t *testing.T) {
 	apitesting.SetupTestClickHouse(t, testChDB)
 	setupSearchTables(t)
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+const (
+	// defaultSRAlternates is the number of alternate paths encoded when none is requested.
+	defaultSRAlternates = 3
+	// maxSRAlternates caps the alternate paths encoded per request.
+	maxSRAlternates = 10
+)
+
+// SRSegment is one entry of a segment routing label stack.
+type SRSegment struct {
+	Type     string `json:"type"` // "node" or "adjacency"
+	FromPK   string `json:"fromPK"`
+	FromCode string `json:"fromCode"`
+	ToPK     string `json:"toPK"`
+	ToCode   string `json:"toCode"`
+	SID      uint32 `json:"sid"` // node SID index, or the label of an adjacency SID
+	Label    uint32 `json:"label"`
+}
+
+// SRPath is a path and the label stack that steers traffic along it. Error is set instead of
+// the segments when the path cannot be encoded, e.g. because a device has no node SID.
+type SRPath struct {
+	Path     []string    `json:"path"` // device codes
+	PathPKs  []string    `json:"pathPKs"`
+	Metric   uint64      `json:"metric"`
+	Hops     int         `json:"hops"`
+	ECMP     bool        `json:"ecmp"` // whether the labels spread traffic over equal-cost paths
+	Segments []SRSegment `json:"segments"`
+	Labels   []uint32    `json:"labels"` // top of stack first
+	Error    string      `json:"error,omitempty"`
+}
+
+// SRPathsResponse is the response for the segment routing label stacks between two devices.
+type SRPathsResponse struct {
+	FromPK     string   `json:"fromPK"`
+	FromCode   string   `json:"fromCode"`
+	ToPK       string   `json:"toPK"`
+	ToCode     string   `json:"toCode"`
+	Shortest   *SRPath  `json:"shortest,omitempty"`
+	Explicit   *SRPath  `json:"explicit,omitempty"` // through the requested waypoints
+	Alternates []SRPath `json:"alternates"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// GetSRPaths computes the SR-MPLS label stack for the IGP shortest path between two devices,
+// for an explicit path through optional waypoints, and for the next-best alternate paths.
+func GetSRPaths(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	fromPK := r.URL.Query().Get("from")
+	toPK := r.URL.Query().Get("to")
+	if fromPK == "" || toPK == "" {
+		writeJSON(w, SRPathsResponse{Error: "from and to parameters are required"})
+		return
+	}
+	if fromPK == toPK {
+		writeJSON(w, SRPathsResponse{Error: "from and to must be different devices"})
+		return
+	}
+	var via []string
+	for pk := range strings.SplitSeq(r.URL.Query().Get("via"), ",") {
+		if pk = strings.TrimSpace(pk); pk != "" {
+			via = append(via, pk)
+		}
+	}
+	alternates := defaultSRAlternates
+	if v := r.URL.Query().Get("alternates"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			alternates = min(n, maxSRAlternates)
+		}
+	}
+
+	start := time.Now()
+	response := SRPathsResponse{FromPK: fromPK, ToPK: toPK, Alternates: []SRPath{}}
+
+	topo, err := loadRoutingTopology(ctx)
+	if err != nil {
+		log.Printf("SR paths topology error: %v", err)
+		response.Error = err.Error()
+		writeJSON(w, response)
+		return
+	}
+	response.FromCode = deviceCode(topo, fromPK)
+	response.ToCode = deviceCode(topo, toPK)
+
+	if !topo.SPF(fromPK).Reachable(toPK) {
+		response.Error = "No path found between devices"
+		writeJSON(w, response)
+		return
+	}
+
+	p, err := topo.NodeLabelStack(fromPK, nil, toPK)
+	if err != nil {
+		// Still show the path the labels would have steered
+		route := topo.Route(fromPK, toPK, 1)
+		p = routing.SRPath{Devices: route.Paths[0].Devices, Metric: route.Metric, ECMP: route.PathCount > 1}
+	}
+	shortest := viewSRPath(topo, p, err)
+	response.Shortest = &shortest
+
+	if len(via) > 0 {
+		p, err := topo.NodeLabelStack(fromPK, via, toPK)
+		explicit := viewSRPath(topo, p, err)
+		response.Explicit = &explicit
+	}
+
+	for _, path := range topo.AlternatePaths(fromPK, toPK, alternates) {
+		p, err := topo.StrictLabelStack(path.Devices)
+		if err != nil {
+			p = routing.SRPath{Devices: path.Devices, Metric: path.Metric}
+		}
+		response.Alternates = append(response.Alternates, viewSRPath(topo, p, err))
+	}
+
+	metrics.RecordClickHouseQuery(time.Since(start), nil)
+	writeJSON(w, response)
+}
+
+// viewSRPath resolves a label stack to device codes for responses, carrying err as the path's
+// error.
+func viewSRPath(topo *routing.Topology, p routing.SRPath, err error) SRPath {
+	v := SRPath{
+		Path:     make([]string, len(p.Devices)),
+		PathPKs:  append([]string{}, p.Devices...),
+		Metric:   p.Metric,
+		Hops:     max(len(p.Devices)-1, 0),
+		ECMP:     p.ECMP,
+		Segments: make([]SRSegment, 0, len(p.Segments)),
+		Labels:   append([]uint32{}, p.Labels()...),
+	}
+	for i, pk := range p.Devices {
+		v.Path[i] = deviceCode(topo, pk)
+	}
+	for _, s := range p.Segments {
+		v.Segments = append(v.Segments, SRSegment{
+			Type:     string(s.Type),
+			FromPK:   s.From,
+			FromCode: deviceCode(topo, s.From),
+			ToPK:     s.To,
+			ToCode:   deviceCode(topo, s.To),
+			SID:      s.SID,
+			Label:    s.Label,
+		})
+	}
+	if err != nil {
+		v.Error = err.Error()
+	}
+	return v
+}
+
+// SRSIDIssue is a segment routing misconfiguration.
+type SRSIDIssue struct {
+	Type        string   `json:"type"`
+	DevicePKs   []string `json:"devicePKs"`
+	DeviceCodes []string `json:"deviceCodes"`
+	SID         uint32   `json:"sid,omitempty"`
+	Detail      string   `json:"detail"`
+}
+
+// SRValidationResponse is the response for the fleet-wide SID validation.
+type SRValidationResponse struct {
+	DeviceCount int          `json:"deviceCount"`
+	Issues      []SRSIDIssue `json:"issues"`
+	IssueCount  int          `json:"issueCount"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// GetSRValidation checks that segment routing labels are unique and within the SRGB across
+// the fleet.
+func GetSRValidation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	response := SRValidationResponse{Issues: []SRSIDIssue{}}
+
+	topo, err := loadRoutingTopology(ctx)
+	if err != nil {
+		log.Printf("SR validation topology error: %v", err)
+		response.Error = err.Error()
+		writeJSON(w, response)
+		return
+	}
+	response.DeviceCount = len(topo.DevicePKs())
+
+	for _, issue := range topo.ValidateSIDs() {
+		codes := make([]string, len(issue.Devices))
+		for i, pk := range issue.Devices {
+			codes[i] = deviceCode(topo, pk)
+		}
+		response.Issues = append(response.Issues, SRSIDIssue{
+			Type:        string(issue.Type),
+			DevicePKs:   issue.Devices,
+			DeviceCodes: codes,
+			SID:         issue.SID,
+			Detail:      issue.Detail,
+		})
+	}
+	response.IssueCount = len(response.Issues)
+
+	metrics.RecordClickHouseQuery(time.Since(start), nil)
+	writeJSON(w, response)
+}
@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+// slaIntervalSeconds is the width of the latency aggregate intervals the report is scored on
+const slaIntervalSeconds = 300
+
+// slaOutageLossPct is the packet loss at which an interval counts as an outage, matching the
+// "outage" severity of packet loss outages
+const slaOutageLossPct = 10.0
+
+// slaDrainLookback is how far before the report period status changes are read, so drains that
+// began before the period are excluded too
+const slaDrainLookback = 90 * 24 * time.Hour
+
+// SLALinkReport is a link's SLA compliance over the report period. Intervals are 5-minute
+// latency aggregate windows; percentages are nil when no interval was eligible or measured.
+// Eligible intervals without a latency aggregate are reported as no data rather than outages,
+// since a gap in telemetry ingestion says nothing about the link.
+type SLALinkReport struct {
+	LinkPK            string  `json:"link_pk"`
+	LinkCode          string  `json:"link_code"`
+	LinkType          string  `json:"link_type"`
+	ContributorCode   string  `json:"contributor_code"`
+	SideAMetro        string  `json:"side_a_metro"`
+	SideZMetro        string  `json:"side_z_metro"`
+	CommittedRttUs    float64 `json:"committed_rtt_us"`
+	CommittedJitterUs float64 `json:"committed_jitter_us"`
+
+	Intervals         int      `json:"intervals"`          // Intervals the link existed in the period
+	DrainedIntervals  int      `json:"drained_intervals"`  // Excluded because the link was drained
+	EligibleIntervals int      `json:"eligible_intervals"` // Intervals - DrainedIntervals
+	NoDataIntervals   int      `json:"no_data_intervals"`  // Eligible intervals without a latency aggregate
+	OutageIntervals   int      `json:"outage_intervals"`   // Eligible intervals with data and loss >= 10%
+	OutageMinutes     float64  `json:"outage_minutes"`
+	UptimePct         *float64 `json:"uptime_pct"` // Of eligible intervals with data
+
+	RttMeasuredIntervals    int      `json:"rtt_measured_intervals"`
+	RttWithinCommitPct      *float64 `json:"rtt_within_commit_pct"`
+	JitterMeasuredIntervals int      `json:"jitter_measured_intervals"`
+	JitterWithinCommitPct   *float64 `json:"jitter_within_commit_pct"`
+
+	AvgRttUs    *float64 `json:"avg_rtt_us"`
+	AvgJitterUs *float64 `json:"avg_jitter_us"`
+	AvgLossPct  *float64 `json:"avg_loss_pct"`
+
+	rttWithin    int
+	jitterWithin int
+}
+
+// SLAContributorReport rolls up the SLA compliance of a contributor's links, weighting each link
+// by its intervals
+type SLAContributorReport struct {
+	ContributorCode         string   `json:"contributor_code"`
+	Links                   int      `json:"links"`
+	LinksWithCommitments    int      `json:"links_with_commitments"`
+	EligibleIntervals       int      `json:"eligible_intervals"`
+	DrainedIntervals        int      `json:"drained_intervals"`
+	NoDataIntervals         int      `json:"no_data_intervals"`
+	OutageIntervals         int      `json:"outage_intervals"`
+	OutageMinutes           float64  `json:"outage_minutes"`
+	UptimePct               *float64 `json:"uptime_pct"`
+	RttMeasuredIntervals    int      `json:"rtt_measured_intervals"`
+	RttWithinCommitPct      *float64 `json:"rtt_within_commit_pct"`
+	JitterMeasuredIntervals int      `json:"jitter_measured_intervals"`
+	JitterWithinCommitPct   *float64 `json:"jitter_within_commit_pct"`
+	LinksBelowRttCommit     int      `json:"links_below_rtt_commit"` // Links within commit for < 99% of measured intervals
+}
+
+// SLAReportResponse is the API response for an SLA compliance report. Rows are flat so they can be
+// rendered as tables or exported as CSV without further processing.
+type SLAReportResponse struct {
+	Month                  string                 `json:"month"`
+	PeriodStart            string                 `json:"period_start"`
+	PeriodEnd              string                 `json:"period_end"`
+	Complete               bool                   `json:"complete"` // False while the month is in progress
+	IntervalSeconds        int                    `json:"interval_seconds"`
+	OutageLossThresholdPct float64                `json:"outage_loss_threshold_pct"`
+	GeneratedAt            string                 `json:"generated_at"`
+	Contributors           []SLAContributorReport `json:"contributors"`
+	Links                  []SLALinkReport        `json:"links"`
+}
+
+// slaInterval is one latency aggregate interval of a link
+type slaInterval struct {
+	samples   int64
+	lossPct   float64
+	rttAvg    *float64
+	jitterAvg *float64
+	rttMet    *bool
+	jitterMet *bool
+}
+
+// slaLink is a link that existed during the report period
+type slaLink struct {
+	report      SLALinkReport
+	activeFrom  time.Time
+	activeUntil time.Time
+}
+
+// parseSLAMonth parses a YYYY-MM month, defaulting to the current month
+func parseSLAMonth(month string, now time.Time) (time.Time, error) {
+	if month == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("month must be formatted as YYYY-MM")
+	}
+	if start.After(now) {
+		return time.Time{}, fmt.Errorf("month %s has not started", month)
+	}
+	return start, nil
+}
+
+// GetSLAReport returns per-link and per-contributor SLA compliance for a month
+func GetSLAReport(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	monthStart, err := parseSLAMonth(r.URL.Query().Get("month"), now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := fetchSLAReport(ctx, monthStart, now, r.URL.Query().Get("contributor"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// GetSLAReportCSV returns an SLA compliance report as CSV, one row per link, or per contributor
+// with level=contributors
+func GetSLAReportCSV(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	monthStart, err := parseSLAMonth(r.URL.Query().Get("month"), now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := fetchSLAReport(ctx, monthStart, now, r.URL.Query().Get("contributor"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to fetch %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	level := r.URL.Query().Get("level")
+	if level == "" {
+		level = "links"
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=sla-%s-%s.csv", report.Month, level))
+
+	if level == "contributors" {
+		_, _ = w.Write([]byte("month,contributor,links,links_with_commitments,eligible_intervals,drained_intervals,no_data_intervals,outage_intervals,outage_minutes,uptime_pct,rtt_measured_intervals,rtt_within_commit_pct,jitter_measured_intervals,jitter_within_commit_pct,links_below_rtt_commit\n"))
+		for _, c := range report.Contributors {
+			line := fmt.Sprintf("%s,%s,%d,%d,%d,%d,%d,%d,%.0f,%s,%d,%s,%d,%s,%d\n",
+				report.Month, c.ContributorCode, c.Links, c.LinksWithCommitments, c.EligibleIntervals,
+				c.DrainedIntervals, c.NoDataIntervals, c.OutageIntervals, c.OutageMinutes, formatSLAPct(c.UptimePct),
+				c.RttMeasuredIntervals, formatSLAPct(c.RttWithinCommitPct),
+				c.JitterMeasuredIntervals, formatSLAPct(c.JitterWithinCommitPct), c.LinksBelowRttCommit)
+			_, _ = w.Write([]byte(line))
+		}
+		return
+	}
+
+	_, _ = w.Write([]byte("month,link_code,link_type,contributor,side_a_metro,side_z_metro,committed_rtt_us,committed_jitter_us,intervals,drained_intervals,eligible_intervals,no_data_intervals,outage_intervals,outage_minutes,uptime_pct,rtt_measured_intervals,rtt_within_commit_pct,jitter_measured_intervals,jitter_within_commit_pct,avg_rtt_us,avg_jitter_us,avg_loss_pct\n"))
+	for _, l := range report.Links {
+		line := fmt.Sprintf("%s,%s,%s,%s,%s,%s,%.0f,%.0f,%d,%d,%d,%d,%d,%.0f,%s,%d,%s,%d,%s,%s,%s,%s\n",
+			report.Month, l.LinkCode, l.LinkType, l.ContributorCode, l.SideAMetro, l.SideZMetro,
+			l.CommittedRttUs, l.CommittedJitterUs, l.Intervals, l.DrainedIntervals, l.EligibleIntervals,
+			l.NoDataIntervals, l.OutageIntervals, l.OutageMinutes, formatSLAPct(l.UptimePct),
+			l.RttMeasuredIntervals, formatSLAPct(l.RttWithinCommitPct),
+			l.JitterMeasuredIntervals, formatSLAPct(l.JitterWithinCommitPct),
+			formatSLAValue(l.AvgRttUs), formatSLAValue(l.AvgJitterUs), formatSLAValue(l.AvgLossPct))
+		_, _ = w.Write([]byte(line))
+	}
+}
+
+func formatSLAPct(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 3, 64)
+}
+
+func formatSLAValue(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', 1, 64)
+}
+
+// fetchSLAReport builds the SLA report for the month starting at monthStart, up to now while the
+// month is in progress. contributor optionally limits the report to one contributor code.
+func fetchSLAReport(ctx context.Context, monthStart, now time.Time, contributor string) (*SLAReportResponse, error) {
+	interval := slaIntervalSeconds * time.Second
+	periodEnd := monthStart.AddDate(0, 1, 0)
+	complete := true
+	if now.Before(periodEnd) {
+		periodEnd = now.Truncate(interval)
+		complete = false
+	}
+
+	links, err := querySLALinks(ctx, monthStart, periodEnd, contributor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query links: %w", err)
+	}
+	intervals, err := querySLAIntervals(ctx, monthStart, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latency aggregates: %w", err)
+	}
+	drained, err := fetchDrainedPeriods(ctx, envDB(ctx), now.Sub(monthStart)+slaDrainLookback)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch drained periods: %w", err)
+	}
+
+	report := &SLAReportResponse{
+		Month:                  monthStart.Format("2006-01"),
+		PeriodStart:            monthStart.Format(time.RFC3339),
+		PeriodEnd:              periodEnd.Format(time.RFC3339),
+		Complete:               complete,
+		IntervalSeconds:        slaIntervalSeconds,
+		OutageLossThresholdPct: slaOutageLossPct,
+		GeneratedAt:            now.Format(time.RFC3339),
+		Contributors:           []SLAContributorReport{},
+		Links:                  []SLALinkReport{},
+	}
+
+	for _, l := range links {
+		from := maxTime(monthStart, l.activeFrom.Truncate(interval))
+		until := periodEnd
+		if !l.activeUntil.IsZero() && l.activeUntil.Before(until) {
+			until = l.activeUntil
+		}
+		link := l.report
+		scoreSLALink(&link, from, until, interval, intervals[link.LinkPK], drained[link.LinkPK])
+		report.Links = append(report.Links, link)
+	}
+	sort.Slice(report.Links, func(i, j int) bool {
+		if report.Links[i].ContributorCode != report.Links[j].ContributorCode {
+			return report.Links[i].ContributorCode < report.Links[j].ContributorCode
+		}
+		return report.Links[i].LinkCode < report.Links[j].LinkCode
+	})
+
+	report.Contributors = rollUpSLAContributors(report.Links, interval)
+	return report, nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// scoreSLALink scores the intervals of a link in [from, until). Drained intervals are excluded.
+// Eligible intervals without samples count as no data and are left out of uptime; those with
+// loss at or above the outage threshold count as outages. RTT and jitter compliance are measured
+// over the intervals where they are known.
+func scoreSLALink(l *SLALinkReport, from, until time.Time, interval time.Duration, intervals map[int64]slaInterval, drained []drainedPeriod) {
+	var rttSum, jitterSum, lossSum float64
+	var rttCount, jitterCount, lossCount int
+
+	for t := from; t.Before(until); t = t.Add(interval) {
+		l.Intervals++
+		if len(drained) > 0 && gapOverlapsDrainedPeriod(t, t.Add(interval), drained) {
+			l.DrainedIntervals++
+			continue
+		}
+		l.EligibleIntervals++
+
+		iv, ok := intervals[t.Unix()]
+		if !ok || iv.samples == 0 {
+			l.NoDataIntervals++
+			continue
+		}
+		if iv.lossPct >= slaOutageLossPct {
+			l.OutageIntervals++
+		}
+
+		lossSum += iv.lossPct
+		lossCount++
+		if iv.rttAvg != nil {
+			rttSum += *iv.rttAvg
+			rttCount++
+		}
+		if iv.jitterAvg != nil {
+			jitterSum += *iv.jitterAvg
+			jitterCount++
+		}
+		if iv.rttMet != nil {
+			l.RttMeasuredIntervals++
+			if *iv.rttMet {
+				l.rttWithin++
+			}
+		}
+		if iv.jitterMet != nil {
+			l.JitterMeasuredIntervals++
+			if *iv.jitterMet {
+				l.jitterWithin++
+			}
+		}
+	}
+
+	l.OutageMinutes = float64(l.OutageIntervals) * interval.Minutes()
+	measured := l.EligibleIntervals - l.NoDataIntervals
+	l.UptimePct = slaPct(measured-l.OutageIntervals, measured)
+	l.RttWithinCommitPct = slaPct(l.rttWithin, l.RttMeasuredIntervals)
+	l.JitterWithinCommitPct = slaPct(l.jitterWithin, l.JitterMeasuredIntervals)
+	l.AvgRttUs = slaMean(rttSum, rttCount)
+	l.AvgJitterUs = slaMean(jitterSum, jitterCount)
+	l.AvgLossPct = slaMean(lossSum, lossCount)
+}
+
+// rollUpSLAContributors sums link intervals per contributor, sorted by contributor code
+func rollUpSLAContributors(links []SLALinkReport, interval time.Duration) []SLAContributorReport {
+	type totals struct {
+		report       SLAContributorReport
+		rttWithin    int
+		jitterWithin int
+	}
+	byContributor := make(map[string]*totals)
+	for _, l := range links {
+		t, ok := byContributor[l.ContributorCode]
+		if !ok {
+			t = &totals{report: SLAContributorReport{ContributorCode: l.ContributorCode}}
+			byContributor[l.ContributorCode] = t
+		}
+		c := &t.report
+		c.Links++
+		if l.CommittedRttUs > 0 || l.CommittedJitterUs > 0 {
+			c.LinksWithCommitments++
+		}
+		c.EligibleIntervals += l.EligibleIntervals
+		c.DrainedIntervals += l.DrainedIntervals
+		c.NoDataIntervals += l.NoDataIntervals
+		c.OutageIntervals += l.OutageIntervals
+		c.RttMeasuredIntervals += l.RttMeasuredIntervals
+		c.JitterMeasuredIntervals += l.JitterMeasuredIntervals
+		t.rttWithin += l.rttWithin
+		t.jitterWithin += l.jitterWithin
+		if l.RttWithinCommitPct != nil && *l.RttWithinCommitPct < 99 {
+			c.LinksBelowRttCommit++
+		}
+	}
+
+	contributors := make([]SLAContributorReport, 0, len(byContributor))
+	for _, t := range byContributor {
+		c := t.report
+		c.OutageMinutes = float64(c.OutageIntervals) * interval.Minutes()
+		measured := c.EligibleIntervals - c.NoDataIntervals
+		c.UptimePct = slaPct(measured-c.OutageIntervals, measured)
+		c.RttWithinCommitPct = slaPct(t.rttWithin, c.RttMeasuredIntervals)
+		c.JitterWithinCommitPct = slaPct(t.jitterWithin, c.JitterMeasuredIntervals)
+		contributors = append(contributors, c)
+	}
+	sort.Slice(contributors, func(i, j int) bool {
+		return contributors[i].ContributorCode < contributors[j].ContributorCode
+	})
+	return contributors
+}
+
+func slaPct(n, total int) *float64 {
+	if total <= 0 {
+		return nil
+	}
+	pct := float64(n) * 100 / float64(total)
+	return &pct
+}
+
+func slaMean(sum float64, n int) *float64 {
+	if n == 0 {
+		return nil
+	}
+	mean := sum / float64(n)
+	return &mean
+}
+
+// querySLALinks returns the links that existed during [start, end), with their latest attributes
+// in the period. Links that are gone today are included for the part of the period they existed.
+func querySLALinks(ctx context.Context, start, end time.Time, contributor string) ([]slaLink, error) {
+	query := `
+		SELECT
+			h.pk,
+			h.code,
+			h.link_type,
+			COALESCE(c.code, '') as contributor_code,
+			COALESCE(ma.code, '') as side_a_metro,
+			COALESCE(mz.code, '') as side_z_metro,
+			h.committed_rtt_ns,
+			h.committed_jitter_ns,
+			h.first_seen,
+			h.deleted_at
+		FROM (
+			SELECT
+				pk,
+				argMax(code, (snapshot_ts, ingested_at)) as code,
+				argMax(link_type, (snapshot_ts, ingested_at)) as link_type,
+				argMax(contributor_pk, (snapshot_ts, ingested_at)) as contributor_pk,
+				argMax(side_a_pk, (snapshot_ts, ingested_at)) as side_a_pk,
+				argMax(side_z_pk, (snapshot_ts, ingested_at)) as side_z_pk,
+				argMaxIf(committed_rtt_ns, (snapshot_ts, ingested_at), is_deleted = 0) as committed_rtt_ns,
+				argMaxIf(committed_jitter_ns, (snapshot_ts, ingested_at), is_deleted = 0) as committed_jitter_ns,
+				min(snapshot_ts) as first_seen,
+				if(argMax(is_deleted, (snapshot_ts, ingested_at)) = 1, max(snapshot_ts), toDateTime64(0, 3)) as deleted_at
+			FROM dim_dz_links_history
+			WHERE snapshot_ts < ?
+			GROUP BY pk
+		) h
+		LEFT JOIN dz_contributors_current c ON h.contributor_pk = c.pk
+		LEFT JOIN dz_devices_current da ON h.side_a_pk = da.pk
+		LEFT JOIN dz_devices_current dz ON h.side_z_pk = dz.pk
+		LEFT JOIN dz_metros_current ma ON da.metro_pk = ma.pk
+		LEFT JOIN dz_metros_current mz ON dz.metro_pk = mz.pk
+		WHERE (h.deleted_at = toDateTime64(0, 3) OR h.deleted_at > ?)
+	`
+	args := []any{end, start}
+	if contributor != "" {
+		query += " AND lower(c.code) = ?"
+		args = append(args, strings.ToLower(contributor))
+	}
+
+	queryStart := time.Now()
+	rows, err := envDB(ctx).Query(ctx, query, args...)
+	metrics.RecordClickHouseQuery(time.Since(queryStart), err)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []slaLink
+	for rows.Next() {
+		var (
+			l                             slaLink
+			committedRTTNs, committedJtNs int64
+		)
+		if err := rows.Scan(&l.report.LinkPK, &l.report.LinkCode, &l.report.LinkType, &l.report.ContributorCode,
+			&l.report.SideAMetro, &l.report.SideZMetro, &committedRTTNs, &committedJtNs, &l.activeFrom, &l.activeUntil); err != nil {
+			return nil, fmt.Errorf("sla link scan error: %w", err)
+		}
+		l.report.CommittedRttUs = float64(committedRTTNs) / 1000
+		l.report.CommittedJitterUs = float64(committedJtNs) / 1000
+		l.activeFrom = l.activeFrom.UTC()
+		if l.activeUntil.Unix() <= 0 {
+			l.activeUntil = time.Time{}
+		} else {
+			l.activeUntil = l.activeUntil.UTC()
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// querySLAIntervals reads the interval latency aggregates in [start, end), keyed by link and
+// interval start (unix seconds)
+func querySLAIntervals(ctx context.Context, start, end time.Time) (map[string]map[int64]slaInterval, error) {
+	query := `
+		SELECT
+			link_pk,
+			event_ts,
+			sum(samples) as samples,
+			sum(lost_samples) * 100.0 / greatest(sum(samples), 1) as loss_pct,
+			avgOrNull(rtt_avg_us) as rtt_avg_us,
+			avgOrNull(jitter_avg_us) as jitter_avg_us,
+			if(countIf(rtt_sla_met IS NOT NULL) = 0, NULL, toBool(countIf(rtt_sla_met = false) = 0)) as rtt_sla_met,
+			if(countIf(jitter_sla_met IS NOT NULL) = 0, NULL, toBool(countIf(jitter_sla_met = false) = 0)) as jitter_sla_met
+		FROM fact_dz_link_latency_aggregates FINAL
+		WHERE granularity = 'interval'
+		  AND period_seconds = ?
+		  AND event_ts >= ? AND event_ts < ?
+		GROUP BY link_pk, event_ts
+	`
+
+	queryStart := time.Now()
+	rows, err := envDB(ctx).Query(ctx, query, int64(slaIntervalSeconds), start, end)
+	metrics.RecordClickHouseQuery(time.Since(queryStart), err)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]map[int64]slaInterval)
+	for rows.Next() {
+		var (
+			linkPK  string
+			eventTS time.Time
+			iv      slaInterval
+		)
+		if err := rows.Scan(&linkPK, &eventTS, &iv.samples, &iv.lossPct, &iv.rttAvg, &iv.jitterAvg, &iv.rttMet, &iv.jitterMet); err != nil {
+			return nil, fmt.Errorf("sla interval scan error: %w", err)
+		}
+		if result[linkPK] == nil {
+			result[linkPK] = make(map[int64]slaInterval)
+		}
+		result[linkPK][eventTS.Unix()] = iv
+	}
+	return result, rows.Err()
+}
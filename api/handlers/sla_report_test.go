@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSLAMonth(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+
+	start, err := parseSLAMonth("", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), start)
+
+	start, err = parseSLAMonth("2025-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), start)
+
+	_, err = parseSLAMonth("2025-04", now)
+	assert.Error(t, err)
+	_, err = parseSLAMonth("January", now)
+	assert.Error(t, err)
+}
+
+func TestScoreSLALink(t *testing.T) {
+	interval := 5 * time.Minute
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := from.Add(time.Hour) // 12 intervals
+
+	yes, no := true, false
+	rtt := 1000.0
+	healthy := slaInterval{samples: 60, rttAvg: &rtt, jitterAvg: &rtt, rttMet: &yes, jitterMet: &yes}
+	intervals := make(map[int64]slaInterval)
+	for i := range 12 {
+		intervals[from.Add(time.Duration(i)*interval).Unix()] = healthy
+	}
+	// Interval 2 misses the RTT commitment, interval 3 loses 50% of packets, interval 4 has no
+	// data and intervals 8-9 are drained
+	slow := healthy
+	slow.rttMet = &no
+	intervals[from.Add(2*interval).Unix()] = slow
+	lossy := healthy
+	lossy.lossPct = 50
+	intervals[from.Add(3*interval).Unix()] = lossy
+	delete(intervals, from.Add(4*interval).Unix())
+	drainEnd := from.Add(10 * interval)
+	drained := []drainedPeriod{{Start: from.Add(8 * interval), End: &drainEnd}}
+
+	l := SLALinkReport{LinkCode: "link-1", ContributorCode: "c1", CommittedRttUs: 1500}
+	scoreSLALink(&l, from, until, interval, intervals, drained)
+
+	assert.Equal(t, 12, l.Intervals)
+	assert.Equal(t, 2, l.DrainedIntervals)
+	assert.Equal(t, 10, l.EligibleIntervals)
+	// The interval without data is neither up nor down
+	assert.Equal(t, 1, l.NoDataIntervals)
+	assert.Equal(t, 1, l.OutageIntervals)
+	assert.Equal(t, 5.0, l.OutageMinutes)
+	require.NotNil(t, l.UptimePct)
+	assert.InDelta(t, 800.0/9, *l.UptimePct, 1e-9)
+	assert.Equal(t, 9, l.RttMeasuredIntervals)
+	require.NotNil(t, l.RttWithinCommitPct)
+	assert.InDelta(t, 800.0/9, *l.RttWithinCommitPct, 1e-9)
+	require.NotNil(t, l.JitterWithinCommitPct)
+	assert.InDelta(t, 100.0, *l.JitterWithinCommitPct, 1e-9)
+	require.NotNil(t, l.AvgLossPct)
+	assert.InDelta(t, 50.0/9, *l.AvgLossPct, 1e-9)
+
+	// A link drained for the whole period has nothing to score
+	idle := SLALinkReport{LinkCode: "link-2", ContributorCode: "c1"}
+	scoreSLALink(&idle, from, until, interval, nil, []drainedPeriod{{Start: from.Add(-time.Hour)}})
+	assert.Equal(t, 12, idle.DrainedIntervals)
+	assert.Nil(t, idle.UptimePct)
+	assert.Nil(t, idle.RttWithinCommitPct)
+
+	contributors := rollUpSLAContributors([]SLALinkReport{l, idle}, interval)
+	require.Len(t, contributors, 1)
+	c := contributors[0]
+	assert.Equal(t, "c1", c.ContributorCode)
+	assert.Equal(t, 2, c.Links)
+	assert.Equal(t, 1, c.LinksWithCommitments)
+	assert.Equal(t, 10, c.EligibleIntervals)
+	assert.Equal(t, 14, c.DrainedIntervals)
+	assert.Equal(t, 1, c.NoDataIntervals)
+	require.NotNil(t, c.UptimePct)
+	assert.InDelta(t, 800.0/9, *c.UptimePct, 1e-9)
+	assert.Equal(t, 1, c.LinksBelowRttCommit)
+}
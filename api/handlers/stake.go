@@ -53,8 +53,19 @@ func GetStakeOverview(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
 	defer cancel()
 
+	overview := fetchStakeOverviewData(ctx)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(overview); err != nil {
+		log.Printf("JSON encoding error: %v", err)
+	}
+}
+
+// fetchStakeOverviewData fetches current DZ stake share with 24h and 7d deltas.
+// Used by both the handler and the agent analytics tools.
+func fetchStakeOverviewData(ctx context.Context) *StakeOverview {
 	start := time.Now()
-	overview := StakeOverview{
+	overview := &StakeOverview{
 		FetchedAt: time.Now().UTC().Format(time.RFC3339),
 	}
 
@@ -207,10 +218,7 @@ func GetStakeOverview(w http.ResponseWriter, r *http.Request) {
 	overview.DZStakeChange7d = overview.DZStakeSol - overview.DZStakeSol7dAgo
 	overview.ShareChange7d = overview.StakeSharePct - overview.StakeSharePct7dAgo
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(overview); err != nil {
-		log.Printf("JSON encoding error: %v", err)
-	}
+	return overview
 }
 
 func GetStakeHistory(w http.ResponseWriter, r *http.Request) {
@@ -182,8 +182,9 @@ type NonActivatedLink struct {
 }
 
 type InfrastructureAlerts struct {
-	Devices []NonActivatedDevice `json:"devices"`
-	Links   []NonActivatedLink   `json:"links"`
+	Devices   []NonActivatedDevice `json:"devices"`
+	Links     []NonActivatedLink   `json:"links"`
+	Anomalies []LinkAnomalyAlert   `json:"anomalies"` // Links deviating from their latency/jitter/loss baseline
 }
 
 // Thresholds for health classification (matching methodology)
@@ -242,8 +243,9 @@ func fetchStatusData(ctx context.Context) *StatusResponse {
 			Issues: []InterfaceIssue{},
 		},
 		Alerts: InfrastructureAlerts{
-			Devices: []NonActivatedDevice{},
-			Links:   []NonActivatedLink{},
+			Devices:   []NonActivatedDevice{},
+			Links:     []NonActivatedLink{},
+			Anomalies: []LinkAnomalyAlert{},
 		},
 	}
 
@@ -1028,6 +1030,16 @@ func fetchStatusData(ctx context.Context) *StatusResponse {
 		return rows.Err()
 	})
 
+	// Links deviating from their latency/jitter/loss baseline
+	g.Go(func() error {
+		anomalies, err := queryActiveLinkAnomalies(ctx)
+		if err != nil {
+			return err
+		}
+		resp.Alerts.Anomalies = anomalies
+		return nil
+	})
+
 	err := g.Wait()
 	duration := time.Since(start)
 	metrics.RecordClickHouseQuery(duration, err)
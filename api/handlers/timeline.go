@@ -650,6 +650,7 @@ func GetTimeline(w http.ResponseWriter, r *http.Request) {
 		contributorEvents []TimelineEvent
 		userEvents        []TimelineEvent
 		packetLossEvents  []TimelineEvent
+		anomalyEvents     []TimelineEvent
 		interfaceEvents   []TimelineEvent
 		validatorEvents   []TimelineEvent
 		mu                sync.Mutex
@@ -758,6 +759,21 @@ func GetTimeline(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
+	// Query link latency/jitter/loss anomaly events
+	if shouldIncludeCategory("link_anomaly") {
+		g.Go(func() error {
+			events, err := queryLinkAnomalyEvents(ctx, params.StartTime, params.EndTime)
+			if err != nil {
+				log.Printf("Error querying link anomaly events: %v", err)
+				return nil
+			}
+			mu.Lock()
+			anomalyEvents = events
+			mu.Unlock()
+			return nil
+		})
+	}
+
 	// Query interface telemetry events (carrier, errors, discards)
 	if shouldIncludeCategory("interface_carrier") || shouldIncludeCategory("interface_errors") || shouldIncludeCategory("interface_discards") {
 		g.Go(func() error {
@@ -880,6 +896,7 @@ func GetTimeline(w http.ResponseWriter, r *http.Request) {
 	allEvents = append(allEvents, contributorEvents...)
 	allEvents = append(allEvents, userEvents...)
 	allEvents = append(allEvents, packetLossEvents...)
+	allEvents = append(allEvents, anomalyEvents...)
 	allEvents = append(allEvents, interfaceEvents...)
 	allEvents = append(allEvents, validatorEvents...)
 	allEvents = append(allEvents, gossipNetworkEvents...)
@@ -1197,39 +1214,87 @@ func computeHistogram(events []TimelineEvent, startTime, endTime time.Time) []Hi
 	return buckets
 }
 
+// changeLogField maps a dim_change_log column to the field name shown in the timeline.
+type changeLogField struct {
+	Column string
+	Name   string
+}
+
+// Fields reported in entity update events, in display order. Other payload changes still
+// produce an update event but are not listed.
+var (
+	deviceChangeFields = []changeLogField{
+		{"status", "status"},
+		{"device_type", "device_type"},
+		{"public_ip", "public_ip"},
+		{"contributor_pk", "contributor"},
+		{"metro_pk", "metro"},
+		{"max_users", "max_users"},
+	}
+	linkChangeFields = []changeLogField{
+		{"status", "status"},
+		{"link_type", "link_type"},
+		{"tunnel_net", "tunnel_net"},
+		{"contributor_pk", "contributor"},
+		{"side_a_pk", "side_a"},
+		{"side_z_pk", "side_z"},
+		{"committed_rtt_ns", "committed_rtt"},
+		{"committed_jitter_ns", "committed_jitter"},
+		{"bandwidth_bps", "bandwidth"},
+		{"isis_delay_override_ns", "isis_delay_override"},
+	}
+	metroChangeFields = []changeLogField{
+		{"name", "name"},
+		{"longitude", "longitude"},
+		{"latitude", "latitude"},
+	}
+	contributorChangeFields = []changeLogField{
+		{"code", "code"},
+		{"name", "name"},
+	}
+	userChangeFields = []changeLogField{
+		{"status", "status"},
+		{"kind", "kind"},
+		{"client_ip", "client_ip"},
+		{"dz_ip", "dz_ip"},
+		{"device_pk", "device"},
+		{"tunnel_id", "tunnel_id"},
+	}
+)
+
+// decodeChangeLogFields decodes the changes column of a dim_change_log row, keeping only
+// the tracked fields (renamed for display) in the order they are listed.
+func decodeChangeLogFields(raw string, fields []changeLogField) ([]FieldChange, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var decoded []FieldChange
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	byColumn := make(map[string]FieldChange, len(decoded))
+	for _, c := range decoded {
+		byColumn[c.Field] = c
+	}
+	var changes []FieldChange
+	for _, f := range fields {
+		if c, ok := byColumn[f.Column]; ok {
+			changes = append(changes, FieldChange{Field: f.Name, OldValue: c.OldValue, NewValue: c.NewValue})
+		}
+	}
+	return changes, nil
+}
+
 func queryDeviceChanges(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
 	query := `
-		WITH min_ts AS (
-			SELECT min(snapshot_ts) as ts FROM dim_dz_devices_history
-		),
-		all_history AS (
-			SELECT
-				entity_id,
-				snapshot_ts,
-				pk,
-				code,
-				status,
-				device_type,
-				public_ip,
-				contributor_pk,
-				metro_pk,
-				max_users,
-				is_deleted,
-				attrs_hash,
-				row_number() OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as row_num,
-				lag(status) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_status,
-				lag(device_type) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_device_type,
-				lag(public_ip) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_public_ip,
-				lag(contributor_pk) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_contributor_pk,
-				lag(metro_pk) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_metro_pk,
-				lag(max_users) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_max_users,
-				lag(attrs_hash) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_attrs_hash,
-				lag(is_deleted) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_is_deleted
-			FROM dim_dz_devices_history
-		)
 		SELECT
 			h.entity_id,
 			h.snapshot_ts,
+			h.change_type,
+			h.changes,
 			h.pk,
 			h.code,
 			h.status,
@@ -1238,29 +1303,28 @@ func queryDeviceChanges(ctx context.Context, startTime, endTime time.Time) ([]Ti
 			h.contributor_pk,
 			h.metro_pk,
 			h.max_users,
-			h.is_deleted,
-			h.prev_status,
-			h.prev_device_type,
-			h.prev_public_ip,
-			h.prev_contributor_pk,
-			h.prev_metro_pk,
-			h.prev_max_users,
-			h.prev_is_deleted,
-			CASE
-				WHEN h.row_num = 1 THEN 'created'
-				WHEN h.is_deleted = 1 AND h.prev_is_deleted = 0 THEN 'deleted'
-				ELSE 'updated'
-			END as change_type,
 			COALESCE(c.code, '') as contributor_code,
 			COALESCE(m.code, '') as metro_code
-		FROM all_history h
-		CROSS JOIN min_ts
+		FROM (
+			SELECT
+				entity_id,
+				snapshot_ts,
+				change_type,
+				changes,
+				JSONExtractString(attrs, 'pk') as pk,
+				JSONExtractString(attrs, 'code') as code,
+				JSONExtractString(attrs, 'status') as status,
+				JSONExtractString(attrs, 'device_type') as device_type,
+				JSONExtractString(attrs, 'public_ip') as public_ip,
+				JSONExtractString(attrs, 'contributor_pk') as contributor_pk,
+				JSONExtractString(attrs, 'metro_pk') as metro_pk,
+				toInt32(JSONExtractInt(attrs, 'max_users')) as max_users
+			FROM dim_change_log
+			WHERE dataset = 'dz_devices'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?
+		) h
 		LEFT JOIN dz_contributors_current c ON h.contributor_pk = c.pk
 		LEFT JOIN dz_metros_current m ON h.metro_pk = m.pk
-		WHERE (h.attrs_hash != h.prev_attrs_hash OR h.row_num = 1)
-		  AND h.snapshot_ts >= ? AND h.snapshot_ts <= ?
-		  -- Exclude initial ingestion events (created at the earliest snapshot time)
-		  AND NOT (h.row_num = 1 AND h.snapshot_ts = min_ts.ts)
 		ORDER BY h.snapshot_ts DESC, h.entity_id
 		LIMIT 200
 	`
@@ -1276,60 +1340,34 @@ func queryDeviceChanges(ctx context.Context, startTime, endTime time.Time) ([]Ti
 	var events []TimelineEvent
 	for rows.Next() {
 		var (
-			entityID          string
-			snapshotTS        time.Time
-			pk                string
-			code              string
-			status            string
-			deviceType        string
-			publicIP          string
-			contributorPK     string
-			metroPK           string
-			maxUsers          int32
-			isDeleted         uint8
-			prevStatus        *string
-			prevDeviceType    *string
-			prevPublicIP      *string
-			prevContributorPK *string
-			prevMetroPK       *string
-			prevMaxUsers      *int32
-			prevIsDeleted     *uint8
-			changeType        string
-			contributorCode   string
-			metroCode         string
+			entityID        string
+			snapshotTS      time.Time
+			changeType      string
+			rawChanges      string
+			pk              string
+			code            string
+			status          string
+			deviceType      string
+			publicIP        string
+			contributorPK   string
+			metroPK         string
+			maxUsers        int32
+			contributorCode string
+			metroCode       string
 		)
 
 		if err := rows.Scan(
-			&entityID, &snapshotTS, &pk, &code, &status, &deviceType, &publicIP,
-			&contributorPK, &metroPK, &maxUsers, &isDeleted,
-			&prevStatus, &prevDeviceType, &prevPublicIP, &prevContributorPK,
-			&prevMetroPK, &prevMaxUsers, &prevIsDeleted, &changeType,
+			&entityID, &snapshotTS, &changeType, &rawChanges,
+			&pk, &code, &status, &deviceType, &publicIP,
+			&contributorPK, &metroPK, &maxUsers,
 			&contributorCode, &metroCode,
 		); err != nil {
 			return nil, fmt.Errorf("device scan error: %w", err)
 		}
 
-		// Build changes list for updates
-		var changes []FieldChange
-		if changeType == "updated" {
-			if prevStatus != nil && *prevStatus != status {
-				changes = append(changes, FieldChange{Field: "status", OldValue: *prevStatus, NewValue: status})
-			}
-			if prevDeviceType != nil && *prevDeviceType != deviceType {
-				changes = append(changes, FieldChange{Field: "device_type", OldValue: *prevDeviceType, NewValue: deviceType})
-			}
-			if prevPublicIP != nil && *prevPublicIP != publicIP {
-				changes = append(changes, FieldChange{Field: "public_ip", OldValue: *prevPublicIP, NewValue: publicIP})
-			}
-			if prevContributorPK != nil && *prevContributorPK != contributorPK {
-				changes = append(changes, FieldChange{Field: "contributor", OldValue: *prevContributorPK, NewValue: contributorPK})
-			}
-			if prevMetroPK != nil && *prevMetroPK != metroPK {
-				changes = append(changes, FieldChange{Field: "metro", OldValue: *prevMetroPK, NewValue: metroPK})
-			}
-			if prevMaxUsers != nil && *prevMaxUsers != maxUsers {
-				changes = append(changes, FieldChange{Field: "max_users", OldValue: *prevMaxUsers, NewValue: maxUsers})
-			}
+		changes, err := decodeChangeLogFields(rawChanges, deviceChangeFields)
+		if err != nil {
+			return nil, fmt.Errorf("device changes decode error: %w", err)
 		}
 
 		var title string
@@ -1407,47 +1445,11 @@ func queryDeviceChanges(ctx context.Context, startTime, endTime time.Time) ([]Ti
 
 func queryLinkChanges(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
 	query := `
-		WITH min_ts AS (
-			SELECT min(snapshot_ts) as ts FROM dim_dz_links_history
-		),
-		all_history AS (
-			SELECT
-				entity_id,
-				snapshot_ts,
-				pk,
-				code,
-				status,
-				link_type,
-				tunnel_net,
-				contributor_pk,
-				side_a_pk,
-				side_z_pk,
-				side_a_iface_name,
-				side_z_iface_name,
-				committed_rtt_ns,
-				committed_jitter_ns,
-				bandwidth_bps,
-				isis_delay_override_ns,
-				is_deleted,
-				attrs_hash,
-				row_number() OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as row_num,
-				lag(status) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_status,
-				lag(link_type) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_link_type,
-				lag(tunnel_net) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_tunnel_net,
-				lag(contributor_pk) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_contributor_pk,
-				lag(side_a_pk) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_side_a_pk,
-				lag(side_z_pk) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_side_z_pk,
-				lag(committed_rtt_ns) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_committed_rtt_ns,
-				lag(committed_jitter_ns) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_committed_jitter_ns,
-				lag(bandwidth_bps) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_bandwidth_bps,
-				lag(isis_delay_override_ns) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_isis_delay_override_ns,
-				lag(attrs_hash) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_attrs_hash,
-				lag(is_deleted) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_is_deleted
-			FROM dim_dz_links_history
-		)
 		SELECT
 			h.entity_id,
 			h.snapshot_ts,
+			h.change_type,
+			h.changes,
 			h.pk,
 			h.code,
 			h.status,
@@ -1462,23 +1464,6 @@ func queryLinkChanges(ctx context.Context, startTime, endTime time.Time) ([]Time
 			h.committed_jitter_ns,
 			h.bandwidth_bps,
 			h.isis_delay_override_ns,
-			h.is_deleted,
-			h.prev_status,
-			h.prev_link_type,
-			h.prev_tunnel_net,
-			h.prev_contributor_pk,
-			h.prev_side_a_pk,
-			h.prev_side_z_pk,
-			h.prev_committed_rtt_ns,
-			h.prev_committed_jitter_ns,
-			h.prev_bandwidth_bps,
-			h.prev_isis_delay_override_ns,
-			h.prev_is_deleted,
-			CASE
-				WHEN h.row_num = 1 THEN 'created'
-				WHEN h.is_deleted = 1 AND h.prev_is_deleted = 0 THEN 'deleted'
-				ELSE 'updated'
-			END as change_type,
 			COALESCE(c.code, '') as contributor_code,
 			COALESCE(da.code, '') as side_a_code,
 			COALESCE(dz.code, '') as side_z_code,
@@ -1486,17 +1471,35 @@ func queryLinkChanges(ctx context.Context, startTime, endTime time.Time) ([]Time
 			COALESCE(mz.code, '') as side_z_metro_code,
 			COALESCE(ma.pk, '') as side_a_metro_pk,
 			COALESCE(mz.pk, '') as side_z_metro_pk
-		FROM all_history h
-		CROSS JOIN min_ts
+		FROM (
+			SELECT
+				entity_id,
+				snapshot_ts,
+				change_type,
+				changes,
+				JSONExtractString(attrs, 'pk') as pk,
+				JSONExtractString(attrs, 'code') as code,
+				JSONExtractString(attrs, 'status') as status,
+				JSONExtractString(attrs, 'link_type') as link_type,
+				JSONExtractString(attrs, 'tunnel_net') as tunnel_net,
+				JSONExtractString(attrs, 'contributor_pk') as contributor_pk,
+				JSONExtractString(attrs, 'side_a_pk') as side_a_pk,
+				JSONExtractString(attrs, 'side_z_pk') as side_z_pk,
+				JSONExtractString(attrs, 'side_a_iface_name') as side_a_iface_name,
+				JSONExtractString(attrs, 'side_z_iface_name') as side_z_iface_name,
+				JSONExtractInt(attrs, 'committed_rtt_ns') as committed_rtt_ns,
+				JSONExtractInt(attrs, 'committed_jitter_ns') as committed_jitter_ns,
+				JSONExtractInt(attrs, 'bandwidth_bps') as bandwidth_bps,
+				JSONExtractInt(attrs, 'isis_delay_override_ns') as isis_delay_override_ns
+			FROM dim_change_log
+			WHERE dataset = 'dz_links'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?
+		) h
 		LEFT JOIN dz_contributors_current c ON h.contributor_pk = c.pk
 		LEFT JOIN dz_devices_current da ON h.side_a_pk = da.pk
 		LEFT JOIN dz_devices_current dz ON h.side_z_pk = dz.pk
 		LEFT JOIN dz_metros_current ma ON da.metro_pk = ma.pk
 		LEFT JOIN dz_metros_current mz ON dz.metro_pk = mz.pk
-		WHERE (h.attrs_hash != h.prev_attrs_hash OR h.row_num = 1)
-		  AND h.snapshot_ts >= ? AND h.snapshot_ts <= ?
-		  -- Exclude initial ingestion events (created at the earliest snapshot time)
-		  AND NOT (h.row_num = 1 AND h.snapshot_ts = min_ts.ts)
 		ORDER BY h.snapshot_ts DESC, h.entity_id
 		LIMIT 200
 	`
@@ -1512,90 +1515,47 @@ func queryLinkChanges(ctx context.Context, startTime, endTime time.Time) ([]Time
 	var events []TimelineEvent
 	for rows.Next() {
 		var (
-			entityID              string
-			snapshotTS            time.Time
-			pk                    string
-			code                  string
-			status                string
-			linkType              string
-			tunnelNet             string
-			contributorPK         string
-			sideAPK               string
-			sideZPK               string
-			sideAIfaceName        string
-			sideZIfaceName        string
-			committedRttNs        int64
-			committedJitterNs     int64
-			bandwidthBps          int64
-			isisDelayOverride     int64
-			isDeleted             uint8
-			prevStatus            *string
-			prevLinkType          *string
-			prevTunnelNet         *string
-			prevContributorPK     *string
-			prevSideAPK           *string
-			prevSideZPK           *string
-			prevCommittedRttNs    *int64
-			prevCommittedJitter   *int64
-			prevBandwidthBps      *int64
-			prevISISDelayOverride *int64
-			prevIsDeleted         *uint8
-			changeType            string
-			contributorCode       string
-			sideACode             string
-			sideZCode             string
-			sideAMetroCode        string
-			sideZMetroCode        string
-			sideAMetroPK          string
-			sideZMetroPK          string
+			entityID          string
+			snapshotTS        time.Time
+			changeType        string
+			rawChanges        string
+			pk                string
+			code              string
+			status            string
+			linkType          string
+			tunnelNet         string
+			contributorPK     string
+			sideAPK           string
+			sideZPK           string
+			sideAIfaceName    string
+			sideZIfaceName    string
+			committedRttNs    int64
+			committedJitterNs int64
+			bandwidthBps      int64
+			isisDelayOverride int64
+			contributorCode   string
+			sideACode         string
+			sideZCode         string
+			sideAMetroCode    string
+			sideZMetroCode    string
+			sideAMetroPK      string
+			sideZMetroPK      string
 		)
 
 		if err := rows.Scan(
-			&entityID, &snapshotTS, &pk, &code, &status, &linkType, &tunnelNet,
+			&entityID, &snapshotTS, &changeType, &rawChanges,
+			&pk, &code, &status, &linkType, &tunnelNet,
 			&contributorPK, &sideAPK, &sideZPK, &sideAIfaceName, &sideZIfaceName,
-			&committedRttNs, &committedJitterNs, &bandwidthBps, &isisDelayOverride, &isDeleted,
-			&prevStatus, &prevLinkType, &prevTunnelNet, &prevContributorPK,
-			&prevSideAPK, &prevSideZPK, &prevCommittedRttNs, &prevCommittedJitter,
-			&prevBandwidthBps, &prevISISDelayOverride, &prevIsDeleted, &changeType,
+			&committedRttNs, &committedJitterNs, &bandwidthBps, &isisDelayOverride,
 			&contributorCode, &sideACode, &sideZCode, &sideAMetroCode, &sideZMetroCode,
 			&sideAMetroPK, &sideZMetroPK,
 		); err != nil {
 			return nil, fmt.Errorf("link scan error: %w", err)
 		}
 
-		// Build changes list for updates
-		var changes []FieldChange
-		if changeType == "updated" {
-			if prevStatus != nil && *prevStatus != status {
-				changes = append(changes, FieldChange{Field: "status", OldValue: *prevStatus, NewValue: status})
-			}
-			if prevLinkType != nil && *prevLinkType != linkType {
-				changes = append(changes, FieldChange{Field: "link_type", OldValue: *prevLinkType, NewValue: linkType})
-			}
-			if prevTunnelNet != nil && *prevTunnelNet != tunnelNet {
-				changes = append(changes, FieldChange{Field: "tunnel_net", OldValue: *prevTunnelNet, NewValue: tunnelNet})
-			}
-			if prevContributorPK != nil && *prevContributorPK != contributorPK {
-				changes = append(changes, FieldChange{Field: "contributor", OldValue: *prevContributorPK, NewValue: contributorPK})
-			}
-			if prevSideAPK != nil && *prevSideAPK != sideAPK {
-				changes = append(changes, FieldChange{Field: "side_a", OldValue: *prevSideAPK, NewValue: sideAPK})
-			}
-			if prevSideZPK != nil && *prevSideZPK != sideZPK {
-				changes = append(changes, FieldChange{Field: "side_z", OldValue: *prevSideZPK, NewValue: sideZPK})
-			}
-			if prevCommittedRttNs != nil && *prevCommittedRttNs != committedRttNs {
-				changes = append(changes, FieldChange{Field: "committed_rtt", OldValue: *prevCommittedRttNs, NewValue: committedRttNs})
-			}
-			if prevCommittedJitter != nil && *prevCommittedJitter != committedJitterNs {
-				changes = append(changes, FieldChange{Field: "committed_jitter", OldValue: *prevCommittedJitter, NewValue: committedJitterNs})
-			}
-			if prevBandwidthBps != nil && *prevBandwidthBps != bandwidthBps {
-				changes = append(changes, FieldChange{Field: "bandwidth", OldValue: *prevBandwidthBps, NewValue: bandwidthBps})
-			}
-			if prevISISDelayOverride != nil && *prevISISDelayOverride != isisDelayOverride {
-				changes = append(changes, FieldChange{Field: "isis_delay_override", OldValue: *prevISISDelayOverride, NewValue: isisDelayOverride})
-			}
+		changes, err := decodeChangeLogFields(rawChanges, linkChangeFields)
+		if err != nil {
+			return nil, fmt.Errorf("link changes decode error: %w", err)
 		}
 
 		var title string
@@ -1689,53 +1649,20 @@ func queryLinkChanges(ctx context.Context, startTime, endTime time.Time) ([]Time
 
 func queryMetroChanges(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
 	query := `
-		WITH min_ts AS (
-			SELECT min(snapshot_ts) as ts FROM dim_dz_metros_history
-		),
-		all_history AS (
-			SELECT
-				entity_id,
-				snapshot_ts,
-				pk,
-				code,
-				name,
-				longitude,
-				latitude,
-				is_deleted,
-				attrs_hash,
-				row_number() OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as row_num,
-				lag(name) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_name,
-				lag(longitude) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_longitude,
-				lag(latitude) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_latitude,
-				lag(attrs_hash) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_attrs_hash,
-				lag(is_deleted) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_is_deleted
-			FROM dim_dz_metros_history
-		)
 		SELECT
-			h.entity_id,
-			h.snapshot_ts,
-			h.pk,
-			h.code,
-			h.name,
-			h.longitude,
-			h.latitude,
-			h.is_deleted,
-			h.prev_name,
-			h.prev_longitude,
-			h.prev_latitude,
-			h.prev_is_deleted,
-			CASE
-				WHEN h.row_num = 1 THEN 'created'
-				WHEN h.is_deleted = 1 AND h.prev_is_deleted = 0 THEN 'deleted'
-				ELSE 'updated'
-			END as change_type
-		FROM all_history h
-		CROSS JOIN min_ts
-		WHERE (h.attrs_hash != h.prev_attrs_hash OR h.row_num = 1)
-		  AND h.snapshot_ts >= ? AND h.snapshot_ts <= ?
-		  -- Exclude initial ingestion events (created at the earliest snapshot time)
-		  AND NOT (h.row_num = 1 AND h.snapshot_ts = min_ts.ts)
-		ORDER BY h.snapshot_ts DESC, h.entity_id
+			entity_id,
+			snapshot_ts,
+			change_type,
+			changes,
+			JSONExtractString(attrs, 'pk') as pk,
+			JSONExtractString(attrs, 'code') as code,
+			JSONExtractString(attrs, 'name') as name,
+			JSONExtractFloat(attrs, 'longitude') as longitude,
+			JSONExtractFloat(attrs, 'latitude') as latitude
+		FROM dim_change_log
+		WHERE dataset = 'dz_metros'
+		  AND snapshot_ts >= ? AND snapshot_ts <= ?
+		ORDER BY snapshot_ts DESC, entity_id
 		LIMIT 100
 	`
 
@@ -1750,37 +1677,24 @@ func queryMetroChanges(ctx context.Context, startTime, endTime time.Time) ([]Tim
 	var events []TimelineEvent
 	for rows.Next() {
 		var (
-			entityID      string
-			snapshotTS    time.Time
-			pk            string
-			code          string
-			name          string
-			longitude     float64
-			latitude      float64
-			isDeleted     uint8
-			prevName      *string
-			prevLongitude *float64
-			prevLatitude  *float64
-			prevIsDeleted *uint8
-			changeType    string
+			entityID   string
+			snapshotTS time.Time
+			changeType string
+			rawChanges string
+			pk         string
+			code       string
+			name       string
+			longitude  float64
+			latitude   float64
 		)
 
-		if err := rows.Scan(&entityID, &snapshotTS, &pk, &code, &name, &longitude, &latitude, &isDeleted, &prevName, &prevLongitude, &prevLatitude, &prevIsDeleted, &changeType); err != nil {
+		if err := rows.Scan(&entityID, &snapshotTS, &changeType, &rawChanges, &pk, &code, &name, &longitude, &latitude); err != nil {
 			return nil, fmt.Errorf("metro scan error: %w", err)
 		}
 
-		// Build changes list
-		var changes []FieldChange
-		if changeType == "updated" {
-			if prevName != nil && *prevName != name {
-				changes = append(changes, FieldChange{Field: "name", OldValue: *prevName, NewValue: name})
-			}
-			if prevLongitude != nil && *prevLongitude != longitude {
-				changes = append(changes, FieldChange{Field: "longitude", OldValue: *prevLongitude, NewValue: longitude})
-			}
-			if prevLatitude != nil && *prevLatitude != latitude {
-				changes = append(changes, FieldChange{Field: "latitude", OldValue: *prevLatitude, NewValue: latitude})
-			}
+		changes, err := decodeChangeLogFields(rawChanges, metroChangeFields)
+		if err != nil {
+			return nil, fmt.Errorf("metro changes decode error: %w", err)
 		}
 
 		var title string
@@ -1838,47 +1752,18 @@ func queryMetroChanges(ctx context.Context, startTime, endTime time.Time) ([]Tim
 
 func queryContributorChanges(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
 	query := `
-		WITH min_ts AS (
-			SELECT min(snapshot_ts) as ts FROM dim_dz_contributors_history
-		),
-		all_history AS (
-			SELECT
-				entity_id,
-				snapshot_ts,
-				pk,
-				code,
-				name,
-				is_deleted,
-				attrs_hash,
-				row_number() OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as row_num,
-				lag(name) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_name,
-				lag(code) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_code,
-				lag(attrs_hash) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_attrs_hash,
-				lag(is_deleted) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_is_deleted
-			FROM dim_dz_contributors_history
-		)
 		SELECT
-			h.entity_id,
-			h.snapshot_ts,
-			h.pk,
-			h.code,
-			h.name,
-			h.is_deleted,
-			h.prev_code,
-			h.prev_name,
-			h.prev_is_deleted,
-			CASE
-				WHEN h.row_num = 1 THEN 'created'
-				WHEN h.is_deleted = 1 AND h.prev_is_deleted = 0 THEN 'deleted'
-				ELSE 'updated'
-			END as change_type
-		FROM all_history h
-		CROSS JOIN min_ts
-		WHERE (h.attrs_hash != h.prev_attrs_hash OR h.row_num = 1)
-		  AND h.snapshot_ts >= ? AND h.snapshot_ts <= ?
-		  -- Exclude initial ingestion events (created at the earliest snapshot time)
-		  AND NOT (h.row_num = 1 AND h.snapshot_ts = min_ts.ts)
-		ORDER BY h.snapshot_ts DESC, h.entity_id
+			entity_id,
+			snapshot_ts,
+			change_type,
+			changes,
+			JSONExtractString(attrs, 'pk') as pk,
+			JSONExtractString(attrs, 'code') as code,
+			JSONExtractString(attrs, 'name') as name
+		FROM dim_change_log
+		WHERE dataset = 'dz_contributors'
+		  AND snapshot_ts >= ? AND snapshot_ts <= ?
+		ORDER BY snapshot_ts DESC, entity_id
 		LIMIT 100
 	`
 
@@ -1893,31 +1778,22 @@ func queryContributorChanges(ctx context.Context, startTime, endTime time.Time)
 	var events []TimelineEvent
 	for rows.Next() {
 		var (
-			entityID      string
-			snapshotTS    time.Time
-			pk            string
-			code          string
-			name          string
-			isDeleted     uint8
-			prevCode      *string
-			prevName      *string
-			prevIsDeleted *uint8
-			changeType    string
+			entityID   string
+			snapshotTS time.Time
+			changeType string
+			rawChanges string
+			pk         string
+			code       string
+			name       string
 		)
 
-		if err := rows.Scan(&entityID, &snapshotTS, &pk, &code, &name, &isDeleted, &prevCode, &prevName, &prevIsDeleted, &changeType); err != nil {
+		if err := rows.Scan(&entityID, &snapshotTS, &changeType, &rawChanges, &pk, &code, &name); err != nil {
 			return nil, fmt.Errorf("contributor scan error: %w", err)
 		}
 
-		// Build changes list
-		var changes []FieldChange
-		if changeType == "updated" {
-			if prevCode != nil && *prevCode != code {
-				changes = append(changes, FieldChange{Field: "code", OldValue: *prevCode, NewValue: code})
-			}
-			if prevName != nil && *prevName != name {
-				changes = append(changes, FieldChange{Field: "name", OldValue: *prevName, NewValue: name})
-			}
+		changes, err := decodeChangeLogFields(rawChanges, contributorChangeFields)
+		if err != nil {
+			return nil, fmt.Errorf("contributor changes decode error: %w", err)
 		}
 
 		var title string
@@ -1977,42 +1853,15 @@ func queryUserChanges(ctx context.Context, startTime, endTime time.Time, include
 	// Build internal user filter
 	internalFilter := ""
 	if !includeInternal && len(internalUserPubkeys) > 0 {
-		internalFilter = fmt.Sprintf(" AND owner_pubkey NOT IN ('%s')", strings.Join(internalUserPubkeys, "','"))
+		internalFilter = fmt.Sprintf(" AND h.owner_pubkey NOT IN ('%s')", strings.Join(internalUserPubkeys, "','"))
 	}
 
 	query := fmt.Sprintf(`
-		WITH min_ts AS (
-			SELECT min(snapshot_ts) as ts FROM dim_dz_users_history
-		),
-		all_history AS (
-			SELECT
-				entity_id,
-				snapshot_ts,
-				pk,
-				owner_pubkey,
-				kind,
-				status,
-				client_ip,
-				dz_ip,
-				device_pk,
-				tunnel_id,
-				is_deleted,
-				attrs_hash,
-				row_number() OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as row_num,
-				lag(status) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_status,
-				lag(kind) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_kind,
-				lag(client_ip) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_client_ip,
-				lag(dz_ip) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_dz_ip,
-				lag(device_pk) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_device_pk,
-				lag(tunnel_id) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_tunnel_id,
-				lag(attrs_hash) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_attrs_hash,
-				lag(is_deleted) OVER (PARTITION BY entity_id ORDER BY snapshot_ts, ingested_at, op_id) as prev_is_deleted
-			FROM dim_dz_users_history
-			WHERE kind NOT IN ('validator', 'gossip_only')%s
-		)
 		SELECT
 			h.entity_id,
 			h.snapshot_ts,
+			h.change_type,
+			h.changes,
 			h.pk,
 			h.owner_pubkey,
 			h.kind,
@@ -2021,29 +1870,29 @@ func queryUserChanges(ctx context.Context, startTime, endTime time.Time, include
 			h.dz_ip,
 			h.device_pk,
 			h.tunnel_id,
-			h.is_deleted,
-			h.prev_status,
-			h.prev_kind,
-			h.prev_client_ip,
-			h.prev_dz_ip,
-			h.prev_device_pk,
-			h.prev_tunnel_id,
-			h.prev_is_deleted,
-			CASE
-				WHEN h.row_num = 1 THEN 'created'
-				WHEN h.is_deleted = 1 AND h.prev_is_deleted = 0 THEN 'deleted'
-				ELSE 'updated'
-			END as change_type,
 			COALESCE(d.code, '') as device_code,
 			COALESCE(m.code, '') as metro_code
-		FROM all_history h
-		CROSS JOIN min_ts
+		FROM (
+			SELECT
+				entity_id,
+				snapshot_ts,
+				change_type,
+				changes,
+				JSONExtractString(attrs, 'pk') as pk,
+				JSONExtractString(attrs, 'owner_pubkey') as owner_pubkey,
+				JSONExtractString(attrs, 'kind') as kind,
+				JSONExtractString(attrs, 'status') as status,
+				JSONExtractString(attrs, 'client_ip') as client_ip,
+				JSONExtractString(attrs, 'dz_ip') as dz_ip,
+				JSONExtractString(attrs, 'device_pk') as device_pk,
+				toInt32(JSONExtractInt(attrs, 'tunnel_id')) as tunnel_id
+			FROM dim_change_log
+			WHERE dataset = 'dz_users'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?
+		) h
 		LEFT JOIN dz_devices_current d ON h.device_pk = d.pk
 		LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
-		WHERE (h.attrs_hash != h.prev_attrs_hash OR h.row_num = 1)
-		  AND h.snapshot_ts >= ? AND h.snapshot_ts <= ?
-		  -- Exclude initial ingestion events (created at the earliest snapshot time)
-		  AND NOT (h.row_num = 1 AND h.snapshot_ts = min_ts.ts)
+		WHERE h.kind NOT IN ('validator', 'gossip_only')%s
 		ORDER BY h.snapshot_ts DESC, h.entity_id
 		LIMIT 200
 	`, internalFilter)
@@ -2059,59 +1908,33 @@ func queryUserChanges(ctx context.Context, startTime, endTime time.Time, include
 	var events []TimelineEvent
 	for rows.Next() {
 		var (
-			entityID      string
-			snapshotTS    time.Time
-			pk            string
-			ownerPubkey   string
-			kind          string
-			status        string
-			clientIP      string
-			dzIP          string
-			devicePK      string
-			tunnelID      int32
-			isDeleted     uint8
-			prevStatus    *string
-			prevKind      *string
-			prevClientIP  *string
-			prevDZIP      *string
-			prevDevicePK  *string
-			prevTunnelID  *int32
-			prevIsDeleted *uint8
-			changeType    string
-			deviceCode    string
-			metroCode     string
+			entityID    string
+			snapshotTS  time.Time
+			changeType  string
+			rawChanges  string
+			pk          string
+			ownerPubkey string
+			kind        string
+			status      string
+			clientIP    string
+			dzIP        string
+			devicePK    string
+			tunnelID    int32
+			deviceCode  string
+			metroCode   string
 		)
 
 		if err := rows.Scan(
-			&entityID, &snapshotTS, &pk, &ownerPubkey, &kind, &status, &clientIP, &dzIP,
-			&devicePK, &tunnelID, &isDeleted,
-			&prevStatus, &prevKind, &prevClientIP, &prevDZIP, &prevDevicePK, &prevTunnelID, &prevIsDeleted,
-			&changeType, &deviceCode, &metroCode,
+			&entityID, &snapshotTS, &changeType, &rawChanges,
+			&pk, &ownerPubkey, &kind, &status, &clientIP, &dzIP,
+			&devicePK, &tunnelID, &deviceCode, &metroCode,
 		); err != nil {
 			return nil, fmt.Errorf("user scan error: %w", err)
 		}
 
-		// Build changes list
-		var changes []FieldChange
-		if changeType == "updated" {
-			if prevStatus != nil && *prevStatus != status {
-				changes = append(changes, FieldChange{Field: "status", OldValue: *prevStatus, NewValue: status})
-			}
-			if prevKind != nil && *prevKind != kind {
-				changes = append(changes, FieldChange{Field: "kind", OldValue: *prevKind, NewValue: kind})
-			}
-			if prevClientIP != nil && *prevClientIP != clientIP {
-				changes = append(changes, FieldChange{Field: "client_ip", OldValue: *prevClientIP, NewValue: clientIP})
-			}
-			if prevDZIP != nil && *prevDZIP != dzIP {
-				changes = append(changes, FieldChange{Field: "dz_ip", OldValue: *prevDZIP, NewValue: dzIP})
-			}
-			if prevDevicePK != nil && *prevDevicePK != devicePK {
-				changes = append(changes, FieldChange{Field: "device", OldValue: *prevDevicePK, NewValue: devicePK})
-			}
-			if prevTunnelID != nil && *prevTunnelID != tunnelID {
-				changes = append(changes, FieldChange{Field: "tunnel_id", OldValue: *prevTunnelID, NewValue: tunnelID})
-			}
+		changes, err := decodeChangeLogFields(rawChanges, userChangeFields)
+		if err != nil {
+			return nil, fmt.Errorf("user changes decode error: %w", err)
 		}
 
 		var title string
@@ -2550,12 +2373,14 @@ func queryValidatorEvents(ctx context.Context, startTime, endTime time.Time, inc
 	// Build internal user filter
 	internalFilter := ""
 	if !includeInternal && len(internalUserPubkeys) > 0 {
-		internalFilter = fmt.Sprintf(" AND u.owner_pubkey NOT IN ('%s')", strings.Join(internalUserPubkeys, "','"))
+		internalFilter = fmt.Sprintf(" AND JSONExtractString(attrs, 'owner_pubkey') NOT IN ('%s')", strings.Join(internalUserPubkeys, "','"))
 	}
 
 	// Validators/gossip nodes are identified by joining users with gossip_nodes via dz_ip = gossip_ip
 	// A user is a "validator" if their gossip node has a vote account, otherwise "gossip_node"
-	// We use history tables to detect both join AND leave events (current tables miss users who left)
+	// Join and leave events are user status transitions from dim_change_log: created activated,
+	// status changed to or from activated, or deleted while activated (attrs of a delete is the
+	// last state, so its status is the status before the delete)
 	query := fmt.Sprintf(`
 		WITH total_stake AS (
 			SELECT sum(activated_stake_lamports) as total
@@ -2567,25 +2392,44 @@ func queryValidatorEvents(ctx context.Context, startTime, endTime time.Time, inc
 			FROM dim_solana_gossip_nodes_history
 			WHERE snapshot_ts >= ? AND snapshot_ts <= ?
 		),
+		user_changes AS (
+			SELECT
+				entity_id,
+				snapshot_ts,
+				change_type,
+				JSONExtractString(attrs, 'pk') as pk,
+				JSONExtractString(attrs, 'owner_pubkey') as owner_pubkey,
+				JSONExtractString(attrs, 'kind') as kind,
+				JSONExtractString(attrs, 'status') as status,
+				JSONExtractString(attrs, 'dz_ip') as dz_ip,
+				JSONExtractString(attrs, 'device_pk') as device_pk,
+				arrayFirst(c -> JSONExtractString(c, 'field') = 'status', JSONExtractArrayRaw(changes)) as status_change
+			FROM dim_change_log
+			WHERE dataset = 'dz_users'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?%s
+		),
 		all_history AS (
 			SELECT
-				u.entity_id,
-				u.snapshot_ts,
-				u.pk,
-				u.owner_pubkey,
-				u.kind,
-				u.status,
-				u.is_deleted,
-				u.dz_ip,
-				u.device_pk,
-				u.attrs_hash,
-				row_number() OVER (PARTITION BY u.entity_id ORDER BY u.snapshot_ts, u.ingested_at, u.op_id) as row_num,
-				lag(u.status) OVER (PARTITION BY u.entity_id ORDER BY u.snapshot_ts, u.ingested_at, u.op_id) as prev_status,
-				lag(u.is_deleted) OVER (PARTITION BY u.entity_id ORDER BY u.snapshot_ts, u.ingested_at, u.op_id) as prev_is_deleted,
-				lag(u.attrs_hash) OVER (PARTITION BY u.entity_id ORDER BY u.snapshot_ts, u.ingested_at, u.op_id) as prev_attrs_hash
-			FROM dim_dz_users_history u
+				entity_id,
+				snapshot_ts,
+				pk,
+				owner_pubkey,
+				kind,
+				status,
+				toUInt8(change_type = 'deleted') as is_deleted,
+				multiIf(
+					change_type = 'created', CAST(NULL, 'Nullable(String)'),
+					change_type = 'deleted' OR status_change = '', status,
+					JSONExtractString(status_change, 'old_value')
+				) as prev_status,
+				dz_ip,
+				device_pk
+			FROM user_changes
 			-- Include users whose dz_ip was in the gossip nodes during the time range
-			WHERE u.dz_ip IN (SELECT gossip_ip FROM gossip_ips)%s
+			WHERE dz_ip IN (SELECT gossip_ip FROM gossip_ips)
+			  AND ((change_type = 'created' AND status = 'activated')
+			       OR (change_type = 'updated' AND status_change != '' AND (status = 'activated' OR JSONExtractString(status_change, 'old_value') = 'activated'))
+			       OR (change_type = 'deleted' AND status = 'activated'))
 		),
 		-- Get latest gossip node info for each IP from history (for validators who may have left)
 		latest_gossip AS (
@@ -2610,8 +2454,8 @@ func queryValidatorEvents(ctx context.Context, startTime, endTime time.Time, inc
 			uc.status,
 			uc.is_deleted,
 			uc.prev_status,
-			COALESCE(uc.dz_ip, '') as dz_ip,
-			COALESCE(uc.device_pk, '') as device_pk,
+			uc.dz_ip,
+			uc.device_pk,
 			COALESCE(d.code, '') as device_code,
 			COALESCE(m.code, '') as metro_code,
 			COALESCE(cont.code, '') as contributor_code,
@@ -2632,22 +2476,17 @@ func queryValidatorEvents(ctx context.Context, startTime, endTime time.Time, inc
 		-- Historical gossip/vote info (for validators who have left)
 		LEFT JOIN latest_gossip gn_hist ON uc.dz_ip = gn_hist.gossip_ip
 		LEFT JOIN latest_vote va_hist ON gn_hist.pubkey = va_hist.node_pubkey
-		WHERE (uc.attrs_hash != uc.prev_attrs_hash OR uc.prev_attrs_hash = 0)
-		  AND ((uc.status = 'activated' AND uc.prev_status != 'activated')
-		       OR (uc.status != 'activated' AND uc.prev_status = 'activated')
-		       OR (uc.is_deleted = 1 AND (uc.prev_is_deleted = 0 OR uc.prev_is_deleted IS NULL) AND uc.prev_status = 'activated'))
-		  AND uc.snapshot_ts >= ? AND uc.snapshot_ts <= ?
 		ORDER BY uc.snapshot_ts DESC, uc.entity_id
 		LIMIT 200
 	`, internalFilter)
 
 	start := time.Now()
-	// Query has 4 pairs of time parameters: gossip_ips, latest_gossip, latest_vote, and final WHERE
+	// Query has 4 pairs of time parameters: gossip_ips, user_changes, latest_gossip, and latest_vote
 	rows, err := envDB(ctx).Query(ctx, query,
 		startTime, endTime, // gossip_ips CTE
+		startTime, endTime, // user_changes CTE
 		startTime, endTime, // latest_gossip CTE
 		startTime, endTime, // latest_vote CTE
-		startTime, endTime, // final WHERE clause
 	)
 	if err != nil {
 		return nil, err
@@ -2754,8 +2593,8 @@ func queryValidatorEvents(ctx context.Context, startTime, endTime time.Time, inc
 // queryGossipNetworkChanges detects when gossip nodes appear or disappear from the Solana network
 // This is separate from DZ user status - it tracks the Solana gossip network itself
 func queryGossipNetworkChanges(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
-	// Find gossip nodes that disappeared from the network
-	// by tracking node PUBKEYS (not IPs) that are no longer in the current gossip table
+	// Find gossip nodes that disappeared from the network from their deletes in dim_change_log,
+	// tracking node PUBKEYS (not IPs) that are no longer in the current gossip table
 	// This correctly handles validators that change IP addresses
 	query := `
 		WITH total_stake AS (
@@ -2766,17 +2605,19 @@ func queryGossipNetworkChanges(ctx context.Context, startTime, endTime time.Time
 		current_pubkeys AS (
 			SELECT DISTINCT pubkey FROM solana_gossip_nodes_current
 		),
-		-- Find nodes (by pubkey) that were seen in history but are no longer in current
-		-- Their "offline" time is their last seen timestamp
+		-- Nodes (by pubkey) deleted in the time range that are not back in current
+		-- Their "offline" time is the delete; attrs holds their last known state
 		disappeared AS (
 			SELECT
-				gn.pubkey,
-				argMax(gn.gossip_ip, gn.snapshot_ts) as last_gossip_ip,
-				max(gn.snapshot_ts) as last_seen_ts
-			FROM dim_solana_gossip_nodes_history gn
-			WHERE gn.pubkey NOT IN (SELECT pubkey FROM current_pubkeys)
-			GROUP BY gn.pubkey
-			HAVING max(gn.snapshot_ts) >= ? AND max(gn.snapshot_ts) <= ?
+				JSONExtractString(attrs, 'pubkey') as pubkey,
+				argMax(JSONExtractString(attrs, 'gossip_ip'), snapshot_ts) as last_gossip_ip,
+				max(snapshot_ts) as last_seen_ts
+			FROM dim_change_log
+			WHERE dataset = 'solana_gossip_nodes'
+			  AND change_type = 'deleted'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?
+			  AND JSONExtractString(attrs, 'pubkey') NOT IN (SELECT pubkey FROM current_pubkeys)
+			GROUP BY pubkey
 		)
 		SELECT
 			d.last_gossip_ip as gossip_ip,
@@ -2799,6 +2640,7 @@ func queryGossipNetworkChanges(ctx context.Context, startTime, endTime time.Time
 			SELECT node_pubkey, argMax(vote_pubkey, snapshot_ts) as vote_pubkey,
 			       argMax(activated_stake_lamports, snapshot_ts) as stake_lamports
 			FROM dim_solana_vote_accounts_history
+			WHERE node_pubkey IN (SELECT pubkey FROM disappeared)
 			GROUP BY node_pubkey
 		) va_hist ON d.pubkey = va_hist.node_pubkey
 		-- Check if this node was connected to DZ (using last known IP)
@@ -2898,9 +2740,9 @@ func queryGossipNetworkChanges(ctx context.Context, startTime, endTime time.Time
 }
 
 func queryVoteAccountChanges(ctx context.Context, startTime, endTime time.Time) ([]TimelineEvent, error) {
-	// Track validators (vote accounts) joining or leaving the network
-	// A validator "joins" when their vote_pubkey first appears in the vote accounts table
-	// A validator "leaves" when their vote_pubkey is no longer in the current vote accounts table
+	// Track validators (vote accounts) joining or leaving the network from dim_change_log
+	// A validator "joins" when their vote account is created with stake or goes from no stake to some
+	// A validator "leaves" when their vote account is deleted and is not back in the current table
 	query := `
 		WITH total_stake AS (
 			SELECT sum(activated_stake_lamports) as total
@@ -2914,31 +2756,38 @@ func queryVoteAccountChanges(ctx context.Context, startTime, endTime time.Time)
 		current_vote_pubkeys AS (
 			SELECT DISTINCT vote_pubkey FROM solana_vote_accounts_current
 		),
-		-- Find validators that left: were in history but not in current
-		-- Their "left" time is their last seen timestamp
+		-- Find validators that left: deleted in the time range and not back in current
+		-- Their "left" time is the delete; attrs holds their last known state
 		left_validators AS (
 			SELECT
-				va.vote_pubkey,
-				argMax(va.node_pubkey, va.snapshot_ts) as node_pubkey,
-				argMax(va.activated_stake_lamports, va.snapshot_ts) as last_stake,
-				max(va.snapshot_ts) as last_seen_ts
-			FROM dim_solana_vote_accounts_history va
-			WHERE va.vote_pubkey NOT IN (SELECT vote_pubkey FROM current_vote_pubkeys)
-			  AND va.activated_stake_lamports > 0
-			GROUP BY va.vote_pubkey
-			HAVING max(va.snapshot_ts) >= ? AND max(va.snapshot_ts) <= ?
+				JSONExtractString(attrs, 'vote_pubkey') as vote_pubkey,
+				argMax(JSONExtractString(attrs, 'node_pubkey'), snapshot_ts) as node_pubkey,
+				argMax(JSONExtractInt(attrs, 'activated_stake_lamports'), snapshot_ts) as last_stake,
+				max(snapshot_ts) as last_seen_ts
+			FROM dim_change_log
+			WHERE dataset = 'solana_vote_accounts'
+			  AND change_type = 'deleted'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?
+			  AND JSONExtractString(attrs, 'vote_pubkey') NOT IN (SELECT vote_pubkey FROM current_vote_pubkeys)
+			  AND JSONExtractInt(attrs, 'activated_stake_lamports') > 0
+			GROUP BY vote_pubkey
 		),
-		-- Find validators that joined: first appeared within the time range
+		-- Find validators that joined: created with stake, or first staked, within the time range
 		joined_validators AS (
 			SELECT
-				va.vote_pubkey,
-				argMin(va.node_pubkey, va.snapshot_ts) as node_pubkey,
-				argMin(va.activated_stake_lamports, va.snapshot_ts) as first_stake,
-				min(va.snapshot_ts) as first_seen_ts
-			FROM dim_solana_vote_accounts_history va
-			WHERE va.activated_stake_lamports > 0
-			GROUP BY va.vote_pubkey
-			HAVING min(va.snapshot_ts) >= ? AND min(va.snapshot_ts) <= ?
+				JSONExtractString(attrs, 'vote_pubkey') as vote_pubkey,
+				argMin(JSONExtractString(attrs, 'node_pubkey'), snapshot_ts) as node_pubkey,
+				argMin(JSONExtractInt(attrs, 'activated_stake_lamports'), snapshot_ts) as first_stake,
+				min(snapshot_ts) as first_seen_ts
+			FROM dim_change_log
+			WHERE dataset = 'solana_vote_accounts'
+			  AND snapshot_ts >= ? AND snapshot_ts <= ?
+			  AND JSONExtractInt(attrs, 'activated_stake_lamports') > 0
+			  AND (change_type = 'created'
+			       OR (change_type = 'updated'
+			           AND has(changed_fields, 'activated_stake_lamports')
+			           AND JSONExtractInt(arrayFirst(c -> JSONExtractString(c, 'field') = 'activated_stake_lamports', JSONExtractArrayRaw(changes)), 'old_value') = 0))
+			GROUP BY vote_pubkey
 		)
 		SELECT
 			lv.vote_pubkey,
@@ -1187,12 +1187,14 @@ func insertVoteAccountHistory(t *testing.T, votePubkey, nodePubkey string, stake
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_solana_vote_accounts_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, vote_pubkey, epoch, node_pubkey, activated_stake_lamports, epoch_vote_account, commission_percentage) VALUES ('%s', '%s', '%s', '%s', 0, 0, '%s', 0, '%s', %d, 'true', 0)`,
 		votePubkey, tsFormat(ts), tsFormat(ts), uuid.New().String(), votePubkey, nodePubkey, stake)))
+	rebuildChangeLog(t, "solana_vote_accounts", "dim_solana_vote_accounts_history", voteAccountChangeLogColumns)
 }
 
 func insertGossipNodeHistory(t *testing.T, pubkey, gossipIP string, ts time.Time) {
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_solana_gossip_nodes_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pubkey, epoch, gossip_ip, gossip_port, tpuquic_ip, tpuquic_port, version) VALUES ('%s', '%s', '%s', '%s', 0, 0, '%s', 0, '%s', 0, '', 0, '')`,
 		pubkey, tsFormat(ts), tsFormat(ts), uuid.New().String(), pubkey, gossipIP)))
+	rebuildChangeLog(t, "solana_gossip_nodes", "dim_solana_gossip_nodes_history", gossipNodeChangeLogColumns)
 }
 
 // insertCurrentVoteAccount inserts a vote account into the history table with a
@@ -1202,6 +1204,7 @@ func insertCurrentVoteAccount(t *testing.T, votePubkey, nodePubkey string, stake
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_solana_vote_accounts_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, vote_pubkey, epoch, node_pubkey, activated_stake_lamports, epoch_vote_account, commission_percentage) VALUES ('%s', '%s', '%s', '%s', 0, 0, '%s', 0, '%s', %d, 'true', 0)`,
 		votePubkey, tsFormat(futureTS), tsFormat(futureTS), uuid.New().String(), votePubkey, nodePubkey, stake)))
+	rebuildChangeLog(t, "solana_vote_accounts", "dim_solana_vote_accounts_history", voteAccountChangeLogColumns)
 }
 
 // insertCurrentGossipNode inserts a gossip node into the history table with a
@@ -1211,6 +1214,7 @@ func insertCurrentGossipNode(t *testing.T, pubkey, gossipIP string) {
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_solana_gossip_nodes_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pubkey, epoch, gossip_ip, gossip_port, tpuquic_ip, tpuquic_port, version) VALUES ('%s', '%s', '%s', '%s', 0, 0, '%s', 0, '%s', 0, '', 0, '')`,
 		pubkey, tsFormat(futureTS), tsFormat(futureTS), uuid.New().String(), pubkey, gossipIP)))
+	rebuildChangeLog(t, "solana_gossip_nodes", "dim_solana_gossip_nodes_history", gossipNodeChangeLogColumns)
 }
 
 // deleteCurrentVoteAccount inserts a deleted row into the history table at the
@@ -1221,6 +1225,7 @@ func deleteCurrentVoteAccount(t *testing.T, votePubkey string, deleteTS time.Tim
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_solana_vote_accounts_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, vote_pubkey, epoch, node_pubkey, activated_stake_lamports, epoch_vote_account, commission_percentage) VALUES ('%s', '%s', '%s', '%s', 1, 0, '%s', 0, '', 0, '', 0)`,
 		votePubkey, tsFormat(deleteTS), tsFormat(deleteTS), uuid.New().String(), votePubkey)))
+	rebuildChangeLog(t, "solana_vote_accounts", "dim_solana_vote_accounts_history", voteAccountChangeLogColumns)
 }
 
 // deleteCurrentGossipNode inserts a deleted row into the history table at the
@@ -1229,6 +1234,137 @@ func deleteCurrentGossipNode(t *testing.T, pubkey string, deleteTS time.Time) {
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_solana_gossip_nodes_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pubkey, epoch, gossip_ip, gossip_port, tpuquic_ip, tpuquic_port, version) VALUES ('%s', '%s', '%s', '%s', 1, 0, '%s', 0, '', 0, '', 0, '')`,
 		pubkey, tsFormat(deleteTS), tsFormat(deleteTS), uuid.New().String(), pubkey)))
+	rebuildChangeLog(t, "solana_gossip_nodes", "dim_solana_gossip_nodes_history", gossipNodeChangeLogColumns)
+}
+
+// changeLogColumn is a history column copied into rebuilt change log entries.
+type changeLogColumn struct {
+	Name    string
+	Numeric bool
+}
+
+var (
+	voteAccountChangeLogColumns = []changeLogColumn{
+		{"vote_pubkey", false}, {"epoch", true}, {"node_pubkey", false},
+		{"activated_stake_lamports", true}, {"epoch_vote_account", false}, {"commission_percentage", true},
+	}
+	gossipNodeChangeLogColumns = []changeLogColumn{
+		{"pubkey", false}, {"epoch", true}, {"gossip_ip", false},
+		{"gossip_port", true}, {"tpuquic_ip", false}, {"tpuquic_port", true}, {"version", false},
+	}
+	dzUserChangeLogColumns = []changeLogColumn{
+		{"pk", false}, {"owner_pubkey", false}, {"status", false}, {"kind", false},
+		{"client_ip", false}, {"dz_ip", false}, {"device_pk", false}, {"tunnel_id", true},
+	}
+)
+
+// rebuildChangeLog replaces the dim_change_log entries of a dataset with the transitions in its
+// history table, as WriteBatch would have recorded them (including creations, so entities first
+// seen inside a test's time range show up). The first column is the primary key. Rebuilding
+// from the whole table keeps the log right regardless of the order the helpers insert rows in.
+func rebuildChangeLog(t *testing.T, dataset, historyTable string, columns []changeLogColumn) {
+	ctx := t.Context()
+	require.NoError(t, config.DB.Exec(ctx, `DELETE FROM dim_change_log WHERE dataset = ?`, dataset))
+
+	selects := make([]string, 0, len(columns))
+	for _, c := range columns {
+		selects = append(selects, fmt.Sprintf("toString(%s)", c.Name))
+	}
+	rows, err := config.DB.Query(ctx, fmt.Sprintf(`
+		SELECT entity_id, toString(op_id), snapshot_ts, is_deleted, %s
+		FROM %s
+		ORDER BY entity_id, snapshot_ts, ingested_at, op_id
+	`, strings.Join(selects, ", "), historyTable))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type version struct {
+		entityID, opID string
+		snapshotTS     time.Time
+		isDeleted      uint8
+		values         []string
+	}
+	var prev *version
+	var values []string
+	for rows.Next() {
+		v := version{values: make([]string, len(columns))}
+		dest := []any{&v.entityID, &v.opID, &v.snapshotTS, &v.isDeleted}
+		for i := range v.values {
+			dest = append(dest, &v.values[i])
+		}
+		require.NoError(t, rows.Scan(dest...))
+		if prev != nil && prev.entityID != v.entityID {
+			prev = nil
+		}
+
+		prevExists := prev != nil && prev.isDeleted == 0
+		state := v
+		changeType := ""
+		var changes []map[string]any
+		var changedFields []string
+		switch {
+		case v.isDeleted == 1:
+			if prevExists {
+				changeType = "deleted"
+				state = *prev
+			}
+		case !prevExists:
+			changeType = "created"
+		default:
+			for i, c := range columns[1:] {
+				if prev.values[i+1] != v.values[i+1] {
+					changes = append(changes, map[string]any{
+						"field":     c.Name,
+						"old_value": changeLogValue(c, prev.values[i+1]),
+						"new_value": changeLogValue(c, v.values[i+1]),
+					})
+					changedFields = append(changedFields, "'"+c.Name+"'")
+				}
+			}
+			if len(changes) > 0 {
+				changeType = "updated"
+			}
+		}
+		if changeType != "" {
+			attrs := make(map[string]any, len(columns))
+			for i, c := range columns {
+				attrs[c.Name] = changeLogValue(c, state.values[i])
+			}
+			attrsJSON, err := json.Marshal(attrs)
+			require.NoError(t, err)
+			if changes == nil {
+				changes = []map[string]any{}
+			}
+			changesJSON, err := json.Marshal(changes)
+			require.NoError(t, err)
+			values = append(values, fmt.Sprintf("('%s', '%s', '%s', '%s', '%s', '%s', [%s], '%s', '%s')",
+				dataset, v.entityID, v.opID, tsFormat(v.snapshotTS), tsFormat(v.snapshotTS), changeType,
+				strings.Join(changedFields, ", "), changesJSON, attrsJSON))
+		}
+		current := v
+		prev = &current
+	}
+	require.NoError(t, rows.Err())
+
+	if len(values) > 0 {
+		require.NoError(t, config.DB.Exec(ctx, fmt.Sprintf(
+			`INSERT INTO dim_change_log (dataset, entity_id, op_id, snapshot_ts, ingested_at, change_type, changed_fields, changes, attrs) VALUES %s`,
+			strings.Join(values, ", "))))
+	}
+}
+
+// changeLogValue converts a history value read as a string back to its JSON type.
+func changeLogValue(c changeLogColumn, v string) any {
+	if c.Numeric {
+		return json.Number(v)
+	}
+	return v
+}
+
+func insertChangeLog(t *testing.T, dataset, entityID, changeType, changes, attrs string, ts time.Time) {
+	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
+		`INSERT INTO dim_change_log (dataset, entity_id, op_id, snapshot_ts, ingested_at, change_type, changed_fields, changes, attrs) VALUES ('%s', '%s', '%s', '%s', '%s', '%s', [], '%s', '%s')`,
+		dataset, entityID, uuid.New().String(), tsFormat(ts), tsFormat(ts), changeType, changes, attrs)))
 }
 
 func insertDZUserHistory(t *testing.T, pk, entityID, ownerPubkey, dzIP, devicePK, status string, ts time.Time) {
@@ -1237,6 +1373,7 @@ func insertDZUserHistory(t *testing.T, pk, entityID, ownerPubkey, dzIP, devicePK
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_dz_users_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id) VALUES ('%s', '%s', '%s', '%s', 0, %d, '%s', '%s', '%s', '', '', '%s', '%s', 0)`,
 		entityID, tsFormat(ts), tsFormat(ts), uuid.New().String(), attrsHash, pk, ownerPubkey, status, dzIP, devicePK)))
+	rebuildChangeLog(t, "dz_users", "dim_dz_users_history", dzUserChangeLogColumns)
 }
 
 func insertDZUserHistoryDeleted(t *testing.T, pk, entityID, ownerPubkey, dzIP, devicePK, status string, ts time.Time) {
@@ -1244,6 +1381,7 @@ func insertDZUserHistoryDeleted(t *testing.T, pk, entityID, ownerPubkey, dzIP, d
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_dz_users_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id) VALUES ('%s', '%s', '%s', '%s', 1, %d, '%s', '%s', '%s', '', '', '%s', '%s', 0)`,
 		entityID, tsFormat(ts), tsFormat(ts), uuid.New().String(), attrsHash, pk, ownerPubkey, status, dzIP, devicePK)))
+	rebuildChangeLog(t, "dz_users", "dim_dz_users_history", dzUserChangeLogColumns)
 }
 
 // insertDZUserCurrent inserts a DZ user into the history table with a
@@ -1254,6 +1392,7 @@ func insertDZUserCurrent(t *testing.T, pk, dzIP, status, ownerPubkey, devicePK s
 	require.NoError(t, config.DB.Exec(t.Context(), fmt.Sprintf(
 		`INSERT INTO dim_dz_users_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id) VALUES ('%s', '%s', '%s', '%s', 0, 0, '%s', '%s', '%s', '', '', '%s', '%s', 0)`,
 		entityID, tsFormat(futureTS), tsFormat(futureTS), uuid.New().String(), pk, ownerPubkey, status, dzIP, devicePK)))
+	rebuildChangeLog(t, "dz_users", "dim_dz_users_history", dzUserChangeLogColumns)
 }
 
 func findEventsByType(events []handlers.TimelineEvent, eventType string) []handlers.TimelineEvent {
@@ -2179,6 +2318,10 @@ func TestMinStakePct_NonValidatorPassThrough(t *testing.T) {
 	require.NoError(t, config.DB.Exec(ctx, fmt.Sprintf(
 		`INSERT INTO dim_dz_devices_history (entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pk, status, device_type, code, public_ip, contributor_pk, metro_pk, max_users) VALUES ('dev-entity-1', '%s', '%s', '%s', 0, 2, 'dev-1', 'activated', 'router', 'DEV-001', '10.0.0.1', '', '', 0)`,
 		tsFormat(t2), tsFormat(t2), uuid.New().String())))
+	insertChangeLog(t, "dz_devices", "dev-entity-1", "updated",
+		`[{"field":"status","old_value":"pending","new_value":"activated"}]`,
+		`{"pk":"dev-1","status":"activated","device_type":"router","code":"DEV-001","public_ip":"10.0.0.1","contributor_pk":"","metro_pk":"","max_users":0}`,
+		t2)
 
 	// Small validator (1% stake)
 	insertCurrentVoteAccount(t, "vote-small", "node-small", 10_000_000_000_000)
@@ -2389,3 +2532,52 @@ func TestCombinedFilters(t *testing.T) {
 	}
 	assert.True(t, found, "vote-A (on DZ, joined, 10%% stake) should be in results")
 }
+
+func TestTimeline_EntityChangesFromChangeLog(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+
+	t1 := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2025, 6, 1, 1, 0, 0, 0, time.UTC)
+
+	insertChangeLog(t, "dz_devices", "dev-entity-1", "created", `[]`,
+		`{"pk":"dev-1","status":"pending","device_type":"router","code":"DEV-001","public_ip":"10.0.0.1","contributor_pk":"","metro_pk":"","max_users":64}`,
+		t1)
+	insertChangeLog(t, "dz_links", "link-entity-1", "updated",
+		`[{"field":"bandwidth_bps","old_value":1000,"new_value":10000},{"field":"side_a_iface_name","old_value":"Ethernet1","new_value":"Ethernet2"}]`,
+		`{"pk":"link-1","status":"activated","code":"LINK-001","tunnel_net":"","contributor_pk":"","side_a_pk":"","side_z_pk":"","side_a_iface_name":"Ethernet2","side_z_iface_name":"","link_type":"WAN","committed_rtt_ns":0,"committed_jitter_ns":0,"bandwidth_bps":10000,"isis_delay_override_ns":0}`,
+		t2)
+	// Outside the requested range
+	insertChangeLog(t, "dz_metros", "metro-entity-1", "deleted", `[]`,
+		`{"pk":"metro-1","code":"ams","name":"Amsterdam","longitude":4.9,"latitude":52.4}`,
+		t2.Add(time.Hour))
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/timeline?start=%s&end=%s&category=state_change",
+		t1.Add(-time.Minute).Format(time.RFC3339), t2.Add(time.Minute).Format(time.RFC3339)), nil)
+	rr := httptest.NewRecorder()
+	handlers.GetTimeline(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp handlers.TimelineResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+	byType := make(map[string]handlers.TimelineEvent)
+	for _, e := range resp.Events {
+		byType[e.EntityType] = e
+	}
+	require.NotContains(t, byType, "metro")
+
+	device, ok := byType["device"]
+	require.True(t, ok, "expected device event")
+	assert.Equal(t, "entity_created", device.EventType)
+	assert.Equal(t, "Device DEV-001 created", device.Title)
+	entity := getDetails(t, device)["entity"].(map[string]any)
+	assert.Equal(t, float64(64), entity["max_users"])
+
+	link, ok := byType["link"]
+	require.True(t, ok, "expected link event")
+	assert.Equal(t, "entity_updated", link.EventType)
+	assert.Equal(t, "Link LINK-001 bandwidth changed", link.Title, "untracked columns are not listed")
+	changes := getDetails(t, link)["changes"].([]any)
+	require.Len(t, changes, 1)
+	assert.Equal(t, map[string]any{"field": "bandwidth", "old_value": float64(1000), "new_value": float64(10000)}, changes[0])
+}
@@ -911,6 +911,59 @@ func TestTrafficDashboardBurstiness_Empty(t *testing.T) {
 	assert.Empty(t, resp.Entities)
 }
 
+// --- Forecast endpoint tests ---
+
+func TestTrafficDashboardForecast(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	seedDashboardData(t)
+
+	// Two weeks of midday samples on link-1, growing from 57% to 70.5% utilization of 100Gbps.
+	// Today's samples from seedDashboardData are excluded as an incomplete day.
+	require.NoError(t, config.DB.Exec(t.Context(), `INSERT INTO fact_dz_device_interface_counters
+		(event_ts, ingested_at, device_pk, intf, link_pk, in_octets_delta, out_octets_delta, delta_duration, in_discards_delta, out_discards_delta)
+		SELECT
+			toStartOfDay(now()) - toIntervalDay(number + 1) + toIntervalHour(12),
+			now(), 'dev-1', 'Port-Channel1000', 'link-1',
+			toInt64((0.705 - 0.01 * number) * 375000000000), 0, 30.0, 0, 0
+		FROM numbers(14)`))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/traffic/dashboard/forecast?horizon_days=30", nil)
+	rr := httptest.NewRecorder()
+
+	handlers.GetTrafficDashboardForecast(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp handlers.CapacityForecastResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "link", resp.Entity)
+	require.Len(t, resp.Entities, 1)
+
+	e := resp.Entities[0]
+	assert.Equal(t, "link-1", e.PK)
+	assert.Equal(t, 14, e.HistoryDays)
+	assert.InDelta(t, 0.01, e.TrendPerDay, 1e-6)
+	require.NotNil(t, e.DaysUntilSaturation)
+	assert.Equal(t, 10, *e.DaysUntilSaturation)
+	assert.Len(t, e.Series, 14+30)
+}
+
+func TestTrafficDashboardForecast_Empty(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/traffic/dashboard/forecast?entity=device", nil)
+	rr := httptest.NewRecorder()
+
+	handlers.GetTrafficDashboardForecast(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp handlers.CapacityForecastResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "device", resp.Entity)
+	assert.Empty(t, resp.Entities)
+}
+
 // --- Health endpoint tests ---
 
 // seedHealthData inserts data with nonzero error/discard/carrier values for health testing.
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/malbeclabs/lake/api/metrics"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+const (
+	// defaultUtilizationThreshold is the projected link utilization, in percent, above which a
+	// link is flagged as overloaded.
+	defaultUtilizationThreshold = 80.0
+	// trafficProjectionWindow is how much interface counter history the traffic matrix averages.
+	trafficProjectionWindow = time.Hour
+)
+
+// ProjectedLinkLoad is a surviving link's traffic before and after failures are rerouted.
+// Loads are for the busier direction after rerouting.
+type ProjectedLinkLoad struct {
+	LinkPK               string  `json:"linkPK"`
+	LinkCode             string  `json:"linkCode"`
+	FromCode             string  `json:"fromCode"` // Device sending in the busier direction
+	ToCode               string  `json:"toCode"`
+	BandwidthBps         int64   `json:"bandwidthBps"`
+	CurrentBps           float64 `json:"currentBps"`
+	ProjectedBps         float64 `json:"projectedBps"`
+	CurrentUtilization   float64 `json:"currentUtilization"`   // Percent of bandwidth
+	ProjectedUtilization float64 `json:"projectedUtilization"` // Percent of bandwidth
+	Overloaded           bool    `json:"overloaded"`           // Projected utilization exceeds the threshold
+}
+
+// TrafficProjection is the projected load on every surviving link.
+type TrafficProjection struct {
+	UtilizationThreshold float64             `json:"utilizationThreshold"`
+	Links                []ProjectedLinkLoad `json:"links"`
+	OverloadedCount      int                 `json:"overloadedCount"`
+	UnroutedBps          float64             `json:"unroutedBps"` // User and transit traffic left with no path
+}
+
+// trafficLink is a link's capacity and endpoints.
+type trafficLink struct {
+	pk, code         string
+	sideAPK, sideZPK string
+	bandwidthBps     int64
+}
+
+// trafficMatrix is the observed traffic used to project load after failures.
+type trafficMatrix struct {
+	// Per-device user tunnel traffic entering and leaving DZ
+	ingress, egress map[string]float64
+	// Measured traffic per link direction
+	measured map[routing.LinkDirection]float64
+	links    []trafficLink
+}
+
+// loadTrafficMatrix reads user tunnel and link traffic over the projection window, and link
+// capacities.
+func loadTrafficMatrix(ctx context.Context) (*trafficMatrix, error) {
+	start := time.Now()
+	m := &trafficMatrix{
+		ingress:  make(map[string]float64),
+		egress:   make(map[string]float64),
+		measured: make(map[routing.LinkDirection]float64),
+	}
+	window := int(trafficProjectionWindow.Seconds())
+
+	// Rates are summed per interface so devices with many tunnels add up
+	userQuery := `
+		SELECT device_pk, SUM(in_bps), SUM(out_bps)
+		FROM (
+			SELECT
+				device_pk,
+				intf,
+				SUM(in_octets_delta) * 8 / SUM(delta_duration) AS in_bps,
+				SUM(out_octets_delta) * 8 / SUM(delta_duration) AS out_bps
+			FROM fact_dz_device_interface_counters
+			WHERE event_ts > now() - INTERVAL ? SECOND
+				AND user_tunnel_id IS NOT NULL
+				AND delta_duration > 0
+				AND in_octets_delta >= 0
+				AND out_octets_delta >= 0
+			GROUP BY device_pk, intf
+		)
+		GROUP BY device_pk
+	`
+	rows, err := envDB(ctx).Query(ctx, userQuery, window)
+	if err != nil {
+		metrics.RecordClickHouseQuery(time.Since(start), err)
+		return nil, fmt.Errorf("failed to query user traffic: %w", err)
+	}
+	for rows.Next() {
+		var devicePK string
+		var in, out float64
+		if err := rows.Scan(&devicePK, &in, &out); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan user traffic: %w", err)
+		}
+		// A tunnel's inbound octets come from the user, so they enter DZ here
+		m.ingress[devicePK] = in
+		m.egress[devicePK] = out
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		metrics.RecordClickHouseQuery(time.Since(start), err)
+		return nil, fmt.Errorf("failed to read user traffic: %w", err)
+	}
+
+	// Traffic a device sends out of its side of a link travels towards the other side
+	linkQuery := `
+		SELECT link_pk, device_pk, SUM(out_bps)
+		FROM (
+			SELECT
+				link_pk,
+				device_pk,
+				intf,
+				SUM(out_octets_delta) * 8 / SUM(delta_duration) AS out_bps
+			FROM fact_dz_device_interface_counters
+			WHERE event_ts > now() - INTERVAL ? SECOND
+				AND link_pk != ''
+				AND delta_duration > 0
+				AND out_octets_delta >= 0
+			GROUP BY link_pk, device_pk, intf
+		)
+		GROUP BY link_pk, device_pk
+	`
+	rows, err = envDB(ctx).Query(ctx, linkQuery, window)
+	if err != nil {
+		metrics.RecordClickHouseQuery(time.Since(start), err)
+		return nil, fmt.Errorf("failed to query link traffic: %w", err)
+	}
+	for rows.Next() {
+		var linkPK, devicePK string
+		var out float64
+		if err := rows.Scan(&linkPK, &devicePK, &out); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan link traffic: %w", err)
+		}
+		m.measured[routing.LinkDirection{LinkPK: linkPK, From: devicePK}] = out
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		metrics.RecordClickHouseQuery(time.Since(start), err)
+		return nil, fmt.Errorf("failed to read link traffic: %w", err)
+	}
+
+	capacityQuery := `
+		SELECT pk, code, COALESCE(side_a_pk, ''), COALESCE(side_z_pk, ''), COALESCE(bandwidth_bps, 0)
+		FROM dz_links_current
+	`
+	rows, err = envDB(ctx).Query(ctx, capacityQuery)
+	metrics.RecordClickHouseQuery(time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query link capacity: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l trafficLink
+		if err := rows.Scan(&l.pk, &l.code, &l.sideAPK, &l.sideZPK, &l.bandwidthBps); err != nil {
+			return nil, fmt.Errorf("failed to scan link capacity: %w", err)
+		}
+		m.links = append(m.links, l)
+	}
+	return m, rows.Err()
+}
+
+// demands estimates device-to-device traffic with a gravity model: each device's user
+// ingress is spread over the other devices in proportion to their user egress.
+func (m *trafficMatrix) demands() []routing.Demand {
+	totalEgress := 0.0
+	for _, bps := range m.egress {
+		totalEgress += bps
+	}
+
+	sources := make([]string, 0, len(m.ingress))
+	for pk := range m.ingress {
+		sources = append(sources, pk)
+	}
+	sort.Strings(sources)
+	targets := make([]string, 0, len(m.egress))
+	for pk := range m.egress {
+		targets = append(targets, pk)
+	}
+	sort.Strings(targets)
+
+	var demands []routing.Demand
+	for _, src := range sources {
+		// Traffic between users on the same device never crosses a link
+		remote := totalEgress - m.egress[src]
+		if m.ingress[src] <= 0 || remote <= 0 {
+			continue
+		}
+		for _, dst := range targets {
+			if dst == src || m.egress[dst] <= 0 {
+				continue
+			}
+			demands = append(demands, routing.Demand{
+				Source: src,
+				Target: dst,
+				BPS:    m.ingress[src] * m.egress[dst] / remote,
+			})
+		}
+	}
+	return demands
+}
+
+// project reroutes the traffic matrix around the failures. Each link direction keeps its
+// measured load plus the change the model predicts, so transit traffic the model cannot see
+// still counts against capacity. Such transit on a failed link is rerouted between the link's
+// endpoints, or counted as unrouted when they are no longer connected.
+func (m *trafficMatrix) project(topo *routing.Topology, failures routing.Failures, threshold float64) TrafficProjection {
+	after := topo.Without(failures)
+	demands := m.demands()
+	before, rerouted := topo.Distribute(demands), after.Distribute(demands)
+	transit := after.Distribute(m.failedTransit(failures, before))
+
+	projection := TrafficProjection{
+		UtilizationThreshold: threshold,
+		Links:                []ProjectedLinkLoad{},
+		UnroutedBps:          max(rerouted.Unrouted-before.Unrouted, 0) + transit.Unrouted,
+	}
+
+	for _, l := range m.links {
+		if l.sideAPK == "" || l.sideZPK == "" || linkFailed(failures, l) {
+			continue
+		}
+
+		var best ProjectedLinkLoad
+		for i, dir := range [][2]string{{l.sideAPK, l.sideZPK}, {l.sideZPK, l.sideAPK}} {
+			key := routing.LinkDirection{LinkPK: l.pk, From: dir[0]}
+			current := m.measured[key]
+			projected := max(current+rerouted.Links[key]-before.Links[key]+transit.Links[key], 0)
+			if i > 0 && projected <= best.ProjectedBps {
+				continue
+			}
+			best = ProjectedLinkLoad{
+				LinkPK:       l.pk,
+				LinkCode:     l.code,
+				FromCode:     deviceCode(topo, dir[0]),
+				ToCode:       deviceCode(topo, dir[1]),
+				BandwidthBps: l.bandwidthBps,
+				CurrentBps:   current,
+				ProjectedBps: projected,
+			}
+		}
+		if l.bandwidthBps > 0 {
+			best.CurrentUtilization = best.CurrentBps * 100 / float64(l.bandwidthBps)
+			best.ProjectedUtilization = best.ProjectedBps * 100 / float64(l.bandwidthBps)
+			best.Overloaded = best.ProjectedUtilization > threshold
+		}
+		if best.Overloaded {
+			projection.OverloadedCount++
+		}
+		projection.Links = append(projection.Links, best)
+	}
+
+	sort.SliceStable(projection.Links, func(i, j int) bool {
+		a, b := projection.Links[i], projection.Links[j]
+		if a.ProjectedUtilization != b.ProjectedUtilization {
+			return a.ProjectedUtilization > b.ProjectedUtilization
+		}
+		return a.LinkCode < b.LinkCode
+	})
+	return projection
+}
+
+// failedTransit returns, as demands between each failed link direction's endpoints, the
+// measured load the model's routing of the matrix doesn't explain.
+func (m *trafficMatrix) failedTransit(failures routing.Failures, before routing.TrafficLoad) []routing.Demand {
+	var demands []routing.Demand
+	for _, l := range m.links {
+		if l.sideAPK == "" || l.sideZPK == "" || !linkFailed(failures, l) {
+			continue
+		}
+		for _, dir := range [][2]string{{l.sideAPK, l.sideZPK}, {l.sideZPK, l.sideAPK}} {
+			key := routing.LinkDirection{LinkPK: l.pk, From: dir[0]}
+			if residual := m.measured[key] - before.Links[key]; residual > 0 {
+				demands = append(demands, routing.Demand{Source: dir[0], Target: dir[1], BPS: residual})
+			}
+		}
+	}
+	return demands
+}
+
+// linkFailed reports whether a link is out of service under the failures.
+func linkFailed(f routing.Failures, l trafficLink) bool {
+	for _, pk := range f.Links {
+		if pk == l.pk {
+			return true
+		}
+	}
+	for _, pk := range f.Devices {
+		if pk == l.sideAPK || pk == l.sideZPK {
+			return true
+		}
+	}
+	edge := routing.NewEdge(l.sideAPK, l.sideZPK)
+	for _, e := range f.Edges {
+		if e = routing.NewEdge(e.A, e.Z); e.A == edge.A && e.Z == edge.Z {
+			return true
+		}
+	}
+	return false
+}
+
+// projectTraffic projects link load once the failures are rerouted. Traffic only adds detail
+// to an impact analysis, so a failed read is logged and yields nil.
+func projectTraffic(ctx context.Context, topo *routing.Topology, failures routing.Failures, threshold float64) *TrafficProjection {
+	if threshold <= 0 {
+		threshold = defaultUtilizationThreshold
+	}
+	m, err := loadTrafficMatrix(ctx)
+	if err != nil {
+		log.Printf("Traffic projection error: %v", err)
+		return nil
+	}
+	projection := m.project(topo, failures, threshold)
+	return &projection
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficMatrixDemands(t *testing.T) {
+	t.Parallel()
+
+	m := &trafficMatrix{
+		ingress: map[string]float64{"a": 90, "b": 10},
+		egress:  map[string]float64{"a": 50, "b": 20, "c": 10},
+	}
+	// a's users send to b and c in proportion to their egress, never to a itself
+	assert.Equal(t, []routing.Demand{
+		{Source: "a", Target: "b", BPS: 60},
+		{Source: "a", Target: "c", BPS: 30},
+		{Source: "b", Target: "a", BPS: 50.0 * 10 / 60},
+		{Source: "b", Target: "c", BPS: 10.0 * 10 / 60},
+	}, m.demands())
+}
+
+func TestTrafficMatrixProject(t *testing.T) {
+	t.Parallel()
+
+	// A triangle where a reaches b directly
+	var adjacencies []graph.ISISAdjacency
+	var links []graph.ISISLink
+	var capacity []trafficLink
+	for _, pair := range [][2]string{{"a", "b"}, {"a", "c"}, {"c", "b"}} {
+		pk := pair[0] + "-" + pair[1]
+		adjacencies = append(adjacencies,
+			graph.ISISAdjacency{FromDevicePK: pair[0], ToDevicePK: pair[1], Metric: 10},
+			graph.ISISAdjacency{FromDevicePK: pair[1], ToDevicePK: pair[0], Metric: 10},
+		)
+		links = append(links, graph.ISISLink{PK: pk, SideAPK: pair[0], SideZPK: pair[1]})
+		capacity = append(capacity, trafficLink{pk: pk, code: pk, sideAPK: pair[0], sideZPK: pair[1], bandwidthBps: 1000})
+	}
+	topo := routing.NewTopology(nil, adjacencies, links)
+
+	m := &trafficMatrix{
+		ingress: map[string]float64{"a": 100},
+		egress:  map[string]float64{"b": 100},
+		// a-b also carries transit traffic the model cannot attribute
+		measured: map[routing.LinkDirection]float64{
+			{LinkPK: "a-b", From: "a"}: 150,
+			{LinkPK: "a-c", From: "a"}: 20,
+		},
+		links: capacity,
+	}
+
+	// The modelled 100 and the 50 of transit on a-b both move to a-c-b
+	projection := m.project(topo, routing.Failures{Links: []string{"a-b"}}, 15)
+	require.Len(t, projection.Links, 2)
+	assert.Equal(t, 1, projection.OverloadedCount)
+
+	ac := projection.Links[0]
+	assert.Equal(t, "a-c", ac.LinkPK)
+	assert.Equal(t, "a", ac.FromCode)
+	assert.Equal(t, 20.0, ac.CurrentBps)
+	assert.Equal(t, 170.0, ac.ProjectedBps)
+	assert.InDelta(t, 17.0, ac.ProjectedUtilization, 1e-9)
+	assert.True(t, ac.Overloaded)
+
+	// Exactly at the threshold is not overloaded
+	cb := projection.Links[1]
+	assert.Equal(t, "c-b", cb.LinkPK)
+	assert.Equal(t, 150.0, cb.ProjectedBps)
+	assert.False(t, cb.Overloaded)
+	assert.Zero(t, projection.UnroutedBps)
+
+	// Taking b down removes its users' traffic and the transit towards it from the links
+	// instead, leaving both unrouted
+	projection = m.project(topo, routing.Failures{Devices: []string{"b"}}, 10)
+	require.Len(t, projection.Links, 1)
+	assert.Equal(t, 20.0, projection.Links[0].ProjectedBps)
+	assert.Equal(t, 150.0, projection.UnroutedBps)
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/malbeclabs/lake/api/metrics"
+)
+
+// UserHealth is a user's latest tunnel health score, from fact_dz_user_health.
+type UserHealth struct {
+	EvaluatedAt        string   `json:"evaluated_at"` // start of the scored window
+	Score              float64  `json:"score"`        // 0-100, 100 is a clean tunnel
+	Status             string   `json:"status"`       // healthy, degraded or at_risk
+	Issues             []string `json:"issues"`
+	CoveragePct        float64  `json:"coverage_pct"`
+	ErrorRatePct       float64  `json:"error_rate_pct"`
+	DiscardRatePct     float64  `json:"discard_rate_pct"`
+	CarrierTransitions int64    `json:"carrier_transitions"`
+}
+
+// splitIssues parses the comma-separated issues column.
+func splitIssues(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// queryUserHealth returns the latest health of a user, or nil if it has not been scored in the
+// last hour.
+func queryUserHealth(ctx context.Context, pk string) (*UserHealth, error) {
+	rows, err := envDB(ctx).Query(ctx, `
+		SELECT
+			formatDateTime(event_ts, '%Y-%m-%dT%H:%i:%sZ', 'UTC') as evaluated_at,
+			score, status, issues, coverage_pct, error_rate_pct, discard_rate_pct, carrier_transitions
+		FROM dz_user_health_current
+		WHERE user_pk = ?
+	`, pk)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var h UserHealth
+	var issues string
+	if err := rows.Scan(&h.EvaluatedAt, &h.Score, &h.Status, &issues, &h.CoveragePct,
+		&h.ErrorRatePct, &h.DiscardRatePct, &h.CarrierTransitions); err != nil {
+		return nil, err
+	}
+	h.Issues = splitIssues(issues)
+	return &h, nil
+}
+
+type UserHealthPoint struct {
+	Time               string   `json:"time"`
+	Score              float64  `json:"score"`
+	Status             string   `json:"status"`
+	Issues             []string `json:"issues"`
+	CoveragePct        float64  `json:"coverage_pct"`
+	ErrorRatePct       float64  `json:"error_rate_pct"`
+	DiscardRatePct     float64  `json:"discard_rate_pct"`
+	CarrierTransitions int64    `json:"carrier_transitions"`
+}
+
+// GetUserHealth returns a user's tunnel health scores over the last hours (default 24, max 168).
+func GetUserHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	pk := chi.URLParam(r, "pk")
+	if pk == "" {
+		http.Error(w, "missing user pk", http.StatusBadRequest)
+		return
+	}
+	hours := 24
+	if h := r.URL.Query().Get("hours"); h != "" {
+		if v, err := strconv.Atoi(h); err == nil && v > 0 && v <= 168 {
+			hours = v
+		}
+	}
+
+	start := time.Now()
+	query := `
+		SELECT
+			formatDateTime(event_ts, '%Y-%m-%dT%H:%i:%sZ', 'UTC') as time,
+			score, status, issues, coverage_pct, error_rate_pct, discard_rate_pct, carrier_transitions
+		FROM fact_dz_user_health FINAL
+		WHERE user_pk = ?
+			AND event_ts > now() - INTERVAL ? HOUR
+		ORDER BY event_ts
+	`
+
+	rows, err := envDB(ctx).Query(ctx, query, pk, hours)
+	duration := time.Since(start)
+	metrics.RecordClickHouseQuery(duration, err)
+
+	if err != nil {
+		log.Printf("UserHealth query error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	points := []UserHealthPoint{}
+	for rows.Next() {
+		var p UserHealthPoint
+		var issues string
+		if err := rows.Scan(&p.Time, &p.Score, &p.Status, &issues, &p.CoveragePct,
+			&p.ErrorRatePct, &p.DiscardRatePct, &p.CarrierTransitions); err != nil {
+			log.Printf("UserHealth scan error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		p.Issues = splitIssues(issues)
+		points = append(points, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("UserHealth rows error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(points); err != nil {
+		log.Printf("JSON encoding error: %v", err)
+	}
+}
+
+type UserAtRisk struct {
+	PK          string     `json:"pk"`
+	OwnerPubkey string     `json:"owner_pubkey"`
+	Kind        string     `json:"kind"`
+	DzIP        string     `json:"dz_ip"`
+	ClientIP    string     `json:"client_ip"`
+	TunnelID    int32      `json:"tunnel_id"`
+	DevicePK    string     `json:"device_pk"`
+	DeviceCode  string     `json:"device_code"`
+	MetroCode   string     `json:"metro_code"`
+	Health      UserHealth `json:"health"`
+	// Share of the user's windows in the last 24 hours that were not healthy
+	UnhealthyPct24h float64 `json:"unhealthy_pct_24h"`
+}
+
+type UsersAtRiskResponse struct {
+	Users    []UserAtRisk `json:"users"`
+	AtRisk   int          `json:"at_risk"`
+	Degraded int          `json:"degraded"`
+}
+
+// GetUsersAtRisk lists the users whose latest tunnel health is not healthy, worst first.
+func GetUsersAtRisk(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	query := `
+		WITH recent AS (
+			SELECT user_pk, countIf(status != 'healthy') * 100.0 / count() as unhealthy_pct
+			FROM fact_dz_user_health FINAL
+			WHERE event_ts > now() - INTERVAL 24 HOUR
+			GROUP BY user_pk
+		)
+		SELECT
+			u.pk,
+			COALESCE(u.owner_pubkey, '') as owner_pubkey,
+			COALESCE(u.kind, '') as kind,
+			COALESCE(u.dz_ip, '') as dz_ip,
+			COALESCE(u.client_ip, '') as client_ip,
+			COALESCE(u.tunnel_id, 0) as tunnel_id,
+			COALESCE(u.device_pk, '') as device_pk,
+			COALESCE(d.code, '') as device_code,
+			COALESCE(m.code, '') as metro_code,
+			formatDateTime(h.event_ts, '%Y-%m-%dT%H:%i:%sZ', 'UTC') as evaluated_at,
+			h.score,
+			h.status,
+			h.issues,
+			h.coverage_pct,
+			h.error_rate_pct,
+			h.discard_rate_pct,
+			h.carrier_transitions,
+			COALESCE(rc.unhealthy_pct, 0) as unhealthy_pct
+		FROM dz_user_health_current h
+		JOIN dz_users_current u ON h.user_pk = u.pk
+		LEFT JOIN dz_devices_current d ON u.device_pk = d.pk
+		LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+		LEFT JOIN recent rc ON h.user_pk = rc.user_pk
+		WHERE h.status != 'healthy'
+		ORDER BY h.score, unhealthy_pct DESC, u.pk
+	`
+
+	rows, err := envDB(ctx).Query(ctx, query)
+	duration := time.Since(start)
+	metrics.RecordClickHouseQuery(duration, err)
+
+	if err != nil {
+		log.Printf("UsersAtRisk query error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	response := UsersAtRiskResponse{Users: []UserAtRisk{}}
+	for rows.Next() {
+		var u UserAtRisk
+		var issues string
+		if err := rows.Scan(
+			&u.PK,
+			&u.OwnerPubkey,
+			&u.Kind,
+			&u.DzIP,
+			&u.ClientIP,
+			&u.TunnelID,
+			&u.DevicePK,
+			&u.DeviceCode,
+			&u.MetroCode,
+			&u.Health.EvaluatedAt,
+			&u.Health.Score,
+			&u.Health.Status,
+			&issues,
+			&u.Health.CoveragePct,
+			&u.Health.ErrorRatePct,
+			&u.Health.DiscardRatePct,
+			&u.Health.CarrierTransitions,
+			&u.UnhealthyPct24h,
+		); err != nil {
+			log.Printf("UsersAtRisk scan error: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		u.Health.Issues = splitIssues(issues)
+		if u.Health.Status == "at_risk" {
+			response.AtRisk++
+		} else {
+			response.Degraded++
+		}
+		response.Users = append(response.Users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("UsersAtRisk rows error: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("JSON encoding error: %v", err)
+	}
+}
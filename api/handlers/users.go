@@ -164,6 +164,8 @@ type UserDetail struct {
 	VotePubkey      string  `json:"vote_pubkey"`
 	StakeSol        float64 `json:"stake_sol"`
 	StakeWeightPct  float64 `json:"stake_weight_pct"`
+	// Latest tunnel health, nil when the user has not been scored in the last hour
+	Health *UserHealth `json:"health"`
 }
 
 func GetUser(w http.ResponseWriter, r *http.Request) {
@@ -277,6 +279,11 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Health is best effort so the user still loads without it
+	if user.Health, err = queryUserHealth(ctx, pk); err != nil {
+		log.Printf("User health query error: %v", err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(user); err != nil {
 		log.Printf("JSON encoding error: %v", err)
@@ -243,3 +243,98 @@ func TestGetUserTraffic_MissingPK(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+func insertUserHealthTestData(t *testing.T) {
+	ctx := t.Context()
+
+	// A healthy window followed by one where the tunnel flapped and went quiet
+	err := config.DB.Exec(ctx, `
+		INSERT INTO fact_dz_user_health
+			(event_ts, ingested_at, user_pk, device_pk, tunnel_id, window_seconds, score, status, issues,
+			 device_status, samples, coverage_pct, in_pkts, out_pkts, errors, discards, carrier_transitions,
+			 error_rate_pct, discard_rate_pct)
+		VALUES
+			(toStartOfFiveMinutes(now()) - INTERVAL 10 MINUTE, now(), 'user-1', 'dev-ams1', 501, 300, 100, 'healthy', '',
+			 'activated', 5, 100, 1000, 1000, 0, 0, 0, 0, 0),
+			(toStartOfFiveMinutes(now()) - INTERVAL 5 MINUTE, now(), 'user-1', 'dev-ams1', 501, 300, 45, 'at_risk', 'no_traffic,carrier_transitions',
+			 'activated', 5, 100, 0, 0, 0, 0, 3, 0, 0)
+	`)
+	require.NoError(t, err)
+}
+
+func TestGetUser_IncludesHealth(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	insertUserTestData(t)
+
+	getUser := func() handlers.UserDetail {
+		req := httptest.NewRequest(http.MethodGet, "/api/dz/users/user-1", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("pk", "user-1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handlers.GetUser(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var user handlers.UserDetail
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&user))
+		return user
+	}
+
+	// Not scored yet
+	assert.Nil(t, getUser().Health)
+
+	insertUserHealthTestData(t)
+	health := getUser().Health
+	require.NotNil(t, health)
+	assert.Equal(t, float64(45), health.Score)
+	assert.Equal(t, "at_risk", health.Status)
+	assert.Equal(t, []string{"no_traffic", "carrier_transitions"}, health.Issues)
+	assert.Equal(t, int64(3), health.CarrierTransitions)
+}
+
+func TestGetUserHealth_ReturnsHistory(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	insertUserTestData(t)
+	insertUserHealthTestData(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dz/users/user-1/health?hours=1", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("pk", "user-1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handlers.GetUserHealth(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var points []handlers.UserHealthPoint
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&points))
+	require.Len(t, points, 2)
+	assert.Equal(t, "healthy", points[0].Status)
+	assert.Empty(t, points[0].Issues)
+	assert.Equal(t, "at_risk", points[1].Status)
+}
+
+func TestGetUsersAtRisk(t *testing.T) {
+	apitesting.SetupTestClickHouseWithMigrations(t, testChDB)
+	insertUserTestData(t)
+	insertUserHealthTestData(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dz/users/at-risk", nil)
+	rr := httptest.NewRecorder()
+	handlers.GetUsersAtRisk(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response handlers.UsersAtRiskResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	require.Len(t, response.Users, 1)
+	assert.Equal(t, 1, response.AtRisk)
+	assert.Equal(t, 0, response.Degraded)
+
+	u := response.Users[0]
+	assert.Equal(t, "user-1", u.PK)
+	assert.Equal(t, "ams001-dz001", u.DeviceCode)
+	assert.Equal(t, "ams", u.MetroCode)
+	assert.Equal(t, float64(45), u.Health.Score)
+	assert.InDelta(t, 50.0, u.UnhealthyPct24h, 0.01)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -38,19 +39,25 @@ func RecommendVisualization(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response := GetVisualizationRecommendation(r.Context(), req)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// GetVisualizationRecommendation asks the LLM to recommend a chart for the given
+// query result. It is shared by the web UI endpoint and the Slack bot, which
+// renders the recommended chart server-side.
+func GetVisualizationRecommendation(ctx context.Context, req VisualizeRequest) VisualizeResponse {
 	if len(req.Columns) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(VisualizeResponse{Recommended: false, Reasoning: "No columns provided"})
-		return
+		return VisualizeResponse{Recommended: false, Reasoning: "No columns provided"}
 	}
 
 	// Check if we have Anthropic API key
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
 	if apiKey == "" {
-		w.Header().Set("Content-Type", "application/json")
 		slog.Error("ANTHROPIC_API_KEY is not set")
-		_ = json.NewEncoder(w).Encode(VisualizeResponse{Recommended: false, Error: "AI service is not configured. Please contact the administrator."})
-		return
+		return VisualizeResponse{Recommended: false, Error: "AI service is not configured. Please contact the administrator."}
 	}
 
 	// Build prompt
@@ -59,7 +66,7 @@ func RecommendVisualization(w http.ResponseWriter, r *http.Request) {
 	// Call Anthropic
 	client := anthropic.NewClient()
 	start := time.Now()
-	message, err := client.Messages.New(r.Context(), anthropic.MessageNewParams{
+	message, err := client.Messages.New(ctx, anthropic.MessageNewParams{
 		Model:     anthropic.ModelClaudeHaiku4_5,
 		MaxTokens: 1024,
 		Messages: []anthropic.MessageParam{
@@ -69,9 +76,7 @@ func RecommendVisualization(w http.ResponseWriter, r *http.Request) {
 	duration := time.Since(start)
 	metrics.RecordAnthropicRequest("messages", duration, err)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(VisualizeResponse{Recommended: false, Error: internalError("Failed to recommend visualization", err)})
-		return
+		return VisualizeResponse{Recommended: false, Error: internalError("Failed to recommend visualization", err)}
 	}
 	metrics.RecordAnthropicTokens(message.Usage.InputTokens, message.Usage.OutputTokens)
 
@@ -85,10 +90,7 @@ func RecommendVisualization(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse JSON response
-	response := parseVisualizeResponse(responseText)
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(response)
+	return parseVisualizeResponse(responseText)
 }
 
 func buildVisualizePrompt(req VisualizeRequest) string {
@@ -8,9 +8,15 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/malbeclabs/lake/api/config"
 	"github.com/malbeclabs/lake/api/metrics"
-	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+const (
+	// simulateRemovalPathLimit caps the affected paths listed by the link removal simulation.
+	simulateRemovalPathLimit = 5
+	// whatIfItemPathLimit caps the affected paths listed for each removed device or link.
+	whatIfItemPathLimit = 10
 )
 
 // SimulateLinkRemovalResponse is the response for simulating link removal
@@ -38,9 +44,14 @@ type AffectedPath struct {
 	AfterHops    int    `json:"afterHops,omitempty"`   // 0 if no alternate path
 	AfterMetric  uint32 `json:"afterMetric,omitempty"` // 0 if no alternate path
 	HasAlternate bool   `json:"hasAlternate"`
+	// Device codes along the first equal-cost path before and after removal
+	BeforePath  []string `json:"beforePath,omitempty"`
+	AfterPath   []string `json:"afterPath,omitempty"`
+	MetricDelta int64    `json:"metricDelta,omitempty"`
 }
 
-// GetSimulateLinkRemoval simulates removing a link and shows the impact
+// GetSimulateLinkRemoval simulates removing the adjacency between two devices and shows the
+// exact rerouting computed by SPF
 func GetSimulateLinkRemoval(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
@@ -55,9 +66,6 @@ func GetSimulateLinkRemoval(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
-	defer session.Close(ctx)
-
 	response := SimulateLinkRemovalResponse{
 		SourcePK:            sourcePK,
 		TargetPK:            targetPK,
@@ -65,187 +73,52 @@ func GetSimulateLinkRemoval(w http.ResponseWriter, r *http.Request) {
 		AffectedPaths:       []AffectedPath{},
 	}
 
-	// Get device codes
-	codesCypher := `
-		MATCH (s:Device {pk: $source_pk})
-		MATCH (t:Device {pk: $target_pk})
-		RETURN s.code AS source_code, t.code AS target_code
-	`
-	codesResult, err := session.Run(ctx, codesCypher, map[string]any{
-		"source_pk": sourcePK,
-		"target_pk": targetPK,
-	})
+	topo, err := loadRoutingTopology(ctx)
 	if err != nil {
-		log.Printf("Simulate link removal codes query error: %v", err)
+		log.Printf("Simulate link removal topology error: %v", err)
 		response.Error = err.Error()
 		writeJSON(w, response)
 		return
 	}
-	if codesRecord, err := codesResult.Single(ctx); err == nil {
-		sourceCode, _ := codesRecord.Get("source_code")
-		targetCode, _ := codesRecord.Get("target_code")
-		response.SourceCode = asString(sourceCode)
-		response.TargetCode = asString(targetCode)
-	}
-
-	// Check if removing this link would disconnect any devices
-	// A device becomes disconnected if it has degree 1 (leaf node) - removing its only link disconnects it
-	disconnectCypher := `
-		MATCH (s:Device {pk: $source_pk}), (t:Device {pk: $target_pk})
-		WHERE s.isis_system_id IS NOT NULL AND t.isis_system_id IS NOT NULL
-
-		// Count neighbors of each endpoint
-		OPTIONAL MATCH (s)-[:ISIS_ADJACENT]-(sn:Device)
-		WHERE sn.isis_system_id IS NOT NULL
-		WITH s, t, count(DISTINCT sn) AS sourceDegree
-		OPTIONAL MATCH (t)-[:ISIS_ADJACENT]-(tn:Device)
-		WHERE tn.isis_system_id IS NOT NULL
-		WITH s, t, sourceDegree, count(DISTINCT tn) AS targetDegree
-
-		// A partition occurs if one endpoint has degree 1 (it's a leaf node)
-		// If both have degree > 1, there must be alternate paths (even if longer)
-		WITH s, t, sourceDegree, targetDegree,
-		     CASE WHEN sourceDegree = 1 OR targetDegree = 1 THEN true ELSE false END AS causesPartition
-		WHERE causesPartition = true
-
-		// Return the device(s) that would be disconnected - the leaf node(s)
-		UNWIND CASE
-			WHEN sourceDegree = 1 AND targetDegree = 1 THEN [s, t]
-			WHEN sourceDegree = 1 THEN [s]
-			WHEN targetDegree = 1 THEN [t]
-			ELSE []
-		END AS d
-		RETURN d.pk AS pk, d.code AS code, d.status AS status, d.device_type AS device_type
-	`
-
-	disconnectResult, err := session.Run(ctx, disconnectCypher, map[string]any{
-		"source_pk": sourcePK,
-		"target_pk": targetPK,
-	})
-	if err != nil {
-		log.Printf("Simulate link removal disconnect query error: %v", err)
-		response.Error = "failed to query disconnect impact"
-	} else {
-		disconnectRecords, err := disconnectResult.Collect(ctx)
-		if err != nil {
-			log.Printf("Simulate link removal disconnect collect error: %v", err)
-			response.Error = "failed to query disconnect impact"
-		} else {
-			log.Printf("Simulate link removal disconnect query returned %d records", len(disconnectRecords))
-			for _, record := range disconnectRecords {
-				pk, _ := record.Get("pk")
-				code, _ := record.Get("code")
-				status, _ := record.Get("status")
-				deviceType, _ := record.Get("device_type")
-
-				response.DisconnectedDevices = append(response.DisconnectedDevices, ImpactDevice{
-					PK:         asString(pk),
-					Code:       asString(code),
-					Status:     asString(status),
-					DeviceType: asString(deviceType),
-				})
-			}
-		}
+	response.SourceCode = deviceCode(topo, sourcePK)
+	response.TargetCode = deviceCode(topo, targetPK)
+
+	impact := topo.Impact(routing.Failures{Edges: []routing.Edge{routing.NewEdge(sourcePK, targetPK)}}, 1)
+	for _, pk := range impact.Disconnected {
+		d, _ := topo.Device(pk)
+		response.DisconnectedDevices = append(response.DisconnectedDevices, ImpactDevice{
+			PK:         pk,
+			Code:       deviceCode(topo, pk),
+			Status:     d.Status,
+			DeviceType: d.DeviceType,
+		})
 	}
 	response.DisconnectedCount = len(response.DisconnectedDevices)
 	response.CausesPartition = response.DisconnectedCount > 0
 
-	// Find affected paths - paths that currently use this link
-	// Simplified query: just check direct neighbors of source and target
-	affectedCypher := `
-		MATCH (src:Device {pk: $source_pk}), (tgt:Device {pk: $target_pk})
-		WHERE src.isis_system_id IS NOT NULL AND tgt.isis_system_id IS NOT NULL
-
-		// Get the metric of the link being removed
-		OPTIONAL MATCH (src)-[linkRel:ISIS_ADJACENT]-(tgt)
-		WITH src, tgt, min(linkRel.metric) AS linkMetric
-
-		// Get immediate neighbors of source with their link metrics
-		OPTIONAL MATCH (src)-[srcRel:ISIS_ADJACENT]-(srcNeighbor:Device)
-		WHERE srcNeighbor.isis_system_id IS NOT NULL AND srcNeighbor.pk <> tgt.pk
-		WITH src, tgt, linkMetric, collect(DISTINCT {device: srcNeighbor, metric: srcRel.metric}) AS srcNeighborsData
-
-		// Get immediate neighbors of target with their link metrics
-		OPTIONAL MATCH (tgt)-[tgtRel:ISIS_ADJACENT]-(tgtNeighbor:Device)
-		WHERE tgtNeighbor.isis_system_id IS NOT NULL AND tgtNeighbor.pk <> src.pk
-		WITH src, tgt, linkMetric, srcNeighborsData, collect(DISTINCT {device: tgtNeighbor, metric: tgtRel.metric}) AS tgtNeighborsData
-
-		// For each source neighbor, check path to target neighbors via this link
-		UNWIND CASE WHEN size(srcNeighborsData) > 0 THEN srcNeighborsData ELSE [null] END AS srcData
-		UNWIND CASE WHEN size(tgtNeighborsData) > 0 THEN tgtNeighborsData ELSE [null] END AS tgtData
-		WITH src, tgt, linkMetric, srcData, tgtData
-		WHERE srcData IS NOT NULL AND tgtData IS NOT NULL
-		  AND srcData.device.pk <> tgtData.device.pk
-
-		WITH srcData.device AS fromDevice, tgtData.device AS toDevice, src, tgt,
-		     3 AS beforeHops,
-		     coalesce(srcData.metric, 0) + coalesce(linkMetric, 0) + coalesce(tgtData.metric, 0) AS beforeMetric
-
-		// Check if there's an alternate path not using the link being removed
-		OPTIONAL MATCH altPath = shortestPath((fromDevice)-[:ISIS_ADJACENT*]-(toDevice))
-		WHERE NONE(r IN relationships(altPath) WHERE
-		      (startNode(r).pk = src.pk AND endNode(r).pk = tgt.pk) OR
-		      (startNode(r).pk = tgt.pk AND endNode(r).pk = src.pk))
-		WITH fromDevice, toDevice, beforeHops, beforeMetric, altPath,
-		     CASE WHEN altPath IS NOT NULL THEN length(altPath) ELSE 0 END AS afterHops,
-		     CASE WHEN altPath IS NOT NULL
-		          THEN reduce(total = 0, r IN relationships(altPath) | total + coalesce(r.metric, 0))
-		          ELSE 0 END AS afterMetric
-
-		// Only include paths where the path through the link is actually preferred
-		WHERE afterHops = 0 OR (afterHops > 0 AND beforeMetric < afterMetric)
-
-		RETURN fromDevice.pk AS from_pk,
-		       fromDevice.code AS from_code,
-		       toDevice.pk AS to_pk,
-		       toDevice.code AS to_code,
-		       beforeHops,
-		       beforeMetric,
-		       afterHops,
-		       afterMetric
-		LIMIT 5
-	`
-
-	affectedResult, err := session.Run(ctx, affectedCypher, map[string]any{
-		"source_pk": sourcePK,
-		"target_pk": targetPK,
-	})
-	if err != nil {
-		log.Printf("Simulate link removal affected paths query error: %v", err)
-		response.Error = "failed to query affected paths"
-	} else {
-		affectedRecords, err := affectedResult.Collect(ctx)
-		if err != nil {
-			log.Printf("Simulate link removal affected paths collect error: %v", err)
-			response.Error = "failed to query affected paths"
-		} else {
-			for _, record := range affectedRecords {
-				fromPK, _ := record.Get("from_pk")
-				fromCode, _ := record.Get("from_code")
-				toPK, _ := record.Get("to_pk")
-				toCode, _ := record.Get("to_code")
-				beforeHops, _ := record.Get("beforeHops")
-				beforeMetric, _ := record.Get("beforeMetric")
-				afterHops, _ := record.Get("afterHops")
-				afterMetric, _ := record.Get("afterMetric")
-
-				hasAlternate := afterHops != nil && asInt64(afterHops) > 0
-
-				response.AffectedPaths = append(response.AffectedPaths, AffectedPath{
-					FromPK:       asString(fromPK),
-					FromCode:     asString(fromCode),
-					ToPK:         asString(toPK),
-					ToCode:       asString(toCode),
-					BeforeHops:   int(asInt64(beforeHops)),
-					BeforeMetric: uint32(asInt64(beforeMetric)),
-					AfterHops:    int(asInt64(afterHops)),
-					AfterMetric:  uint32(asInt64(afterMetric)),
-					HasAlternate: hasAlternate,
-				})
-			}
+	for _, c := range impact.Changes {
+		if len(response.AffectedPaths) >= simulateRemovalPathLimit {
+			break
+		}
+		path := AffectedPath{
+			FromPK:       c.Source,
+			FromCode:     deviceCode(topo, c.Source),
+			ToPK:         c.Target,
+			ToCode:       deviceCode(topo, c.Target),
+			BeforeHops:   c.Before.Hops,
+			BeforeMetric: uint32(c.Before.Metric),
+			BeforePath:   routePathCodes(topo, c.Before),
+			HasAlternate: c.After.Reachable,
 		}
+		if c.After.Reachable {
+			path.AfterHops = c.After.Hops
+			path.AfterMetric = uint32(c.After.Metric)
+			path.AfterPath = routePathCodes(topo, c.After)
+			path.MetricDelta = c.MetricDelta()
+		}
+		response.AffectedPaths = append(response.AffectedPaths, path)
 	}
-	response.AffectedPathCount = len(response.AffectedPaths)
+	response.AffectedPathCount = len(impact.Changes)
 
 	duration := time.Since(start)
 	metrics.RecordClickHouseQuery(duration, nil)
@@ -321,7 +194,7 @@ func GetSimulateLinkAddition(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
-	session := config.Neo4jSession(ctx)
+	session := envNeo4jSession(ctx)
 	defer session.Close(ctx)
 
 	response := SimulateLinkAdditionResponse{
@@ -545,6 +418,8 @@ func GetSimulateLinkAddition(w http.ResponseWriter, r *http.Request) {
 type WhatIfRemovalRequest struct {
 	Devices []string `json:"devices"` // Device PKs
 	Links   []string `json:"links"`   // Link PKs
+	// UtilizationThreshold flags links projected above this percent of bandwidth (default 80)
+	UtilizationThreshold float64 `json:"utilizationThreshold,omitempty"`
 }
 
 // WhatIfRemovalResponse is the response for unified what-if removal analysis
@@ -554,6 +429,7 @@ type WhatIfRemovalResponse struct {
 	TotalDisconnected  int                  `json:"totalDisconnected"`
 	AffectedPaths      []WhatIfAffectedPath `json:"affectedPaths,omitempty"`
 	DisconnectedList   []string             `json:"disconnectedList,omitempty"`
+	Traffic            *TrafficProjection   `json:"traffic,omitempty"` // Projected link load with everything removed
 	Error              string               `json:"error,omitempty"`
 }
 
@@ -579,7 +455,11 @@ type WhatIfAffectedPath struct {
 	MetricBefore int    `json:"metricBefore"`
 	HopsAfter    int    `json:"hopsAfter"`   // -1 if disconnected
 	MetricAfter  int    `json:"metricAfter"` // -1 if disconnected
-	Status       string `json:"status"`      // "rerouted", "degraded", "disconnected"
+	MetricDelta  int    `json:"metricDelta,omitempty"`
+	// Device codes along the first equal-cost path before and after removal
+	BeforePath []string `json:"beforePath,omitempty"`
+	AfterPath  []string `json:"afterPath,omitempty"`
+	Status     string   `json:"status"` // "rerouted", "degraded", "disconnected"
 }
 
 // PostWhatIfRemoval analyzes the impact of removing devices and/or links
@@ -587,8 +467,6 @@ func PostWhatIfRemoval(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	start := time.Now()
-
 	// Parse request body
 	var req WhatIfRemovalRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -601,487 +479,96 @@ func PostWhatIfRemoval(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := config.Neo4jSession(ctx)
-	defer session.Close(ctx)
+	writeJSON(w, fetchWhatIfRemovalData(ctx, req))
+}
 
-	response := WhatIfRemovalResponse{
+// fetchWhatIfRemovalData computes the impact of removing each requested device and link, and
+// of removing them all together. Used by both the handler and the agent analytics tools.
+func fetchWhatIfRemovalData(ctx context.Context, req WhatIfRemovalRequest) *WhatIfRemovalResponse {
+	start := time.Now()
+
+	response := &WhatIfRemovalResponse{
 		Items:            []WhatIfRemovalItem{},
 		AffectedPaths:    []WhatIfAffectedPath{},
 		DisconnectedList: []string{},
 	}
 
-	// Analyze each device
+	topo, err := loadRoutingTopology(ctx)
+	if err != nil {
+		log.Printf("What-if removal topology error: %v", err)
+		response.Error = err.Error()
+		return response
+	}
+	metros := loadDeviceMetros(ctx)
+
+	var all []routing.Failures
 	for _, devicePK := range req.Devices {
-		item := analyzeDeviceRemoval(ctx, session, devicePK, 10)
+		item := WhatIfRemovalItem{Type: "device", PK: devicePK, Code: devicePK}
+		if _, ok := topo.Device(devicePK); ok {
+			f := routing.Failures{Devices: []string{devicePK}}
+			item.Code = deviceCode(topo, devicePK)
+			fillWhatIfRemovalItem(&item, analyzeRoutingImpact(topo, metros, f))
+			all = append(all, f)
+		} else {
+			fillWhatIfRemovalItem(&item, routingImpact{})
+		}
 		response.Items = append(response.Items, item)
-		response.TotalAffectedPaths += item.AffectedPathCount
-		response.TotalDisconnected += item.DisconnectedCount
-		response.AffectedPaths = append(response.AffectedPaths, item.AffectedPaths...)
-		response.DisconnectedList = append(response.DisconnectedList, item.DisconnectedDevices...)
 	}
-
-	// Analyze each link
 	for _, linkPK := range req.Links {
-		item := analyzeLinkRemoval(ctx, session, linkPK, 10)
+		item := WhatIfRemovalItem{Type: "link", PK: linkPK}
+		f, code, err := resolveLinkFailure(ctx, topo, linkPK)
+		if err != nil {
+			log.Printf("What-if removal link lookup error: %v", err)
+			item.Code = "Link not found"
+			fillWhatIfRemovalItem(&item, routingImpact{})
+		} else {
+			item.Code = code
+			fillWhatIfRemovalItem(&item, analyzeRoutingImpact(topo, metros, f))
+			all = append(all, f)
+		}
 		response.Items = append(response.Items, item)
-		response.TotalAffectedPaths += item.AffectedPathCount
-		response.TotalDisconnected += item.DisconnectedCount
-		response.AffectedPaths = append(response.AffectedPaths, item.AffectedPaths...)
-		response.DisconnectedList = append(response.DisconnectedList, item.DisconnectedDevices...)
 	}
 
-	// Limit total affected paths in response
-	if len(response.AffectedPaths) > 50 {
-		response.AffectedPaths = response.AffectedPaths[:50]
+	failures := mergeFailures(all...)
+	combined := analyzeRoutingImpact(topo, metros, failures)
+	response.TotalAffectedPaths = len(combined.changes)
+	response.TotalDisconnected = len(combined.disconnected)
+	response.DisconnectedList = combined.disconnected
+	for _, c := range combined.changes {
+		if len(response.AffectedPaths) >= 50 {
+			break
+		}
+		response.AffectedPaths = append(response.AffectedPaths, c.whatIfPath())
 	}
+	response.Traffic = projectTraffic(ctx, topo, failures, req.UtilizationThreshold)
 
 	duration := time.Since(start)
 	metrics.RecordClickHouseQuery(duration, nil)
 
-	log.Printf("What-if removal: %d devices, %d links, totalPaths=%d, totalDisconnected=%d in %v",
-		len(req.Devices), len(req.Links), response.TotalAffectedPaths, response.TotalDisconnected, duration)
-
-	writeJSON(w, response)
-}
-
-// analyzeDeviceRemoval computes the impact of removing a single device
-func analyzeDeviceRemoval(ctx context.Context, session neo4j.Session, devicePK string, pathLimit int) WhatIfRemovalItem {
-	item := WhatIfRemovalItem{
-		Type:                "device",
-		PK:                  devicePK,
-		AffectedPaths:       []WhatIfAffectedPath{},
-		DisconnectedDevices: []string{},
+	overloaded := 0
+	if response.Traffic != nil {
+		overloaded = response.Traffic.OverloadedCount
 	}
+	log.Printf("What-if removal: %d devices, %d links, totalPaths=%d, totalDisconnected=%d, overloadedLinks=%d in %v",
+		len(req.Devices), len(req.Links), response.TotalAffectedPaths, response.TotalDisconnected, overloaded, duration)
 
-	// Get device code and neighbor info
-	infoCypher := `
-		MATCH (d:Device {pk: $devicePK})
-		WHERE d.isis_system_id IS NOT NULL
-
-		// Get device code
-		WITH d
-
-		// Find all neighbors
-		OPTIONAL MATCH (d)-[:ISIS_ADJACENT]-(neighbor:Device)
-		WHERE neighbor.isis_system_id IS NOT NULL
-		WITH d, collect(DISTINCT neighbor) AS neighbors
-
-		// Find leaf neighbors (degree 1) that would be disconnected
-		UNWIND neighbors AS neighbor
-		OPTIONAL MATCH (neighbor)-[:ISIS_ADJACENT]-(neighborOfNeighbor:Device)
-		WHERE neighborOfNeighbor.isis_system_id IS NOT NULL
-		WITH d, neighbor, count(DISTINCT neighborOfNeighbor) AS neighborDegree
-		WITH d,
-		     collect(CASE WHEN neighborDegree = 1 THEN neighbor.code ELSE null END) AS leafCodes
-
-		RETURN d.code AS code,
-		       [x IN leafCodes WHERE x IS NOT NULL] AS disconnectedDevices
-	`
-
-	result, err := session.Run(ctx, infoCypher, map[string]any{"devicePK": devicePK})
-	if err != nil {
-		log.Printf("Device removal info query error: %v", err)
-		item.Code = devicePK
-		return item
-	}
-
-	if result.Next(ctx) {
-		record := result.Record()
-		code, _ := record.Get("code")
-		disconnected, _ := record.Get("disconnectedDevices")
-
-		item.Code = asString(code)
-		if arr, ok := disconnected.([]any); ok {
-			for _, v := range arr {
-				if s := asString(v); s != "" {
-					item.DisconnectedDevices = append(item.DisconnectedDevices, s)
-				}
-			}
-		}
-	}
-
-	item.DisconnectedCount = len(item.DisconnectedDevices)
-	item.CausesPartition = item.DisconnectedCount > 0
-
-	// Find affected paths through this device
-	pathsCypher := `
-		MATCH (offline:Device {pk: $devicePK})
-		WHERE offline.isis_system_id IS NOT NULL
-
-		// Find neighbors of the offline device
-		MATCH (offline)-[:ISIS_ADJACENT]-(neighbor:Device)
-		WHERE neighbor.isis_system_id IS NOT NULL
-
-		WITH offline, collect(DISTINCT neighbor) AS neighbors
-
-		// For each pair of neighbors, check if shortest path goes through offline device
-		UNWIND neighbors AS n1
-		UNWIND neighbors AS n2
-		WITH offline, n1, n2
-		WHERE n1.pk < n2.pk
-
-		// Get current shortest path
-		MATCH currentPath = shortestPath((n1)-[:ISIS_ADJACENT*]-(n2))
-		WITH offline, n1, n2, currentPath,
-		     length(currentPath) AS currentHops,
-		     reduce(m = 0, r IN relationships(currentPath) | m + coalesce(r.metric, 10)) AS currentMetric,
-		     any(node IN nodes(currentPath) WHERE node.pk = offline.pk) AS goesThruOffline
-
-		WHERE goesThruOffline = true
-
-		// Get metro info
-		OPTIONAL MATCH (n1)-[:LOCATED_IN]->(m1:Metro)
-		OPTIONAL MATCH (n2)-[:LOCATED_IN]->(m2:Metro)
-
-		RETURN n1.pk AS sourcePK, n1.code AS sourceCode,
-		       n2.pk AS targetPK, n2.code AS targetCode,
-		       COALESCE(m1.code, '') AS sourceMetro,
-		       COALESCE(m2.code, '') AS targetMetro,
-		       currentHops, currentMetric
-		LIMIT $limit
-	`
-
-	pathsResult, err := session.Run(ctx, pathsCypher, map[string]any{
-		"devicePK": devicePK,
-		"limit":    pathLimit * 2, // Get more for filtering
-	})
-	if err != nil {
-		log.Printf("Device removal paths query error: %v", err)
-		return item
-	}
-
-	type pathCandidate struct {
-		sourcePK      string
-		sourceCode    string
-		targetPK      string
-		targetCode    string
-		sourceMetro   string
-		targetMetro   string
-		currentHops   int
-		currentMetric int
-	}
-	candidates := []pathCandidate{}
-
-	for pathsResult.Next(ctx) {
-		record := pathsResult.Record()
-		sourcePK, _ := record.Get("sourcePK")
-		sourceCode, _ := record.Get("sourceCode")
-		targetPK, _ := record.Get("targetPK")
-		targetCode, _ := record.Get("targetCode")
-		sourceMetro, _ := record.Get("sourceMetro")
-		targetMetro, _ := record.Get("targetMetro")
-		currentHops, _ := record.Get("currentHops")
-		currentMetric, _ := record.Get("currentMetric")
-
-		candidates = append(candidates, pathCandidate{
-			sourcePK:      asString(sourcePK),
-			sourceCode:    asString(sourceCode),
-			targetPK:      asString(targetPK),
-			targetCode:    asString(targetCode),
-			sourceMetro:   asString(sourceMetro),
-			targetMetro:   asString(targetMetro),
-			currentHops:   int(asInt64(currentHops)),
-			currentMetric: int(asInt64(currentMetric)),
-		})
-	}
+	return response
+}
 
-	// Find alternate paths avoiding the offline device
-	for _, c := range candidates {
-		if len(item.AffectedPaths) >= pathLimit {
+// fillWhatIfRemovalItem records an item's impact, keeping its worst affected paths.
+func fillWhatIfRemovalItem(item *WhatIfRemovalItem, impact routingImpact) {
+	item.AffectedPaths = []WhatIfAffectedPath{}
+	for _, c := range impact.changes {
+		if len(item.AffectedPaths) >= whatIfItemPathLimit {
 			break
 		}
-
-		path := WhatIfAffectedPath{
-			Source:       c.sourceCode,
-			Target:       c.targetCode,
-			SourceMetro:  c.sourceMetro,
-			TargetMetro:  c.targetMetro,
-			HopsBefore:   c.currentHops,
-			MetricBefore: c.currentMetric,
-			HopsAfter:    -1,
-			MetricAfter:  -1,
-			Status:       "disconnected",
-		}
-
-		altCypher := `
-			MATCH (source:Device {pk: $sourcePK}), (target:Device {pk: $targetPK})
-			MATCH altPath = shortestPath((source)-[:ISIS_ADJACENT*]-(target))
-			WHERE none(n IN nodes(altPath) WHERE n.pk = $devicePK)
-			WITH altPath, length(altPath) AS altHops,
-			     reduce(m = 0, r IN relationships(altPath) | m + coalesce(r.metric, 10)) AS altMetric
-			RETURN altHops, altMetric
-			LIMIT 1
-		`
-
-		altResult, err := session.Run(ctx, altCypher, map[string]any{
-			"sourcePK": c.sourcePK,
-			"targetPK": c.targetPK,
-			"devicePK": devicePK,
-		})
-		if err == nil && altResult.Next(ctx) {
-			record := altResult.Record()
-			altHops, _ := record.Get("altHops")
-			altMetric, _ := record.Get("altMetric")
-
-			path.HopsAfter = int(asInt64(altHops))
-			path.MetricAfter = int(asInt64(altMetric))
-
-			hopIncrease := path.HopsAfter - path.HopsBefore
-			metricIncrease := path.MetricAfter - path.MetricBefore
-			if hopIncrease > 2 || metricIncrease > 50 {
-				path.Status = "degraded"
-			} else {
-				path.Status = "rerouted"
-			}
-		}
-
-		item.AffectedPaths = append(item.AffectedPaths, path)
+		item.AffectedPaths = append(item.AffectedPaths, c.whatIfPath())
 	}
-
-	item.AffectedPathCount = len(item.AffectedPaths)
-	return item
-}
-
-// analyzeLinkRemoval computes the impact of removing a single link
-func analyzeLinkRemoval(ctx context.Context, session neo4j.Session, linkPK string, pathLimit int) WhatIfRemovalItem {
-	item := WhatIfRemovalItem{
-		Type:                "link",
-		PK:                  linkPK,
-		AffectedPaths:       []WhatIfAffectedPath{},
-		DisconnectedDevices: []string{},
-	}
-
-	// First, resolve link PK to device endpoints via ClickHouse
-	linkQuery := `
-		SELECT
-			COALESCE(l.side_a_pk, '') as side_a_pk,
-			COALESCE(l.side_z_pk, '') as side_z_pk,
-			COALESCE(da.code, '') as side_a_code,
-			COALESCE(dz.code, '') as side_z_code
-		FROM dz_links_current l
-		LEFT JOIN dz_devices_current da ON l.side_a_pk = da.pk
-		LEFT JOIN dz_devices_current dz ON l.side_z_pk = dz.pk
-		WHERE l.pk = $1
-	`
-
-	var sideAPK, sideZPK, sideACode, sideZCode string
-	if err := envDB(ctx).QueryRow(ctx, linkQuery, linkPK).Scan(&sideAPK, &sideZPK, &sideACode, &sideZCode); err != nil {
-		log.Printf("Link lookup error for %s: %v", linkPK, err)
-		item.Code = "Link not found"
-		return item
+	item.AffectedPathCount = len(impact.changes)
+	item.DisconnectedDevices = impact.disconnected
+	if item.DisconnectedDevices == nil {
+		item.DisconnectedDevices = []string{}
 	}
-
-	if sideAPK == "" || sideZPK == "" {
-		item.Code = "Link missing endpoints"
-		return item
-	}
-
-	item.Code = sideACode + " - " + sideZCode
-
-	// Check if either endpoint is a leaf (would be disconnected)
-	disconnectCypher := `
-		MATCH (s:Device {pk: $sourcePK}), (t:Device {pk: $targetPK})
-		WHERE s.isis_system_id IS NOT NULL AND t.isis_system_id IS NOT NULL
-
-		// Count neighbors of each endpoint
-		OPTIONAL MATCH (s)-[:ISIS_ADJACENT]-(sn:Device)
-		WHERE sn.isis_system_id IS NOT NULL
-		WITH s, t, count(DISTINCT sn) AS sourceDegree
-		OPTIONAL MATCH (t)-[:ISIS_ADJACENT]-(tn:Device)
-		WHERE tn.isis_system_id IS NOT NULL
-		WITH s, t, sourceDegree, count(DISTINCT tn) AS targetDegree
-
-		RETURN sourceDegree, targetDegree, s.code AS sourceCode, t.code AS targetCode
-	`
-
-	degResult, err := session.Run(ctx, disconnectCypher, map[string]any{
-		"sourcePK": sideAPK,
-		"targetPK": sideZPK,
-	})
-	if err != nil {
-		log.Printf("Link disconnect check error: %v", err)
-	} else if degResult.Next(ctx) {
-		record := degResult.Record()
-		sourceDegree, _ := record.Get("sourceDegree")
-		targetDegree, _ := record.Get("targetDegree")
-		sourceCode, _ := record.Get("sourceCode")
-		targetCode, _ := record.Get("targetCode")
-
-		if asInt64(sourceDegree) == 1 {
-			item.DisconnectedDevices = append(item.DisconnectedDevices, asString(sourceCode))
-		}
-		if asInt64(targetDegree) == 1 {
-			item.DisconnectedDevices = append(item.DisconnectedDevices, asString(targetCode))
-		}
-	}
-
 	item.DisconnectedCount = len(item.DisconnectedDevices)
 	item.CausesPartition = item.DisconnectedCount > 0
-
-	// Find affected paths - paths that currently use this link
-	affectedCypher := `
-		MATCH (src:Device {pk: $sourcePK}), (tgt:Device {pk: $targetPK})
-		WHERE src.isis_system_id IS NOT NULL AND tgt.isis_system_id IS NOT NULL
-
-		// Get the metric of the link being removed
-		OPTIONAL MATCH (src)-[linkRel:ISIS_ADJACENT]-(tgt)
-		WITH src, tgt, min(linkRel.metric) AS linkMetric
-
-		// Get immediate neighbors of source with their link metrics
-		OPTIONAL MATCH (src)-[srcRel:ISIS_ADJACENT]-(srcNeighbor:Device)
-		WHERE srcNeighbor.isis_system_id IS NOT NULL AND srcNeighbor.pk <> tgt.pk
-		WITH src, tgt, linkMetric, collect(DISTINCT {device: srcNeighbor, metric: srcRel.metric}) AS srcNeighborsData
-
-		// Get immediate neighbors of target with their link metrics
-		OPTIONAL MATCH (tgt)-[tgtRel:ISIS_ADJACENT]-(tgtNeighbor:Device)
-		WHERE tgtNeighbor.isis_system_id IS NOT NULL AND tgtNeighbor.pk <> src.pk
-		WITH src, tgt, linkMetric, srcNeighborsData, collect(DISTINCT {device: tgtNeighbor, metric: tgtRel.metric}) AS tgtNeighborsData
-
-		// For each source neighbor, check path to target neighbors via this link
-		UNWIND CASE WHEN size(srcNeighborsData) > 0 THEN srcNeighborsData ELSE [null] END AS srcData
-		UNWIND CASE WHEN size(tgtNeighborsData) > 0 THEN tgtNeighborsData ELSE [null] END AS tgtData
-		WITH src, tgt, linkMetric, srcData, tgtData
-		WHERE srcData IS NOT NULL AND tgtData IS NOT NULL
-		  AND srcData.device.pk <> tgtData.device.pk
-
-		WITH srcData.device AS fromDevice, tgtData.device AS toDevice, src, tgt,
-		     3 AS beforeHops,
-		     coalesce(srcData.metric, 0) + coalesce(linkMetric, 0) + coalesce(tgtData.metric, 0) AS beforeMetric
-
-		// Get metro info
-		OPTIONAL MATCH (fromDevice)-[:LOCATED_IN]->(fm:Metro)
-		OPTIONAL MATCH (toDevice)-[:LOCATED_IN]->(tm:Metro)
-
-		RETURN fromDevice.pk AS fromPK, fromDevice.code AS fromCode,
-		       toDevice.pk AS toPK, toDevice.code AS toCode,
-		       COALESCE(fm.code, '') AS fromMetro,
-		       COALESCE(tm.code, '') AS toMetro,
-		       beforeHops, beforeMetric,
-		       src.pk AS srcPK, tgt.pk AS tgtPK
-		LIMIT $limit
-	`
-
-	affectedResult, err := session.Run(ctx, affectedCypher, map[string]any{
-		"sourcePK": sideAPK,
-		"targetPK": sideZPK,
-		"limit":    pathLimit * 2,
-	})
-	if err != nil {
-		log.Printf("Link affected paths query error: %v", err)
-		return item
-	}
-
-	type pathCandidate struct {
-		fromPK       string
-		fromCode     string
-		toPK         string
-		toCode       string
-		fromMetro    string
-		toMetro      string
-		beforeHops   int
-		beforeMetric int
-		srcPK        string
-		tgtPK        string
-	}
-	candidates := []pathCandidate{}
-
-	for affectedResult.Next(ctx) {
-		record := affectedResult.Record()
-		fromPK, _ := record.Get("fromPK")
-		fromCode, _ := record.Get("fromCode")
-		toPK, _ := record.Get("toPK")
-		toCode, _ := record.Get("toCode")
-		fromMetro, _ := record.Get("fromMetro")
-		toMetro, _ := record.Get("toMetro")
-		beforeHops, _ := record.Get("beforeHops")
-		beforeMetric, _ := record.Get("beforeMetric")
-		srcPK, _ := record.Get("srcPK")
-		tgtPK, _ := record.Get("tgtPK")
-
-		candidates = append(candidates, pathCandidate{
-			fromPK:       asString(fromPK),
-			fromCode:     asString(fromCode),
-			toPK:         asString(toPK),
-			toCode:       asString(toCode),
-			fromMetro:    asString(fromMetro),
-			toMetro:      asString(toMetro),
-			beforeHops:   int(asInt64(beforeHops)),
-			beforeMetric: int(asInt64(beforeMetric)),
-			srcPK:        asString(srcPK),
-			tgtPK:        asString(tgtPK),
-		})
-	}
-
-	// Find alternate paths avoiding this link
-	for _, c := range candidates {
-		if len(item.AffectedPaths) >= pathLimit {
-			break
-		}
-
-		path := WhatIfAffectedPath{
-			Source:       c.fromCode,
-			Target:       c.toCode,
-			SourceMetro:  c.fromMetro,
-			TargetMetro:  c.toMetro,
-			HopsBefore:   c.beforeHops,
-			MetricBefore: c.beforeMetric,
-			HopsAfter:    -1,
-			MetricAfter:  -1,
-			Status:       "disconnected",
-		}
-
-		// Find alternate path not using this specific link
-		altCypher := `
-			MATCH (from:Device {pk: $fromPK}), (to:Device {pk: $toPK})
-			OPTIONAL MATCH altPath = shortestPath((from)-[:ISIS_ADJACENT*]-(to))
-			WHERE NONE(r IN relationships(altPath) WHERE
-			      (startNode(r).pk = $srcPK AND endNode(r).pk = $tgtPK) OR
-			      (startNode(r).pk = $tgtPK AND endNode(r).pk = $srcPK))
-			WITH altPath,
-			     CASE WHEN altPath IS NOT NULL THEN length(altPath) ELSE 0 END AS altHops,
-			     CASE WHEN altPath IS NOT NULL
-			          THEN reduce(m = 0, r IN relationships(altPath) | m + coalesce(r.metric, 10))
-			          ELSE 0 END AS altMetric
-			WHERE altPath IS NOT NULL
-			RETURN altHops, altMetric
-			LIMIT 1
-		`
-
-		altResult, err := session.Run(ctx, altCypher, map[string]any{
-			"fromPK": c.fromPK,
-			"toPK":   c.toPK,
-			"srcPK":  c.srcPK,
-			"tgtPK":  c.tgtPK,
-		})
-		if err == nil && altResult.Next(ctx) {
-			record := altResult.Record()
-			altHops, _ := record.Get("altHops")
-			altMetric, _ := record.Get("altMetric")
-
-			path.HopsAfter = int(asInt64(altHops))
-			path.MetricAfter = int(asInt64(altMetric))
-
-			// Only include if the path through the link was actually preferred
-			if path.MetricBefore < path.MetricAfter || path.HopsAfter == 0 {
-				hopIncrease := path.HopsAfter - path.HopsBefore
-				metricIncrease := path.MetricAfter - path.MetricBefore
-				if path.HopsAfter == 0 {
-					path.Status = "disconnected"
-					path.HopsAfter = -1
-					path.MetricAfter = -1
-				} else if hopIncrease > 2 || metricIncrease > 50 {
-					path.Status = "degraded"
-				} else {
-					path.Status = "rerouted"
-				}
-				item.AffectedPaths = append(item.AffectedPaths, path)
-			}
-		} else {
-			// No alternate path found
-			item.AffectedPaths = append(item.AffectedPaths, path)
-		}
-	}
-
-	item.AffectedPathCount = len(item.AffectedPaths)
-	return item
 }
@@ -478,8 +478,17 @@ func (m *WorkflowManager) runWorkflow(
 		cfg.FormatContext = prompts.Slack
 	}
 
-	// Add Neo4j support if available (mainnet only)
-	if config.Neo4jClient != nil && rw.Env == EnvMainnet {
+	// Add cross-environment query support if more than one env is configured
+	cfg.EnvQuerier = NewEnvQuerier()
+
+	// Validate SQL against the schema and with EXPLAIN before executing it
+	cfg.SQLValidator = workflow.NewSchemaSQLValidator(schemaFetcher, querier)
+
+	// Add curated analytics tools
+	cfg.Analytics = NewAnalyticsProvider()
+
+	// Add Neo4j support if the env has a graph
+	if config.Neo4jClientForEnv(string(rw.Env)) != nil {
 		cfg.GraphQuerier = NewNeo4jQuerier()
 		cfg.GraphSchemaFetcher = NewNeo4jSchemaFetcher()
 	}
@@ -502,6 +511,7 @@ func (m *WorkflowManager) runWorkflow(
 	sqlStepIDs := make(map[string]string)
 	cypherStepIDs := make(map[string]string)
 	docsStepIDs := make(map[string]string)
+	analyticsStepIDs := make(map[string]string)
 
 	// Track metrics from the last checkpoint (for final persistence)
 	var lastLLMCalls, lastInputTokens, lastOutputTokens int
@@ -639,6 +649,28 @@ func (m *WorkflowManager) runWorkflow(
 				},
 			})
 
+		// Analytics stages (streamed only; not persisted as steps)
+		case workflow.StageAnalyticsStarted:
+			stepID := uuid.New().String()
+			analyticsStepIDs[progress.AnalyticsTool] = stepID
+			rw.broadcast(WorkflowEvent{
+				Type: "analytics_started",
+				Data: map[string]string{
+					"id":   stepID,
+					"tool": progress.AnalyticsTool,
+				},
+			})
+		case workflow.StageAnalyticsComplete:
+			rw.broadcast(WorkflowEvent{
+				Type: "analytics_done",
+				Data: map[string]any{
+					"id":    analyticsStepIDs[progress.AnalyticsTool],
+					"tool":  progress.AnalyticsTool,
+					"error": progress.AnalyticsError,
+					"env":   string(rw.Env),
+				},
+			})
+
 		case workflow.StageSynthesizing:
 			rw.broadcast(WorkflowEvent{
 				Type: "synthesizing",
@@ -907,8 +939,17 @@ func (m *WorkflowManager) resumeWorkflow(
 		cfg.FormatContext = prompts.Slack
 	}
 
-	// Add Neo4j support if available (mainnet only)
-	if config.Neo4jClient != nil && rw.Env == EnvMainnet {
+	// Add cross-environment query support if more than one env is configured
+	cfg.EnvQuerier = NewEnvQuerier()
+
+	// Validate SQL against the schema and with EXPLAIN before executing it
+	cfg.SQLValidator = workflow.NewSchemaSQLValidator(schemaFetcher, querier)
+
+	// Add curated analytics tools
+	cfg.Analytics = NewAnalyticsProvider()
+
+	// Add Neo4j support if the env has a graph
+	if config.Neo4jClientForEnv(string(rw.Env)) != nil {
 		cfg.GraphQuerier = NewNeo4jQuerier()
 		cfg.GraphSchemaFetcher = NewNeo4jSchemaFetcher()
 	}
@@ -931,6 +972,7 @@ func (m *WorkflowManager) resumeWorkflow(
 	sqlStepIDs := make(map[string]string)
 	cypherStepIDs := make(map[string]string)
 	docsStepIDs := make(map[string]string)
+	analyticsStepIDs := make(map[string]string)
 
 	// Track metrics from the last checkpoint (for final persistence)
 	var lastLLMCalls, lastInputTokens, lastOutputTokens int
@@ -1068,6 +1110,28 @@ func (m *WorkflowManager) resumeWorkflow(
 				},
 			})
 
+		// Analytics stages (streamed only; not persisted as steps)
+		case workflow.StageAnalyticsStarted:
+			stepID := uuid.New().String()
+			analyticsStepIDs[progress.AnalyticsTool] = stepID
+			rw.broadcast(WorkflowEvent{
+				Type: "analytics_started",
+				Data: map[string]string{
+					"id":   stepID,
+					"tool": progress.AnalyticsTool,
+				},
+			})
+		case workflow.StageAnalyticsComplete:
+			rw.broadcast(WorkflowEvent{
+				Type: "analytics_done",
+				Data: map[string]any{
+					"id":    analyticsStepIDs[progress.AnalyticsTool],
+					"tool":  progress.AnalyticsTool,
+					"error": progress.AnalyticsError,
+					"env":   string(rw.Env),
+				},
+			})
+
 		case workflow.StageSynthesizing:
 			rw.broadcast(WorkflowEvent{
 				Type: "synthesizing",
@@ -408,8 +408,8 @@ func main() {
 		r.Use(handlers.QueryRateLimitMiddleware)
 
 		r.Get("/api/catalog", handlers.GetCatalog)
-		r.Get("/api/stats", handlers.GetStats)
-		r.Get("/api/status", handlers.GetStatus)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/stats", handlers.GetStats)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/status", handlers.GetStatus)
 		r.Get("/api/status/link-history", handlers.GetLinkHistory)
 		r.Get("/api/status/device-history", handlers.GetDeviceHistory)
 		r.Get("/api/status/interface-issues", handlers.GetInterfaceIssues)
@@ -418,33 +418,42 @@ func main() {
 		r.Get("/api/status/links/{pk}/history", handlers.GetSingleLinkHistory)
 		r.Get("/api/timeline", handlers.GetTimeline)
 		r.Get("/api/timeline/bounds", handlers.GetTimelineBounds)
+		r.Get("/api/changes/stream", handlers.GetChangesStream)
 
 		// Outage routes
-		r.Get("/api/outages/links", handlers.GetLinkOutages)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/outages/links", handlers.GetLinkOutages)
 		r.Get("/api/outages/links/csv", handlers.GetLinkOutagesCSV)
 
+		// SLA report routes
+		r.Get("/api/sla/report", handlers.GetSLAReport)
+		r.Get("/api/sla/report/csv", handlers.GetSLAReportCSV)
+
 		// Search routes
 		r.Get("/api/search", handlers.Search)
 		r.Get("/api/search/autocomplete", handlers.SearchAutocomplete)
 
 		// DZ entity routes
-		r.Get("/api/dz/devices", handlers.GetDevices)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/devices", handlers.GetDevices)
 		r.Get("/api/dz/devices/{pk}", handlers.GetDevice)
-		r.Get("/api/dz/links", handlers.GetLinks)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/links", handlers.GetLinks)
 		r.Get("/api/dz/links/{pk}", handlers.GetLink)
-		r.Get("/api/dz/links-health", handlers.GetLinkHealth)
-		r.Get("/api/dz/metros", handlers.GetMetros)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/links-health", handlers.GetLinkHealth)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/metros", handlers.GetMetros)
 		r.Get("/api/dz/metros/{pk}", handlers.GetMetro)
-		r.Get("/api/dz/contributors", handlers.GetContributors)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/contributors", handlers.GetContributors)
 		r.Get("/api/dz/contributors/{pk}", handlers.GetContributor)
-		r.Get("/api/dz/users", handlers.GetUsers)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/users", handlers.GetUsers)
+		r.Get("/api/dz/users/at-risk", handlers.GetUsersAtRisk)
 		r.Get("/api/dz/users/{pk}", handlers.GetUser)
+		r.Get("/api/dz/users/{pk}/health", handlers.GetUserHealth)
 		r.Get("/api/dz/users/{pk}/traffic", handlers.GetUserTraffic)
 		r.Get("/api/dz/users/{pk}/multicast-groups", handlers.GetUserMulticastGroups)
-		r.Get("/api/dz/multicast-groups", handlers.GetMulticastGroups)
+		r.With(handlers.MultiEnvMiddleware).Get("/api/dz/multicast-groups", handlers.GetMulticastGroups)
 		r.Get("/api/dz/multicast-groups/{pk}", handlers.GetMulticastGroup)
 		r.Get("/api/dz/multicast-groups/{pk}/tree-paths", handlers.GetMulticastTreePaths)
 		r.Get("/api/dz/multicast-groups/{pk}/traffic", handlers.GetMulticastGroupTraffic)
+		r.Get("/api/dz/multicast-groups/{pk}/delivery", handlers.GetMulticastGroupDelivery)
+		r.Get("/api/dz/{entity}/{pk}/history", handlers.GetEntityHistory)
 		r.Get("/api/dz/field-values", handlers.GetFieldValues)
 
 		// Solana entity routes
@@ -469,15 +478,17 @@ func main() {
 		r.Get("/api/traffic/dashboard/drilldown", handlers.GetTrafficDashboardDrilldown)
 		r.Get("/api/traffic/dashboard/burstiness", handlers.GetTrafficDashboardBurstiness)
 		r.Get("/api/traffic/dashboard/health", handlers.GetTrafficDashboardHealth)
+		r.Get("/api/traffic/dashboard/forecast", handlers.GetTrafficDashboardForecast)
 
 		// Topology endpoints (ClickHouse only)
 		r.Get("/api/topology", handlers.GetTopology)
 		r.Get("/api/topology/traffic", handlers.GetTopologyTraffic)
 		r.Get("/api/topology/link-latency", handlers.GetLinkLatencyHistory)
 		r.Get("/api/topology/latency-comparison", handlers.GetLatencyComparison)
+		r.Get("/api/topology/internet-advantage", handlers.GetInternetAdvantage)
 		r.Get("/api/topology/latency-history/{origin}/{target}", handlers.GetLatencyHistory)
 
-		// Topology endpoints (require Neo4j — mainnet only)
+		// Topology endpoints (require a Neo4j graph for the env)
 		r.Group(func(r chi.Router) {
 			r.Use(handlers.RequireNeo4jMiddleware)
 			r.Get("/api/topology/isis", handlers.GetISISTopology)
@@ -494,6 +505,9 @@ func main() {
 			r.Get("/api/topology/metro-path-detail", handlers.GetMetroPathDetail)
 			r.Get("/api/topology/metro-paths", handlers.GetMetroPaths)
 			r.Get("/api/topology/metro-device-paths", handlers.GetMetroDevicePaths)
+			r.Get("/api/topology/sr-paths", handlers.GetSRPaths)
+			r.Get("/api/topology/sr-validation", handlers.GetSRValidation)
+			r.Get("/api/topology/latency-divergence", handlers.GetLatencyDivergence)
 			r.Post("/api/topology/maintenance-impact", handlers.PostMaintenanceImpact)
 			r.Post("/api/topology/whatif-removal", handlers.PostWhatIfRemoval)
 		})
@@ -503,7 +517,7 @@ func main() {
 		r.Post("/api/sql/generate", handlers.GenerateSQL)
 		r.Post("/api/sql/generate/stream", handlers.GenerateSQLStream)
 
-		// Cypher endpoints (require Neo4j — mainnet only)
+		// Cypher endpoints (require a Neo4j graph for the env)
 		r.Group(func(r chi.Router) {
 			r.Use(handlers.RequireNeo4jMiddleware)
 			r.Post("/api/cypher/query", handlers.ExecuteCypher)
@@ -532,6 +546,25 @@ func main() {
 	r.Put("/api/sessions/{id}", handlers.UpdateSession)
 	r.Delete("/api/sessions/{id}", handlers.DeleteSession)
 
+	// Incident routes (annotating requires sign-in)
+	r.Get("/api/incidents", handlers.ListIncidents)
+	r.Get("/api/incidents/{id}", handlers.GetIncident)
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.RequireAuth)
+		r.Put("/api/incidents/{id}", handlers.UpdateIncident)
+		r.Post("/api/incidents/{id}/notes", handlers.CreateIncidentNote)
+	})
+
+	// Maintenance window routes (scheduling requires sign-in)
+	r.Get("/api/maintenance-windows", handlers.ListMaintenanceWindows)
+	r.Get("/api/maintenance-windows/{id}", handlers.GetMaintenanceWindow)
+	r.Group(func(r chi.Router) {
+		r.Use(handlers.RequireAuth)
+		r.Post("/api/maintenance-windows", handlers.CreateMaintenanceWindow)
+		r.Put("/api/maintenance-windows/{id}", handlers.UpdateMaintenanceWindow)
+		r.Delete("/api/maintenance-windows/{id}", handlers.DeleteMaintenanceWindow)
+	})
+
 	// Session workflow route (get running workflow for a session)
 	r.Get("/api/sessions/{id}/workflow", handlers.GetWorkflowForSession)
 
@@ -607,6 +640,9 @@ func main() {
 	// Start cleanup worker for expired sessions/nonces
 	handlers.StartCleanupWorker(serverCtx)
 
+	// Start correlating outages and events into incidents
+	handlers.StartIncidentCorrelator(serverCtx)
+
 	// Initialize usage metrics and start daily reset worker
 	handlers.InitUsageMetrics(serverCtx)
 	handlers.StartDailyResetWorker(serverCtx)
@@ -719,6 +755,7 @@ func startSlackBot(ctx context.Context, r *chi.Mux) *slackbot.EventHandler {
 		slog.Default(),
 		cfg.WebBaseURL,
 	)
+	msgProcessor.SetChartRecommender(slackbot.NewVisualizationRecommender())
 	msgProcessor.StartCleanup(ctx)
 
 	// Set up event handler
@@ -815,6 +852,7 @@ func startSlackBotMultiTenant(ctx context.Context, r *chi.Mux) *slackbot.EventHa
 		slog.Default(),
 		os.Getenv("WEB_BASE_URL"),
 	)
+	msgProcessor.SetChartRecommender(slackbot.NewVisualizationRecommender())
 	msgProcessor.StartCleanup(ctx)
 
 	// Set up event handler (no default client)
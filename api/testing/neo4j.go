@@ -116,7 +116,8 @@ func NewNeo4jDB(ctx context.Context, log *slog.Logger, cfg *Neo4jDBConfig) (*Neo
 	return db, nil
 }
 
-// SetupTestNeo4j sets up a test Neo4j client and configures config.Neo4jClient.
+// SetupTestNeo4j sets up a test Neo4j client and configures it as the mainnet-beta
+// entry in config.Neo4jClients.
 // Creates a read-only client matching the API's usage pattern.
 func SetupTestNeo4j(t *testing.T, db *Neo4jDB) {
 	ctx := t.Context()
@@ -126,15 +127,15 @@ func SetupTestNeo4j(t *testing.T, db *Neo4jDB) {
 	require.NoError(t, err, "failed to create Neo4j client")
 
 	// Save old client and swap
-	oldClient := config.Neo4jClient
-	oldDatabase := config.Neo4jDatabase
-	config.Neo4jClient = client
-	config.Neo4jDatabase = neo4j.DefaultDatabase
+	oldClients := config.Neo4jClients
+	oldDatabases := config.Neo4jDatabases
+	config.Neo4jClients = map[string]neo4j.Client{"mainnet-beta": client}
+	config.Neo4jDatabases = map[string]string{"mainnet-beta": neo4j.DefaultDatabase}
 
 	t.Cleanup(func() {
 		client.Close(context.Background())
-		config.Neo4jClient = oldClient
-		config.Neo4jDatabase = oldDatabase
+		config.Neo4jClients = oldClients
+		config.Neo4jDatabases = oldDatabases
 	})
 }
 
@@ -179,15 +180,15 @@ func SetupTestNeo4jWithData(t *testing.T, db *Neo4jDB, seedFunc func(ctx context
 	require.NoError(t, err, "failed to create Neo4j read-only client")
 
 	// Save old client and swap
-	oldClient := config.Neo4jClient
-	oldDatabase := config.Neo4jDatabase
-	config.Neo4jClient = roClient
-	config.Neo4jDatabase = neo4j.DefaultDatabase
+	oldClients := config.Neo4jClients
+	oldDatabases := config.Neo4jDatabases
+	config.Neo4jClients = map[string]neo4j.Client{"mainnet-beta": roClient}
+	config.Neo4jDatabases = map[string]string{"mainnet-beta": neo4j.DefaultDatabase}
 
 	t.Cleanup(func() {
 		roClient.Close(context.Background())
-		config.Neo4jClient = oldClient
-		config.Neo4jDatabase = oldDatabase
+		config.Neo4jClients = oldClients
+		config.Neo4jDatabases = oldDatabases
 	})
 }
 
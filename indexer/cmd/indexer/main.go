@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -25,12 +26,18 @@ import (
 	"github.com/malbeclabs/doublezero/tools/maxmind/pkg/geoip"
 	"github.com/malbeclabs/doublezero/tools/maxmind/pkg/metrodb"
 	"github.com/malbeclabs/doublezero/tools/solana/pkg/rpc"
+	"github.com/malbeclabs/lake/indexer/pkg/changes"
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	dztelemlatency "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/latency"
 	dztelemusage "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/usage"
 	"github.com/malbeclabs/lake/indexer/pkg/indexer"
 	"github.com/malbeclabs/lake/indexer/pkg/metrics"
 	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 	"github.com/malbeclabs/lake/indexer/pkg/server"
+	"github.com/malbeclabs/lake/indexer/pkg/simulator"
 	"github.com/malbeclabs/lake/indexer/pkg/sol"
 	"github.com/malbeclabs/lake/utils/pkg/logger"
 	"github.com/oschwald/geoip2-golang"
@@ -105,6 +112,14 @@ func run() error {
 	isisS3RegionFlag := flag.String("isis-s3-region", "us-east-1", "AWS region for IS-IS S3 bucket (or set ISIS_S3_REGION env var)")
 	isisRefreshIntervalFlag := flag.Duration("isis-refresh-interval", 30*time.Second, "Refresh interval for IS-IS sync (or set ISIS_REFRESH_INTERVAL env var)")
 
+	// Change event webhook configuration
+	changeWebhookURLsFlag := flag.StringSlice("change-webhook-url", nil, "Webhook URL to POST change events to, repeatable (or set CHANGE_WEBHOOK_URLS env var, comma-separated)")
+	changeWebhookSecretFlag := flag.String("change-webhook-secret", "", "Secret used to HMAC-sign change event webhooks (or set CHANGE_WEBHOOK_SECRET env var)")
+
+	// Synthetic network
+	simulateFlag := flag.String("simulate", "", "Run against a synthetic network from a scenario file instead of the DZ ledger, Solana RPC, InfluxDB and S3 (or set SIMULATE_SCENARIO env var)")
+	simulateStartFlag := flag.String("simulate-start", "", "RFC3339 time of the scenario's T+0, to resume a simulation across restarts (default: now)")
+
 	// Readiness configuration
 	skipReadyWaitFlag := flag.Bool("skip-ready-wait", false, "Skip waiting for views to be ready (for preview/dev environments)")
 
@@ -164,20 +179,45 @@ func run() error {
 		}
 	}
 
+	// Override change webhook flags with environment variables if set
+	if envChangeWebhookURLs := os.Getenv("CHANGE_WEBHOOK_URLS"); envChangeWebhookURLs != "" {
+		*changeWebhookURLsFlag = strings.Split(envChangeWebhookURLs, ",")
+	}
+	if envChangeWebhookSecret := os.Getenv("CHANGE_WEBHOOK_SECRET"); envChangeWebhookSecret != "" {
+		*changeWebhookSecretFlag = envChangeWebhookSecret
+	}
+
+	// Override simulate flag with environment variable if set
+	if envSimulateScenario := os.Getenv("SIMULATE_SCENARIO"); envSimulateScenario != "" {
+		*simulateFlag = envSimulateScenario
+	}
+	simulating := *simulateFlag != ""
+
 	// Override mock device usage flag with environment variable if set
 	if os.Getenv("MOCK_DEVICE_USAGE") == "true" {
 		*mockDeviceUsageFlag = true
 	}
 
-	// For non-mainnet envs, use "lake_<env>" as the ClickHouse database.
+	// For non-mainnet envs, use "lake_<env>" as the ClickHouse database and
+	// "lake-<env>" as the Neo4j database (Neo4j names cannot contain underscores).
 	if *dzEnvFlag != config.EnvMainnetBeta {
 		*clickhouseDatabaseFlag = "lake_" + *dzEnvFlag
+		*neo4jDatabaseFlag = "lake-" + *dzEnvFlag
+	}
+
+	// Simulations always write to their own databases so synthetic data never lands in a
+	// real environment's lake.
+	if simulating {
+		*clickhouseDatabaseFlag = "lake_sim"
+		*neo4jDatabaseFlag = "lake-sim"
 	}
 
-	// Solana, GeoIP, Neo4j, and ISIS are only enabled for mainnet-beta for now.
-	solanaEnabled := *dzEnvFlag == config.EnvMainnetBeta
-	geoipEnabled := *dzEnvFlag == config.EnvMainnetBeta
-	neo4jEnabled := *dzEnvFlag == config.EnvMainnetBeta
+	// Solana, GeoIP, and ISIS are only enabled for mainnet-beta for now.
+	// Neo4j is enabled for every env, each writing its own graph database.
+	// Simulations fabricate Solana and ISIS data, and skip GeoIP (synthetic IPs don't resolve).
+	solanaEnabled := *dzEnvFlag == config.EnvMainnetBeta || simulating
+	geoipEnabled := *dzEnvFlag == config.EnvMainnetBeta && !simulating
+	isisEnabled := *dzEnvFlag == config.EnvMainnetBeta || simulating
 
 	networkConfig, err := config.NetworkConfigForEnv(*dzEnvFlag)
 	if err != nil {
@@ -185,7 +225,7 @@ func run() error {
 	}
 
 	var solanaNetworkConfig *config.SolanaNetworkConfig
-	if solanaEnabled {
+	if solanaEnabled && !simulating {
 		solanaNetworkConfig, err = config.SolanaNetworkConfigForEnv(*solanaEnvFlag)
 		if err != nil {
 			return fmt.Errorf("failed to get solana network config: %w", err)
@@ -201,7 +241,8 @@ func run() error {
 		"solana_env", *solanaEnvFlag,
 		"solana_enabled", solanaEnabled,
 		"geoip_enabled", geoipEnabled,
-		"neo4j_enabled", neo4jEnabled,
+		"isis_enabled", isisEnabled,
+		"simulate", *simulateFlag,
 	)
 
 	// Set up signal handling with detailed logging
@@ -248,16 +289,31 @@ func run() error {
 		}()
 	}
 
-	dzRPCClient := rpc.NewWithRetries(networkConfig.LedgerPublicRPCURL, nil)
-	defer dzRPCClient.Close()
-	serviceabilityClient := serviceability.New(dzRPCClient, networkConfig.ServiceabilityProgramID)
-	telemetryClient := telemetry.New(log, dzRPCClient, nil, networkConfig.TelemetryProgramID)
-
-	var solanaRPC sol.SolanaRPC
-	if solanaEnabled {
-		solanaRPCClient := rpc.NewWithRetries(solanaNetworkConfig.RPCURL, nil)
-		defer solanaRPCClient.Close()
-		solanaRPC = solanaRPCClient
+	var (
+		serviceabilityRPC dzsvc.ServiceabilityRPC
+		telemetryRPC      dztelemlatency.TelemetryRPC
+		dzEpochRPC        dztelemlatency.EpochRPC
+		solanaRPC         sol.SolanaRPC
+		isisSource        isis.Source
+	)
+	if simulating {
+		sim, err := newSimulator(log, *simulateFlag, *simulateStartFlag)
+		if err != nil {
+			return err
+		}
+		serviceabilityRPC, telemetryRPC, dzEpochRPC, solanaRPC, isisSource = sim, sim, sim, sim, sim
+	} else {
+		dzRPCClient := rpc.NewWithRetries(networkConfig.LedgerPublicRPCURL, nil)
+		defer dzRPCClient.Close()
+		serviceabilityRPC = serviceability.New(dzRPCClient, networkConfig.ServiceabilityProgramID)
+		telemetryRPC = telemetry.New(log, dzRPCClient, nil, networkConfig.TelemetryProgramID)
+		dzEpochRPC = dzRPCClient
+
+		if solanaEnabled {
+			solanaRPCClient := rpc.NewWithRetries(solanaNetworkConfig.RPCURL, nil)
+			defer solanaRPCClient.Close()
+			solanaRPC = solanaRPCClient
+		}
 	}
 
 	// Initialize ClickHouse client (required)
@@ -327,7 +383,7 @@ func run() error {
 	}
 
 	// Initialize InfluxDB client from environment variables (optional, mainnet-beta only)
-	influxEnabled := *dzEnvFlag == config.EnvMainnetBeta
+	influxEnabled := *dzEnvFlag == config.EnvMainnetBeta || simulating
 	var influxDBClient dztelemusage.InfluxDBClient
 	influxURL := os.Getenv("INFLUX_URL")
 	influxToken := os.Getenv("INFLUX_TOKEN")
@@ -340,8 +396,8 @@ func run() error {
 	}
 	if !influxEnabled {
 		log.Info("device usage (InfluxDB) disabled for non-mainnet env")
-	} else if *mockDeviceUsageFlag {
-		log.Info("device usage: using mock data (--mock-device-usage enabled)")
+	} else if *mockDeviceUsageFlag || simulating {
+		log.Info("device usage: using mock data (--mock-device-usage or --simulate enabled)")
 		influxDBClient = dztelemusage.NewMockInfluxDBClient(dztelemusage.MockInfluxDBClientConfig{
 			ClickHouse: clickhouseDB,
 			Logger:     log,
@@ -364,9 +420,9 @@ func run() error {
 		log.Info("device usage (InfluxDB) environment variables not set, telemetry usage view will be disabled")
 	}
 
-	// Initialize Neo4j client (optional, mainnet-beta only)
+	// Initialize Neo4j client (optional)
 	var neo4jClient neo4j.Client
-	if neo4jEnabled && *neo4jURIFlag != "" {
+	if *neo4jURIFlag != "" {
 		if *createDatabaseFlag {
 			if err := neo4j.CreateDatabase(ctx, log, *neo4jURIFlag, *neo4jUsernameFlag, *neo4jPasswordFlag, *neo4jDatabaseFlag); err != nil {
 				return fmt.Errorf("failed to create Neo4j database: %w", err)
@@ -386,7 +442,31 @@ func run() error {
 
 		log.Info("Neo4j client initialized", "uri", *neo4jURIFlag, "database", *neo4jDatabaseFlag)
 	} else {
-		log.Info("Neo4j disabled", "neo4j_enabled", neo4jEnabled, "neo4j_uri_set", *neo4jURIFlag != "")
+		log.Info("Neo4j URI not set, graph sync will be disabled")
+	}
+
+	// Initialize change event webhooks (optional)
+	var changeSinks changes.MultiSink
+	for _, url := range *changeWebhookURLsFlag {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		sink, err := changes.NewWebhookSink(changes.WebhookSinkConfig{
+			Logger: log,
+			URL:    url,
+			Secret: *changeWebhookSecretFlag,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create change webhook sink: %w", err)
+		}
+		sink.Start(ctx)
+		changeSinks = append(changeSinks, sink)
+		log.Info("change webhook enabled", "url", url, "signed", *changeWebhookSecretFlag != "")
+	}
+	var changeSink dataset.ChangeSink
+	if len(changeSinks) > 0 {
+		changeSink = changeSinks
 	}
 
 	// Initialize server
@@ -420,11 +500,11 @@ func run() error {
 			GeoIPResolver: geoIPResolver,
 
 			// Serviceability configuration
-			ServiceabilityRPC: serviceabilityClient,
+			ServiceabilityRPC: serviceabilityRPC,
 
 			// Telemetry configuration
-			TelemetryRPC:           telemetryClient,
-			DZEpochRPC:             dzRPCClient,
+			TelemetryRPC:           telemetryRPC,
+			DZEpochRPC:             dzEpochRPC,
 			InternetLatencyAgentPK: networkConfig.InternetLatencyCollectorPK,
 			InternetDataProviders:  telemetryconfig.InternetTelemetryDataProviders,
 
@@ -439,6 +519,7 @@ func run() error {
 
 			// Neo4j configuration
 			Neo4j:                 neo4jClient,
+			Neo4jDatabase:         *neo4jDatabaseFlag,
 			Neo4jMigrationsEnable: *neo4jMigrationsEnableFlag,
 			Neo4jMigrationsConfig: neo4j.MigrationConfig{
 				URI:      *neo4jURIFlag,
@@ -448,10 +529,14 @@ func run() error {
 			},
 
 			// ISIS configuration
-			ISISEnabled:         *isisEnabledFlag && neo4jEnabled,
+			ISISEnabled:         *isisEnabledFlag && isisEnabled && neo4jClient != nil,
 			ISISS3Bucket:        *isisS3BucketFlag,
 			ISISS3Region:        *isisS3RegionFlag,
 			ISISRefreshInterval: *isisRefreshIntervalFlag,
+			ISISSource:          isisSource,
+
+			// Change events
+			ChangeSink: changeSink,
 
 			// Readiness configuration
 			SkipReadyWait: *skipReadyWaitFlag,
@@ -482,6 +567,30 @@ func run() error {
 	}
 }
 
+// newSimulator loads a scenario and starts its clock at start (RFC3339), or now if empty.
+func newSimulator(log *slog.Logger, scenarioPath, start string) (*simulator.Simulator, error) {
+	scenario, err := simulator.LoadScenario(scenarioPath)
+	if err != nil {
+		return nil, err
+	}
+	var startTime time.Time
+	if start != "" {
+		startTime, err = time.Parse(time.RFC3339, start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid simulate-start: %w", err)
+		}
+	}
+	sim, err := simulator.New(simulator.Config{
+		Logger:   log,
+		Scenario: scenario,
+		Start:    startTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create simulator: %w", err)
+	}
+	return sim, nil
+}
+
 func initializeGeoIP(cityDBPath, asnDBPath string, log *slog.Logger) (geoip.Resolver, func() error, error) {
 	cityDB, err := geoip2.Open(cityDBPath)
 	if err != nil {
@@ -0,0 +1,26 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Shared change log for all SCD2 dimension datasets
+-- WriteBatch appends one row per entity created, updated, or deleted by an op
+-- changes: JSON array of {"field", "old_value", "new_value"} for updated payload columns
+-- attrs: JSON object with the PK and payload columns after the change (last state for deletes)
+-- Ordered by (dataset, snapshot_ts) so time-range reads prune instead of scanning history
+CREATE TABLE IF NOT EXISTS dim_change_log
+(
+    dataset LowCardinality(String),
+    entity_id String,
+    op_id UUID,
+    snapshot_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    change_type LowCardinality(String),
+    changed_fields Array(String),
+    changes String,
+    attrs String
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(snapshot_ts)
+ORDER BY (dataset, snapshot_ts, entity_id, op_id);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS dim_change_log;
@@ -0,0 +1,19 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Payload column set hashed into attrs_hash for each SCD2 dimension dataset, one row per version
+-- MigrateSchema appends a version after rehashing history, so adding a payload column does not
+-- make the next WriteBatch write a new version of every entity
+CREATE TABLE IF NOT EXISTS dim_schema_versions
+(
+    dataset LowCardinality(String),
+    version UInt32,
+    hash_columns Array(String),
+    rehashed_rows UInt64,
+    applied_at DateTime64(3)
+) ENGINE = MergeTree
+ORDER BY (dataset, version);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS dim_schema_versions;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- One row per write of the fact datasets that opt in to change recording
+-- Served alongside dim_change_log by the changes stream, ordered by (snapshot_ts, op_id)
+-- min_time/max_time: range of the dataset's time column in the write (NULL without one)
+CREATE TABLE IF NOT EXISTS fact_change_log
+(
+    dataset LowCardinality(String),
+    op_id UUID,
+    snapshot_ts DateTime64(3),
+    row_count UInt64,
+    min_time Nullable(DateTime64(3)),
+    max_time Nullable(DateTime64(3))
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(snapshot_ts)
+ORDER BY (dataset, snapshot_ts, op_id);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS fact_change_log;
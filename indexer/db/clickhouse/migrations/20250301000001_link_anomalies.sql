@@ -0,0 +1,32 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Link latency anomalies flagged against per-link baselines built from fact_dz_device_link_latency
+-- One row per (window, link, metric) that deviated; event_ts is the start of the window
+-- metric: rtt (median RTT), jitter (mean IPDV) or loss (loss %), both directions combined
+-- baseline_median/baseline_mad: robust baseline over the lookback, seasonal when the same hour of
+-- day has enough history; score: deviation from the median in scaled MADs
+-- severity: warning or critical; cleared when re-evaluation found the window no longer deviates
+CREATE TABLE IF NOT EXISTS fact_dz_link_anomalies
+(
+    event_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    link_pk String,
+    metric LowCardinality(String),
+    window_seconds Int32,
+    value Float64,
+    baseline_median Float64,
+    baseline_mad Float64,
+    score Float64,
+    severity LowCardinality(String),
+    samples Int64,
+    baseline_windows Int64,
+    seasonal Bool
+)
+ENGINE = ReplacingMergeTree(ingested_at)
+PARTITION BY toYYYYMM(event_ts)
+ORDER BY (event_ts, link_pk, metric);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS fact_dz_link_anomalies;
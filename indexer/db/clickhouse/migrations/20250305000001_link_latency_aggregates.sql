@@ -0,0 +1,45 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Per-link latency aggregates maintained by the telemetry latency view from fact_dz_device_link_latency
+-- One row per (link, epoch, granularity, period), both directions combined
+-- granularity: interval (fixed-width windows; event_ts is the window start) or epoch (the whole
+-- epoch so far; event_ts is the epoch's first sample slot)
+-- expected_samples: sample slots elapsed in the period given each circuit's sampling interval, so
+-- expected_samples - samples is the number of samples never written
+-- rtt_*/jitter_*: delivered samples only, NULL when none were delivered; jitter is IPDV
+-- rtt_sla_met/jitter_sla_met: average RTT/jitter within the link's committed values, NULL when the
+-- link has no commitment or no sample was delivered
+CREATE TABLE IF NOT EXISTS fact_dz_link_latency_aggregates
+(
+    event_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    link_pk String,
+    epoch Int64,
+    granularity LowCardinality(String),
+    period_seconds Int64,
+    samples Int64,
+    expected_samples Int64,
+    lost_samples Int64,
+    loss_pct Float64,
+    rtt_min_us Nullable(Float64),
+    rtt_avg_us Nullable(Float64),
+    rtt_p50_us Nullable(Float64),
+    rtt_p95_us Nullable(Float64),
+    rtt_p99_us Nullable(Float64),
+    rtt_max_us Nullable(Float64),
+    jitter_avg_us Nullable(Float64),
+    jitter_p95_us Nullable(Float64),
+    jitter_p99_us Nullable(Float64),
+    committed_rtt_us Float64,
+    committed_jitter_us Float64,
+    rtt_sla_met Nullable(Bool),
+    jitter_sla_met Nullable(Bool)
+)
+ENGINE = ReplacingMergeTree(ingested_at)
+PARTITION BY toYYYYMM(event_ts)
+ORDER BY (event_ts, link_pk, epoch, granularity);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS fact_dz_link_latency_aggregates;
@@ -0,0 +1,164 @@
+-- +goose Up
+
+-- DZ vs Public Internet Advantage by Epoch
+-- Tracks how much faster DZ is than the public internet for each directly-connected metro pair,
+-- one row per (epoch, metro pair), so the benefit can be followed over time.
+--
+-- Metrics:
+--   *_p50/p95/p99_rtt_ms: RTT percentiles over all delivered samples in the epoch
+--   *_improvement_ms/pct: internet minus DZ at that percentile (positive = DZ is faster)
+--   dz_win_pct: share of hours in the epoch, with samples on both sides, where DZ's average
+--     RTT beat the internet's
+--   improvement_stddev_ms: standard deviation of the hourly improvement; lower is a steadier
+--     advantage
+--
+-- NOTE: Metro pairs are normalized using least/greatest like dz_vs_internet_latency_comparison,
+-- and only pairs with both DZ and internet samples in the epoch are included.
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW dz_vs_internet_latency_by_epoch
+AS
+WITH
+-- Delivered DZ samples on inter-metro links, tagged with the normalized metro pair
+dz_samples AS (
+    SELECT
+        f.epoch AS epoch,
+        least(ma.code, mz.code) AS metro1,
+        greatest(ma.code, mz.code) AS metro2,
+        f.event_ts AS event_ts,
+        f.rtt_us AS rtt_us
+    FROM fact_dz_device_link_latency f
+    JOIN dz_links_current l ON f.link_pk = l.pk
+    JOIN dz_devices_current da ON l.side_a_pk = da.pk
+    JOIN dz_devices_current dz ON l.side_z_pk = dz.pk
+    JOIN dz_metros_current ma ON da.metro_pk = ma.pk
+    JOIN dz_metros_current mz ON dz.metro_pk = mz.pk
+    WHERE f.link_pk != ''
+      AND f.loss = false
+      AND f.rtt_us > 0
+      AND ma.code != mz.code  -- Exclude intra-metro links
+),
+
+-- Internet samples across all providers, tagged with the normalized metro pair
+internet_samples AS (
+    SELECT
+        f.epoch AS epoch,
+        least(ma.code, mz.code) AS metro1,
+        greatest(ma.code, mz.code) AS metro2,
+        f.event_ts AS event_ts,
+        f.rtt_us AS rtt_us
+    FROM fact_dz_internet_metro_latency f
+    JOIN dz_metros_current ma ON f.origin_metro_pk = ma.pk
+    JOIN dz_metros_current mz ON f.target_metro_pk = mz.pk
+    WHERE f.rtt_us > 0
+      AND ma.code != mz.code  -- Exclude same-metro measurements
+),
+
+dz_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        min(event_ts) AS start_ts,
+        max(event_ts) AS end_ts,
+        quantile(0.5)(rtt_us) / 1000.0 AS p50_rtt_ms,
+        quantile(0.95)(rtt_us) / 1000.0 AS p95_rtt_ms,
+        quantile(0.99)(rtt_us) / 1000.0 AS p99_rtt_ms,
+        count() AS sample_count
+    FROM dz_samples
+    GROUP BY epoch, metro1, metro2
+),
+
+internet_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        min(event_ts) AS start_ts,
+        max(event_ts) AS end_ts,
+        quantile(0.5)(rtt_us) / 1000.0 AS p50_rtt_ms,
+        quantile(0.95)(rtt_us) / 1000.0 AS p95_rtt_ms,
+        quantile(0.99)(rtt_us) / 1000.0 AS p99_rtt_ms,
+        count() AS sample_count
+    FROM internet_samples
+    GROUP BY epoch, metro1, metro2
+),
+
+-- Hourly averages on each side, compared where both have samples
+hourly AS (
+    SELECT
+        dz.epoch AS epoch,
+        dz.metro1 AS metro1,
+        dz.metro2 AS metro2,
+        dz.avg_rtt_ms AS dz_avg_rtt_ms,
+        inet.avg_rtt_ms AS internet_avg_rtt_ms
+    FROM (
+        SELECT epoch, metro1, metro2, toStartOfHour(event_ts) AS hour, avg(rtt_us) / 1000.0 AS avg_rtt_ms
+        FROM dz_samples
+        GROUP BY epoch, metro1, metro2, hour
+    ) dz
+    JOIN (
+        SELECT epoch, metro1, metro2, toStartOfHour(event_ts) AS hour, avg(rtt_us) / 1000.0 AS avg_rtt_ms
+        FROM internet_samples
+        GROUP BY epoch, metro1, metro2, hour
+    ) inet
+        ON dz.epoch = inet.epoch
+        AND dz.metro1 = inet.metro1
+        AND dz.metro2 = inet.metro2
+        AND dz.hour = inet.hour
+),
+
+hourly_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        count() AS compared_hours,
+        countIf(dz_avg_rtt_ms < internet_avg_rtt_ms) AS dz_win_hours,
+        stddevPop(internet_avg_rtt_ms - dz_avg_rtt_ms) AS improvement_stddev_ms
+    FROM hourly
+    GROUP BY epoch, metro1, metro2
+)
+
+SELECT
+    dz.epoch AS epoch,
+    dz.metro1 AS origin_metro,
+    dz.metro2 AS target_metro,
+    least(dz.start_ts, inet.start_ts) AS start_ts,
+    greatest(dz.end_ts, inet.end_ts) AS end_ts,
+
+    -- DZ metrics
+    round(dz.p50_rtt_ms, 2) AS dz_p50_rtt_ms,
+    round(dz.p95_rtt_ms, 2) AS dz_p95_rtt_ms,
+    round(dz.p99_rtt_ms, 2) AS dz_p99_rtt_ms,
+    dz.sample_count AS dz_sample_count,
+
+    -- Internet metrics
+    round(inet.p50_rtt_ms, 2) AS internet_p50_rtt_ms,
+    round(inet.p95_rtt_ms, 2) AS internet_p95_rtt_ms,
+    round(inet.p99_rtt_ms, 2) AS internet_p99_rtt_ms,
+    inet.sample_count AS internet_sample_count,
+
+    -- Advantage (positive = DZ is faster)
+    round(inet.p50_rtt_ms - dz.p50_rtt_ms, 2) AS median_improvement_ms,
+    round((inet.p50_rtt_ms - dz.p50_rtt_ms) / inet.p50_rtt_ms * 100, 1) AS median_improvement_pct,
+    round(inet.p95_rtt_ms - dz.p95_rtt_ms, 2) AS p95_improvement_ms,
+    round((inet.p95_rtt_ms - dz.p95_rtt_ms) / inet.p95_rtt_ms * 100, 1) AS p95_improvement_pct,
+    round(inet.p99_rtt_ms - dz.p99_rtt_ms, 2) AS p99_improvement_ms,
+    round((inet.p99_rtt_ms - dz.p99_rtt_ms) / inet.p99_rtt_ms * 100, 1) AS p99_improvement_pct,
+
+    -- Consistency of the advantage across the epoch
+    COALESCE(h.compared_hours, 0) AS compared_hours,
+    if(h.compared_hours > 0, round(h.dz_win_hours * 100.0 / h.compared_hours, 1), NULL) AS dz_win_pct,
+    if(h.compared_hours > 0, round(h.improvement_stddev_ms, 2), NULL) AS improvement_stddev_ms
+
+FROM dz_epoch dz
+JOIN internet_epoch inet
+    ON dz.epoch = inet.epoch
+    AND dz.metro1 = inet.metro1
+    AND dz.metro2 = inet.metro2
+LEFT JOIN hourly_epoch h
+    ON dz.epoch = h.epoch
+    AND dz.metro1 = h.metro1
+    AND dz.metro2 = h.metro2
+ORDER BY origin_metro, target_metro, epoch;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS dz_vs_internet_latency_by_epoch;
+-- +goose StatementEnd
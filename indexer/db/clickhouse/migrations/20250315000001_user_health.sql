@@ -0,0 +1,65 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Per-user tunnel health scored from the user's tunnel interface counters and device status
+-- One row per (window, user); event_ts is the start of the window
+-- score: 0-100, 100 is a clean tunnel; status: healthy (>= 80), degraded (>= 50) or at_risk
+-- issues: comma-separated reasons the score was lowered (device_not_activated, no_telemetry,
+-- telemetry_gap, no_traffic, carrier_transitions, errors, discards)
+-- coverage_pct: share of the window covered by counter samples; rates are % of packets
+CREATE TABLE IF NOT EXISTS fact_dz_user_health
+(
+    event_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    user_pk String,
+    device_pk String,
+    tunnel_id Int32,
+    window_seconds Int32,
+    score Float64,
+    status LowCardinality(String),
+    issues String,
+    device_status LowCardinality(String),
+    samples Int64,
+    coverage_pct Float64,
+    in_pkts Int64,
+    out_pkts Int64,
+    errors Int64,
+    discards Int64,
+    carrier_transitions Int64,
+    error_rate_pct Float64,
+    discard_rate_pct Float64
+)
+ENGINE = ReplacingMergeTree(ingested_at)
+PARTITION BY toYYYYMM(event_ts)
+ORDER BY (event_ts, user_pk);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Latest health of each current user, from windows scored in the last hour
+CREATE OR REPLACE VIEW dz_user_health_current
+AS
+SELECT
+    user_pk,
+    argMax(event_ts, event_ts) AS event_ts,
+    argMax(device_pk, event_ts) AS device_pk,
+    argMax(tunnel_id, event_ts) AS tunnel_id,
+    argMax(score, event_ts) AS score,
+    argMax(status, event_ts) AS status,
+    argMax(issues, event_ts) AS issues,
+    argMax(coverage_pct, event_ts) AS coverage_pct,
+    argMax(error_rate_pct, event_ts) AS error_rate_pct,
+    argMax(discard_rate_pct, event_ts) AS discard_rate_pct,
+    argMax(carrier_transitions, event_ts) AS carrier_transitions
+FROM fact_dz_user_health FINAL
+WHERE event_ts > now() - INTERVAL 1 HOUR
+  AND user_pk IN (SELECT pk FROM dz_users_current)
+GROUP BY user_pk;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS dz_user_health_current;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS fact_dz_user_health;
+-- +goose StatementEnd
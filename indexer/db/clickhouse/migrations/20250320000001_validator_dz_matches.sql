@@ -0,0 +1,326 @@
+-- +goose Up
+
+-- Validator to DZ User Matches
+-- Written by the indexer's validator matching stage, one row per validator identity (node
+-- pubkey) matched to the DZ user it connects through. Validators that stop matching are
+-- tombstoned, so the history records every connect and disconnect.
+--
+-- match_method, most confident first:
+--   dz_ip:                gossip IP is the user's DZ IP
+--   tpu_dz_ip:            TPU QUIC IP is the user's DZ IP (gossip advertised elsewhere)
+--   client_ip_port_range: gossip and TPU QUIC on the user's client IP with ports from one
+--                         validator's port range, as when the DZ IP is behind NAT
+--   client_ip:            gossip or TPU QUIC IP is the user's client IP
+-- confidence: the method's base confidence (1.0, 0.9, 0.7, 0.5) divided among the candidate
+-- users and among the validators matched to the same user by the same method.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS dim_solana_validator_dz_matches_history
+(
+    entity_id String,
+    snapshot_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    op_id UUID,
+    is_deleted UInt8 DEFAULT 0,
+    attrs_hash UInt64,
+    node_pubkey String,  -- Natural key: entity_id contains the same value, but this column is for user queries
+    vote_pubkey String,
+    user_pk String,
+    owner_pubkey String,
+    device_pk String,
+    dz_ip String,
+    client_ip String,
+    gossip_ip String,
+    gossip_port Int32,
+    tpuquic_ip String,
+    tpuquic_port Int32,
+    match_method String,
+    confidence Float64,
+    candidates Int32
+) ENGINE = MergeTree
+PARTITION BY toYYYYMM(snapshot_ts)
+ORDER BY (entity_id, snapshot_ts, ingested_at, op_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stg_dim_solana_validator_dz_matches_snapshot
+(
+    entity_id String,
+    snapshot_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    op_id UUID,
+    is_deleted UInt8 DEFAULT 0,
+    attrs_hash UInt64,
+    node_pubkey String,  -- Natural key: entity_id contains the same value, but this column is for user queries
+    vote_pubkey String,
+    user_pk String,
+    owner_pubkey String,
+    device_pk String,
+    dz_ip String,
+    client_ip String,
+    gossip_ip String,
+    gossip_port Int32,
+    tpuquic_ip String,
+    tpuquic_port Int32,
+    match_method String,
+    confidence Float64,
+    candidates Int32
+) ENGINE = MergeTree
+PARTITION BY toDate(snapshot_ts)
+ORDER BY (op_id, entity_id)
+TTL ingested_at + INTERVAL 7 DAY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- solana_validator_dz_matches_current
+CREATE OR REPLACE VIEW solana_validator_dz_matches_current
+AS
+WITH ranked AS (
+    SELECT
+        *,
+        row_number() OVER (PARTITION BY entity_id ORDER BY snapshot_ts DESC, ingested_at DESC, op_id DESC) AS rn
+    FROM dim_solana_validator_dz_matches_history
+)
+SELECT
+    entity_id,
+    snapshot_ts,
+    ingested_at,
+    op_id,
+    attrs_hash,
+    node_pubkey,
+    vote_pubkey,
+    user_pk,
+    owner_pubkey,
+    device_pk,
+    dz_ip,
+    client_ip,
+    gossip_ip,
+    gossip_port,
+    tpuquic_ip,
+    tpuquic_port,
+    match_method,
+    confidence,
+    candidates
+FROM ranked
+WHERE rn = 1 AND is_deleted = 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- solana_validator_dz_match_periods
+-- One row per continuous period a validator was matched to one DZ user. A period ends when the
+-- validator stops matching or matches another user; disconnected_ts is NULL while it lasts.
+-- Method, confidence and addresses are the latest seen in the period.
+CREATE OR REPLACE VIEW solana_validator_dz_match_periods
+AS
+WITH lagged AS (
+    SELECT
+        *,
+        row_number() OVER w AS seq,
+        lagInFrame(is_deleted) OVER w AS prev_is_deleted,
+        lagInFrame(user_pk) OVER w AS prev_user_pk
+    FROM dim_solana_validator_dz_matches_history
+    WINDOW w AS (PARTITION BY node_pubkey ORDER BY snapshot_ts, ingested_at, op_id ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)
+),
+flagged AS (
+    SELECT
+        *,
+        -- A new period starts when the match appears, disappears or moves to another user
+        if(seq = 1 OR is_deleted != prev_is_deleted OR (is_deleted = 0 AND user_pk != prev_user_pk), 1, 0) AS starts_period
+    FROM lagged
+),
+numbered AS (
+    SELECT
+        *,
+        sum(starts_period) OVER (PARTITION BY node_pubkey ORDER BY snapshot_ts, ingested_at, op_id ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS period_id
+    FROM flagged
+),
+periods AS (
+    SELECT
+        node_pubkey,
+        period_id,
+        any(is_deleted) AS is_deleted,
+        min(snapshot_ts) AS start_ts,
+        argMax(vote_pubkey, snapshot_ts) AS vote_pubkey,
+        any(user_pk) AS user_pk,
+        argMax(owner_pubkey, snapshot_ts) AS owner_pubkey,
+        argMax(device_pk, snapshot_ts) AS device_pk,
+        argMax(dz_ip, snapshot_ts) AS dz_ip,
+        argMax(client_ip, snapshot_ts) AS client_ip,
+        argMax(gossip_ip, snapshot_ts) AS gossip_ip,
+        argMax(match_method, snapshot_ts) AS match_method,
+        argMax(confidence, snapshot_ts) AS confidence,
+        min(confidence) AS min_confidence
+    FROM numbered
+    GROUP BY node_pubkey, period_id
+),
+bounded AS (
+    SELECT
+        *,
+        leadInFrame(toNullable(start_ts)) OVER (PARTITION BY node_pubkey ORDER BY period_id ROWS BETWEEN CURRENT ROW AND 1 FOLLOWING) AS end_ts
+    FROM periods
+)
+SELECT
+    node_pubkey,
+    vote_pubkey,
+    user_pk,
+    owner_pubkey,
+    device_pk,
+    dz_ip,
+    client_ip,
+    gossip_ip,
+    match_method,
+    confidence,
+    min_confidence,
+    start_ts AS connected_ts,
+    end_ts AS disconnected_ts
+FROM bounded
+WHERE is_deleted = 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- solana_validators_on_dz_connections
+-- Same as before, plus the validator's current match from the matching stage (user_pk,
+-- match_method, match_confidence; empty and 0 when it isn't currently matched)
+CREATE OR REPLACE VIEW solana_validators_on_dz_connections
+AS
+WITH connection_events AS (
+    -- Find all times when a validator was connected (user, gossip node, and vote account all exist together)
+    -- The connection timestamp is the maximum of the three snapshot_ts values
+    SELECT
+        va.vote_pubkey,
+        va.node_pubkey,
+        u.owner_pubkey,
+        u.dz_ip,
+        u.device_pk,
+        va.activated_stake_lamports,
+        va.commission_percentage,
+        GREATEST(u.snapshot_ts, gn.snapshot_ts, va.snapshot_ts) AS connected_ts
+    FROM dim_dz_users_history u
+    JOIN dim_solana_gossip_nodes_history gn ON u.dz_ip = gn.gossip_ip AND gn.gossip_ip != ''
+    JOIN dim_solana_vote_accounts_history va ON gn.pubkey = va.node_pubkey
+    WHERE u.is_deleted = 0 AND u.status = 'activated' AND u.dz_ip != ''
+      AND gn.is_deleted = 0
+      AND va.is_deleted = 0 AND va.epoch_vote_account = 'true' AND va.activated_stake_lamports > 0
+),
+first_connections AS (
+    -- Get first connection time per validator (GROUP BY only immutable identifiers)
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        MIN(connected_ts) AS first_connected_ts,
+        MAX(connected_ts) AS last_connected_ts
+    FROM connection_events
+    GROUP BY vote_pubkey, node_pubkey
+),
+latest_values AS (
+    -- Get latest stake/commission values per validator using row_number
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        owner_pubkey,
+        dz_ip,
+        device_pk,
+        activated_stake_lamports,
+        commission_percentage,
+        ROW_NUMBER() OVER (PARTITION BY vote_pubkey, node_pubkey ORDER BY connected_ts DESC) AS rn
+    FROM connection_events
+)
+SELECT
+    fc.vote_pubkey AS vote_pubkey,
+    fc.node_pubkey AS node_pubkey,
+    lv.owner_pubkey AS owner_pubkey,
+    lv.dz_ip AS dz_ip,
+    lv.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    lv.activated_stake_lamports AS activated_stake_lamports,
+    lv.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    lv.commission_percentage AS commission_percentage,
+    fc.first_connected_ts AS first_connected_ts,
+    COALESCE(mt.user_pk, '') AS user_pk,
+    COALESCE(mt.match_method, '') AS match_method,
+    COALESCE(mt.confidence, 0) AS match_confidence
+FROM first_connections fc
+JOIN latest_values lv ON fc.vote_pubkey = lv.vote_pubkey AND fc.node_pubkey = lv.node_pubkey AND lv.rn = 1
+LEFT JOIN dz_devices_current d ON lv.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+LEFT JOIN solana_validator_dz_matches_current mt ON fc.node_pubkey = mt.node_pubkey;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW solana_validators_on_dz_connections
+AS
+WITH connection_events AS (
+    -- Find all times when a validator was connected (user, gossip node, and vote account all exist together)
+    -- The connection timestamp is the maximum of the three snapshot_ts values
+    SELECT
+        va.vote_pubkey,
+        va.node_pubkey,
+        u.owner_pubkey,
+        u.dz_ip,
+        u.device_pk,
+        va.activated_stake_lamports,
+        va.commission_percentage,
+        GREATEST(u.snapshot_ts, gn.snapshot_ts, va.snapshot_ts) AS connected_ts
+    FROM dim_dz_users_history u
+    JOIN dim_solana_gossip_nodes_history gn ON u.dz_ip = gn.gossip_ip AND gn.gossip_ip != ''
+    JOIN dim_solana_vote_accounts_history va ON gn.pubkey = va.node_pubkey
+    WHERE u.is_deleted = 0 AND u.status = 'activated' AND u.dz_ip != ''
+      AND gn.is_deleted = 0
+      AND va.is_deleted = 0 AND va.epoch_vote_account = 'true' AND va.activated_stake_lamports > 0
+),
+first_connections AS (
+    -- Get first connection time per validator (GROUP BY only immutable identifiers)
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        MIN(connected_ts) AS first_connected_ts,
+        MAX(connected_ts) AS last_connected_ts
+    FROM connection_events
+    GROUP BY vote_pubkey, node_pubkey
+),
+latest_values AS (
+    -- Get latest stake/commission values per validator using row_number
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        owner_pubkey,
+        dz_ip,
+        device_pk,
+        activated_stake_lamports,
+        commission_percentage,
+        ROW_NUMBER() OVER (PARTITION BY vote_pubkey, node_pubkey ORDER BY connected_ts DESC) AS rn
+    FROM connection_events
+)
+SELECT
+    fc.vote_pubkey AS vote_pubkey,
+    fc.node_pubkey AS node_pubkey,
+    lv.owner_pubkey AS owner_pubkey,
+    lv.dz_ip AS dz_ip,
+    lv.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    lv.activated_stake_lamports AS activated_stake_lamports,
+    lv.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    lv.commission_percentage AS commission_percentage,
+    fc.first_connected_ts AS first_connected_ts
+FROM first_connections fc
+JOIN latest_values lv ON fc.vote_pubkey = lv.vote_pubkey AND fc.node_pubkey = lv.node_pubkey AND lv.rn = 1
+LEFT JOIN dz_devices_current d ON lv.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP VIEW IF EXISTS solana_validator_dz_match_periods;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP VIEW IF EXISTS solana_validator_dz_matches_current;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stg_dim_solana_validator_dz_matches_snapshot;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS dim_solana_validator_dz_matches_history;
+-- +goose StatementEnd
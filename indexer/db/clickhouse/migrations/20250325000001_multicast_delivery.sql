@@ -0,0 +1,84 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Multicast group delivery: what each subscriber received compared with what the group's
+-- publishers sent, from tunnel and link interface counters
+-- One row per (window, group, subscriber); event_ts is the start of the window
+-- expected_pkts: packets the group's other publishers sent into their tunnels
+-- received_pkts: packets the subscriber's device sent down the subscriber's tunnel
+-- delivery_ratio: received / expected; loss_pct: shortfall in % of expected (0 when over)
+-- exclusive: every tunnel involved is in this group only, so the ratio is exact; otherwise
+--   the tunnels also carry other groups' traffic and the ratio is an estimate
+-- status: delivered, loss_in_tree (a tree hop is lossy), loss_at_edge (no lossy hop, so at
+--   the subscriber's device or tunnel), loss_unlocated (no tree to check), idle (publishers
+--   sent nothing) or no_data (no counters)
+-- worst_hop_*: the lossiest link on the IS-IS paths from the publishers' devices, by the
+--   multicast packets the upstream device sent on it and the downstream device received
+-- path_hops, unmeasured_hops, lag_us: hops of the slowest publisher path, those without
+--   latency samples, and the sum of its links' one-way latencies (half the mean RTT)
+CREATE TABLE IF NOT EXISTS fact_dz_multicast_delivery
+(
+    event_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    group_pk String,
+    subscriber_pk String,
+    subscriber_device_pk String,
+    subscriber_tunnel_id Int32,
+    window_seconds Int32,
+    publishers Int32,
+    expected_pkts Int64,
+    received_pkts Int64,
+    delivery_ratio Float64,
+    loss_pct Float64,
+    exclusive Bool,
+    status LowCardinality(String),
+    worst_hop_from_device_pk String,
+    worst_hop_to_device_pk String,
+    worst_hop_link_pk String,
+    worst_hop_loss_pct Float64,
+    path_hops Int32,
+    unmeasured_hops Int32,
+    lag_us Float64
+)
+ENGINE = ReplacingMergeTree(ingested_at)
+PARTITION BY toYYYYMM(event_ts)
+ORDER BY (event_ts, group_pk, subscriber_pk);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Latest delivery of each current group subscriber, from windows evaluated in the last hour
+CREATE OR REPLACE VIEW dz_multicast_delivery_current
+AS
+SELECT
+    group_pk,
+    subscriber_pk,
+    argMax(event_ts, event_ts) AS event_ts,
+    argMax(subscriber_device_pk, event_ts) AS subscriber_device_pk,
+    argMax(publishers, event_ts) AS publishers,
+    argMax(expected_pkts, event_ts) AS expected_pkts,
+    argMax(received_pkts, event_ts) AS received_pkts,
+    argMax(delivery_ratio, event_ts) AS delivery_ratio,
+    argMax(loss_pct, event_ts) AS loss_pct,
+    argMax(exclusive, event_ts) AS exclusive,
+    argMax(status, event_ts) AS status,
+    argMax(worst_hop_from_device_pk, event_ts) AS worst_hop_from_device_pk,
+    argMax(worst_hop_to_device_pk, event_ts) AS worst_hop_to_device_pk,
+    argMax(worst_hop_link_pk, event_ts) AS worst_hop_link_pk,
+    argMax(worst_hop_loss_pct, event_ts) AS worst_hop_loss_pct,
+    argMax(path_hops, event_ts) AS path_hops,
+    argMax(unmeasured_hops, event_ts) AS unmeasured_hops,
+    argMax(lag_us, event_ts) AS lag_us
+FROM fact_dz_multicast_delivery FINAL
+WHERE event_ts > now() - INTERVAL 1 HOUR
+  AND group_pk IN (SELECT pk FROM dz_multicast_groups_current)
+  AND subscriber_pk IN (SELECT pk FROM dz_users_current)
+GROUP BY group_pk, subscriber_pk;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS dz_multicast_delivery_current;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS fact_dz_multicast_delivery;
+-- +goose StatementEnd
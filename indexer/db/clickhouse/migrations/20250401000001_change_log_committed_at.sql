@@ -0,0 +1,42 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Time a change log row was written, used as the changes stream cursor
+-- snapshot_ts is taken when a write starts, so a slow or concurrent writer can commit rows
+-- behind a cursor a consumer already passed; committed_at is taken right before the insert
+-- Rows written before this column existed default to their snapshot_ts
+ALTER TABLE dim_change_log
+    ADD COLUMN IF NOT EXISTS committed_at DateTime64(3) DEFAULT snapshot_ts AFTER ingested_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE dim_change_log
+    ADD INDEX IF NOT EXISTS idx_committed_at committed_at TYPE minmax GRANULARITY 1;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE fact_change_log
+    ADD COLUMN IF NOT EXISTS committed_at DateTime64(3) DEFAULT snapshot_ts AFTER snapshot_ts;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE fact_change_log
+    ADD INDEX IF NOT EXISTS idx_committed_at committed_at TYPE minmax GRANULARITY 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE fact_change_log DROP INDEX IF EXISTS idx_committed_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE fact_change_log DROP COLUMN IF EXISTS committed_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE dim_change_log DROP INDEX IF EXISTS idx_committed_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE dim_change_log DROP COLUMN IF EXISTS committed_at;
+-- +goose StatementEnd
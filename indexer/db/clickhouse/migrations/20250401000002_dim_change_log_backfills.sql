@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Progress of the dim_change_log backfill for each SCD2 dimension dataset
+-- BackfillChangeLog appends a row with completed = 0 when it starts and completed = 1 when it
+-- finishes, so a finished dataset is never rescanned and an interrupted one resumes
+-- cutoff_ts: backfilled entries are those before it (the first entry WriteBatch logged, or the
+-- start of the backfill if there was none); it is kept across retries of the same backfill
+CREATE TABLE IF NOT EXISTS dim_change_log_backfills
+(
+    dataset LowCardinality(String),
+    cutoff_ts DateTime64(3),
+    completed UInt8,
+    entries UInt64,
+    recorded_at DateTime64(3)
+) ENGINE = MergeTree
+ORDER BY (dataset, recorded_at);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS dim_change_log_backfills;
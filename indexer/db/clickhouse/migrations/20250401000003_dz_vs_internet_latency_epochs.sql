@@ -0,0 +1,223 @@
+-- +goose Up
+
+-- +goose StatementBegin
+-- Per-epoch DZ vs public internet latency for each directly-connected metro pair, maintained by
+-- the telemetry latency view for epochs that receive samples. Metros are resolved from the history
+-- dims as of each link's (or internet pair's) latest sample in the epoch.
+-- event_ts: the earliest sample of the pair in the epoch, on either side; end_ts the latest
+-- dz_*/internet_*: RTT percentiles and counts over the delivered samples of the epoch
+-- compared_hours/dz_win_hours: hours with samples on both sides, and those where DZ's average RTT
+-- beat the internet's; improvement_stddev_ms: standard deviation of the hourly improvement
+CREATE TABLE IF NOT EXISTS fact_dz_vs_internet_latency_epochs
+(
+    event_ts DateTime64(3),
+    ingested_at DateTime64(3),
+    epoch Int64,
+    origin_metro String,
+    target_metro String,
+    end_ts DateTime64(3),
+    dz_p50_rtt_ms Float64,
+    dz_p95_rtt_ms Float64,
+    dz_p99_rtt_ms Float64,
+    dz_sample_count UInt64,
+    internet_p50_rtt_ms Float64,
+    internet_p95_rtt_ms Float64,
+    internet_p99_rtt_ms Float64,
+    internet_sample_count UInt64,
+    compared_hours UInt64,
+    dz_win_hours UInt64,
+    improvement_stddev_ms Float64
+)
+ENGINE = ReplacingMergeTree(ingested_at)
+ORDER BY (epoch, origin_metro, target_metro);
+-- +goose StatementEnd
+
+-- DZ vs Public Internet Advantage by Epoch, now read from the per-epoch aggregates rather than
+-- rescanning every sample on each query. Columns are unchanged.
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW dz_vs_internet_latency_by_epoch
+AS
+SELECT
+    e.epoch AS epoch,
+    e.origin_metro AS origin_metro,
+    e.target_metro AS target_metro,
+    e.event_ts AS start_ts,
+    e.end_ts AS end_ts,
+
+    -- DZ metrics
+    round(e.dz_p50_rtt_ms, 2) AS dz_p50_rtt_ms,
+    round(e.dz_p95_rtt_ms, 2) AS dz_p95_rtt_ms,
+    round(e.dz_p99_rtt_ms, 2) AS dz_p99_rtt_ms,
+    e.dz_sample_count AS dz_sample_count,
+
+    -- Internet metrics
+    round(e.internet_p50_rtt_ms, 2) AS internet_p50_rtt_ms,
+    round(e.internet_p95_rtt_ms, 2) AS internet_p95_rtt_ms,
+    round(e.internet_p99_rtt_ms, 2) AS internet_p99_rtt_ms,
+    e.internet_sample_count AS internet_sample_count,
+
+    -- Advantage (positive = DZ is faster)
+    round(e.internet_p50_rtt_ms - e.dz_p50_rtt_ms, 2) AS median_improvement_ms,
+    round((e.internet_p50_rtt_ms - e.dz_p50_rtt_ms) / e.internet_p50_rtt_ms * 100, 1) AS median_improvement_pct,
+    round(e.internet_p95_rtt_ms - e.dz_p95_rtt_ms, 2) AS p95_improvement_ms,
+    round((e.internet_p95_rtt_ms - e.dz_p95_rtt_ms) / e.internet_p95_rtt_ms * 100, 1) AS p95_improvement_pct,
+    round(e.internet_p99_rtt_ms - e.dz_p99_rtt_ms, 2) AS p99_improvement_ms,
+    round((e.internet_p99_rtt_ms - e.dz_p99_rtt_ms) / e.internet_p99_rtt_ms * 100, 1) AS p99_improvement_pct,
+
+    -- Consistency of the advantage across the epoch
+    e.compared_hours AS compared_hours,
+    if(e.compared_hours > 0, round(e.dz_win_hours * 100.0 / e.compared_hours, 1), NULL) AS dz_win_pct,
+    if(e.compared_hours > 0, round(e.improvement_stddev_ms, 2), NULL) AS improvement_stddev_ms
+
+FROM fact_dz_vs_internet_latency_epochs AS e FINAL
+ORDER BY origin_metro, target_metro, epoch;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW dz_vs_internet_latency_by_epoch
+AS
+WITH
+-- Delivered DZ samples on inter-metro links, tagged with the normalized metro pair
+dz_samples AS (
+    SELECT
+        f.epoch AS epoch,
+        least(ma.code, mz.code) AS metro1,
+        greatest(ma.code, mz.code) AS metro2,
+        f.event_ts AS event_ts,
+        f.rtt_us AS rtt_us
+    FROM fact_dz_device_link_latency f
+    JOIN dz_links_current l ON f.link_pk = l.pk
+    JOIN dz_devices_current da ON l.side_a_pk = da.pk
+    JOIN dz_devices_current dz ON l.side_z_pk = dz.pk
+    JOIN dz_metros_current ma ON da.metro_pk = ma.pk
+    JOIN dz_metros_current mz ON dz.metro_pk = mz.pk
+    WHERE f.link_pk != ''
+      AND f.loss = false
+      AND f.rtt_us > 0
+      AND ma.code != mz.code  -- Exclude intra-metro links
+),
+
+-- Internet samples across all providers, tagged with the normalized metro pair
+internet_samples AS (
+    SELECT
+        f.epoch AS epoch,
+        least(ma.code, mz.code) AS metro1,
+        greatest(ma.code, mz.code) AS metro2,
+        f.event_ts AS event_ts,
+        f.rtt_us AS rtt_us
+    FROM fact_dz_internet_metro_latency f
+    JOIN dz_metros_current ma ON f.origin_metro_pk = ma.pk
+    JOIN dz_metros_current mz ON f.target_metro_pk = mz.pk
+    WHERE f.rtt_us > 0
+      AND ma.code != mz.code  -- Exclude same-metro measurements
+),
+
+dz_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        min(event_ts) AS start_ts,
+        max(event_ts) AS end_ts,
+        quantile(0.5)(rtt_us) / 1000.0 AS p50_rtt_ms,
+        quantile(0.95)(rtt_us) / 1000.0 AS p95_rtt_ms,
+        quantile(0.99)(rtt_us) / 1000.0 AS p99_rtt_ms,
+        count() AS sample_count
+    FROM dz_samples
+    GROUP BY epoch, metro1, metro2
+),
+
+internet_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        min(event_ts) AS start_ts,
+        max(event_ts) AS end_ts,
+        quantile(0.5)(rtt_us) / 1000.0 AS p50_rtt_ms,
+        quantile(0.95)(rtt_us) / 1000.0 AS p95_rtt_ms,
+        quantile(0.99)(rtt_us) / 1000.0 AS p99_rtt_ms,
+        count() AS sample_count
+    FROM internet_samples
+    GROUP BY epoch, metro1, metro2
+),
+
+-- Hourly averages on each side, compared where both have samples
+hourly AS (
+    SELECT
+        dz.epoch AS epoch,
+        dz.metro1 AS metro1,
+        dz.metro2 AS metro2,
+        dz.avg_rtt_ms AS dz_avg_rtt_ms,
+        inet.avg_rtt_ms AS internet_avg_rtt_ms
+    FROM (
+        SELECT epoch, metro1, metro2, toStartOfHour(event_ts) AS hour, avg(rtt_us) / 1000.0 AS avg_rtt_ms
+        FROM dz_samples
+        GROUP BY epoch, metro1, metro2, hour
+    ) dz
+    JOIN (
+        SELECT epoch, metro1, metro2, toStartOfHour(event_ts) AS hour, avg(rtt_us) / 1000.0 AS avg_rtt_ms
+        FROM internet_samples
+        GROUP BY epoch, metro1, metro2, hour
+    ) inet
+        ON dz.epoch = inet.epoch
+        AND dz.metro1 = inet.metro1
+        AND dz.metro2 = inet.metro2
+        AND dz.hour = inet.hour
+),
+
+hourly_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        count() AS compared_hours,
+        countIf(dz_avg_rtt_ms < internet_avg_rtt_ms) AS dz_win_hours,
+        stddevPop(internet_avg_rtt_ms - dz_avg_rtt_ms) AS improvement_stddev_ms
+    FROM hourly
+    GROUP BY epoch, metro1, metro2
+)
+
+SELECT
+    dz.epoch AS epoch,
+    dz.metro1 AS origin_metro,
+    dz.metro2 AS target_metro,
+    least(dz.start_ts, inet.start_ts) AS start_ts,
+    greatest(dz.end_ts, inet.end_ts) AS end_ts,
+
+    -- DZ metrics
+    round(dz.p50_rtt_ms, 2) AS dz_p50_rtt_ms,
+    round(dz.p95_rtt_ms, 2) AS dz_p95_rtt_ms,
+    round(dz.p99_rtt_ms, 2) AS dz_p99_rtt_ms,
+    dz.sample_count AS dz_sample_count,
+
+    -- Internet metrics
+    round(inet.p50_rtt_ms, 2) AS internet_p50_rtt_ms,
+    round(inet.p95_rtt_ms, 2) AS internet_p95_rtt_ms,
+    round(inet.p99_rtt_ms, 2) AS internet_p99_rtt_ms,
+    inet.sample_count AS internet_sample_count,
+
+    -- Advantage (positive = DZ is faster)
+    round(inet.p50_rtt_ms - dz.p50_rtt_ms, 2) AS median_improvement_ms,
+    round((inet.p50_rtt_ms - dz.p50_rtt_ms) / inet.p50_rtt_ms * 100, 1) AS median_improvement_pct,
+    round(inet.p95_rtt_ms - dz.p95_rtt_ms, 2) AS p95_improvement_ms,
+    round((inet.p95_rtt_ms - dz.p95_rtt_ms) / inet.p95_rtt_ms * 100, 1) AS p95_improvement_pct,
+    round(inet.p99_rtt_ms - dz.p99_rtt_ms, 2) AS p99_improvement_ms,
+    round((inet.p99_rtt_ms - dz.p99_rtt_ms) / inet.p99_rtt_ms * 100, 1) AS p99_improvement_pct,
+
+    -- Consistency of the advantage across the epoch
+    COALESCE(h.compared_hours, 0) AS compared_hours,
+    if(h.compared_hours > 0, round(h.dz_win_hours * 100.0 / h.compared_hours, 1), NULL) AS dz_win_pct,
+    if(h.compared_hours > 0, round(h.improvement_stddev_ms, 2), NULL) AS improvement_stddev_ms
+
+FROM dz_epoch dz
+JOIN internet_epoch inet
+    ON dz.epoch = inet.epoch
+    AND dz.metro1 = inet.metro1
+    AND dz.metro2 = inet.metro2
+LEFT JOIN hourly_epoch h
+    ON dz.epoch = h.epoch
+    AND dz.metro1 = h.metro1
+    AND dz.metro2 = h.metro2
+ORDER BY origin_metro, target_metro, epoch;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS fact_dz_vs_internet_latency_epochs;
+-- +goose StatementEnd
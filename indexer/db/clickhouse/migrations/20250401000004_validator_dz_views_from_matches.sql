@@ -0,0 +1,374 @@
+-- +goose Up
+
+-- Solana Validator DZ Views from Matches
+-- The on-DZ, off-DZ, connection and disconnection views matched validators to users by gossip
+-- IP = DZ IP only, missing validators behind NAT or advertising another IP. They now read the
+-- validator matching stage's output (solana_validator_dz_matches_current for the current
+-- state, solana_validator_dz_match_periods for history), which applies every match method.
+-- Columns are unchanged, except that the on-DZ view also gains the match columns.
+
+-- +goose StatementBegin
+-- solana_validators_on_dz_current
+-- Validators currently matched to a DZ user, with a staked epoch vote account
+-- connected_ts: start of the current match period
+CREATE OR REPLACE VIEW solana_validators_on_dz_current
+AS
+SELECT
+    va.vote_pubkey AS vote_pubkey,
+    mt.node_pubkey AS node_pubkey,
+    mt.owner_pubkey AS owner_pubkey,
+    mt.dz_ip AS dz_ip,
+    mt.client_ip AS client_ip,
+    mt.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    va.activated_stake_lamports AS activated_stake_lamports,
+    va.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    va.commission_percentage AS commission_percentage,
+    va.epoch AS epoch,
+    p.connected_ts AS connected_ts,
+    mt.user_pk AS user_pk,
+    mt.match_method AS match_method,
+    mt.confidence AS match_confidence
+FROM solana_validator_dz_matches_current mt
+JOIN solana_vote_accounts_current va ON mt.node_pubkey = va.node_pubkey
+LEFT JOIN (
+    SELECT node_pubkey, connected_ts
+    FROM solana_validator_dz_match_periods
+    WHERE disconnected_ts IS NULL
+) p ON mt.node_pubkey = p.node_pubkey
+LEFT JOIN dz_devices_current d ON mt.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+WHERE va.epoch_vote_account = 'true'
+  AND va.activated_stake_lamports > 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- solana_validators_on_dz_connections
+-- When each validator first matched a DZ user, from its match periods
+-- Returns the latest match and stake/commission values (not values at connection time)
+CREATE OR REPLACE VIEW solana_validators_on_dz_connections
+AS
+WITH first_connections AS (
+    -- First match and latest matched user per validator
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        MIN(connected_ts) AS first_connected_ts,
+        argMax(owner_pubkey, connected_ts) AS owner_pubkey,
+        argMax(dz_ip, connected_ts) AS dz_ip,
+        argMax(device_pk, connected_ts) AS device_pk
+    FROM solana_validator_dz_match_periods
+    GROUP BY vote_pubkey, node_pubkey
+),
+latest_values AS (
+    -- Latest stake/commission values while the vote account was staked
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        activated_stake_lamports,
+        commission_percentage,
+        ROW_NUMBER() OVER (PARTITION BY vote_pubkey, node_pubkey ORDER BY snapshot_ts DESC, ingested_at DESC, op_id DESC) AS rn
+    FROM dim_solana_vote_accounts_history
+    WHERE is_deleted = 0 AND epoch_vote_account = 'true' AND activated_stake_lamports > 0
+)
+SELECT
+    fc.vote_pubkey AS vote_pubkey,
+    fc.node_pubkey AS node_pubkey,
+    fc.owner_pubkey AS owner_pubkey,
+    fc.dz_ip AS dz_ip,
+    fc.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    lv.activated_stake_lamports AS activated_stake_lamports,
+    lv.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    lv.commission_percentage AS commission_percentage,
+    fc.first_connected_ts AS first_connected_ts,
+    COALESCE(mt.user_pk, '') AS user_pk,
+    COALESCE(mt.match_method, '') AS match_method,
+    COALESCE(mt.confidence, 0) AS match_confidence
+FROM first_connections fc
+JOIN latest_values lv ON fc.vote_pubkey = lv.vote_pubkey AND fc.node_pubkey = lv.node_pubkey AND lv.rn = 1
+LEFT JOIN dz_devices_current d ON fc.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+LEFT JOIN solana_validator_dz_matches_current mt ON fc.node_pubkey = mt.node_pubkey;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- solana_validators_off_dz_current
+-- Staked validators not currently matched to a DZ user, with their geoip location
+CREATE OR REPLACE VIEW solana_validators_off_dz_current
+AS
+SELECT
+    va.vote_pubkey AS vote_pubkey,
+    va.node_pubkey AS node_pubkey,
+    va.activated_stake_lamports AS activated_stake_lamports,
+    va.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    va.commission_percentage AS commission_percentage,
+    va.epoch AS epoch,
+    gn.gossip_ip AS gossip_ip,
+    geo.city AS city,
+    geo.region AS region,
+    geo.country AS country,
+    geo.country_code AS country_code
+FROM solana_vote_accounts_current va
+JOIN solana_gossip_nodes_current gn ON va.node_pubkey = gn.pubkey
+LEFT JOIN geoip_records_current geo ON gn.gossip_ip = geo.ip
+WHERE va.epoch_vote_account = 'true'
+  AND va.activated_stake_lamports > 0
+  AND va.node_pubkey NOT IN (SELECT node_pubkey FROM solana_validator_dz_matches_current);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- solana_validators_disconnections
+-- Validators whose last match period ended and that aren't matched again
+-- Stake and commission are the latest staked values at or before the disconnection
+CREATE OR REPLACE VIEW solana_validators_disconnections
+AS
+WITH last_periods AS (
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        owner_pubkey,
+        dz_ip,
+        device_pk,
+        connected_ts,
+        assumeNotNull(disconnected_ts) AS disconnected_ts,
+        ROW_NUMBER() OVER (PARTITION BY node_pubkey ORDER BY connected_ts DESC) AS rn
+    FROM solana_validator_dz_match_periods
+    WHERE disconnected_ts IS NOT NULL
+),
+validator_disconnections AS (
+    SELECT
+        lp.vote_pubkey AS vote_pubkey,
+        lp.node_pubkey AS node_pubkey,
+        lp.owner_pubkey AS owner_pubkey,
+        lp.dz_ip AS dz_ip,
+        lp.device_pk AS device_pk,
+        argMax(va.activated_stake_lamports, va.snapshot_ts) AS activated_stake_lamports,
+        argMax(va.commission_percentage, va.snapshot_ts) AS commission_percentage,
+        lp.connected_ts AS connected_ts,
+        lp.disconnected_ts AS disconnected_ts
+    FROM last_periods lp
+    JOIN dim_solana_vote_accounts_history va
+        ON lp.vote_pubkey = va.vote_pubkey AND lp.node_pubkey = va.node_pubkey
+    WHERE lp.rn = 1
+      AND va.snapshot_ts <= lp.disconnected_ts
+      AND va.is_deleted = 0 AND va.epoch_vote_account = 'true' AND va.activated_stake_lamports > 0
+    GROUP BY lp.vote_pubkey, lp.node_pubkey, lp.owner_pubkey, lp.dz_ip, lp.device_pk, lp.connected_ts, lp.disconnected_ts
+)
+SELECT
+    vd.vote_pubkey AS vote_pubkey,
+    vd.node_pubkey AS node_pubkey,
+    vd.owner_pubkey AS owner_pubkey,
+    vd.dz_ip AS dz_ip,
+    vd.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    vd.activated_stake_lamports AS activated_stake_lamports,
+    vd.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    vd.commission_percentage AS commission_percentage,
+    vd.connected_ts AS connected_ts,
+    vd.disconnected_ts AS disconnected_ts
+FROM validator_disconnections vd
+LEFT JOIN dz_devices_current d ON vd.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+-- Excluding currently connected
+WHERE vd.node_pubkey NOT IN (SELECT node_pubkey FROM solana_validator_dz_matches_current);
+-- +goose StatementEnd
+
+-- +goose Down
+-- Restore the gossip IP = DZ IP definitions
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW solana_validators_disconnections
+AS
+WITH connection_events AS (
+    -- Find all times when a validator was connected (user, gossip node, and vote account all exist together)
+    SELECT
+        va.vote_pubkey,
+        va.node_pubkey,
+        u.owner_pubkey,
+        u.dz_ip,
+        u.device_pk,
+        u.entity_id AS user_entity_id,
+        va.activated_stake_lamports,
+        va.commission_percentage,
+        GREATEST(u.snapshot_ts, gn.snapshot_ts, va.snapshot_ts) AS connected_ts
+    FROM dim_dz_users_history u
+    JOIN dim_solana_gossip_nodes_history gn ON u.dz_ip = gn.gossip_ip AND gn.gossip_ip != ''
+    JOIN dim_solana_vote_accounts_history va ON gn.pubkey = va.node_pubkey
+    WHERE u.is_deleted = 0 AND u.status = 'activated' AND u.dz_ip != ''
+      AND gn.is_deleted = 0
+      AND va.is_deleted = 0 AND va.epoch_vote_account = 'true' AND va.activated_stake_lamports > 0
+),
+disconnection_events AS (
+    -- Find when users were deleted (disconnected)
+    SELECT
+        entity_id AS user_entity_id,
+        snapshot_ts AS disconnected_ts
+    FROM dim_dz_users_history
+    WHERE is_deleted = 1
+),
+validator_disconnections AS (
+    -- Join connection events with disconnection events
+    -- A validator disconnected if they had a connection and the user was later deleted
+    SELECT
+        ce.vote_pubkey,
+        ce.node_pubkey,
+        ce.owner_pubkey,
+        ce.dz_ip,
+        ce.device_pk,
+        ce.activated_stake_lamports,
+        ce.commission_percentage,
+        ce.connected_ts,
+        de.disconnected_ts,
+        ROW_NUMBER() OVER (PARTITION BY ce.vote_pubkey, ce.node_pubkey ORDER BY de.disconnected_ts DESC) AS rn
+    FROM connection_events ce
+    JOIN disconnection_events de ON ce.user_entity_id = de.user_entity_id
+    WHERE de.disconnected_ts > ce.connected_ts  -- Disconnection must be after connection
+)
+SELECT
+    vd.vote_pubkey AS vote_pubkey,
+    vd.node_pubkey AS node_pubkey,
+    vd.owner_pubkey AS owner_pubkey,
+    vd.dz_ip AS dz_ip,
+    vd.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    vd.activated_stake_lamports AS activated_stake_lamports,
+    vd.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    vd.commission_percentage AS commission_percentage,
+    vd.connected_ts AS connected_ts,
+    vd.disconnected_ts AS disconnected_ts
+FROM validator_disconnections vd
+LEFT JOIN dz_devices_current d ON vd.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+-- Most recent disconnection per validator, excluding currently connected
+WHERE vd.rn = 1
+  AND vd.vote_pubkey NOT IN (SELECT vote_pubkey FROM solana_validators_on_dz_current);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW solana_validators_off_dz_current
+AS
+SELECT
+    va.vote_pubkey AS vote_pubkey,
+    va.node_pubkey AS node_pubkey,
+    va.activated_stake_lamports AS activated_stake_lamports,
+    va.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    va.commission_percentage AS commission_percentage,
+    va.epoch AS epoch,
+    gn.gossip_ip AS gossip_ip,
+    geo.city AS city,
+    geo.region AS region,
+    geo.country AS country,
+    geo.country_code AS country_code
+FROM solana_vote_accounts_current va
+JOIN solana_gossip_nodes_current gn ON va.node_pubkey = gn.pubkey
+LEFT JOIN geoip_records_current geo ON gn.gossip_ip = geo.ip
+WHERE va.epoch_vote_account = 'true'
+  AND va.activated_stake_lamports > 0
+  AND va.vote_pubkey NOT IN (SELECT vote_pubkey FROM solana_validators_on_dz_current);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW solana_validators_on_dz_connections
+AS
+WITH connection_events AS (
+    -- Find all times when a validator was connected (user, gossip node, and vote account all exist together)
+    -- The connection timestamp is the maximum of the three snapshot_ts values
+    SELECT
+        va.vote_pubkey,
+        va.node_pubkey,
+        u.owner_pubkey,
+        u.dz_ip,
+        u.device_pk,
+        va.activated_stake_lamports,
+        va.commission_percentage,
+        GREATEST(u.snapshot_ts, gn.snapshot_ts, va.snapshot_ts) AS connected_ts
+    FROM dim_dz_users_history u
+    JOIN dim_solana_gossip_nodes_history gn ON u.dz_ip = gn.gossip_ip AND gn.gossip_ip != ''
+    JOIN dim_solana_vote_accounts_history va ON gn.pubkey = va.node_pubkey
+    WHERE u.is_deleted = 0 AND u.status = 'activated' AND u.dz_ip != ''
+      AND gn.is_deleted = 0
+      AND va.is_deleted = 0 AND va.epoch_vote_account = 'true' AND va.activated_stake_lamports > 0
+),
+first_connections AS (
+    -- Get first connection time per validator (GROUP BY only immutable identifiers)
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        MIN(connected_ts) AS first_connected_ts,
+        MAX(connected_ts) AS last_connected_ts
+    FROM connection_events
+    GROUP BY vote_pubkey, node_pubkey
+),
+latest_values AS (
+    -- Get latest stake/commission values per validator using row_number
+    SELECT
+        vote_pubkey,
+        node_pubkey,
+        owner_pubkey,
+        dz_ip,
+        device_pk,
+        activated_stake_lamports,
+        commission_percentage,
+        ROW_NUMBER() OVER (PARTITION BY vote_pubkey, node_pubkey ORDER BY connected_ts DESC) AS rn
+    FROM connection_events
+)
+SELECT
+    fc.vote_pubkey AS vote_pubkey,
+    fc.node_pubkey AS node_pubkey,
+    lv.owner_pubkey AS owner_pubkey,
+    lv.dz_ip AS dz_ip,
+    lv.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    lv.activated_stake_lamports AS activated_stake_lamports,
+    lv.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    lv.commission_percentage AS commission_percentage,
+    fc.first_connected_ts AS first_connected_ts,
+    COALESCE(mt.user_pk, '') AS user_pk,
+    COALESCE(mt.match_method, '') AS match_method,
+    COALESCE(mt.confidence, 0) AS match_confidence
+FROM first_connections fc
+JOIN latest_values lv ON fc.vote_pubkey = lv.vote_pubkey AND fc.node_pubkey = lv.node_pubkey AND lv.rn = 1
+LEFT JOIN dz_devices_current d ON lv.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+LEFT JOIN solana_validator_dz_matches_current mt ON fc.node_pubkey = mt.node_pubkey;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE VIEW solana_validators_on_dz_current
+AS
+SELECT
+    va.vote_pubkey AS vote_pubkey,
+    va.node_pubkey AS node_pubkey,
+    u.owner_pubkey AS owner_pubkey,
+    u.dz_ip AS dz_ip,
+    u.client_ip AS client_ip,
+    u.device_pk AS device_pk,
+    d.code AS device_code,
+    m.code AS device_metro_code,
+    m.name AS device_metro_name,
+    va.activated_stake_lamports AS activated_stake_lamports,
+    va.activated_stake_lamports / 1000000000.0 AS activated_stake_sol,
+    va.commission_percentage AS commission_percentage,
+    va.epoch AS epoch,
+    -- Connection timestamp is the latest of when each component appeared
+    GREATEST(u.snapshot_ts, gn.snapshot_ts, va.snapshot_ts) AS connected_ts
+FROM dz_users_current u
+JOIN solana_gossip_nodes_current gn ON u.dz_ip = gn.gossip_ip
+JOIN solana_vote_accounts_current va ON gn.pubkey = va.node_pubkey
+LEFT JOIN dz_devices_current d ON u.device_pk = d.pk
+LEFT JOIN dz_metros_current m ON d.metro_pk = m.pk
+WHERE u.status = 'activated'
+  AND u.dz_ip != ''
+  AND va.epoch_vote_account = 'true'
+  AND va.activated_stake_lamports > 0;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+package changes
+
+import (
+	"context"
+	"errors"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+)
+
+// MultiSink publishes events to every sink, returning the joined errors of those that fail.
+type MultiSink []dataset.ChangeSink
+
+func (m MultiSink) Publish(ctx context.Context, events []dataset.ChangeEvent) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Publish(ctx, events); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
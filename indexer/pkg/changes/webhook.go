@@ -0,0 +1,194 @@
+package changes
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/utils/pkg/retry"
+)
+
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the webhook
+	// secret, prefixed with "sha256=".
+	SignatureHeader = "X-Lake-Signature"
+	// TimestampHeader carries the unix time in seconds at which the delivery was signed.
+	TimestampHeader = "X-Lake-Timestamp"
+
+	defaultQueueSize      = 1024
+	defaultMaxBatchEvents = 500
+	defaultRequestTimeout = 10 * time.Second
+)
+
+// ErrQueueFull is returned by WebhookSink.Publish when deliveries are not keeping up.
+var ErrQueueFull = errors.New("webhook queue is full")
+
+// WebhookPayload is the JSON body POSTed to a webhook.
+type WebhookPayload struct {
+	Events []dataset.ChangeEvent `json:"events"`
+}
+
+type WebhookSinkConfig struct {
+	Logger *slog.Logger
+	URL    string
+	// Secret signs each delivery; deliveries are unsigned if empty
+	Secret     string
+	HTTPClient *http.Client
+	Retry      retry.Config
+	// QueueSize is the number of Publish calls buffered for delivery
+	QueueSize int
+	// MaxBatchEvents caps the number of events per request
+	MaxBatchEvents int
+}
+
+func (cfg *WebhookSinkConfig) Validate() error {
+	if cfg.Logger == nil {
+		return errors.New("logger is required")
+	}
+	if cfg.URL == "" {
+		return errors.New("url is required")
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: defaultRequestTimeout}
+	}
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry = retry.Config{
+			MaxAttempts: 5,
+			BaseBackoff: time.Second,
+			MaxBackoff:  30 * time.Second,
+		}
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.MaxBatchEvents <= 0 {
+		cfg.MaxBatchEvents = defaultMaxBatchEvents
+	}
+	return nil
+}
+
+// WebhookSink delivers change events to an HTTP endpoint in the background, retrying failed
+// deliveries with backoff. Publish only enqueues, so a slow endpoint never stalls ingestion;
+// events are dropped (and logged) once the queue is full or retries are exhausted.
+type WebhookSink struct {
+	log   *slog.Logger
+	cfg   WebhookSinkConfig
+	queue chan []dataset.ChangeEvent
+
+	wg sync.WaitGroup
+}
+
+func NewWebhookSink(cfg WebhookSinkConfig) (*WebhookSink, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &WebhookSink{
+		log:   cfg.Logger,
+		cfg:   cfg,
+		queue: make(chan []dataset.ChangeEvent, cfg.QueueSize),
+	}, nil
+}
+
+// Start delivers queued events until ctx is done. Events still queued at that point are
+// dropped.
+func (s *WebhookSink) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case events := <-s.queue:
+				for start := 0; start < len(events); start += s.cfg.MaxBatchEvents {
+					batch := events[start:min(start+s.cfg.MaxBatchEvents, len(events))]
+					if err := s.deliver(ctx, batch); err != nil {
+						s.log.Error("changes/webhook: dropping events after failed delivery", "url", s.cfg.URL, "events", len(batch), "error", err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Wait blocks until the delivery loop started by Start has exited.
+func (s *WebhookSink) Wait() {
+	s.wg.Wait()
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, events []dataset.ChangeEvent) error {
+	select {
+	case s.queue <- events:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (s *WebhookSink) deliver(ctx context.Context, events []dataset.ChangeEvent) error {
+	body, err := json.Marshal(WebhookPayload{Events: events})
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	return retry.Do(ctx, s.cfg.Retry, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if s.cfg.Secret != "" {
+			ts := strconv.FormatInt(time.Now().Unix(), 10)
+			req.Header.Set(TimestampHeader, ts)
+			req.Header.Set(SignatureHeader, "sha256="+Sign(s.cfg.Secret, ts, body))
+		}
+
+		resp, err := s.cfg.HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &statusError{code: resp.StatusCode}
+		}
+		return nil
+	})
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by secret. Receivers verify a
+// delivery by recomputing it from the TimestampHeader and raw body and comparing it to the
+// SignatureHeader in constant time.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// statusError is a non-2xx webhook response; retry.IsRetryable retries 429 and 5xx.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.code)
+}
+
+func (e *statusError) StatusCode() int {
+	return e.code
+}
@@ -0,0 +1,86 @@
+package changes
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/utils/pkg/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_Changes_WebhookSink_DeliversSignedEvents(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	received := make(chan WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		// Fail the first attempt to exercise retries
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		ts := r.Header.Get(TimestampHeader)
+		require.NotEmpty(t, ts)
+		require.Equal(t, "sha256="+Sign("secret", ts, body), r.Header.Get(SignatureHeader))
+
+		var payload WebhookPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookSinkConfig{
+		Logger: slog.New(slog.DiscardHandler),
+		URL:    srv.URL,
+		Secret: "secret",
+		Retry:  retry.Config{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	})
+	require.NoError(t, err)
+	sink.Start(t.Context())
+
+	event := dataset.ChangeEvent{
+		Kind:       dataset.ChangeKindDimension,
+		Dataset:    "dz_devices",
+		ChangeType: dataset.ChangeTypeCreated,
+		OpID:       uuid.New(),
+		EntityID:   "abc",
+	}
+	require.NoError(t, sink.Publish(t.Context(), []dataset.ChangeEvent{event}))
+
+	select {
+	case payload := <-received:
+		require.Len(t, payload.Events, 1)
+		require.Equal(t, event.OpID, payload.Events[0].OpID)
+		require.Equal(t, "dz_devices", payload.Events[0].Dataset)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestLake_Changes_WebhookSink_QueueFull(t *testing.T) {
+	t.Parallel()
+
+	sink, err := NewWebhookSink(WebhookSinkConfig{
+		Logger:    slog.New(slog.DiscardHandler),
+		URL:       "http://127.0.0.1:0",
+		QueueSize: 1,
+	})
+	require.NoError(t, err)
+
+	// Not started, so the second publish cannot be queued
+	require.NoError(t, sink.Publish(t.Context(), []dataset.ChangeEvent{{}}))
+	require.ErrorIs(t, sink.Publish(t.Context(), []dataset.ChangeEvent{{}}), ErrQueueFull)
+}
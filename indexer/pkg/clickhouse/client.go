@@ -25,6 +25,14 @@ func ContextWithSyncInsert(ctx context.Context) context.Context {
 	}))
 }
 
+// ContextWithSyncMutations returns a context that makes ALTER ... UPDATE/DELETE wait until the
+// mutation has finished on all replicas. Use this when later queries depend on the mutated rows.
+func ContextWithSyncMutations(ctx context.Context) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"mutations_sync": 2,
+	}))
+}
+
 // Client represents a ClickHouse database connection
 type Client interface {
 	Conn(ctx context.Context) (Connection, error)
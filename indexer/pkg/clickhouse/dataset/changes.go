@@ -0,0 +1,213 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+)
+
+// FactChangeLogTableName is the table that records one row per fact write of the datasets that
+// opt in with FactDataset.RecordChanges.
+const FactChangeLogTableName = "fact_change_log"
+
+// Change kinds published to a ChangeSink.
+const (
+	ChangeKindDimension = "dimension"
+	ChangeKindFact      = "fact"
+)
+
+// ChangeTypeAppended is the change type of a fact write.
+const ChangeTypeAppended = "appended"
+
+// ChangeCommitLag is how far behind the ClickHouse clock ReadChanges stays. committed_at is
+// taken just before a change log insert, so a row can become visible after rows with a later
+// committed_at; holding back the most recent rows keeps a cursor from passing one that is
+// still being inserted.
+var ChangeCommitLag = 30 * time.Second
+
+// ChangeSink receives change events once the write that produced them is committed.
+// Implementations should not block for long: publish errors are logged, never returned to the
+// writer, so a sink that falls behind loses events rather than stalling ingestion. Consumers
+// that need every event resume from the change log tables by cursor.
+type ChangeSink interface {
+	Publish(ctx context.Context, events []ChangeEvent) error
+}
+
+// ChangeEvent is a single change published to a ChangeSink: one per entity created, updated,
+// or deleted by a dimension write, and one per fact write.
+type ChangeEvent struct {
+	Cursor      string    `json:"cursor"`
+	Kind        string    `json:"kind"`
+	Dataset     string    `json:"dataset"`
+	ChangeType  string    `json:"change_type"`
+	OpID        uuid.UUID `json:"op_id"`
+	SnapshotTS  time.Time `json:"snapshot_ts"`
+	IngestedAt  time.Time `json:"ingested_at"`
+	CommittedAt time.Time `json:"committed_at"`
+
+	// Dimension changes only
+	EntityID SurrogateKey   `json:"entity_id,omitempty"`
+	Changes  []FieldChange  `json:"changes,omitempty"`
+	Attrs    map[string]any `json:"attrs,omitempty"`
+
+	// Fact writes only: row count and the range of the dataset's time column
+	Rows    uint64     `json:"rows,omitempty"`
+	MinTime *time.Time `json:"min_time,omitempty"`
+	MaxTime *time.Time `json:"max_time,omitempty"`
+}
+
+// ChangeCursor is the position of a change in the change stream. Changes are ordered by
+// committed_at, then op_id, then entity_id (empty for fact writes). Ordering by commit rather
+// than snapshot time means a slow writer's rows land ahead of existing cursors instead of
+// behind them.
+type ChangeCursor struct {
+	CommittedAt time.Time
+	OpID        uuid.UUID
+	EntityID    SurrogateKey
+}
+
+// String encodes the cursor as "<committed_at unix ms>.<op_id>.<entity_id>".
+func (c ChangeCursor) String() string {
+	return fmt.Sprintf("%d.%s.%s", c.CommittedAt.UnixMilli(), c.OpID, c.EntityID)
+}
+
+// ParseChangeCursor decodes a cursor produced by ChangeCursor.String.
+func ParseChangeCursor(s string) (ChangeCursor, error) {
+	parts := strings.SplitN(s, ".", 3)
+	if len(parts) != 3 {
+		return ChangeCursor{}, fmt.Errorf("invalid change cursor %q", s)
+	}
+	ms, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("invalid change cursor timestamp %q: %w", parts[0], err)
+	}
+	opID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return ChangeCursor{}, fmt.Errorf("invalid change cursor op_id %q: %w", parts[1], err)
+	}
+	return ChangeCursor{
+		CommittedAt: time.UnixMilli(ms).UTC(),
+		OpID:        opID,
+		EntityID:    SurrogateKey(parts[2]),
+	}, nil
+}
+
+// ChangeEventFromEntry converts a dimension change log entry to a change event.
+func ChangeEventFromEntry(e ChangeLogEntry) ChangeEvent {
+	committedAt := e.CommittedAt
+	if committedAt.IsZero() {
+		committedAt = e.SnapshotTS
+	}
+	return ChangeEvent{
+		Cursor:      ChangeCursor{CommittedAt: committedAt, OpID: e.OpID, EntityID: e.EntityID}.String(),
+		Kind:        ChangeKindDimension,
+		Dataset:     e.Dataset,
+		ChangeType:  e.ChangeType,
+		OpID:        e.OpID,
+		SnapshotTS:  e.SnapshotTS,
+		IngestedAt:  e.IngestedAt,
+		CommittedAt: committedAt,
+		EntityID:    e.EntityID,
+		Changes:     e.Changes,
+		Attrs:       e.Attrs,
+	}
+}
+
+// publishChanges sends events to sink, logging rather than returning failures since the
+// change is already committed.
+func publishChanges(ctx context.Context, log *slog.Logger, sink ChangeSink, dataset string, events []ChangeEvent) {
+	if sink == nil || len(events) == 0 {
+		return
+	}
+	if err := sink.Publish(ctx, events); err != nil {
+		log.Warn("failed to publish change events", "dataset", dataset, "events", len(events), "error", err)
+	}
+}
+
+// ReadChanges returns up to limit changes after the cursor from dim_change_log and
+// fact_change_log, in cursor order. If datasets is non-empty, only those datasets are read.
+// Changes committed within ChangeCommitLag of the ClickHouse clock are held back until a
+// later call.
+func ReadChanges(ctx context.Context, conn clickhouse.Connection, after ChangeCursor, datasets []string, limit int) ([]ChangeEvent, error) {
+	datasetFilter := ""
+	if len(datasets) > 0 {
+		datasetFilter = "AND has(?, dataset)"
+	}
+	query := fmt.Sprintf(`
+		SELECT kind, dataset, entity_id, op_id, snapshot_ts, ingested_at, committed_at, change_type, changes, attrs, row_count, min_time, max_time
+		FROM (
+			SELECT
+				'%s' AS kind, dataset, entity_id, op_id, snapshot_ts, ingested_at, committed_at, change_type, changes, attrs,
+				toUInt64(0) AS row_count,
+				CAST(NULL, 'Nullable(DateTime64(3))') AS min_time,
+				CAST(NULL, 'Nullable(DateTime64(3))') AS max_time
+			FROM %s
+			WHERE (committed_at, toString(op_id), entity_id) > (toDateTime64(?, 3), ?, ?)
+			  AND committed_at <= now64(3) - toIntervalMillisecond(?) %s
+			UNION ALL
+			SELECT
+				'%s' AS kind, dataset, '' AS entity_id, op_id, snapshot_ts, snapshot_ts AS ingested_at, committed_at, '%s' AS change_type, '[]' AS changes, '{}' AS attrs,
+				row_count, min_time, max_time
+			FROM %s
+			WHERE (committed_at, toString(op_id), '') > (toDateTime64(?, 3), ?, ?)
+			  AND committed_at <= now64(3) - toIntervalMillisecond(?) %s
+		)
+		ORDER BY committed_at, toString(op_id), entity_id
+		LIMIT ?
+	`, ChangeKindDimension, ChangeLogTableName, datasetFilter, ChangeKindFact, ChangeTypeAppended, FactChangeLogTableName, datasetFilter)
+
+	if after.CommittedAt.IsZero() {
+		after.CommittedAt = time.UnixMilli(0).UTC()
+	}
+	cursorArgs := []any{after.CommittedAt, after.OpID.String(), string(after.EntityID), ChangeCommitLag.Milliseconds()}
+	var args []any
+	for range 2 {
+		args = append(args, cursorArgs...)
+		if len(datasets) > 0 {
+			args = append(args, datasets)
+		}
+	}
+	args = append(args, limit)
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query changes: %w", err)
+	}
+	defer rows.Close()
+
+	var events []ChangeEvent
+	for rows.Next() {
+		var (
+			e                ChangeEvent
+			entityID         string
+			changes, attrs   string
+			minTime, maxTime *time.Time
+		)
+		if err := rows.Scan(&e.Kind, &e.Dataset, &entityID, &e.OpID, &e.SnapshotTS, &e.IngestedAt, &e.CommittedAt, &e.ChangeType, &changes, &attrs, &e.Rows, &minTime, &maxTime); err != nil {
+			return nil, fmt.Errorf("failed to scan change: %w", err)
+		}
+		e.EntityID = SurrogateKey(entityID)
+		e.MinTime, e.MaxTime = minTime, maxTime
+		if e.Kind == ChangeKindDimension {
+			if err := json.Unmarshal([]byte(changes), &e.Changes); err != nil {
+				return nil, fmt.Errorf("failed to decode changes of %s: %w", entityID, err)
+			}
+			if err := json.Unmarshal([]byte(attrs), &e.Attrs); err != nil {
+				return nil, fmt.Errorf("failed to decode attrs of %s: %w", entityID, err)
+			}
+		}
+		e.Cursor = ChangeCursor{CommittedAt: e.CommittedAt, OpID: e.OpID, EntityID: e.EntityID}.String()
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating changes: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,470 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+)
+
+// ChangeLogTableName is the table shared by all dimension datasets that records one row per
+// entity created, updated, or deleted by WriteBatch.
+const ChangeLogTableName = "dim_change_log"
+
+// Change types recorded in the change log.
+const (
+	ChangeTypeCreated = "created"
+	ChangeTypeUpdated = "updated"
+	ChangeTypeDeleted = "deleted"
+)
+
+// ChangeLogBackfillsTableName is the table that records the progress of BackfillChangeLog for
+// each dataset.
+const ChangeLogBackfillsTableName = "dim_change_log_backfills"
+
+// changeLogBatchSize bounds the number of entries sent in a single insert during backfill.
+const changeLogBatchSize = 10000
+
+// FieldChange is a payload column whose value changed between two versions of an entity.
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue any    `json:"old_value"`
+	NewValue any    `json:"new_value"`
+}
+
+// ChangeLogEntry is a single row of the change log.
+type ChangeLogEntry struct {
+	Dataset    string
+	EntityID   SurrogateKey
+	OpID       uuid.UUID
+	SnapshotTS time.Time
+	IngestedAt time.Time
+	// CommittedAt is when the entry was written to the change log (the changes stream cursor)
+	// Zero means SnapshotTS, as for entries backfilled from history
+	CommittedAt time.Time
+	ChangeType  string
+	// Changes lists the payload columns that changed, in schema order (updates only)
+	Changes []FieldChange
+	// Attrs holds the PK and payload columns of the entity after the change
+	// For deletes this is the last known state
+	Attrs map[string]any
+}
+
+// ChangedFields returns the names of the changed columns.
+func (e ChangeLogEntry) ChangedFields() []string {
+	fields := make([]string, 0, len(e.Changes))
+	for _, c := range e.Changes {
+		fields = append(fields, c.Field)
+	}
+	return fields
+}
+
+// DiffVersions compares the previous version of an entity (nil if it never existed) with the
+// next history row and returns the change log entry describing the transition.
+// Returns false if the transition is not a visible change (e.g. a tombstone for an entity
+// that was already deleted, or a row whose payload is unchanged).
+func (d *DimensionType2Dataset) DiffVersions(prev, curr map[string]any) (ChangeLogEntry, bool) {
+	entry := ChangeLogEntry{
+		Dataset:    d.schema.Name(),
+		EntityID:   SurrogateKey(fmt.Sprint(curr["entity_id"])),
+		SnapshotTS: toTime(curr["snapshot_ts"]),
+		IngestedAt: toTime(curr["ingested_at"]),
+		Attrs:      make(map[string]any, len(d.pkCols)+len(d.payloadCols)),
+	}
+	if opID, ok := curr["op_id"].(uuid.UUID); ok {
+		entry.OpID = opID
+	}
+	for _, col := range d.pkCols {
+		entry.Attrs[col] = curr[col]
+	}
+	for _, col := range d.payloadCols {
+		entry.Attrs[col] = curr[col]
+	}
+
+	prevExists := prev != nil && !isDeletedRow(prev)
+	switch {
+	case isDeletedRow(curr):
+		if !prevExists {
+			return ChangeLogEntry{}, false
+		}
+		entry.ChangeType = ChangeTypeDeleted
+	case !prevExists:
+		entry.ChangeType = ChangeTypeCreated
+	default:
+		entry.ChangeType = ChangeTypeUpdated
+		entry.Changes = d.DiffColumns(prev, curr)
+		if len(entry.Changes) == 0 {
+			return ChangeLogEntry{}, false
+		}
+	}
+
+	return entry, true
+}
+
+// DiffColumns returns the payload columns whose values differ between two rows of this
+// dataset, in schema order.
+func (d *DimensionType2Dataset) DiffColumns(before, after map[string]any) []FieldChange {
+	var changes []FieldChange
+	for _, col := range d.payloadCols {
+		if !valuesEqual(before[col], after[col]) {
+			changes = append(changes, FieldChange{Field: col, OldValue: before[col], NewValue: after[col]})
+		}
+	}
+	return changes
+}
+
+// writeChangeLog appends change log entries for the rows written to history by opID.
+// Safe to call more than once for the same op_id: entries are only written if none exist yet.
+// The initial load of a dataset (no prior history) is not logged, since every entity would
+// otherwise show up as created. Returns the entries written, if any.
+func (d *DimensionType2Dataset) writeChangeLog(ctx context.Context, conn clickhouse.Connection, opID uuid.UUID, snapshotTS time.Time) ([]ChangeLogEntry, error) {
+	logged, err := d.countRows(ctx, conn, fmt.Sprintf(`
+		SELECT count()
+		FROM %s
+		WHERE dataset = ? AND snapshot_ts = ? AND op_id = ?
+	`, ChangeLogTableName), d.schema.Name(), snapshotTS, opID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check change log: %w", err)
+	}
+	if logged > 0 {
+		return nil, nil
+	}
+
+	allCols, err := d.AllColumns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract all columns: %w", err)
+	}
+
+	written, err := scanRows(ctx, conn, fmt.Sprintf(`
+		SELECT %s
+		FROM %s
+		WHERE snapshot_ts = ? AND op_id = ?
+	`, strings.Join(allCols, ", "), d.HistoryTableName()),
+		[]any{snapshotTS, opID}, mapScanner, "failed to query written rows")
+	if err != nil {
+		return nil, err
+	}
+	if len(written) == 0 {
+		return nil, nil
+	}
+
+	// Previous version of each written entity: latest row at or before the snapshot from another op
+	previous, err := scanRows(ctx, conn, fmt.Sprintf(`
+		SELECT %s
+		FROM %s h
+		WHERE h.snapshot_ts <= ? AND h.op_id != ?
+		  AND h.entity_id IN (SELECT entity_id FROM %s WHERE snapshot_ts = ? AND op_id = ?)
+		GROUP BY h.entity_id
+	`, d.buildArgMaxSelect("h"), d.HistoryTableName(), d.HistoryTableName()),
+		[]any{snapshotTS, opID, snapshotTS, opID}, mapScanner, "failed to query previous rows")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(previous) == 0 {
+		others, err := d.countRows(ctx, conn, fmt.Sprintf(`
+			SELECT count()
+			FROM %s
+			WHERE op_id != ?
+		`, d.HistoryTableName()), opID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check prior history: %w", err)
+		}
+		if others == 0 {
+			d.log.Debug("skipping change log for initial load", "dataset", d.schema.Name(), "op_id", opID)
+			return nil, nil
+		}
+	}
+
+	prevByEntity := make(map[string]map[string]any, len(previous))
+	for _, row := range previous {
+		prevByEntity[fmt.Sprint(row["entity_id"])] = row
+	}
+
+	committedAt := time.Now().UTC().Truncate(time.Millisecond)
+	entries := make([]ChangeLogEntry, 0, len(written))
+	for _, row := range written {
+		if entry, ok := d.DiffVersions(prevByEntity[fmt.Sprint(row["entity_id"])], row); ok {
+			entry.CommittedAt = committedAt
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := insertChangeLog(ctx, conn, entries); err != nil {
+		return nil, err
+	}
+
+	d.log.Debug("wrote change log", "dataset", d.schema.Name(), "entries", len(entries), "op_id", opID)
+	return entries, nil
+}
+
+// BackfillChangeLog populates the change log for this dataset from the history that predates
+// it: versions before the first entry WriteBatch logged, or all of history if there is none.
+// Progress is recorded in dim_change_log_backfills, so a finished backfill is never repeated
+// and an interrupted one starts over from the same cutoff, making this safe to call on every
+// startup. Creations at the dataset's first snapshot (the initial load) are not logged.
+// Returns the number of entries written.
+func (d *DimensionType2Dataset) BackfillChangeLog(ctx context.Context, conn clickhouse.Connection) (int, error) {
+	cutoff, completed, err := d.changeLogBackfillState(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if completed {
+		return 0, nil
+	}
+
+	if cutoff.IsZero() {
+		rows, err := conn.Query(ctx, fmt.Sprintf(`
+			SELECT count(), min(snapshot_ts)
+			FROM %s
+			WHERE dataset = ?
+		`, ChangeLogTableName), d.schema.Name())
+		if err != nil {
+			return 0, fmt.Errorf("failed to check change log: %w", err)
+		}
+		var (
+			logged   uint64
+			firstLog time.Time
+		)
+		if rows.Next() {
+			if err := rows.Scan(&logged, &firstLog); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to scan change log bounds: %w", err)
+			}
+		}
+		rows.Close()
+
+		cutoff = time.Now().UTC().Truncate(time.Millisecond)
+		if logged > 0 {
+			cutoff = firstLog
+		}
+		if err := d.recordChangeLogBackfill(ctx, conn, cutoff, false, 0); err != nil {
+			return 0, err
+		}
+	} else {
+		// Resuming an interrupted backfill: drop what it wrote before writing it again
+		if err := conn.Exec(ctx, fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE dataset = ? AND snapshot_ts < ?
+		`, ChangeLogTableName), d.schema.Name(), cutoff); err != nil {
+			return 0, fmt.Errorf("failed to clear partial backfill: %w", err)
+		}
+	}
+
+	allCols, err := d.AllColumns()
+	if err != nil {
+		return 0, fmt.Errorf("failed to extract all columns: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+		SELECT %s, min(snapshot_ts) OVER () AS first_snapshot_ts
+		FROM %s
+		WHERE snapshot_ts < ?
+		ORDER BY entity_id, snapshot_ts, ingested_at, op_id
+	`, strings.Join(allCols, ", "), d.HistoryTableName()), cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	columns := rows.Columns()
+	valuePtrs, _ := InitializeScanTargets(rows.ColumnTypes())
+
+	var (
+		prev    map[string]any
+		entries []ChangeLogEntry
+		total   int
+	)
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return total, fmt.Errorf("failed to scan history row: %w", err)
+		}
+		curr := dereferencePointersToMap(valuePtrs, columns)
+		if prev != nil && prev["entity_id"] != curr["entity_id"] {
+			prev = nil
+		}
+
+		entry, ok := d.DiffVersions(prev, curr)
+		if ok && !(entry.ChangeType == ChangeTypeCreated && entry.SnapshotTS.Equal(toTime(curr["first_snapshot_ts"]))) {
+			entries = append(entries, entry)
+		}
+		prev = curr
+
+		if len(entries) >= changeLogBatchSize {
+			if err := insertChangeLog(ctx, conn, entries); err != nil {
+				return total, err
+			}
+			total += len(entries)
+			entries = entries[:0]
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return total, fmt.Errorf("error iterating history rows: %w", err)
+	}
+
+	if err := insertChangeLog(ctx, conn, entries); err != nil {
+		return total, err
+	}
+	total += len(entries)
+
+	if err := d.recordChangeLogBackfill(ctx, conn, cutoff, true, uint64(total)); err != nil {
+		return total, err
+	}
+
+	d.log.Info("backfilled change log", "dataset", d.schema.Name(), "entries", total, "cutoff", cutoff)
+	return total, nil
+}
+
+// changeLogBackfillState returns the cutoff of the latest recorded backfill of this dataset and
+// whether it completed. The cutoff is zero if no backfill has been started.
+func (d *DimensionType2Dataset) changeLogBackfillState(ctx context.Context, conn clickhouse.Connection) (time.Time, bool, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+		SELECT argMax(cutoff_ts, recorded_at), argMax(completed, recorded_at), count()
+		FROM %s
+		WHERE dataset = ?
+	`, ChangeLogBackfillsTableName), d.schema.Name())
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to query change log backfill state: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		cutoff    time.Time
+		completed uint8
+		recorded  uint64
+	)
+	if rows.Next() {
+		if err := rows.Scan(&cutoff, &completed, &recorded); err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to scan change log backfill state: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return time.Time{}, false, fmt.Errorf("error iterating change log backfill state: %w", err)
+	}
+	if recorded == 0 {
+		return time.Time{}, false, nil
+	}
+	return cutoff.UTC(), completed == 1, nil
+}
+
+// recordChangeLogBackfill appends a progress row for this dataset's backfill.
+func (d *DimensionType2Dataset) recordChangeLogBackfill(ctx context.Context, conn clickhouse.Connection, cutoff time.Time, completed bool, entries uint64) error {
+	var completedFlag uint8
+	if completed {
+		completedFlag = 1
+	}
+	if err := conn.Exec(clickhouse.ContextWithSyncInsert(ctx), fmt.Sprintf(`
+		INSERT INTO %s (dataset, cutoff_ts, completed, entries, recorded_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, ChangeLogBackfillsTableName), d.schema.Name(), cutoff, completedFlag, entries, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record change log backfill: %w", err)
+	}
+	return nil
+}
+
+// insertChangeLog writes entries to the change log table.
+func insertChangeLog(ctx context.Context, conn clickhouse.Connection, entries []ChangeLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	syncCtx := clickhouse.ContextWithSyncInsert(ctx)
+	batch, err := conn.PrepareBatch(syncCtx, fmt.Sprintf(`
+		INSERT INTO %s (dataset, entity_id, op_id, snapshot_ts, ingested_at, committed_at, change_type, changed_fields, changes, attrs)
+	`, ChangeLogTableName))
+	if err != nil {
+		return fmt.Errorf("failed to prepare change log batch: %w", err)
+	}
+	defer batch.Close()
+
+	for _, e := range entries {
+		changes := make([]FieldChange, 0, len(e.Changes))
+		for _, c := range e.Changes {
+			changes = append(changes, FieldChange{Field: c.Field, OldValue: jsonSafe(c.OldValue), NewValue: jsonSafe(c.NewValue)})
+		}
+		changesJSON, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to encode changes for %s: %w", e.EntityID, err)
+		}
+
+		attrs := make(map[string]any, len(e.Attrs))
+		for k, v := range e.Attrs {
+			attrs[k] = jsonSafe(v)
+		}
+		attrsJSON, err := json.Marshal(attrs)
+		if err != nil {
+			return fmt.Errorf("failed to encode attrs for %s: %w", e.EntityID, err)
+		}
+
+		committedAt := e.CommittedAt
+		if committedAt.IsZero() {
+			committedAt = e.SnapshotTS
+		}
+		if err := batch.Append(e.Dataset, string(e.EntityID), e.OpID, e.SnapshotTS, e.IngestedAt, committedAt, e.ChangeType, e.ChangedFields(), string(changesJSON), string(attrsJSON)); err != nil {
+			return fmt.Errorf("failed to append change log entry: %w", err)
+		}
+	}
+
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send change log batch: %w", err)
+	}
+	return nil
+}
+
+// countRows runs a single-value count query.
+func (d *DimensionType2Dataset) countRows(ctx context.Context, conn clickhouse.Connection, query string, args ...any) (uint64, error) {
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var count uint64
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, err
+		}
+	}
+	return count, rows.Err()
+}
+
+func isDeletedRow(row map[string]any) bool {
+	v, ok := row["is_deleted"].(uint8)
+	return ok && v == 1
+}
+
+func toTime(v any) time.Time {
+	t, _ := v.(time.Time)
+	return t
+}
+
+// valuesEqual compares two scanned column values, treating timestamps by instant.
+func valuesEqual(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// jsonSafe replaces values that encoding/json cannot represent (NaN and infinite floats) with nil.
+func jsonSafe(v any) any {
+	switch f := v.(type) {
+	case float64:
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil
+		}
+	case float32:
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return nil
+		}
+	}
+	return v
+}
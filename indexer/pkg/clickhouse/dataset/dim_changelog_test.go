@@ -0,0 +1,260 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/stretchr/testify/require"
+)
+
+type changeLogRow struct {
+	entityID      string
+	changeType    string
+	snapshotTS    time.Time
+	changedFields []string
+	changes       []FieldChange
+	attrs         map[string]any
+}
+
+func queryChangeLog(t *testing.T, ctx context.Context, conn clickhouse.Connection, dataset string) []changeLogRow {
+	rows, err := conn.Query(ctx, `
+		SELECT entity_id, change_type, snapshot_ts, changed_fields, changes, attrs
+		FROM dim_change_log
+		WHERE dataset = ?
+		ORDER BY snapshot_ts, entity_id
+	`, dataset)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var result []changeLogRow
+	for rows.Next() {
+		var r changeLogRow
+		var changes, attrs string
+		require.NoError(t, rows.Scan(&r.entityID, &r.changeType, &r.snapshotTS, &r.changedFields, &changes, &attrs))
+		require.NoError(t, json.Unmarshal([]byte(changes), &r.changes))
+		require.NoError(t, json.Unmarshal([]byte(attrs), &r.attrs))
+		result = append(result, r)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_ChangeLog(t *testing.T) {
+	t.Parallel()
+	log := testLogger()
+	conn := testConn(t)
+	createSinglePKTables(t, conn)
+
+	ctx := t.Context()
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	t3 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ds, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+	require.NoError(t, err)
+
+	write := func(snapshotTS time.Time, opID uuid.UUID, rows [][]any) {
+		err := ds.WriteBatch(ctx, conn, len(rows), func(i int) ([]any, error) {
+			return rows[i], nil
+		}, &DimensionType2DatasetWriteConfig{
+			SnapshotTS:          snapshotTS,
+			OpID:                opID,
+			MissingMeansDeleted: true,
+		})
+		require.NoError(t, err)
+	}
+
+	// Initial load is not logged
+	write(t1, uuid.New(), [][]any{
+		{"entity1", "CODE1", "Name1"},
+		{"entity2", "CODE2", "Name2"},
+	})
+	require.Empty(t, queryChangeLog(t, ctx, conn, "test_single_pk"))
+
+	// entity1 renamed, entity2 removed, entity3 added
+	op2 := uuid.New()
+	write(t2, op2, [][]any{
+		{"entity1", "CODE1", "Renamed1"},
+		{"entity3", "CODE3", "Name3"},
+	})
+
+	entries := queryChangeLog(t, ctx, conn, "test_single_pk")
+	require.Len(t, entries, 3)
+
+	byEntity := make(map[string]changeLogRow)
+	for _, e := range entries {
+		require.True(t, e.snapshotTS.Equal(t2))
+		byEntity[e.entityID] = e
+	}
+
+	updated := byEntity[string(NewNaturalKey("entity1").ToSurrogate())]
+	require.Equal(t, ChangeTypeUpdated, updated.changeType)
+	require.Equal(t, []string{"name"}, updated.changedFields)
+	require.Equal(t, []FieldChange{{Field: "name", OldValue: "Name1", NewValue: "Renamed1"}}, updated.changes)
+	require.Equal(t, "entity1", updated.attrs["pk"])
+	require.Equal(t, "Renamed1", updated.attrs["name"])
+
+	deleted := byEntity[string(NewNaturalKey("entity2").ToSurrogate())]
+	require.Equal(t, ChangeTypeDeleted, deleted.changeType)
+	require.Empty(t, deleted.changes)
+	require.Equal(t, "Name2", deleted.attrs["name"])
+
+	created := byEntity[string(NewNaturalKey("entity3").ToSurrogate())]
+	require.Equal(t, ChangeTypeCreated, created.changeType)
+	require.Equal(t, "CODE3", created.attrs["code"])
+
+	// Retrying the same op does not duplicate entries
+	write(t2, op2, [][]any{
+		{"entity1", "CODE1", "Renamed1"},
+		{"entity3", "CODE3", "Name3"},
+	})
+	require.Len(t, queryChangeLog(t, ctx, conn, "test_single_pk"), 3)
+
+	// Empty snapshot deletes everything that is left
+	err = ds.WriteBatch(ctx, conn, 0, nil, &DimensionType2DatasetWriteConfig{
+		SnapshotTS:          t3,
+		MissingMeansDeleted: true,
+	})
+	require.NoError(t, err)
+
+	entries = queryChangeLog(t, ctx, conn, "test_single_pk")
+	require.Len(t, entries, 5)
+	for _, e := range entries[3:] {
+		require.Equal(t, ChangeTypeDeleted, e.changeType)
+		require.True(t, e.snapshotTS.Equal(t3))
+	}
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_BackfillChangeLog(t *testing.T) {
+	t.Parallel()
+	log := testLogger()
+	conn := testConn(t)
+	createSinglePKTables(t, conn)
+
+	ctx := t.Context()
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+
+	ds, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+	require.NoError(t, err)
+
+	for _, snapshot := range []struct {
+		ts   time.Time
+		rows [][]any
+	}{
+		{t1, [][]any{{"entity1", "CODE1", "Name1"}, {"entity2", "CODE2", "Name2"}}},
+		{t2, [][]any{{"entity1", "CODE1-B", "Name1"}, {"entity3", "CODE3", "Name3"}}},
+	} {
+		err := ds.WriteBatch(ctx, conn, len(snapshot.rows), func(i int) ([]any, error) {
+			return snapshot.rows[i], nil
+		}, &DimensionType2DatasetWriteConfig{
+			SnapshotTS:          snapshot.ts,
+			MissingMeansDeleted: true,
+		})
+		require.NoError(t, err)
+	}
+
+	written := queryChangeLog(t, ctx, conn, "test_single_pk")
+	require.Len(t, written, 3)
+
+	// Rebuild the log from history and compare with what WriteBatch recorded
+	require.NoError(t, conn.Exec(ctx, "TRUNCATE TABLE dim_change_log"))
+	count, err := ds.BackfillChangeLog(ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	require.Equal(t, written, queryChangeLog(t, ctx, conn, "test_single_pk"))
+
+	// Backfill is a no-op once it has completed, even if the log is emptied again
+	require.NoError(t, conn.Exec(ctx, "TRUNCATE TABLE dim_change_log"))
+	count, err = ds.BackfillChangeLog(ctx, conn)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	require.Empty(t, queryChangeLog(t, ctx, conn, "test_single_pk"))
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_BackfillChangeLog_BeforeFirstEntry(t *testing.T) {
+	t.Parallel()
+	log := testLogger()
+	conn := testConn(t)
+	createSinglePKTables(t, conn)
+
+	ctx := t.Context()
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	t3 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ds, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+	require.NoError(t, err)
+
+	for _, snapshot := range []struct {
+		ts   time.Time
+		rows [][]any
+	}{
+		{t1, [][]any{{"entity1", "CODE1", "Name1"}, {"entity2", "CODE2", "Name2"}}},
+		{t2, [][]any{{"entity1", "CODE1-B", "Name1"}, {"entity2", "CODE2", "Name2"}}},
+		{t3, [][]any{{"entity1", "CODE1-B", "Name1"}, {"entity2", "CODE2-B", "Name2"}}},
+	} {
+		err := ds.WriteBatch(ctx, conn, len(snapshot.rows), func(i int) ([]any, error) {
+			return snapshot.rows[i], nil
+		}, &DimensionType2DatasetWriteConfig{
+			SnapshotTS:          snapshot.ts,
+			MissingMeansDeleted: true,
+		})
+		require.NoError(t, err)
+	}
+
+	written := queryChangeLog(t, ctx, conn, "test_single_pk")
+	require.Len(t, written, 2)
+
+	// The log starts at t3, as if change logging was enabled after the t2 write
+	require.NoError(t, conn.Exec(ctx, "DELETE FROM dim_change_log WHERE snapshot_ts < ?", t3))
+
+	count, err := ds.BackfillChangeLog(ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.Equal(t, written, queryChangeLog(t, ctx, conn, "test_single_pk"))
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_DiffVersions(t *testing.T) {
+	t.Parallel()
+	ds, err := NewDimensionType2Dataset(testLogger(), &testSchemaSinglePK{})
+	require.NoError(t, err)
+
+	row := func(isDeleted uint8, code, name string) map[string]any {
+		return map[string]any{
+			"entity_id":   "e1",
+			"snapshot_ts": time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			"is_deleted":  isDeleted,
+			"pk":          "entity1",
+			"code":        code,
+			"name":        name,
+		}
+	}
+
+	entry, ok := ds.DiffVersions(nil, row(0, "A", "a"))
+	require.True(t, ok)
+	require.Equal(t, ChangeTypeCreated, entry.ChangeType)
+
+	entry, ok = ds.DiffVersions(row(1, "A", "a"), row(0, "A", "a"))
+	require.True(t, ok)
+	require.Equal(t, ChangeTypeCreated, entry.ChangeType, "recreation after delete is a create")
+
+	entry, ok = ds.DiffVersions(row(0, "A", "a"), row(0, "B", "b"))
+	require.True(t, ok)
+	require.Equal(t, ChangeTypeUpdated, entry.ChangeType)
+	require.Equal(t, []string{"code", "name"}, entry.ChangedFields())
+
+	_, ok = ds.DiffVersions(row(0, "A", "a"), row(0, "A", "a"))
+	require.False(t, ok, "unchanged payload is not a change")
+
+	entry, ok = ds.DiffVersions(row(0, "A", "a"), row(1, "A", "a"))
+	require.True(t, ok)
+	require.Equal(t, ChangeTypeDeleted, entry.ChangeType)
+
+	_, ok = ds.DiffVersions(row(1, "A", "a"), row(1, "A", "a"))
+	require.False(t, ok, "tombstone of a deleted entity is not a change")
+}
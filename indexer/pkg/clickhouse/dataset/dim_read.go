@@ -2,6 +2,8 @@ package dataset
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
@@ -80,6 +82,85 @@ func (d *DimensionType2Dataset) GetAsOfRows(ctx context.Context, conn clickhouse
 	return scanRows(ctx, conn, query, args, mapScanner, "failed to query entities as of time")
 }
 
+// GetHistory returns every version of a single entity written between from and to (inclusive),
+// oldest first. Tombstone rows are included (is_deleted=1) so callers can see deletions.
+// A zero from or to leaves that side of the range unbounded.
+func (d *DimensionType2Dataset) GetHistory(ctx context.Context, conn clickhouse.Connection, entityID SurrogateKey, from, to time.Time) ([]map[string]any, error) {
+	query, args := buildQuery(queryParams{
+		queryType:        queryTypeHistory,
+		historyTableName: d.HistoryTableName(),
+		entityID:         entityID,
+		fromTime:         from,
+		toTime:           to,
+	})
+
+	return scanRows(ctx, conn, query, args, mapScanner, "failed to query entity history")
+}
+
+// DimensionDiff describes how a dataset changed between two points in time.
+// Each list is ordered by entity_id.
+type DimensionDiff struct {
+	Added   []map[string]any // Entities valid at t2 but not at t1 (state at t2)
+	Removed []map[string]any // Entities valid at t1 but not at t2 (state at t1)
+	Changed []EntityDiff     // Entities valid at both times whose payload differs
+}
+
+// EntityDiff is an entity whose payload columns differ between two points in time.
+type EntityDiff struct {
+	EntityID SurrogateKey
+	Before   map[string]any
+	After    map[string]any
+	Changes  []FieldChange
+}
+
+// DiffAsOf compares the dataset as of t1 with the dataset as of t2.
+func (d *DimensionType2Dataset) DiffAsOf(ctx context.Context, conn clickhouse.Connection, t1, t2 time.Time) (*DimensionDiff, error) {
+	before, err := d.GetAsOfRows(ctx, conn, nil, t1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rows as of t1: %w", err)
+	}
+	after, err := d.GetAsOfRows(ctx, conn, nil, t2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rows as of t2: %w", err)
+	}
+
+	beforeByEntity := make(map[string]map[string]any, len(before))
+	for _, row := range before {
+		beforeByEntity[fmt.Sprint(row["entity_id"])] = row
+	}
+
+	diff := &DimensionDiff{}
+	for _, row := range after {
+		entityID := fmt.Sprint(row["entity_id"])
+		prev, ok := beforeByEntity[entityID]
+		if !ok {
+			diff.Added = append(diff.Added, row)
+			continue
+		}
+		delete(beforeByEntity, entityID)
+		if changes := d.DiffColumns(prev, row); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, EntityDiff{
+				EntityID: SurrogateKey(entityID),
+				Before:   prev,
+				After:    row,
+				Changes:  changes,
+			})
+		}
+	}
+	for _, row := range beforeByEntity {
+		diff.Removed = append(diff.Removed, row)
+	}
+
+	byEntityID := func(rows []map[string]any) func(i, j int) bool {
+		return func(i, j int) bool { return fmt.Sprint(rows[i]["entity_id"]) < fmt.Sprint(rows[j]["entity_id"]) }
+	}
+	sort.Slice(diff.Added, byEntityID(diff.Added))
+	sort.Slice(diff.Removed, byEntityID(diff.Removed))
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].EntityID < diff.Changed[j].EntityID })
+
+	return diff, nil
+}
+
 // Query executes a raw SQL query and returns the results as []map[string]any.
 // The query can be any valid SQL query. Use ? placeholders for parameters and provide them in args.
 //
@@ -14,6 +14,7 @@ type queryType int
 const (
 	queryTypeCurrent queryType = iota
 	queryTypeAsOf
+	queryTypeHistory
 )
 
 // queryParams holds parameters for building queries
@@ -22,6 +23,8 @@ type queryParams struct {
 	entityID         SurrogateKey
 	entityIDs        []SurrogateKey
 	asOfTime         time.Time
+	fromTime         time.Time // history only; zero means unbounded
+	toTime           time.Time // history only; zero means unbounded
 	historyTableName string
 }
 
@@ -134,6 +137,25 @@ func buildQuery(p queryParams) (string, []any) {
 				args = append(args, convertToAnySlice(p.entityIDs)...)
 			}
 		}
+
+	case queryTypeHistory:
+		// All versions of a single entity, including tombstones, oldest first
+		query = fmt.Sprintf(`
+			SELECT *
+			FROM %s
+			WHERE entity_id = ?`, p.historyTableName)
+		args = []any{string(p.entityID)}
+		if !p.fromTime.IsZero() {
+			query += " AND snapshot_ts >= ?"
+			args = append(args, p.fromTime)
+		}
+		if !p.toTime.IsZero() {
+			query += " AND snapshot_ts <= ?"
+			args = append(args, p.toTime)
+		}
+		query += `
+			ORDER BY snapshot_ts, ingested_at, op_id
+		`
 	}
 
 	return query, args
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
 	"github.com/stretchr/testify/require"
 )
 
@@ -537,6 +539,110 @@ func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 }
 
+// writeSnapshots writes each snapshot with MissingMeansDeleted so omitted entities are tombstoned.
+func writeSnapshots(t *testing.T, conn clickhouse.Connection, d *DimensionType2Dataset, snapshots map[time.Time][][]any) {
+	ctx := t.Context()
+	times := make([]time.Time, 0, len(snapshots))
+	for ts := range snapshots {
+		times = append(times, ts)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for _, ts := range times {
+		rows := snapshots[ts]
+		err := d.WriteBatch(ctx, conn, len(rows), func(i int) ([]any, error) {
+			return rows[i], nil
+		}, &DimensionType2DatasetWriteConfig{
+			SnapshotTS:          ts,
+			MissingMeansDeleted: true,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_GetHistory(t *testing.T) {
+	t.Parallel()
+	log := testLogger()
+	conn := testConn(t)
+	createSinglePKTables(t, conn)
+
+	ctx := t.Context()
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	t3 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	d, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+	require.NoError(t, err)
+	writeSnapshots(t, conn, d, map[time.Time][][]any{
+		t1: {{"entity1", "CODE1", "Name1"}, {"entity2", "CODE2", "Name2"}},
+		t2: {{"entity1", "CODE1", "Name1_V2"}, {"entity2", "CODE2", "Name2"}},
+		t3: {{"entity2", "CODE2", "Name2"}},
+	})
+
+	entityID := NewNaturalKey("entity1").ToSurrogate()
+
+	t.Run("full_history", func(t *testing.T) {
+		versions, err := d.GetHistory(ctx, conn, entityID, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		require.Equal(t, "Name1", versions[0]["name"])
+		require.Equal(t, "Name1_V2", versions[1]["name"])
+		require.Equal(t, uint8(1), versions[2]["is_deleted"], "deletion is returned as a tombstone")
+	})
+
+	t.Run("bounded_range", func(t *testing.T) {
+		versions, err := d.GetHistory(ctx, conn, entityID, t2, t2)
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.Equal(t, "Name1_V2", versions[0]["name"])
+	})
+
+	t.Run("unknown_entity", func(t *testing.T) {
+		versions, err := d.GetHistory(ctx, conn, NewNaturalKey("missing").ToSurrogate(), time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Empty(t, versions)
+	})
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_DiffAsOf(t *testing.T) {
+	t.Parallel()
+	log := testLogger()
+	conn := testConn(t)
+	createSinglePKTables(t, conn)
+
+	ctx := t.Context()
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+
+	d, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+	require.NoError(t, err)
+	writeSnapshots(t, conn, d, map[time.Time][][]any{
+		t1: {{"entity1", "CODE1", "Name1"}, {"entity2", "CODE2", "Name2"}, {"entity3", "CODE3", "Name3"}},
+		t2: {{"entity1", "CODE1_V2", "Name1"}, {"entity3", "CODE3", "Name3"}, {"entity4", "CODE4", "Name4"}},
+	})
+
+	diff, err := d.DiffAsOf(ctx, conn, t1, t2)
+	require.NoError(t, err)
+
+	require.Len(t, diff.Added, 1)
+	require.Equal(t, "entity4", diff.Added[0]["pk"])
+
+	require.Len(t, diff.Removed, 1)
+	require.Equal(t, "entity2", diff.Removed[0]["pk"])
+
+	require.Len(t, diff.Changed, 1)
+	require.Equal(t, NewNaturalKey("entity1").ToSurrogate(), diff.Changed[0].EntityID)
+	require.Equal(t, []FieldChange{{Field: "code", OldValue: "CODE1", NewValue: "CODE1_V2"}}, diff.Changed[0].Changes)
+	require.Equal(t, "CODE1", diff.Changed[0].Before["code"])
+	require.Equal(t, "CODE1_V2", diff.Changed[0].After["code"])
+
+	// Same point in time has no differences
+	diff, err = d.DiffAsOf(ctx, conn, t2, t2)
+	require.NoError(t, err)
+	require.Empty(t, diff.Added)
+	require.Empty(t, diff.Removed)
+	require.Empty(t, diff.Changed)
+}
+
 func TestLake_Clickhouse_Dataset_DimensionType2_Query(t *testing.T) {
 	t.Parallel()
 	log := testLogger()
@@ -0,0 +1,174 @@
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+)
+
+// SchemaVersionsTableName is the table shared by all dimension datasets that records the payload
+// columns hashed into attrs_hash, one row per schema version.
+const SchemaVersionsTableName = "dim_schema_versions"
+
+// SchemaVersion is a recorded version of a dataset's attrs_hash column set.
+type SchemaVersion struct {
+	Dataset      string
+	Version      uint32
+	HashColumns  []string
+	RehashedRows uint64
+	AppliedAt    time.Time
+}
+
+// SchemaMigrationPlan describes what MigrateSchema does (or did) to bring a dataset's history in
+// line with its current schema.
+type SchemaMigrationPlan struct {
+	Dataset string
+	// From is the latest recorded version, nil if none has been recorded yet
+	From *SchemaVersion
+	// HashColumns is the payload column set of the current schema
+	HashColumns    []string
+	AddedColumns   []string
+	RemovedColumns []string
+	// StaleRows is the number of history rows whose attrs_hash differs from the current schema's hash
+	StaleRows uint64
+	// SpuriousVersions is the number of history rows the next WriteBatch would write for entities
+	// whose payload is unchanged, if history were not rehashed first
+	SpuriousVersions uint64
+}
+
+// NeedsMigration reports whether MigrateSchema would rewrite history or record a new version.
+func (p *SchemaMigrationPlan) NeedsMigration() bool {
+	return p.From == nil || p.StaleRows > 0 || len(p.AddedColumns) > 0 || len(p.RemovedColumns) > 0
+}
+
+// CurrentSchemaVersion returns the latest recorded schema version of this dataset, or nil if
+// none has been recorded.
+func (d *DimensionType2Dataset) CurrentSchemaVersion(ctx context.Context, conn clickhouse.Connection) (*SchemaVersion, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+		SELECT version, hash_columns, rehashed_rows, applied_at
+		FROM %s
+		WHERE dataset = ?
+		ORDER BY version DESC
+		LIMIT 1
+	`, SchemaVersionsTableName), d.schema.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema version: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	v := &SchemaVersion{Dataset: d.schema.Name()}
+	if err := rows.Scan(&v.Version, &v.HashColumns, &v.RehashedRows, &v.AppliedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan schema version: %w", err)
+	}
+	return v, nil
+}
+
+// IsCurrentSchemaVersion reports whether v records the current payload columns, in which case
+// history was rehashed with them and no migration is needed.
+func (d *DimensionType2Dataset) IsCurrentSchemaVersion(v *SchemaVersion) bool {
+	return v != nil && slices.Equal(v.HashColumns, d.payloadCols)
+}
+
+// PlanSchemaMigration is a dry run of MigrateSchema: it compares the recorded schema version
+// with the current payload columns and counts the history rows a schema change affects,
+// without modifying anything.
+func (d *DimensionType2Dataset) PlanSchemaMigration(ctx context.Context, conn clickhouse.Connection) (*SchemaMigrationPlan, error) {
+	from, err := d.CurrentSchemaVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SchemaMigrationPlan{
+		Dataset:     d.schema.Name(),
+		From:        from,
+		HashColumns: slices.Clone(d.payloadCols),
+	}
+	if from != nil {
+		for _, col := range d.payloadCols {
+			if !slices.Contains(from.HashColumns, col) {
+				plan.AddedColumns = append(plan.AddedColumns, col)
+			}
+		}
+		for _, col := range from.HashColumns {
+			if !slices.Contains(d.payloadCols, col) {
+				plan.RemovedColumns = append(plan.RemovedColumns, col)
+			}
+		}
+	}
+
+	plan.StaleRows, err = d.countRows(ctx, conn, fmt.Sprintf(`
+		SELECT count()
+		FROM %s
+		WHERE attrs_hash != %s
+	`, d.HistoryTableName(), d.AttrsHashExpression()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count stale history rows: %w", err)
+	}
+
+	// Only the latest active version of an entity is compared against the next snapshot
+	plan.SpuriousVersions, err = d.countRows(ctx, conn, fmt.Sprintf(`
+		SELECT count()
+		FROM (
+			SELECT %s
+			FROM %s h
+			GROUP BY h.entity_id
+		) l
+		WHERE l.is_deleted = 0 AND l.attrs_hash != %s
+	`, d.buildArgMaxSelect("h"), d.HistoryTableName(), d.AttrsHashExpressionWithPrefix("l", false)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count spurious versions: %w", err)
+	}
+
+	return plan, nil
+}
+
+// MigrateSchema rehashes history rows in place with the current payload columns and records a
+// new schema version if the column set changed. Run it after a migration adds or removes
+// payload columns and before the next WriteBatch; otherwise every active entity gets a new
+// history version even though none of its values changed. Rows keep their values (added
+// columns hold their column default), so only genuine changes are versioned afterwards.
+// Safe to call on every startup: it does nothing once history matches the recorded version.
+func (d *DimensionType2Dataset) MigrateSchema(ctx context.Context, conn clickhouse.Connection) (*SchemaMigrationPlan, error) {
+	plan, err := d.PlanSchemaMigration(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.NeedsMigration() {
+		return plan, nil
+	}
+
+	if plan.StaleRows > 0 {
+		expr := d.AttrsHashExpression()
+		query := fmt.Sprintf(`
+			ALTER TABLE %s
+			UPDATE attrs_hash = %s
+			WHERE attrs_hash != %s
+		`, d.HistoryTableName(), expr, expr)
+		if err := conn.Exec(clickhouse.ContextWithSyncMutations(ctx), query); err != nil {
+			return nil, fmt.Errorf("failed to rehash history: %w", err)
+		}
+		d.log.Info("rehashed history", "dataset", d.schema.Name(), "rows", plan.StaleRows, "added_columns", plan.AddedColumns, "removed_columns", plan.RemovedColumns)
+	}
+
+	if !d.IsCurrentSchemaVersion(plan.From) {
+		var version uint32 = 1
+		if plan.From != nil {
+			version = plan.From.Version + 1
+		}
+		if err := conn.Exec(clickhouse.ContextWithSyncInsert(ctx), fmt.Sprintf(`
+			INSERT INTO %s (dataset, version, hash_columns, rehashed_rows, applied_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, SchemaVersionsTableName), d.schema.Name(), version, plan.HashColumns, plan.StaleRows, time.Now().UTC().Truncate(time.Millisecond)); err != nil {
+			return nil, fmt.Errorf("failed to record schema version: %w", err)
+		}
+		d.log.Info("recorded schema version", "dataset", d.schema.Name(), "version", version)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,108 @@
+package dataset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchemaSinglePKExtra is testSchemaSinglePK after a migration added the extra column.
+type testSchemaSinglePKExtra struct{}
+
+func (s *testSchemaSinglePKExtra) Name() string {
+	return "test_single_pk"
+}
+func (s *testSchemaSinglePKExtra) PrimaryKeyColumns() []string {
+	return []string{"pk:VARCHAR"}
+}
+func (s *testSchemaSinglePKExtra) PayloadColumns() []string {
+	return []string{"code:VARCHAR", "name:VARCHAR", "extra:VARCHAR"}
+}
+
+func TestLake_Clickhouse_Dataset_DimensionType2_MigrateSchema(t *testing.T) {
+	t.Parallel()
+	log := testLogger()
+	conn := testConn(t)
+	createSinglePKTables(t, conn)
+
+	ctx := t.Context()
+	t1 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+
+	ds, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+	require.NoError(t, err)
+
+	err = ds.WriteBatch(ctx, conn, 2, func(i int) ([]any, error) {
+		return []any{fmt.Sprintf("entity%d", i+1), fmt.Sprintf("CODE%d", i+1), fmt.Sprintf("Name%d", i+1)}, nil
+	}, &DimensionType2DatasetWriteConfig{SnapshotTS: t1, OpID: uuid.New()})
+	require.NoError(t, err)
+
+	countHistory := func() uint64 {
+		count, err := ds.countRows(ctx, conn, fmt.Sprintf("SELECT count() FROM %s", ds.HistoryTableName()))
+		require.NoError(t, err)
+		return count
+	}
+
+	// First run records version 1 without touching history
+	plan, err := ds.MigrateSchema(ctx, conn)
+	require.NoError(t, err)
+	require.Nil(t, plan.From)
+	require.Zero(t, plan.StaleRows)
+
+	version, err := ds.CurrentSchemaVersion(ctx, conn)
+	require.NoError(t, err)
+	require.NotNil(t, version)
+	require.Equal(t, uint32(1), version.Version)
+	require.Equal(t, []string{"code", "name"}, version.HashColumns)
+
+	// Add a payload column
+	for _, table := range []string{ds.HistoryTableName(), ds.StagingTableName()} {
+		require.NoError(t, conn.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN extra String DEFAULT ''", table)))
+	}
+	ds, err = NewDimensionType2Dataset(log, &testSchemaSinglePKExtra{})
+	require.NoError(t, err)
+
+	t.Run("dry_run_reports_spurious_versions", func(t *testing.T) {
+		plan, err := ds.PlanSchemaMigration(ctx, conn)
+		require.NoError(t, err)
+		require.True(t, plan.NeedsMigration())
+		require.Equal(t, uint32(1), plan.From.Version)
+		require.Equal(t, []string{"extra"}, plan.AddedColumns)
+		require.Empty(t, plan.RemovedColumns)
+		require.Equal(t, uint64(2), plan.StaleRows)
+		require.Equal(t, uint64(2), plan.SpuriousVersions)
+
+		again, err := ds.PlanSchemaMigration(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, plan.StaleRows, again.StaleRows)
+		require.Equal(t, uint64(2), countHistory())
+	})
+
+	t.Run("migrate_rehashes_history", func(t *testing.T) {
+		plan, err := ds.MigrateSchema(ctx, conn)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), plan.StaleRows)
+
+		after, err := ds.PlanSchemaMigration(ctx, conn)
+		require.NoError(t, err)
+		require.False(t, after.NeedsMigration())
+		require.Equal(t, uint32(2), after.From.Version)
+		require.Equal(t, uint64(2), after.From.RehashedRows)
+		require.Zero(t, after.SpuriousVersions)
+	})
+
+	t.Run("next_snapshot_versions_genuine_changes_only", func(t *testing.T) {
+		err := ds.WriteBatch(ctx, conn, 2, func(i int) ([]any, error) {
+			extra := ""
+			if i == 0 {
+				extra = "set"
+			}
+			return []any{fmt.Sprintf("entity%d", i+1), fmt.Sprintf("CODE%d", i+1), fmt.Sprintf("Name%d", i+1), extra}, nil
+		}, &DimensionType2DatasetWriteConfig{SnapshotTS: t2, OpID: uuid.New()})
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), countHistory())
+	})
+}
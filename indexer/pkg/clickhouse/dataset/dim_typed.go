@@ -80,6 +80,78 @@ func (t *TypedDimensionType2Dataset[T]) GetAsOfRows(ctx context.Context, conn cl
 	return scanRows(ctx, conn, query, args, typedScanner[T], "failed to query entities as of time")
 }
 
+// GetHistory returns every version of a single entity written between from and to (inclusive)
+// as typed structs, oldest first. Tombstone rows are included; map is_deleted onto a field of T
+// to tell them apart. A zero from or to leaves that side of the range unbounded.
+func (t *TypedDimensionType2Dataset[T]) GetHistory(ctx context.Context, conn clickhouse.Connection, entityID SurrogateKey, from, to time.Time) ([]T, error) {
+	query, args := buildQuery(queryParams{
+		queryType:        queryTypeHistory,
+		historyTableName: t.dataset.HistoryTableName(),
+		entityID:         entityID,
+		fromTime:         from,
+		toTime:           to,
+	})
+
+	return scanRows(ctx, conn, query, args, typedScanner[T], "failed to query entity history")
+}
+
+// TypedDimensionDiff is the typed form of DimensionDiff.
+type TypedDimensionDiff[T any] struct {
+	Added   []T
+	Removed []T
+	Changed []TypedEntityDiff[T]
+}
+
+// TypedEntityDiff is the typed form of EntityDiff.
+type TypedEntityDiff[T any] struct {
+	EntityID SurrogateKey
+	Before   T
+	After    T
+	Changes  []FieldChange
+}
+
+// DiffAsOf compares the dataset as of t1 with the dataset as of t2, returning typed structs.
+func (t *TypedDimensionType2Dataset[T]) DiffAsOf(ctx context.Context, conn clickhouse.Connection, t1, t2 time.Time) (*TypedDimensionDiff[T], error) {
+	diff, err := t.dataset.DiffAsOf(ctx, conn, t1, t2)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TypedDimensionDiff[T]{}
+	for _, row := range diff.Added {
+		v, err := mapIntoStruct[T](row)
+		if err != nil {
+			return nil, err
+		}
+		result.Added = append(result.Added, v)
+	}
+	for _, row := range diff.Removed {
+		v, err := mapIntoStruct[T](row)
+		if err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, v)
+	}
+	for _, c := range diff.Changed {
+		before, err := mapIntoStruct[T](c.Before)
+		if err != nil {
+			return nil, err
+		}
+		after, err := mapIntoStruct[T](c.After)
+		if err != nil {
+			return nil, err
+		}
+		result.Changed = append(result.Changed, TypedEntityDiff[T]{
+			EntityID: c.EntityID,
+			Before:   before,
+			After:    after,
+			Changes:  c.Changes,
+		})
+	}
+
+	return result, nil
+}
+
 // WriteBatch writes a batch of typed structs to the dataset.
 // The struct type T should have fields matching the column names (case-insensitive, snake_case to CamelCase).
 // Use struct tags `ch:"column_name"` to explicitly map fields to columns.
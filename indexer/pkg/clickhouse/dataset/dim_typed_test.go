@@ -2,6 +2,7 @@ package dataset
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -112,4 +113,39 @@ func TestLake_Clickhouse_Dataset_DimensionType2_Typed(t *testing.T) {
 		require.Equal(t, "CODE5", current.Code)
 		require.Equal(t, "Name5", current.Name)
 	})
+
+	// Test typed history and diff
+	t.Run("history_and_diff_typed", func(t *testing.T) {
+		d, err := NewDimensionType2Dataset(log, &testSchemaSinglePK{})
+		require.NoError(t, err)
+		typed := NewTypedDimensionType2Dataset[Contributor](d)
+
+		t1 := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+		t2 := time.Date(2024, 2, 1, 11, 0, 0, 0, time.UTC)
+		writeSnapshots(t, conn, d, map[time.Time][][]any{
+			t1: {{"entity7", "CODE7", "Name7"}},
+			t2: {{"entity7", "CODE7", "Name7_V2"}, {"entity8", "CODE8", "Name8"}},
+		})
+
+		history, err := typed.GetHistory(ctx, conn, NewNaturalKey("entity7").ToSurrogate(), t1, t2)
+		require.NoError(t, err)
+		require.Equal(t, []Contributor{
+			{PK: "entity7", Code: "CODE7", Name: "Name7"},
+			{PK: "entity7", Code: "CODE7", Name: "Name7_V2"},
+		}, history)
+
+		diff, err := typed.DiffAsOf(ctx, conn, t1, t2)
+		require.NoError(t, err)
+		require.Contains(t, diff.Added, Contributor{PK: "entity8", Code: "CODE8", Name: "Name8"})
+		var changed *TypedEntityDiff[Contributor]
+		for i := range diff.Changed {
+			if diff.Changed[i].After.PK == "entity7" {
+				changed = &diff.Changed[i]
+			}
+		}
+		require.NotNil(t, changed)
+		require.Equal(t, "Name7", changed.Before.Name)
+		require.Equal(t, "Name7_V2", changed.After.Name)
+		require.Equal(t, []FieldChange{{Field: "name", OldValue: "Name7", NewValue: "Name7_V2"}}, changed.Changes)
+	})
 }
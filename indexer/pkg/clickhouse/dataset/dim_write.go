@@ -16,6 +16,8 @@ type DimensionType2DatasetWriteConfig struct {
 	IngestedAt          time.Time
 	MissingMeansDeleted bool
 	CleanupStaging      *bool
+	// ChangeSink, if set, receives an event for each change log entry written by the batch
+	ChangeSink ChangeSink
 }
 
 func (c *DimensionType2DatasetWriteConfig) Validate() error {
@@ -39,9 +41,10 @@ func (c *DimensionType2DatasetWriteConfig) Validate() error {
 	return nil
 }
 
-// WriteBatch implements the new 2-step ingestion flow:
+// WriteBatch implements the ingestion flow:
 // Step 1: Load snapshot into staging table (with attrs_hash computed)
 // Step 2: Compute delta and write directly to history using INSERT INTO ... SELECT
+// Step 3: Append a row per created, updated, or deleted entity to the shared change log
 func (d *DimensionType2Dataset) WriteBatch(
 	ctx context.Context,
 	conn clickhouse.Connection,
@@ -59,7 +62,12 @@ func (d *DimensionType2Dataset) WriteBatch(
 	if count == 0 {
 		// Handle empty snapshot: if MissingMeansDeleted, we need to insert tombstones
 		if cfg.MissingMeansDeleted {
-			return d.processEmptySnapshot(ctx, conn, cfg.OpID, cfg.SnapshotTS, cfg.IngestedAt)
+			if err := d.processEmptySnapshot(ctx, conn, cfg.OpID, cfg.SnapshotTS, cfg.IngestedAt); err != nil {
+				return err
+			}
+			if err := d.recordChanges(clickhouse.ContextWithSyncInsert(ctx), conn, cfg); err != nil {
+				return err
+			}
 		}
 		return nil
 	}
@@ -72,6 +80,10 @@ func (d *DimensionType2Dataset) WriteBatch(
 	}
 	if alreadyProcessed {
 		d.log.Info("op_id already processed, skipping (idempotent retry)", "dataset", d.schema.Name(), "op_id", cfg.OpID)
+		// A previous attempt may have failed between the history insert and the change log write
+		if err := d.recordChanges(clickhouse.ContextWithSyncInsert(ctx), conn, cfg); err != nil {
+			return err
+		}
 		return nil // Idempotent: already processed, nothing to do
 	}
 
@@ -310,6 +322,11 @@ func (d *DimensionType2Dataset) WriteBatch(
 
 	d.log.Info("wrote delta to history", "dataset", d.schema.Name(), "new_or_changed", newChangedCount, "deleted", deletedCount, "op_id", cfg.OpID)
 
+	// Step 3: Record the per-entity changes of this op in the shared change log
+	if err := d.recordChanges(deltaSyncCtx, conn, cfg); err != nil {
+		return err
+	}
+
 	// Optional: Clean up staging rows for this op_id
 	// This helps with fast turnover and reduces staging table size
 	// ALTER TABLE ... DELETE is a mutation operation and can be costly on busy clusters
@@ -328,6 +345,22 @@ func (d *DimensionType2Dataset) WriteBatch(
 	return nil
 }
 
+// recordChanges writes the change log entries of cfg.OpID and publishes them to cfg.ChangeSink.
+func (d *DimensionType2Dataset) recordChanges(ctx context.Context, conn clickhouse.Connection, cfg *DimensionType2DatasetWriteConfig) error {
+	entries, err := d.writeChangeLog(ctx, conn, cfg.OpID, cfg.SnapshotTS)
+	if err != nil {
+		return fmt.Errorf("failed to write change log: %w", err)
+	}
+	if cfg.ChangeSink != nil && len(entries) > 0 {
+		events := make([]ChangeEvent, 0, len(entries))
+		for _, e := range entries {
+			events = append(events, ChangeEventFromEntry(e))
+		}
+		publishChanges(ctx, d.log, cfg.ChangeSink, d.schema.Name(), events)
+	}
+	return nil
+}
+
 // checkOpIDAlreadyProcessed checks if an op_id has already been committed to history
 // Returns true if already processed (for idempotency), false otherwise
 func (d *DimensionType2Dataset) checkOpIDAlreadyProcessed(ctx context.Context, conn clickhouse.Connection, opID uuid.UUID) (bool, error) {
@@ -1570,6 +1570,7 @@ func createMultiplePKTables(t *testing.T, conn clickhouse.Connection) {
 	`
 	err = conn.Exec(ctx, createStagingTableSQL)
 	require.NoError(t, err)
+	createChangeLogTable(t, conn)
 }
 
 func createSinglePKTables(t *testing.T, conn clickhouse.Connection) {
@@ -1617,4 +1618,41 @@ func createSinglePKTables(t *testing.T, conn clickhouse.Connection) {
 	`
 	err = conn.Exec(ctx, createStagingTableSQL)
 	require.NoError(t, err)
+	createChangeLogTable(t, conn)
+}
+
+func createChangeLogTable(t *testing.T, conn clickhouse.Connection) {
+	ctx := t.Context()
+
+	createChangeLogTableSQL := `
+		CREATE TABLE IF NOT EXISTS dim_change_log (
+			dataset LowCardinality(String),
+			entity_id String,
+			op_id UUID,
+			snapshot_ts DateTime64(3),
+			ingested_at DateTime64(3),
+			committed_at DateTime64(3) DEFAULT snapshot_ts,
+			change_type LowCardinality(String),
+			changed_fields Array(String),
+			changes String,
+			attrs String
+		) ENGINE = MergeTree
+		PARTITION BY toYYYYMM(snapshot_ts)
+		ORDER BY (dataset, snapshot_ts, entity_id, op_id)
+	`
+	err := conn.Exec(ctx, createChangeLogTableSQL)
+	require.NoError(t, err)
+
+	createChangeLogBackfillsTableSQL := `
+		CREATE TABLE IF NOT EXISTS dim_change_log_backfills (
+			dataset LowCardinality(String),
+			cutoff_ts DateTime64(3),
+			completed UInt8,
+			entries UInt64,
+			recorded_at DateTime64(3)
+		) ENGINE = MergeTree
+		ORDER BY (dataset, recorded_at)
+	`
+	err = conn.Exec(ctx, createChangeLogBackfillsTableSQL)
+	require.NoError(t, err)
 }
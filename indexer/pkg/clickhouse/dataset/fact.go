@@ -23,6 +23,12 @@ type FactDataset struct {
 	// WriteBatchSize overrides the default sub-batch size for WriteBatch.
 	// If zero, defaults to 50,000 rows.
 	WriteBatchSize int
+
+	// RecordChanges makes WriteBatch append a row per write to fact_change_log, so the write
+	// shows up in the change stream.
+	RecordChanges bool
+	// ChangeSink, if set, receives an event for each write recorded in fact_change_log.
+	ChangeSink ChangeSink
 }
 
 func NewFactDataset(log *slog.Logger, schema FactSchema) (*FactDataset, error) {
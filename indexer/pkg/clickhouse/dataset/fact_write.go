@@ -3,7 +3,10 @@ package dataset
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
 )
 
@@ -13,6 +16,7 @@ const defaultWriteBatchSize = 50_000
 // The writeRowFn should return data in the order specified by the Columns configuration.
 // Note: ingested_at should be included in writeRowFn output if required by the table schema.
 // Large batches are automatically split into sub-batches of defaultWriteBatchSize rows.
+// If RecordChanges is set, the write is recorded in fact_change_log once all rows are sent.
 func (f *FactDataset) WriteBatch(
 	ctx context.Context,
 	conn clickhouse.Connection,
@@ -33,6 +37,13 @@ func (f *FactDataset) WriteBatch(
 	insertSQL := fmt.Sprintf("INSERT INTO %s", f.TableName())
 	expectedColCount := len(f.cols)
 
+	// Track the range of the time column for the change log
+	timeColIdx := -1
+	if f.RecordChanges && f.schema.TimeColumn() != "" {
+		timeColIdx = slices.Index(f.cols, f.schema.TimeColumn())
+	}
+	var minTime, maxTime time.Time
+
 	for start := 0; start < count; start += batchSize {
 		end := min(start+batchSize, count)
 
@@ -70,6 +81,17 @@ func (f *FactDataset) WriteBatch(
 				batch.Close()
 				return fmt.Errorf("failed to append row %d: %w", i, err)
 			}
+
+			if timeColIdx >= 0 {
+				if t, ok := row[timeColIdx].(time.Time); ok {
+					if minTime.IsZero() || t.Before(minTime) {
+						minTime = t
+					}
+					if t.After(maxTime) {
+						maxTime = t
+					}
+				}
+			}
 		}
 
 		if err := batch.Send(); err != nil {
@@ -79,5 +101,41 @@ func (f *FactDataset) WriteBatch(
 		f.log.Debug("wrote fact sub-batch", "table", f.schema.Name(), "start", start, "end", end, "total", count)
 	}
 
+	if f.RecordChanges {
+		if err := f.recordChange(ctx, conn, uint64(count), minTime, maxTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordChange appends a fact_change_log row for a completed write and publishes it to
+// ChangeSink. minTime and maxTime are zero if the dataset has no time column.
+func (f *FactDataset) recordChange(ctx context.Context, conn clickhouse.Connection, rows uint64, minTime, maxTime time.Time) error {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	event := ChangeEvent{
+		Kind:        ChangeKindFact,
+		Dataset:     f.schema.Name(),
+		ChangeType:  ChangeTypeAppended,
+		OpID:        uuid.New(),
+		SnapshotTS:  now,
+		IngestedAt:  now,
+		CommittedAt: now,
+		Rows:        rows,
+	}
+	event.Cursor = ChangeCursor{CommittedAt: event.CommittedAt, OpID: event.OpID}.String()
+	if !minTime.IsZero() {
+		event.MinTime, event.MaxTime = &minTime, &maxTime
+	}
+
+	if err := conn.Exec(clickhouse.ContextWithSyncInsert(ctx), fmt.Sprintf(`
+		INSERT INTO %s (dataset, op_id, snapshot_ts, committed_at, row_count, min_time, max_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, FactChangeLogTableName), event.Dataset, event.OpID, event.SnapshotTS, event.CommittedAt, rows, event.MinTime, event.MaxTime); err != nil {
+		return fmt.Errorf("failed to write fact change log: %w", err)
+	}
+
+	publishChanges(ctx, f.log, f.ChangeSink, f.schema.Name(), []ChangeEvent{event})
 	return nil
 }
@@ -455,6 +455,18 @@ func scanIntoStruct[T any](valuePtrs []any, columns []string) (T, error) {
 	return result, nil
 }
 
+// mapIntoStruct converts a row returned by the untyped API into a struct, using the same
+// column-to-field mapping as scanIntoStruct.
+func mapIntoStruct[T any](row map[string]any) (T, error) {
+	columns := make([]string, 0, len(row))
+	valuePtrs := make([]any, 0, len(row))
+	for col, val := range row {
+		columns = append(columns, col)
+		valuePtrs = append(valuePtrs, &val)
+	}
+	return scanIntoStruct[T](valuePtrs, columns)
+}
+
 // setFieldValue sets a struct field value, handling type conversions.
 func setFieldValue(fieldValue reflect.Value, fieldType reflect.Type, val any) error {
 	if val == nil {
@@ -203,24 +203,33 @@ func Reset(ctx context.Context, log *slog.Logger, cfg MigrationConfig) error {
 
 // Version returns the current migration version
 func Version(ctx context.Context, log *slog.Logger, cfg MigrationConfig) error {
+	version, err := DBVersion(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	log.Info("current migration version", "version", version)
+	return nil
+}
+
+// DBVersion returns the version of the most recent migration applied to the database
+func DBVersion(ctx context.Context, cfg MigrationConfig) (int64, error) {
 	db, err := newSQLDB(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create database connection for migrations: %w", err)
+		return 0, fmt.Errorf("failed to create database connection for migrations: %w", err)
 	}
 	defer db.Close()
 
 	provider, err := newProvider(db)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	version, err := provider.GetDBVersion(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get version: %w", err)
+		return 0, fmt.Errorf("failed to get version: %w", err)
 	}
-
-	log.Info("current migration version", "version", version)
-	return nil
+	return version, nil
 }
 
 // MigrationStatus returns the status of all migrations
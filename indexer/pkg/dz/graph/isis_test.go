@@ -69,9 +69,12 @@ func TestStore_SyncISIS(t *testing.T) {
 	// LSP from device1 shows neighbor with IP 172.16.0.117 (which is device2's IP on this link)
 	lsps := []isis.LSP{
 		{
-			SystemID: "ac10.0001.0000.00-00",
-			Hostname: "DZ-NY7-SW01",
-			RouterID: "172.16.0.1",
+			SystemID:   "ac10.0001.0000.00-00",
+			Hostname:   "DZ-NY7-SW01",
+			RouterID:   "172.16.0.1",
+			SRGBBase:   16000,
+			SRGBRange:  8000,
+			PrefixSIDs: []isis.PrefixSID{{Prefix: "172.16.0.1/32", Index: 1}},
 			Neighbors: []isis.Neighbor{
 				{
 					SystemID:     "ac10.0002.0000",
@@ -102,14 +105,18 @@ func TestStore_SyncISIS(t *testing.T) {
 	require.NotNil(t, adjSids, "expected adj_sids to be set")
 
 	// Check that Device was updated with ISIS properties
-	res, err = session.Run(ctx, "MATCH (d:Device {pk: 'device1'}) RETURN d.isis_system_id AS system_id, d.isis_router_id AS router_id", nil)
+	res, err = session.Run(ctx, "MATCH (d:Device {pk: 'device1'}) RETURN d.isis_system_id AS system_id, d.isis_router_id AS router_id, d.isis_srgb_base AS srgb_base, d.isis_node_sid AS node_sid", nil)
 	require.NoError(t, err)
 	record, err = res.Single(ctx)
 	require.NoError(t, err)
 	systemID, _ := record.Get("system_id")
 	routerID, _ := record.Get("router_id")
+	srgbBase, _ := record.Get("srgb_base")
+	nodeSID, _ := record.Get("node_sid")
 	require.Equal(t, "ac10.0001.0000.00-00", systemID, "expected ISIS system_id")
 	require.Equal(t, "172.16.0.1", routerID, "expected ISIS router_id")
+	require.Equal(t, int64(16000), srgbBase, "expected ISIS SRGB base")
+	require.Equal(t, int64(1), nodeSID, "expected ISIS node SID")
 
 	// Check that ISIS_ADJACENT relationship was created
 	res, err = session.Run(ctx, "MATCH (d1:Device {pk: 'device1'})-[r:ISIS_ADJACENT]->(d2:Device {pk: 'device2'}) RETURN r.metric AS metric, r.neighbor_addr AS neighbor_addr", nil)
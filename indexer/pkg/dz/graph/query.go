@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
 	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
 	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 )
@@ -101,6 +102,10 @@ type ISISDevice struct {
 	DeviceType string
 	SystemID   string
 	RouterID   string
+	SRGBBase   uint32
+	SRGBRange  uint32
+	NodeSID    *uint32 // Index of the node SID, nil if the router advertises none
+	PrefixSIDs []isis.PrefixSID
 }
 
 // ISISLink represents a link with ISIS properties.
@@ -137,7 +142,7 @@ type NetworkLink struct {
 // UnreachableIfDown returns devices that would become unreachable if the specified device goes down.
 // maxHops limits how far to search in the graph (0 = unlimited).
 func (s *Store) UnreachableIfDown(ctx context.Context, devicePK string, maxHops int) ([]dzsvc.Device, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -214,7 +219,7 @@ func (s *Store) UnreachableIfDown(ctx context.Context, devicePK string, maxHops
 
 // ReachableFromMetro returns devices reachable from a metro, optionally filtering for active links only.
 func (s *Store) ReachableFromMetro(ctx context.Context, metroPK string, activeOnly bool) ([]dzsvc.Device, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -296,7 +301,7 @@ func (s *Store) ReachableFromMetro(ctx context.Context, metroPK string, activeOn
 // ShortestPath finds the shortest path between two devices.
 // weightBy determines how the path is weighted (hops, RTT, or bandwidth).
 func (s *Store) ShortestPath(ctx context.Context, fromPK, toPK string, weightBy PathWeight) ([]PathSegment, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -371,7 +376,7 @@ func (s *Store) ShortestPath(ctx context.Context, fromPK, toPK string, weightBy
 
 // ExplainRoute returns a detailed route explanation between two devices.
 func (s *Store) ExplainRoute(ctx context.Context, fromPK, toPK string) ([]RouteHop, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -428,7 +433,7 @@ func (s *Store) ExplainRoute(ctx context.Context, fromPK, toPK string) ([]RouteH
 
 // NetworkAroundDevice returns the network subgraph around a device up to N hops.
 func (s *Store) NetworkAroundDevice(ctx context.Context, devicePK string, hops int) (*NetworkSubgraph, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -532,7 +537,7 @@ func (s *Store) NetworkAroundDevice(ctx context.Context, devicePK string, hops i
 
 // ISISAdjacencies returns all ISIS adjacencies for a device.
 func (s *Store) ISISAdjacencies(ctx context.Context, devicePK string) ([]ISISAdjacency, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -592,7 +597,7 @@ func (s *Store) ISISAdjacencies(ctx context.Context, devicePK string) ([]ISISAdj
 
 // ISISTopology returns the full ISIS topology (all devices with ISIS data and their adjacencies).
 func (s *Store) ISISTopology(ctx context.Context) ([]ISISDevice, []ISISAdjacency, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -607,7 +612,12 @@ func (s *Store) ISISTopology(ctx context.Context) ([]ISISDevice, []ISISAdjacency
 		       d.status AS status,
 		       d.device_type AS device_type,
 		       d.isis_system_id AS system_id,
-		       d.isis_router_id AS router_id
+		       d.isis_router_id AS router_id,
+		       d.isis_srgb_base AS srgb_base,
+		       d.isis_srgb_range AS srgb_range,
+		       d.isis_node_sid AS node_sid,
+		       d.isis_prefix_sid_prefixes AS prefix_sid_prefixes,
+		       d.isis_prefix_sid_indexes AS prefix_sid_indexes
 	`
 
 	devices, err := session.ExecuteRead(ctx, func(tx neo4j.Transaction) (any, error) {
@@ -629,6 +639,11 @@ func (s *Store) ISISTopology(ctx context.Context) ([]ISISDevice, []ISISAdjacency
 			deviceType, _ := record.Get("device_type")
 			systemID, _ := record.Get("system_id")
 			routerID, _ := record.Get("router_id")
+			srgbBase, _ := record.Get("srgb_base")
+			srgbRange, _ := record.Get("srgb_range")
+			nodeSID, _ := record.Get("node_sid")
+			prefixes, _ := record.Get("prefix_sid_prefixes")
+			indexes, _ := record.Get("prefix_sid_indexes")
 
 			devices = append(devices, ISISDevice{
 				PK:         asString(pk),
@@ -637,6 +652,10 @@ func (s *Store) ISISTopology(ctx context.Context) ([]ISISDevice, []ISISAdjacency
 				DeviceType: asString(deviceType),
 				SystemID:   asString(systemID),
 				RouterID:   asString(routerID),
+				SRGBBase:   uint32(asInt64(srgbBase)),
+				SRGBRange:  uint32(asInt64(srgbRange)),
+				NodeSID:    asUint32Ptr(nodeSID),
+				PrefixSIDs: asPrefixSIDs(prefixes, indexes),
 			})
 		}
 		return devices, nil
@@ -700,7 +719,7 @@ func (s *Store) ISISTopology(ctx context.Context) ([]ISISDevice, []ISISAdjacency
 // ShortestPathByISISMetric finds the shortest path using ISIS metrics as weights.
 // This traverses ISIS_ADJACENT relationships directly (control plane path).
 func (s *Store) ShortestPathByISISMetric(ctx context.Context, fromPK, toPK string) ([]ISISDevice, uint32, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -757,7 +776,7 @@ func (s *Store) ShortestPathByISISMetric(ctx context.Context, fromPK, toPK strin
 
 // LinksWithISISMetrics returns all links that have ISIS metrics set.
 func (s *Store) LinksWithISISMetrics(ctx context.Context) ([]ISISLink, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -844,7 +863,7 @@ type extraISISResult struct {
 // CompareTopology compares configured (serviceability) topology with ISIS-discovered topology.
 // It returns discrepancies including missing ISIS adjacencies, extra ISIS adjacencies, and metric mismatches.
 func (s *Store) CompareTopology(ctx context.Context) (*TopologyComparison, error) {
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -1188,6 +1207,28 @@ func parseISISDevices(v any) []ISISDevice {
 	return devices
 }
 
+func asUint32Ptr(v any) *uint32 {
+	if v == nil {
+		return nil
+	}
+	n := uint32(asInt64(v))
+	return &n
+}
+
+// asPrefixSIDs zips the parallel prefix and index lists stored on a Device node.
+func asPrefixSIDs(prefixes, indexes any) []isis.PrefixSID {
+	p, _ := prefixes.([]any)
+	i := asUint32Slice(indexes)
+	if len(p) == 0 || len(p) != len(i) {
+		return nil
+	}
+	sids := make([]isis.PrefixSID, len(p))
+	for k := range p {
+		sids[k] = isis.PrefixSID{Prefix: asString(p[k]), Index: i[k]}
+	}
+	return sids
+}
+
 func asUint32Slice(v any) []uint32 {
 	if v == nil {
 		return nil
@@ -182,9 +182,12 @@ func setupISISTestData(t *testing.T, chClient clickhouse.Client, graphStore *Sto
 	// device3 sees device2 via 172.16.0.2
 	lsps := []isis.LSP{
 		{
-			SystemID: "0000.0000.0001.00-00",
-			Hostname: "DZ-NY1-SW01",
-			RouterID: "10.0.0.1",
+			SystemID:   "0000.0000.0001.00-00",
+			Hostname:   "DZ-NY1-SW01",
+			RouterID:   "10.0.0.1",
+			SRGBBase:   16000,
+			SRGBRange:  8000,
+			PrefixSIDs: []isis.PrefixSID{{Prefix: "10.0.0.1/32", Index: 1}},
 			Neighbors: []isis.Neighbor{
 				{SystemID: "0000.0000.0002", Metric: 100, NeighborAddr: "172.16.0.1", AdjSIDs: []uint32{16001}},
 			},
@@ -278,6 +281,12 @@ func TestStore_ISISTopology(t *testing.T) {
 	require.Contains(t, deviceMap, "device1")
 	require.Equal(t, "0000.0000.0001.00-00", deviceMap["device1"].SystemID)
 	require.Equal(t, "10.0.0.1", deviceMap["device1"].RouterID)
+	require.Equal(t, uint32(16000), deviceMap["device1"].SRGBBase)
+	require.Equal(t, uint32(8000), deviceMap["device1"].SRGBRange)
+	require.NotNil(t, deviceMap["device1"].NodeSID)
+	require.Equal(t, uint32(1), *deviceMap["device1"].NodeSID)
+	require.Equal(t, []isis.PrefixSID{{Prefix: "10.0.0.1/32", Index: 1}}, deviceMap["device1"].PrefixSIDs)
+	require.Nil(t, deviceMap["device2"].NodeSID)
 
 	// Should have 4 adjacencies total (bidirectional links)
 	// device1->device2, device2->device1, device2->device3, device3->device2
@@ -19,6 +19,10 @@ type StoreConfig struct {
 	Logger     *slog.Logger
 	Neo4j      neo4j.Client
 	ClickHouse clickhouse.Client
+
+	// Database is the Neo4j database the store reads and writes, allowing one
+	// graph per DZ environment. Defaults to the client's configured database.
+	Database string
 }
 
 func (cfg *StoreConfig) Validate() error {
@@ -52,10 +56,42 @@ func NewStore(cfg StoreConfig) (*Store, error) {
 	}, nil
 }
 
+// NewQueryStore creates a read-only Store over an existing graph. It can run queries but
+// not sync, since it has no ClickHouse source.
+func NewQueryStore(logger *slog.Logger, client neo4j.Client, database string) (*Store, error) {
+	if logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if client == nil {
+		return nil, errors.New("neo4j client is required")
+	}
+	return &Store{
+		log: logger,
+		cfg: StoreConfig{Logger: logger, Neo4j: client, Database: database},
+	}, nil
+}
+
+// Database returns the Neo4j database the store targets, or empty if it uses
+// the client's configured database.
+func (s *Store) Database() string {
+	return s.cfg.Database
+}
+
+// session opens a session against the store's target database.
+func (s *Store) session(ctx context.Context) (neo4j.Session, error) {
+	if s.cfg.Database == "" {
+		return s.cfg.Neo4j.Session(ctx)
+	}
+	return s.cfg.Neo4j.SessionForDatabase(ctx, s.cfg.Database)
+}
+
 // Sync reads current state from ClickHouse and replaces the Neo4j graph.
 // This performs a full sync atomically within a single transaction.
 // Readers see either the old state or the new state, never an empty/partial state.
 func (s *Store) Sync(ctx context.Context) error {
+	if s.cfg.ClickHouse == nil {
+		return errors.New("graph: store has no clickhouse source")
+	}
 	s.log.Debug("graph: starting sync")
 
 	// Read current data from ClickHouse
@@ -91,7 +127,7 @@ func (s *Store) Sync(ctx context.Context) error {
 		"users", len(users),
 		"contributors", len(contributors))
 
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create Neo4j session: %w", err)
 	}
@@ -148,6 +184,9 @@ func (s *Store) Sync(ctx context.Context) error {
 // atomically within a single transaction. This ensures there is never a moment where the graph
 // has base nodes but no ISIS relationships.
 func (s *Store) SyncWithISIS(ctx context.Context, lsps []isis.LSP) error {
+	if s.cfg.ClickHouse == nil {
+		return errors.New("graph: store has no clickhouse source")
+	}
 	s.log.Debug("graph: starting sync with ISIS", "lsps", len(lsps))
 
 	// Read current data from ClickHouse
@@ -183,7 +222,7 @@ func (s *Store) SyncWithISIS(ctx context.Context, lsps []isis.LSP) error {
 		"users", len(users),
 		"contributors", len(contributors))
 
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create Neo4j session: %w", err)
 	}
@@ -403,13 +442,24 @@ func updateDeviceISISInTx(ctx context.Context, tx neo4j.Transaction, devicePK st
 		MATCH (d:Device {pk: $pk})
 		SET d.isis_system_id = $system_id,
 		    d.isis_router_id = $router_id,
+		    d.isis_srgb_base = $srgb_base,
+		    d.isis_srgb_range = $srgb_range,
+		    d.isis_node_sid = $node_sid,
+		    d.isis_prefix_sid_prefixes = $prefix_sid_prefixes,
+		    d.isis_prefix_sid_indexes = $prefix_sid_indexes,
 		    d.isis_last_sync = $last_sync
 	`
+	prefixes, indexes := prefixSIDParams(lsp)
 	res, err := tx.Run(ctx, cypher, map[string]any{
-		"pk":        devicePK,
-		"system_id": lsp.SystemID,
-		"router_id": lsp.RouterID,
-		"last_sync": timestamp.Unix(),
+		"pk":                  devicePK,
+		"system_id":           lsp.SystemID,
+		"router_id":           lsp.RouterID,
+		"srgb_base":           lsp.SRGBBase,
+		"srgb_range":          lsp.SRGBRange,
+		"node_sid":            nodeSIDParam(lsp),
+		"prefix_sid_prefixes": prefixes,
+		"prefix_sid_indexes":  indexes,
+		"last_sync":           timestamp.Unix(),
 	})
 	if err != nil {
 		return err
@@ -418,6 +468,26 @@ func updateDeviceISISInTx(ctx context.Context, tx neo4j.Transaction, devicePK st
 	return err
 }
 
+// nodeSIDParam returns the router's node SID index, or nil when it advertises none.
+func nodeSIDParam(lsp isis.LSP) any {
+	if sid, ok := lsp.NodeSID(); ok {
+		return sid
+	}
+	return nil
+}
+
+// prefixSIDParams splits the router's prefix SIDs into parallel lists, since node properties
+// cannot hold maps.
+func prefixSIDParams(lsp isis.LSP) ([]string, []uint32) {
+	prefixes := make([]string, len(lsp.PrefixSIDs))
+	indexes := make([]uint32, len(lsp.PrefixSIDs))
+	for i, sid := range lsp.PrefixSIDs {
+		prefixes[i] = sid.Prefix
+		indexes[i] = sid.Index
+	}
+	return prefixes, indexes
+}
+
 // createISISAdjacentInTx creates or updates an ISIS_ADJACENT relationship within a transaction.
 func createISISAdjacentInTx(ctx context.Context, tx neo4j.Transaction, fromPK, toPK string, neighbor isis.Neighbor, bandwidth int64, timestamp time.Time) error {
 	cypher := `
@@ -744,7 +814,7 @@ type tunnelMapping struct {
 func (s *Store) SyncISIS(ctx context.Context, lsps []isis.LSP) error {
 	s.log.Debug("graph: starting ISIS sync", "lsps", len(lsps))
 
-	session, err := s.cfg.Neo4j.Session(ctx)
+	session, err := s.session(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create Neo4j session: %w", err)
 	}
@@ -918,13 +988,24 @@ func (s *Store) updateDeviceISIS(ctx context.Context, session neo4j.Session, dev
 		MATCH (d:Device {pk: $pk})
 		SET d.isis_system_id = $system_id,
 		    d.isis_router_id = $router_id,
+		    d.isis_srgb_base = $srgb_base,
+		    d.isis_srgb_range = $srgb_range,
+		    d.isis_node_sid = $node_sid,
+		    d.isis_prefix_sid_prefixes = $prefix_sid_prefixes,
+		    d.isis_prefix_sid_indexes = $prefix_sid_indexes,
 		    d.isis_last_sync = $last_sync
 	`
+	prefixes, indexes := prefixSIDParams(lsp)
 	res, err := session.Run(ctx, cypher, map[string]any{
-		"pk":        devicePK,
-		"system_id": lsp.SystemID,
-		"router_id": lsp.RouterID,
-		"last_sync": timestamp.Unix(),
+		"pk":                  devicePK,
+		"system_id":           lsp.SystemID,
+		"router_id":           lsp.RouterID,
+		"srgb_base":           lsp.SRGBBase,
+		"srgb_range":          lsp.SRGBRange,
+		"node_sid":            nodeSIDParam(lsp),
+		"prefix_sid_prefixes": prefixes,
+		"prefix_sid_indexes":  indexes,
+		"last_sync":           timestamp.Unix(),
 	})
 	if err != nil {
 		return err
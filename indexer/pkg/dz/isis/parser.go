@@ -29,6 +29,7 @@ type jsonLevel struct {
 type jsonLSP struct {
 	Hostname           jsonHostname             `json:"hostname"`
 	Neighbors          []jsonNeighbor           `json:"neighbors"`
+	Reachabilities     []jsonReachability       `json:"reachabilities"`
 	RouterCapabilities []jsonRouterCapabilities `json:"routerCapabilities"`
 }
 
@@ -50,6 +51,18 @@ type jsonAdjSID struct {
 	AdjSID uint32 `json:"adjSid"`
 }
 
+// jsonReachability represents an IPv4 prefix advertised by the router.
+type jsonReachability struct {
+	ReachabilityV4Addr     string                     `json:"reachabilityV4Addr"`
+	MaskLength             int                        `json:"maskLength"`
+	SRPrefixReachabilities []jsonSRPrefixReachability `json:"srPrefixReachabilities"`
+}
+
+// jsonSRPrefixReachability represents a segment routing prefix SID entry.
+type jsonSRPrefixReachability struct {
+	SID uint32 `json:"sid"`
+}
+
 // jsonRouterCapabilities contains router capability information.
 type jsonRouterCapabilities struct {
 	RouterID  string `json:"routerId"`
@@ -91,6 +104,18 @@ func Parse(data []byte) ([]LSP, error) {
 		// RouterCapabilities is an array; use the first entry if present
 		if len(jsonLSP.RouterCapabilities) > 0 {
 			lsp.RouterID = jsonLSP.RouterCapabilities[0].RouterID
+			lsp.SRGBBase = jsonLSP.RouterCapabilities[0].SRGBBase
+			lsp.SRGBRange = jsonLSP.RouterCapabilities[0].SRGBRange
+		}
+
+		// Prefixes carrying a prefix SID
+		for _, r := range jsonLSP.Reachabilities {
+			for _, sr := range r.SRPrefixReachabilities {
+				lsp.PrefixSIDs = append(lsp.PrefixSIDs, PrefixSID{
+					Prefix: fmt.Sprintf("%s/%d", r.ReachabilityV4Addr, r.MaskLength),
+					Index:  sr.SID,
+				})
+			}
 		}
 
 		// Convert neighbors
@@ -24,6 +24,17 @@ func TestParse(t *testing.T) {
 												"srgbBase": 16000,
 												"srgbRange": 8000
 											}],
+											"reachabilities": [
+												{
+													"reachabilityV4Addr": "172.16.0.1",
+													"maskLength": 32,
+													"srPrefixReachabilities": [{"sid": 1}]
+												},
+												{
+													"reachabilityV4Addr": "172.16.0.116",
+													"maskLength": 31
+												}
+											],
 											"neighbors": [
 												{
 													"systemId": "ac10.0002.0000",
@@ -81,6 +92,12 @@ func TestParse(t *testing.T) {
 		assert.Equal(t, "ac10.0001.0000.00-00", ny7LSP.SystemID)
 		assert.Equal(t, "DZ-NY7-SW01", ny7LSP.Hostname)
 		assert.Equal(t, "172.16.0.1", ny7LSP.RouterID)
+		assert.Equal(t, uint32(16000), ny7LSP.SRGBBase)
+		assert.Equal(t, uint32(8000), ny7LSP.SRGBRange)
+		assert.Equal(t, []PrefixSID{{Prefix: "172.16.0.1/32", Index: 1}}, ny7LSP.PrefixSIDs)
+		nodeSID, ok := ny7LSP.NodeSID()
+		assert.True(t, ok)
+		assert.Equal(t, uint32(1), nodeSID)
 		assert.Len(t, ny7LSP.Neighbors, 2)
 
 		// Check first neighbor
@@ -88,6 +105,15 @@ func TestParse(t *testing.T) {
 		assert.Equal(t, uint32(1000), ny7LSP.Neighbors[0].Metric)
 		assert.Equal(t, "172.16.0.117", ny7LSP.Neighbors[0].NeighborAddr)
 		assert.Equal(t, []uint32{100001, 100002}, ny7LSP.Neighbors[0].AdjSIDs)
+
+		// DC1 advertises no prefix SIDs
+		for _, lsp := range lsps {
+			if lsp.Hostname == "DZ-DC1-SW01" {
+				assert.Empty(t, lsp.PrefixSIDs)
+				_, ok := lsp.NodeSID()
+				assert.False(t, ok)
+			}
+		}
 	})
 
 	t.Run("empty neighbors", func(t *testing.T) {
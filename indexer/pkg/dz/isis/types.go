@@ -2,10 +2,30 @@ package isis
 
 // LSP represents an IS-IS Link State PDU from a router.
 type LSP struct {
-	SystemID  string     // IS-IS system ID, e.g., "ac10.0001.0000.00-00"
-	Hostname  string     // Router hostname, e.g., "DZ-NY7-SW01"
-	RouterID  string     // Router ID from capabilities, e.g., "172.16.0.1"
-	Neighbors []Neighbor // Adjacent neighbors
+	SystemID   string      // IS-IS system ID, e.g., "ac10.0001.0000.00-00"
+	Hostname   string      // Router hostname, e.g., "DZ-NY7-SW01"
+	RouterID   string      // Router ID from capabilities, e.g., "172.16.0.1"
+	SRGBBase   uint32      // First label of the segment routing global block
+	SRGBRange  uint32      // Number of labels in the segment routing global block
+	PrefixSIDs []PrefixSID // Segment routing prefix SIDs advertised by the router
+	Neighbors  []Neighbor  // Adjacent neighbors
+}
+
+// PrefixSID is a segment routing SID advertised for a prefix. Its label is the SRGB base of
+// the router reading it plus the index.
+type PrefixSID struct {
+	Prefix string // e.g., "172.16.0.1/32"
+	Index  uint32
+}
+
+// NodeSID returns the index of the router's node SID: the prefix SID on its router ID's /32.
+func (l LSP) NodeSID() (uint32, bool) {
+	for _, sid := range l.PrefixSIDs {
+		if l.RouterID != "" && sid.Prefix == l.RouterID+"/32" {
+			return sid.Index, true
+		}
+	}
+	return 0, false
 }
 
 // Neighbor represents an IS-IS adjacency to a neighboring router.
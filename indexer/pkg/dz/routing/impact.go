@@ -0,0 +1,103 @@
+package routing
+
+import "sort"
+
+// RouteChange is a device pair whose routing differs once failures are applied.
+type RouteChange struct {
+	Source string
+	Target string
+	Before Route
+	After  Route
+}
+
+// Disconnected reports whether the pair lost every path.
+func (c RouteChange) Disconnected() bool {
+	return c.Before.Reachable && !c.After.Reachable
+}
+
+// MetricDelta returns the change in path metric, or 0 if either side is unreachable.
+func (c RouteChange) MetricDelta() int64 {
+	if !c.Before.Reachable || !c.After.Reachable {
+		return 0
+	}
+	return int64(c.After.Metric) - int64(c.Before.Metric)
+}
+
+// Impact is the effect of a set of failures on routing.
+type Impact struct {
+	Failures Failures
+	// Disconnected lists the surviving devices cut off from the largest part of the network
+	// they belonged to.
+	Disconnected []string
+	// Changes lists the surviving device pairs, with Source < Target, whose shortest metric or
+	// set of equal-cost paths changed. Disconnected pairs come first, then the largest metric
+	// increases.
+	Changes []RouteChange
+}
+
+// Disconnected returns the devices that survive the failures but are cut off from the largest
+// part of the network they belonged to.
+func (t *Topology) Disconnected(f Failures) []string {
+	return t.disconnected(t.Without(f))
+}
+
+func (t *Topology) disconnected(after *Topology) []string {
+	before := make(map[string]int)
+	for i, component := range t.Components() {
+		for _, pk := range component {
+			before[pk] = i
+		}
+	}
+	components := after.Components()
+	if len(components) == 0 {
+		return nil
+	}
+	main := make(map[string]bool, len(components[0]))
+	for _, pk := range components[0] {
+		main[pk] = true
+	}
+	home := before[components[0][0]]
+	var disconnected []string
+	for _, pk := range after.pks {
+		if !main[pk] && before[pk] == home {
+			disconnected = append(disconnected, pk)
+		}
+	}
+	return disconnected
+}
+
+// Impact applies the failures and compares routing between every pair of surviving devices,
+// keeping up to pathLimit equal-cost paths on each side of a change.
+func (t *Topology) Impact(f Failures, pathLimit int) Impact {
+	after := t.Without(f)
+	impact := Impact{Failures: f, Disconnected: t.disconnected(after)}
+
+	for _, src := range after.pks {
+		bt, at := t.SPF(src), after.SPF(src)
+		for _, dst := range after.pks {
+			if dst <= src || !bt.Reachable(dst) {
+				continue
+			}
+			// The surviving paths are a subset of the original ones, so an unchanged metric and
+			// path count mean the same set of paths
+			if m, ok := at.Metric(dst); ok && m == bt.dist[dst] && at.PathCount(dst) == bt.PathCount(dst) {
+				continue
+			}
+			impact.Changes = append(impact.Changes, RouteChange{
+				Source: src,
+				Target: dst,
+				Before: bt.Route(dst, pathLimit),
+				After:  at.Route(dst, pathLimit),
+			})
+		}
+	}
+
+	sort.SliceStable(impact.Changes, func(i, j int) bool {
+		ci, cj := impact.Changes[i], impact.Changes[j]
+		if ci.Disconnected() != cj.Disconnected() {
+			return ci.Disconnected()
+		}
+		return ci.MetricDelta() > cj.MetricDelta()
+	})
+	return impact
+}
@@ -0,0 +1,223 @@
+package routing
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrTooManyCombinations is returned when a k-failure search would test more edge
+// combinations than allowed.
+var ErrTooManyCombinations = errors.New("too many failure combinations")
+
+// Components returns the connected components of the undirected topology, largest first and
+// then by their lowest device PK. Each component is sorted.
+func (t *Topology) Components() [][]string {
+	neighbors := t.neighbors()
+	visited := make(map[string]bool, len(t.pks))
+	var components [][]string
+	for _, pk := range t.pks {
+		if visited[pk] {
+			continue
+		}
+		visited[pk] = true
+		component := []string{pk}
+		for i := 0; i < len(component); i++ {
+			for _, n := range neighbors[component[i]] {
+				if !visited[n] {
+					visited[n] = true
+					component = append(component, n)
+				}
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		if len(components[i]) != len(components[j]) {
+			return len(components[i]) > len(components[j])
+		}
+		return components[i][0] < components[j][0]
+	})
+	return components
+}
+
+// Bridges returns the edges whose loss splits a connected component. A bridge carried by
+// parallel links only splits the network when all of them fail.
+func (t *Topology) Bridges() []Edge {
+	bridges, _ := t.tarjan()
+	edges := t.Edges()
+	var out []Edge
+	for _, e := range edges {
+		if bridges[pairOf(e.A, e.Z)] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// ArticulationPoints returns the devices whose loss splits a connected component, sorted.
+func (t *Topology) ArticulationPoints() []string {
+	_, points := t.tarjan()
+	var out []string
+	for _, pk := range t.pks {
+		if points[pk] {
+			out = append(out, pk)
+		}
+	}
+	return out
+}
+
+// tarjan finds bridges and articulation points over the undirected topology in one DFS.
+func (t *Topology) tarjan() (map[devicePair]bool, map[string]bool) {
+	neighbors := t.neighbors()
+	disc := make(map[string]int, len(t.pks))
+	low := make(map[string]int, len(t.pks))
+	bridges := make(map[devicePair]bool)
+	points := make(map[string]bool)
+	timer := 0
+
+	var visit func(pk, parent string)
+	visit = func(pk, parent string) {
+		timer++
+		disc[pk] = timer
+		low[pk] = timer
+		children := 0
+		for _, n := range neighbors[pk] {
+			if n == parent {
+				continue
+			}
+			if _, seen := disc[n]; seen {
+				low[pk] = min(low[pk], disc[n])
+				continue
+			}
+			children++
+			visit(n, pk)
+			low[pk] = min(low[pk], low[n])
+			if low[n] > disc[pk] {
+				bridges[pairOf(pk, n)] = true
+			}
+			if parent != "" && low[n] >= disc[pk] {
+				points[pk] = true
+			}
+		}
+		if parent == "" && children > 1 {
+			points[pk] = true
+		}
+	}
+	for _, pk := range t.pks {
+		if _, seen := disc[pk]; !seen {
+			visit(pk, "")
+		}
+	}
+	return bridges, points
+}
+
+// MinimalEdgeCuts returns every set of at most k edges whose loss splits a connected
+// component and that contains no smaller such set, ordered by size. It returns
+// ErrTooManyCombinations if that would test more than maxCombinations edge sets.
+func (t *Topology) MinimalEdgeCuts(k, maxCombinations int) ([][]Edge, error) {
+	edges := t.Edges()
+	total := 0
+	for size := 1; size <= k && size <= len(edges); size++ {
+		total += combinations(len(edges), size, maxCombinations-total+1)
+		if total > maxCombinations {
+			return nil, ErrTooManyCombinations
+		}
+	}
+
+	index := make(map[string]int, len(t.pks))
+	for i, pk := range t.pks {
+		index[pk] = i
+	}
+	baseline := t.componentCount(index, edges, nil)
+
+	var cuts [][]int
+	var cut [][]Edge
+	combo := make([]int, 0, k)
+	var search func(start, size int)
+	search = func(start, size int) {
+		if len(combo) == size {
+			for _, c := range cuts {
+				if subset(c, combo) {
+					return
+				}
+			}
+			removed := make(map[int]bool, size)
+			for _, i := range combo {
+				removed[i] = true
+			}
+			if t.componentCount(index, edges, removed) > baseline {
+				cuts = append(cuts, append([]int(nil), combo...))
+				set := make([]Edge, len(combo))
+				for j, i := range combo {
+					set[j] = edges[i]
+				}
+				cut = append(cut, set)
+			}
+			return
+		}
+		for i := start; i < len(edges); i++ {
+			combo = append(combo, i)
+			search(i+1, size)
+			combo = combo[:len(combo)-1]
+		}
+	}
+	for size := 1; size <= k; size++ {
+		search(0, size)
+	}
+	return cut, nil
+}
+
+// componentCount counts connected components using the edges not in removed.
+func (t *Topology) componentCount(index map[string]int, edges []Edge, removed map[int]bool) int {
+	parent := make([]int, len(t.pks))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	count := len(t.pks)
+	for i, e := range edges {
+		if removed[i] {
+			continue
+		}
+		a, z := find(index[e.A]), find(index[e.Z])
+		if a != z {
+			parent[a] = z
+			count--
+		}
+	}
+	return count
+}
+
+// combinations returns n choose k, or any value above limit once it is exceeded.
+func combinations(n, k, limit int) int {
+	result := 1
+	for i := 1; i <= k; i++ {
+		result = result * (n - k + i) / i
+		if result > limit {
+			return limit + 1
+		}
+	}
+	return result
+}
+
+// subset reports whether every element of a, both sorted, is in b.
+func subset(a, b []int) bool {
+	j := 0
+	for _, x := range a {
+		for j < len(b) && b[j] < x {
+			j++
+		}
+		if j == len(b) || b[j] != x {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,262 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTopology builds a topology from undirected "a-z" edges with symmetric metrics, each
+// carried by one link named after the edge.
+func testTopology(edges map[string]uint32) *Topology {
+	var devices []graph.ISISDevice
+	var adjacencies []graph.ISISAdjacency
+	var links []graph.ISISLink
+	seen := make(map[string]bool)
+	for name, metric := range edges {
+		a, z := name[:1], name[2:]
+		for _, pk := range []string{a, z} {
+			if !seen[pk] {
+				seen[pk] = true
+				devices = append(devices, graph.ISISDevice{PK: pk, Code: pk + "-dz1"})
+			}
+		}
+		adjacencies = append(adjacencies,
+			graph.ISISAdjacency{FromDevicePK: a, ToDevicePK: z, Metric: metric},
+			graph.ISISAdjacency{FromDevicePK: z, ToDevicePK: a, Metric: metric},
+		)
+		links = append(links, graph.ISISLink{PK: name, Code: name, SideAPK: a, SideZPK: z, ISISMetric: metric})
+	}
+	return NewTopology(devices, adjacencies, links)
+}
+
+type fakeSource struct {
+	devices     []graph.ISISDevice
+	adjacencies []graph.ISISAdjacency
+	links       []graph.ISISLink
+}
+
+func (s fakeSource) ISISTopology(context.Context) ([]graph.ISISDevice, []graph.ISISAdjacency, error) {
+	return s.devices, s.adjacencies, nil
+}
+
+func (s fakeSource) LinksWithISISMetrics(context.Context) ([]graph.ISISLink, error) {
+	return s.links, nil
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	src := fakeSource{
+		devices: []graph.ISISDevice{{PK: "a", Code: "a-dz1"}},
+		adjacencies: []graph.ISISAdjacency{
+			{FromDevicePK: "a", FromCode: "a-dz1", ToDevicePK: "b", ToCode: "b-dz1", Metric: 10},
+			{FromDevicePK: "a", ToDevicePK: "b", Metric: 5},
+			{FromDevicePK: "b", ToDevicePK: "a", Metric: 0},
+		},
+		links: []graph.ISISLink{
+			{PK: "l2", SideAPK: "b", SideZPK: "a"},
+			{PK: "l1", SideAPK: "a", SideZPK: "b"},
+		},
+	}
+	topo, err := Load(context.Background(), src)
+	require.NoError(t, err)
+
+	// Devices only seen in adjacencies are added with their code
+	b, ok := topo.Device("b")
+	require.True(t, ok)
+	assert.Equal(t, "b-dz1", b.Code)
+
+	// Duplicates keep the lowest metric, zero counts as 1, and parallel links are attached
+	assert.Equal(t, []Adjacency{{From: "a", To: "b", Metric: 5, LinkPKs: []string{"l1", "l2"}}}, topo.Adjacencies("a"))
+	assert.Equal(t, uint32(1), topo.Adjacencies("b")[0].Metric)
+}
+
+func TestSPF(t *testing.T) {
+	t.Parallel()
+
+	// a-b-d and a-c-d cost 20 each, a-d direct costs 30
+	topo := testTopology(map[string]uint32{"a-b": 10, "b-d": 10, "a-c": 5, "c-d": 15, "a-d": 30, "d-e": 1})
+	tree := topo.SPF("a")
+
+	metric, ok := tree.Metric("d")
+	require.True(t, ok)
+	assert.Equal(t, uint64(20), metric)
+	assert.Equal(t, 2, tree.Hops("d"))
+	assert.Equal(t, 2, tree.PathCount("d"))
+	assert.Equal(t, []string{"b", "c"}, tree.NextHops("e"))
+	assert.Equal(t, []Path{
+		{Devices: []string{"a", "b", "d", "e"}, Metric: 21},
+		{Devices: []string{"a", "c", "d", "e"}, Metric: 21},
+	}, tree.Paths("e", 0))
+	assert.Len(t, tree.Paths("e", 1), 1)
+
+	route := topo.Route("a", "missing", 1)
+	assert.False(t, route.Reachable)
+	assert.Equal(t, -1, tree.Hops("missing"))
+}
+
+func TestReweighted(t *testing.T) {
+	t.Parallel()
+
+	// a-b-c wins on metric, but a-c is faster once weighted by its link
+	topo := testTopology(map[string]uint32{"a-b": 10, "b-c": 10, "a-c": 30})
+	fast := topo.Reweighted(func(adj Adjacency) uint32 {
+		if adj.LinkPKs[0] == "a-c" {
+			return 5
+		}
+		return adj.Metric
+	})
+
+	assert.Equal(t, []string{"a", "b", "c"}, topo.Route("a", "c", 1).Paths[0].Devices)
+	assert.Equal(t, []string{"a", "c"}, fast.Route("a", "c", 1).Paths[0].Devices)
+	assert.Equal(t, uint64(5), fast.Route("c", "a", 1).Metric)
+}
+
+func TestSPFAsymmetricMetrics(t *testing.T) {
+	t.Parallel()
+
+	topo := NewTopology(nil, []graph.ISISAdjacency{
+		{FromDevicePK: "a", ToDevicePK: "b", Metric: 100},
+		{FromDevicePK: "b", ToDevicePK: "a", Metric: 10},
+		{FromDevicePK: "a", ToDevicePK: "c", Metric: 10},
+		{FromDevicePK: "c", ToDevicePK: "b", Metric: 10},
+	}, nil)
+
+	assert.Equal(t, []string{"a", "c", "b"}, topo.Route("a", "b", 1).Paths[0].Devices)
+	assert.Equal(t, []string{"b", "a"}, topo.Route("b", "a", 1).Paths[0].Devices)
+	// c has no adjacency back, so a is only reachable through b
+	assert.Equal(t, uint64(20), topo.Route("c", "a", 1).Metric)
+}
+
+func TestBridgesAndArticulationPoints(t *testing.T) {
+	t.Parallel()
+
+	// A ring a-b-c-d with a chain d-e-f hanging off it
+	topo := testTopology(map[string]uint32{"a-b": 1, "b-c": 1, "c-d": 1, "d-a": 1, "d-e": 1, "e-f": 1})
+
+	var bridges []string
+	for _, e := range topo.Bridges() {
+		bridges = append(bridges, e.A+"-"+e.Z)
+	}
+	assert.Equal(t, []string{"d-e", "e-f"}, bridges)
+	assert.Equal(t, []string{"d", "e"}, topo.ArticulationPoints())
+	assert.Len(t, topo.Components(), 1)
+}
+
+func TestMinimalEdgeCuts(t *testing.T) {
+	t.Parallel()
+
+	topo := testTopology(map[string]uint32{"a-b": 1, "b-c": 1, "c-a": 1, "c-d": 1})
+
+	cuts, err := topo.MinimalEdgeCuts(2, 100)
+	require.NoError(t, err)
+	var names [][]string
+	for _, cut := range cuts {
+		var set []string
+		for _, e := range cut {
+			set = append(set, e.A+"-"+e.Z)
+		}
+		names = append(names, set)
+	}
+	// The bridge alone, then each pair of ring edges; pairs containing the bridge are not minimal
+	assert.Equal(t, [][]string{{"c-d"}, {"a-b", "a-c"}, {"a-b", "b-c"}, {"a-c", "b-c"}}, names)
+
+	_, err = topo.MinimalEdgeCuts(3, 5)
+	assert.ErrorIs(t, err, ErrTooManyCombinations)
+}
+
+func TestImpact(t *testing.T) {
+	t.Parallel()
+
+	// A ring a-b-c-d where a-b is the cheap way round, and a chain d-e
+	topo := testTopology(map[string]uint32{"a-b": 10, "b-c": 10, "c-d": 10, "a-d": 50, "d-e": 10})
+
+	t.Run("link on the ring reroutes", func(t *testing.T) {
+		t.Parallel()
+
+		impact := topo.Impact(Failures{Links: []string{"a-b"}}, 1)
+		assert.Empty(t, impact.Disconnected)
+		require.NotEmpty(t, impact.Changes)
+
+		// a to b now goes the long way round: a-d-c-b
+		first := impact.Changes[0]
+		assert.Equal(t, "a", first.Source)
+		assert.Equal(t, "b", first.Target)
+		assert.Equal(t, int64(60), first.MetricDelta())
+		assert.Equal(t, []string{"a", "d", "c", "b"}, first.After.Paths[0].Devices)
+
+		for _, c := range impact.Changes {
+			assert.False(t, c.Disconnected())
+			assert.NotEqual(t, [2]string{"c", "d"}, [2]string{c.Source, c.Target})
+		}
+	})
+
+	t.Run("bridge disconnects", func(t *testing.T) {
+		t.Parallel()
+
+		impact := topo.Impact(Failures{Edges: []Edge{NewEdge("e", "d")}}, 1)
+		assert.Equal(t, []string{"e"}, impact.Disconnected)
+		assert.Equal(t, impact.Disconnected, topo.Disconnected(impact.Failures))
+		require.Len(t, impact.Changes, 4)
+		for _, c := range impact.Changes {
+			assert.True(t, c.Disconnected())
+			assert.Equal(t, "e", c.Target)
+		}
+	})
+
+	t.Run("device removes its pairs", func(t *testing.T) {
+		t.Parallel()
+
+		impact := topo.Impact(Failures{Devices: []string{"d"}}, 1)
+		assert.Equal(t, []string{"e"}, impact.Disconnected)
+		for _, c := range impact.Changes {
+			assert.NotEqual(t, "d", c.Source)
+			assert.NotEqual(t, "d", c.Target)
+		}
+	})
+
+	t.Run("parallel link survives", func(t *testing.T) {
+		t.Parallel()
+
+		parallel := NewTopology(nil, []graph.ISISAdjacency{
+			{FromDevicePK: "a", ToDevicePK: "b", Metric: 10},
+			{FromDevicePK: "b", ToDevicePK: "a", Metric: 10},
+		}, []graph.ISISLink{{PK: "l1", SideAPK: "a", SideZPK: "b"}, {PK: "l2", SideAPK: "a", SideZPK: "b"}})
+
+		assert.Empty(t, parallel.Impact(Failures{Links: []string{"l1"}}, 1).Changes)
+		assert.Equal(t, []string{"b"}, parallel.Impact(Failures{Links: []string{"l1", "l2"}}, 1).Disconnected)
+	})
+}
+
+func TestDistribute(t *testing.T) {
+	t.Parallel()
+
+	// a reaches d over a-b-d and a-c-d at equal cost; e is isolated
+	topo := testTopology(map[string]uint32{"a-b": 10, "b-d": 10, "a-c": 10, "c-d": 10})
+	topo.devices["e"] = Device{PK: "e"}
+	topo.index()
+
+	load := topo.Distribute([]Demand{
+		{Source: "a", Target: "d", BPS: 100},
+		{Source: "d", Target: "b", BPS: 40},
+		{Source: "a", Target: "e", BPS: 7},
+		{Source: "a", Target: "a", BPS: 1000},
+	})
+	assert.Equal(t, map[LinkDirection]float64{
+		{LinkPK: "a-b", From: "a"}: 50,
+		{LinkPK: "b-d", From: "b"}: 50,
+		{LinkPK: "a-c", From: "a"}: 50,
+		{LinkPK: "c-d", From: "c"}: 50,
+		{LinkPK: "b-d", From: "d"}: 40,
+	}, load.Links)
+	assert.Equal(t, 7.0, load.Unrouted)
+
+	// Losing b sends everything over c
+	load = topo.Without(Failures{Devices: []string{"b"}}).Distribute([]Demand{{Source: "a", Target: "d", BPS: 100}})
+	assert.Equal(t, 100.0, load.Links[LinkDirection{LinkPK: "a-c", From: "a"}])
+	assert.Equal(t, 100.0, load.Links[LinkDirection{LinkPK: "c-d", From: "c"}])
+}
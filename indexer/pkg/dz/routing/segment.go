@@ -0,0 +1,397 @@
+package routing
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SegmentType is the kind of SID a segment is steered by.
+type SegmentType string
+
+const (
+	// SegmentNode forwards along the IGP shortest path, with ECMP, to a device.
+	SegmentNode SegmentType = "node"
+	// SegmentAdjacency forwards over one adjacency, whatever the IGP prefers.
+	SegmentAdjacency SegmentType = "adjacency"
+)
+
+// Segment is one entry of an SR-MPLS label stack.
+type Segment struct {
+	Type SegmentType
+	From string // device the segment starts at
+	To   string // device the segment ends at
+	// SID is the node SID index for node segments and the local label for adjacency segments.
+	SID uint32
+	// Label is the MPLS label for the SID as read by From. With a uniform SRGB across the
+	// fleet, which ValidateSIDs checks, it is the label on the wire.
+	Label uint32
+}
+
+// SRPath is a path through the network and the label stack that steers traffic along it.
+type SRPath struct {
+	Devices  []string
+	Metric   uint64
+	ECMP     bool // whether the segments may spread traffic over other equal-cost paths
+	Segments []Segment
+}
+
+// Labels returns the label stack, top of stack first.
+func (p SRPath) Labels() []uint32 {
+	labels := make([]uint32, len(p.Segments))
+	for i, s := range p.Segments {
+		labels[i] = s.Label
+	}
+	return labels
+}
+
+// NodeLabelStack steers traffic from src through each waypoint in turn to dst with one node
+// segment per waypoint and one for dst. With no waypoints it is the IGP shortest path. The
+// devices are the first of the equal-cost paths between each pair of segment endpoints.
+func (t *Topology) NodeLabelStack(src string, via []string, dst string) (SRPath, error) {
+	if _, ok := t.devices[src]; !ok {
+		return SRPath{}, fmt.Errorf("unknown device %s", src)
+	}
+	p := SRPath{Devices: []string{src}}
+	from := src
+	for _, to := range append(append([]string(nil), via...), dst) {
+		if to == from {
+			continue
+		}
+		d, ok := t.devices[to]
+		if !ok {
+			return SRPath{}, fmt.Errorf("unknown device %s", to)
+		}
+		if d.NodeSID == nil {
+			return SRPath{}, fmt.Errorf("device %s has no node SID", t.code(to))
+		}
+		tree := t.SPF(from)
+		paths := tree.Paths(to, 1)
+		if len(paths) == 0 {
+			return SRPath{}, fmt.Errorf("no path from %s to %s", t.code(from), t.code(to))
+		}
+		p.Devices = append(p.Devices, paths[0].Devices[1:]...)
+		p.Metric += paths[0].Metric
+		p.ECMP = p.ECMP || tree.PathCount(to) > 1
+		p.Segments = append(p.Segments, t.nodeSegment(from, to))
+		from = to
+	}
+	return p, nil
+}
+
+// StrictLabelStack encodes an explicit device path as the shortest label stack that keeps
+// traffic on exactly that path. Each stretch of the path that is the unique IGP shortest path
+// to its end is compressed into a node segment, and every other hop takes an adjacency
+// segment.
+func (t *Topology) StrictLabelStack(devices []string) (SRPath, error) {
+	if len(devices) == 0 {
+		return SRPath{}, fmt.Errorf("empty path")
+	}
+	for _, pk := range devices {
+		if _, ok := t.devices[pk]; !ok {
+			return SRPath{}, fmt.Errorf("unknown device %s", pk)
+		}
+	}
+	p := SRPath{Devices: append([]string(nil), devices...)}
+	for i := 0; i+1 < len(devices); i++ {
+		adj, ok := t.adjacency(devices[i], devices[i+1])
+		if !ok {
+			return SRPath{}, fmt.Errorf("no adjacency from %s to %s", t.code(devices[i]), t.code(devices[i+1]))
+		}
+		p.Metric += uint64(adj.Metric)
+	}
+
+	for i := 0; i+1 < len(devices); {
+		tree := t.SPF(devices[i])
+		// The furthest device the path reaches as the only shortest path from here
+		end := i
+		for j := i + 1; j < len(devices); j++ {
+			if d := t.devices[devices[j]]; d.NodeSID != nil && tree.PathCount(devices[j]) == 1 &&
+				slices.Equal(tree.Paths(devices[j], 1)[0].Devices, devices[i:j+1]) {
+				end = j
+			}
+		}
+		if end > i {
+			p.Segments = append(p.Segments, t.nodeSegment(devices[i], devices[end]))
+			i = end
+			continue
+		}
+
+		adj, _ := t.adjacency(devices[i], devices[i+1])
+		if len(adj.AdjSIDs) == 0 {
+			return SRPath{}, fmt.Errorf("no adjacency SID from %s to %s", t.code(devices[i]), t.code(devices[i+1]))
+		}
+		p.Segments = append(p.Segments, Segment{
+			Type:  SegmentAdjacency,
+			From:  devices[i],
+			To:    devices[i+1],
+			SID:   adj.AdjSIDs[0],
+			Label: adj.AdjSIDs[0],
+		})
+		i++
+	}
+	return p, nil
+}
+
+// AlternatePaths returns up to limit loop-free paths from src to dst that are longer than
+// the IGP shortest paths, in order of metric, using Yen's k shortest paths over the directed
+// adjacencies. The equal-cost shortest paths themselves are never included.
+func (t *Topology) AlternatePaths(src, dst string, limit int) []Path {
+	found := t.SPF(src).Paths(dst, 0)
+	if len(found) == 0 || limit <= 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, p := range found {
+		seen[strings.Join(p.Devices, ",")] = true
+	}
+
+	var alternates, candidates []Path
+	for next := 0; next < len(found) && len(alternates) < limit; next++ {
+		p := found[next]
+		// Deviate from p at each device in turn, avoiding every earlier path's next hop from
+		// the same root and the root's own devices
+		var rootMetric uint64
+		for i := 0; i+1 < len(p.Devices); i++ {
+			spur, root := p.Devices[i], p.Devices[:i+1]
+			arcs := make(map[devicePair]bool)
+			for _, q := range found {
+				if len(q.Devices) > i+1 && slices.Equal(q.Devices[:i+1], root) {
+					arcs[devicePair{q.Devices[i], q.Devices[i+1]}] = true
+				}
+			}
+			devices := make(map[string]bool, i)
+			for _, pk := range root[:i] {
+				devices[pk] = true
+			}
+			if spurPaths := t.prune(devices, arcs).SPF(spur).Paths(dst, 1); len(spurPaths) > 0 {
+				path := Path{
+					Devices: append(slices.Clone(root), spurPaths[0].Devices[1:]...),
+					Metric:  rootMetric + spurPaths[0].Metric,
+				}
+				if key := strings.Join(path.Devices, ","); !seen[key] {
+					seen[key] = true
+					candidates = append(candidates, path)
+				}
+			}
+			adj, _ := t.adjacency(spur, p.Devices[i+1])
+			rootMetric += uint64(adj.Metric)
+		}
+		// Once every path found so far has been deviated from, the best candidate is next
+		if next < len(found)-1 {
+			continue
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].Metric != candidates[j].Metric {
+				return candidates[i].Metric < candidates[j].Metric
+			}
+			if len(candidates[i].Devices) != len(candidates[j].Devices) {
+				return len(candidates[i].Devices) < len(candidates[j].Devices)
+			}
+			return slices.Compare(candidates[i].Devices, candidates[j].Devices) < 0
+		})
+		found = append(found, candidates[0])
+		alternates = append(alternates, candidates[0])
+		candidates = candidates[1:]
+	}
+	return alternates
+}
+
+// prune returns a copy of the topology without the given devices and directed adjacencies.
+func (t *Topology) prune(devices map[string]bool, arcs map[devicePair]bool) *Topology {
+	n := &Topology{
+		devices: make(map[string]Device, len(t.devices)),
+		out:     make(map[string][]Adjacency, len(t.out)),
+		links:   t.links,
+	}
+	for pk, d := range t.devices {
+		if !devices[pk] {
+			n.devices[pk] = d
+		}
+	}
+	for from, adjs := range t.out {
+		if devices[from] {
+			continue
+		}
+		for _, adj := range adjs {
+			if !devices[adj.To] && !arcs[devicePair{adj.From, adj.To}] {
+				n.out[from] = append(n.out[from], adj)
+			}
+		}
+	}
+	n.index()
+	return n
+}
+
+func (t *Topology) nodeSegment(from, to string) Segment {
+	sid := *t.devices[to].NodeSID
+	return Segment{Type: SegmentNode, From: from, To: to, SID: sid, Label: t.devices[from].SRGBBase + sid}
+}
+
+func (t *Topology) adjacency(from, to string) (Adjacency, bool) {
+	for _, adj := range t.out[from] {
+		if adj.To == to {
+			if _, ok := t.devices[to]; ok {
+				return adj, true
+			}
+		}
+	}
+	return Adjacency{}, false
+}
+
+// code returns the device's code for messages, falling back to its PK.
+func (t *Topology) code(pk string) string {
+	if d, ok := t.devices[pk]; ok && d.Code != "" {
+		return d.Code
+	}
+	return pk
+}
+
+// SIDIssueType is a kind of segment routing misconfiguration.
+type SIDIssueType string
+
+const (
+	SIDIssueMissingSRGB        SIDIssueType = "missing_srgb"
+	SIDIssueMissingNodeSID     SIDIssueType = "missing_node_sid"
+	SIDIssueDuplicatePrefixSID SIDIssueType = "duplicate_prefix_sid"
+	SIDIssueOutsideSRGB        SIDIssueType = "sid_outside_srgb"
+	SIDIssueSRGBMismatch       SIDIssueType = "srgb_mismatch"
+	SIDIssueAdjSIDInSRGB       SIDIssueType = "adj_sid_in_srgb"
+	SIDIssueDuplicateAdjSID    SIDIssueType = "duplicate_adj_sid"
+)
+
+// SIDIssue is a segment routing misconfiguration and the devices involved, sorted.
+type SIDIssue struct {
+	Type    SIDIssueType
+	Devices []string
+	SID     uint32
+	Detail  string
+}
+
+// ValidateSIDs checks that segment routing labels are unique and fit the SRGB across the fleet:
+// every router has an SRGB and a node SID, the SRGB is the same everywhere, each prefix SID
+// index belongs to one prefix and fits every router's SRGB, and adjacency SIDs are unique per
+// router and allocated outside its SRGB. Issues are ordered by type, then SID.
+func (t *Topology) ValidateSIDs() []SIDIssue {
+	var issues []SIDIssue
+
+	srgbDevices := make(map[[2]uint32][]string)
+	prefixes := make(map[uint32]map[string][]string) // index -> prefix -> devices
+	for _, pk := range t.pks {
+		d := t.devices[pk]
+		if d.SRGBRange == 0 {
+			issues = append(issues, SIDIssue{Type: SIDIssueMissingSRGB, Devices: []string{pk}, Detail: "router advertises no SRGB"})
+		} else {
+			srgb := [2]uint32{d.SRGBBase, d.SRGBRange}
+			srgbDevices[srgb] = append(srgbDevices[srgb], pk)
+		}
+		if d.NodeSID == nil {
+			issues = append(issues, SIDIssue{Type: SIDIssueMissingNodeSID, Devices: []string{pk}, Detail: "no prefix SID on the router ID"})
+		}
+		for _, sid := range d.PrefixSIDs {
+			if prefixes[sid.Index] == nil {
+				prefixes[sid.Index] = make(map[string][]string)
+			}
+			prefixes[sid.Index][sid.Prefix] = append(prefixes[sid.Index][sid.Prefix], pk)
+		}
+
+		adjSIDs := make(map[uint32][]string)
+		for _, adj := range t.out[pk] {
+			for _, sid := range adj.AdjSIDs {
+				adjSIDs[sid] = append(adjSIDs[sid], adj.To)
+				if d.SRGBRange > 0 && sid >= d.SRGBBase && sid-d.SRGBBase < d.SRGBRange {
+					issues = append(issues, SIDIssue{
+						Type:    SIDIssueAdjSIDInSRGB,
+						Devices: []string{pk},
+						SID:     sid,
+						Detail:  fmt.Sprintf("adjacency SID to %s is inside the SRGB %d-%d", t.code(adj.To), d.SRGBBase, d.SRGBBase+d.SRGBRange-1),
+					})
+				}
+			}
+		}
+		for sid, neighbors := range adjSIDs {
+			if len(neighbors) > 1 {
+				codes := make([]string, len(neighbors))
+				for i, n := range neighbors {
+					codes[i] = t.code(n)
+				}
+				issues = append(issues, SIDIssue{
+					Type:    SIDIssueDuplicateAdjSID,
+					Devices: []string{pk},
+					SID:     sid,
+					Detail:  "adjacency SID used towards " + strings.Join(codes, ", "),
+				})
+			}
+		}
+	}
+
+	// Routers that differ from the most common SRGB read the same index as different labels
+	var common [2]uint32
+	for srgb, devices := range srgbDevices {
+		if n := len(srgbDevices[common]); len(devices) > n || (len(devices) == n && (srgb[0] < common[0] || (srgb[0] == common[0] && srgb[1] < common[1]))) {
+			common = srgb
+		}
+	}
+	for srgb, devices := range srgbDevices {
+		if srgb == common {
+			continue
+		}
+		issues = append(issues, SIDIssue{
+			Type:    SIDIssueSRGBMismatch,
+			Devices: sortedCopy(devices),
+			Detail:  fmt.Sprintf("SRGB %d/%d differs from %d/%d used by %d routers", srgb[0], srgb[1], common[0], common[1], len(srgbDevices[common])),
+		})
+	}
+
+	for index, byPrefix := range prefixes {
+		var devices, names []string
+		for prefix, pks := range byPrefix {
+			devices = append(devices, pks...)
+			names = append(names, prefix)
+		}
+		if len(byPrefix) > 1 {
+			sort.Strings(names)
+			issues = append(issues, SIDIssue{
+				Type:    SIDIssueDuplicatePrefixSID,
+				Devices: sortedCopy(devices),
+				SID:     index,
+				Detail:  "index used by " + strings.Join(names, ", "),
+			})
+		}
+		var outside []string
+		for _, pk := range t.pks {
+			if d := t.devices[pk]; d.SRGBRange > 0 && index >= d.SRGBRange {
+				outside = append(outside, pk)
+			}
+		}
+		if len(outside) > 0 {
+			issues = append(issues, SIDIssue{
+				Type:    SIDIssueOutsideSRGB,
+				Devices: sortedCopy(devices),
+				SID:     index,
+				Detail:  fmt.Sprintf("index does not fit the SRGB of %d routers", len(outside)),
+			})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Type != issues[j].Type {
+			return issues[i].Type < issues[j].Type
+		}
+		if issues[i].SID != issues[j].SID {
+			return issues[i].SID < issues[j].SID
+		}
+		return strings.Join(issues[i].Devices, ",") < strings.Join(issues[j].Devices, ",")
+	})
+	return issues
+}
+
+func sortedCopy(pks []string) []string {
+	pks = slices.Clone(pks)
+	sort.Strings(pks)
+	return slices.Compact(pks)
+}
@@ -0,0 +1,153 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// srTopology builds a topology from undirected "a-z" edges like testTopology, with an SRGB
+// of 16000/8000 everywhere, node SIDs numbered in device order and adjacency SIDs from 100000.
+func srTopology(pks []string, edges [][3]any) *Topology {
+	devices := make([]graph.ISISDevice, len(pks))
+	for i, pk := range pks {
+		sid := uint32(i + 1)
+		devices[i] = graph.ISISDevice{
+			PK:         pk,
+			Code:       pk + "-dz1",
+			RouterID:   "10.0.0." + pk,
+			SRGBBase:   16000,
+			SRGBRange:  8000,
+			NodeSID:    &sid,
+			PrefixSIDs: []isis.PrefixSID{{Prefix: "10.0.0." + pk + "/32", Index: sid}},
+		}
+	}
+	var adjacencies []graph.ISISAdjacency
+	for i, e := range edges {
+		a, z, metric := e[0].(string), e[1].(string), uint32(e[2].(int))
+		adjacencies = append(adjacencies,
+			graph.ISISAdjacency{FromDevicePK: a, ToDevicePK: z, Metric: metric, AdjSIDs: []uint32{uint32(100000 + 2*i)}},
+			graph.ISISAdjacency{FromDevicePK: z, ToDevicePK: a, Metric: metric, AdjSIDs: []uint32{uint32(100001 + 2*i)}},
+		)
+	}
+	return NewTopology(devices, adjacencies, nil)
+}
+
+func TestNodeLabelStack(t *testing.T) {
+	t.Parallel()
+
+	// a reaches d over two equal-cost paths
+	topo := srTopology([]string{"a", "b", "c", "d"}, [][3]any{
+		{"a", "b", 10}, {"b", "d", 10}, {"a", "c", 10}, {"c", "d", 10},
+	})
+
+	p, err := topo.NodeLabelStack("a", nil, "d")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "d"}, p.Devices)
+	assert.Equal(t, uint64(20), p.Metric)
+	assert.True(t, p.ECMP)
+	assert.Equal(t, []Segment{{Type: SegmentNode, From: "a", To: "d", SID: 4, Label: 16004}}, p.Segments)
+
+	// A waypoint pins the path to one side
+	p, err = topo.NodeLabelStack("a", []string{"c"}, "d")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c", "d"}, p.Devices)
+	assert.False(t, p.ECMP)
+	assert.Equal(t, []uint32{16003, 16004}, p.Labels())
+
+	_, err = topo.NodeLabelStack("a", nil, "missing")
+	assert.Error(t, err)
+}
+
+func TestStrictLabelStack(t *testing.T) {
+	t.Parallel()
+
+	// a-b-c is the shortest path to c; a-d-c costs more and a-c direct is the worst
+	topo := srTopology([]string{"a", "b", "c", "d"}, [][3]any{
+		{"a", "b", 10}, {"b", "c", 10}, {"a", "d", 15}, {"d", "c", 15}, {"a", "c", 50},
+	})
+
+	// The shortest path compresses to one node segment
+	p, err := topo.StrictLabelStack([]string{"a", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{16003}, p.Labels())
+	assert.Equal(t, uint64(20), p.Metric)
+
+	// a-d is the shortest path to d, and d-c from there
+	p, err = topo.StrictLabelStack([]string{"a", "d", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{16004, 16003}, p.Labels())
+
+	// The direct link is never the shortest path, so it takes its adjacency SID
+	p, err = topo.StrictLabelStack([]string{"a", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []Segment{{Type: SegmentAdjacency, From: "a", To: "c", SID: 100008, Label: 100008}}, p.Segments)
+	assert.Equal(t, uint64(50), p.Metric)
+
+	_, err = topo.StrictLabelStack([]string{"b", "d"})
+	assert.Error(t, err, "no adjacency")
+}
+
+func TestAlternatePaths(t *testing.T) {
+	t.Parallel()
+
+	topo := srTopology([]string{"a", "b", "c", "d"}, [][3]any{
+		{"a", "b", 10}, {"b", "c", 10}, {"a", "d", 15}, {"d", "c", 15}, {"a", "c", 50}, {"b", "d", 1},
+	})
+
+	paths := topo.AlternatePaths("a", "c", 3)
+	require.Len(t, paths, 3)
+	assert.Equal(t, Path{Devices: []string{"a", "b", "d", "c"}, Metric: 26}, paths[0])
+	assert.Equal(t, Path{Devices: []string{"a", "d", "b", "c"}, Metric: 26}, paths[1])
+	assert.Equal(t, Path{Devices: []string{"a", "d", "c"}, Metric: 30}, paths[2])
+
+	all := topo.AlternatePaths("a", "c", 100)
+	assert.Len(t, all, 4)
+	assert.Equal(t, []string{"a", "c"}, all[3].Devices)
+	assert.Empty(t, topo.AlternatePaths("a", "missing", 3))
+}
+
+func TestValidateSIDs(t *testing.T) {
+	t.Parallel()
+
+	topo := srTopology([]string{"a", "b"}, [][3]any{{"a", "b", 10}})
+	assert.Empty(t, topo.ValidateSIDs())
+
+	dup, big := uint32(1), uint32(9000)
+	topo = NewTopology([]graph.ISISDevice{
+		{PK: "a", SRGBBase: 16000, SRGBRange: 8000, NodeSID: &dup, PrefixSIDs: []isis.PrefixSID{{Prefix: "10.0.0.1/32", Index: 1}}},
+		{PK: "b", SRGBBase: 16000, SRGBRange: 8000, NodeSID: &dup, PrefixSIDs: []isis.PrefixSID{{Prefix: "10.0.0.2/32", Index: 1}}},
+		{PK: "c", SRGBBase: 20000, SRGBRange: 8000, NodeSID: &big, PrefixSIDs: []isis.PrefixSID{{Prefix: "10.0.0.3/32", Index: 9000}}},
+		{PK: "d"},
+	}, []graph.ISISAdjacency{
+		{FromDevicePK: "a", ToDevicePK: "b", Metric: 10, AdjSIDs: []uint32{16500}},
+		{FromDevicePK: "b", ToDevicePK: "a", Metric: 10, AdjSIDs: []uint32{100000}},
+		{FromDevicePK: "b", ToDevicePK: "c", Metric: 10, AdjSIDs: []uint32{100000}},
+	}, nil)
+
+	var types []SIDIssueType
+	for _, issue := range topo.ValidateSIDs() {
+		types = append(types, issue.Type)
+	}
+	assert.Equal(t, []SIDIssueType{
+		SIDIssueAdjSIDInSRGB,
+		SIDIssueDuplicateAdjSID,
+		SIDIssueDuplicatePrefixSID,
+		SIDIssueMissingNodeSID,
+		SIDIssueMissingSRGB,
+		SIDIssueOutsideSRGB,
+		SIDIssueSRGBMismatch,
+	}, types)
+
+	issues := topo.ValidateSIDs()
+	assert.Equal(t, []string{"a"}, issues[0].Devices)
+	assert.Equal(t, uint32(16500), issues[0].SID)
+	assert.Equal(t, []string{"b"}, issues[1].Devices)
+	assert.Equal(t, []string{"a", "b"}, issues[2].Devices)
+	assert.Equal(t, []string{"d"}, issues[3].Devices)
+	assert.Equal(t, []string{"c"}, issues[5].Devices)
+	assert.Equal(t, []string{"c"}, issues[6].Devices)
+}
@@ -0,0 +1,242 @@
+package routing
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// maxPathCount caps equal-cost path counting so it cannot overflow on dense meshes.
+const maxPathCount = 1 << 30
+
+// Tree is the result of an SPF run from one device: the shortest metric to every reachable
+// device and, for ECMP, every predecessor on an equal-cost shortest path.
+type Tree struct {
+	source string
+	dist   map[string]uint64
+	preds  map[string][]string
+	hops   map[string]int // fewest hops over the equal-cost shortest paths
+	count  map[string]int // number of equal-cost shortest paths, capped at maxPathCount
+}
+
+// Path is one shortest path, from the source device to the target.
+type Path struct {
+	Devices []string
+	Metric  uint64
+}
+
+// Route is the result of routing between two devices.
+type Route struct {
+	Source    string
+	Target    string
+	Reachable bool
+	Metric    uint64
+	Hops      int // fewest hops over the equal-cost paths, 0 if unreachable
+	PathCount int // number of equal-cost paths
+	Paths     []Path
+}
+
+type spfItem struct {
+	pk   string
+	dist uint64
+}
+
+type spfQueue []spfItem
+
+func (q spfQueue) Len() int { return len(q) }
+func (q spfQueue) Less(i, j int) bool {
+	if q[i].dist != q[j].dist {
+		return q[i].dist < q[j].dist
+	}
+	return q[i].pk < q[j].pk
+}
+func (q spfQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *spfQueue) Push(x any)   { *q = append(*q, x.(spfItem)) }
+func (q *spfQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// SPF runs Dijkstra from source over the directed adjacencies, keeping every equal-cost
+// predecessor.
+func (t *Topology) SPF(source string) *Tree {
+	tree := &Tree{
+		source: source,
+		dist:   make(map[string]uint64),
+		preds:  make(map[string][]string),
+		hops:   make(map[string]int),
+		count:  make(map[string]int),
+	}
+	if _, ok := t.devices[source]; !ok {
+		return tree
+	}
+
+	tree.dist[source] = 0
+	settled := make(map[string]bool, len(t.devices))
+	var order []string
+	q := &spfQueue{{pk: source}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(spfItem)
+		if settled[item.pk] || item.dist != tree.dist[item.pk] {
+			continue
+		}
+		settled[item.pk] = true
+		order = append(order, item.pk)
+
+		for _, adj := range t.out[item.pk] {
+			if _, ok := t.devices[adj.To]; !ok || settled[adj.To] {
+				continue
+			}
+			d := item.dist + uint64(adj.Metric)
+			current, seen := tree.dist[adj.To]
+			switch {
+			case !seen || d < current:
+				tree.dist[adj.To] = d
+				tree.preds[adj.To] = []string{item.pk}
+				heap.Push(q, spfItem{pk: adj.To, dist: d})
+			case d == current:
+				tree.preds[adj.To] = append(tree.preds[adj.To], item.pk)
+			}
+		}
+	}
+
+	// Metrics are positive, so every predecessor settles before the devices it leads to
+	tree.count[source] = 1
+	for _, pk := range order[1:] {
+		preds := tree.preds[pk]
+		sort.Strings(preds)
+		hops, count := -1, 0
+		for _, p := range preds {
+			if hops < 0 || tree.hops[p]+1 < hops {
+				hops = tree.hops[p] + 1
+			}
+			count += tree.count[p]
+			if count > maxPathCount {
+				count = maxPathCount
+			}
+		}
+		tree.hops[pk] = hops
+		tree.count[pk] = count
+	}
+	return tree
+}
+
+// Source returns the device the tree was computed from.
+func (tr *Tree) Source() string {
+	return tr.source
+}
+
+// Reachable reports whether dst has a path from the source.
+func (tr *Tree) Reachable(dst string) bool {
+	_, ok := tr.dist[dst]
+	return ok
+}
+
+// Metric returns the shortest metric to dst.
+func (tr *Tree) Metric(dst string) (uint64, bool) {
+	d, ok := tr.dist[dst]
+	return d, ok
+}
+
+// Hops returns the fewest hops over the shortest paths to dst, or -1 if it is unreachable.
+func (tr *Tree) Hops(dst string) int {
+	if !tr.Reachable(dst) {
+		return -1
+	}
+	return tr.hops[dst]
+}
+
+// PathCount returns the number of equal-cost shortest paths to dst.
+func (tr *Tree) PathCount(dst string) int {
+	return tr.count[dst]
+}
+
+// NextHops returns the neighbors of the source that lie on a shortest path to dst.
+func (tr *Tree) NextHops(dst string) []string {
+	if dst == tr.source || !tr.Reachable(dst) {
+		return nil
+	}
+	set := make(map[string]bool)
+	var walk func(pk string)
+	visited := make(map[string]bool)
+	walk = func(pk string) {
+		if visited[pk] {
+			return
+		}
+		visited[pk] = true
+		for _, p := range tr.preds[pk] {
+			if p == tr.source {
+				set[pk] = true
+				continue
+			}
+			walk(p)
+		}
+	}
+	walk(dst)
+
+	hops := make([]string, 0, len(set))
+	for pk := range set {
+		hops = append(hops, pk)
+	}
+	sort.Strings(hops)
+	return hops
+}
+
+// Paths returns up to limit of the equal-cost shortest paths to dst in a stable order. A
+// limit of zero or less returns them all.
+func (tr *Tree) Paths(dst string, limit int) []Path {
+	if !tr.Reachable(dst) {
+		return nil
+	}
+	metric := tr.dist[dst]
+	if dst == tr.source {
+		return []Path{{Devices: []string{dst}, Metric: metric}}
+	}
+
+	var paths []Path
+	// Walk predecessors back from dst, building each path in reverse
+	reversed := []string{dst}
+	var walk func(pk string) bool
+	walk = func(pk string) bool {
+		if pk == tr.source {
+			devices := make([]string, len(reversed))
+			for i, d := range reversed {
+				devices[len(reversed)-1-i] = d
+			}
+			paths = append(paths, Path{Devices: devices, Metric: metric})
+			return limit <= 0 || len(paths) < limit
+		}
+		for _, p := range tr.preds[pk] {
+			reversed = append(reversed, p)
+			more := walk(p)
+			reversed = reversed[:len(reversed)-1]
+			if !more {
+				return false
+			}
+		}
+		return true
+	}
+	walk(dst)
+	return paths
+}
+
+// Route summarizes the routing to dst with up to pathLimit of its equal-cost paths.
+func (tr *Tree) Route(dst string, pathLimit int) Route {
+	r := Route{Source: tr.source, Target: dst}
+	metric, ok := tr.dist[dst]
+	if !ok {
+		return r
+	}
+	r.Reachable = true
+	r.Metric = metric
+	r.Hops = tr.hops[dst]
+	r.PathCount = tr.count[dst]
+	r.Paths = tr.Paths(dst, pathLimit)
+	return r
+}
+
+// Route computes the route between two devices with up to pathLimit of its equal-cost paths.
+func (t *Topology) Route(src, dst string, pathLimit int) Route {
+	return t.SPF(src).Route(dst, pathLimit)
+}
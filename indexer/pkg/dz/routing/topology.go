@@ -0,0 +1,363 @@
+// Package routing runs IS-IS shortest path first over an in-memory copy of the network
+// topology, for exact what-if analysis of device and link failures.
+package routing
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
+)
+
+// Source provides the IS-IS topology. *graph.Store implements it.
+type Source interface {
+	ISISTopology(ctx context.Context) ([]graph.ISISDevice, []graph.ISISAdjacency, error)
+	LinksWithISISMetrics(ctx context.Context) ([]graph.ISISLink, error)
+}
+
+// Device is a router taking part in IS-IS, with the segment routing state it advertises.
+type Device struct {
+	PK         string
+	Code       string
+	Status     string
+	DeviceType string
+	RouterID   string
+	SRGBBase   uint32
+	SRGBRange  uint32
+	NodeSID    *uint32 // node SID index, nil if the router advertises none
+	PrefixSIDs []isis.PrefixSID
+}
+
+// Link is a configured link with an IS-IS metric.
+type Link struct {
+	PK      string
+	Code    string
+	Status  string
+	SideAPK string
+	SideZPK string
+	Metric  uint32
+}
+
+// Adjacency is a directed IS-IS adjacency, the links that carry it and the adjacency SIDs
+// the From device allocated for it.
+type Adjacency struct {
+	From    string
+	To      string
+	Metric  uint32
+	LinkPKs []string
+	AdjSIDs []uint32
+}
+
+// Edge is an undirected connection between two devices, with A < Z, and the links that
+// carry it. It exists if there is an adjacency in either direction.
+type Edge struct {
+	A       string
+	Z       string
+	Metric  uint32 // lowest metric of the two directions
+	LinkPKs []string
+}
+
+// NewEdge returns the edge between two devices with its endpoints ordered.
+func NewEdge(a, z string) Edge {
+	if z < a {
+		a, z = z, a
+	}
+	return Edge{A: a, Z: z}
+}
+
+type devicePair struct{ a, z string }
+
+func pairOf(a, z string) devicePair {
+	if z < a {
+		a, z = z, a
+	}
+	return devicePair{a, z}
+}
+
+// Topology is an immutable snapshot of the IS-IS topology.
+type Topology struct {
+	devices map[string]Device
+	pks     []string // sorted
+	out     map[string][]Adjacency
+	links   map[string]Link
+}
+
+// Load reads the IS-IS topology from src.
+func Load(ctx context.Context, src Source) (*Topology, error) {
+	devices, adjacencies, err := src.ISISTopology(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load isis topology: %w", err)
+	}
+	links, err := src.LinksWithISISMetrics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load isis links: %w", err)
+	}
+	return NewTopology(devices, adjacencies, links), nil
+}
+
+// NewTopology builds a topology from IS-IS devices, adjacencies and links. Adjacencies are
+// matched to the links between the same two devices. Duplicate adjacencies keep the lowest
+// metric, and a zero metric counts as 1 as it does in IS-IS. Their adjacency SIDs are merged,
+// as each parallel adjacency has its own.
+func NewTopology(devices []graph.ISISDevice, adjacencies []graph.ISISAdjacency, links []graph.ISISLink) *Topology {
+	t := &Topology{
+		devices: make(map[string]Device, len(devices)),
+		out:     make(map[string][]Adjacency),
+		links:   make(map[string]Link, len(links)),
+	}
+	for _, d := range devices {
+		if d.PK == "" {
+			continue
+		}
+		t.devices[d.PK] = Device{
+			PK:         d.PK,
+			Code:       d.Code,
+			Status:     d.Status,
+			DeviceType: d.DeviceType,
+			RouterID:   d.RouterID,
+			SRGBBase:   d.SRGBBase,
+			SRGBRange:  d.SRGBRange,
+			NodeSID:    d.NodeSID,
+			PrefixSIDs: d.PrefixSIDs,
+		}
+	}
+
+	pairLinks := make(map[devicePair][]string)
+	for _, l := range links {
+		if l.PK == "" || l.SideAPK == "" || l.SideZPK == "" {
+			continue
+		}
+		t.links[l.PK] = Link{PK: l.PK, Code: l.Code, Status: l.Status, SideAPK: l.SideAPK, SideZPK: l.SideZPK, Metric: l.ISISMetric}
+		pair := pairOf(l.SideAPK, l.SideZPK)
+		pairLinks[pair] = append(pairLinks[pair], l.PK)
+	}
+
+	seen := make(map[devicePair]int) // directed (from, to) -> index in out[from]
+	for _, adj := range adjacencies {
+		if adj.FromDevicePK == "" || adj.ToDevicePK == "" || adj.FromDevicePK == adj.ToDevicePK {
+			continue
+		}
+		for pk, code := range map[string]string{adj.FromDevicePK: adj.FromCode, adj.ToDevicePK: adj.ToCode} {
+			if _, ok := t.devices[pk]; !ok {
+				t.devices[pk] = Device{PK: pk, Code: code}
+			}
+		}
+
+		metric := adj.Metric
+		if metric == 0 {
+			metric = 1
+		}
+		key := devicePair{adj.FromDevicePK, adj.ToDevicePK}
+		if i, ok := seen[key]; ok {
+			existing := &t.out[adj.FromDevicePK][i]
+			if metric < existing.Metric {
+				existing.Metric = metric
+			}
+			existing.AdjSIDs = mergeSIDs(existing.AdjSIDs, adj.AdjSIDs)
+			continue
+		}
+
+		linkPKs := append([]string(nil), pairLinks[pairOf(adj.FromDevicePK, adj.ToDevicePK)]...)
+		sort.Strings(linkPKs)
+		seen[key] = len(t.out[adj.FromDevicePK])
+		t.out[adj.FromDevicePK] = append(t.out[adj.FromDevicePK], Adjacency{
+			From:    adj.FromDevicePK,
+			To:      adj.ToDevicePK,
+			Metric:  metric,
+			LinkPKs: linkPKs,
+			AdjSIDs: mergeSIDs(nil, adj.AdjSIDs),
+		})
+	}
+
+	t.index()
+	return t
+}
+
+// mergeSIDs adds the SIDs in add to sids, skipping duplicates, and returns them sorted.
+func mergeSIDs(sids, add []uint32) []uint32 {
+	for _, sid := range add {
+		if !slices.Contains(sids, sid) {
+			sids = append(sids, sid)
+		}
+	}
+	slices.Sort(sids)
+	return sids
+}
+
+// index sorts devices and adjacencies so every traversal is deterministic.
+func (t *Topology) index() {
+	t.pks = make([]string, 0, len(t.devices))
+	for pk := range t.devices {
+		t.pks = append(t.pks, pk)
+	}
+	sort.Strings(t.pks)
+	for _, adjs := range t.out {
+		sort.Slice(adjs, func(i, j int) bool { return adjs[i].To < adjs[j].To })
+	}
+}
+
+// Device returns the device with the given PK.
+func (t *Topology) Device(pk string) (Device, bool) {
+	d, ok := t.devices[pk]
+	return d, ok
+}
+
+// DevicePKs returns every device PK in sorted order.
+func (t *Topology) DevicePKs() []string {
+	return append([]string(nil), t.pks...)
+}
+
+// Link returns the link with the given PK.
+func (t *Topology) Link(pk string) (Link, bool) {
+	l, ok := t.links[pk]
+	return l, ok
+}
+
+// Adjacencies returns the adjacencies out of a device, ordered by neighbor.
+func (t *Topology) Adjacencies(pk string) []Adjacency {
+	return append([]Adjacency(nil), t.out[pk]...)
+}
+
+// Edges returns every undirected edge, ordered by endpoints.
+func (t *Topology) Edges() []Edge {
+	byPair := make(map[devicePair]*Edge)
+	for _, from := range t.pks {
+		for _, adj := range t.out[from] {
+			pair := pairOf(adj.From, adj.To)
+			e, ok := byPair[pair]
+			if !ok {
+				e = &Edge{A: pair.a, Z: pair.z, Metric: adj.Metric, LinkPKs: adj.LinkPKs}
+				byPair[pair] = e
+			}
+			if adj.Metric < e.Metric {
+				e.Metric = adj.Metric
+			}
+		}
+	}
+
+	edges := make([]Edge, 0, len(byPair))
+	for _, e := range byPair {
+		edges = append(edges, *e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].A != edges[j].A {
+			return edges[i].A < edges[j].A
+		}
+		return edges[i].Z < edges[j].Z
+	})
+	return edges
+}
+
+// neighbors returns each device's neighbors in either direction, sorted.
+func (t *Topology) neighbors() map[string][]string {
+	sets := make(map[string]map[string]bool, len(t.pks))
+	for _, pk := range t.pks {
+		sets[pk] = make(map[string]bool)
+	}
+	for _, from := range t.pks {
+		for _, adj := range t.out[from] {
+			sets[adj.From][adj.To] = true
+			sets[adj.To][adj.From] = true
+		}
+	}
+
+	neighbors := make(map[string][]string, len(sets))
+	for pk, set := range sets {
+		list := make([]string, 0, len(set))
+		for n := range set {
+			list = append(list, n)
+		}
+		sort.Strings(list)
+		neighbors[pk] = list
+	}
+	return neighbors
+}
+
+// Failures is a set of devices, links and edges taken out of service.
+type Failures struct {
+	Devices []string
+	Links   []string
+	// Edges removes the adjacencies in both directions between two devices, whatever links
+	// carry them.
+	Edges []Edge
+}
+
+// Without returns a copy of the topology with the failures removed. An adjacency is lost when
+// either device fails, its edge fails, or every link carrying it fails.
+func (t *Topology) Without(f Failures) *Topology {
+	failedDevices := make(map[string]bool, len(f.Devices))
+	for _, pk := range f.Devices {
+		failedDevices[pk] = true
+	}
+	failedLinks := make(map[string]bool, len(f.Links))
+	for _, pk := range f.Links {
+		failedLinks[pk] = true
+	}
+	failedEdges := make(map[devicePair]bool, len(f.Edges))
+	for _, e := range f.Edges {
+		failedEdges[pairOf(e.A, e.Z)] = true
+	}
+
+	n := &Topology{
+		devices: make(map[string]Device, len(t.devices)),
+		out:     make(map[string][]Adjacency, len(t.out)),
+		links:   make(map[string]Link, len(t.links)),
+	}
+	for pk, d := range t.devices {
+		if !failedDevices[pk] {
+			n.devices[pk] = d
+		}
+	}
+	for pk, l := range t.links {
+		if !failedLinks[pk] && !failedDevices[l.SideAPK] && !failedDevices[l.SideZPK] {
+			n.links[pk] = l
+		}
+	}
+	for from, adjs := range t.out {
+		if failedDevices[from] {
+			continue
+		}
+		for _, adj := range adjs {
+			if failedDevices[adj.To] || failedEdges[pairOf(adj.From, adj.To)] {
+				continue
+			}
+			var linkPKs []string
+			for _, pk := range adj.LinkPKs {
+				if !failedLinks[pk] {
+					linkPKs = append(linkPKs, pk)
+				}
+			}
+			if len(adj.LinkPKs) > 0 && len(linkPKs) == 0 {
+				continue
+			}
+			adj.LinkPKs = linkPKs
+			n.out[from] = append(n.out[from], adj)
+		}
+	}
+	n.index()
+	return n
+}
+
+// Reweighted returns a copy of the topology with every adjacency's metric replaced by weight,
+// e.g. to route by measured latency instead of the configured metrics. A zero weight counts
+// as 1.
+func (t *Topology) Reweighted(weight func(Adjacency) uint32) *Topology {
+	n := &Topology{
+		devices: t.devices,
+		out:     make(map[string][]Adjacency, len(t.out)),
+		links:   t.links,
+	}
+	for from, adjs := range t.out {
+		out := make([]Adjacency, len(adjs))
+		for i, adj := range adjs {
+			adj.Metric = max(weight(adj), 1)
+			out[i] = adj
+		}
+		n.out[from] = out
+	}
+	n.pks = t.pks
+	return n
+}
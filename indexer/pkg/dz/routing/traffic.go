@@ -0,0 +1,118 @@
+package routing
+
+import "sort"
+
+// Demand is traffic offered from one device to another, in bits per second.
+type Demand struct {
+	Source string
+	Target string
+	BPS    float64
+}
+
+// LinkDirection is one direction of a link, identified by the device sending onto it.
+type LinkDirection struct {
+	LinkPK string
+	From   string
+}
+
+// TrafficLoad is the traffic each link carries once demands are routed.
+type TrafficLoad struct {
+	Links map[LinkDirection]float64
+	// Unrouted is the demand with no path to its target.
+	Unrouted float64
+}
+
+// Distribute routes demands over the shortest paths. Like IS-IS forwarding, each device
+// splits traffic evenly across its equal-cost next hops, and an adjacency splits it evenly
+// across its parallel links. Traffic over adjacencies with no known link is dropped from
+// the per-link totals.
+func (t *Topology) Distribute(demands []Demand) TrafficLoad {
+	load := TrafficLoad{Links: make(map[LinkDirection]float64)}
+
+	bySource := make(map[string][]Demand)
+	for _, d := range demands {
+		if d.BPS <= 0 || d.Source == d.Target {
+			continue
+		}
+		bySource[d.Source] = append(bySource[d.Source], d)
+	}
+	sources := make([]string, 0, len(bySource))
+	for src := range bySource {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	for _, src := range sources {
+		tree := t.SPF(src)
+		for _, d := range bySource[src] {
+			if !tree.Reachable(d.Target) {
+				load.Unrouted += d.BPS
+				continue
+			}
+			for edge, bps := range tree.flow(d.Target, d.BPS) {
+				t.addEdgeLoad(load.Links, edge.a, edge.z, bps)
+			}
+		}
+	}
+	return load
+}
+
+// flow splits bps from the source to dst over the equal-cost paths, returning the traffic on
+// each directed adjacency.
+func (tr *Tree) flow(dst string, bps float64) map[devicePair]float64 {
+	// Successors of each device on the shortest paths to dst
+	next := make(map[string][]string)
+	onPath := map[string]bool{dst: true}
+	queue := []string{dst}
+	for i := 0; i < len(queue); i++ {
+		for _, p := range tr.preds[queue[i]] {
+			next[p] = append(next[p], queue[i])
+			if !onPath[p] {
+				onPath[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	order := make([]string, 0, len(onPath))
+	for pk := range onPath {
+		order = append(order, pk)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if tr.dist[order[i]] != tr.dist[order[j]] {
+			return tr.dist[order[i]] < tr.dist[order[j]]
+		}
+		return order[i] < order[j]
+	})
+
+	edges := make(map[devicePair]float64)
+	inflow := map[string]float64{tr.source: bps}
+	for _, pk := range order {
+		hops := next[pk]
+		if len(hops) == 0 || inflow[pk] == 0 {
+			continue
+		}
+		share := inflow[pk] / float64(len(hops))
+		for _, n := range hops {
+			edges[devicePair{pk, n}] += share
+			inflow[n] += share
+		}
+	}
+	return edges
+}
+
+func (t *Topology) addEdgeLoad(links map[LinkDirection]float64, from, to string, bps float64) {
+	for _, adj := range t.out[from] {
+		if adj.To != to {
+			continue
+		}
+		if len(adj.LinkPKs) == 0 {
+			return
+		}
+		share := bps / float64(len(adj.LinkPKs))
+		for _, pk := range adj.LinkPKs {
+			links[LinkDirection{LinkPK: pk, From: from}] += share
+		}
+		return
+	}
+}
@@ -13,6 +13,8 @@ import (
 type StoreConfig struct {
 	Logger     *slog.Logger
 	ClickHouse clickhouse.Client
+	// ChangeSink, if set, receives the change events of each write (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *StoreConfig) Validate() error {
@@ -64,6 +66,7 @@ func (s *Store) ReplaceContributors(ctx context.Context, contributors []Contribu
 		return contributorSchema.ToRow(contributors[i]), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	}); err != nil {
 		return fmt.Errorf("failed to write contributors to ClickHouse: %w", err)
 	}
@@ -90,6 +93,7 @@ func (s *Store) ReplaceDevices(ctx context.Context, devices []Device) error {
 		return deviceSchema.ToRow(devices[i]), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	}); err != nil {
 		return fmt.Errorf("failed to write devices to ClickHouse: %w", err)
 	}
@@ -116,6 +120,7 @@ func (s *Store) ReplaceUsers(ctx context.Context, users []User) error {
 		return userSchema.ToRow(users[i]), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	}); err != nil {
 		return fmt.Errorf("failed to write users to ClickHouse: %w", err)
 	}
@@ -142,6 +147,7 @@ func (s *Store) ReplaceMetros(ctx context.Context, metros []Metro) error {
 		return metroSchema.ToRow(metros[i]), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	}); err != nil {
 		return fmt.Errorf("failed to write metros to ClickHouse: %w", err)
 	}
@@ -168,6 +174,7 @@ func (s *Store) ReplaceLinks(ctx context.Context, links []Link) error {
 		return linkSchema.ToRow(links[i]), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	}); err != nil {
 		return fmt.Errorf("failed to write links to ClickHouse: %w", err)
 	}
@@ -194,6 +201,7 @@ func (s *Store) ReplaceMulticastGroups(ctx context.Context, groups []MulticastGr
 		return multicastGroupSchema.ToRow(groups[i]), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	}); err != nil {
 		return fmt.Errorf("failed to write multicast groups to ClickHouse: %w", err)
 	}
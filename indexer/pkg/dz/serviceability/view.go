@@ -13,6 +13,7 @@ import (
 	"github.com/jonboulle/clockwork"
 	"github.com/malbeclabs/doublezero/smartcontract/sdk/go/serviceability"
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
 	"github.com/malbeclabs/lake/indexer/pkg/metrics"
 )
 
@@ -118,6 +119,8 @@ type ViewConfig struct {
 	ServiceabilityRPC ServiceabilityRPC
 	RefreshInterval   time.Duration
 	ClickHouse        clickhouse.Client
+	// ChangeSink, if set, receives the change events of the view's writes (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *ViewConfig) Validate() error {
@@ -159,6 +162,7 @@ func NewView(cfg ViewConfig) (*View, error) {
 	store, err := NewStore(StoreConfig{
 		Logger:     cfg.Logger,
 		ClickHouse: cfg.ClickHouse,
+		ChangeSink: cfg.ChangeSink,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create store: %w", err)
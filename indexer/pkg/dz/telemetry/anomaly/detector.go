@@ -0,0 +1,215 @@
+package dztelemanomaly
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+type Metric string
+
+const (
+	MetricRTT    Metric = "rtt"
+	MetricJitter Metric = "jitter"
+	MetricLoss   Metric = "loss"
+)
+
+const (
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+	// SeverityCleared marks a window that was flagged by an earlier evaluation but no longer
+	// qualifies, e.g. once late samples arrived. It replaces the earlier row.
+	SeverityCleared = "cleared"
+)
+
+// madScale makes the median absolute deviation a consistent estimator of the standard
+// deviation for normally distributed values.
+const madScale = 1.4826
+
+// Window is a link's latency samples, both directions combined, aggregated over one detection
+// window. RTTUs and JitterUs are NaN when no sample in the window was delivered.
+type Window struct {
+	LinkPK   string
+	Start    time.Time
+	Samples  int64
+	RTTUs    float64 // median RTT of delivered samples
+	JitterUs float64 // mean IPDV of delivered samples
+	LossPct  float64
+}
+
+func (w Window) value(m Metric) float64 {
+	switch m {
+	case MetricRTT:
+		return w.RTTUs
+	case MetricJitter:
+		return w.JitterUs
+	default:
+		return w.LossPct
+	}
+}
+
+// Anomaly is a window whose metric deviated above the link's baseline.
+type Anomaly struct {
+	LinkPK          string
+	WindowStart     time.Time
+	Metric          Metric
+	Value           float64
+	BaselineMedian  float64
+	BaselineMAD     float64
+	Score           float64
+	Severity        string
+	Samples         int64
+	BaselineWindows int64
+	Seasonal        bool
+}
+
+type DetectorConfig struct {
+	// Window is the width of the aggregation window (default: 5m).
+	Window time.Duration
+	// Lookback is how much history before a window its baseline is built from (default: 7d).
+	Lookback time.Duration
+	// MinSamples is the minimum number of samples for a window to be evaluated (default: 10).
+	MinSamples int64
+	// MinBaselineWindows is the minimum number of baseline windows for a window to be
+	// evaluated (default: 24).
+	MinBaselineWindows int
+	// MinSeasonalWindows is the minimum number of windows at the same hour of day for the
+	// baseline to be seasonal; below it the whole lookback is used (default: 12).
+	MinSeasonalWindows int
+	// WarningScore and CriticalScore are the deviations, in scaled MADs above the baseline
+	// median, at which a window is flagged (defaults: 5 and 10).
+	WarningScore  float64
+	CriticalScore float64
+}
+
+func (cfg *DetectorConfig) setDefaults() {
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Minute
+	}
+	if cfg.Lookback <= 0 {
+		cfg.Lookback = 7 * 24 * time.Hour
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 10
+	}
+	if cfg.MinBaselineWindows <= 0 {
+		cfg.MinBaselineWindows = 24
+	}
+	if cfg.MinSeasonalWindows <= 0 {
+		cfg.MinSeasonalWindows = 12
+	}
+	if cfg.WarningScore <= 0 {
+		cfg.WarningScore = 5
+	}
+	if cfg.CriticalScore <= 0 {
+		cfg.CriticalScore = 2 * cfg.WarningScore
+	}
+}
+
+// minSpread is the smallest spread a baseline is scored against, so that links with very
+// stable history don't flag deviations too small to matter. RTT and jitter floors scale with
+// the baseline; loss is in percentage points.
+func minSpread(m Metric, median float64) float64 {
+	switch m {
+	case MetricRTT:
+		return max(0.02*median, 50)
+	case MetricJitter:
+		return max(0.1*median, 20)
+	default:
+		return 0.5
+	}
+}
+
+// Detect evaluates the windows of one link that start at or after from against baselines built
+// from the windows before them. windows must be sorted by start time. Only deviations above the
+// baseline are flagged, since a link getting faster or less lossy is not a degradation.
+func Detect(cfg DetectorConfig, windows []Window, from time.Time) []Anomaly {
+	cfg.setDefaults()
+
+	var anomalies []Anomaly
+	for i, w := range windows {
+		if w.Start.Before(from) || w.Samples < cfg.MinSamples {
+			continue
+		}
+
+		var all, seasonal []Window
+		for _, b := range windows[:i] {
+			if b.Start.Before(w.Start.Add(-cfg.Lookback)) || b.Samples < cfg.MinSamples {
+				continue
+			}
+			all = append(all, b)
+			if b.Start.Hour() == w.Start.Hour() {
+				seasonal = append(seasonal, b)
+			}
+		}
+		baseline, isSeasonal := all, false
+		if len(seasonal) >= cfg.MinSeasonalWindows {
+			baseline, isSeasonal = seasonal, true
+		}
+		if len(baseline) < cfg.MinBaselineWindows {
+			continue
+		}
+
+		for _, m := range []Metric{MetricRTT, MetricJitter, MetricLoss} {
+			value := w.value(m)
+			if math.IsNaN(value) {
+				continue
+			}
+			values := make([]float64, 0, len(baseline))
+			for _, b := range baseline {
+				if v := b.value(m); !math.IsNaN(v) {
+					values = append(values, v)
+				}
+			}
+			if len(values) < cfg.MinBaselineWindows {
+				continue
+			}
+
+			med, mad := medianMAD(values)
+			spread := max(madScale*mad, minSpread(m, med))
+			score := (value - med) / spread
+			if score < cfg.WarningScore {
+				continue
+			}
+
+			severity := SeverityWarning
+			if score >= cfg.CriticalScore {
+				severity = SeverityCritical
+			}
+			anomalies = append(anomalies, Anomaly{
+				LinkPK:          w.LinkPK,
+				WindowStart:     w.Start,
+				Metric:          m,
+				Value:           value,
+				BaselineMedian:  med,
+				BaselineMAD:     mad,
+				Score:           score,
+				Severity:        severity,
+				Samples:         w.Samples,
+				BaselineWindows: int64(len(values)),
+				Seasonal:        isSeasonal,
+			})
+		}
+	}
+	return anomalies
+}
+
+// medianMAD returns the median of values and their median absolute deviation from it.
+// values is reordered.
+func medianMAD(values []float64) (float64, float64) {
+	med := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - med)
+	}
+	return med, median(deviations)
+}
+
+func median(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
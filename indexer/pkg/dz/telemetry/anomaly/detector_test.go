@@ -0,0 +1,150 @@
+package dztelemanomaly
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// steadyWindows returns n windows of a link with RTT alternating around rttUs, ending at end.
+func steadyWindows(linkPK string, end time.Time, n int, rttUs float64) []Window {
+	windows := make([]Window, n)
+	for i := range windows {
+		windows[i] = Window{
+			LinkPK:   linkPK,
+			Start:    end.Add(-time.Duration(n-i) * 5 * time.Minute),
+			Samples:  30,
+			RTTUs:    rttUs + float64(i%3)*10,
+			JitterUs: 40 + float64(i%2)*5,
+			LossPct:  0,
+		}
+	}
+	return windows
+}
+
+func TestLake_TelemetryAnomaly_Detect(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("flags latency increase above baseline", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 14000, JitterUs: 42, LossPct: 0})
+
+		anomalies := Detect(DetectorConfig{}, windows, now)
+		require.Len(t, anomalies, 1)
+		a := anomalies[0]
+		require.Equal(t, MetricRTT, a.Metric)
+		require.Equal(t, "link1", a.LinkPK)
+		require.Equal(t, now, a.WindowStart)
+		require.Equal(t, float64(14000), a.Value)
+		require.Equal(t, float64(10010), a.BaselineMedian)
+		require.Equal(t, SeverityCritical, a.Severity)
+		require.False(t, a.Seasonal)
+		require.Equal(t, int64(100), a.BaselineWindows)
+	})
+
+	t.Run("ignores deviations within the spread floor", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		// 100us over a 10ms baseline is below the 2% floor times the warning score
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 10100, JitterUs: 45, LossPct: 0})
+
+		require.Empty(t, Detect(DetectorConfig{}, windows, now))
+	})
+
+	t.Run("does not flag improvements", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 5000, JitterUs: 1, LossPct: 0})
+
+		require.Empty(t, Detect(DetectorConfig{}, windows, now))
+	})
+
+	t.Run("flags loss and skips RTT when every sample was lost", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: math.NaN(), JitterUs: math.NaN(), LossPct: 100})
+
+		anomalies := Detect(DetectorConfig{}, windows, now)
+		require.Len(t, anomalies, 1)
+		require.Equal(t, MetricLoss, anomalies[0].Metric)
+		require.Equal(t, float64(100), anomalies[0].Value)
+	})
+
+	t.Run("flags jitter increase", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 10010, JitterUs: 400, LossPct: 0})
+
+		anomalies := Detect(DetectorConfig{}, windows, now)
+		require.Len(t, anomalies, 1)
+		require.Equal(t, MetricJitter, anomalies[0].Metric)
+	})
+
+	t.Run("requires enough baseline windows", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 10, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 50000, JitterUs: 42, LossPct: 0})
+
+		require.Empty(t, Detect(DetectorConfig{}, windows, now))
+	})
+
+	t.Run("skips windows with too few samples", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 3, RTTUs: 50000, JitterUs: 42, LossPct: 0})
+
+		require.Empty(t, Detect(DetectorConfig{}, windows, now))
+	})
+
+	t.Run("only evaluates windows from the given time", func(t *testing.T) {
+		t.Parallel()
+
+		windows := steadyWindows("link1", now, 100, 10000)
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 50000, JitterUs: 42, LossPct: 0})
+
+		require.Empty(t, Detect(DetectorConfig{}, windows, now.Add(5*time.Minute)))
+	})
+
+	t.Run("uses a seasonal baseline when the hour of day has enough history", func(t *testing.T) {
+		t.Parallel()
+
+		// Two days of hourly-varying RTT: 20ms during hour 12, 10ms otherwise
+		var windows []Window
+		start := now.Add(-48 * time.Hour)
+		for ts := start; ts.Before(now); ts = ts.Add(5 * time.Minute) {
+			rtt := 10000.0
+			if ts.Hour() == 12 {
+				rtt = 20000
+			}
+			windows = append(windows, Window{LinkPK: "link1", Start: ts, Samples: 30, RTTUs: rtt, JitterUs: 40, LossPct: 0})
+		}
+		windows = append(windows, Window{LinkPK: "link1", Start: now, Samples: 30, RTTUs: 20000, JitterUs: 40, LossPct: 0})
+
+		// 20ms is normal for hour 12 even though most of the lookback is at 10ms
+		require.Empty(t, Detect(DetectorConfig{}, windows, now))
+	})
+}
+
+func TestLake_TelemetryAnomaly_MedianMAD(t *testing.T) {
+	t.Parallel()
+
+	med, mad := medianMAD([]float64{1, 2, 3, 4, 100})
+	require.Equal(t, float64(3), med)
+	require.Equal(t, float64(1), mad)
+
+	med, mad = medianMAD([]float64{4, 1, 3, 2})
+	require.Equal(t, 2.5, med)
+	require.Equal(t, float64(1), mad)
+}
@@ -0,0 +1,58 @@
+package dztelemanomaly
+
+import (
+	"log/slog"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+)
+
+type LinkAnomalySchema struct{}
+
+func (s *LinkAnomalySchema) Name() string {
+	return "dz_link_anomalies"
+}
+
+func (s *LinkAnomalySchema) UniqueKeyColumns() []string {
+	return []string{"event_ts", "link_pk", "metric"}
+}
+
+func (s *LinkAnomalySchema) Columns() []string {
+	return []string{
+		"ingested_at:TIMESTAMP",
+		"link_pk:VARCHAR",
+		"metric:VARCHAR",
+		"window_seconds:INTEGER",
+		"value:DOUBLE",
+		"baseline_median:DOUBLE",
+		"baseline_mad:DOUBLE",
+		"score:DOUBLE",
+		"severity:VARCHAR",
+		"samples:BIGINT",
+		"baseline_windows:BIGINT",
+		"seasonal:BOOLEAN",
+	}
+}
+
+func (s *LinkAnomalySchema) TimeColumn() string {
+	return "event_ts"
+}
+
+func (s *LinkAnomalySchema) PartitionByTime() bool {
+	return true
+}
+
+func (s *LinkAnomalySchema) Grain() string {
+	return "one row per anomalous link, metric and detection window; severity cleared marks a retracted anomaly"
+}
+
+func (s *LinkAnomalySchema) DedupMode() dataset.DedupMode {
+	return dataset.DedupReplacing
+}
+
+func (s *LinkAnomalySchema) DedupVersionColumn() string {
+	return "ingested_at"
+}
+
+func NewLinkAnomalyDataset(log *slog.Logger) (*dataset.FactDataset, error) {
+	return dataset.NewFactDataset(log, &LinkAnomalySchema{})
+}
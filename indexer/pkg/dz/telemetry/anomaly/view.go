@@ -0,0 +1,322 @@
+package dztelemanomaly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/indexer/pkg/metrics"
+)
+
+type ViewConfig struct {
+	Logger          *slog.Logger
+	Clock           clockwork.Clock
+	ClickHouse      clickhouse.Client
+	RefreshInterval time.Duration
+	Detector        DetectorConfig
+	// Reevaluate is how far back completed windows are re-evaluated on each refresh, so samples
+	// that arrive late are still scored (default: 1h). Rewrites replace earlier rows, and
+	// windows that no longer qualify are cleared.
+	Reevaluate time.Duration
+	// ChangeSink, if set, receives the change events of the view's writes (optional)
+	ChangeSink dataset.ChangeSink
+}
+
+func (cfg *ViewConfig) Validate() error {
+	if cfg.Logger == nil {
+		return errors.New("logger is required")
+	}
+	if cfg.ClickHouse == nil {
+		return errors.New("clickhouse connection is required")
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("refresh interval must be greater than 0")
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	cfg.Detector.setDefaults()
+	if cfg.Reevaluate <= 0 {
+		cfg.Reevaluate = time.Hour
+	}
+	return nil
+}
+
+// View periodically scores completed windows of link latency samples against per-link
+// baselines and writes the deviations to fact_dz_link_anomalies.
+type View struct {
+	log       *slog.Logger
+	cfg       ViewConfig
+	refreshMu sync.Mutex // prevents concurrent refreshes
+
+	// evaluatedUntil is the end of the last window evaluated, so refreshes within a window
+	// are skipped.
+	evaluatedUntil time.Time
+	// windows holds each link's detection windows over the lookback, up to windowsUntil, so
+	// a refresh only re-reads the re-evaluation period and whatever completed since. Nil until
+	// the first successful refresh reads the whole lookback.
+	windows      map[string][]Window
+	windowsUntil time.Time
+}
+
+func NewView(cfg ViewConfig) (*View, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &View{
+		log: cfg.Logger,
+		cfg: cfg,
+	}, nil
+}
+
+func (v *View) Start(ctx context.Context) {
+	go func() {
+		v.log.Info("telemetry/anomaly: starting refresh loop", "interval", v.cfg.RefreshInterval, "window", v.cfg.Detector.Window)
+
+		v.safeRefresh(ctx)
+
+		ticker := v.cfg.Clock.NewTicker(v.cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.Chan():
+				v.safeRefresh(ctx)
+			}
+		}
+	}()
+}
+
+// safeRefresh wraps Refresh with panic recovery to prevent the refresh loop from dying
+func (v *View) safeRefresh(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.log.Error("telemetry/anomaly: refresh panicked", "panic", r)
+			metrics.ViewRefreshTotal.WithLabelValues("anomaly", "panic").Inc()
+		}
+	}()
+
+	if err := v.Refresh(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		v.log.Error("telemetry/anomaly: refresh failed", "error", err)
+	}
+}
+
+// Refresh evaluates the windows completed since the last refresh, along with the re-evaluation
+// period before them.
+func (v *View) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	window := v.cfg.Detector.Window
+	end := v.cfg.Clock.Now().UTC().Truncate(window)
+	if !end.After(v.evaluatedUntil) {
+		return nil
+	}
+
+	refreshStart := time.Now()
+	defer func() {
+		duration := time.Since(refreshStart)
+		v.log.Info("telemetry/anomaly: refresh completed", "duration", duration.String())
+		metrics.ViewRefreshDuration.WithLabelValues("anomaly").Observe(duration.Seconds())
+	}()
+
+	from := end.Add(-v.cfg.Reevaluate)
+	lookbackStart := from.Add(-v.cfg.Detector.Lookback)
+	queryStart := lookbackStart
+	if v.windows != nil && v.windowsUntil.After(lookbackStart) {
+		queryStart = from
+		if v.windowsUntil.Before(from) {
+			queryStart = v.windowsUntil
+		}
+	}
+	fetched, err := v.queryWindows(ctx, queryStart, end)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("anomaly", "error").Inc()
+		return fmt.Errorf("failed to query latency windows: %w", err)
+	}
+	windows := mergeWindows(v.windows, fetched, lookbackStart, queryStart)
+
+	var anomalies []Anomaly
+	for _, linkWindows := range windows {
+		anomalies = append(anomalies, Detect(v.cfg.Detector, linkWindows, from)...)
+	}
+	cleared, err := v.clearedAnomalies(ctx, anomalies, from, end)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("anomaly", "error").Inc()
+		return fmt.Errorf("failed to query previous link anomalies: %w", err)
+	}
+	anomalies = append(anomalies, cleared...)
+
+	if err := v.writeAnomalies(ctx, anomalies); err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("anomaly", "error").Inc()
+		return fmt.Errorf("failed to write link anomalies: %w", err)
+	}
+
+	v.log.Debug("telemetry/anomaly: evaluated windows", "from", from, "to", end, "links", len(windows), "anomalies", len(anomalies)-len(cleared), "cleared", len(cleared))
+	v.evaluatedUntil = end
+	v.windows, v.windowsUntil = windows, end
+	metrics.ViewRefreshTotal.WithLabelValues("anomaly", "success").Inc()
+	return nil
+}
+
+// mergeWindows keeps the cached windows in [lookbackStart, queryStart), which are settled, and
+// appends the windows read from queryStart on. Links are kept while they have any window.
+func mergeWindows(cached, fetched map[string][]Window, lookbackStart, queryStart time.Time) map[string][]Window {
+	merged := make(map[string][]Window, len(fetched))
+	for linkPK, ws := range cached {
+		var kept []Window
+		for _, w := range ws {
+			if !w.Start.Before(lookbackStart) && w.Start.Before(queryStart) {
+				kept = append(kept, w)
+			}
+		}
+		if len(kept) > 0 {
+			merged[linkPK] = kept
+		}
+	}
+	for linkPK, ws := range fetched {
+		merged[linkPK] = append(merged[linkPK], ws...)
+	}
+	return merged
+}
+
+// queryWindows aggregates latency samples in [start, end) into detection windows, grouped by
+// link and sorted by start time.
+func (v *View) queryWindows(ctx context.Context, start, end time.Time) (map[string][]Window, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	query := `
+		SELECT
+			link_pk,
+			toStartOfInterval(event_ts, toIntervalSecond(?)) AS window_start,
+			toInt64(count()) AS samples,
+			toFloat64(quantileIf(0.5)(rtt_us, NOT loss AND rtt_us > 0)) AS rtt_us,
+			toFloat64(avgIf(ipdv_us, NOT loss AND ipdv_us IS NOT NULL)) AS jitter_us,
+			countIf(loss OR rtt_us = 0) * 100.0 / count() AS loss_pct
+		FROM fact_dz_device_link_latency
+		WHERE event_ts >= ? AND event_ts < ?
+		GROUP BY link_pk, window_start
+		ORDER BY link_pk, window_start
+	`
+	rows, err := conn.Query(ctx, query, int64(v.cfg.Detector.Window.Seconds()), start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := make(map[string][]Window)
+	for rows.Next() {
+		var w Window
+		if err := rows.Scan(&w.LinkPK, &w.Start, &w.Samples, &w.RTTUs, &w.JitterUs, &w.LossPct); err != nil {
+			return nil, fmt.Errorf("failed to scan latency window: %w", err)
+		}
+		w.Start = w.Start.UTC()
+		windows[w.LinkPK] = append(windows[w.LinkPK], w)
+	}
+	return windows, rows.Err()
+}
+
+// clearedAnomalies returns a cleared row for each window in [from, end) flagged by an earlier
+// evaluation that is not among anomalies, so re-evaluation retracts it instead of leaving it.
+func (v *View) clearedAnomalies(ctx context.Context, anomalies []Anomaly, from, end time.Time) ([]Anomaly, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT event_ts, link_pk, metric
+		FROM fact_dz_link_anomalies FINAL
+		WHERE event_ts >= ? AND event_ts < ? AND severity != ?
+	`, from, end, SeverityCleared)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type key struct {
+		start  time.Time
+		linkPK string
+		metric Metric
+	}
+	flagged := make(map[key]struct{}, len(anomalies))
+	for _, a := range anomalies {
+		flagged[key{a.WindowStart, a.LinkPK, a.Metric}] = struct{}{}
+	}
+
+	var cleared []Anomaly
+	for rows.Next() {
+		var (
+			start  time.Time
+			linkPK string
+			metric string
+		)
+		if err := rows.Scan(&start, &linkPK, &metric); err != nil {
+			return nil, fmt.Errorf("failed to scan link anomaly: %w", err)
+		}
+		start = start.UTC()
+		if _, ok := flagged[key{start, linkPK, Metric(metric)}]; ok {
+			continue
+		}
+		cleared = append(cleared, Anomaly{
+			LinkPK:      linkPK,
+			WindowStart: start,
+			Metric:      Metric(metric),
+			Severity:    SeverityCleared,
+		})
+	}
+	return cleared, rows.Err()
+}
+
+func (v *View) writeAnomalies(ctx context.Context, anomalies []Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	ds, err := NewLinkAnomalyDataset(v.log)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	ds.RecordChanges = true
+	ds.ChangeSink = v.cfg.ChangeSink
+
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	ingestedAt := time.Now().UTC()
+	windowSeconds := int32(v.cfg.Detector.Window.Seconds())
+	return ds.WriteBatch(ctx, conn, len(anomalies), func(i int) ([]any, error) {
+		a := anomalies[i]
+		return []any{
+			a.WindowStart, // event_ts
+			ingestedAt,    // ingested_at
+			a.LinkPK,
+			string(a.Metric),
+			windowSeconds,
+			a.Value,
+			a.BaselineMedian,
+			a.BaselineMAD,
+			a.Score,
+			a.Severity,
+			a.Samples,
+			a.BaselineWindows,
+			a.Seasonal,
+		}, nil
+	})
+}
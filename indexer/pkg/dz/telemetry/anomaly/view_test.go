@@ -0,0 +1,119 @@
+package dztelemanomaly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_TelemetryAnomaly_View_Refresh(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-3 * time.Hour)
+
+	// A sample every 20s for 3 hours at ~10ms, with the last complete window at 15ms
+	err = conn.Exec(ctx, `
+		INSERT INTO fact_dz_device_link_latency
+		SELECT
+			addSeconds(toDateTime64(?, 3), number * 20) AS event_ts,
+			now64(3) AS ingested_at,
+			1 AS epoch,
+			toInt32(number) AS sample_index,
+			'dev_a' AS origin_device_pk,
+			'dev_z' AS target_device_pk,
+			'link1' AS link_pk,
+			if(event_ts >= toDateTime64(?, 3), 15000, 10000 + (number % 3) * 10) AS rtt_us,
+			false AS loss,
+			toInt64(5) AS ipdv_us
+		FROM numbers(540)
+	`, start, now.Add(-5*time.Minute))
+	require.NoError(t, err)
+
+	clock := clockwork.NewFakeClockAt(now.Add(30 * time.Second))
+	view, err := NewView(ViewConfig{
+		Logger:          laketesting.NewLogger(),
+		Clock:           clock,
+		ClickHouse:      db,
+		RefreshInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, view.Refresh(ctx))
+
+	rows, err := conn.Query(ctx, `
+		SELECT event_ts, metric, value, baseline_median, severity, window_seconds
+		FROM fact_dz_link_anomalies FINAL
+		WHERE link_pk = 'link1'
+		ORDER BY event_ts, metric
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		eventTS        time.Time
+		metric         string
+		value          float64
+		baselineMedian float64
+		severity       string
+		windowSeconds  int32
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.eventTS, &r.metric, &r.value, &r.baselineMedian, &r.severity, &r.windowSeconds))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+
+	require.Len(t, got, 1)
+	require.Equal(t, now.Add(-5*time.Minute), got[0].eventTS.UTC())
+	require.Equal(t, string(MetricRTT), got[0].metric)
+	require.Equal(t, float64(15000), got[0].value)
+	require.Equal(t, float64(10010), got[0].baselineMedian)
+	require.Equal(t, SeverityCritical, got[0].severity)
+	require.Equal(t, int32(300), got[0].windowSeconds)
+
+	// Refreshing again before the next window completes is a no-op
+	require.NoError(t, view.Refresh(ctx))
+
+	// Late samples at the usual latency bring the flagged window back to its baseline, so the
+	// next evaluation clears it
+	err = conn.Exec(ctx, `
+		INSERT INTO fact_dz_device_link_latency
+		SELECT
+			addSeconds(toDateTime64(?, 3), number * 5 + 2) AS event_ts,
+			now64(3) AS ingested_at,
+			1 AS epoch,
+			toInt32(1000 + number) AS sample_index,
+			'dev_z' AS origin_device_pk,
+			'dev_a' AS target_device_pk,
+			'link1' AS link_pk,
+			10000 AS rtt_us,
+			false AS loss,
+			toInt64(5) AS ipdv_us
+		FROM numbers(30)
+	`, now.Add(-5*time.Minute))
+	require.NoError(t, err)
+	clock.Advance(5 * time.Minute)
+	require.NoError(t, view.Refresh(ctx))
+
+	cleared, err := conn.Query(ctx, `
+		SELECT severity FROM fact_dz_link_anomalies FINAL
+		WHERE link_pk = 'link1' AND event_ts = ? AND metric = 'rtt'
+	`, now.Add(-5*time.Minute))
+	require.NoError(t, err)
+	defer cleared.Close()
+	require.True(t, cleared.Next())
+	var severity string
+	require.NoError(t, cleared.Scan(&severity))
+	require.Equal(t, SeverityCleared, severity)
+}
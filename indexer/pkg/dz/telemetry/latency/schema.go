@@ -93,6 +93,114 @@ func (s *InternetMetroLatencySchema) DedupVersionColumn() string {
 	return "ingested_at"
 }
 
+type LinkLatencyAggregateSchema struct{}
+
+func (s *LinkLatencyAggregateSchema) Name() string {
+	return "dz_link_latency_aggregates"
+}
+
+func (s *LinkLatencyAggregateSchema) UniqueKeyColumns() []string {
+	return []string{"event_ts", "link_pk", "epoch", "granularity"}
+}
+
+func (s *LinkLatencyAggregateSchema) Columns() []string {
+	return []string{
+		"ingested_at:TIMESTAMP",
+		"link_pk:VARCHAR",
+		"epoch:BIGINT",
+		"granularity:VARCHAR",
+		"period_seconds:BIGINT",
+		"samples:BIGINT",
+		"expected_samples:BIGINT",
+		"lost_samples:BIGINT",
+		"loss_pct:DOUBLE",
+		"rtt_min_us:DOUBLE",
+		"rtt_avg_us:DOUBLE",
+		"rtt_p50_us:DOUBLE",
+		"rtt_p95_us:DOUBLE",
+		"rtt_p99_us:DOUBLE",
+		"rtt_max_us:DOUBLE",
+		"jitter_avg_us:DOUBLE",
+		"jitter_p95_us:DOUBLE",
+		"jitter_p99_us:DOUBLE",
+		"committed_rtt_us:DOUBLE",
+		"committed_jitter_us:DOUBLE",
+		"rtt_sla_met:BOOLEAN",
+		"jitter_sla_met:BOOLEAN",
+	}
+}
+
+func (s *LinkLatencyAggregateSchema) TimeColumn() string {
+	return "event_ts"
+}
+
+func (s *LinkLatencyAggregateSchema) PartitionByTime() bool {
+	return true
+}
+
+func (s *LinkLatencyAggregateSchema) Grain() string {
+	return "one row per link, epoch and aggregation period"
+}
+
+func (s *LinkLatencyAggregateSchema) DedupMode() dataset.DedupMode {
+	return dataset.DedupReplacing
+}
+
+func (s *LinkLatencyAggregateSchema) DedupVersionColumn() string {
+	return "ingested_at"
+}
+
+type InternetAdvantageEpochSchema struct{}
+
+func (s *InternetAdvantageEpochSchema) Name() string {
+	return "dz_vs_internet_latency_epochs"
+}
+
+func (s *InternetAdvantageEpochSchema) UniqueKeyColumns() []string {
+	return []string{"epoch", "origin_metro", "target_metro"}
+}
+
+func (s *InternetAdvantageEpochSchema) Columns() []string {
+	return []string{
+		"ingested_at:TIMESTAMP",
+		"epoch:BIGINT",
+		"origin_metro:VARCHAR",
+		"target_metro:VARCHAR",
+		"end_ts:TIMESTAMP",
+		"dz_p50_rtt_ms:DOUBLE",
+		"dz_p95_rtt_ms:DOUBLE",
+		"dz_p99_rtt_ms:DOUBLE",
+		"dz_sample_count:BIGINT",
+		"internet_p50_rtt_ms:DOUBLE",
+		"internet_p95_rtt_ms:DOUBLE",
+		"internet_p99_rtt_ms:DOUBLE",
+		"internet_sample_count:BIGINT",
+		"compared_hours:BIGINT",
+		"dz_win_hours:BIGINT",
+		"improvement_stddev_ms:DOUBLE",
+	}
+}
+
+func (s *InternetAdvantageEpochSchema) TimeColumn() string {
+	return "event_ts"
+}
+
+func (s *InternetAdvantageEpochSchema) PartitionByTime() bool {
+	return false
+}
+
+func (s *InternetAdvantageEpochSchema) Grain() string {
+	return "one row per epoch and directly-connected metro pair"
+}
+
+func (s *InternetAdvantageEpochSchema) DedupMode() dataset.DedupMode {
+	return dataset.DedupReplacing
+}
+
+func (s *InternetAdvantageEpochSchema) DedupVersionColumn() string {
+	return "ingested_at"
+}
+
 func NewDeviceLinkLatencyDataset(log *slog.Logger) (*dataset.FactDataset, error) {
 	return dataset.NewFactDataset(log, &DeviceLinkLatencySchema{})
 }
@@ -100,3 +208,11 @@ func NewDeviceLinkLatencyDataset(log *slog.Logger) (*dataset.FactDataset, error)
 func NewInternetMetroLatencyDataset(log *slog.Logger) (*dataset.FactDataset, error) {
 	return dataset.NewFactDataset(log, &InternetMetroLatencySchema{})
 }
+
+func NewLinkLatencyAggregateDataset(log *slog.Logger) (*dataset.FactDataset, error) {
+	return dataset.NewFactDataset(log, &LinkLatencyAggregateSchema{})
+}
+
+func NewInternetAdvantageEpochDataset(log *slog.Logger) (*dataset.FactDataset, error) {
+	return dataset.NewFactDataset(log, &InternetAdvantageEpochSchema{})
+}
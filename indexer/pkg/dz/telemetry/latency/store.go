@@ -10,11 +10,14 @@ import (
 	"time"
 
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
 )
 
 type StoreConfig struct {
 	Logger     *slog.Logger
 	ClickHouse clickhouse.Client
+	// ChangeSink, if set, receives the change events of each write (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *StoreConfig) Validate() error {
@@ -158,6 +161,9 @@ func (s *Store) AppendDeviceLinkLatencySamples(ctx context.Context, samples []De
 	if err != nil {
 		return fmt.Errorf("failed to create dataset: %w", err)
 	}
+	// Latency writes are published to the change stream so consumers see new samples arrive
+	ds.RecordChanges = true
+	ds.ChangeSink = s.cfg.ChangeSink
 
 	conn, err := s.cfg.ClickHouse.Conn(ctx)
 	if err != nil {
@@ -321,6 +327,9 @@ func (s *Store) AppendInternetMetroLatencySamples(ctx context.Context, samples [
 	if err != nil {
 		return fmt.Errorf("failed to create dataset: %w", err)
 	}
+	// Latency writes are published to the change stream so consumers see new samples arrive
+	ds.RecordChanges = true
+	ds.ChangeSink = s.cfg.ChangeSink
 
 	conn, err := s.cfg.ClickHouse.Conn(ctx)
 	if err != nil {
@@ -0,0 +1,323 @@
+package dztelemlatency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InternetAdvantageEpoch is the DZ and public internet latency of a directly-connected metro pair
+// over one epoch. Metros are ordered alphabetically.
+type InternetAdvantageEpoch struct {
+	Epoch               uint64
+	OriginMetro         string
+	TargetMetro         string
+	StartTS             time.Time
+	EndTS               time.Time
+	DZP50RTTMs          float64
+	DZP95RTTMs          float64
+	DZP99RTTMs          float64
+	DZSampleCount       uint64
+	InternetP50RTTMs    float64
+	InternetP95RTTMs    float64
+	InternetP99RTTMs    float64
+	InternetSampleCount uint64
+	ComparedHours       uint64
+	DZWinHours          uint64
+	ImprovementStddevMs float64
+}
+
+// latestVersionAt orders the history rows of an entity so argMax picks the version in effect at
+// the time in ts, or its earliest version if it was first seen after ts.
+const latestVersionAt = `(%[1]s.snapshot_ts <= %[2]s, if(%[1]s.snapshot_ts <= %[2]s, 1, -1) * toUnixTimestamp64Milli(%[1]s.snapshot_ts))`
+
+// internetAdvantageQuery compares DZ and internet RTT per epoch and metro pair over the epochs in
+// %[1]s. Each link and internet pair is placed in metros as of its latest sample in the epoch,
+// from the history dims, so links and metros that changed or are gone are still attributed.
+var internetAdvantageQuery = `
+WITH
+dz_link_epochs AS (
+    SELECT link_pk, epoch, max(event_ts) AS ts
+    FROM fact_dz_device_link_latency
+    WHERE epoch IN (%[1]s) AND link_pk != ''
+    GROUP BY link_pk, epoch
+),
+dz_link_sides AS (
+    SELECT
+        le.link_pk AS link_pk,
+        le.epoch AS epoch,
+        le.ts AS ts,
+        argMax(l.side_a_pk, ` + fmt.Sprintf(latestVersionAt, "l", "le.ts") + `) AS side_a_pk,
+        argMax(l.side_z_pk, ` + fmt.Sprintf(latestVersionAt, "l", "le.ts") + `) AS side_z_pk
+    FROM dz_link_epochs le
+    JOIN dim_dz_links_history l ON le.link_pk = l.pk
+    GROUP BY le.link_pk, le.epoch, le.ts
+),
+dz_device_metros AS (
+    SELECT
+        p.device_pk AS device_pk,
+        p.ts AS ts,
+        argMax(d.metro_pk, ` + fmt.Sprintf(latestVersionAt, "d", "p.ts") + `) AS metro_pk
+    FROM (
+        SELECT side_a_pk AS device_pk, ts FROM dz_link_sides
+        UNION DISTINCT
+        SELECT side_z_pk AS device_pk, ts FROM dz_link_sides
+    ) p
+    JOIN dim_dz_devices_history d ON p.device_pk = d.pk
+    GROUP BY p.device_pk, p.ts
+),
+internet_pair_epochs AS (
+    SELECT origin_metro_pk, target_metro_pk, epoch, max(event_ts) AS ts
+    FROM fact_dz_internet_metro_latency
+    WHERE epoch IN (%[1]s)
+    GROUP BY origin_metro_pk, target_metro_pk, epoch
+),
+metro_points AS (
+    SELECT metro_pk, ts FROM dz_device_metros
+    UNION DISTINCT
+    SELECT origin_metro_pk AS metro_pk, ts FROM internet_pair_epochs
+    UNION DISTINCT
+    SELECT target_metro_pk AS metro_pk, ts FROM internet_pair_epochs
+),
+metro_codes AS (
+    SELECT
+        p.metro_pk AS metro_pk,
+        p.ts AS ts,
+        argMax(m.code, ` + fmt.Sprintf(latestVersionAt, "m", "p.ts") + `) AS code
+    FROM metro_points p
+    JOIN dim_dz_metros_history m ON p.metro_pk = m.pk
+    GROUP BY p.metro_pk, p.ts
+),
+dz_link_metros AS (
+    SELECT
+        s.link_pk AS link_pk,
+        s.epoch AS epoch,
+        least(ma.code, mz.code) AS metro1,
+        greatest(ma.code, mz.code) AS metro2
+    FROM dz_link_sides s
+    JOIN dz_device_metros da ON s.side_a_pk = da.device_pk AND s.ts = da.ts
+    JOIN dz_device_metros dz ON s.side_z_pk = dz.device_pk AND s.ts = dz.ts
+    JOIN metro_codes ma ON da.metro_pk = ma.metro_pk AND s.ts = ma.ts
+    JOIN metro_codes mz ON dz.metro_pk = mz.metro_pk AND s.ts = mz.ts
+    WHERE ma.code != mz.code  -- Exclude intra-metro links
+),
+internet_pair_metros AS (
+    SELECT
+        pe.origin_metro_pk AS origin_metro_pk,
+        pe.target_metro_pk AS target_metro_pk,
+        pe.epoch AS epoch,
+        least(mo.code, mt.code) AS metro1,
+        greatest(mo.code, mt.code) AS metro2
+    FROM internet_pair_epochs pe
+    JOIN metro_codes mo ON pe.origin_metro_pk = mo.metro_pk AND pe.ts = mo.ts
+    JOIN metro_codes mt ON pe.target_metro_pk = mt.metro_pk AND pe.ts = mt.ts
+    WHERE mo.code != mt.code  -- Exclude same-metro measurements
+),
+dz_samples AS (
+    SELECT f.epoch AS epoch, lm.metro1 AS metro1, lm.metro2 AS metro2, f.event_ts AS event_ts, f.rtt_us AS rtt_us
+    FROM fact_dz_device_link_latency f
+    JOIN dz_link_metros lm ON f.link_pk = lm.link_pk AND f.epoch = lm.epoch
+    WHERE f.epoch IN (%[1]s) AND f.link_pk != '' AND f.loss = false AND f.rtt_us > 0
+),
+internet_samples AS (
+    SELECT f.epoch AS epoch, pm.metro1 AS metro1, pm.metro2 AS metro2, f.event_ts AS event_ts, f.rtt_us AS rtt_us
+    FROM fact_dz_internet_metro_latency f
+    JOIN internet_pair_metros pm
+        ON f.origin_metro_pk = pm.origin_metro_pk
+        AND f.target_metro_pk = pm.target_metro_pk
+        AND f.epoch = pm.epoch
+    WHERE f.epoch IN (%[1]s) AND f.rtt_us > 0
+),
+dz_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        min(event_ts) AS start_ts,
+        max(event_ts) AS end_ts,
+        quantile(0.5)(rtt_us) / 1000.0 AS p50_rtt_ms,
+        quantile(0.95)(rtt_us) / 1000.0 AS p95_rtt_ms,
+        quantile(0.99)(rtt_us) / 1000.0 AS p99_rtt_ms,
+        count() AS sample_count
+    FROM dz_samples
+    GROUP BY epoch, metro1, metro2
+),
+internet_epoch AS (
+    SELECT
+        epoch, metro1, metro2,
+        min(event_ts) AS start_ts,
+        max(event_ts) AS end_ts,
+        quantile(0.5)(rtt_us) / 1000.0 AS p50_rtt_ms,
+        quantile(0.95)(rtt_us) / 1000.0 AS p95_rtt_ms,
+        quantile(0.99)(rtt_us) / 1000.0 AS p99_rtt_ms,
+        count() AS sample_count
+    FROM internet_samples
+    GROUP BY epoch, metro1, metro2
+),
+-- Hourly averages on each side, compared where both have samples
+hourly_epoch AS (
+    SELECT
+        dz.epoch AS epoch,
+        dz.metro1 AS metro1,
+        dz.metro2 AS metro2,
+        count() AS compared_hours,
+        countIf(dz.avg_rtt_ms < inet.avg_rtt_ms) AS dz_win_hours,
+        stddevPop(inet.avg_rtt_ms - dz.avg_rtt_ms) AS improvement_stddev_ms
+    FROM (
+        SELECT epoch, metro1, metro2, toStartOfHour(event_ts) AS hour, avg(rtt_us) / 1000.0 AS avg_rtt_ms
+        FROM dz_samples
+        GROUP BY epoch, metro1, metro2, hour
+    ) dz
+    JOIN (
+        SELECT epoch, metro1, metro2, toStartOfHour(event_ts) AS hour, avg(rtt_us) / 1000.0 AS avg_rtt_ms
+        FROM internet_samples
+        GROUP BY epoch, metro1, metro2, hour
+    ) inet
+        ON dz.epoch = inet.epoch
+        AND dz.metro1 = inet.metro1
+        AND dz.metro2 = inet.metro2
+        AND dz.hour = inet.hour
+    GROUP BY dz.epoch, dz.metro1, dz.metro2
+)
+SELECT
+    dz.epoch,
+    dz.metro1,
+    dz.metro2,
+    least(dz.start_ts, inet.start_ts),
+    greatest(dz.end_ts, inet.end_ts),
+    dz.p50_rtt_ms, dz.p95_rtt_ms, dz.p99_rtt_ms, dz.sample_count,
+    inet.p50_rtt_ms, inet.p95_rtt_ms, inet.p99_rtt_ms, inet.sample_count,
+    h.compared_hours, h.dz_win_hours, h.improvement_stddev_ms
+FROM dz_epoch dz
+JOIN internet_epoch inet
+    ON dz.epoch = inet.epoch
+    AND dz.metro1 = inet.metro1
+    AND dz.metro2 = inet.metro2
+LEFT JOIN hourly_epoch h
+    ON dz.epoch = h.epoch
+    AND dz.metro1 = h.metro1
+    AND dz.metro2 = h.metro2
+`
+
+// RefreshInternetAdvantageEpochs recomputes the per-epoch DZ vs internet latency of every
+// directly-connected metro pair for epochs, reading only those epochs' samples.
+func (s *Store) RefreshInternetAdvantageEpochs(ctx context.Context, epochs []uint64) error {
+	if len(epochs) == 0 {
+		return nil
+	}
+
+	// Epochs are integers, so they are inlined rather than bound four times over
+	epochList := make([]string, len(epochs))
+	for i, epoch := range epochs {
+		epochList[i] = strconv.FormatUint(epoch, 10)
+	}
+
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+	rows, err := conn.Query(ctx, fmt.Sprintf(internetAdvantageQuery, strings.Join(epochList, ", ")))
+	if err != nil {
+		return fmt.Errorf("failed to query internet advantage: %w", err)
+	}
+	defer rows.Close()
+
+	var results []InternetAdvantageEpoch
+	for rows.Next() {
+		var (
+			r     InternetAdvantageEpoch
+			epoch int64
+		)
+		if err := rows.Scan(&epoch, &r.OriginMetro, &r.TargetMetro, &r.StartTS, &r.EndTS,
+			&r.DZP50RTTMs, &r.DZP95RTTMs, &r.DZP99RTTMs, &r.DZSampleCount,
+			&r.InternetP50RTTMs, &r.InternetP95RTTMs, &r.InternetP99RTTMs, &r.InternetSampleCount,
+			&r.ComparedHours, &r.DZWinHours, &r.ImprovementStddevMs); err != nil {
+			return fmt.Errorf("failed to scan internet advantage: %w", err)
+		}
+		r.Epoch = uint64(epoch)
+		r.StartTS = r.StartTS.UTC()
+		r.EndTS = r.EndTS.UTC()
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := s.writeInternetAdvantageEpochs(ctx, results); err != nil {
+		return fmt.Errorf("failed to write internet advantage: %w", err)
+	}
+	s.log.Debug("telemetry/latency: internet advantage refreshed", "epochs", epochs, "pairs", len(results))
+	return nil
+}
+
+// UnrefreshedAdvantageEpochs returns the epochs with both device link and internet samples but
+// no DZ vs internet latency, such as those written before it was maintained.
+func (s *Store) UnrefreshedAdvantageEpochs(ctx context.Context) ([]uint64, error) {
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+	rows, err := conn.Query(ctx, `
+		SELECT DISTINCT epoch
+		FROM fact_dz_internet_metro_latency
+		WHERE epoch IN (SELECT DISTINCT epoch FROM fact_dz_device_link_latency)
+		  AND epoch NOT IN (SELECT DISTINCT epoch FROM fact_dz_vs_internet_latency_epochs)
+		ORDER BY epoch
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unrefreshed epochs: %w", err)
+	}
+	defer rows.Close()
+
+	var epochs []uint64
+	for rows.Next() {
+		var epoch int64
+		if err := rows.Scan(&epoch); err != nil {
+			return nil, fmt.Errorf("failed to scan epoch: %w", err)
+		}
+		epochs = append(epochs, uint64(epoch))
+	}
+	return epochs, rows.Err()
+}
+
+func (s *Store) writeInternetAdvantageEpochs(ctx context.Context, results []InternetAdvantageEpoch) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	ds, err := NewInternetAdvantageEpochDataset(s.log)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	ds.RecordChanges = true
+	ds.ChangeSink = s.cfg.ChangeSink
+
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	ingestedAt := time.Now().UTC()
+	return ds.WriteBatch(ctx, conn, len(results), func(i int) ([]any, error) {
+		r := results[i]
+		return []any{
+			r.StartTS,  // event_ts
+			ingestedAt, // ingested_at
+			int64(r.Epoch),
+			r.OriginMetro,
+			r.TargetMetro,
+			r.EndTS,
+			r.DZP50RTTMs,
+			r.DZP95RTTMs,
+			r.DZP99RTTMs,
+			r.DZSampleCount,
+			r.InternetP50RTTMs,
+			r.InternetP95RTTMs,
+			r.InternetP99RTTMs,
+			r.InternetSampleCount,
+			r.ComparedHours,
+			r.DZWinHours,
+			r.ImprovementStddevMs,
+		}, nil
+	})
+}
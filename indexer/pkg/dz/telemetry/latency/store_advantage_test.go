@@ -0,0 +1,103 @@
+package dztelemlatency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_TelemetryLatency_Store_RefreshInternetAdvantageEpochs(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	store, err := NewStore(StoreConfig{
+		Logger:     laketesting.NewLogger(),
+		ClickHouse: db,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	start := time.Now().UTC().Add(-6 * time.Hour).Truncate(time.Hour)
+
+	// The link is only first seen in the history dims after its samples, and has since been
+	// deleted, so it is resolved from its earliest version
+	require.NoError(t, conn.Exec(ctx, `INSERT INTO dim_dz_metros_history
+		(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash, pk, code, name)
+		VALUES
+		('metro-1', ?, now(), generateUUIDv4(), 0, 1, 'metro-1', 'FRA', 'Frankfurt'),
+		('metro-2', ?, now(), generateUUIDv4(), 0, 2, 'metro-2', 'AMS', 'Amsterdam')`, start, start))
+	require.NoError(t, conn.Exec(ctx, `INSERT INTO dim_dz_devices_history
+		(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+		 pk, status, device_type, code, public_ip, contributor_pk, metro_pk, max_users)
+		VALUES
+		('dev-1', ?, now(), generateUUIDv4(), 0, 1, 'dev-1', 'activated', 'router', 'FRA-DZ01', '', '', 'metro-1', 0),
+		('dev-2', ?, now(), generateUUIDv4(), 0, 2, 'dev-2', 'activated', 'router', 'AMS-DZ01', '', '', 'metro-2', 0)`, start, start))
+	require.NoError(t, conn.Exec(ctx, `INSERT INTO dim_dz_links_history
+		(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+		 pk, status, code, tunnel_net, contributor_pk, side_a_pk, side_z_pk,
+		 side_a_iface_name, side_z_iface_name, link_type, committed_rtt_ns,
+		 committed_jitter_ns, bandwidth_bps, isis_delay_override_ns)
+		VALUES
+		('link-1', ?, now(), generateUUIDv4(), 0, 1, 'link-1', 'activated', 'FRA-AMS', '', '', 'dev-1', 'dev-2', '', '', 'WAN', 0, 0, 10000000000, 0),
+		('link-1', ?, now(), generateUUIDv4(), 1, 2, 'link-1', 'activated', 'FRA-AMS', '', '', 'dev-1', 'dev-2', '', '', 'WAN', 0, 0, 10000000000, 0)`,
+		start.Add(5*time.Hour), start.Add(5*time.Hour+time.Minute)))
+
+	// Two hours of samples in epoch 100 and one in epoch 101: DZ at 5ms and the internet at 10ms
+	require.NoError(t, conn.Exec(ctx, `INSERT INTO fact_dz_device_link_latency
+		(event_ts, ingested_at, epoch, sample_index, origin_device_pk, target_device_pk, link_pk, rtt_us, loss, ipdv_us)
+		SELECT addMinutes(toDateTime64(?, 3), number * 10), now(), if(number < 12, 100, 101), number,
+		       'dev-1', 'dev-2', 'link-1', 5000, false, 100
+		FROM numbers(18)`, start))
+	require.NoError(t, conn.Exec(ctx, `INSERT INTO fact_dz_internet_metro_latency
+		(event_ts, ingested_at, epoch, sample_index, origin_metro_pk, target_metro_pk, data_provider, rtt_us, ipdv_us)
+		SELECT addMinutes(toDateTime64(?, 3), number * 10 + 5), now(), if(number < 12, 100, 101), number,
+		       'metro-2', 'metro-1', 'ripeatlas', 10000, 500
+		FROM numbers(18)`, start))
+
+	require.NoError(t, store.RefreshInternetAdvantageEpochs(ctx, []uint64{100}))
+
+	rows, err := conn.Query(ctx, `
+		SELECT epoch, origin_metro, target_metro, median_improvement_ms, median_improvement_pct,
+			dz_sample_count, internet_sample_count, compared_hours, dz_win_pct
+		FROM dz_vs_internet_latency_by_epoch`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		epoch                  int64
+		origin, target         string
+		improvementMs          float64
+		improvementPct         float64
+		dzSamples, inetSamples uint64
+		comparedHours          uint64
+		dzWinPct               *float64
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.epoch, &r.origin, &r.target, &r.improvementMs, &r.improvementPct,
+			&r.dzSamples, &r.inetSamples, &r.comparedHours, &r.dzWinPct))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+
+	// Only the refreshed epoch is aggregated
+	require.Len(t, got, 1)
+	r := got[0]
+	require.Equal(t, int64(100), r.epoch)
+	require.Equal(t, "AMS", r.origin)
+	require.Equal(t, "FRA", r.target)
+	require.InDelta(t, 5.0, r.improvementMs, 0.01)
+	require.InDelta(t, 50.0, r.improvementPct, 0.1)
+	require.Equal(t, uint64(12), r.dzSamples)
+	require.Equal(t, uint64(12), r.inetSamples)
+	require.Equal(t, uint64(2), r.comparedHours)
+	require.NotNil(t, r.dzWinPct)
+	require.InDelta(t, 100.0, *r.dzWinPct, 0.1)
+}
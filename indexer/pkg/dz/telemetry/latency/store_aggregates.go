@@ -0,0 +1,618 @@
+package dztelemlatency
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+)
+
+const (
+	AggregateGranularityInterval = "interval"
+	AggregateGranularityEpoch    = "epoch"
+)
+
+// DefaultAggregateInterval is the width of the interval aggregates when none is configured
+const DefaultAggregateInterval = 5 * time.Minute
+
+// maxEpochDuration bounds how far around the refreshed samples the sampling of their epochs is read.
+// Solana epochs are ~2-3 days.
+const maxEpochDuration = 4 * 24 * time.Hour
+
+// LinkLatencyAggregate summarizes the latency samples of a link, both directions combined, over
+// one interval or over an epoch. RTT and jitter fields are nil when no sample was delivered.
+type LinkLatencyAggregate struct {
+	PeriodStart     time.Time
+	LinkPK          string
+	Epoch           uint64
+	Granularity     string
+	PeriodSeconds   int64
+	Samples         int64
+	ExpectedSamples int64
+	LostSamples     int64
+	LossPct         float64
+
+	RTTMinUs *float64
+	RTTAvgUs *float64
+	RTTP50Us *float64
+	RTTP95Us *float64
+	RTTP99Us *float64
+	RTTMaxUs *float64
+
+	JitterAvgUs *float64
+	JitterP95Us *float64
+	JitterP99Us *float64
+
+	CommittedRTTUs    float64
+	CommittedJitterUs float64
+	// RTTSLAMet and JitterSLAMet report whether the average RTT and jitter were within the link's
+	// committed values, nil when the link has no commitment or no sample was delivered
+	RTTSLAMet    *bool
+	JitterSLAMet *bool
+}
+
+// circuitEpoch is the sampling schedule of one direction of a link during an epoch, derived from
+// the samples written for it
+type circuitEpoch struct {
+	originDevicePK string
+	targetDevicePK string
+	linkPK         string
+	epoch          uint64
+	start          time.Time     // time of sample index 0
+	step           time.Duration // sampling interval, 0 when fewer than two samples are known
+	last           time.Time     // time of the latest sample
+	end            time.Time     // start of the circuit's next epoch, zero if not seen yet
+}
+
+// elapsedUntil returns the end of the circuit's sampling so far: now, or just after the latest
+// sample if that is later, bounded by the start of the next epoch
+func (c circuitEpoch) elapsedUntil(now time.Time) time.Time {
+	elapsed := now
+	if next := c.last.Add(max(c.step, time.Millisecond)); next.After(elapsed) {
+		elapsed = next
+	}
+	if !c.end.IsZero() && elapsed.After(c.end) {
+		elapsed = c.end
+	}
+	return elapsed
+}
+
+// expectedSamples returns the number of sample slots of the circuit in [from, to) that have
+// elapsed by now
+func (c circuitEpoch) expectedSamples(from, to, now time.Time) int64 {
+	if from.Before(c.start) {
+		from = c.start
+	}
+	if elapsed := c.elapsedUntil(now); to.After(elapsed) {
+		to = elapsed
+	}
+	if !to.After(from) {
+		return 0
+	}
+	if c.step <= 0 {
+		if from.Equal(c.start) {
+			return 1
+		}
+		return 0
+	}
+	return ceilDiv(to.Sub(c.start), c.step) - ceilDiv(from.Sub(c.start), c.step)
+}
+
+func ceilDiv(d, step time.Duration) int64 {
+	return int64((d + step - 1) / step)
+}
+
+// linkEpoch identifies the samples of a link, both directions combined, during an epoch
+type linkEpoch struct {
+	linkPK string
+	epoch  uint64
+}
+
+// AggregatePeriods are the interval and epoch aggregates that written samples fell into, so a
+// refresh recomputes only those. The zero value is empty and uses DefaultAggregateInterval.
+type AggregatePeriods struct {
+	Interval time.Duration
+
+	intervals map[time.Time]map[string]struct{} // interval start -> links with samples in it
+	epochs    map[linkEpoch]struct{}
+	earliest  time.Time
+	latest    time.Time
+}
+
+func (p *AggregatePeriods) interval() time.Duration {
+	if p.Interval <= 0 {
+		return DefaultAggregateInterval
+	}
+	return p.Interval
+}
+
+// Add records the periods of samples with a link.
+func (p *AggregatePeriods) Add(samples []DeviceLinkLatencySample) {
+	for _, sample := range samples {
+		if sample.LinkPK == "" {
+			continue
+		}
+		t := sample.Time.UTC()
+		p.record(sample.LinkPK, sample.Epoch, t, t)
+	}
+}
+
+// record adds the interval of a link's samples from earliest to latest, which must share an
+// interval, and their epoch.
+func (p *AggregatePeriods) record(linkPK string, epoch uint64, earliest, latest time.Time) {
+	if p.intervals == nil {
+		p.intervals = make(map[time.Time]map[string]struct{})
+		p.epochs = make(map[linkEpoch]struct{})
+	}
+	start := earliest.Truncate(p.interval())
+	if p.intervals[start] == nil {
+		p.intervals[start] = make(map[string]struct{})
+	}
+	p.intervals[start][linkPK] = struct{}{}
+	p.epochs[linkEpoch{linkPK, epoch}] = struct{}{}
+	if p.earliest.IsZero() || earliest.Before(p.earliest) {
+		p.earliest = earliest
+	}
+	if latest.After(p.latest) {
+		p.latest = latest
+	}
+}
+
+// Empty reports whether no periods were recorded.
+func (p *AggregatePeriods) Empty() bool {
+	return len(p.epochs) == 0
+}
+
+// ranges returns the recorded intervals merged into contiguous [start, end) time ranges, in order
+func (p *AggregatePeriods) ranges() [][2]time.Time {
+	starts := make([]time.Time, 0, len(p.intervals))
+	for start := range p.intervals {
+		starts = append(starts, start)
+	}
+	slices.SortFunc(starts, time.Time.Compare)
+
+	var ranges [][2]time.Time
+	for _, start := range starts {
+		if n := len(ranges); n > 0 && ranges[n-1][1].Equal(start) {
+			ranges[n-1][1] = start.Add(p.interval())
+			continue
+		}
+		ranges = append(ranges, [2]time.Time{start, start.Add(p.interval())})
+	}
+	return ranges
+}
+
+// RefreshLinkLatencyAggregates recomputes the interval and epoch aggregates in periods, reading
+// only the samples of those intervals and of those links' epochs. now bounds the sample slots
+// counted as expected, so it should be the current time for live data.
+func (s *Store) RefreshLinkLatencyAggregates(ctx context.Context, links []dzsvc.Link, periods AggregatePeriods, now time.Time) error {
+	if periods.Empty() {
+		return nil
+	}
+	interval := periods.interval()
+	to := now.UTC()
+	if latest := periods.latest.Add(time.Millisecond); latest.After(to) {
+		to = latest
+	}
+
+	linkSet := make(map[string]struct{})
+	epochSet := make(map[uint64]struct{})
+	for le := range periods.epochs {
+		linkSet[le.linkPK] = struct{}{}
+		epochSet[le.epoch] = struct{}{}
+		epochSet[le.epoch+1] = struct{}{} // bounds the touched epoch
+	}
+	linkPKs := make([]any, 0, len(linkSet))
+	for pk := range linkSet {
+		linkPKs = append(linkPKs, pk)
+	}
+	epochs := make([]any, 0, len(epochSet))
+	for epoch := range epochSet {
+		epochs = append(epochs, int64(epoch))
+	}
+
+	circuits, err := s.queryCircuitEpochs(ctx, linkPKs, epochs,
+		periods.earliest.Add(-maxEpochDuration), to.Add(maxEpochDuration))
+	if err != nil {
+		return fmt.Errorf("failed to query circuit sampling: %w", err)
+	}
+	circuitsByLinkEpoch := make(map[linkEpoch][]circuitEpoch)
+	scanStart := to
+	for _, c := range circuits {
+		le := linkEpoch{c.linkPK, c.epoch}
+		circuitsByLinkEpoch[le] = append(circuitsByLinkEpoch[le], c)
+		if _, ok := periods.epochs[le]; ok && c.start.Before(scanStart) {
+			scanStart = c.start
+		}
+	}
+
+	// Intervals are read as contiguous time ranges, then narrowed to the links touched in each
+	var rangeConds []string
+	intervalArgs := append([]any{}, linkPKs...)
+	for _, r := range periods.ranges() {
+		rangeConds = append(rangeConds, "(event_ts >= ? AND event_ts < ?)")
+		intervalArgs = append(intervalArgs, r[0], r[1])
+	}
+	intervalAggs, err := s.queryLinkLatencyAggregates(ctx, interval,
+		fmt.Sprintf("link_pk IN (%s) AND (%s)", placeholders(len(linkPKs)), strings.Join(rangeConds, " OR ")),
+		intervalArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to query interval aggregates: %w", err)
+	}
+	epochArgs := append(append([]any{}, linkPKs...), epochs...)
+	epochArgs = append(epochArgs, scanStart.Truncate(time.Second), to)
+	epochAggs, err := s.queryLinkLatencyAggregates(ctx, 0,
+		fmt.Sprintf("link_pk IN (%s) AND epoch IN (%s) AND event_ts >= ? AND event_ts < ?", placeholders(len(linkPKs)), placeholders(len(epochs))),
+		epochArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to query epoch aggregates: %w", err)
+	}
+
+	linksByPK := make(map[string]dzsvc.Link, len(links))
+	for _, l := range links {
+		linksByPK[l.PK] = l
+	}
+
+	aggs := make([]LinkLatencyAggregate, 0, len(intervalAggs)+len(epochAggs))
+	for _, a := range intervalAggs {
+		if _, ok := periods.intervals[a.PeriodStart][a.LinkPK]; !ok {
+			continue
+		}
+		a.Granularity = AggregateGranularityInterval
+		a.PeriodSeconds = int64(interval.Seconds())
+		for _, c := range circuitsByLinkEpoch[linkEpoch{a.LinkPK, a.Epoch}] {
+			a.ExpectedSamples += c.expectedSamples(a.PeriodStart, a.PeriodStart.Add(interval), to)
+		}
+		aggs = append(aggs, a)
+	}
+	intervalCount := len(aggs)
+	for _, a := range epochAggs {
+		if _, ok := periods.epochs[linkEpoch{a.LinkPK, a.Epoch}]; !ok {
+			continue
+		}
+		a.Granularity = AggregateGranularityEpoch
+		var epochStart, epochEnd time.Time
+		for _, c := range circuitsByLinkEpoch[linkEpoch{a.LinkPK, a.Epoch}] {
+			elapsed := c.elapsedUntil(to)
+			a.ExpectedSamples += c.expectedSamples(c.start, elapsed, to)
+			if epochStart.IsZero() || c.start.Before(epochStart) {
+				epochStart = c.start
+			}
+			if elapsed.After(epochEnd) {
+				epochEnd = elapsed
+			}
+		}
+		// The epoch's first sample slot is stable as samples arrive, unlike its first sample
+		if !epochStart.IsZero() {
+			a.PeriodStart = epochStart.Truncate(time.Second)
+			a.PeriodSeconds = int64(epochEnd.Sub(epochStart).Seconds())
+		}
+		aggs = append(aggs, a)
+	}
+
+	for i := range aggs {
+		a := &aggs[i]
+		if a.ExpectedSamples < a.Samples {
+			a.ExpectedSamples = a.Samples
+		}
+		if link, ok := linksByPK[a.LinkPK]; ok {
+			a.CommittedRTTUs = float64(link.CommittedRTTNs) / 1000
+			a.CommittedJitterUs = float64(link.CommittedJitterNs) / 1000
+		}
+		a.RTTSLAMet = withinCommitted(a.RTTAvgUs, a.CommittedRTTUs)
+		a.JitterSLAMet = withinCommitted(a.JitterAvgUs, a.CommittedJitterUs)
+	}
+
+	if err := s.writeLinkLatencyAggregates(ctx, aggs); err != nil {
+		return fmt.Errorf("failed to write link latency aggregates: %w", err)
+	}
+	s.log.Debug("telemetry/device-link: aggregates refreshed", "from", periods.earliest, "to", to, "intervals", intervalCount, "epochs", len(aggs)-intervalCount)
+	return nil
+}
+
+// UnaggregatedEpochs returns, latest first, the epochs with device link samples older than the
+// earliest interval aggregate, which were written before aggregation began. Aggregating them
+// latest first moves that boundary back, so an interrupted backfill resumes where it stopped.
+func (s *Store) UnaggregatedEpochs(ctx context.Context) ([]uint64, error) {
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT count(), min(event_ts)
+		FROM fact_dz_link_latency_aggregates
+		WHERE granularity = ?
+	`, AggregateGranularityInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query earliest aggregate: %w", err)
+	}
+	var (
+		aggregated uint64
+		earliest   time.Time
+	)
+	if rows.Next() {
+		if err := rows.Scan(&aggregated, &earliest); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan earliest aggregate: %w", err)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT DISTINCT epoch FROM fact_dz_device_link_latency WHERE link_pk != ''`
+	var args []any
+	if aggregated > 0 {
+		query += ` AND event_ts < ?`
+		args = append(args, earliest)
+	}
+	rows, err = conn.Query(ctx, query+` ORDER BY epoch DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unaggregated epochs: %w", err)
+	}
+	defer rows.Close()
+
+	var epochs []uint64
+	for rows.Next() {
+		var epoch int64
+		if err := rows.Scan(&epoch); err != nil {
+			return nil, fmt.Errorf("failed to scan epoch: %w", err)
+		}
+		epochs = append(epochs, uint64(epoch))
+	}
+	return epochs, rows.Err()
+}
+
+// BackfillLinkLatencyAggregates recomputes the interval and epoch aggregates of every device
+// link sample already stored for epoch.
+func (s *Store) BackfillLinkLatencyAggregates(ctx context.Context, links []dzsvc.Link, epoch uint64, interval time.Duration, now time.Time) error {
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	periods := AggregatePeriods{Interval: interval}
+	rows, err := conn.Query(ctx, `
+		SELECT link_pk, min(event_ts), max(event_ts)
+		FROM fact_dz_device_link_latency
+		WHERE epoch = ? AND link_pk != ''
+		GROUP BY link_pk, toStartOfInterval(event_ts, toIntervalSecond(?))
+	`, int64(epoch), int64(periods.interval().Seconds()))
+	if err != nil {
+		return fmt.Errorf("failed to query epoch intervals: %w", err)
+	}
+	for rows.Next() {
+		var (
+			linkPK           string
+			earliest, latest time.Time
+		)
+		if err := rows.Scan(&linkPK, &earliest, &latest); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan epoch interval: %w", err)
+		}
+		periods.record(linkPK, epoch, earliest.UTC(), latest.UTC())
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	return s.RefreshLinkLatencyAggregates(ctx, links, periods, now)
+}
+
+// placeholders returns a comma-separated list of n ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// sampleTimeRange returns the times of the earliest and latest of a non-empty set of samples
+func sampleTimeRange(samples []DeviceLinkLatencySample) (time.Time, time.Time) {
+	earliest, latest := samples[0].Time, samples[0].Time
+	for _, sample := range samples[1:] {
+		if sample.Time.Before(earliest) {
+			earliest = sample.Time
+		}
+		if sample.Time.After(latest) {
+			latest = sample.Time
+		}
+	}
+	return earliest, latest
+}
+
+// withinCommitted compares a measured average against a committed value, returning nil when
+// either is unknown
+func withinCommitted(value *float64, committed float64) *bool {
+	if value == nil || committed <= 0 {
+		return nil
+	}
+	met := *value <= committed
+	return &met
+}
+
+// queryCircuitEpochs derives the sampling schedule of the circuits of linkPKs during epochs,
+// from their samples in [start, end)
+func (s *Store) queryCircuitEpochs(ctx context.Context, linkPKs, epochs []any, start, end time.Time) ([]circuitEpoch, error) {
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT
+		origin_device_pk,
+		target_device_pk,
+		link_pk,
+		epoch,
+		min(event_ts) AS first_ts,
+		max(event_ts) AS last_ts,
+		min(sample_index) AS min_idx,
+		max(sample_index) AS max_idx
+	FROM fact_dz_device_link_latency
+	WHERE event_ts >= ? AND event_ts < ? AND link_pk IN (%s) AND epoch IN (%s)
+	GROUP BY origin_device_pk, target_device_pk, link_pk, epoch`, placeholders(len(linkPKs)), placeholders(len(epochs)))
+	args := append([]any{start, end}, linkPKs...)
+	rows, err := conn.Query(ctx, query, append(args, epochs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var circuits []circuitEpoch
+	starts := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			c               circuitEpoch
+			epoch           int64
+			firstTS, lastTS time.Time
+			minIdx, maxIdx  int32
+		)
+		if err := rows.Scan(&c.originDevicePK, &c.targetDevicePK, &c.linkPK, &epoch, &firstTS, &lastTS, &minIdx, &maxIdx); err != nil {
+			return nil, fmt.Errorf("failed to scan circuit sampling: %w", err)
+		}
+		c.epoch = uint64(epoch)
+		c.last = lastTS.UTC()
+		c.start = firstTS.UTC()
+		if maxIdx > minIdx {
+			c.step = (lastTS.Sub(firstTS) / time.Duration(maxIdx-minIdx)).Round(time.Millisecond)
+			c.start = c.start.Add(-time.Duration(minIdx) * c.step)
+		}
+		starts[fmt.Sprintf("%s:%s:%s:%d", c.originDevicePK, c.targetDevicePK, c.linkPK, c.epoch)] = c.start
+		circuits = append(circuits, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range circuits {
+		c := &circuits[i]
+		if next, ok := starts[fmt.Sprintf("%s:%s:%s:%d", c.originDevicePK, c.targetDevicePK, c.linkPK, c.epoch+1)]; ok {
+			c.end = next
+		}
+	}
+	return circuits, nil
+}
+
+// queryLinkLatencyAggregates aggregates the samples matching where per link, epoch and interval,
+// or per link and epoch when interval is 0, in which case the period starts at the first sample.
+// Expected samples, granularity and SLA fields are left for the caller.
+func (s *Store) queryLinkLatencyAggregates(ctx context.Context, interval time.Duration, where string, args ...any) ([]LinkLatencyAggregate, error) {
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	periodExpr, groupBy := "min(event_ts)", "link_pk, epoch"
+	if interval > 0 {
+		periodExpr = fmt.Sprintf("toStartOfInterval(event_ts, toIntervalSecond(%d))", int64(interval.Seconds()))
+		groupBy += ", period_start"
+	}
+
+	// FINAL so samples re-ingested by backfills are counted once
+	query := fmt.Sprintf(`SELECT
+		link_pk,
+		epoch,
+		%s AS period_start,
+		toInt64(count()) AS samples,
+		toInt64(countIf(loss OR rtt_us = 0)) AS lost,
+		toFloat64(minIf(rtt_us, NOT loss AND rtt_us > 0)) AS rtt_min,
+		avgIf(rtt_us, NOT loss AND rtt_us > 0) AS rtt_avg,
+		quantilesIf(0.5, 0.95, 0.99)(rtt_us, NOT loss AND rtt_us > 0) AS rtt_quantiles,
+		toFloat64(maxIf(rtt_us, NOT loss AND rtt_us > 0)) AS rtt_max,
+		toInt64(countIf(NOT loss AND ipdv_us IS NOT NULL)) AS jitter_samples,
+		avgIf(ifNull(ipdv_us, 0), NOT loss AND ipdv_us IS NOT NULL) AS jitter_avg,
+		quantilesIf(0.95, 0.99)(ifNull(ipdv_us, 0), NOT loss AND ipdv_us IS NOT NULL) AS jitter_quantiles
+	FROM fact_dz_device_link_latency FINAL
+	WHERE %s AND link_pk != ''
+	GROUP BY %s`, periodExpr, where, groupBy)
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aggs []LinkLatencyAggregate
+	for rows.Next() {
+		var (
+			a                      LinkLatencyAggregate
+			epoch                  int64
+			rttMin, rttAvg, rttMax float64
+			rttQuantiles           []float64
+			jitterSamples          int64
+			jitterAvg              float64
+			jitterQuantiles        []float64
+		)
+		if err := rows.Scan(&a.LinkPK, &epoch, &a.PeriodStart, &a.Samples, &a.LostSamples,
+			&rttMin, &rttAvg, &rttQuantiles, &rttMax, &jitterSamples, &jitterAvg, &jitterQuantiles); err != nil {
+			return nil, fmt.Errorf("failed to scan latency aggregate: %w", err)
+		}
+		a.Epoch = uint64(epoch)
+		a.PeriodStart = a.PeriodStart.UTC()
+		if a.Samples > 0 {
+			a.LossPct = float64(a.LostSamples) * 100 / float64(a.Samples)
+		}
+		if a.Samples > a.LostSamples && len(rttQuantiles) == 3 {
+			a.RTTMinUs, a.RTTAvgUs, a.RTTMaxUs = &rttMin, &rttAvg, &rttMax
+			a.RTTP50Us, a.RTTP95Us, a.RTTP99Us = &rttQuantiles[0], &rttQuantiles[1], &rttQuantiles[2]
+		}
+		if jitterSamples > 0 && len(jitterQuantiles) == 2 {
+			a.JitterAvgUs = &jitterAvg
+			a.JitterP95Us, a.JitterP99Us = &jitterQuantiles[0], &jitterQuantiles[1]
+		}
+		aggs = append(aggs, a)
+	}
+	return aggs, rows.Err()
+}
+
+func (s *Store) writeLinkLatencyAggregates(ctx context.Context, aggs []LinkLatencyAggregate) error {
+	if len(aggs) == 0 {
+		return nil
+	}
+
+	ds, err := NewLinkLatencyAggregateDataset(s.log)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	ds.RecordChanges = true
+	ds.ChangeSink = s.cfg.ChangeSink
+
+	conn, err := s.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	ingestedAt := time.Now().UTC()
+	return ds.WriteBatch(ctx, conn, len(aggs), func(i int) ([]any, error) {
+		a := aggs[i]
+		return []any{
+			a.PeriodStart, // event_ts
+			ingestedAt,    // ingested_at
+			a.LinkPK,
+			int64(a.Epoch),
+			a.Granularity,
+			a.PeriodSeconds,
+			a.Samples,
+			a.ExpectedSamples,
+			a.LostSamples,
+			a.LossPct,
+			a.RTTMinUs,
+			a.RTTAvgUs,
+			a.RTTP50Us,
+			a.RTTP95Us,
+			a.RTTP99Us,
+			a.RTTMaxUs,
+			a.JitterAvgUs,
+			a.JitterP95Us,
+			a.JitterP99Us,
+			a.CommittedRTTUs,
+			a.CommittedJitterUs,
+			a.RTTSLAMet,
+			a.JitterSLAMet,
+		}, nil
+	})
+}
@@ -0,0 +1,181 @@
+package dztelemlatency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_TelemetryLatency_Store_CircuitEpochExpectedSamples(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := circuitEpoch{
+		start: start,
+		step:  10 * time.Second,
+		last:  start.Add(590 * time.Second), // sample index 59
+	}
+
+	// A full 5 minute window holds 30 slots
+	require.Equal(t, int64(30), c.expectedSamples(start, start.Add(5*time.Minute), start.Add(time.Hour)))
+
+	// Slots after now haven't elapsed yet
+	require.Equal(t, int64(60), c.expectedSamples(start, start.Add(time.Hour), start.Add(10*time.Minute)))
+	require.Equal(t, int64(67), c.expectedSamples(start, start.Add(time.Hour), start.Add(11*time.Minute+time.Second)))
+
+	// Windows starting before the epoch only count its slots
+	require.Equal(t, int64(30), c.expectedSamples(start.Add(-time.Minute), start.Add(5*time.Minute), start.Add(time.Hour)))
+
+	// The next epoch's start bounds the slots
+	c.end = start.Add(7 * time.Minute)
+	require.Equal(t, int64(12), c.expectedSamples(start.Add(5*time.Minute), start.Add(10*time.Minute), start.Add(time.Hour)))
+
+	// Without a known sampling interval only the single known sample is expected
+	single := circuitEpoch{start: start, last: start}
+	require.Equal(t, int64(1), single.expectedSamples(start, start.Add(5*time.Minute), start))
+	require.Equal(t, int64(0), single.expectedSamples(start.Add(time.Second), start.Add(5*time.Minute), start))
+}
+
+func TestLake_TelemetryLatency_Store_AggregatePeriods(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	periods := AggregatePeriods{Interval: 5 * time.Minute}
+	require.True(t, periods.Empty())
+
+	// Samples in the first two intervals and a late one an hour later; samples without a link
+	// are not aggregated
+	periods.Add([]DeviceLinkLatencySample{
+		{LinkPK: "link1", Epoch: 7, Time: start.Add(time.Minute)},
+		{LinkPK: "link2", Epoch: 7, Time: start.Add(6 * time.Minute)},
+		{LinkPK: "link1", Epoch: 8, Time: start.Add(time.Hour + time.Second)},
+		{Epoch: 8, Time: start.Add(2 * time.Hour)},
+	})
+	require.False(t, periods.Empty())
+	require.Equal(t, [][2]time.Time{
+		{start, start.Add(10 * time.Minute)},
+		{start.Add(time.Hour), start.Add(time.Hour + 5*time.Minute)},
+	}, periods.ranges())
+	require.Len(t, periods.epochs, 3)
+	require.Equal(t, start.Add(time.Hour+time.Second), periods.latest)
+}
+
+func TestLake_TelemetryLatency_Store_RefreshLinkLatencyAggregates(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	store, err := NewStore(StoreConfig{
+		Logger:     laketesting.NewLogger(),
+		ClickHouse: db,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	originPK, targetPK, linkPK := testPK(1), testPK(2), testPK(3)
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Hour)
+
+	// 10 minutes of samples every 10 seconds in one direction. Sample 5 is lost and samples
+	// 40-44 were never written.
+	var samples []DeviceLinkLatencySample
+	for i := range 60 {
+		if i >= 40 && i < 45 {
+			continue
+		}
+		rtt := uint32(1000 + (i%2)*100)
+		if i == 5 {
+			rtt = 0
+		}
+		samples = append(samples, DeviceLinkLatencySample{
+			OriginDevicePK:  originPK,
+			TargetDevicePK:  targetPK,
+			LinkPK:          linkPK,
+			Epoch:           7,
+			SampleIndex:     i,
+			Time:            start.Add(time.Duration(i) * 10 * time.Second),
+			RTTMicroseconds: rtt,
+		})
+	}
+	require.NoError(t, store.AppendDeviceLinkLatencySamples(ctx, samples))
+
+	links := []dzsvc.Link{{PK: linkPK, CommittedRTTNs: 1_050_000, CommittedJitterNs: 50_000}}
+	periods := AggregatePeriods{Interval: 5 * time.Minute}
+	periods.Add(samples)
+	require.NoError(t, store.RefreshLinkLatencyAggregates(ctx, links, periods, start.Add(10*time.Minute)))
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	rows, err := conn.Query(ctx, `
+		SELECT event_ts, granularity, period_seconds, samples, expected_samples, lost_samples, loss_pct,
+			rtt_min_us, rtt_avg_us, rtt_max_us, jitter_avg_us, rtt_sla_met, jitter_sla_met
+		FROM fact_dz_link_latency_aggregates FINAL
+		WHERE link_pk = ?
+		ORDER BY granularity, event_ts`, linkPK)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type aggRow struct {
+		eventTS         time.Time
+		granularity     string
+		periodSeconds   int64
+		samples         int64
+		expectedSamples int64
+		lostSamples     int64
+		lossPct         float64
+		rttMin          *float64
+		rttAvg          *float64
+		rttMax          *float64
+		jitterAvg       *float64
+		rttSLAMet       *bool
+		jitterSLAMet    *bool
+	}
+	var got []aggRow
+	for rows.Next() {
+		var r aggRow
+		require.NoError(t, rows.Scan(&r.eventTS, &r.granularity, &r.periodSeconds, &r.samples, &r.expectedSamples,
+			&r.lostSamples, &r.lossPct, &r.rttMin, &r.rttAvg, &r.rttMax, &r.jitterAvg, &r.rttSLAMet, &r.jitterSLAMet))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, got, 3)
+
+	// Epoch row covers every slot since index 0
+	epochRow := got[0]
+	require.Equal(t, AggregateGranularityEpoch, epochRow.granularity)
+	require.True(t, epochRow.eventTS.Equal(start))
+	require.Equal(t, int64(600), epochRow.periodSeconds)
+	require.Equal(t, int64(55), epochRow.samples)
+	require.Equal(t, int64(60), epochRow.expectedSamples)
+	require.Equal(t, int64(1), epochRow.lostSamples)
+
+	first, second := got[1], got[2]
+	require.Equal(t, AggregateGranularityInterval, first.granularity)
+	require.True(t, first.eventTS.Equal(start))
+	require.Equal(t, int64(300), first.periodSeconds)
+	require.Equal(t, int64(30), first.samples)
+	require.Equal(t, int64(30), first.expectedSamples)
+	require.Equal(t, int64(1), first.lostSamples)
+	require.InDelta(t, 100.0/30, first.lossPct, 1e-9)
+	require.NotNil(t, first.rttMin)
+	require.Equal(t, 1000.0, *first.rttMin)
+	require.Equal(t, 1100.0, *first.rttMax)
+	// 15 delivered samples at 1000us and 14 at 1100us average just under the 1050us commitment
+	require.InDelta(t, 30400.0/29, *first.rttAvg, 1e-9)
+	require.NotNil(t, first.rttSLAMet)
+	require.True(t, *first.rttSLAMet)
+	// RTT alternates by 100us, except around the lost sample where it compares 1000us to 1000us
+	require.NotNil(t, first.jitterAvg)
+	require.InDelta(t, 2700.0/28, *first.jitterAvg, 1e-9)
+	require.NotNil(t, first.jitterSLAMet)
+	require.False(t, *first.jitterSLAMet)
+
+	require.Equal(t, AggregateGranularityInterval, second.granularity)
+	require.True(t, second.eventTS.Equal(start.Add(5*time.Minute)))
+	require.Equal(t, int64(25), second.samples)
+	require.Equal(t, int64(30), second.expectedSamples)
+	require.Equal(t, int64(0), second.lostSamples)
+}
@@ -127,6 +127,15 @@ func (s *Store) BackfillDeviceLinkLatencyForEpoch(
 		if err := s.AppendDeviceLinkLatencySamples(ctx, allSamples); err != nil {
 			return nil, err
 		}
+		_, latest := sampleTimeRange(allSamples)
+		var periods AggregatePeriods
+		periods.Add(allSamples)
+		if err := s.RefreshLinkLatencyAggregates(ctx, links, periods, latest.Add(time.Millisecond)); err != nil {
+			return nil, err
+		}
+		if err := s.RefreshInternetAdvantageEpochs(ctx, []uint64{epoch}); err != nil {
+			return nil, err
+		}
 	}
 
 	return &BackfillDeviceLinkLatencyResult{
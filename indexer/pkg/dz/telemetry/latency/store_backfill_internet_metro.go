@@ -136,6 +136,9 @@ func (s *Store) BackfillInternetMetroLatencyForEpoch(
 		if err := s.AppendInternetMetroLatencySamples(ctx, allSamples); err != nil {
 			return nil, err
 		}
+		if err := s.RefreshInternetAdvantageEpochs(ctx, []uint64{epoch}); err != nil {
+			return nil, err
+		}
 	}
 
 	return &BackfillInternetMetroLatencyResult{
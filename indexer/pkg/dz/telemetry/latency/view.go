@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/jonboulle/clockwork"
 	"github.com/malbeclabs/doublezero/smartcontract/sdk/go/telemetry"
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
 	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
 	"github.com/malbeclabs/lake/indexer/pkg/metrics"
 )
@@ -26,6 +28,9 @@ type EpochRPC interface {
 	GetEpochInfo(ctx context.Context, commitment solanarpc.CommitmentType) (*solanarpc.GetEpochInfoResult, error)
 }
 
+// advantageEpochsPerQuery bounds the epochs recomputed by one internet advantage query
+const advantageEpochsPerQuery = 8
+
 type ViewConfig struct {
 	Logger                     *slog.Logger
 	Clock                      clockwork.Clock
@@ -38,6 +43,13 @@ type ViewConfig struct {
 	Serviceability             *dzsvc.View
 	RefreshInterval            time.Duration
 	ServiceabilityReadyTimeout time.Duration
+	// AggregateInterval is the width of the per-link interval aggregates (default: 5m)
+	AggregateInterval time.Duration
+	// AdvantageRefreshInterval is how often the per-epoch DZ vs internet latency is recomputed
+	// for epochs that received samples (default: 15m)
+	AdvantageRefreshInterval time.Duration
+	// ChangeSink, if set, receives the change events of the view's writes (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *ViewConfig) Validate() error {
@@ -75,6 +87,12 @@ func (cfg *ViewConfig) Validate() error {
 	if cfg.ServiceabilityReadyTimeout <= 0 {
 		cfg.ServiceabilityReadyTimeout = 2 * cfg.RefreshInterval
 	}
+	if cfg.AggregateInterval <= 0 {
+		cfg.AggregateInterval = DefaultAggregateInterval
+	}
+	if cfg.AdvantageRefreshInterval <= 0 {
+		cfg.AdvantageRefreshInterval = 15 * time.Minute
+	}
 	return nil
 }
 
@@ -85,6 +103,18 @@ type View struct {
 	readyOnce sync.Once
 	readyCh   chan struct{}
 	refreshMu sync.Mutex // prevents concurrent refreshes
+
+	// pendingAggregates are the aggregate periods of written samples not yet re-aggregated,
+	// kept across refreshes until an aggregate refresh succeeds
+	pendingAggregates AggregatePeriods
+	// aggregatesBackfilled is set once no stored samples predate the aggregates
+	aggregatesBackfilled bool
+	// pendingAdvantageEpochs are the epochs of written samples whose DZ vs internet latency
+	// hasn't been recomputed since, and advantageRefreshedAt when it last was
+	pendingAdvantageEpochs map[uint64]struct{}
+	advantageRefreshedAt   time.Time
+	// advantageSeeded is set once the epochs stored without DZ vs internet latency are pending
+	advantageSeeded bool
 }
 
 func NewView(cfg ViewConfig) (*View, error) {
@@ -95,16 +125,19 @@ func NewView(cfg ViewConfig) (*View, error) {
 	store, err := NewStore(StoreConfig{
 		Logger:     cfg.Logger,
 		ClickHouse: cfg.ClickHouse,
+		ChangeSink: cfg.ChangeSink,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create store: %w", err)
 	}
 
 	v := &View{
-		log:     cfg.Logger,
-		cfg:     cfg,
-		store:   store,
-		readyCh: make(chan struct{}),
+		log:                    cfg.Logger,
+		cfg:                    cfg,
+		store:                  store,
+		readyCh:                make(chan struct{}),
+		pendingAggregates:      AggregatePeriods{Interval: cfg.AggregateInterval},
+		pendingAdvantageEpochs: make(map[uint64]struct{}),
 	}
 
 	return v, nil
@@ -186,6 +219,11 @@ func (v *View) Refresh(ctx context.Context) error {
 		return fmt.Errorf("failed to refresh device-link latency samples: %w", err)
 	}
 
+	if err := v.backfillAggregates(ctx, links); err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("telemetry", "error").Inc()
+		return fmt.Errorf("failed to backfill link latency aggregates: %w", err)
+	}
+
 	// Refresh internet-metro latency samples if configured
 	if !v.cfg.InternetLatencyAgentPK.IsZero() && len(v.cfg.InternetDataProviders) > 0 {
 		metros, err := dzsvc.QueryCurrentMetros(ctx, v.log, v.cfg.ClickHouse)
@@ -200,6 +238,11 @@ func (v *View) Refresh(ctx context.Context) error {
 		}
 	}
 
+	if err := v.refreshInternetAdvantage(ctx); err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("telemetry", "error").Inc()
+		return fmt.Errorf("failed to refresh internet advantage: %w", err)
+	}
+
 	// Signal readiness once (close channel) - safe to call multiple times
 	v.readyOnce.Do(func() {
 		close(v.readyCh)
@@ -210,6 +253,67 @@ func (v *View) Refresh(ctx context.Context) error {
 	return nil
 }
 
+// backfillAggregates aggregates the latest epoch of samples stored before aggregation began,
+// one epoch per refresh so the backfill doesn't hold up new samples, until none are left.
+func (v *View) backfillAggregates(ctx context.Context, links []dzsvc.Link) error {
+	if v.aggregatesBackfilled {
+		return nil
+	}
+	epochs, err := v.store.UnaggregatedEpochs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(epochs) == 0 {
+		v.aggregatesBackfilled = true
+		v.log.Info("telemetry/latency: link latency aggregates are backfilled")
+		return nil
+	}
+	if err := v.store.BackfillLinkLatencyAggregates(ctx, links, epochs[0], v.cfg.AggregateInterval, v.cfg.Clock.Now()); err != nil {
+		return err
+	}
+	v.log.Info("telemetry/latency: backfilled link latency aggregates", "epoch", epochs[0], "remaining", len(epochs)-1)
+	return nil
+}
+
+// refreshInternetAdvantage recomputes the per-epoch DZ vs internet latency of the epochs that
+// received samples, at most once per AdvantageRefreshInterval. Epochs stay pending until a
+// refresh succeeds.
+func (v *View) refreshInternetAdvantage(ctx context.Context) error {
+	if !v.advantageSeeded {
+		epochs, err := v.store.UnrefreshedAdvantageEpochs(ctx)
+		if err != nil {
+			return err
+		}
+		for _, epoch := range epochs {
+			v.pendingAdvantageEpochs[epoch] = struct{}{}
+		}
+		v.advantageSeeded = true
+		if len(epochs) > 0 {
+			v.log.Info("telemetry/latency: backfilling internet advantage", "epochs", len(epochs))
+		}
+	}
+	if len(v.pendingAdvantageEpochs) == 0 || v.cfg.Clock.Since(v.advantageRefreshedAt) < v.cfg.AdvantageRefreshInterval {
+		return nil
+	}
+	epochs := make([]uint64, 0, len(v.pendingAdvantageEpochs))
+	for epoch := range v.pendingAdvantageEpochs {
+		epochs = append(epochs, epoch)
+	}
+	slices.Sort(epochs)
+	// A few epochs at a time, so a backlog doesn't become one query over every sample and
+	// progress made before a failure is kept
+	for chunk := range slices.Chunk(epochs, advantageEpochsPerQuery) {
+		if err := v.store.RefreshInternetAdvantageEpochs(ctx, chunk); err != nil {
+			return err
+		}
+		for _, epoch := range chunk {
+			delete(v.pendingAdvantageEpochs, epoch)
+		}
+	}
+	v.advantageRefreshedAt = v.cfg.Clock.Now()
+	return nil
+}
+
 // Ready returns true if the view has completed at least one successful refresh
 func (v *View) Ready() bool {
 	select {
@@ -172,7 +172,20 @@ done:
 		if err := v.store.AppendDeviceLinkLatencySamples(ctx, allSamples); err != nil {
 			return fmt.Errorf("failed to append latency samples: %w", err)
 		}
+		v.pendingAggregates.Add(allSamples)
+		for _, sample := range allSamples {
+			v.pendingAdvantageEpochs[sample.Epoch] = struct{}{}
+		}
 		v.log.Debug("telemetry/device-link: sample refresh completed", "links", linksProcessed, "samples", len(allSamples))
 	}
+
+	// Re-aggregate the periods of the new samples, so late samples update their intervals too,
+	// along with any left over from a failed refresh
+	if !v.pendingAggregates.Empty() {
+		if err := v.store.RefreshLinkLatencyAggregates(ctx, links, v.pendingAggregates, v.cfg.Clock.Now()); err != nil {
+			return fmt.Errorf("failed to refresh link latency aggregates: %w", err)
+		}
+		v.pendingAggregates = AggregatePeriods{Interval: v.cfg.AggregateInterval}
+	}
 	return nil
 }
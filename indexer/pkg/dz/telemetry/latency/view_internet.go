@@ -191,6 +191,9 @@ done:
 		if err := v.store.AppendInternetMetroLatencySamples(ctx, allSamples); err != nil {
 			return fmt.Errorf("failed to append internet-metro latency samples: %w", err)
 		}
+		for _, sample := range allSamples {
+			v.pendingAdvantageEpochs[sample.Epoch] = struct{}{}
+		}
 		v.log.Debug("telemetry/internet-metro: sample refresh completed", "metros", metrosProcessed, "samples", len(allSamples))
 	}
 	return nil
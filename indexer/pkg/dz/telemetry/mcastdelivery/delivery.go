@@ -0,0 +1,291 @@
+package dztelemmcastdelivery
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+)
+
+const (
+	// StatusDelivered is a subscriber receiving what the group's publishers send, within the
+	// loss threshold.
+	StatusDelivered = "delivered"
+	// StatusLossInTree is loss located at a hop of the multicast tree.
+	StatusLossInTree = "loss_in_tree"
+	// StatusLossAtEdge is loss with no lossy hop on the tree, so at the subscriber's device or
+	// tunnel.
+	StatusLossAtEdge = "loss_at_edge"
+	// StatusLossUnlocated is loss with no tree to locate it on, e.g. without a topology.
+	StatusLossUnlocated = "loss_unlocated"
+	// StatusIdle is a window in which the publishers sent nothing.
+	StatusIdle = "idle"
+	// StatusNoData is a window without counters for the subscriber or any publisher.
+	StatusNoData = "no_data"
+)
+
+// Member is an activated multicast user's tunnel.
+type Member struct {
+	UserPK   string
+	DevicePK string
+	TunnelID int32
+	// Groups is the number of groups the tunnel publishes or subscribes to. A tunnel in more
+	// than one group carries their traffic together.
+	Groups int
+}
+
+// Group is a multicast group with its publishing and subscribing tunnels. A user that does
+// both is in both lists.
+type Group struct {
+	PK          string
+	Publishers  []Member
+	Subscribers []Member
+}
+
+type TunnelKey struct {
+	DevicePK string
+	TunnelID int32
+}
+
+type LinkKey struct {
+	DevicePK string
+	LinkPK   string
+}
+
+// TunnelCounters are a tunnel's counters summed over a window. Counters are from the device's
+// view, so In is traffic from the user.
+type TunnelCounters struct {
+	Samples int64
+	InPkts  int64
+	OutPkts int64
+}
+
+// LinkCounters are the multicast packet counters of a device's interface on a link, summed
+// over a window.
+type LinkCounters struct {
+	InMulticastPkts  int64
+	OutMulticastPkts int64
+}
+
+// Window is the telemetry of one evaluation window.
+type Window struct {
+	Start   time.Time
+	Tunnels map[TunnelKey]TunnelCounters
+	Links   map[LinkKey]LinkCounters
+	// LinkLatencyUs is each link's mean one-way latency (half its RTT) in the window.
+	LinkLatencyUs map[string]float64
+}
+
+// HopLoss is the multicast packet loss on one link of a tree hop, from the packets the
+// upstream device sent on it to those the downstream device received.
+type HopLoss struct {
+	FromDevicePK string
+	ToDevicePK   string
+	LinkPK       string
+	SentPkts     int64
+	ReceivedPkts int64
+	LossPct      float64
+}
+
+// Delivery is how well a subscriber received a group's traffic over one window.
+type Delivery struct {
+	GroupPK     string
+	Subscriber  Member
+	WindowStart time.Time
+	// Publishers is the number of the group's publishers other than the subscriber itself.
+	Publishers int
+	// ExpectedPkts is what the publishers sent into their tunnels; ReceivedPkts is what the
+	// subscriber's device sent down its tunnel.
+	ExpectedPkts  int64
+	ReceivedPkts  int64
+	DeliveryRatio float64
+	LossPct       float64
+	// Exclusive is set when every tunnel involved is in this group only, so the counters
+	// carry no other group's traffic and the ratio is exact.
+	Exclusive bool
+	Status    string
+	// WorstHop is the lossiest hop on the paths from the publishers, nil if none was measured.
+	WorstHop *HopLoss
+	// PathHops, UnmeasuredHops and LagUs describe the slowest publisher path: its hop count,
+	// the hops without latency samples, and the sum of its hops' one-way latencies.
+	PathHops       int
+	UnmeasuredHops int
+	LagUs          float64
+}
+
+type EvaluatorConfig struct {
+	// MinLossPct is the loss, in % of expected packets, at or above which a subscriber or hop
+	// counts as lossy (default: 1).
+	MinLossPct float64
+}
+
+func (cfg *EvaluatorConfig) setDefaults() {
+	if cfg.MinLossPct <= 0 {
+		cfg.MinLossPct = 1
+	}
+}
+
+// Evaluate compares, for each subscriber of the group, the packets its publishers sent with
+// the packets it received, and walks the tree from each publisher's device to the
+// subscriber's to find the hop where loss occurs and the path's lag. The tree follows the
+// IS-IS shortest path, the first of any equal-cost paths, and is skipped if topo is nil.
+// Subscribers of a group with no other publisher have nothing to receive and are skipped.
+func Evaluate(cfg EvaluatorConfig, g Group, w Window, topo *routing.Topology) []Delivery {
+	cfg.setDefaults()
+
+	var deliveries []Delivery
+	for _, sub := range g.Subscribers {
+		var pubs []Member
+		for _, p := range g.Publishers {
+			if p.UserPK != sub.UserPK {
+				pubs = append(pubs, p)
+			}
+		}
+		if len(pubs) == 0 {
+			continue
+		}
+
+		d := Delivery{
+			GroupPK:     g.PK,
+			Subscriber:  sub,
+			WindowStart: w.Start,
+			Publishers:  len(pubs),
+			Exclusive:   sub.Groups == 1,
+		}
+		withCounters := 0
+		for _, p := range pubs {
+			if p.Groups != 1 {
+				d.Exclusive = false
+			}
+			if c, ok := w.Tunnels[TunnelKey{p.DevicePK, p.TunnelID}]; ok {
+				d.ExpectedPkts += max(c.InPkts, 0)
+				withCounters++
+			}
+		}
+		subCounters, ok := w.Tunnels[TunnelKey{sub.DevicePK, sub.TunnelID}]
+		if !ok || withCounters == 0 {
+			d.Status = StatusNoData
+			deliveries = append(deliveries, d)
+			continue
+		}
+		d.ReceivedPkts = max(subCounters.OutPkts, 0)
+		if d.ExpectedPkts == 0 {
+			d.Status = StatusIdle
+			deliveries = append(deliveries, d)
+			continue
+		}
+
+		d.DeliveryRatio = round3(float64(d.ReceivedPkts) / float64(d.ExpectedPkts))
+		d.LossPct = round3(max(1-float64(d.ReceivedPkts)/float64(d.ExpectedPkts), 0) * 100)
+
+		located := topo != nil && walkTrees(&d, pubs, w, topo)
+		switch {
+		case d.LossPct < cfg.MinLossPct:
+			d.Status = StatusDelivered
+		case d.WorstHop != nil && d.WorstHop.LossPct >= cfg.MinLossPct:
+			d.Status = StatusLossInTree
+		case located:
+			d.Status = StatusLossAtEdge
+		default:
+			d.Status = StatusLossUnlocated
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries
+}
+
+// walkTrees fills in the worst hop and the slowest path from the publishers' devices to the
+// subscriber's, and reports whether every publisher's device had a path.
+func walkTrees(d *Delivery, pubs []Member, w Window, topo *routing.Topology) bool {
+	located := true
+	seen := make(map[string]bool)
+	lagSet := false
+	for _, p := range pubs {
+		if seen[p.DevicePK] {
+			continue
+		}
+		seen[p.DevicePK] = true
+
+		route := topo.Route(p.DevicePK, d.Subscriber.DevicePK, 1)
+		if !route.Reachable || len(route.Paths) == 0 {
+			located = false
+			continue
+		}
+		devices := route.Paths[0].Devices
+
+		var lag float64
+		unmeasured := 0
+		for i := 1; i < len(devices); i++ {
+			from, to := devices[i-1], devices[i]
+			linkPKs := hopLinks(topo, from, to)
+
+			var latencies []float64
+			for _, linkPK := range linkPKs {
+				if l, ok := w.LinkLatencyUs[linkPK]; ok {
+					latencies = append(latencies, l)
+				}
+				if h, ok := hopLoss(w, from, to, linkPK); ok {
+					if d.WorstHop == nil || h.LossPct > d.WorstHop.LossPct {
+						d.WorstHop = &h
+					}
+				}
+			}
+			if len(latencies) == 0 {
+				unmeasured++
+				continue
+			}
+			sum := 0.0
+			for _, l := range latencies {
+				sum += l
+			}
+			lag += sum / float64(len(latencies))
+		}
+
+		if !lagSet || lag > d.LagUs {
+			d.LagUs = math.Round(lag*10) / 10
+			d.PathHops = len(devices) - 1
+			d.UnmeasuredHops = unmeasured
+			lagSet = true
+		}
+	}
+	return located
+}
+
+// hopLinks returns the links carrying the adjacency from one device to the next, sorted.
+func hopLinks(topo *routing.Topology, from, to string) []string {
+	for _, adj := range topo.Adjacencies(from) {
+		if adj.To == to {
+			links := slices.Clone(adj.LinkPKs)
+			slices.Sort(links)
+			return links
+		}
+	}
+	return nil
+}
+
+// hopLoss measures the loss on a link of a hop, if the upstream device sent multicast
+// packets on it and both sides reported counters.
+func hopLoss(w Window, from, to, linkPK string) (HopLoss, bool) {
+	sent, ok := w.Links[LinkKey{from, linkPK}]
+	if !ok || sent.OutMulticastPkts <= 0 {
+		return HopLoss{}, false
+	}
+	recv, ok := w.Links[LinkKey{to, linkPK}]
+	if !ok {
+		return HopLoss{}, false
+	}
+	h := HopLoss{
+		FromDevicePK: from,
+		ToDevicePK:   to,
+		LinkPK:       linkPK,
+		SentPkts:     sent.OutMulticastPkts,
+		ReceivedPkts: max(recv.InMulticastPkts, 0),
+	}
+	h.LossPct = round3(max(1-float64(h.ReceivedPkts)/float64(h.SentPkts), 0) * 100)
+	return h, true
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
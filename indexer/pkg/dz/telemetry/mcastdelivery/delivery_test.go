@@ -0,0 +1,133 @@
+package dztelemmcastdelivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/malbeclabs/lake/indexer/pkg/dz/graph"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+	"github.com/stretchr/testify/require"
+)
+
+// testTopology is a line pub-mid-sub, with links "pm" and "ms".
+func testTopology() *routing.Topology {
+	devices := []graph.ISISDevice{{PK: "pub"}, {PK: "mid"}, {PK: "sub"}}
+	adjacencies := []graph.ISISAdjacency{
+		{FromDevicePK: "pub", ToDevicePK: "mid", Metric: 10},
+		{FromDevicePK: "mid", ToDevicePK: "pub", Metric: 10},
+		{FromDevicePK: "mid", ToDevicePK: "sub", Metric: 10},
+		{FromDevicePK: "sub", ToDevicePK: "mid", Metric: 10},
+	}
+	links := []graph.ISISLink{
+		{PK: "pm", SideAPK: "pub", SideZPK: "mid", ISISMetric: 10},
+		{PK: "ms", SideAPK: "mid", SideZPK: "sub", ISISMetric: 10},
+	}
+	return routing.NewTopology(devices, adjacencies, links)
+}
+
+func TestLake_TelemetryMulticastDelivery_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	pub := Member{UserPK: "user1", DevicePK: "pub", TunnelID: 501, Groups: 1}
+	sub := Member{UserPK: "user2", DevicePK: "sub", TunnelID: 502, Groups: 1}
+	group := Group{PK: "group1", Publishers: []Member{pub}, Subscribers: []Member{sub}}
+
+	window := func(received, midReceived, subReceived int64) Window {
+		return Window{
+			Start: start,
+			Tunnels: map[TunnelKey]TunnelCounters{
+				{"pub", 501}: {Samples: 5, InPkts: 10000},
+				{"sub", 502}: {Samples: 5, OutPkts: received},
+			},
+			Links: map[LinkKey]LinkCounters{
+				{"pub", "pm"}: {OutMulticastPkts: 10000},
+				{"mid", "pm"}: {InMulticastPkts: midReceived},
+				{"mid", "ms"}: {OutMulticastPkts: midReceived},
+				{"sub", "ms"}: {InMulticastPkts: subReceived},
+			},
+			LinkLatencyUs: map[string]float64{"pm": 1500, "ms": 2500},
+		}
+	}
+
+	t.Run("full delivery", func(t *testing.T) {
+		t.Parallel()
+
+		ds := Evaluate(EvaluatorConfig{}, group, window(10000, 10000, 10000), testTopology())
+		require.Len(t, ds, 1)
+		d := ds[0]
+		require.Equal(t, StatusDelivered, d.Status)
+		require.Equal(t, int64(10000), d.ExpectedPkts)
+		require.Equal(t, 1.0, d.DeliveryRatio)
+		require.Zero(t, d.LossPct)
+		require.True(t, d.Exclusive)
+		require.Equal(t, 2, d.PathHops)
+		require.Zero(t, d.UnmeasuredHops)
+		require.Equal(t, 4000.0, d.LagUs)
+		require.Equal(t, start, d.WindowStart)
+	})
+
+	t.Run("locates loss at a tree hop", func(t *testing.T) {
+		t.Parallel()
+
+		ds := Evaluate(EvaluatorConfig{}, group, window(9000, 10000, 9000), testTopology())
+		require.Len(t, ds, 1)
+		d := ds[0]
+		require.Equal(t, StatusLossInTree, d.Status)
+		require.Equal(t, 0.9, d.DeliveryRatio)
+		require.Equal(t, 10.0, d.LossPct)
+		require.NotNil(t, d.WorstHop)
+		require.Equal(t, "ms", d.WorstHop.LinkPK)
+		require.Equal(t, "mid", d.WorstHop.FromDevicePK)
+		require.Equal(t, "sub", d.WorstHop.ToDevicePK)
+		require.Equal(t, 10.0, d.WorstHop.LossPct)
+	})
+
+	t.Run("loss with a clean tree is at the edge", func(t *testing.T) {
+		t.Parallel()
+
+		ds := Evaluate(EvaluatorConfig{}, group, window(9000, 10000, 10000), testTopology())
+		require.Equal(t, StatusLossAtEdge, ds[0].Status)
+	})
+
+	t.Run("loss without a topology is unlocated", func(t *testing.T) {
+		t.Parallel()
+
+		ds := Evaluate(EvaluatorConfig{}, group, window(9000, 10000, 9000), nil)
+		require.Equal(t, StatusLossUnlocated, ds[0].Status)
+		require.Nil(t, ds[0].WorstHop)
+		require.Zero(t, ds[0].LagUs)
+	})
+
+	t.Run("idle publishers and missing counters", func(t *testing.T) {
+		t.Parallel()
+
+		w := window(0, 0, 0)
+		w.Tunnels[TunnelKey{"pub", 501}] = TunnelCounters{Samples: 5}
+		ds := Evaluate(EvaluatorConfig{}, group, w, nil)
+		require.Equal(t, StatusIdle, ds[0].Status)
+
+		ds = Evaluate(EvaluatorConfig{}, group, Window{Start: start}, nil)
+		require.Equal(t, StatusNoData, ds[0].Status)
+	})
+
+	t.Run("tunnels in other groups are not exclusive", func(t *testing.T) {
+		t.Parallel()
+
+		shared := sub
+		shared.Groups = 2
+		g := Group{PK: "group1", Publishers: []Member{pub}, Subscribers: []Member{shared}}
+		ds := Evaluate(EvaluatorConfig{}, g, window(12000, 10000, 12000), nil)
+		require.False(t, ds[0].Exclusive)
+		require.Equal(t, 1.2, ds[0].DeliveryRatio)
+		require.Zero(t, ds[0].LossPct)
+		require.Equal(t, StatusDelivered, ds[0].Status)
+	})
+
+	t.Run("skips a subscriber that is the only publisher", func(t *testing.T) {
+		t.Parallel()
+
+		g := Group{PK: "group1", Publishers: []Member{pub}, Subscribers: []Member{pub}}
+		require.Empty(t, Evaluate(EvaluatorConfig{}, g, window(10000, 10000, 10000), nil))
+	})
+}
@@ -0,0 +1,66 @@
+package dztelemmcastdelivery
+
+import (
+	"log/slog"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+)
+
+type MulticastDeliverySchema struct{}
+
+func (s *MulticastDeliverySchema) Name() string {
+	return "dz_multicast_delivery"
+}
+
+func (s *MulticastDeliverySchema) UniqueKeyColumns() []string {
+	return []string{"event_ts", "group_pk", "subscriber_pk"}
+}
+
+func (s *MulticastDeliverySchema) Columns() []string {
+	return []string{
+		"ingested_at:TIMESTAMP",
+		"group_pk:VARCHAR",
+		"subscriber_pk:VARCHAR",
+		"subscriber_device_pk:VARCHAR",
+		"subscriber_tunnel_id:INTEGER",
+		"window_seconds:INTEGER",
+		"publishers:INTEGER",
+		"expected_pkts:BIGINT",
+		"received_pkts:BIGINT",
+		"delivery_ratio:DOUBLE",
+		"loss_pct:DOUBLE",
+		"exclusive:BOOLEAN",
+		"status:VARCHAR",
+		"worst_hop_from_device_pk:VARCHAR",
+		"worst_hop_to_device_pk:VARCHAR",
+		"worst_hop_link_pk:VARCHAR",
+		"worst_hop_loss_pct:DOUBLE",
+		"path_hops:INTEGER",
+		"unmeasured_hops:INTEGER",
+		"lag_us:DOUBLE",
+	}
+}
+
+func (s *MulticastDeliverySchema) TimeColumn() string {
+	return "event_ts"
+}
+
+func (s *MulticastDeliverySchema) PartitionByTime() bool {
+	return true
+}
+
+func (s *MulticastDeliverySchema) Grain() string {
+	return "one row per multicast group subscriber and evaluation window"
+}
+
+func (s *MulticastDeliverySchema) DedupMode() dataset.DedupMode {
+	return dataset.DedupReplacing
+}
+
+func (s *MulticastDeliverySchema) DedupVersionColumn() string {
+	return "ingested_at"
+}
+
+func NewMulticastDeliveryDataset(log *slog.Logger) (*dataset.FactDataset, error) {
+	return dataset.NewFactDataset(log, &MulticastDeliverySchema{})
+}
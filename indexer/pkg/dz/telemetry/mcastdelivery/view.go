@@ -0,0 +1,473 @@
+package dztelemmcastdelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/routing"
+	"github.com/malbeclabs/lake/indexer/pkg/metrics"
+)
+
+type ViewConfig struct {
+	Logger          *slog.Logger
+	Clock           clockwork.Clock
+	ClickHouse      clickhouse.Client
+	RefreshInterval time.Duration
+	// Window is the width of each evaluation window (default: 5m).
+	Window    time.Duration
+	Evaluator EvaluatorConfig
+	// Reevaluate is how many windows back each refresh compares again, so a publisher's or
+	// subscriber's counters that land after its window was first evaluated revise the
+	// delivery (default: 15m). Rewrites replace earlier rows.
+	Reevaluate time.Duration
+	// IngestionLag is how long counters take to land after the time they cover. Windows are
+	// only evaluated once they ended at least this long ago, so a subscriber isn't compared
+	// with publishers whose counters haven't arrived (default: 10m, two device usage refreshes).
+	IngestionLag time.Duration
+	// Topology, if set, provides the IS-IS topology the multicast trees are walked on to
+	// locate loss and estimate lag (optional)
+	Topology routing.Source
+	// ChangeSink, if set, receives the change events of the view's writes (optional)
+	ChangeSink dataset.ChangeSink
+}
+
+func (cfg *ViewConfig) Validate() error {
+	if cfg.Logger == nil {
+		return errors.New("logger is required")
+	}
+	if cfg.ClickHouse == nil {
+		return errors.New("clickhouse connection is required")
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("refresh interval must be greater than 0")
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Minute
+	}
+	cfg.Evaluator.setDefaults()
+	if cfg.Reevaluate <= 0 {
+		cfg.Reevaluate = 15 * time.Minute
+	}
+	if cfg.IngestionLag <= 0 {
+		cfg.IngestionLag = 10 * time.Minute
+	}
+	return nil
+}
+
+// View periodically compares what each multicast group's publishers send with what each of
+// its subscribers receives over completed windows of tunnel and link counters, and writes the
+// result to fact_dz_multicast_delivery.
+type View struct {
+	log       *slog.Logger
+	cfg       ViewConfig
+	refreshMu sync.Mutex // prevents concurrent refreshes
+
+	// evaluatedUntil is where the last successful evaluation stopped. Until another window is
+	// past the ingestion lag there is nothing new to compare, and refreshes return early.
+	evaluatedUntil time.Time
+}
+
+func NewView(cfg ViewConfig) (*View, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &View{
+		log: cfg.Logger,
+		cfg: cfg,
+	}, nil
+}
+
+func (v *View) Start(ctx context.Context) {
+	go func() {
+		v.log.Info("telemetry/mcastdelivery: starting refresh loop", "interval", v.cfg.RefreshInterval, "window", v.cfg.Window)
+
+		v.safeRefresh(ctx)
+
+		ticker := v.cfg.Clock.NewTicker(v.cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.Chan():
+				v.safeRefresh(ctx)
+			}
+		}
+	}()
+}
+
+// safeRefresh wraps Refresh with panic recovery to prevent the refresh loop from dying
+func (v *View) safeRefresh(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.log.Error("telemetry/mcastdelivery: refresh panicked", "panic", r)
+			metrics.ViewRefreshTotal.WithLabelValues("mcastdelivery", "panic").Inc()
+		}
+	}()
+
+	if err := v.Refresh(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		v.log.Error("telemetry/mcastdelivery: refresh failed", "error", err)
+	}
+}
+
+// Refresh evaluates every multicast group's subscribers over the windows in the re-evaluation
+// period that ended at least the ingestion lag ago.
+func (v *View) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	window := v.cfg.Window
+	end := v.cfg.Clock.Now().UTC().Add(-v.cfg.IngestionLag).Truncate(window)
+	if !end.After(v.evaluatedUntil) {
+		return nil
+	}
+
+	refreshStart := time.Now()
+	defer func() {
+		duration := time.Since(refreshStart)
+		v.log.Info("telemetry/mcastdelivery: refresh completed", "duration", duration.String())
+		metrics.ViewRefreshDuration.WithLabelValues("mcastdelivery").Observe(duration.Seconds())
+	}()
+
+	from := end.Add(-v.cfg.Reevaluate)
+	groups, err := v.queryGroups(ctx)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("mcastdelivery", "error").Inc()
+		return fmt.Errorf("failed to query multicast groups: %w", err)
+	}
+	if len(groups) == 0 {
+		v.evaluatedUntil = end
+		metrics.ViewRefreshTotal.WithLabelValues("mcastdelivery", "success").Inc()
+		return nil
+	}
+
+	// Without a topology, delivery is still measured but loss can't be located on the tree
+	var topo *routing.Topology
+	if v.cfg.Topology != nil {
+		topo, err = routing.Load(ctx, v.cfg.Topology)
+		if err != nil {
+			v.log.Warn("telemetry/mcastdelivery: failed to load topology, skipping tree hops", "error", err)
+			topo = nil
+		}
+	}
+
+	windows, err := v.queryWindows(ctx, from, end)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("mcastdelivery", "error").Inc()
+		return fmt.Errorf("failed to query counters: %w", err)
+	}
+
+	var deliveries []Delivery
+	for start := from; start.Before(end); start = start.Add(window) {
+		w, ok := windows[start]
+		if !ok {
+			w = &Window{Start: start}
+		}
+		for _, g := range groups {
+			deliveries = append(deliveries, Evaluate(v.cfg.Evaluator, g, *w, topo)...)
+		}
+	}
+
+	if err := v.writeDeliveries(ctx, deliveries); err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("mcastdelivery", "error").Inc()
+		return fmt.Errorf("failed to write multicast delivery: %w", err)
+	}
+
+	v.log.Debug("telemetry/mcastdelivery: evaluated windows", "from", from, "to", end, "groups", len(groups), "rows", len(deliveries))
+	v.evaluatedUntil = end
+	metrics.ViewRefreshTotal.WithLabelValues("mcastdelivery", "success").Inc()
+	return nil
+}
+
+// queryGroups returns the multicast groups with their activated publishing and subscribing
+// tunnels, ordered by group PK.
+func (v *View) queryGroups(ctx context.Context) ([]Group, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT pk, device_pk, tunnel_id, publishers, subscribers
+		FROM dz_users_current
+		WHERE status = 'activated' AND kind = 'multicast' AND tunnel_id != 0
+		ORDER BY pk
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make(map[string]*Group)
+	group := func(pk string) *Group {
+		g, ok := groups[pk]
+		if !ok {
+			g = &Group{PK: pk}
+			groups[pk] = g
+		}
+		return g
+	}
+	for rows.Next() {
+		var (
+			m                       Member
+			publishers, subscribers string
+		)
+		if err := rows.Scan(&m.UserPK, &m.DevicePK, &m.TunnelID, &publishers, &subscribers); err != nil {
+			return nil, fmt.Errorf("failed to scan multicast user: %w", err)
+		}
+		pubs, subs := parseGroupPKs(publishers), parseGroupPKs(subscribers)
+		inGroups := make(map[string]bool)
+		for _, pk := range append(pubs, subs...) {
+			inGroups[pk] = true
+		}
+		m.Groups = len(inGroups)
+		for _, pk := range pubs {
+			g := group(pk)
+			g.Publishers = append(g.Publishers, m)
+		}
+		for _, pk := range subs {
+			g := group(pk)
+			g.Subscribers = append(g.Subscribers, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PK < result[j].PK })
+	return result, nil
+}
+
+// parseGroupPKs parses a JSON array of group PKs, treating anything else as no groups.
+func parseGroupPKs(s string) []string {
+	var pks []string
+	if err := json.Unmarshal([]byte(s), &pks); err != nil {
+		return nil
+	}
+	return pks
+}
+
+// sampleShare expands a counter sample into the window its interval ends in (k = 0) and the
+// one before (k = 1), with the share of its interval that falls in each. The window width in
+// seconds is bound four times. A sample without a duration falls wholly in the window it ends in.
+const sampleShare = `arrayJoin([0, 1]) AS k,
+				toUnixTimestamp64Milli(event_ts) / 1000 AS end_s,
+				greatest(COALESCE(delta_duration, 0), 0) AS duration_s,
+				intDiv(toUnixTimestamp64Milli(event_ts), ? * 1000) * ? - k * ? AS window_start_s,
+				if(duration_s > 0,
+					greatest(least(end_s, window_start_s + ?) - greatest(end_s - duration_s, window_start_s), 0) / duration_s,
+					toFloat64(k = 0)) AS share`
+
+// queryWindows sums tunnel counters, link multicast counters and link latency in
+// [start, end) into evaluation windows, keyed by window start. Negative deltas from counter
+// resets are ignored.
+//
+// Devices sample their counters at unrelated times, so a publisher's and a subscriber's samples
+// don't end on the same boundaries, and packets counted near the end of a window on one side can
+// be counted in the next on the other. Each sample's delta is therefore split across the windows
+// its interval overlaps, in proportion to the overlap, so both sides are measured over the same
+// span. Samples are assumed to cover no more than one window.
+func (v *View) queryWindows(ctx context.Context, start, end time.Time) (map[time.Time]*Window, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	windowSeconds := int64(v.cfg.Window.Seconds())
+	windows := make(map[time.Time]*Window)
+	window := func(ts time.Time) *Window {
+		ts = ts.UTC()
+		w, ok := windows[ts]
+		if !ok {
+			w = &Window{
+				Start:         ts,
+				Tunnels:       make(map[TunnelKey]TunnelCounters),
+				Links:         make(map[LinkKey]LinkCounters),
+				LinkLatencyUs: make(map[string]float64),
+			}
+			windows[ts] = w
+		}
+		return w
+	}
+
+	tunnelRows, err := conn.Query(ctx, `
+		SELECT
+			device_pk,
+			tunnel_id,
+			toDateTime(window_start_s, 'UTC') AS window_start,
+			toInt64(count()) AS samples,
+			toInt64(round(sum(in_pkts * share))) AS in_pkts,
+			toInt64(round(sum(out_pkts * share))) AS out_pkts
+		FROM (
+			SELECT
+				device_pk,
+				toInt32(assumeNotNull(user_tunnel_id)) AS tunnel_id,
+				greatest(COALESCE(in_pkts_delta, 0), 0) AS in_pkts,
+				greatest(COALESCE(out_pkts_delta, 0), 0) AS out_pkts,
+				`+sampleShare+`
+			FROM fact_dz_device_interface_counters
+			WHERE event_ts > ? AND event_ts < ?
+				AND user_tunnel_id IS NOT NULL
+		)
+		WHERE share > 0 AND window_start_s >= ? AND window_start_s < ?
+		GROUP BY device_pk, tunnel_id, window_start_s
+	`, windowSeconds, windowSeconds, windowSeconds, windowSeconds, start, end.Add(v.cfg.Window), start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer tunnelRows.Close()
+	for tunnelRows.Next() {
+		var (
+			key     TunnelKey
+			ts      time.Time
+			counter TunnelCounters
+		)
+		if err := tunnelRows.Scan(&key.DevicePK, &key.TunnelID, &ts, &counter.Samples, &counter.InPkts, &counter.OutPkts); err != nil {
+			return nil, fmt.Errorf("failed to scan tunnel counters: %w", err)
+		}
+		window(ts).Tunnels[key] = counter
+	}
+	if err := tunnelRows.Err(); err != nil {
+		return nil, err
+	}
+
+	linkRows, err := conn.Query(ctx, `
+		SELECT
+			device_pk,
+			link_pk,
+			toDateTime(window_start_s, 'UTC') AS window_start,
+			toInt64(round(sum(in_multicast_pkts * share))) AS in_multicast_pkts,
+			toInt64(round(sum(out_multicast_pkts * share))) AS out_multicast_pkts
+		FROM (
+			SELECT
+				device_pk,
+				link_pk,
+				greatest(COALESCE(in_multicast_pkts_delta, 0), 0) AS in_multicast_pkts,
+				greatest(COALESCE(out_multicast_pkts_delta, 0), 0) AS out_multicast_pkts,
+				`+sampleShare+`
+			FROM fact_dz_device_interface_counters
+			WHERE event_ts > ? AND event_ts < ?
+				AND link_pk != ''
+		)
+		WHERE share > 0 AND window_start_s >= ? AND window_start_s < ?
+		GROUP BY device_pk, link_pk, window_start_s
+	`, windowSeconds, windowSeconds, windowSeconds, windowSeconds, start, end.Add(v.cfg.Window), start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer linkRows.Close()
+	for linkRows.Next() {
+		var (
+			key     LinkKey
+			ts      time.Time
+			counter LinkCounters
+		)
+		if err := linkRows.Scan(&key.DevicePK, &key.LinkPK, &ts, &counter.InMulticastPkts, &counter.OutMulticastPkts); err != nil {
+			return nil, fmt.Errorf("failed to scan link counters: %w", err)
+		}
+		window(ts).Links[key] = counter
+	}
+	if err := linkRows.Err(); err != nil {
+		return nil, err
+	}
+
+	latencyRows, err := conn.Query(ctx, `
+		SELECT
+			link_pk,
+			toStartOfInterval(event_ts, toIntervalSecond(?)) AS window_start,
+			avg(rtt_us) / 2 AS one_way_us
+		FROM fact_dz_device_link_latency
+		WHERE event_ts >= ? AND event_ts < ?
+			AND link_pk != ''
+			AND loss = false
+			AND rtt_us > 0
+		GROUP BY link_pk, window_start
+	`, windowSeconds, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer latencyRows.Close()
+	for latencyRows.Next() {
+		var (
+			linkPK  string
+			ts      time.Time
+			latency float64
+		)
+		if err := latencyRows.Scan(&linkPK, &ts, &latency); err != nil {
+			return nil, fmt.Errorf("failed to scan link latency: %w", err)
+		}
+		window(ts).LinkLatencyUs[linkPK] = latency
+	}
+	return windows, latencyRows.Err()
+}
+
+func (v *View) writeDeliveries(ctx context.Context, deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	ds, err := NewMulticastDeliveryDataset(v.log)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	ds.RecordChanges = true
+	ds.ChangeSink = v.cfg.ChangeSink
+
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	ingestedAt := time.Now().UTC()
+	windowSeconds := int32(v.cfg.Window.Seconds())
+	return ds.WriteBatch(ctx, conn, len(deliveries), func(i int) ([]any, error) {
+		d := deliveries[i]
+		var hop HopLoss
+		if d.WorstHop != nil {
+			hop = *d.WorstHop
+		}
+		return []any{
+			d.WindowStart, // event_ts
+			ingestedAt,    // ingested_at
+			d.GroupPK,
+			d.Subscriber.UserPK,
+			d.Subscriber.DevicePK,
+			d.Subscriber.TunnelID,
+			windowSeconds,
+			int32(d.Publishers),
+			d.ExpectedPkts,
+			d.ReceivedPkts,
+			d.DeliveryRatio,
+			d.LossPct,
+			d.Exclusive,
+			d.Status,
+			hop.FromDevicePK,
+			hop.ToDevicePK,
+			hop.LinkPK,
+			hop.LossPct,
+			int32(d.PathHops),
+			int32(d.UnmeasuredHops),
+			d.LagUs,
+		}, nil
+	})
+}
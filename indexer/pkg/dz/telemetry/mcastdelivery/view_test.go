@@ -0,0 +1,94 @@
+package dztelemmcastdelivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_TelemetryMulticastDelivery_View_Refresh(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	err = conn.Exec(ctx, `
+		INSERT INTO dim_dz_users_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id, publishers, subscribers)
+		VALUES
+			('user1', now(), now(), generateUUIDv4(), 0, 1, 'user1', '', 'activated', 'multicast', '', '', 'dev1', 501, '["group1"]', '[]'),
+			('user2', now(), now(), generateUUIDv4(), 0, 2, 'user2', '', 'activated', 'multicast', '', '', 'dev2', 502, '[]', '["group1"]'),
+			('user3', now(), now(), generateUUIDv4(), 0, 3, 'user3', '', 'activated', 'multicast', '', '', 'dev3', 503, '[]', '["group1"]')
+	`)
+	require.NoError(t, err)
+
+	// A counter sample ending every minute over 15 minutes: user1 publishes 1000 packets a
+	// minute, user2 receives all of them and user3 loses a tenth
+	err = conn.Exec(ctx, `
+		INSERT INTO fact_dz_device_interface_counters
+			(event_ts, ingested_at, device_pk, host, intf, user_tunnel_id, link_pk, link_side,
+			 model_name, serial_number, in_pkts_delta, out_pkts_delta, delta_duration)
+		SELECT
+			addMinutes(toDateTime64(?, 3), intDiv(number, 3)) AS event_ts,
+			now64(3),
+			['dev1', 'dev2', 'dev3'][number % 3 + 1],
+			'',
+			['Tunnel501', 'Tunnel502', 'Tunnel503'][number % 3 + 1],
+			[501, 502, 503][number % 3 + 1],
+			'', '', '', '',
+			if(number % 3 = 0, 1000, 0),
+			[0, 1000, 900][number % 3 + 1],
+			60
+		FROM numbers(45)
+	`, now.Add(-14*time.Minute))
+	require.NoError(t, err)
+
+	view, err := NewView(ViewConfig{
+		Logger:          laketesting.NewLogger(),
+		Clock:           clockwork.NewFakeClockAt(now.Add(10*time.Minute + 30*time.Second)),
+		ClickHouse:      db,
+		RefreshInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, view.Refresh(ctx))
+
+	rows, err := conn.Query(ctx, `
+		SELECT subscriber_pk, count(), sum(expected_pkts), sum(received_pkts), min(delivery_ratio), any(status), any(exclusive)
+		FROM fact_dz_multicast_delivery FINAL
+		WHERE group_pk = 'group1'
+		GROUP BY subscriber_pk
+		ORDER BY subscriber_pk
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		subscriber         string
+		windows            uint64
+		expected, received int64
+		ratio              float64
+		status             string
+		exclusive          bool
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.subscriber, &r.windows, &r.expected, &r.received, &r.ratio, &r.status, &r.exclusive))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+
+	require.Equal(t, []row{
+		{"user2", 3, 15000, 15000, 1, StatusDelivered, true},
+		{"user3", 3, 15000, 13500, 0.9, StatusLossUnlocated, true},
+	}, got)
+}
@@ -0,0 +1,172 @@
+package dztelemuserhealth
+
+import (
+	"time"
+)
+
+const (
+	StatusHealthy  = "healthy"
+	StatusDegraded = "degraded"
+	StatusAtRisk   = "at_risk"
+)
+
+// Issues are the reasons a user's score was lowered.
+const (
+	IssueDeviceNotActivated = "device_not_activated"
+	IssueNoTelemetry        = "no_telemetry"
+	IssueTelemetryGap       = "telemetry_gap"
+	IssueNoTraffic          = "no_traffic"
+	IssueCarrierTransitions = "carrier_transitions"
+	IssueErrors             = "errors"
+	IssueDiscards           = "discards"
+)
+
+// Score penalties, out of 100. Error and discard penalties scale with the rate up to their cap.
+const (
+	penaltyDeviceNotActivated = 40
+	penaltyNoTelemetry        = 50
+	penaltyTelemetryGap       = 30 // at no coverage, scaled down as coverage rises
+	penaltyNoTraffic          = 10 // idle alone stays healthy
+	penaltyCarrierTransition  = 10 // per transition
+	maxCarrierPenalty         = 30
+	maxErrorPenalty           = 25 // reached at a 1% error rate
+	maxDiscardPenalty         = 15 // reached at a 1% discard rate
+)
+
+// User is an activated user whose tunnel is scored.
+type User struct {
+	PK           string
+	DevicePK     string
+	TunnelID     int32
+	DeviceStatus string
+}
+
+// Window is a user's tunnel interface counters summed over one scoring window. Counters are
+// from the device's view, so In is traffic from the user.
+type Window struct {
+	Start              time.Time
+	Samples            int64
+	CoveredSeconds     float64 // sum of the samples' delta durations
+	InPkts             int64
+	OutPkts            int64
+	Errors             int64 // in and out
+	Discards           int64 // in and out
+	CarrierTransitions int64
+}
+
+// Health is a user's tunnel health over one window.
+type Health struct {
+	User               User
+	WindowStart        time.Time
+	Score              float64
+	Status             string
+	Issues             []string
+	Samples            int64
+	CoveragePct        float64
+	InPkts             int64
+	OutPkts            int64
+	Errors             int64
+	Discards           int64
+	CarrierTransitions int64
+	ErrorRatePct       float64
+	DiscardRatePct     float64
+}
+
+type ScorerConfig struct {
+	// Window is the width of the scoring window (default: 5m).
+	Window time.Duration
+	// MinCoveragePct is the share of a window that counter samples must cover before the gap
+	// is penalized (default: 80).
+	MinCoveragePct float64
+	// MinRatePct is the error or discard rate, in % of packets, above which it is reported as
+	// an issue (default: 0.01).
+	MinRatePct float64
+	// DegradedScore and AtRiskScore are the scores below which a user is degraded or at risk
+	// (defaults: 80 and 50).
+	DegradedScore float64
+	AtRiskScore   float64
+}
+
+func (cfg *ScorerConfig) setDefaults() {
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Minute
+	}
+	if cfg.MinCoveragePct <= 0 {
+		cfg.MinCoveragePct = 80
+	}
+	if cfg.MinRatePct <= 0 {
+		cfg.MinRatePct = 0.01
+	}
+	if cfg.DegradedScore <= 0 {
+		cfg.DegradedScore = 80
+	}
+	if cfg.AtRiskScore <= 0 {
+		cfg.AtRiskScore = 50
+	}
+}
+
+// Score rates a user's tunnel over one window, starting from 100 and subtracting a penalty for
+// each issue found. A window without samples is scored as missing telemetry rather than
+// idle, since the counters, not the tunnel, may be what is missing.
+func Score(cfg ScorerConfig, u User, w Window) Health {
+	cfg.setDefaults()
+
+	h := Health{
+		User:               u,
+		WindowStart:        w.Start,
+		Issues:             []string{},
+		Samples:            w.Samples,
+		InPkts:             w.InPkts,
+		OutPkts:            w.OutPkts,
+		Errors:             w.Errors,
+		Discards:           w.Discards,
+		CarrierTransitions: w.CarrierTransitions,
+	}
+	penalty := 0.0
+	flag := func(issue string, p float64) {
+		h.Issues = append(h.Issues, issue)
+		penalty += p
+	}
+
+	if u.DeviceStatus != "activated" {
+		flag(IssueDeviceNotActivated, penaltyDeviceNotActivated)
+	}
+
+	if w.Samples == 0 {
+		flag(IssueNoTelemetry, penaltyNoTelemetry)
+	} else {
+		h.CoveragePct = min(w.CoveredSeconds/cfg.Window.Seconds(), 1) * 100
+		if h.CoveragePct < cfg.MinCoveragePct {
+			flag(IssueTelemetryGap, penaltyTelemetryGap*(1-h.CoveragePct/100))
+		}
+
+		pkts := w.InPkts + w.OutPkts
+		if pkts <= 0 {
+			flag(IssueNoTraffic, penaltyNoTraffic)
+		} else {
+			h.ErrorRatePct = float64(w.Errors) / float64(pkts) * 100
+			h.DiscardRatePct = float64(w.Discards) / float64(pkts) * 100
+			if h.ErrorRatePct > cfg.MinRatePct {
+				flag(IssueErrors, min(h.ErrorRatePct, 1)*maxErrorPenalty)
+			}
+			if h.DiscardRatePct > cfg.MinRatePct {
+				flag(IssueDiscards, min(h.DiscardRatePct, 1)*maxDiscardPenalty)
+			}
+		}
+
+		if w.CarrierTransitions > 0 {
+			flag(IssueCarrierTransitions, min(float64(w.CarrierTransitions)*penaltyCarrierTransition, maxCarrierPenalty))
+		}
+	}
+
+	h.Score = max(100-penalty, 0)
+	switch {
+	case h.Score < cfg.AtRiskScore:
+		h.Status = StatusAtRisk
+	case h.Score < cfg.DegradedScore:
+		h.Status = StatusDegraded
+	default:
+		h.Status = StatusHealthy
+	}
+	return h
+}
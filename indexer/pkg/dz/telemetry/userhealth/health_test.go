@@ -0,0 +1,87 @@
+package dztelemuserhealth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_TelemetryUserHealth_Score(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	user := User{PK: "user1", DevicePK: "dev1", TunnelID: 501, DeviceStatus: "activated"}
+	clean := Window{Start: start, Samples: 5, CoveredSeconds: 300, InPkts: 50000, OutPkts: 50000}
+
+	t.Run("clean tunnel is healthy", func(t *testing.T) {
+		t.Parallel()
+
+		h := Score(ScorerConfig{}, user, clean)
+		require.Equal(t, float64(100), h.Score)
+		require.Equal(t, StatusHealthy, h.Status)
+		require.Empty(t, h.Issues)
+		require.Equal(t, float64(100), h.CoveragePct)
+		require.Equal(t, start, h.WindowStart)
+	})
+
+	t.Run("errors and discards scale with their rate", func(t *testing.T) {
+		t.Parallel()
+
+		w := clean
+		w.Errors = 500   // 0.5%
+		w.Discards = 100 // 0.1%
+		h := Score(ScorerConfig{}, user, w)
+		require.Equal(t, []string{IssueErrors, IssueDiscards}, h.Issues)
+		require.InDelta(t, 0.5, h.ErrorRatePct, 1e-9)
+		require.InDelta(t, 100-12.5-1.5, h.Score, 1e-9)
+		require.Equal(t, StatusHealthy, h.Status)
+
+		// Rates past 1% take the whole penalty
+		w.Errors = 10000
+		h = Score(ScorerConfig{}, user, w)
+		require.InDelta(t, 100-25-1.5, h.Score, 1e-9)
+		require.Equal(t, StatusDegraded, h.Status)
+	})
+
+	t.Run("carrier transitions and gaps", func(t *testing.T) {
+		t.Parallel()
+
+		w := clean
+		w.CoveredSeconds = 150
+		w.CarrierTransitions = 4
+		h := Score(ScorerConfig{}, user, w)
+		require.Equal(t, []string{IssueTelemetryGap, IssueCarrierTransitions}, h.Issues)
+		require.Equal(t, float64(50), h.CoveragePct)
+		require.InDelta(t, 100-15-30, h.Score, 1e-9)
+		require.Equal(t, StatusDegraded, h.Status)
+	})
+
+	t.Run("idle tunnel on a drained device is degraded", func(t *testing.T) {
+		t.Parallel()
+
+		drained := user
+		drained.DeviceStatus = "drained"
+		w := Window{Start: start, Samples: 5, CoveredSeconds: 300}
+		h := Score(ScorerConfig{}, drained, w)
+		require.Equal(t, []string{IssueDeviceNotActivated, IssueNoTraffic}, h.Issues)
+		require.Equal(t, float64(50), h.Score)
+		require.Equal(t, StatusDegraded, h.Status)
+
+		// Idle alone is flagged but not penalized below healthy
+		h = Score(ScorerConfig{}, user, w)
+		require.Equal(t, []string{IssueNoTraffic}, h.Issues)
+		require.Equal(t, float64(90), h.Score)
+		require.Equal(t, StatusHealthy, h.Status)
+	})
+
+	t.Run("missing samples are missing telemetry", func(t *testing.T) {
+		t.Parallel()
+
+		h := Score(ScorerConfig{}, user, Window{Start: start})
+		require.Equal(t, []string{IssueNoTelemetry}, h.Issues)
+		require.Equal(t, float64(50), h.Score)
+		require.Equal(t, StatusDegraded, h.Status)
+		require.Zero(t, h.CoveragePct)
+	})
+}
@@ -0,0 +1,64 @@
+package dztelemuserhealth
+
+import (
+	"log/slog"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+)
+
+type UserHealthSchema struct{}
+
+func (s *UserHealthSchema) Name() string {
+	return "dz_user_health"
+}
+
+func (s *UserHealthSchema) UniqueKeyColumns() []string {
+	return []string{"event_ts", "user_pk"}
+}
+
+func (s *UserHealthSchema) Columns() []string {
+	return []string{
+		"ingested_at:TIMESTAMP",
+		"user_pk:VARCHAR",
+		"device_pk:VARCHAR",
+		"tunnel_id:INTEGER",
+		"window_seconds:INTEGER",
+		"score:DOUBLE",
+		"status:VARCHAR",
+		"issues:VARCHAR",
+		"device_status:VARCHAR",
+		"samples:BIGINT",
+		"coverage_pct:DOUBLE",
+		"in_pkts:BIGINT",
+		"out_pkts:BIGINT",
+		"errors:BIGINT",
+		"discards:BIGINT",
+		"carrier_transitions:BIGINT",
+		"error_rate_pct:DOUBLE",
+		"discard_rate_pct:DOUBLE",
+	}
+}
+
+func (s *UserHealthSchema) TimeColumn() string {
+	return "event_ts"
+}
+
+func (s *UserHealthSchema) PartitionByTime() bool {
+	return true
+}
+
+func (s *UserHealthSchema) Grain() string {
+	return "one row per activated user and scoring window"
+}
+
+func (s *UserHealthSchema) DedupMode() dataset.DedupMode {
+	return dataset.DedupReplacing
+}
+
+func (s *UserHealthSchema) DedupVersionColumn() string {
+	return "ingested_at"
+}
+
+func NewUserHealthDataset(log *slog.Logger) (*dataset.FactDataset, error) {
+	return dataset.NewFactDataset(log, &UserHealthSchema{})
+}
@@ -0,0 +1,297 @@
+package dztelemuserhealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/indexer/pkg/metrics"
+)
+
+type ViewConfig struct {
+	Logger          *slog.Logger
+	Clock           clockwork.Clock
+	ClickHouse      clickhouse.Client
+	RefreshInterval time.Duration
+	Scorer          ScorerConfig
+	// Reevaluate is how far back completed windows are re-scored on each refresh, so counters
+	// that arrive late are still counted (default: 15m). Rewrites replace earlier rows.
+	Reevaluate time.Duration
+	// IngestionLag is how long counters take to land after the window they cover; only windows
+	// ending before now minus the lag are scored, so the newest one isn't scored without its
+	// counters (default: 10m, two device usage refreshes).
+	IngestionLag time.Duration
+	// ChangeSink, if set, receives the change events of the view's writes (optional)
+	ChangeSink dataset.ChangeSink
+}
+
+func (cfg *ViewConfig) Validate() error {
+	if cfg.Logger == nil {
+		return errors.New("logger is required")
+	}
+	if cfg.ClickHouse == nil {
+		return errors.New("clickhouse connection is required")
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("refresh interval must be greater than 0")
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	cfg.Scorer.setDefaults()
+	if cfg.Reevaluate <= 0 {
+		cfg.Reevaluate = 15 * time.Minute
+	}
+	if cfg.IngestionLag <= 0 {
+		cfg.IngestionLag = 10 * time.Minute
+	}
+	return nil
+}
+
+// View periodically scores the tunnel health of activated users over completed windows of
+// their tunnel interface counters and writes the scores to fact_dz_user_health.
+type View struct {
+	log       *slog.Logger
+	cfg       ViewConfig
+	refreshMu sync.Mutex // prevents concurrent refreshes
+
+	// evaluatedUntil is the end of the last window scored, so refreshes between window
+	// boundaries are skipped.
+	evaluatedUntil time.Time
+}
+
+func NewView(cfg ViewConfig) (*View, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &View{
+		log: cfg.Logger,
+		cfg: cfg,
+	}, nil
+}
+
+func (v *View) Start(ctx context.Context) {
+	go func() {
+		v.log.Info("telemetry/userhealth: starting refresh loop", "interval", v.cfg.RefreshInterval, "window", v.cfg.Scorer.Window)
+
+		v.safeRefresh(ctx)
+
+		ticker := v.cfg.Clock.NewTicker(v.cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.Chan():
+				v.safeRefresh(ctx)
+			}
+		}
+	}()
+}
+
+// safeRefresh wraps Refresh with panic recovery to prevent the refresh loop from dying
+func (v *View) safeRefresh(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.log.Error("telemetry/userhealth: refresh panicked", "panic", r)
+			metrics.ViewRefreshTotal.WithLabelValues("userhealth", "panic").Inc()
+		}
+	}()
+
+	if err := v.Refresh(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		v.log.Error("telemetry/userhealth: refresh failed", "error", err)
+	}
+}
+
+// Refresh scores every activated user over the windows in the re-evaluation period that ended
+// at least the ingestion lag ago.
+func (v *View) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	window := v.cfg.Scorer.Window
+	end := v.cfg.Clock.Now().UTC().Add(-v.cfg.IngestionLag).Truncate(window)
+	if !end.After(v.evaluatedUntil) {
+		return nil
+	}
+
+	refreshStart := time.Now()
+	defer func() {
+		duration := time.Since(refreshStart)
+		v.log.Info("telemetry/userhealth: refresh completed", "duration", duration.String())
+		metrics.ViewRefreshDuration.WithLabelValues("userhealth").Observe(duration.Seconds())
+	}()
+
+	from := end.Add(-v.cfg.Reevaluate)
+	users, err := v.queryUsers(ctx)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("userhealth", "error").Inc()
+		return fmt.Errorf("failed to query users: %w", err)
+	}
+	windows, err := v.queryWindows(ctx, from, end)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("userhealth", "error").Inc()
+		return fmt.Errorf("failed to query tunnel counters: %w", err)
+	}
+
+	var scores []Health
+	for _, u := range users {
+		for start := from; start.Before(end); start = start.Add(window) {
+			w, ok := windows[windowKey{u.DevicePK, u.TunnelID, start}]
+			if !ok {
+				w = Window{Start: start}
+			}
+			scores = append(scores, Score(v.cfg.Scorer, u, w))
+		}
+	}
+
+	if err := v.writeScores(ctx, scores); err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("userhealth", "error").Inc()
+		return fmt.Errorf("failed to write user health: %w", err)
+	}
+
+	v.log.Debug("telemetry/userhealth: scored windows", "from", from, "to", end, "users", len(users), "scores", len(scores))
+	v.evaluatedUntil = end
+	metrics.ViewRefreshTotal.WithLabelValues("userhealth", "success").Inc()
+	return nil
+}
+
+// queryUsers returns the activated users that have a tunnel, with their device's status.
+func (v *View) queryUsers(ctx context.Context) ([]User, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	rows, err := conn.Query(ctx, `
+		SELECT u.pk, u.device_pk, u.tunnel_id, COALESCE(d.status, '') AS device_status
+		FROM dz_users_current u
+		LEFT JOIN dz_devices_current d ON u.device_pk = d.pk
+		WHERE u.status = 'activated' AND u.tunnel_id != 0
+		ORDER BY u.pk
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.PK, &u.DevicePK, &u.TunnelID, &u.DeviceStatus); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+type windowKey struct {
+	devicePK string
+	tunnelID int32
+	start    time.Time
+}
+
+// queryWindows sums user tunnel counters in [start, end) into scoring windows, keyed by device,
+// tunnel and window start. Negative deltas from counter resets are ignored.
+func (v *View) queryWindows(ctx context.Context, start, end time.Time) (map[windowKey]Window, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	query := `
+		SELECT
+			device_pk,
+			toInt32(assumeNotNull(user_tunnel_id)) AS tunnel_id,
+			toStartOfInterval(event_ts, toIntervalSecond(?)) AS window_start,
+			toInt64(count()) AS samples,
+			toFloat64(sum(greatest(COALESCE(delta_duration, 0), 0))) AS covered_seconds,
+			toInt64(sum(greatest(COALESCE(in_pkts_delta, 0), 0))) AS in_pkts,
+			toInt64(sum(greatest(COALESCE(out_pkts_delta, 0), 0))) AS out_pkts,
+			toInt64(sum(greatest(COALESCE(in_errors_delta, 0), 0) + greatest(COALESCE(out_errors_delta, 0), 0))) AS errors,
+			toInt64(sum(greatest(COALESCE(in_discards_delta, 0), 0) + greatest(COALESCE(out_discards_delta, 0), 0))) AS discards,
+			toInt64(sum(greatest(COALESCE(carrier_transitions_delta, 0), 0))) AS carrier_transitions
+		FROM fact_dz_device_interface_counters
+		WHERE event_ts >= ? AND event_ts < ?
+			AND user_tunnel_id IS NOT NULL
+		GROUP BY device_pk, tunnel_id, window_start
+	`
+	rows, err := conn.Query(ctx, query, int64(v.cfg.Scorer.Window.Seconds()), start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := make(map[windowKey]Window)
+	for rows.Next() {
+		var (
+			devicePK string
+			tunnelID int32
+			w        Window
+		)
+		if err := rows.Scan(&devicePK, &tunnelID, &w.Start, &w.Samples, &w.CoveredSeconds,
+			&w.InPkts, &w.OutPkts, &w.Errors, &w.Discards, &w.CarrierTransitions); err != nil {
+			return nil, fmt.Errorf("failed to scan tunnel counters: %w", err)
+		}
+		w.Start = w.Start.UTC()
+		windows[windowKey{devicePK, tunnelID, w.Start}] = w
+	}
+	return windows, rows.Err()
+}
+
+func (v *View) writeScores(ctx context.Context, scores []Health) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	ds, err := NewUserHealthDataset(v.log)
+	if err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	ds.RecordChanges = true
+	ds.ChangeSink = v.cfg.ChangeSink
+
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	ingestedAt := time.Now().UTC()
+	windowSeconds := int32(v.cfg.Scorer.Window.Seconds())
+	return ds.WriteBatch(ctx, conn, len(scores), func(i int) ([]any, error) {
+		h := scores[i]
+		return []any{
+			h.WindowStart, // event_ts
+			ingestedAt,    // ingested_at
+			h.User.PK,
+			h.User.DevicePK,
+			h.User.TunnelID,
+			windowSeconds,
+			h.Score,
+			h.Status,
+			strings.Join(h.Issues, ","),
+			h.User.DeviceStatus,
+			h.Samples,
+			h.CoveragePct,
+			h.InPkts,
+			h.OutPkts,
+			h.Errors,
+			h.Discards,
+			h.CarrierTransitions,
+			h.ErrorRatePct,
+			h.DiscardRatePct,
+		}, nil
+	})
+}
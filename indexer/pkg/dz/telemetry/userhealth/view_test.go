@@ -0,0 +1,112 @@
+package dztelemuserhealth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_TelemetryUserHealth_View_Refresh(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	err = conn.Exec(ctx, `
+		INSERT INTO dim_dz_devices_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 pk, status, device_type, code, public_ip, contributor_pk, metro_pk, max_users)
+		VALUES ('dev1', now(), now(), generateUUIDv4(), 0, 1, 'dev1', 'activated', 'router', 'dz1', '', '', '', 0)
+	`)
+	require.NoError(t, err)
+	err = conn.Exec(ctx, `
+		INSERT INTO dim_dz_users_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id)
+		VALUES
+			('user1', now(), now(), generateUUIDv4(), 0, 1, 'user1', '', 'activated', 'ibrl', '', '', 'dev1', 501),
+			('user2', now(), now(), generateUUIDv4(), 0, 2, 'user2', '', 'activated', 'ibrl', '', '', 'dev1', 502),
+			('user3', now(), now(), generateUUIDv4(), 0, 3, 'user3', '', 'pending', 'ibrl', '', '', 'dev1', 503)
+	`)
+	require.NoError(t, err)
+
+	// A counter sample every minute for 15 minutes on both tunnels; Tunnel502 flaps in the last
+	// window
+	err = conn.Exec(ctx, `
+		INSERT INTO fact_dz_device_interface_counters
+			(event_ts, ingested_at, device_pk, host, intf, user_tunnel_id, link_pk, link_side,
+			 model_name, serial_number, in_pkts_delta, out_pkts_delta, in_errors_delta,
+			 carrier_transitions_delta, delta_duration)
+		SELECT
+			addMinutes(toDateTime64(?, 3), intDiv(number, 2)) AS event_ts,
+			now64(3),
+			'dev1', 'dz1',
+			if(number % 2 = 0, 'Tunnel501', 'Tunnel502'),
+			if(number % 2 = 0, 501, 502),
+			'', '', '', '',
+			1000, 1000, 0,
+			if(number % 2 = 1 AND intDiv(number, 2) >= 10, 1, 0),
+			60
+		FROM numbers(30)
+	`, now.Add(-15*time.Minute))
+	require.NoError(t, err)
+
+	view, err := NewView(ViewConfig{
+		Logger:          laketesting.NewLogger(),
+		Clock:           clockwork.NewFakeClockAt(now.Add(10*time.Minute + 30*time.Second)),
+		ClickHouse:      db,
+		RefreshInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, view.Refresh(ctx))
+
+	rows, err := conn.Query(ctx, `
+		SELECT event_ts, user_pk, tunnel_id, score, status, issues, samples
+		FROM fact_dz_user_health FINAL
+		ORDER BY user_pk, event_ts
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		eventTS  time.Time
+		userPK   string
+		tunnelID int32
+		score    float64
+		status   string
+		issues   string
+		samples  int64
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.eventTS, &r.userPK, &r.tunnelID, &r.score, &r.status, &r.issues, &r.samples))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+
+	// Three windows for each activated user; the pending user is not scored
+	require.Len(t, got, 6)
+	for _, r := range got[:3] {
+		require.Equal(t, "user1", r.userPK)
+		require.Equal(t, StatusHealthy, r.status)
+		require.Equal(t, int64(5), r.samples)
+	}
+	require.Equal(t, now.Add(-15*time.Minute), got[0].eventTS.UTC())
+	require.Equal(t, "user2", got[5].userPK)
+	require.Equal(t, int32(502), got[5].tunnelID)
+	require.Equal(t, IssueCarrierTransitions, got[5].issues)
+	require.Equal(t, float64(70), got[5].score)
+	require.Equal(t, StatusDegraded, got[5].status)
+
+	// Refreshing again before the next window is past the ingestion lag is a no-op
+	require.NoError(t, view.Refresh(ctx))
+}
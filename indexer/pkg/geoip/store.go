@@ -14,6 +14,8 @@ import (
 type StoreConfig struct {
 	Logger     *slog.Logger
 	ClickHouse clickhouse.Client
+	// ChangeSink, if set, receives the change events of each write (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *StoreConfig) Validate() error {
@@ -65,6 +67,7 @@ func (s *Store) UpsertRecords(ctx context.Context, records []*geoip.Record) erro
 		return row, nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: false,
+		ChangeSink:          s.cfg.ChangeSink,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write records to ClickHouse: %w", err)
@@ -0,0 +1,43 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	"github.com/malbeclabs/lake/indexer/pkg/sol"
+)
+
+// backfillChangeLog populates dim_change_log from the history of the serviceability and Solana
+// datasets that predates it. Datasets whose backfill has completed are skipped.
+func backfillChangeLog(ctx context.Context, log *slog.Logger, ch clickhouse.Client) error {
+	conn, err := ch.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	constructors := []func(*slog.Logger) (*dataset.DimensionType2Dataset, error){
+		dzsvc.NewContributorDataset,
+		dzsvc.NewDeviceDataset,
+		dzsvc.NewUserDataset,
+		dzsvc.NewMetroDataset,
+		dzsvc.NewLinkDataset,
+		dzsvc.NewMulticastGroupDataset,
+		sol.NewGossipNodeDataset,
+		sol.NewVoteAccountDataset,
+		sol.NewLeaderScheduleDataset,
+	}
+	for _, newDataset := range constructors {
+		ds, err := newDataset(log)
+		if err != nil {
+			return fmt.Errorf("failed to create dataset: %w", err)
+		}
+		if _, err := ds.BackfillChangeLog(ctx, conn); err != nil {
+			return fmt.Errorf("failed to backfill change log for %s: %w", ds.BaseTableName(), err)
+		}
+	}
+	return nil
+}
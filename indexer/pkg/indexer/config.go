@@ -10,6 +10,8 @@ import (
 	"github.com/jonboulle/clockwork"
 	"github.com/malbeclabs/doublezero/tools/maxmind/pkg/geoip"
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
 	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
 	dztelemlatency "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/latency"
 	dztelemusage "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/usage"
@@ -55,6 +57,9 @@ type Config struct {
 
 	// Neo4j configuration (optional).
 	Neo4j neo4j.Client
+	// Neo4jDatabase is the graph database for this DZ env. Defaults to the
+	// client's configured database.
+	Neo4jDatabase string
 
 	// ISIS configuration (optional, requires Neo4j).
 	ISISEnabled         bool
@@ -62,6 +67,11 @@ type Config struct {
 	ISISS3Region        string        // AWS region (default: us-east-1)
 	ISISS3EndpointURL   string        // Custom S3 endpoint URL (for testing)
 	ISISRefreshInterval time.Duration // Refresh interval for IS-IS sync (default: 30s)
+	ISISSource          isis.Source   // Overrides the S3 source (e.g. a simulator)
+
+	// ChangeSink receives change events from dimension writes and selected fact writes
+	// (optional).
+	ChangeSink dataset.ChangeSink
 
 	// SkipReadyWait makes the Ready() method return true immediately without waiting
 	// for views to be populated. Useful for preview/dev environments where fast startup
@@ -11,11 +11,15 @@ import (
 	dzgraph "github.com/malbeclabs/lake/indexer/pkg/dz/graph"
 	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
 	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	dztelemanomaly "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/anomaly"
 	dztelemlatency "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/latency"
+	dztelemmcastdelivery "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/mcastdelivery"
 	dztelemusage "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/usage"
+	dztelemuserhealth "github.com/malbeclabs/lake/indexer/pkg/dz/telemetry/userhealth"
 	mcpgeoip "github.com/malbeclabs/lake/indexer/pkg/geoip"
 	"github.com/malbeclabs/lake/indexer/pkg/neo4j"
 	"github.com/malbeclabs/lake/indexer/pkg/sol"
+	"github.com/malbeclabs/lake/indexer/pkg/validatormatch"
 )
 
 type Indexer struct {
@@ -25,9 +29,13 @@ type Indexer struct {
 	svc          *dzsvc.View
 	graphStore   *dzgraph.Store
 	telemLatency *dztelemlatency.View
+	telemAnomaly *dztelemanomaly.View
 	telemUsage   *dztelemusage.View
+	userHealth   *dztelemuserhealth.View
+	mcastDeliv   *dztelemmcastdelivery.View
 	sol          *sol.View
 	geoip        *mcpgeoip.View
+	valMatch     *validatormatch.View
 	isisSource   isis.Source
 
 	startedAt time.Time
@@ -44,6 +52,16 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 			return nil, fmt.Errorf("failed to run ClickHouse migrations: %w", err)
 		}
 		cfg.Logger.Info("ClickHouse migrations completed")
+
+		// Rehash dimension history in the background after payload column changes (no-op when unchanged)
+		if err := migrateDimensionSchemas(ctx, cfg.Logger, cfg.ClickHouse); err != nil {
+			return nil, fmt.Errorf("failed to migrate dimension schemas: %w", err)
+		}
+
+		// Populate the change log from existing history (no-op once populated)
+		if err := backfillChangeLog(ctx, cfg.Logger, cfg.ClickHouse); err != nil {
+			return nil, fmt.Errorf("failed to backfill change log: %w", err)
+		}
 	}
 
 	// Check ClickHouse env lock
@@ -74,6 +92,7 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 		ServiceabilityRPC: cfg.ServiceabilityRPC,
 		RefreshInterval:   cfg.RefreshInterval,
 		ClickHouse:        cfg.ClickHouse,
+		ChangeSink:        cfg.ChangeSink,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create serviceability view: %w", err)
@@ -91,11 +110,24 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 		ClickHouse:             cfg.ClickHouse,
 		Serviceability:         svcView,
 		RefreshInterval:        cfg.RefreshInterval,
+		ChangeSink:             cfg.ChangeSink,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create telemetry view: %w", err)
 	}
 
+	// Initialize link anomaly detection over the latency samples
+	anomalyView, err := dztelemanomaly.NewView(dztelemanomaly.ViewConfig{
+		Logger:          cfg.Logger,
+		Clock:           cfg.Clock,
+		ClickHouse:      cfg.ClickHouse,
+		RefreshInterval: cfg.RefreshInterval,
+		ChangeSink:      cfg.ChangeSink,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create link anomaly view: %w", err)
+	}
+
 	// Initialize solana view (optional)
 	var solanaView *sol.View
 	if cfg.SolanaRPC != nil {
@@ -105,6 +137,7 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 			RPC:             cfg.SolanaRPC,
 			ClickHouse:      cfg.ClickHouse,
 			RefreshInterval: cfg.RefreshInterval,
+			ChangeSink:      cfg.ChangeSink,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create solana view: %w", err)
@@ -117,6 +150,7 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 		geoIPStore, err := mcpgeoip.NewStore(mcpgeoip.StoreConfig{
 			Logger:     cfg.Logger,
 			ClickHouse: cfg.ClickHouse,
+			ChangeSink: cfg.ChangeSink,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create GeoIP store: %w", err)
@@ -136,6 +170,21 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 		}
 	}
 
+	// Initialize validator to DZ user matching (optional, requires solana)
+	var valMatchView *validatormatch.View
+	if solanaView != nil {
+		valMatchView, err = validatormatch.NewView(validatormatch.ViewConfig{
+			Logger:          cfg.Logger,
+			Clock:           cfg.Clock,
+			ClickHouse:      cfg.ClickHouse,
+			RefreshInterval: cfg.RefreshInterval,
+			ChangeSink:      cfg.ChangeSink,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create validator match view: %w", err)
+		}
+	}
+
 	// Initialize graph store if Neo4j is configured
 	var graphStore *dzgraph.Store
 	if cfg.Neo4j != nil {
@@ -143,11 +192,12 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 			Logger:     cfg.Logger,
 			Neo4j:      cfg.Neo4j,
 			ClickHouse: cfg.ClickHouse,
+			Database:   cfg.Neo4jDatabase,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create graph store: %w", err)
 		}
-		cfg.Logger.Info("Neo4j graph store initialized")
+		cfg.Logger.Info("Neo4j graph store initialized", "database", cfg.Neo4jDatabase)
 	}
 
 	// Initialize telemetry usage view if influx client is configured
@@ -167,9 +217,48 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 		}
 	}
 
+	// Initialize user tunnel health scoring over the usage counters
+	var userHealthView *dztelemuserhealth.View
+	if telemetryUsageView != nil {
+		userHealthView, err = dztelemuserhealth.NewView(dztelemuserhealth.ViewConfig{
+			Logger:          cfg.Logger,
+			Clock:           cfg.Clock,
+			ClickHouse:      cfg.ClickHouse,
+			RefreshInterval: cfg.RefreshInterval,
+			IngestionLag:    2 * cfg.DeviceUsageRefreshInterval,
+			ChangeSink:      cfg.ChangeSink,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user health view: %w", err)
+		}
+	}
+
+	// Initialize multicast delivery analytics over the usage counters, walking the multicast
+	// trees on the graph's IS-IS topology when Neo4j is configured
+	var mcastDeliveryView *dztelemmcastdelivery.View
+	if telemetryUsageView != nil {
+		mcastCfg := dztelemmcastdelivery.ViewConfig{
+			Logger:          cfg.Logger,
+			Clock:           cfg.Clock,
+			ClickHouse:      cfg.ClickHouse,
+			RefreshInterval: cfg.RefreshInterval,
+			IngestionLag:    2 * cfg.DeviceUsageRefreshInterval,
+			ChangeSink:      cfg.ChangeSink,
+		}
+		if graphStore != nil {
+			mcastCfg.Topology = graphStore
+		}
+		mcastDeliveryView, err = dztelemmcastdelivery.NewView(mcastCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create multicast delivery view: %w", err)
+		}
+	}
+
 	// Initialize ISIS source if enabled
 	var isisSource isis.Source
-	if cfg.ISISEnabled {
+	if cfg.ISISEnabled && cfg.ISISSource != nil {
+		isisSource = cfg.ISISSource
+	} else if cfg.ISISEnabled {
 		isisSource, err = isis.NewS3Source(ctx, isis.S3SourceConfig{
 			Bucket:      cfg.ISISS3Bucket,
 			Region:      cfg.ISISS3Region,
@@ -190,9 +279,13 @@ func New(ctx context.Context, cfg Config) (*Indexer, error) {
 		svc:          svcView,
 		graphStore:   graphStore,
 		telemLatency: telemView,
+		telemAnomaly: anomalyView,
 		telemUsage:   telemetryUsageView,
+		userHealth:   userHealthView,
+		mcastDeliv:   mcastDeliveryView,
 		sol:          solanaView,
 		geoip:        geoipView,
+		valMatch:     valMatchView,
 		isisSource:   isisSource,
 	}
 
@@ -216,15 +309,25 @@ func (i *Indexer) Start(ctx context.Context) {
 	i.startedAt = i.cfg.Clock.Now()
 	i.svc.Start(ctx)
 	i.telemLatency.Start(ctx)
+	i.telemAnomaly.Start(ctx)
 	if i.sol != nil {
 		i.sol.Start(ctx)
 	}
 	if i.geoip != nil {
 		i.geoip.Start(ctx)
 	}
+	if i.valMatch != nil {
+		i.valMatch.Start(ctx)
+	}
 	if i.telemUsage != nil {
 		i.telemUsage.Start(ctx)
 	}
+	if i.userHealth != nil {
+		i.userHealth.Start(ctx)
+	}
+	if i.mcastDeliv != nil {
+		i.mcastDeliv.Start(ctx)
+	}
 
 	// Start graph sync loop if Neo4j is configured
 	if i.graphStore != nil {
@@ -0,0 +1,109 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	mcpgeoip "github.com/malbeclabs/lake/indexer/pkg/geoip"
+	"github.com/malbeclabs/lake/indexer/pkg/sol"
+	"github.com/malbeclabs/lake/indexer/pkg/validatormatch"
+)
+
+// DimensionDatasets returns every SCD2 dimension dataset written by the indexer.
+func DimensionDatasets(log *slog.Logger) ([]*dataset.DimensionType2Dataset, error) {
+	constructors := []func(*slog.Logger) (*dataset.DimensionType2Dataset, error){
+		dzsvc.NewContributorDataset,
+		dzsvc.NewDeviceDataset,
+		dzsvc.NewUserDataset,
+		dzsvc.NewMetroDataset,
+		dzsvc.NewLinkDataset,
+		dzsvc.NewMulticastGroupDataset,
+		sol.NewLeaderScheduleDataset,
+		sol.NewVoteAccountDataset,
+		sol.NewGossipNodeDataset,
+		mcpgeoip.NewGeoIPRecordDataset,
+		validatormatch.NewValidatorDZMatchDataset,
+	}
+	datasets := make([]*dataset.DimensionType2Dataset, 0, len(constructors))
+	for _, newDataset := range constructors {
+		ds, err := newDataset(log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dataset: %w", err)
+		}
+		datasets = append(datasets, ds)
+	}
+	return datasets, nil
+}
+
+// MigrateDimensionSchemas rehashes the history of every dimension dataset whose payload
+// columns changed since its last recorded schema version. With dryRun set, nothing is
+// modified and the returned plans only report what would change.
+func MigrateDimensionSchemas(ctx context.Context, log *slog.Logger, conn clickhouse.Connection, dryRun bool) ([]*dataset.SchemaMigrationPlan, error) {
+	datasets, err := DimensionDatasets(log)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]*dataset.SchemaMigrationPlan, 0, len(datasets))
+	for _, ds := range datasets {
+		var plan *dataset.SchemaMigrationPlan
+		if dryRun {
+			plan, err = ds.PlanSchemaMigration(ctx, conn)
+		} else {
+			plan, err = ds.MigrateSchema(ctx, conn)
+		}
+		if err != nil {
+			return plans, fmt.Errorf("failed to migrate schema for %s: %w", ds.BaseTableName(), err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// migrateDimensionSchemas migrates, at startup, the dimension datasets whose recorded schema
+// version doesn't match their payload columns. Checking the recorded versions is one small
+// query per dataset, so unchanged datasets skip the history scans. The rehash of the others
+// runs in the background so it doesn't hold up startup; snapshots written before it finishes
+// can still version entities whose payload is unchanged.
+func migrateDimensionSchemas(ctx context.Context, log *slog.Logger, ch clickhouse.Client) error {
+	datasets, err := DimensionDatasets(log)
+	if err != nil {
+		return err
+	}
+
+	conn, err := ch.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	var changed []*dataset.DimensionType2Dataset
+	for _, ds := range datasets {
+		version, err := ds.CurrentSchemaVersion(ctx, conn)
+		if err != nil {
+			return fmt.Errorf("failed to check schema version for %s: %w", ds.BaseTableName(), err)
+		}
+		if !ds.IsCurrentSchemaVersion(version) {
+			changed = append(changed, ds)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	go func() {
+		conn, err := ch.Conn(ctx)
+		if err != nil {
+			log.Error("failed to get connection for dimension schema migration", "error", err)
+			return
+		}
+		for _, ds := range changed {
+			if _, err := ds.MigrateSchema(ctx, conn); err != nil {
+				log.Error("failed to migrate dimension schema", "dataset", ds.BaseTableName(), "error", err)
+			}
+		}
+	}()
+	return nil
+}
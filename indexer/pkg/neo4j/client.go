@@ -13,6 +13,7 @@ const DefaultDatabase = "neo4j"
 // Client represents a Neo4j database connection.
 type Client interface {
 	Session(ctx context.Context) (Session, error)
+	SessionForDatabase(ctx context.Context, database string) (Session, error)
 	Close(ctx context.Context) error
 }
 
@@ -119,14 +120,20 @@ func newClient(ctx context.Context, log *slog.Logger, uri, database, username, p
 }
 
 func (c *client) Session(ctx context.Context) (Session, error) {
+	return c.SessionForDatabase(ctx, c.database)
+}
+
+// SessionForDatabase creates a session against the given database instead of the
+// client's configured one. Sessions on a read-only client are still read-only.
+func (c *client) SessionForDatabase(ctx context.Context, database string) (Session, error) {
 	cfg := neo4j.SessionConfig{
-		DatabaseName: c.database,
+		DatabaseName: database,
 	}
 	if c.readOnly {
 		cfg.AccessMode = neo4j.AccessModeRead
 	}
 	sess := c.driver.NewSession(ctx, cfg)
-	return &session{sess: sess, database: c.database}, nil
+	return &session{sess: sess, database: database}, nil
 }
 
 func (c *client) Close(ctx context.Context) error {
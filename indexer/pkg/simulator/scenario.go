@@ -0,0 +1,451 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Event types that can be injected by a scenario.
+const (
+	// EventLinkDown drops every latency sample on the link and withdraws its IS-IS adjacency.
+	EventLinkDown = "link_down"
+	// EventLinkDrain sets the link status to soft-drained (or hard-drained with drain: hard).
+	EventLinkDrain = "link_drain"
+	// EventDeviceDown drops the samples of every link on the device and withdraws its LSP.
+	EventDeviceDown = "device_down"
+	// EventDeviceDrain sets the device status to drained.
+	EventDeviceDrain = "device_drain"
+	// EventPacketLoss drops the given fraction of the link's latency samples.
+	EventPacketLoss = "packet_loss"
+	// EventLatencySpike adds latency_ms to the link's RTT.
+	EventLatencySpike = "latency_spike"
+	// EventValidatorDelinquent stops the validator voting and producing blocks.
+	EventValidatorDelinquent = "validator_delinquent"
+)
+
+// Duration is a time.Duration that unmarshals from YAML strings such as "10m" or "1h30m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q: %w", node.Line, s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Scenario declares a synthetic network and the failures injected into it. Times such as
+// added_at and event at are offsets from the start of the simulation.
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Seed derives every public key, so the same scenario and seed produce the same entities
+	// across restarts
+	Seed string `yaml:"seed"`
+
+	// StartEpoch is the epoch reported when the simulation starts (default 1)
+	StartEpoch uint64 `yaml:"start_epoch"`
+	// EpochDuration is the length of a simulated epoch (default 1h)
+	EpochDuration Duration `yaml:"epoch_duration"`
+	// SlotDuration is the length of a simulated Solana slot (default 400ms)
+	SlotDuration Duration `yaml:"slot_duration"`
+	// DeviceSampleInterval is the spacing of device link latency samples (default 10s)
+	DeviceSampleInterval Duration `yaml:"device_sample_interval"`
+	// InternetSampleInterval is the spacing of internet metro latency samples (default 1m)
+	InternetSampleInterval Duration `yaml:"internet_sample_interval"`
+
+	Contributors    []ContributorSpec    `yaml:"contributors"`
+	Metros          []MetroSpec          `yaml:"metros"`
+	Devices         []DeviceSpec         `yaml:"devices"`
+	Links           []LinkSpec           `yaml:"links"`
+	Users           []UserSpec           `yaml:"users"`
+	MulticastGroups []MulticastGroupSpec `yaml:"multicast_groups"`
+	Validators      []ValidatorSpec      `yaml:"validators"`
+	Events          []EventSpec          `yaml:"events"`
+}
+
+type ContributorSpec struct {
+	Code string `yaml:"code"`
+}
+
+type MetroSpec struct {
+	Code      string  `yaml:"code"`
+	Name      string  `yaml:"name"`
+	Latitude  float64 `yaml:"lat"`
+	Longitude float64 `yaml:"lng"`
+}
+
+type DeviceSpec struct {
+	Code        string `yaml:"code"`
+	Metro       string `yaml:"metro"`
+	Contributor string `yaml:"contributor"`
+	PublicIP    string `yaml:"public_ip"`
+	// Type is hybrid, transit or edge (default hybrid)
+	Type     string `yaml:"type"`
+	MaxUsers uint16 `yaml:"max_users"`
+	// AddedAt and RemovedAt bound when the device exists (optional)
+	AddedAt   Duration `yaml:"added_at"`
+	RemovedAt Duration `yaml:"removed_at"`
+}
+
+type LinkSpec struct {
+	// Code defaults to "<side_a>:<side_z>"
+	Code  string `yaml:"code"`
+	SideA string `yaml:"side_a"`
+	SideZ string `yaml:"side_z"`
+	// Contributor defaults to side A's contributor
+	Contributor string `yaml:"contributor"`
+	// Type is WAN or DZX (default WAN)
+	Type          string  `yaml:"type"`
+	BandwidthGbps float64 `yaml:"bandwidth_gbps"`
+	// RTTMs defaults to an estimate from the distance between the two metros
+	RTTMs float64 `yaml:"rtt_ms"`
+	// JitterMs defaults to 2% of the RTT
+	JitterMs  float64  `yaml:"jitter_ms"`
+	AddedAt   Duration `yaml:"added_at"`
+	RemovedAt Duration `yaml:"removed_at"`
+}
+
+type UserSpec struct {
+	Device   string `yaml:"device"`
+	ClientIP string `yaml:"client_ip"`
+	// Kind is ibrl, ibrl_with_allocated_ip, edge_filtering or multicast (default ibrl)
+	Kind        string   `yaml:"kind"`
+	Publishers  []string `yaml:"publishers"`
+	Subscribers []string `yaml:"subscribers"`
+	AddedAt     Duration `yaml:"added_at"`
+	RemovedAt   Duration `yaml:"removed_at"`
+}
+
+type MulticastGroupSpec struct {
+	Code             string  `yaml:"code"`
+	MulticastIP      string  `yaml:"multicast_ip"`
+	MaxBandwidthMbps float64 `yaml:"max_bandwidth_mbps"`
+}
+
+type ValidatorSpec struct {
+	Name     string  `yaml:"name"`
+	GossipIP string  `yaml:"gossip_ip"`
+	StakeSOL float64 `yaml:"stake_sol"`
+	// Device connects the validator to DZ through an IBRL user whose client IP is the gossip IP
+	// (optional)
+	Device     string `yaml:"device"`
+	Version    string `yaml:"version"`
+	Commission uint8  `yaml:"commission"`
+	// SkipRate is the fraction of leader slots without a block (default 0.02)
+	SkipRate  float64  `yaml:"skip_rate"`
+	AddedAt   Duration `yaml:"added_at"`
+	RemovedAt Duration `yaml:"removed_at"`
+}
+
+type EventSpec struct {
+	Type string   `yaml:"type"`
+	At   Duration `yaml:"at"`
+	// Duration bounds the event; zero means it lasts for the rest of the simulation
+	Duration  Duration `yaml:"duration"`
+	Link      string   `yaml:"link"`
+	Device    string   `yaml:"device"`
+	Validator string   `yaml:"validator"`
+	// Loss is the fraction of samples dropped by packet_loss
+	Loss float64 `yaml:"loss"`
+	// LatencyMs is the RTT added by latency_spike
+	LatencyMs float64 `yaml:"latency_ms"`
+	// Drain is soft or hard for link_drain (default soft)
+	Drain string `yaml:"drain"`
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario parses and validates a YAML scenario, applying defaults.
+func ParseScenario(data []byte) (*Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	return &s, nil
+}
+
+// Validate checks references between entities and fills in defaults.
+func (s *Scenario) Validate() error {
+	if s.Seed == "" {
+		s.Seed = s.Name
+	}
+	if s.StartEpoch == 0 {
+		s.StartEpoch = 1
+	}
+	if s.EpochDuration <= 0 {
+		s.EpochDuration = Duration(time.Hour)
+	}
+	if s.SlotDuration <= 0 {
+		s.SlotDuration = Duration(400 * time.Millisecond)
+	}
+	if s.DeviceSampleInterval <= 0 {
+		s.DeviceSampleInterval = Duration(10 * time.Second)
+	}
+	if s.InternetSampleInterval <= 0 {
+		s.InternetSampleInterval = Duration(time.Minute)
+	}
+
+	// The serviceability view refuses snapshots without contributors, devices or metros
+	if len(s.Contributors) == 0 {
+		return errors.New("at least one contributor is required")
+	}
+	if len(s.Metros) == 0 {
+		return errors.New("at least one metro is required")
+	}
+	if len(s.Devices) == 0 {
+		return errors.New("at least one device is required")
+	}
+
+	contributors := make(map[string]bool, len(s.Contributors))
+	for _, c := range s.Contributors {
+		if c.Code == "" {
+			return errors.New("contributor code is required")
+		}
+		if contributors[c.Code] {
+			return fmt.Errorf("duplicate contributor %q", c.Code)
+		}
+		contributors[c.Code] = true
+	}
+
+	metros := make(map[string]bool, len(s.Metros))
+	for i := range s.Metros {
+		m := &s.Metros[i]
+		if m.Code == "" {
+			return errors.New("metro code is required")
+		}
+		if metros[m.Code] {
+			return fmt.Errorf("duplicate metro %q", m.Code)
+		}
+		metros[m.Code] = true
+		if m.Name == "" {
+			m.Name = m.Code
+		}
+	}
+
+	devices := make(map[string]*DeviceSpec, len(s.Devices))
+	for i := range s.Devices {
+		d := &s.Devices[i]
+		if d.Code == "" {
+			return errors.New("device code is required")
+		}
+		if devices[d.Code] != nil {
+			return fmt.Errorf("duplicate device %q", d.Code)
+		}
+		devices[d.Code] = d
+		if !metros[d.Metro] {
+			return fmt.Errorf("device %q: unknown metro %q", d.Code, d.Metro)
+		}
+		if !contributors[d.Contributor] {
+			return fmt.Errorf("device %q: unknown contributor %q", d.Code, d.Contributor)
+		}
+		if err := validateIPv4(d.PublicIP); err != nil {
+			return fmt.Errorf("device %q: public_ip: %w", d.Code, err)
+		}
+		switch d.Type {
+		case "":
+			d.Type = "hybrid"
+		case "hybrid", "transit", "edge":
+		default:
+			return fmt.Errorf("device %q: unknown type %q", d.Code, d.Type)
+		}
+		if d.MaxUsers == 0 {
+			d.MaxUsers = 128
+		}
+		if err := validateWindow(d.AddedAt, d.RemovedAt); err != nil {
+			return fmt.Errorf("device %q: %w", d.Code, err)
+		}
+	}
+
+	links := make(map[string]bool, len(s.Links))
+	for i := range s.Links {
+		l := &s.Links[i]
+		if devices[l.SideA] == nil || devices[l.SideZ] == nil {
+			return fmt.Errorf("link %s:%s: unknown device", l.SideA, l.SideZ)
+		}
+		if l.SideA == l.SideZ {
+			return fmt.Errorf("link %s:%s: sides must be different devices", l.SideA, l.SideZ)
+		}
+		if l.Code == "" {
+			l.Code = l.SideA + ":" + l.SideZ
+		}
+		if links[l.Code] {
+			return fmt.Errorf("duplicate link %q", l.Code)
+		}
+		links[l.Code] = true
+		if l.Contributor == "" {
+			l.Contributor = devices[l.SideA].Contributor
+		}
+		if !contributors[l.Contributor] {
+			return fmt.Errorf("link %q: unknown contributor %q", l.Code, l.Contributor)
+		}
+		switch l.Type {
+		case "":
+			l.Type = "WAN"
+		case "WAN", "DZX":
+		default:
+			return fmt.Errorf("link %q: unknown type %q", l.Code, l.Type)
+		}
+		if l.BandwidthGbps <= 0 {
+			l.BandwidthGbps = 10
+		}
+		if l.RTTMs < 0 || l.JitterMs < 0 {
+			return fmt.Errorf("link %q: rtt_ms and jitter_ms must not be negative", l.Code)
+		}
+		if err := validateWindow(l.AddedAt, l.RemovedAt); err != nil {
+			return fmt.Errorf("link %q: %w", l.Code, err)
+		}
+	}
+
+	groups := make(map[string]bool, len(s.MulticastGroups))
+	for _, g := range s.MulticastGroups {
+		if g.Code == "" {
+			return errors.New("multicast group code is required")
+		}
+		if groups[g.Code] {
+			return fmt.Errorf("duplicate multicast group %q", g.Code)
+		}
+		groups[g.Code] = true
+		if err := validateIPv4(g.MulticastIP); err != nil {
+			return fmt.Errorf("multicast group %q: multicast_ip: %w", g.Code, err)
+		}
+	}
+
+	for i := range s.Users {
+		u := &s.Users[i]
+		if devices[u.Device] == nil {
+			return fmt.Errorf("user %d: unknown device %q", i, u.Device)
+		}
+		if err := validateIPv4(u.ClientIP); err != nil {
+			return fmt.Errorf("user %d: client_ip: %w", i, err)
+		}
+		switch u.Kind {
+		case "":
+			u.Kind = "ibrl"
+		case "ibrl", "ibrl_with_allocated_ip", "edge_filtering", "multicast":
+		default:
+			return fmt.Errorf("user %d: unknown kind %q", i, u.Kind)
+		}
+		for _, code := range append(append([]string{}, u.Publishers...), u.Subscribers...) {
+			if !groups[code] {
+				return fmt.Errorf("user %d: unknown multicast group %q", i, code)
+			}
+		}
+		if err := validateWindow(u.AddedAt, u.RemovedAt); err != nil {
+			return fmt.Errorf("user %d: %w", i, err)
+		}
+	}
+
+	validators := make(map[string]bool, len(s.Validators))
+	for i := range s.Validators {
+		v := &s.Validators[i]
+		if v.Name == "" {
+			return errors.New("validator name is required")
+		}
+		if validators[v.Name] {
+			return fmt.Errorf("duplicate validator %q", v.Name)
+		}
+		validators[v.Name] = true
+		if err := validateIPv4(v.GossipIP); err != nil {
+			return fmt.Errorf("validator %q: gossip_ip: %w", v.Name, err)
+		}
+		if v.Device != "" && devices[v.Device] == nil {
+			return fmt.Errorf("validator %q: unknown device %q", v.Name, v.Device)
+		}
+		if v.StakeSOL < 0 {
+			return fmt.Errorf("validator %q: stake_sol must not be negative", v.Name)
+		}
+		if v.Version == "" {
+			v.Version = "2.2.16"
+		}
+		if v.SkipRate == 0 {
+			v.SkipRate = 0.02
+		}
+		if v.SkipRate < 0 || v.SkipRate > 1 {
+			return fmt.Errorf("validator %q: skip_rate must be between 0 and 1", v.Name)
+		}
+		if err := validateWindow(v.AddedAt, v.RemovedAt); err != nil {
+			return fmt.Errorf("validator %q: %w", v.Name, err)
+		}
+	}
+
+	for i := range s.Events {
+		e := &s.Events[i]
+		if e.At < 0 || e.Duration < 0 {
+			return fmt.Errorf("event %d: at and duration must not be negative", i)
+		}
+		switch e.Type {
+		case EventLinkDown, EventLinkDrain, EventPacketLoss, EventLatencySpike:
+			if !links[e.Link] {
+				return fmt.Errorf("event %d (%s): unknown link %q", i, e.Type, e.Link)
+			}
+		case EventDeviceDown, EventDeviceDrain:
+			if devices[e.Device] == nil {
+				return fmt.Errorf("event %d (%s): unknown device %q", i, e.Type, e.Device)
+			}
+		case EventValidatorDelinquent:
+			if !validators[e.Validator] {
+				return fmt.Errorf("event %d (%s): unknown validator %q", i, e.Type, e.Validator)
+			}
+		default:
+			return fmt.Errorf("event %d: unknown type %q", i, e.Type)
+		}
+		switch e.Type {
+		case EventPacketLoss:
+			if e.Loss <= 0 || e.Loss > 1 {
+				return fmt.Errorf("event %d (%s): loss must be in (0, 1]", i, e.Type)
+			}
+		case EventLatencySpike:
+			if e.LatencyMs <= 0 {
+				return fmt.Errorf("event %d (%s): latency_ms must be positive", i, e.Type)
+			}
+		case EventLinkDrain:
+			switch e.Drain {
+			case "":
+				e.Drain = "soft"
+			case "soft", "hard":
+			default:
+				return fmt.Errorf("event %d (%s): drain must be soft or hard", i, e.Type)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateIPv4(s string) error {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() == nil {
+		return fmt.Errorf("invalid IPv4 address %q", s)
+	}
+	return nil
+}
+
+func validateWindow(addedAt, removedAt Duration) error {
+	if addedAt < 0 || removedAt < 0 {
+		return errors.New("added_at and removed_at must not be negative")
+	}
+	if removedAt > 0 && removedAt <= addedAt {
+		return errors.New("removed_at must be after added_at")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package simulator
+
+import (
+	"context"
+
+	"github.com/malbeclabs/doublezero/smartcontract/sdk/go/serviceability"
+)
+
+// GetProgramData implements dzsvc.ServiceabilityRPC with the entities that exist now.
+func (s *Simulator) GetProgramData(ctx context.Context) (*serviceability.ProgramData, error) {
+	now := s.now()
+	pd := &serviceability.ProgramData{}
+
+	for _, c := range s.sc.Contributors {
+		pk := s.contributors[c.Code]
+		pd.Contributors = append(pd.Contributors, serviceability.Contributor{
+			AccountType: serviceability.ContributorType,
+			Owner:       pk,
+			Status:      serviceability.ContributorStatusActivated,
+			Code:        c.Code,
+			PubKey:      pk,
+		})
+	}
+
+	for _, m := range s.metros {
+		pd.Locations = append(pd.Locations, serviceability.Location{
+			AccountType: serviceability.LocationType,
+			Lat:         m.spec.Latitude,
+			Lng:         m.spec.Longitude,
+			Status:      serviceability.LocationStatusActivated,
+			Code:        m.spec.Code,
+			Name:        m.spec.Name,
+			PubKey:      m.locationPK,
+		})
+		pd.Exchanges = append(pd.Exchanges, serviceability.Exchange{
+			AccountType: serviceability.ExchangeType,
+			Lat:         m.spec.Latitude,
+			Lng:         m.spec.Longitude,
+			Status:      serviceability.ExchangeStatusActivated,
+			Code:        m.spec.Code,
+			Name:        m.spec.Name,
+			PubKey:      m.pk,
+		})
+	}
+
+	usersByDevice := make(map[*simDevice]uint16)
+	publishers := make(map[*simGroup]uint32)
+	subscribers := make(map[*simGroup]uint32)
+	for _, u := range s.users {
+		if !s.userExists(u, now) {
+			continue
+		}
+		usersByDevice[u.device]++
+
+		user := serviceability.User{
+			AccountType:  serviceability.UserType,
+			Owner:        u.owner,
+			UserType:     userType(u.spec.Kind),
+			DevicePubKey: u.device.pk,
+			CyoaType:     serviceability.CyoaTypeGREOverDIA,
+			ClientIp:     u.clientIP,
+			DzIp:         u.dzIP,
+			TunnelId:     uint16(500 + u.index),
+			TunnelNet:    u.tunnelNet,
+			Status:       serviceability.UserStatusActivated,
+			PubKey:       u.pk,
+		}
+		if u.validator != nil {
+			user.ValidatorPubKey = u.validator.identity
+		}
+		for _, g := range u.publishers {
+			user.Publishers = append(user.Publishers, g.pk)
+			publishers[g]++
+		}
+		for _, g := range u.subscribers {
+			user.Subscribers = append(user.Subscribers, g.pk)
+			subscribers[g]++
+		}
+		pd.Users = append(pd.Users, user)
+	}
+
+	for _, d := range s.devices {
+		if !s.deviceExists(d, now) {
+			continue
+		}
+		status := serviceability.DeviceStatusActivated
+		if s.activeEvent(s.deviceEvents[d.spec.Code], EventDeviceDrain, now) != nil {
+			status = serviceability.DeviceStatusDrained
+		}
+
+		loopback := serviceability.Interface{
+			Version:       serviceability.CurrentInterfaceVersion,
+			Status:        serviceability.InterfaceStatusActivated,
+			Name:          "Loopback255",
+			InterfaceType: serviceability.InterfaceTypeLoopback,
+			LoopbackType:  serviceability.LoopbackTypeVpnv4,
+		}
+		copy(loopback.IpNet[:4], d.loopback[:])
+		loopback.IpNet[4] = 32
+		interfaces := []serviceability.Interface{loopback}
+		for _, l := range d.links {
+			if !s.linkExists(l, now) {
+				continue
+			}
+			name, ip := l.ifaceA, l.ipA
+			if l.sideZ == d {
+				name, ip = l.ifaceZ, l.ipZ
+			}
+			iface := serviceability.Interface{
+				Version:       serviceability.CurrentInterfaceVersion,
+				Status:        serviceability.InterfaceStatusActivated,
+				Name:          name,
+				InterfaceType: serviceability.InterfaceTypePhysical,
+				Bandwidth:     uint64(l.spec.BandwidthGbps * 1e9),
+				Mtu:           9000,
+			}
+			copy(iface.IpNet[:4], ip[:])
+			iface.IpNet[4] = 31
+			interfaces = append(interfaces, iface)
+		}
+
+		pd.Devices = append(pd.Devices, serviceability.Device{
+			AccountType:       serviceability.DeviceType,
+			Owner:             d.contributor,
+			LocationPubKey:    d.metro.locationPK,
+			ExchangePubKey:    d.metro.pk,
+			DeviceType:        deviceType(d.spec.Type),
+			PublicIp:          d.publicIP,
+			Status:            status,
+			Code:              d.spec.Code,
+			ContributorPubKey: d.contributor,
+			Interfaces:        interfaces,
+			UsersCount:        usersByDevice[d],
+			MaxUsers:          d.spec.MaxUsers,
+			DeviceHealth:      serviceability.DeviceHealthReadyForUsers,
+			PubKey:            d.pk,
+		})
+	}
+
+	for _, l := range s.links {
+		if !s.linkExists(l, now) {
+			continue
+		}
+		status := serviceability.LinkStatusActivated
+		if e := s.activeEvent(s.linkEvents[l.spec.Code], EventLinkDrain, now); e != nil {
+			status = serviceability.LinkStatusSoftDrained
+			if e.Drain == "hard" {
+				status = serviceability.LinkStatusHardDrained
+			}
+		}
+		link := serviceability.Link{
+			AccountType:       serviceability.LinkType,
+			Owner:             l.contributor,
+			SideAPubKey:       l.sideA.pk,
+			SideZPubKey:       l.sideZ.pk,
+			LinkType:          linkType(l.spec.Type),
+			Bandwidth:         uint64(l.spec.BandwidthGbps * 1e9),
+			Mtu:               9000,
+			DelayNs:           uint64(l.rttUs * 1000),
+			JitterNs:          uint64(l.jitterUs * 1000),
+			TunnelId:          uint16(l.index + 1),
+			Status:            status,
+			Code:              l.spec.Code,
+			ContributorPubKey: l.contributor,
+			SideAIfaceName:    l.ifaceA,
+			SideZIfaceName:    l.ifaceZ,
+			LinkHealth:        serviceability.LinkHealthReadyForService,
+			PubKey:            l.pk,
+		}
+		copy(link.TunnelNet[:4], l.ipA[:])
+		link.TunnelNet[4] = 31
+		pd.Links = append(pd.Links, link)
+	}
+
+	for _, g := range s.groups {
+		pd.MulticastGroups = append(pd.MulticastGroups, serviceability.MulticastGroup{
+			AccountType:     serviceability.MulticastGroupType,
+			MulticastIp:     g.ip,
+			MaxBandwidth:    uint64(g.spec.MaxBandwidthMbps * 1e6),
+			Status:          serviceability.MulticastGroupStatusActivated,
+			Code:            g.spec.Code,
+			PublisherCount:  publishers[g],
+			SubscriberCount: subscribers[g],
+			PubKey:          g.pk,
+		})
+	}
+
+	return pd, nil
+}
+
+func deviceType(s string) serviceability.DeviceDeviceType {
+	switch s {
+	case "transit":
+		return serviceability.DeviceDeviceTypeTransit
+	case "edge":
+		return serviceability.DeviceDeviceTypeEdge
+	default:
+		return serviceability.DeviceDeviceTypeHybrid
+	}
+}
+
+func linkType(s string) serviceability.LinkLinkType {
+	if s == "DZX" {
+		return serviceability.LinkLinkTypeDZX
+	}
+	return serviceability.LinkLinkTypeWAN
+}
+
+func userType(s string) serviceability.UserUserType {
+	switch s {
+	case "ibrl_with_allocated_ip":
+		return serviceability.UserTypeIBRLWithAllocatedIP
+	case "edge_filtering":
+		return serviceability.UserTypeEdgeFiltering
+	case "multicast":
+		return serviceability.UserTypeMulticast
+	default:
+		return serviceability.UserTypeIBRL
+	}
+}
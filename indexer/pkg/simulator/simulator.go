@@ -0,0 +1,467 @@
+// Package simulator fabricates a DZ network and Solana cluster from a declarative scenario.
+// The Simulator implements the RPC interfaces of the serviceability, telemetry latency and
+// Solana views and the IS-IS source, so the whole indexer can run without a ledger, Solana
+// RPC or S3.
+//
+// Every answer is a pure function of the scenario, the simulation start and the current
+// time: sample values are derived from hashes rather than stateful random generators, so
+// repeated and overlapping fetches (and restarts with the same start) agree with what has
+// already been ingested.
+package simulator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"net"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// fiberKmPerMs approximates the one-way propagation speed of light in fiber.
+	fiberKmPerMs = 200.0
+	// routeFactor inflates great-circle distance to account for fiber routes.
+	routeFactor = 1.2
+	// internetRouteFactor is the extra path stretch of the public internet over DZ links.
+	internetRouteFactor = 1.5
+)
+
+var (
+	linkTunnelBlock = net.IPv4(172, 16, 0, 0).To4()
+	loopbackBlock   = net.IPv4(172, 17, 0, 0).To4()
+	userTunnelBlock = net.IPv4(169, 254, 0, 0).To4()
+	userDZIPBlock   = net.IPv4(100, 64, 0, 0).To4()
+)
+
+type Config struct {
+	Logger   *slog.Logger
+	Clock    clockwork.Clock
+	Scenario *Scenario
+	// Start is T+0 of the scenario (default: the clock's current time)
+	Start time.Time
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Logger == nil {
+		return errors.New("logger is required")
+	}
+	if cfg.Scenario == nil {
+		return errors.New("scenario is required")
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	if cfg.Start.IsZero() {
+		cfg.Start = cfg.Clock.Now()
+	}
+	return nil
+}
+
+type Simulator struct {
+	log   *slog.Logger
+	cfg   Config
+	sc    *Scenario
+	start time.Time
+
+	contributors map[string]solana.PublicKey
+	metros       []*simMetro
+	devices      []*simDevice
+	links        []*simLink
+	users        []*simUser
+	groups       []*simGroup
+	validators   []*simValidator
+
+	metrosByPK  map[solana.PublicKey]*simMetro
+	devicesByPK map[solana.PublicKey]*simDevice
+	linksByPK   map[solana.PublicKey]*simLink
+
+	linkEvents      map[string][]*EventSpec
+	deviceEvents    map[string][]*EventSpec
+	validatorEvents map[string][]*EventSpec
+}
+
+type simMetro struct {
+	spec       *MetroSpec
+	pk         solana.PublicKey
+	locationPK solana.PublicKey
+}
+
+type simDevice struct {
+	spec        *DeviceSpec
+	index       int
+	pk          solana.PublicKey
+	metro       *simMetro
+	contributor solana.PublicKey
+	publicIP    [4]byte
+	loopback    [4]byte
+	systemID    string
+	links       []*simLink
+}
+
+type simLink struct {
+	spec        *LinkSpec
+	index       int
+	pk          solana.PublicKey
+	sideA       *simDevice
+	sideZ       *simDevice
+	contributor solana.PublicKey
+	ifaceA      string
+	ifaceZ      string
+	// ipA and ipZ are the two addresses of the link's /31 tunnel net
+	ipA      [4]byte
+	ipZ      [4]byte
+	rttUs    float64
+	jitterUs float64
+}
+
+type simUser struct {
+	spec        *UserSpec
+	index       int
+	pk          solana.PublicKey
+	owner       solana.PublicKey
+	device      *simDevice
+	clientIP    [4]byte
+	dzIP        [4]byte
+	tunnelNet   [5]byte
+	validator   *simValidator
+	publishers  []*simGroup
+	subscribers []*simGroup
+}
+
+type simGroup struct {
+	spec *MulticastGroupSpec
+	pk   solana.PublicKey
+	ip   [4]byte
+}
+
+type simValidator struct {
+	spec     *ValidatorSpec
+	identity solana.PublicKey
+	vote     solana.PublicKey
+	gossipIP [4]byte
+}
+
+func New(cfg Config) (*Simulator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Scenario.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	s := &Simulator{
+		log:             cfg.Logger,
+		cfg:             cfg,
+		sc:              cfg.Scenario,
+		start:           cfg.Start.UTC(),
+		contributors:    make(map[string]solana.PublicKey),
+		metrosByPK:      make(map[solana.PublicKey]*simMetro),
+		devicesByPK:     make(map[solana.PublicKey]*simDevice),
+		linksByPK:       make(map[solana.PublicKey]*simLink),
+		linkEvents:      make(map[string][]*EventSpec),
+		deviceEvents:    make(map[string][]*EventSpec),
+		validatorEvents: make(map[string][]*EventSpec),
+	}
+	s.build()
+
+	s.log.Info("simulator: scenario loaded",
+		"name", s.sc.Name,
+		"start", s.start,
+		"metros", len(s.metros),
+		"devices", len(s.devices),
+		"links", len(s.links),
+		"users", len(s.users),
+		"validators", len(s.validators),
+		"events", len(s.sc.Events))
+	return s, nil
+}
+
+// Start returns T+0 of the scenario.
+func (s *Simulator) Start() time.Time {
+	return s.start
+}
+
+func (s *Simulator) build() {
+	for _, c := range s.sc.Contributors {
+		s.contributors[c.Code] = s.pubkey("contributor", c.Code)
+	}
+
+	metrosByCode := make(map[string]*simMetro)
+	for i := range s.sc.Metros {
+		spec := &s.sc.Metros[i]
+		m := &simMetro{
+			spec:       spec,
+			pk:         s.pubkey("metro", spec.Code),
+			locationPK: s.pubkey("location", spec.Code),
+		}
+		s.metros = append(s.metros, m)
+		s.metrosByPK[m.pk] = m
+		metrosByCode[spec.Code] = m
+	}
+
+	devicesByCode := make(map[string]*simDevice)
+	for i := range s.sc.Devices {
+		spec := &s.sc.Devices[i]
+		d := &simDevice{
+			spec:        spec,
+			index:       i,
+			pk:          s.pubkey("device", spec.Code),
+			metro:       metrosByCode[spec.Metro],
+			contributor: s.contributors[spec.Contributor],
+			publicIP:    ipv4(spec.PublicIP),
+			loopback:    offsetIP(loopbackBlock, i+1),
+		}
+		d.systemID = fmt.Sprintf("%02x%02x.%02x%02x.0000.00-00", d.loopback[0], d.loopback[1], d.loopback[2], d.loopback[3])
+		s.devices = append(s.devices, d)
+		s.devicesByPK[d.pk] = d
+		devicesByCode[spec.Code] = d
+	}
+
+	ifaceCounts := make(map[*simDevice]int)
+	for i := range s.sc.Links {
+		spec := &s.sc.Links[i]
+		a, z := devicesByCode[spec.SideA], devicesByCode[spec.SideZ]
+		ifaceCounts[a]++
+		ifaceCounts[z]++
+
+		rttMs := spec.RTTMs
+		if rttMs == 0 {
+			rttMs = estimateRTTMs(a.metro.spec, z.metro.spec, routeFactor) + 0.2
+		}
+		jitterMs := spec.JitterMs
+		if jitterMs == 0 {
+			jitterMs = math.Max(0.02, rttMs*0.02)
+		}
+
+		l := &simLink{
+			spec:        spec,
+			index:       i,
+			pk:          s.pubkey("link", spec.Code),
+			sideA:       a,
+			sideZ:       z,
+			contributor: s.contributors[spec.Contributor],
+			ifaceA:      fmt.Sprintf("Ethernet%d", ifaceCounts[a]),
+			ifaceZ:      fmt.Sprintf("Ethernet%d", ifaceCounts[z]),
+			ipA:         offsetIP(linkTunnelBlock, 2*i),
+			ipZ:         offsetIP(linkTunnelBlock, 2*i+1),
+			rttUs:       rttMs * 1000,
+			jitterUs:    jitterMs * 1000,
+		}
+		s.links = append(s.links, l)
+		s.linksByPK[l.pk] = l
+		a.links = append(a.links, l)
+		z.links = append(z.links, l)
+	}
+
+	groupsByCode := make(map[string]*simGroup)
+	for i := range s.sc.MulticastGroups {
+		spec := &s.sc.MulticastGroups[i]
+		g := &simGroup{spec: spec, pk: s.pubkey("multicast_group", spec.Code), ip: ipv4(spec.MulticastIP)}
+		s.groups = append(s.groups, g)
+		groupsByCode[spec.Code] = g
+	}
+
+	addUser := func(spec *UserSpec, v *simValidator) {
+		i := len(s.users)
+		u := &simUser{
+			spec:      spec,
+			index:     i,
+			pk:        s.pubkey("user", fmt.Sprintf("%d:%s", i, spec.ClientIP)),
+			owner:     s.pubkey("user_owner", fmt.Sprintf("%d:%s", i, spec.ClientIP)),
+			device:    devicesByCode[spec.Device],
+			clientIP:  ipv4(spec.ClientIP),
+			validator: v,
+		}
+		tunnelIP := offsetIP(userTunnelBlock, 2*i)
+		copy(u.tunnelNet[:4], tunnelIP[:])
+		u.tunnelNet[4] = 31
+		if spec.Kind == "ibrl" {
+			u.dzIP = u.clientIP
+		} else {
+			u.dzIP = offsetIP(userDZIPBlock, i+1)
+		}
+		for _, code := range spec.Publishers {
+			u.publishers = append(u.publishers, groupsByCode[code])
+		}
+		for _, code := range spec.Subscribers {
+			u.subscribers = append(u.subscribers, groupsByCode[code])
+		}
+		s.users = append(s.users, u)
+	}
+	for i := range s.sc.Users {
+		addUser(&s.sc.Users[i], nil)
+	}
+
+	for i := range s.sc.Validators {
+		spec := &s.sc.Validators[i]
+		v := &simValidator{
+			spec:     spec,
+			identity: s.pubkey("validator_identity", spec.Name),
+			vote:     s.pubkey("validator_vote", spec.Name),
+			gossipIP: ipv4(spec.GossipIP),
+		}
+		s.validators = append(s.validators, v)
+		if spec.Device != "" {
+			// The validator's DZ connection: an IBRL user from its gossip IP, present while
+			// the validator is
+			addUser(&UserSpec{
+				Device:    spec.Device,
+				ClientIP:  spec.GossipIP,
+				Kind:      "ibrl",
+				AddedAt:   spec.AddedAt,
+				RemovedAt: spec.RemovedAt,
+			}, v)
+		}
+	}
+
+	for i := range s.sc.Events {
+		e := &s.sc.Events[i]
+		switch {
+		case e.Link != "":
+			s.linkEvents[e.Link] = append(s.linkEvents[e.Link], e)
+		case e.Device != "":
+			s.deviceEvents[e.Device] = append(s.deviceEvents[e.Device], e)
+		case e.Validator != "":
+			s.validatorEvents[e.Validator] = append(s.validatorEvents[e.Validator], e)
+		}
+	}
+}
+
+// pubkey derives a stable public key for an entity from the scenario seed.
+func (s *Simulator) pubkey(kind, id string) solana.PublicKey {
+	sum := sha256.Sum256([]byte("lake-simulator:" + s.sc.Seed + ":" + kind + ":" + id))
+	return solana.PublicKeyFromBytes(sum[:])
+}
+
+func (s *Simulator) now() time.Time {
+	return s.cfg.Clock.Now().UTC()
+}
+
+// exists reports whether an entity with the given lifetime exists at t.
+func (s *Simulator) exists(addedAt, removedAt Duration, t time.Time) bool {
+	elapsed := t.Sub(s.start)
+	return elapsed >= time.Duration(addedAt) && (removedAt == 0 || elapsed < time.Duration(removedAt))
+}
+
+func (s *Simulator) eventActive(e *EventSpec, t time.Time) bool {
+	elapsed := t.Sub(s.start)
+	return elapsed >= time.Duration(e.At) && (e.Duration == 0 || elapsed < time.Duration(e.At+e.Duration))
+}
+
+func (s *Simulator) activeEvent(events []*EventSpec, eventType string, t time.Time) *EventSpec {
+	for _, e := range events {
+		if e.Type == eventType && s.eventActive(e, t) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *Simulator) deviceExists(d *simDevice, t time.Time) bool {
+	return s.exists(d.spec.AddedAt, d.spec.RemovedAt, t)
+}
+
+func (s *Simulator) deviceUp(d *simDevice, t time.Time) bool {
+	return s.deviceExists(d, t) && s.activeEvent(s.deviceEvents[d.spec.Code], EventDeviceDown, t) == nil
+}
+
+// linkExists reports whether the link and both of its devices exist at t.
+func (s *Simulator) linkExists(l *simLink, t time.Time) bool {
+	return s.exists(l.spec.AddedAt, l.spec.RemovedAt, t) && s.deviceExists(l.sideA, t) && s.deviceExists(l.sideZ, t)
+}
+
+// linkUp reports whether traffic crosses the link at t.
+func (s *Simulator) linkUp(l *simLink, t time.Time) bool {
+	return s.linkExists(l, t) &&
+		s.deviceUp(l.sideA, t) && s.deviceUp(l.sideZ, t) &&
+		s.activeEvent(s.linkEvents[l.spec.Code], EventLinkDown, t) == nil
+}
+
+func (s *Simulator) userExists(u *simUser, t time.Time) bool {
+	return s.exists(u.spec.AddedAt, u.spec.RemovedAt, t) && s.deviceExists(u.device, t)
+}
+
+func (s *Simulator) validatorExists(v *simValidator, t time.Time) bool {
+	return s.exists(v.spec.AddedAt, v.spec.RemovedAt, t)
+}
+
+func (s *Simulator) validatorDelinquent(v *simValidator, t time.Time) bool {
+	return s.activeEvent(s.validatorEvents[v.spec.Name], EventValidatorDelinquent, t) != nil
+}
+
+// epochAt returns the epoch containing t and the time it started. Times before the
+// simulation start belong to the start epoch.
+func (s *Simulator) epochAt(t time.Time) (uint64, time.Time) {
+	n := uint64(0)
+	if elapsed := t.Sub(s.start); elapsed > 0 {
+		n = uint64(elapsed / time.Duration(s.sc.EpochDuration))
+	}
+	return s.sc.StartEpoch + n, s.epochStart(s.sc.StartEpoch + n)
+}
+
+// epochStart returns the start of an epoch; epoch must not precede the start epoch.
+func (s *Simulator) epochStart(epoch uint64) time.Time {
+	return s.start.Add(time.Duration(epoch-s.sc.StartEpoch) * time.Duration(s.sc.EpochDuration))
+}
+
+func (s *Simulator) slotsPerEpoch() uint64 {
+	return uint64(time.Duration(s.sc.EpochDuration) / time.Duration(s.sc.SlotDuration))
+}
+
+// slotAt returns the absolute slot and the epoch slot index at t.
+func (s *Simulator) slotAt(t time.Time) (epoch, absolute, index uint64) {
+	epoch, start := s.epochAt(t)
+	if t.After(start) {
+		index = min(uint64(t.Sub(start)/time.Duration(s.sc.SlotDuration)), s.slotsPerEpoch()-1)
+	}
+	return epoch, epoch*s.slotsPerEpoch() + index, index
+}
+
+// noise returns a uniform value in [0, 1) determined by the scenario seed, key and n.
+func (s *Simulator) noise(key string, n uint64) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(s.sc.Seed))
+	h.Write([]byte(key))
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	h.Write(buf[:])
+	// splitmix64 finalizer to spread fnv's low-entropy high bits
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11) / (1 << 53)
+}
+
+// estimateRTTMs estimates the round-trip time between two metros from their great-circle
+// distance.
+func estimateRTTMs(a, z *MetroSpec, factor float64) float64 {
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := a.Latitude*math.Pi/180, z.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (z.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	km := 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+	return 2 * km * factor / fiberKmPerMs
+}
+
+func ipv4(s string) [4]byte {
+	var out [4]byte
+	copy(out[:], net.ParseIP(s).To4())
+	return out
+}
+
+func offsetIP(base net.IP, n int) [4]byte {
+	var out [4]byte
+	binary.BigEndian.PutUint32(out[:], binary.BigEndian.Uint32(base)+uint32(n))
+	return out
+}
@@ -0,0 +1,260 @@
+package simulator
+
+import (
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	solanarpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/jonboulle/clockwork"
+	"github.com/malbeclabs/doublezero/smartcontract/sdk/go/serviceability"
+	"github.com/malbeclabs/doublezero/smartcontract/sdk/go/telemetry"
+	"github.com/malbeclabs/lake/indexer/pkg/dz/isis"
+	"github.com/stretchr/testify/require"
+)
+
+const testScenario = `
+name: test
+epoch_duration: 1h
+device_sample_interval: 10s
+contributors:
+  - code: acme
+metros:
+  - {code: nyc, lat: 40.7, lng: -74.0}
+  - {code: lon, lat: 51.5, lng: -0.1}
+devices:
+  - {code: nyc-dz01, metro: nyc, contributor: acme, public_ip: 203.0.113.1}
+  - {code: lon-dz01, metro: lon, contributor: acme, public_ip: 203.0.113.2}
+  - {code: lon-dz02, metro: lon, contributor: acme, public_ip: 203.0.113.3, added_at: 30m}
+links:
+  - {side_a: nyc-dz01, side_z: lon-dz01, rtt_ms: 70}
+  - {side_a: lon-dz01, side_z: lon-dz02, added_at: 30m}
+validators:
+  - {name: alpha, gossip_ip: 192.0.2.1, stake_sol: 1000, device: nyc-dz01}
+  - {name: bravo, gossip_ip: 192.0.2.2, stake_sol: 3000}
+events:
+  - {type: link_down, link: "nyc-dz01:lon-dz01", at: 10m, duration: 5m}
+  - {type: link_drain, link: "nyc-dz01:lon-dz01", at: 20m, drain: hard}
+  - {type: validator_delinquent, validator: bravo, at: 5m, duration: 10m}
+`
+
+func newTestSimulator(t *testing.T) (*Simulator, *clockwork.FakeClock) {
+	t.Helper()
+	scenario, err := ParseScenario([]byte(testScenario))
+	require.NoError(t, err)
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	clock := clockwork.NewFakeClockAt(start)
+	sim, err := New(Config{
+		Logger:   slog.New(slog.DiscardHandler),
+		Clock:    clock,
+		Scenario: scenario,
+	})
+	require.NoError(t, err)
+	return sim, clock
+}
+
+func TestLake_Simulator_DemoScenario(t *testing.T) {
+	t.Parallel()
+
+	scenario, err := LoadScenario("../../scenarios/demo.yaml")
+	require.NoError(t, err)
+	sim, err := New(Config{Logger: slog.New(slog.DiscardHandler), Scenario: scenario})
+	require.NoError(t, err)
+
+	pd, err := sim.GetProgramData(t.Context())
+	require.NoError(t, err)
+	require.NotEmpty(t, pd.Contributors)
+	require.NotEmpty(t, pd.Devices)
+	require.NotEmpty(t, pd.Exchanges)
+	require.NotEmpty(t, pd.Links)
+}
+
+func TestLake_Simulator_ParseScenario_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for name, yaml := range map[string]string{
+		"no devices":        "contributors: [{code: a}]\nmetros: [{code: m}]\n",
+		"unknown metro":     "contributors: [{code: a}]\nmetros: [{code: m}]\ndevices: [{code: d, metro: x, contributor: a, public_ip: 10.0.0.1}]\n",
+		"bad duration":      "epoch_duration: soon\n",
+		"unknown link":      "contributors: [{code: a}]\nmetros: [{code: m}]\ndevices: [{code: d, metro: m, contributor: a, public_ip: 10.0.0.1}]\nevents: [{type: link_down, link: nope}]\n",
+		"unknown event":     "contributors: [{code: a}]\nmetros: [{code: m}]\ndevices: [{code: d, metro: m, contributor: a, public_ip: 10.0.0.1}]\nevents: [{type: meteor, device: d}]\n",
+		"loss out of range": "contributors: [{code: a}]\nmetros: [{code: m}]\ndevices: [{code: d, metro: m, contributor: a, public_ip: 10.0.0.1}, {code: e, metro: m, contributor: a, public_ip: 10.0.0.2}]\nlinks: [{side_a: d, side_z: e}]\nevents: [{type: packet_loss, link: \"d:e\", loss: 2}]\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(yaml))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLake_Simulator_GetProgramData(t *testing.T) {
+	t.Parallel()
+
+	sim, clock := newTestSimulator(t)
+
+	pd, err := sim.GetProgramData(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pd.Devices, 2, "lon-dz02 is not added until 30m")
+	require.Len(t, pd.Links, 1)
+	require.Len(t, pd.Users, 1, "validator alpha connects through a user")
+	require.Equal(t, [4]byte{192, 0, 2, 1}, pd.Users[0].ClientIp)
+	require.Equal(t, sim.validators[0].identity, solana.PublicKeyFromBytes(pd.Users[0].ValidatorPubKey[:]))
+
+	link := pd.Links[0]
+	require.Equal(t, "nyc-dz01:lon-dz01", link.Code)
+	require.Equal(t, serviceability.LinkStatusActivated, link.Status)
+	require.Equal(t, uint64(70_000_000), link.DelayNs)
+	require.Equal(t, [5]byte{172, 16, 0, 0, 31}, link.TunnelNet)
+
+	clock.Advance(35 * time.Minute)
+	pd, err = sim.GetProgramData(t.Context())
+	require.NoError(t, err)
+	require.Len(t, pd.Devices, 3)
+	require.Len(t, pd.Links, 2)
+	require.Equal(t, serviceability.LinkStatusHardDrained, pd.Links[0].Status)
+
+	// Keys are derived from the seed, so a second simulator agrees
+	other, _ := newTestSimulator(t)
+	require.Equal(t, sim.devices[0].pk, other.devices[0].pk)
+}
+
+func TestLake_Simulator_DeviceLatencySamples(t *testing.T) {
+	t.Parallel()
+
+	sim, clock := newTestSimulator(t)
+	l := sim.links[0]
+	a, z := l.sideA.pk, l.sideZ.pk
+	epoch := sim.sc.StartEpoch
+
+	clock.Advance(20 * time.Minute)
+
+	hdr, startIdx, all, err := sim.GetDeviceLatencySamplesTail(t.Context(), a, z, l.pk, epoch, -1)
+	require.NoError(t, err)
+	require.Equal(t, 0, startIdx)
+	require.Len(t, all, 121, "samples every 10s from T+0 through T+20m")
+	require.Equal(t, uint32(121), hdr.NextSampleIndex)
+	require.Equal(t, uint64(sim.start.UnixMicro()), hdr.StartTimestampMicroseconds)
+
+	// The tail agrees with the full fetch
+	_, startIdx, tail, err := sim.GetDeviceLatencySamplesTail(t.Context(), a, z, l.pk, epoch, 99)
+	require.NoError(t, err)
+	require.Equal(t, 100, startIdx)
+	require.Equal(t, all[100:], tail)
+
+	// Link down from 10m to 15m: samples 60..89 are lost, the rest are near 70ms
+	for i, rtt := range all {
+		if i >= 60 && i < 90 {
+			require.Zero(t, rtt, "sample %d", i)
+			continue
+		}
+		require.InDelta(t, 70_000, rtt, 2_000, "sample %d", i)
+	}
+
+	// Wrong direction/link, the previous epoch, and a link that doesn't exist yet are not found
+	_, _, _, err = sim.GetDeviceLatencySamplesTail(t.Context(), a, a, l.pk, epoch, -1)
+	require.ErrorIs(t, err, telemetry.ErrAccountNotFound)
+	_, _, _, err = sim.GetDeviceLatencySamplesTail(t.Context(), a, z, l.pk, epoch-1, -1)
+	require.ErrorIs(t, err, telemetry.ErrAccountNotFound)
+	l2 := sim.links[1]
+	_, _, _, err = sim.GetDeviceLatencySamplesTail(t.Context(), l2.sideA.pk, l2.sideZ.pk, l2.pk, epoch, -1)
+	require.ErrorIs(t, err, telemetry.ErrAccountNotFound)
+
+	// Next epoch starts a new account
+	clock.Advance(time.Hour)
+	hdr, _, _, err = sim.GetDeviceLatencySamplesTail(t.Context(), a, z, l.pk, epoch, -1)
+	require.NoError(t, err)
+	require.Equal(t, uint32(360), hdr.NextSampleIndex, "completed epoch holds a full hour of samples")
+	info, err := sim.GetEpochInfo(t.Context(), solanarpc.CommitmentFinalized)
+	require.NoError(t, err)
+	require.Equal(t, epoch+1, info.Epoch)
+}
+
+func TestLake_Simulator_InternetLatencySamples(t *testing.T) {
+	t.Parallel()
+
+	sim, clock := newTestSimulator(t)
+	clock.Advance(10 * time.Minute)
+
+	nyc, lon := sim.metros[0].pk, sim.metros[1].pk
+	samples, err := sim.GetInternetLatencySamples(t.Context(), "ripeatlas", nyc, lon, solana.PublicKey{}, sim.sc.StartEpoch)
+	require.NoError(t, err)
+	require.Len(t, samples.Samples, 11)
+
+	_, err = sim.GetInternetLatencySamples(t.Context(), "ripeatlas", nyc, nyc, solana.PublicKey{}, sim.sc.StartEpoch)
+	require.ErrorIs(t, err, telemetry.ErrAccountNotFound)
+}
+
+func TestLake_Simulator_ISIS(t *testing.T) {
+	t.Parallel()
+
+	sim, clock := newTestSimulator(t)
+	l := sim.links[0]
+
+	dump, err := sim.FetchLatest(t.Context())
+	require.NoError(t, err)
+	lsps, err := isis.Parse(dump.RawJSON)
+	require.NoError(t, err)
+	require.Len(t, lsps, 2)
+
+	// Each side's adjacency is addressed by the far end's tunnel IP
+	neighborAddrs := make(map[string]string)
+	for _, lsp := range lsps {
+		require.Len(t, lsp.Neighbors, 1)
+		require.Equal(t, uint32(70_000), lsp.Neighbors[0].Metric)
+		neighborAddrs[lsp.Hostname] = lsp.Neighbors[0].NeighborAddr
+		_, ok := lsp.NodeSID()
+		require.True(t, ok, "every device advertises a node SID")
+	}
+	require.Equal(t, net.IP(l.ipZ[:]).String(), neighborAddrs["NYC-DZ01"])
+	require.Equal(t, net.IP(l.ipA[:]).String(), neighborAddrs["LON-DZ01"])
+
+	// The adjacency is withdrawn while the link is down
+	clock.Advance(12 * time.Minute)
+	dump, err = sim.FetchLatest(t.Context())
+	require.NoError(t, err)
+	lsps, err = isis.Parse(dump.RawJSON)
+	require.NoError(t, err)
+	for _, lsp := range lsps {
+		require.Empty(t, lsp.Neighbors)
+	}
+}
+
+func TestLake_Simulator_Solana(t *testing.T) {
+	t.Parallel()
+
+	sim, clock := newTestSimulator(t)
+	clock.Advance(7 * time.Minute)
+
+	votes, err := sim.GetVoteAccounts(t.Context(), nil)
+	require.NoError(t, err)
+	require.Len(t, votes.Current, 1)
+	require.Len(t, votes.Delinquent, 1)
+	require.Equal(t, sim.validators[1].identity, votes.Delinquent[0].NodePubkey)
+	require.Equal(t, uint64(1000*solana.LAMPORTS_PER_SOL), votes.Current[0].ActivatedStake)
+
+	nodes, err := sim.GetClusterNodes(t.Context())
+	require.NoError(t, err)
+	require.Len(t, nodes, 1, "delinquent validators are absent from gossip")
+	require.Equal(t, "192.0.2.1:8001", *nodes[0].Gossip)
+
+	schedule, err := sim.GetLeaderSchedule(t.Context())
+	require.NoError(t, err)
+	var slots int
+	for _, s := range schedule {
+		slots += len(s)
+	}
+	require.Equal(t, int(sim.slotsPerEpoch()), slots)
+	require.Greater(t, len(schedule[sim.validators[1].identity]), len(schedule[sim.validators[0].identity]), "leader slots follow stake")
+
+	production, err := sim.GetBlockProduction(t.Context())
+	require.NoError(t, err)
+	bravo := production.Value.ByIdentity[sim.validators[1].identity]
+	require.Positive(t, bravo[0])
+	require.Less(t, bravo[1], bravo[0], "bravo skips its slots while delinquent")
+
+	slot, err := sim.GetSlot(t.Context(), solanarpc.CommitmentFinalized)
+	require.NoError(t, err)
+	require.Equal(t, production.Value.Range.LastSlot, slot)
+}
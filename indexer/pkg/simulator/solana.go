@@ -0,0 +1,172 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	solanarpc "github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	slotsPerLeader = 4
+	shredVersion   = 50093
+	// creditsPerSlot approximates the vote credits earned by a healthy validator per slot.
+	creditsPerSlot = 16
+)
+
+// GetSlot implements sol.SolanaRPC.
+func (s *Simulator) GetSlot(ctx context.Context, commitment solanarpc.CommitmentType) (uint64, error) {
+	_, absolute, _ := s.slotAt(s.now())
+	return absolute, nil
+}
+
+// GetLeaderSchedule implements sol.SolanaRPC, returning epoch-relative slot indices for the
+// current epoch.
+func (s *Simulator) GetLeaderSchedule(ctx context.Context) (solanarpc.GetLeaderScheduleResult, error) {
+	epoch, _ := s.epochAt(s.now())
+	schedule := make(solanarpc.GetLeaderScheduleResult)
+	for group, v := range s.leaders(epoch) {
+		for i := range uint64(slotsPerLeader) {
+			slot := uint64(group)*slotsPerLeader + i
+			schedule[v.identity] = append(schedule[v.identity], slot)
+		}
+	}
+	return schedule, nil
+}
+
+// GetBlockProduction implements sol.SolanaRPC for the current epoch up to the current slot.
+// Leaders skip slots at their skip rate and every slot while delinquent or removed.
+func (s *Simulator) GetBlockProduction(ctx context.Context) (*solanarpc.GetBlockProductionResult, error) {
+	now := s.now()
+	epoch, absolute, index := s.slotAt(now)
+	epochStart := s.epochStart(epoch)
+	first := epoch * s.slotsPerEpoch()
+
+	byIdentity := make(solanarpc.IdentityToSlotsBlocks)
+	for group, v := range s.leaders(epoch) {
+		for i := range uint64(slotsPerLeader) {
+			slot := uint64(group)*slotsPerLeader + i
+			if slot > index {
+				break
+			}
+			counts := byIdentity[v.identity]
+			counts[0]++
+			t := epochStart.Add(time.Duration(slot) * time.Duration(s.sc.SlotDuration))
+			if s.validatorExists(v, t) && !s.validatorDelinquent(v, t) && s.noise("skip/"+v.spec.Name, first+slot) >= v.spec.SkipRate {
+				counts[1]++
+			}
+			byIdentity[v.identity] = counts
+		}
+	}
+
+	return &solanarpc.GetBlockProductionResult{
+		RPCContext: solanarpc.RPCContext{Context: solanarpc.Context{Slot: absolute}},
+		Value: solanarpc.BlockProductionResult{
+			ByIdentity: byIdentity,
+			Range:      solanarpc.SlotRangeResponse{FirstSlot: first, LastSlot: absolute},
+		},
+	}, nil
+}
+
+// GetVoteAccounts implements sol.SolanaRPC. Delinquent validators stop voting at the start of
+// their delinquency.
+func (s *Simulator) GetVoteAccounts(ctx context.Context, opts *solanarpc.GetVoteAccountsOpts) (*solanarpc.GetVoteAccountsResult, error) {
+	now := s.now()
+	epoch, absolute, index := s.slotAt(now)
+	prevCredits := int64((epoch - s.sc.StartEpoch) * s.slotsPerEpoch() * creditsPerSlot)
+
+	result := &solanarpc.GetVoteAccountsResult{
+		Current:    []solanarpc.VoteAccountsResult{},
+		Delinquent: []solanarpc.VoteAccountsResult{},
+	}
+	for _, v := range s.validators {
+		if !s.validatorExists(v, now) {
+			continue
+		}
+		if opts != nil && opts.VotePubkey != nil && !opts.VotePubkey.Equals(v.vote) {
+			continue
+		}
+
+		lastVote := absolute - min(absolute, uint64(s.noise("vote/"+v.spec.Name, absolute)*3))
+		credits := prevCredits + int64(index*creditsPerSlot)
+		e := s.activeEvent(s.validatorEvents[v.spec.Name], EventValidatorDelinquent, now)
+		if e != nil {
+			_, lastVote, _ = s.slotAt(s.start.Add(time.Duration(e.At)))
+		}
+
+		account := solanarpc.VoteAccountsResult{
+			VotePubkey:       v.vote,
+			NodePubkey:       v.identity,
+			ActivatedStake:   uint64(v.spec.StakeSOL * float64(solana.LAMPORTS_PER_SOL)),
+			EpochVoteAccount: true,
+			Commission:       v.spec.Commission,
+			LastVote:         lastVote,
+			RootSlot:         lastVote - min(lastVote, 31),
+			EpochCredits:     [][]int64{{int64(epoch), credits, prevCredits}},
+		}
+		if e != nil {
+			result.Delinquent = append(result.Delinquent, account)
+		} else {
+			result.Current = append(result.Current, account)
+		}
+	}
+	return result, nil
+}
+
+// GetClusterNodes implements sol.SolanaRPC. Delinquent validators are absent from gossip.
+func (s *Simulator) GetClusterNodes(ctx context.Context) ([]*solanarpc.GetClusterNodesResult, error) {
+	now := s.now()
+	var nodes []*solanarpc.GetClusterNodesResult
+	for _, v := range s.validators {
+		if !s.validatorExists(v, now) || s.validatorDelinquent(v, now) {
+			continue
+		}
+		ip := net.IP(v.gossipIP[:]).String()
+		gossip := fmt.Sprintf("%s:8001", ip)
+		tpu := fmt.Sprintf("%s:8003", ip)
+		tpuQUIC := fmt.Sprintf("%s:8009", ip)
+		version := v.spec.Version
+		nodes = append(nodes, &solanarpc.GetClusterNodesResult{
+			Pubkey:       v.identity,
+			Gossip:       &gossip,
+			TPU:          &tpu,
+			TPUQUIC:      &tpuQUIC,
+			Version:      &version,
+			ShredVersion: shredVersion,
+		})
+	}
+	return nodes, nil
+}
+
+// leaders assigns each group of slotsPerLeader slots in the epoch to a validator, weighted by
+// the stake of the validators that exist at the start of the epoch.
+func (s *Simulator) leaders(epoch uint64) []*simValidator {
+	start := s.epochStart(epoch)
+	var staked []*simValidator
+	var cumulative []float64
+	var total float64
+	for _, v := range s.validators {
+		if v.spec.StakeSOL <= 0 || !s.validatorExists(v, start) {
+			continue
+		}
+		total += v.spec.StakeSOL
+		staked = append(staked, v)
+		cumulative = append(cumulative, total)
+	}
+	if len(staked) == 0 {
+		return nil
+	}
+
+	groups := make([]*simValidator, s.slotsPerEpoch()/slotsPerLeader)
+	key := fmt.Sprintf("leader/%d", epoch)
+	for g := range groups {
+		target := s.noise(key, uint64(g)) * total
+		i := sort.SearchFloat64s(cumulative, target)
+		groups[g] = staked[min(i, len(staked)-1)]
+	}
+	return groups
+}
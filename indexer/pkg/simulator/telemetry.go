@@ -0,0 +1,172 @@
+package simulator
+
+import (
+	"context"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	solanarpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/malbeclabs/doublezero/smartcontract/sdk/go/telemetry"
+)
+
+// GetEpochInfo implements dztelemlatency.EpochRPC and sol.SolanaRPC. DZ and Solana share the
+// simulated epoch clock.
+func (s *Simulator) GetEpochInfo(ctx context.Context, commitment solanarpc.CommitmentType) (*solanarpc.GetEpochInfoResult, error) {
+	epoch, absolute, index := s.slotAt(s.now())
+	return &solanarpc.GetEpochInfoResult{
+		AbsoluteSlot: absolute,
+		BlockHeight:  absolute,
+		Epoch:        epoch,
+		SlotIndex:    index,
+		SlotsInEpoch: s.slotsPerEpoch(),
+	}, nil
+}
+
+// GetDeviceLatencySamplesTail implements dztelemlatency.TelemetryRPC. Samples start at the
+// later of the epoch start and the link's creation; a down link or device yields 0 (loss).
+func (s *Simulator) GetDeviceLatencySamplesTail(ctx context.Context, originDevicePK, targetDevicePK, linkPK solana.PublicKey, epoch uint64, existingMaxIdx int) (*telemetry.DeviceLatencySamplesHeader, int, []uint32, error) {
+	l, ok := s.linksByPK[linkPK]
+	if !ok || epoch < s.sc.StartEpoch {
+		return nil, 0, nil, telemetry.ErrAccountNotFound
+	}
+	var reverse bool
+	switch {
+	case originDevicePK == l.sideA.pk && targetDevicePK == l.sideZ.pk:
+	case originDevicePK == l.sideZ.pk && targetDevicePK == l.sideA.pk:
+		reverse = true
+	default:
+		return nil, 0, nil, telemetry.ErrAccountNotFound
+	}
+
+	created := s.start.Add(time.Duration(max(l.spec.AddedAt, l.sideA.spec.AddedAt, l.sideZ.spec.AddedAt)))
+	var removed time.Time
+	for _, r := range []Duration{l.spec.RemovedAt, l.sideA.spec.RemovedAt, l.sideZ.spec.RemovedAt} {
+		if r > 0 && (removed.IsZero() || s.start.Add(time.Duration(r)).Before(removed)) {
+			removed = s.start.Add(time.Duration(r))
+		}
+	}
+
+	interval := time.Duration(s.sc.DeviceSampleInterval)
+	first, count := s.sampleWindow(epoch, created, removed, interval)
+	if count == 0 {
+		return nil, 0, nil, telemetry.ErrAccountNotFound
+	}
+
+	hdr := &telemetry.DeviceLatencySamplesHeader{
+		AccountType:                  telemetry.AccountTypeDeviceLatencySamples,
+		Epoch:                        epoch,
+		OriginDevicePK:               originDevicePK,
+		TargetDevicePK:               targetDevicePK,
+		LinkPK:                       linkPK,
+		SamplingIntervalMicroseconds: uint64(interval.Microseconds()),
+		StartTimestampMicroseconds:   uint64(first.UnixMicro()),
+		NextSampleIndex:              uint32(count),
+	}
+
+	startIdx := min(max(existingMaxIdx+1, 0), count)
+	tail := make([]uint32, 0, count-startIdx)
+	for i := startIdx; i < count; i++ {
+		tail = append(tail, s.linkSample(l, reverse, first.Add(time.Duration(i)*interval)))
+	}
+	return hdr, startIdx, tail, nil
+}
+
+// GetInternetLatencySamples implements dztelemlatency.TelemetryRPC with internet RTTs
+// estimated from metro distance, stretched relative to DZ links.
+func (s *Simulator) GetInternetLatencySamples(ctx context.Context, dataProviderName string, originLocationPK, targetLocationPK, agentPK solana.PublicKey, epoch uint64) (*telemetry.InternetLatencySamples, error) {
+	origin, okOrigin := s.metrosByPK[originLocationPK]
+	target, okTarget := s.metrosByPK[targetLocationPK]
+	if !okOrigin || !okTarget || origin == target || epoch < s.sc.StartEpoch {
+		return nil, telemetry.ErrAccountNotFound
+	}
+
+	interval := time.Duration(s.sc.InternetSampleInterval)
+	first, count := s.sampleWindow(epoch, s.start, time.Time{}, interval)
+	if count == 0 {
+		return nil, telemetry.ErrAccountNotFound
+	}
+
+	baseUs := (estimateRTTMs(origin.spec, target.spec, internetRouteFactor) + 1) * 1000
+	jitterUs := baseUs * 0.05
+	key := "internet/" + dataProviderName + "/" + origin.spec.Code + "/" + target.spec.Code
+
+	samples := make([]uint32, count)
+	for i := range samples {
+		n := uint64(first.Add(time.Duration(i) * interval).UnixMicro())
+		if s.noise(key+"/loss", n) < 0.001 {
+			continue
+		}
+		samples[i] = uint32(max(baseUs+jitterUs*(s.noise(key, n)+s.noise(key+"/2", n)-1), 1))
+	}
+
+	return &telemetry.InternetLatencySamples{
+		InternetLatencySamplesHeader: telemetry.InternetLatencySamplesHeader{
+			AccountType:                  telemetry.AccountTypeInternetLatencySamples,
+			Epoch:                        epoch,
+			DataProviderName:             dataProviderName,
+			OracleAgentPK:                agentPK,
+			OriginExchangePK:             originLocationPK,
+			TargetExchangePK:             targetLocationPK,
+			SamplingIntervalMicroseconds: uint64(interval.Microseconds()),
+			StartTimestampMicroseconds:   uint64(first.UnixMicro()),
+			NextSampleIndex:              uint32(count),
+		},
+		Samples: samples,
+	}, nil
+}
+
+// sampleWindow returns the time of the first sample written in epoch by a collector that runs
+// from created until removed (zero for never), and the number of samples written so far.
+func (s *Simulator) sampleWindow(epoch uint64, created, removed time.Time, interval time.Duration) (time.Time, int) {
+	epochStart := s.epochStart(epoch)
+	epochEnd := epochStart.Add(time.Duration(s.sc.EpochDuration))
+
+	first := epochStart
+	if created.After(first) {
+		// Align to the epoch's sampling grid
+		first = epochStart.Add((created.Sub(epochStart) + interval - 1) / interval * interval)
+	}
+	end := s.now().Add(time.Nanosecond) // the sample at exactly now has been written
+	if end.After(epochEnd) {
+		end = epochEnd
+	}
+	if !removed.IsZero() && removed.Before(end) {
+		end = removed
+	}
+	if !end.After(first) {
+		return first, 0
+	}
+	return first, int((end.Sub(first) + interval - 1) / interval)
+}
+
+// linkSample returns the RTT in microseconds measured across the link at t, or 0 for a lost
+// sample.
+func (s *Simulator) linkSample(l *simLink, reverse bool, t time.Time) uint32 {
+	if !s.linkUp(l, t) {
+		return 0
+	}
+
+	key := "link/" + l.spec.Code + "/az"
+	if reverse {
+		key = "link/" + l.spec.Code + "/za"
+	}
+	n := uint64(t.UnixMicro())
+
+	rtt := l.rttUs + l.jitterUs*(s.noise(key, n)+s.noise(key+"/2", n)-1)
+	delivered := 1.0
+	for _, e := range s.linkEvents[l.spec.Code] {
+		if !s.eventActive(e, t) {
+			continue
+		}
+		switch e.Type {
+		case EventPacketLoss:
+			delivered *= 1 - e.Loss
+		case EventLatencySpike:
+			rtt += e.LatencyMs * 1000
+		}
+	}
+	if s.noise(key+"/loss", n) >= delivered {
+		return 0
+	}
+	return uint32(max(rtt, 1))
+}
@@ -18,6 +18,8 @@ import (
 type StoreConfig struct {
 	Logger     *slog.Logger
 	ClickHouse clickhouse.Client
+	// ChangeSink, if set, receives the change events of each write (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *StoreConfig) Validate() error {
@@ -69,6 +71,7 @@ func (s *Store) ReplaceLeaderSchedule(ctx context.Context, entries []LeaderSched
 		return leaderScheduleSchema.ToRow(entries[i], currentEpoch), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write leader schedule to ClickHouse: %w", err)
@@ -96,6 +99,7 @@ func (s *Store) ReplaceVoteAccounts(ctx context.Context, accounts []solanarpc.Vo
 		return voteAccountSchema.ToRow(accounts[i], currentEpoch), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write vote accounts to ClickHouse: %w", err)
@@ -145,6 +149,7 @@ func (s *Store) ReplaceGossipNodes(ctx context.Context, nodes []*solanarpc.GetCl
 		return gossipNodeSchema.ToRow(validNodes[i], currentEpoch), nil
 	}, &dataset.DimensionType2DatasetWriteConfig{
 		MissingMeansDeleted: true,
+		ChangeSink:          s.cfg.ChangeSink,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to write gossip nodes to ClickHouse: %w", err)
@@ -12,6 +12,7 @@ import (
 	solanarpc "github.com/gagliardetto/solana-go/rpc"
 	"github.com/jonboulle/clockwork"
 	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
 	"github.com/malbeclabs/lake/indexer/pkg/metrics"
 )
 
@@ -30,6 +31,8 @@ type ViewConfig struct {
 	RPC             SolanaRPC
 	ClickHouse      clickhouse.Client
 	RefreshInterval time.Duration
+	// ChangeSink, if set, receives the change events of the view's writes (optional)
+	ChangeSink dataset.ChangeSink
 }
 
 func (cfg *ViewConfig) Validate() error {
@@ -74,6 +77,7 @@ func NewView(
 	store, err := NewStore(StoreConfig{
 		Logger:     cfg.Logger,
 		ClickHouse: cfg.ClickHouse,
+		ChangeSink: cfg.ChangeSink,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create store: %w", err)
@@ -0,0 +1,413 @@
+package validatormatch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+)
+
+// BackfillsTableName records the progress of the match history backfill. The validator views
+// match gossip IP = DZ IP until it has a completed row.
+const BackfillsTableName = "dim_solana_validator_dz_matches_backfills"
+
+// backfillWindow bounds the history read by one query of the backfill.
+const backfillWindow = 24 * time.Hour
+
+// backfill replays the user, gossip node and vote account history that predates the match
+// history through MatchNodes, writing a match snapshot at each point it changed, so validators
+// keep the connections and disconnections from before the matching stage. The cutoff is the
+// first match snapshot, or now if there is none; at the cutoff, validators the replay left
+// matched that the first snapshot didn't include are disconnected. Progress is recorded like
+// the change log backfill: a completed backfill is never repeated and an interrupted one
+// resumes after the last snapshot it wrote.
+func (v *View) backfill(ctx context.Context) error {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	cutoff, completed, err := backfillState(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if completed {
+		return nil
+	}
+	if cutoff.IsZero() {
+		var matched uint64
+		if err := queryBounds(ctx, conn, `
+			SELECT count(), min(snapshot_ts)
+			FROM dim_solana_validator_dz_matches_history
+		`, []any{&matched, &cutoff}); err != nil {
+			return fmt.Errorf("failed to query first match snapshot: %w", err)
+		}
+		if matched == 0 {
+			cutoff = v.cfg.Clock.Now().UTC().Truncate(time.Millisecond)
+		}
+		if err := recordBackfill(ctx, conn, cutoff, false, 0); err != nil {
+			return err
+		}
+	}
+
+	var resume time.Time
+	if err := queryBounds(ctx, conn, `
+		SELECT max(snapshot_ts)
+		FROM dim_solana_validator_dz_matches_history
+		WHERE snapshot_ts < ?
+	`, []any{&resume}, cutoff); err != nil {
+		return fmt.Errorf("failed to query last backfilled snapshot: %w", err)
+	}
+
+	var (
+		versions uint64
+		start    time.Time
+	)
+	if err := queryBounds(ctx, conn, `
+		SELECT count(), min(snapshot_ts)
+		FROM (
+			SELECT snapshot_ts FROM dim_dz_users_history
+			UNION ALL SELECT snapshot_ts FROM dim_solana_gossip_nodes_history
+			UNION ALL SELECT snapshot_ts FROM dim_solana_vote_accounts_history
+		)
+		WHERE snapshot_ts < ?
+	`, []any{&versions, &start}, cutoff); err != nil {
+		return fmt.Errorf("failed to query history bounds: %w", err)
+	}
+
+	state := newReplayState()
+	var (
+		prev      [][]any
+		snapshots int
+	)
+	for from := start; versions > 0 && from.Before(cutoff); from = from.Add(backfillWindow) {
+		to := from.Add(backfillWindow)
+		if to.After(cutoff) {
+			to = cutoff
+		}
+		rows, err := queryReplayRows(ctx, conn, from, to)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(rows); {
+			ts := rows[i].ts
+			for ; i < len(rows) && rows[i].ts.Equal(ts); i++ {
+				state.apply(rows[i])
+			}
+			if !ts.After(resume) {
+				continue
+			}
+			snapshot, ok := state.matchRows()
+			if !ok || prev != nil && slices.EqualFunc(prev, snapshot, slices.Equal) {
+				continue
+			}
+			if err := v.writeSnapshot(ctx, snapshot, ts, nil); err != nil {
+				return fmt.Errorf("failed to write snapshot at %s: %w", ts, err)
+			}
+			prev = snapshot
+			snapshots++
+		}
+	}
+
+	// Rewrite the first match snapshot so validators it didn't include are disconnected
+	first, err := queryMatchRows(ctx, conn, cutoff)
+	if err != nil {
+		return err
+	}
+	if len(first) > 0 {
+		if err := v.writeSnapshot(ctx, first, cutoff, nil); err != nil {
+			return fmt.Errorf("failed to close backfill at %s: %w", cutoff, err)
+		}
+	}
+
+	if err := recordBackfill(ctx, conn, cutoff, true, uint64(snapshots)); err != nil {
+		return err
+	}
+	v.log.Info("validatormatch: backfilled match history", "cutoff", cutoff, "snapshots", snapshots)
+	return nil
+}
+
+// backfillState returns the cutoff of the latest recorded backfill and whether it completed.
+// The cutoff is zero if no backfill was started.
+func backfillState(ctx context.Context, conn clickhouse.Connection) (time.Time, bool, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf(`
+		SELECT cutoff_ts, completed
+		FROM %s
+		ORDER BY recorded_at DESC
+		LIMIT 1
+	`, BackfillsTableName))
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to query backfill state: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return time.Time{}, false, rows.Err()
+	}
+	var (
+		cutoff    time.Time
+		completed uint8
+	)
+	if err := rows.Scan(&cutoff, &completed); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to scan backfill state: %w", err)
+	}
+	return cutoff.UTC(), completed == 1, nil
+}
+
+func recordBackfill(ctx context.Context, conn clickhouse.Connection, cutoff time.Time, completed bool, snapshots uint64) error {
+	var done uint8
+	if completed {
+		done = 1
+	}
+	if err := conn.Exec(clickhouse.ContextWithSyncInsert(ctx), fmt.Sprintf(`
+		INSERT INTO %s (cutoff_ts, completed, snapshots, recorded_at)
+		VALUES (?, ?, ?, ?)
+	`, BackfillsTableName), cutoff, done, snapshots, time.Now().UTC().Truncate(time.Millisecond)); err != nil {
+		return fmt.Errorf("failed to record backfill: %w", err)
+	}
+	return nil
+}
+
+// queryBounds scans the single row of an aggregate query into dest.
+func queryBounds(ctx context.Context, conn clickhouse.Connection, query string, dest []any, args ...any) error {
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// replayRow is a version of a user, gossip node or vote account.
+type replayRow struct {
+	ts      time.Time
+	deleted bool
+	user    *dzsvc.User
+	node    *Node
+	vote    *replayVote
+}
+
+type replayVote struct {
+	votePubkey       string
+	nodePubkey       string
+	epochVoteAccount bool
+}
+
+// queryReplayRows returns the user, gossip node and vote account versions in [from, to), in
+// the order they were written.
+func queryReplayRows(ctx context.Context, conn clickhouse.Connection, from, to time.Time) ([]replayRow, error) {
+	var out []replayRow
+
+	rows, err := conn.Query(ctx, `
+		SELECT snapshot_ts, is_deleted, pk, owner_pubkey, status, client_ip, dz_ip, device_pk
+		FROM dim_dz_users_history
+		WHERE snapshot_ts >= ? AND snapshot_ts < ?
+		ORDER BY snapshot_ts, ingested_at, op_id
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user history: %w", err)
+	}
+	for rows.Next() {
+		var (
+			r              replayRow
+			deleted        uint8
+			u              dzsvc.User
+			clientIP, dzIP string
+		)
+		if err := rows.Scan(&r.ts, &deleted, &u.PK, &u.OwnerPubkey, &u.Status, &clientIP, &dzIP, &u.DevicePK); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan user history: %w", err)
+		}
+		if clientIP != "" {
+			u.ClientIP = net.ParseIP(clientIP)
+		}
+		if dzIP != "" {
+			u.DZIP = net.ParseIP(dzIP)
+		}
+		r.deleted, r.user = deleted == 1, &u
+		out = append(out, r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = conn.Query(ctx, `
+		SELECT snapshot_ts, is_deleted, pubkey, gossip_ip, gossip_port, tpuquic_ip, tpuquic_port
+		FROM dim_solana_gossip_nodes_history
+		WHERE snapshot_ts >= ? AND snapshot_ts < ?
+		ORDER BY snapshot_ts, ingested_at, op_id
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gossip node history: %w", err)
+	}
+	for rows.Next() {
+		var (
+			r       replayRow
+			deleted uint8
+			n       Node
+		)
+		if err := rows.Scan(&r.ts, &deleted, &n.Pubkey, &n.GossipIP, &n.GossipPort, &n.TPUQUICIP, &n.TPUQUICPort); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan gossip node history: %w", err)
+		}
+		r.deleted, r.node = deleted == 1, &n
+		out = append(out, r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = conn.Query(ctx, `
+		SELECT snapshot_ts, is_deleted, vote_pubkey, node_pubkey, epoch_vote_account
+		FROM dim_solana_vote_accounts_history
+		WHERE snapshot_ts >= ? AND snapshot_ts < ?
+		ORDER BY snapshot_ts, ingested_at, op_id
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vote account history: %w", err)
+	}
+	for rows.Next() {
+		var (
+			r                replayRow
+			deleted          uint8
+			vote             replayVote
+			epochVoteAccount string
+		)
+		if err := rows.Scan(&r.ts, &deleted, &vote.votePubkey, &vote.nodePubkey, &epochVoteAccount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan vote account history: %w", err)
+		}
+		vote.epochVoteAccount = epochVoteAccount == "true"
+		r.deleted, r.vote = deleted == 1, &vote
+		out = append(out, r)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Stable, so versions of one table keep their order within a snapshot
+	slices.SortStableFunc(out, func(a, b replayRow) int { return a.ts.Compare(b.ts) })
+	return out, nil
+}
+
+// queryMatchRows returns the match snapshot written at ts, as rows for writeSnapshot.
+func queryMatchRows(ctx context.Context, conn clickhouse.Connection, ts time.Time) ([][]any, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT node_pubkey, vote_pubkey, user_pk, owner_pubkey, device_pk, dz_ip, client_ip,
+			gossip_ip, gossip_port, tpuquic_ip, tpuquic_port, match_method, confidence, candidates
+		FROM dim_solana_validator_dz_matches_history
+		WHERE snapshot_ts = ? AND is_deleted = 0
+		ORDER BY node_pubkey
+	`, ts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query match snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	var out [][]any
+	for rows.Next() {
+		var (
+			nodePubkey, votePubkey, userPK, ownerPubkey, devicePK string
+			dzIP, clientIP, gossipIP, tpuQUICIP, method           string
+			gossipPort, tpuQUICPort, candidates                   int32
+			confidence                                            float64
+		)
+		if err := rows.Scan(&nodePubkey, &votePubkey, &userPK, &ownerPubkey, &devicePK, &dzIP, &clientIP,
+			&gossipIP, &gossipPort, &tpuQUICIP, &tpuQUICPort, &method, &confidence, &candidates); err != nil {
+			return nil, fmt.Errorf("failed to scan match snapshot: %w", err)
+		}
+		out = append(out, []any{nodePubkey, votePubkey, userPK, ownerPubkey, devicePK, dzIP, clientIP,
+			gossipIP, gossipPort, tpuQUICIP, tpuQUICPort, method, confidence, candidates})
+	}
+	return out, rows.Err()
+}
+
+// replayState is the users, gossip nodes and vote accounts as of the versions applied so far.
+type replayState struct {
+	users map[string]dzsvc.User
+	nodes map[string]Node
+	votes map[string]replayVote
+}
+
+func newReplayState() *replayState {
+	return &replayState{
+		users: make(map[string]dzsvc.User),
+		nodes: make(map[string]Node),
+		votes: make(map[string]replayVote),
+	}
+}
+
+func (s *replayState) apply(r replayRow) {
+	switch {
+	case r.user != nil && r.deleted:
+		delete(s.users, r.user.PK)
+	case r.user != nil:
+		s.users[r.user.PK] = *r.user
+	case r.node != nil && r.deleted:
+		delete(s.nodes, r.node.Pubkey)
+	case r.node != nil:
+		s.nodes[r.node.Pubkey] = *r.node
+	case r.vote != nil && r.deleted:
+		delete(s.votes, r.vote.votePubkey)
+	case r.vote != nil:
+		s.votes[r.vote.votePubkey] = *r.vote
+	}
+}
+
+// matchRows matches the gossip nodes with an epoch vote account to the users, like Refresh
+// does with the current state, and returns the matches as rows ordered by node. Like Refresh,
+// it reports false while there are no users or nodes to match.
+func (s *replayState) matchRows() ([][]any, bool) {
+	if len(s.users) == 0 {
+		return nil, false
+	}
+	votePubkeys := make(map[string]string)
+	for _, vote := range s.votes {
+		if !vote.epochVoteAccount {
+			continue
+		}
+		if pk, ok := votePubkeys[vote.nodePubkey]; !ok || vote.votePubkey < pk {
+			votePubkeys[vote.nodePubkey] = vote.votePubkey
+		}
+	}
+	var nodes []Node
+	for pubkey, n := range s.nodes {
+		if votePubkey, ok := votePubkeys[pubkey]; ok {
+			n.VotePubkey = votePubkey
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, false
+	}
+	slices.SortFunc(nodes, func(a, b Node) int { return strings.Compare(a.Pubkey, b.Pubkey) })
+
+	users := make([]dzsvc.User, 0, len(s.users))
+	for _, u := range s.users {
+		users = append(users, u)
+	}
+
+	matches := MatchNodes(users, nodes)
+	out := make([][]any, len(matches))
+	for i, m := range matches {
+		out[i] = validatorDZMatchSchema.ToRow(m)
+	}
+	return out, true
+}
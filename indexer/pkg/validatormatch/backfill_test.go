@@ -0,0 +1,94 @@
+package validatormatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_ValidatorMatch_View_BackfillsHistory(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	t0, t1, t2 := now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour)
+
+	insertUser := func(ts time.Time, deleted uint8, pk, dzIP string) {
+		err := conn.Exec(ctx, `
+			INSERT INTO dim_dz_users_history
+				(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+				 pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id)
+			VALUES (?, ?, ?, generateUUIDv4(), ?, 1, ?, 'owner', 'activated', 'ibrl', '1.1.1.1', ?, 'dev1', 501)
+		`, pk, ts, ts, deleted, pk, dzIP)
+		require.NoError(t, err)
+	}
+	// user1 connects, disconnects and reconnects before the matching stage ran; user2 stays
+	insertUser(t0, 0, "user1", "10.0.0.1")
+	insertUser(t0, 0, "user2", "10.0.0.2")
+	insertUser(t1, 1, "user1", "10.0.0.1")
+	insertUser(t2, 0, "user1", "10.0.0.1")
+
+	require.NoError(t, conn.Exec(ctx, `
+		INSERT INTO dim_solana_gossip_nodes_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 pubkey, epoch, gossip_ip, gossip_port, tpuquic_ip, tpuquic_port, version)
+		VALUES ('node1', ?, ?, generateUUIDv4(), 0, 1, 'node1', 100, '10.0.0.1', 8001, '10.0.0.1', 8009, '2.0.0')
+	`, t0, t0))
+	require.NoError(t, conn.Exec(ctx, `
+		INSERT INTO dim_solana_vote_accounts_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 vote_pubkey, epoch, node_pubkey, activated_stake_lamports, epoch_vote_account, commission_percentage)
+		VALUES ('vote1', ?, ?, generateUUIDv4(), 0, 1, 'vote1', 100, 'node1', 1000000000, 'true', 5)
+	`, t0, t0))
+
+	view, err := NewView(ViewConfig{
+		Logger:          laketesting.NewLogger(),
+		Clock:           clockwork.NewFakeClockAt(now),
+		ClickHouse:      db,
+		RefreshInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, view.Refresh(ctx))
+
+	cutoff, completed, err := backfillState(ctx, conn)
+	require.NoError(t, err)
+	require.True(t, completed)
+	require.True(t, cutoff.Equal(now))
+
+	rows, err := conn.Query(ctx, `
+		SELECT connected_ts, disconnected_ts
+		FROM solana_validator_dz_match_periods
+		WHERE node_pubkey = 'node1'
+		ORDER BY connected_ts
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type period struct {
+		connected    time.Time
+		disconnected *time.Time
+	}
+	var periods []period
+	for rows.Next() {
+		var p period
+		require.NoError(t, rows.Scan(&p.connected, &p.disconnected))
+		periods = append(periods, p)
+	}
+	require.NoError(t, rows.Err())
+
+	// The replayed disconnection is kept, and the live refresh continues the second period
+	require.Len(t, periods, 2)
+	require.True(t, periods[0].connected.Equal(t0))
+	require.NotNil(t, periods[0].disconnected)
+	require.True(t, periods[0].disconnected.Equal(t1))
+	require.True(t, periods[1].connected.Equal(t2))
+	require.Nil(t, periods[1].disconnected)
+}
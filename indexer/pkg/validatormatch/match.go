@@ -0,0 +1,151 @@
+package validatormatch
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+)
+
+// Method is how a validator's gossip node was matched to a DZ user.
+type Method string
+
+const (
+	// MethodDZIP matches the node's gossip IP to the user's DZ IP.
+	MethodDZIP Method = "dz_ip"
+	// MethodTPUDZIP matches the node's TPU QUIC IP to the user's DZ IP, for validators that
+	// advertise gossip on another address.
+	MethodTPUDZIP Method = "tpu_dz_ip"
+	// MethodClientIPPortRange matches the user's client IP, with both gossip and TPU QUIC
+	// advertised there on ports from one validator's dynamic port range, as when the DZ IP is
+	// behind NAT.
+	MethodClientIPPortRange Method = "client_ip_port_range"
+	// MethodClientIP matches the node's gossip or TPU QUIC IP to the user's client IP.
+	MethodClientIP Method = "client_ip"
+)
+
+// baseConfidence is the confidence of an unambiguous match by each method.
+var baseConfidence = map[Method]float64{
+	MethodDZIP:              1.0,
+	MethodTPUDZIP:           0.9,
+	MethodClientIPPortRange: 0.7,
+	MethodClientIP:          0.5,
+}
+
+// maxPortRangeSpan is the widest gap between a node's gossip and TPU QUIC ports for them to
+// count as one validator's dynamic port range.
+const maxPortRangeSpan = 64
+
+// Node is a validator's gossip node.
+type Node struct {
+	Pubkey      string
+	VotePubkey  string
+	GossipIP    string
+	GossipPort  int32
+	TPUQUICIP   string
+	TPUQUICPort int32
+}
+
+// portRange reports whether the node advertises gossip and TPU QUIC on ip with ports close
+// enough to be one validator's dynamic port range.
+func (n Node) portRange(ip string) bool {
+	if n.GossipIP != ip || n.TPUQUICIP != ip || n.GossipPort == 0 || n.TPUQUICPort == 0 {
+		return false
+	}
+	span := n.GossipPort - n.TPUQUICPort
+	return max(span, -span) <= maxPortRangeSpan
+}
+
+// Match is a validator matched to the DZ user it connects through.
+type Match struct {
+	Node   Node
+	User   dzsvc.User
+	Method Method
+	// Confidence is the method's base confidence, divided among the users the node could
+	// match and among the nodes matched to the same user by the same method.
+	Confidence float64
+	// Candidates is the number of users the node could have matched by Method.
+	Candidates int
+}
+
+// MatchNodes pairs each node with at most one activated user, trying each method in order of
+// confidence and keeping the first that finds a user. Among equally good users, the one with
+// the lowest PK is chosen so matches are stable across refreshes.
+func MatchNodes(users []dzsvc.User, nodes []Node) []Match {
+	byDZIP := make(map[string][]dzsvc.User)
+	byClientIP := make(map[string][]dzsvc.User)
+	for _, u := range users {
+		if u.Status != "activated" {
+			continue
+		}
+		if u.DZIP != nil && !u.DZIP.IsUnspecified() {
+			byDZIP[u.DZIP.String()] = append(byDZIP[u.DZIP.String()], u)
+		}
+		if u.ClientIP != nil && !u.ClientIP.IsUnspecified() {
+			byClientIP[u.ClientIP.String()] = append(byClientIP[u.ClientIP.String()], u)
+		}
+	}
+
+	var matches []Match
+	for _, n := range nodes {
+		method, candidates := candidateUsers(n, byDZIP, byClientIP)
+		if len(candidates) == 0 {
+			continue
+		}
+		slices.SortFunc(candidates, func(a, b dzsvc.User) int { return strings.Compare(a.PK, b.PK) })
+		matches = append(matches, Match{Node: n, User: candidates[0], Method: method, Candidates: len(candidates)})
+	}
+
+	// Nodes sharing a user by the same method split its confidence, e.g. several validators
+	// behind one NAT address
+	type share struct {
+		userPK string
+		method Method
+	}
+	shared := make(map[share]int)
+	for _, m := range matches {
+		shared[share{m.User.PK, m.Method}]++
+	}
+	for i := range matches {
+		m := &matches[i]
+		c := baseConfidence[m.Method] / float64(m.Candidates) / float64(shared[share{m.User.PK, m.Method}])
+		m.Confidence = math.Round(c*1000) / 1000
+	}
+	return matches
+}
+
+// candidateUsers returns the users the node matches by its most confident method.
+func candidateUsers(n Node, byDZIP, byClientIP map[string][]dzsvc.User) (Method, []dzsvc.User) {
+	if n.GossipIP != "" {
+		if users := byDZIP[n.GossipIP]; len(users) > 0 {
+			return MethodDZIP, slices.Clone(users)
+		}
+	}
+	if n.TPUQUICIP != "" {
+		if users := byDZIP[n.TPUQUICIP]; len(users) > 0 {
+			return MethodTPUDZIP, slices.Clone(users)
+		}
+	}
+
+	var users []dzsvc.User
+	seen := make(map[string]bool)
+	for _, ip := range []string{n.GossipIP, n.TPUQUICIP} {
+		if ip == "" {
+			continue
+		}
+		for _, u := range byClientIP[ip] {
+			if !seen[u.PK] {
+				seen[u.PK] = true
+				users = append(users, u)
+			}
+		}
+	}
+	if len(users) == 0 {
+		return "", nil
+	}
+	if n.portRange(n.GossipIP) && len(byClientIP[n.GossipIP]) > 0 {
+		return MethodClientIPPortRange, users
+	}
+	return MethodClientIP, users
+}
@@ -0,0 +1,122 @@
+package validatormatch
+
+import (
+	"net"
+	"testing"
+
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	"github.com/stretchr/testify/require"
+)
+
+func testUser(pk, status, clientIP, dzIP string) dzsvc.User {
+	return dzsvc.User{
+		PK:       pk,
+		Status:   status,
+		ClientIP: net.ParseIP(clientIP),
+		DZIP:     net.ParseIP(dzIP),
+		DevicePK: "dev1",
+	}
+}
+
+func TestLake_ValidatorMatch_MatchNodes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matches gossip IP to DZ IP", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{testUser("user1", "activated", "1.1.1.1", "10.0.0.1")}
+		nodes := []Node{{Pubkey: "node1", VotePubkey: "vote1", GossipIP: "10.0.0.1", GossipPort: 8001}}
+
+		matches := MatchNodes(users, nodes)
+		require.Len(t, matches, 1)
+		require.Equal(t, "user1", matches[0].User.PK)
+		require.Equal(t, MethodDZIP, matches[0].Method)
+		require.Equal(t, 1.0, matches[0].Confidence)
+		require.Equal(t, 1, matches[0].Candidates)
+	})
+
+	t.Run("matches TPU QUIC IP to DZ IP when gossip is elsewhere", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{testUser("user1", "activated", "1.1.1.1", "10.0.0.1")}
+		nodes := []Node{{Pubkey: "node1", GossipIP: "2.2.2.2", GossipPort: 8001, TPUQUICIP: "10.0.0.1", TPUQUICPort: 8009}}
+
+		matches := MatchNodes(users, nodes)
+		require.Len(t, matches, 1)
+		require.Equal(t, MethodTPUDZIP, matches[0].Method)
+		require.Equal(t, 0.9, matches[0].Confidence)
+	})
+
+	t.Run("matches client IP with a port range behind NAT", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{testUser("user1", "activated", "1.1.1.1", "10.0.0.1")}
+		nodes := []Node{{Pubkey: "node1", GossipIP: "1.1.1.1", GossipPort: 8001, TPUQUICIP: "1.1.1.1", TPUQUICPort: 8009}}
+
+		matches := MatchNodes(users, nodes)
+		require.Len(t, matches, 1)
+		require.Equal(t, MethodClientIPPortRange, matches[0].Method)
+		require.Equal(t, 0.7, matches[0].Confidence)
+	})
+
+	t.Run("matches client IP without a port range", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{testUser("user1", "activated", "1.1.1.1", "10.0.0.1")}
+		nodes := []Node{
+			{Pubkey: "node1", GossipIP: "1.1.1.1", GossipPort: 8001, TPUQUICIP: "1.1.1.1", TPUQUICPort: 9500},
+			{Pubkey: "node2", GossipIP: "3.3.3.3", GossipPort: 8001, TPUQUICIP: "1.1.1.1", TPUQUICPort: 8009},
+		}
+
+		matches := MatchNodes(users, nodes)
+		require.Len(t, matches, 2)
+		for _, m := range matches {
+			require.Equal(t, MethodClientIP, m.Method)
+			// Both nodes share the user by the same method
+			require.Equal(t, 0.25, m.Confidence)
+		}
+	})
+
+	t.Run("prefers DZ IP over client IP", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{
+			testUser("user1", "activated", "10.0.0.1", "10.0.0.9"),
+			testUser("user2", "activated", "1.1.1.1", "10.0.0.1"),
+		}
+		nodes := []Node{{Pubkey: "node1", GossipIP: "10.0.0.1", GossipPort: 8001}}
+
+		matches := MatchNodes(users, nodes)
+		require.Len(t, matches, 1)
+		require.Equal(t, "user2", matches[0].User.PK)
+		require.Equal(t, MethodDZIP, matches[0].Method)
+	})
+
+	t.Run("splits confidence among candidate users and picks the lowest PK", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{
+			testUser("user3", "activated", "1.1.1.1", "10.0.0.1"),
+			testUser("user2", "activated", "1.1.1.1", "10.0.0.2"),
+		}
+		nodes := []Node{{Pubkey: "node1", GossipIP: "1.1.1.1", GossipPort: 8001}}
+
+		matches := MatchNodes(users, nodes)
+		require.Len(t, matches, 1)
+		require.Equal(t, "user2", matches[0].User.PK)
+		require.Equal(t, 2, matches[0].Candidates)
+		require.Equal(t, 0.25, matches[0].Confidence)
+	})
+
+	t.Run("ignores users that are not activated and unmatched nodes", func(t *testing.T) {
+		t.Parallel()
+
+		users := []dzsvc.User{testUser("user1", "pending", "1.1.1.1", "10.0.0.1")}
+		nodes := []Node{
+			{Pubkey: "node1", GossipIP: "10.0.0.1", GossipPort: 8001},
+			{Pubkey: "node2", GossipIP: "4.4.4.4", GossipPort: 8001},
+		}
+
+		require.Empty(t, MatchNodes(users, nodes))
+	})
+}
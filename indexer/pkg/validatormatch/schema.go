@@ -0,0 +1,74 @@
+package validatormatch
+
+import (
+	"log/slog"
+
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+)
+
+// ValidatorDZMatchSchema defines the schema for validator to DZ user matches, keyed by the
+// validator's identity (node) pubkey. A validator with no match is deleted, so the history
+// records each connect and disconnect.
+type ValidatorDZMatchSchema struct{}
+
+func (s *ValidatorDZMatchSchema) Name() string {
+	return "solana_validator_dz_matches"
+}
+
+func (s *ValidatorDZMatchSchema) PrimaryKeyColumns() []string {
+	return []string{"node_pubkey:VARCHAR"}
+}
+
+func (s *ValidatorDZMatchSchema) PayloadColumns() []string {
+	return []string{
+		"vote_pubkey:VARCHAR",
+		"user_pk:VARCHAR",
+		"owner_pubkey:VARCHAR",
+		"device_pk:VARCHAR",
+		"dz_ip:VARCHAR",
+		"client_ip:VARCHAR",
+		"gossip_ip:VARCHAR",
+		"gossip_port:INTEGER",
+		"tpuquic_ip:VARCHAR",
+		"tpuquic_port:INTEGER",
+		"match_method:VARCHAR",
+		"confidence:DOUBLE",
+		"candidates:INTEGER",
+	}
+}
+
+func (s *ValidatorDZMatchSchema) ToRow(m Match) []any {
+	var dzIP, clientIP string
+	if m.User.DZIP != nil {
+		dzIP = m.User.DZIP.String()
+	}
+	if m.User.ClientIP != nil {
+		clientIP = m.User.ClientIP.String()
+	}
+	return []any{
+		m.Node.Pubkey,
+		m.Node.VotePubkey,
+		m.User.PK,
+		m.User.OwnerPubkey,
+		m.User.DevicePK,
+		dzIP,
+		clientIP,
+		m.Node.GossipIP,
+		m.Node.GossipPort,
+		m.Node.TPUQUICIP,
+		m.Node.TPUQUICPort,
+		string(m.Method),
+		m.Confidence,
+		int32(m.Candidates),
+	}
+}
+
+func (s *ValidatorDZMatchSchema) GetPrimaryKey(m Match) string {
+	return m.Node.Pubkey
+}
+
+var validatorDZMatchSchema = &ValidatorDZMatchSchema{}
+
+func NewValidatorDZMatchDataset(log *slog.Logger) (*dataset.DimensionType2Dataset, error) {
+	return dataset.NewDimensionType2Dataset(log, validatorDZMatchSchema)
+}
@@ -0,0 +1,223 @@
+package validatormatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse"
+	"github.com/malbeclabs/lake/indexer/pkg/clickhouse/dataset"
+	dzsvc "github.com/malbeclabs/lake/indexer/pkg/dz/serviceability"
+	"github.com/malbeclabs/lake/indexer/pkg/metrics"
+)
+
+type ViewConfig struct {
+	Logger          *slog.Logger
+	Clock           clockwork.Clock
+	ClickHouse      clickhouse.Client
+	RefreshInterval time.Duration
+	// ChangeSink, if set, receives the change events of each write (optional)
+	ChangeSink dataset.ChangeSink
+}
+
+func (cfg *ViewConfig) Validate() error {
+	if cfg.Logger == nil {
+		return errors.New("logger is required")
+	}
+	if cfg.ClickHouse == nil {
+		return errors.New("clickhouse connection is required")
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("refresh interval must be greater than 0")
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clockwork.NewRealClock()
+	}
+	return nil
+}
+
+// View periodically matches validators' gossip nodes to the DZ users they connect through and
+// writes the matches to the solana_validator_dz_matches dimension.
+type View struct {
+	log       *slog.Logger
+	cfg       ViewConfig
+	refreshMu sync.Mutex // prevents concurrent refreshes
+	// backfilled is set once the match history from before the matching stage is backfilled
+	backfilled bool
+}
+
+func NewView(cfg ViewConfig) (*View, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &View{
+		log: cfg.Logger,
+		cfg: cfg,
+	}, nil
+}
+
+func (v *View) Start(ctx context.Context) {
+	go func() {
+		v.log.Info("validatormatch: starting refresh loop", "interval", v.cfg.RefreshInterval)
+
+		v.safeRefresh(ctx)
+
+		ticker := v.cfg.Clock.NewTicker(v.cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.Chan():
+				v.safeRefresh(ctx)
+			}
+		}
+	}()
+}
+
+// safeRefresh wraps Refresh with panic recovery to prevent the refresh loop from dying
+func (v *View) safeRefresh(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.log.Error("validatormatch: refresh panicked", "panic", r)
+			metrics.ViewRefreshTotal.WithLabelValues("validatormatch", "panic").Inc()
+		}
+	}()
+
+	if err := v.Refresh(ctx); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		v.log.Error("validatormatch: refresh failed", "error", err)
+	}
+}
+
+// Refresh matches the current validators to the current users and writes the result as a
+// snapshot, so validators that no longer match are recorded as disconnected. Nothing is written
+// until both users and validators have been ingested, so an empty source doesn't disconnect
+// every validator.
+func (v *View) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	refreshStart := time.Now()
+	defer func() {
+		duration := time.Since(refreshStart)
+		v.log.Info("validatormatch: refresh completed", "duration", duration.String())
+		metrics.ViewRefreshDuration.WithLabelValues("validatormatch").Observe(duration.Seconds())
+	}()
+
+	if !v.backfilled {
+		if err := v.backfill(ctx); err != nil {
+			metrics.ViewRefreshTotal.WithLabelValues("validatormatch", "error").Inc()
+			return fmt.Errorf("failed to backfill validator matches: %w", err)
+		}
+		v.backfilled = true
+	}
+
+	users, err := dzsvc.QueryCurrentUsers(ctx, v.log, v.cfg.ClickHouse)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("validatormatch", "error").Inc()
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	nodes, err := v.queryValidatorNodes(ctx)
+	if err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("validatormatch", "error").Inc()
+		return fmt.Errorf("failed to get validator nodes: %w", err)
+	}
+	if len(users) == 0 || len(nodes) == 0 {
+		v.log.Debug("validatormatch: waiting for users and validators", "users", len(users), "nodes", len(nodes))
+		return nil
+	}
+
+	matches := MatchNodes(users, nodes)
+	if err := v.writeMatches(ctx, matches); err != nil {
+		metrics.ViewRefreshTotal.WithLabelValues("validatormatch", "error").Inc()
+		return fmt.Errorf("failed to write validator matches: %w", err)
+	}
+
+	v.log.Debug("validatormatch: matched validators", "users", len(users), "nodes", len(nodes), "matches", len(matches))
+	metrics.ViewRefreshTotal.WithLabelValues("validatormatch", "success").Inc()
+	return nil
+}
+
+// queryValidatorNodes returns the current gossip nodes that have an epoch vote account.
+// Uses the history tables with the deterministic "latest row per entity" definition.
+func (v *View) queryValidatorNodes(ctx context.Context) ([]Node, error) {
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	query := `
+		WITH gossip AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY entity_id ORDER BY snapshot_ts DESC, ingested_at DESC, op_id DESC) AS rn
+			FROM dim_solana_gossip_nodes_history
+		),
+		votes AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY entity_id ORDER BY snapshot_ts DESC, ingested_at DESC, op_id DESC) AS rn
+			FROM dim_solana_vote_accounts_history
+		)
+		SELECT
+			g.pubkey,
+			min(va.vote_pubkey) AS vote_pubkey,
+			any(g.gossip_ip),
+			any(g.gossip_port),
+			any(g.tpuquic_ip),
+			any(g.tpuquic_port)
+		FROM gossip g
+		JOIN votes va ON g.pubkey = va.node_pubkey
+		WHERE g.rn = 1 AND g.is_deleted = 0
+			AND va.rn = 1 AND va.is_deleted = 0 AND va.epoch_vote_account = 'true'
+		GROUP BY g.pubkey
+		ORDER BY g.pubkey
+	`
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []Node
+	for rows.Next() {
+		var n Node
+		if err := rows.Scan(&n.Pubkey, &n.VotePubkey, &n.GossipIP, &n.GossipPort, &n.TPUQUICIP, &n.TPUQUICPort); err != nil {
+			return nil, fmt.Errorf("failed to scan validator node: %w", err)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+func (v *View) writeMatches(ctx context.Context, matches []Match) error {
+	rows := make([][]any, len(matches))
+	for i, m := range matches {
+		rows[i] = validatorDZMatchSchema.ToRow(m)
+	}
+	return v.writeSnapshot(ctx, rows, v.cfg.Clock.Now(), v.cfg.ChangeSink)
+}
+
+// writeSnapshot writes rows as the complete set of matches at snapshotTS.
+func (v *View) writeSnapshot(ctx context.Context, rows [][]any, snapshotTS time.Time, sink dataset.ChangeSink) error {
+	d, err := NewValidatorDZMatchDataset(v.log)
+	if err != nil {
+		return fmt.Errorf("failed to create dimension dataset: %w", err)
+	}
+
+	conn, err := v.cfg.ClickHouse.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ClickHouse connection: %w", err)
+	}
+
+	return d.WriteBatch(ctx, conn, len(rows), func(i int) ([]any, error) {
+		return rows[i], nil
+	}, &dataset.DimensionType2DatasetWriteConfig{
+		SnapshotTS:          snapshotTS,
+		MissingMeansDeleted: true,
+		ChangeSink:          sink,
+	})
+}
@@ -0,0 +1,154 @@
+package validatormatch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	laketesting "github.com/malbeclabs/lake/utils/pkg/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLake_ValidatorMatch_View_Refresh(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	insertUsers := func(ts time.Time, deleted uint8, pk, clientIP, dzIP string) {
+		err := conn.Exec(ctx, `
+			INSERT INTO dim_dz_users_history
+				(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+				 pk, owner_pubkey, status, kind, client_ip, dz_ip, device_pk, tunnel_id)
+			VALUES (?, ?, ?, generateUUIDv4(), ?, 1, ?, 'owner', 'activated', 'ibrl', ?, ?, 'dev1', 501)
+		`, pk, ts, ts, deleted, pk, clientIP, dzIP)
+		require.NoError(t, err)
+	}
+	insertUsers(now.Add(-time.Hour), 0, "user1", "1.1.1.1", "10.0.0.1")
+	insertUsers(now.Add(-time.Hour), 0, "user2", "2.2.2.2", "10.0.0.2")
+
+	err = conn.Exec(ctx, `
+		INSERT INTO dim_solana_gossip_nodes_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 pubkey, epoch, gossip_ip, gossip_port, tpuquic_ip, tpuquic_port, version)
+		VALUES
+			('node1', ?, ?, generateUUIDv4(), 0, 1, 'node1', 100, '10.0.0.1', 8001, '10.0.0.1', 8009, '2.0.0'),
+			('node2', ?, ?, generateUUIDv4(), 0, 2, 'node2', 100, '2.2.2.2', 8001, '2.2.2.2', 8009, '2.0.0'),
+			('node3', ?, ?, generateUUIDv4(), 0, 3, 'node3', 100, '9.9.9.9', 8001, '9.9.9.9', 8009, '2.0.0')
+	`, now, now, now, now, now, now)
+	require.NoError(t, err)
+	err = conn.Exec(ctx, `
+		INSERT INTO dim_solana_vote_accounts_history
+			(entity_id, snapshot_ts, ingested_at, op_id, is_deleted, attrs_hash,
+			 vote_pubkey, epoch, node_pubkey, activated_stake_lamports, epoch_vote_account, commission_percentage)
+		VALUES
+			('vote1', ?, ?, generateUUIDv4(), 0, 1, 'vote1', 100, 'node1', 1000000000, 'true', 5),
+			('vote2', ?, ?, generateUUIDv4(), 0, 2, 'vote2', 100, 'node2', 1000000000, 'true', 5),
+			('vote3', ?, ?, generateUUIDv4(), 0, 3, 'vote3', 100, 'node3', 1000000000, 'true', 5)
+	`, now, now, now, now, now, now)
+	require.NoError(t, err)
+
+	clock := clockwork.NewFakeClockAt(now)
+	view, err := NewView(ViewConfig{
+		Logger:          laketesting.NewLogger(),
+		Clock:           clock,
+		ClickHouse:      db,
+		RefreshInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, view.Refresh(ctx))
+
+	type match struct {
+		node, vote, user, method string
+		confidence               float64
+	}
+	queryMatches := func() []match {
+		rows, err := conn.Query(ctx, `
+			SELECT node_pubkey, vote_pubkey, user_pk, match_method, confidence
+			FROM solana_validator_dz_matches_current
+			ORDER BY node_pubkey
+		`)
+		require.NoError(t, err)
+		defer rows.Close()
+		var matches []match
+		for rows.Next() {
+			var m match
+			require.NoError(t, rows.Scan(&m.node, &m.vote, &m.user, &m.method, &m.confidence))
+			matches = append(matches, m)
+		}
+		require.NoError(t, rows.Err())
+		return matches
+	}
+
+	require.Equal(t, []match{
+		{"node1", "vote1", "user1", "dz_ip", 1.0},
+		{"node2", "vote2", "user2", "client_ip_port_range", 0.7},
+	}, queryMatches())
+
+	// user1 disconnects, so node1 no longer matches
+	clock.Advance(time.Hour)
+	insertUsers(clock.Now(), 1, "user1", "1.1.1.1", "10.0.0.1")
+	require.NoError(t, view.Refresh(ctx))
+
+	require.Equal(t, []match{
+		{"node2", "vote2", "user2", "client_ip_port_range", 0.7},
+	}, queryMatches())
+
+	rows, err := conn.Query(ctx, `
+		SELECT node_pubkey, user_pk, connected_ts, disconnected_ts
+		FROM solana_validator_dz_match_periods
+		ORDER BY node_pubkey
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type period struct {
+		node, user   string
+		connected    time.Time
+		disconnected *time.Time
+	}
+	var periods []period
+	for rows.Next() {
+		var p period
+		require.NoError(t, rows.Scan(&p.node, &p.user, &p.connected, &p.disconnected))
+		periods = append(periods, p)
+	}
+	require.NoError(t, rows.Err())
+	require.Len(t, periods, 2)
+	require.Equal(t, "node1", periods[0].node)
+	require.True(t, periods[0].connected.Equal(now))
+	require.NotNil(t, periods[0].disconnected)
+	require.True(t, periods[0].disconnected.Equal(now.Add(time.Hour)))
+	require.Equal(t, "node2", periods[1].node)
+	require.Nil(t, periods[1].disconnected)
+}
+
+func TestLake_ValidatorMatch_View_SkipsWithoutUsers(t *testing.T) {
+	t.Parallel()
+
+	db := testClient(t)
+	ctx := context.Background()
+
+	view, err := NewView(ViewConfig{
+		Logger:          laketesting.NewLogger(),
+		ClickHouse:      db,
+		RefreshInterval: time.Minute,
+	})
+	require.NoError(t, err)
+	require.NoError(t, view.Refresh(ctx))
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	rows, err := conn.Query(ctx, `SELECT count() FROM dim_solana_validator_dz_matches_history`)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.True(t, rows.Next())
+	var count uint64
+	require.NoError(t, rows.Scan(&count))
+	require.Zero(t, count)
+}
@@ -0,0 +1,581 @@
+package bot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	chartWidth  = 1000
+	chartHeight = 560
+
+	chartMarginLeft   = 90
+	chartMarginRight  = 30
+	chartMarginTop    = 50
+	chartMarginBottom = 80
+
+	chartMaxSeries = 6
+	chartMaxBars   = 40
+)
+
+// Chart types supported by the renderer. Pie and area charts from the
+// visualization recommender are rendered as bar and line charts respectively.
+const (
+	ChartTypeLine    = "line"
+	ChartTypeArea    = "area"
+	ChartTypeBar     = "bar"
+	ChartTypePie     = "pie"
+	ChartTypeScatter = "scatter"
+)
+
+var (
+	chartBackground = color.RGBA{255, 255, 255, 255}
+	chartAxisColor  = color.RGBA{80, 80, 80, 255}
+	chartGridColor  = color.RGBA{230, 230, 230, 255}
+	chartTextColor  = color.RGBA{40, 40, 40, 255}
+
+	// chartPalette matches the series colors used by the web UI charts.
+	chartPalette = []color.RGBA{
+		{37, 99, 235, 255},
+		{220, 38, 38, 255},
+		{22, 163, 74, 255},
+		{217, 119, 6, 255},
+		{147, 51, 234, 255},
+		{8, 145, 178, 255},
+	}
+)
+
+// ChartSpec describes a chart to render over query result rows.
+type ChartSpec struct {
+	Type  string
+	XAxis string
+	YAxis []string
+	Title string
+}
+
+// chartSeries is a single Y column with its values aligned to the X points.
+type chartSeries struct {
+	name   string
+	values []float64 // NaN for missing values
+}
+
+// chartData is the normalized form of the result rows used for drawing.
+type chartData struct {
+	// Exactly one of numericX or labels is populated.
+	numericX []float64
+	labels   []string
+	timeX    bool
+	series   []chartSeries
+}
+
+// RenderChartPNG renders the chart described by spec over rows and returns the
+// PNG-encoded image.
+func RenderChartPNG(spec ChartSpec, rows []map[string]any) ([]byte, error) {
+	data, err := buildChartData(spec, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+
+	plot := image.Rect(chartMarginLeft, chartMarginTop, chartWidth-chartMarginRight, chartHeight-chartMarginBottom)
+
+	yMin, yMax := data.yRange(spec.Type == ChartTypeBar || spec.Type == ChartTypePie)
+	yTicks := niceTicks(yMin, yMax, 6)
+	yMin, yMax = yTicks[0], yTicks[len(yTicks)-1]
+	yPos := func(v float64) int {
+		return plot.Max.Y - int(math.Round((v-yMin)/(yMax-yMin)*float64(plot.Dy())))
+	}
+
+	// Horizontal grid lines and Y tick labels
+	for _, t := range yTicks {
+		y := yPos(t)
+		drawHLine(img, plot.Min.X, plot.Max.X, y, chartGridColor)
+		label := formatChartNumber(t)
+		drawText(img, plot.Min.X-8-textWidth(label), y+4, label, chartTextColor)
+	}
+
+	switch spec.Type {
+	case ChartTypeBar, ChartTypePie:
+		drawBars(img, plot, data, yPos, yMin)
+	default:
+		drawXY(img, plot, data, yPos, spec.Type == ChartTypeScatter)
+	}
+
+	// Axes
+	drawHLine(img, plot.Min.X, plot.Max.X, plot.Max.Y, chartAxisColor)
+	drawVLine(img, plot.Min.X, plot.Min.Y, plot.Max.Y, chartAxisColor)
+
+	// Title and axis names
+	if spec.Title != "" {
+		title := TruncateString(spec.Title, 120)
+		drawText(img, (chartWidth-textWidth(title))/2, 24, title, chartTextColor)
+	}
+	drawText(img, plot.Min.X+(plot.Dx()-textWidth(spec.XAxis))/2, chartHeight-36, spec.XAxis, chartTextColor)
+
+	// Legend along the bottom edge
+	x := plot.Min.X
+	for i, s := range data.series {
+		c := chartPalette[i%len(chartPalette)]
+		fillRect(img, image.Rect(x, chartHeight-22, x+12, chartHeight-10), c)
+		drawText(img, x+16, chartHeight-11, s.name, chartTextColor)
+		x += 16 + textWidth(s.name) + 24
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// buildChartData extracts the X and Y columns from the rows. Time and numeric
+// X columns produce a continuous axis; anything else is treated as categories.
+func buildChartData(spec ChartSpec, rows []map[string]any) (*chartData, error) {
+	if spec.XAxis == "" || len(spec.YAxis) == 0 {
+		return nil, fmt.Errorf("chart requires an x axis and at least one y axis column")
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("chart requires at least 2 rows, got %d", len(rows))
+	}
+
+	yCols := spec.YAxis
+	if len(yCols) > chartMaxSeries {
+		yCols = yCols[:chartMaxSeries]
+	}
+
+	data := &chartData{}
+	categorical := spec.Type == ChartTypeBar || spec.Type == ChartTypePie
+
+	// Determine whether X is continuous (time or numeric)
+	continuous := !categorical
+	allTime := true
+	for _, row := range rows {
+		v := row[spec.XAxis]
+		if _, ok := chartTime(v); !ok {
+			allTime = false
+		}
+		if _, ok := chartFloat(v); !ok {
+			if _, isTime := chartTime(v); !isTime {
+				continuous = false
+			}
+		}
+	}
+
+	type point struct {
+		x     float64
+		label string
+		ys    []float64
+	}
+	points := make([]point, 0, len(rows))
+	for _, row := range rows {
+		p := point{ys: make([]float64, len(yCols))}
+		v := row[spec.XAxis]
+		if continuous {
+			if t, ok := chartTime(v); ok && allTime {
+				p.x = float64(t.Unix())
+			} else if f, ok := chartFloat(v); ok {
+				p.x = f
+			} else {
+				continue
+			}
+		} else {
+			p.label = chartLabel(v)
+		}
+		for i, col := range yCols {
+			f, ok := chartFloat(row[col])
+			if !ok {
+				f = math.NaN()
+			}
+			p.ys[i] = f
+		}
+		points = append(points, p)
+	}
+
+	if continuous {
+		sort.SliceStable(points, func(i, j int) bool { return points[i].x < points[j].x })
+		data.timeX = allTime
+	} else if len(points) > chartMaxBars {
+		points = points[:chartMaxBars]
+	}
+
+	data.series = make([]chartSeries, len(yCols))
+	for i, col := range yCols {
+		data.series[i] = chartSeries{name: col, values: make([]float64, len(points))}
+	}
+	hasValue := false
+	for i, p := range points {
+		if continuous {
+			data.numericX = append(data.numericX, p.x)
+		} else {
+			data.labels = append(data.labels, p.label)
+		}
+		for j, y := range p.ys {
+			data.series[j].values[i] = y
+			if !math.IsNaN(y) {
+				hasValue = true
+			}
+		}
+	}
+	if !hasValue {
+		return nil, fmt.Errorf("no numeric values found in y axis columns %s", strings.Join(yCols, ", "))
+	}
+	return data, nil
+}
+
+// yRange returns the min and max Y across all series. Bar charts always
+// include zero so bar heights are comparable.
+func (d *chartData) yRange(includeZero bool) (float64, float64) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range d.series {
+		for _, v := range s.values {
+			if math.IsNaN(v) {
+				continue
+			}
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+	}
+	if includeZero {
+		lo = math.Min(lo, 0)
+		hi = math.Max(hi, 0)
+	}
+	if lo == hi {
+		if lo == 0 {
+			return 0, 1
+		}
+		pad := math.Abs(lo) * 0.1
+		return lo - pad, hi + pad
+	}
+	return lo, hi
+}
+
+func drawXY(img *image.RGBA, plot image.Rectangle, data *chartData, yPos func(float64) int, scatter bool) {
+	n := len(data.series[0].values)
+	var xPos func(i int) int
+	if data.numericX != nil {
+		xMin, xMax := data.numericX[0], data.numericX[len(data.numericX)-1]
+		if xMin == xMax {
+			xMax = xMin + 1
+		}
+		xPos = func(i int) int {
+			return plot.Min.X + int(math.Round((data.numericX[i]-xMin)/(xMax-xMin)*float64(plot.Dx())))
+		}
+
+		// X tick labels at evenly spaced positions
+		for k := 0; k <= 5; k++ {
+			v := xMin + (xMax-xMin)*float64(k)/5
+			x := plot.Min.X + plot.Dx()*k/5
+			label := formatChartNumber(v)
+			if data.timeX {
+				label = formatChartTime(time.Unix(int64(v), 0).UTC(), time.Duration(xMax-xMin)*time.Second)
+			}
+			drawVLine(img, x, plot.Max.Y, plot.Max.Y+4, chartAxisColor)
+			w := textWidth(label)
+			drawText(img, min(x-w/2, chartWidth-w-4), plot.Max.Y+18, label, chartTextColor)
+		}
+	} else {
+		step := float64(plot.Dx()) / float64(max(n-1, 1))
+		xPos = func(i int) int { return plot.Min.X + int(math.Round(float64(i)*step)) }
+		drawCategoryLabels(img, plot, data.labels, xPos)
+	}
+
+	for si, s := range data.series {
+		c := chartPalette[si%len(chartPalette)]
+		prevX, prevY, havePrev := 0, 0, false
+		for i, v := range s.values {
+			if math.IsNaN(v) {
+				havePrev = false
+				continue
+			}
+			x, y := xPos(i), yPos(v)
+			if scatter {
+				fillCircle(img, x, y, 3, c)
+				continue
+			}
+			if havePrev {
+				drawLine(img, prevX, prevY, x, y, c)
+			}
+			prevX, prevY, havePrev = x, y, true
+		}
+	}
+}
+
+func drawBars(img *image.RGBA, plot image.Rectangle, data *chartData, yPos func(float64) int, yMin float64) {
+	n := len(data.labels)
+	if n == 0 {
+		return
+	}
+	slot := float64(plot.Dx()) / float64(n)
+	groupWidth := slot * 0.8
+	barWidth := groupWidth / float64(len(data.series))
+	base := yPos(math.Max(0, yMin))
+
+	xCenter := func(i int) int { return plot.Min.X + int(math.Round(slot*(float64(i)+0.5))) }
+	for i := 0; i < n; i++ {
+		left := float64(xCenter(i)) - groupWidth/2
+		for si, s := range data.series {
+			v := s.values[i]
+			if math.IsNaN(v) {
+				continue
+			}
+			x0 := int(math.Round(left + barWidth*float64(si)))
+			x1 := int(math.Round(left + barWidth*float64(si+1)))
+			y := yPos(v)
+			fillRect(img, image.Rect(x0, min(y, base), max(x1-1, x0+1), max(y, base)), chartPalette[si%len(chartPalette)])
+		}
+	}
+	drawCategoryLabels(img, plot, data.labels, xCenter)
+}
+
+// drawCategoryLabels draws as many category labels as fit without overlapping.
+func drawCategoryLabels(img *image.RGBA, plot image.Rectangle, labels []string, xPos func(int) int) {
+	if len(labels) == 0 {
+		return
+	}
+	maxLabel := 0
+	for _, l := range labels {
+		maxLabel = max(maxLabel, textWidth(TruncateString(l, 16)))
+	}
+	every := 1
+	if spacing := plot.Dx() / len(labels); spacing > 0 && maxLabel+8 > spacing {
+		every = (maxLabel+8)/spacing + 1
+	}
+	for i := 0; i < len(labels); i += every {
+		label := TruncateString(labels[i], 16)
+		x := xPos(i)
+		drawText(img, x-textWidth(label)/2, plot.Max.Y+18, label, chartTextColor)
+	}
+}
+
+// niceTicks returns evenly spaced, human-friendly tick values covering [lo, hi].
+func niceTicks(lo, hi float64, target int) []float64 {
+	span := hi - lo
+	rawStep := span / float64(target)
+	mag := math.Pow(10, math.Floor(math.Log10(rawStep)))
+	var step float64
+	switch norm := rawStep / mag; {
+	case norm <= 1:
+		step = mag
+	case norm <= 2:
+		step = 2 * mag
+	case norm <= 5:
+		step = 5 * mag
+	default:
+		step = 10 * mag
+	}
+	start := math.Floor(lo/step) * step
+	end := math.Ceil(hi/step) * step
+	var ticks []float64
+	for v := start; v <= end+step/2; v += step {
+		ticks = append(ticks, v)
+	}
+	if len(ticks) < 2 {
+		ticks = append(ticks, start+step)
+	}
+	return ticks
+}
+
+func formatChartNumber(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e9:
+		return strconv.FormatFloat(v/1e9, 'f', -1, 64) + "B"
+	case abs >= 1e6:
+		return strconv.FormatFloat(v/1e6, 'f', -1, 64) + "M"
+	case abs >= 1e4:
+		return strconv.FormatFloat(v/1e3, 'f', -1, 64) + "K"
+	case abs == 0 || abs >= 1:
+		return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(v, 'g', 3, 64)
+	}
+}
+
+func formatChartTime(t time.Time, span time.Duration) string {
+	switch {
+	case span > 60*24*time.Hour:
+		return t.Format("2006-01-02")
+	case span > 2*24*time.Hour:
+		return t.Format("01-02 15:04")
+	default:
+		return t.Format("15:04")
+	}
+}
+
+// chartFloat converts a result value to a float, dereferencing pointers for
+// nullable columns and parsing numeric strings (e.g. Decimal or UInt64 rendered as text).
+func chartFloat(v any) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	switch val := v.(type) {
+	case float64:
+		return val, !math.IsNaN(val) && !math.IsInf(val, 0)
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case bool:
+		return 0, false
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return f, err == nil
+	case fmt.Stringer:
+		if _, isTime := v.(time.Time); isTime {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(val.String(), 64)
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return 0, false
+		}
+		return chartFloat(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// chartTime converts a result value to a time, accepting time.Time (or a
+// pointer to one) and RFC 3339 / ClickHouse DateTime strings.
+func chartTime(v any) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case *time.Time:
+		if val == nil {
+			return time.Time{}, false
+		}
+		return *val, true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, val); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func chartLabel(v any) string {
+	if v == nil {
+		return "null"
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return "null"
+		}
+		return chartLabel(rv.Elem().Interface())
+	}
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format("2006-01-02 15:04")
+	}
+	return fmt.Sprint(v)
+}
+
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+func textWidth(s string) int {
+	return font.MeasureString(basicfont.Face7x13, s).Round()
+}
+
+func drawHLine(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, c)
+	}
+}
+
+func drawVLine(img *image.RGBA, x, y0, y1 int, c color.Color) {
+	for y := y0; y <= y1; y++ {
+		img.Set(x, y, c)
+	}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+func fillCircle(img *image.RGBA, cx, cy, r int, c color.Color) {
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if dx*dx+dy*dy <= r*r {
+				img.Set(cx+dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+// drawLine draws a 2px wide line using Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		img.Set(x0+1, y0, c)
+		img.Set(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/malbeclabs/lake/agent/pkg/workflow"
+	"github.com/malbeclabs/lake/api/handlers"
+)
+
+const (
+	// chartSampleRows is the number of rows sent to the recommender, matching the web UI.
+	chartSampleRows = 10
+)
+
+// ChartRecommender recommends a chart for the results of an executed query.
+type ChartRecommender interface {
+	// RecommendChart returns the chart to render, or ok=false if the results
+	// are not worth visualizing.
+	RecommendChart(ctx context.Context, question string, result workflow.QueryResult) (spec ChartSpec, ok bool, err error)
+}
+
+// VisualizationRecommender recommends charts using the same LLM recommendation
+// as the web UI's /api/visualize/recommend endpoint.
+type VisualizationRecommender struct{}
+
+// NewVisualizationRecommender creates a new visualization recommender.
+func NewVisualizationRecommender() *VisualizationRecommender {
+	return &VisualizationRecommender{}
+}
+
+// RecommendChart asks the visualization recommender for a chart over the result.
+func (r *VisualizationRecommender) RecommendChart(ctx context.Context, question string, result workflow.QueryResult) (ChartSpec, bool, error) {
+	req := handlers.VisualizeRequest{
+		Columns:  result.Columns,
+		RowCount: result.Count,
+		Query:    result.QueryText(),
+	}
+	for i := 0; i < min(chartSampleRows, len(result.Rows)); i++ {
+		row := make([]any, len(result.Columns))
+		for j, col := range result.Columns {
+			row[j] = result.Rows[i][col]
+		}
+		req.SampleRows = append(req.SampleRows, row)
+	}
+
+	resp := handlers.GetVisualizationRecommendation(ctx, req)
+	if resp.Error != "" {
+		return ChartSpec{}, false, fmt.Errorf("visualization recommendation failed: %s", resp.Error)
+	}
+	if !resp.Recommended || resp.XAxis == "" || len(resp.YAxis) == 0 {
+		return ChartSpec{}, false, nil
+	}
+
+	return ChartSpec{
+		Type:  resp.ChartType,
+		XAxis: resp.XAxis,
+		YAxis: resp.YAxis,
+		Title: question,
+	}, true, nil
+}
+
+// selectChartQuery picks the executed query whose results are most worth
+// charting: a successful SQL query with multiple rows and at least one numeric
+// column. Time-series results are preferred, then the most recent query.
+func selectChartQuery(executedQueries []workflow.ExecutedQuery) (workflow.ExecutedQuery, bool) {
+	var fallback *workflow.ExecutedQuery
+	for i := len(executedQueries) - 1; i >= 0; i-- {
+		eq := executedQueries[i]
+		res := eq.Result
+		if eq.GeneratedQuery.IsCypher() || res.Error != "" || len(res.Rows) < 2 || len(res.Columns) < 2 {
+			continue
+		}
+
+		hasNumeric, hasTime := false, false
+		for _, col := range res.Columns {
+			v := res.Rows[0][col]
+			if _, ok := chartTime(v); ok {
+				hasTime = true
+				continue
+			}
+			if _, ok := chartFloat(v); ok {
+				hasNumeric = true
+			}
+		}
+		if !hasNumeric {
+			continue
+		}
+		if hasTime {
+			return eq, true
+		}
+		if fallback == nil {
+			fallback = &executedQueries[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return workflow.ExecutedQuery{}, false
+}
@@ -0,0 +1,176 @@
+package bot
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/malbeclabs/lake/agent/pkg/workflow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAI_Slack_RenderChartPNG(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeRows := []map[string]any{
+		{"ts": base, "p50_ms": 12.5, "p99_ms": 20.1},
+		{"ts": base.Add(time.Hour), "p50_ms": 13.0, "p99_ms": nil},
+		{"ts": base.Add(2 * time.Hour), "p50_ms": 11.9, "p99_ms": 25.4},
+	}
+	categoryRows := []map[string]any{
+		{"metro": "nyc", "links": uint64(4)},
+		{"metro": "lax", "links": uint64(2)},
+		{"metro": "fra", "links": uint64(7)},
+	}
+
+	tests := []struct {
+		name string
+		spec ChartSpec
+		rows []map[string]any
+	}{
+		{
+			name: "line over time",
+			spec: ChartSpec{Type: ChartTypeLine, XAxis: "ts", YAxis: []string{"p50_ms", "p99_ms"}, Title: "Latency"},
+			rows: timeRows,
+		},
+		{
+			name: "bar over categories",
+			spec: ChartSpec{Type: ChartTypeBar, XAxis: "metro", YAxis: []string{"links"}},
+			rows: categoryRows,
+		},
+		{
+			name: "pie falls back to bar",
+			spec: ChartSpec{Type: ChartTypePie, XAxis: "metro", YAxis: []string{"links"}},
+			rows: categoryRows,
+		},
+		{
+			name: "scatter",
+			spec: ChartSpec{Type: ChartTypeScatter, XAxis: "p50_ms", YAxis: []string{"p99_ms"}},
+			rows: timeRows,
+		},
+		{
+			name: "line over categories",
+			spec: ChartSpec{Type: ChartTypeLine, XAxis: "metro", YAxis: []string{"links"}},
+			rows: categoryRows,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			data, err := RenderChartPNG(tt.spec, tt.rows)
+			require.NoError(t, err)
+
+			img, err := png.Decode(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Equal(t, chartWidth, img.Bounds().Dx())
+			require.Equal(t, chartHeight, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestAI_Slack_RenderChartPNG_Errors(t *testing.T) {
+	t.Parallel()
+
+	rows := []map[string]any{
+		{"name": "a", "value": "x"},
+		{"name": "b", "value": "y"},
+	}
+
+	_, err := RenderChartPNG(ChartSpec{Type: ChartTypeBar, XAxis: "name"}, rows)
+	require.Error(t, err)
+
+	_, err = RenderChartPNG(ChartSpec{Type: ChartTypeBar, XAxis: "name", YAxis: []string{"value"}}, rows[:1])
+	require.Error(t, err)
+
+	_, err = RenderChartPNG(ChartSpec{Type: ChartTypeBar, XAxis: "name", YAxis: []string{"value"}}, rows)
+	require.ErrorContains(t, err, "no numeric values")
+}
+
+func TestAI_Slack_ChartFloat(t *testing.T) {
+	t.Parallel()
+
+	v := int32(5)
+	var nilPtr *float64
+
+	f, ok := chartFloat(&v)
+	require.True(t, ok)
+	require.Equal(t, 5.0, f)
+
+	f, ok = chartFloat("1.25")
+	require.True(t, ok)
+	require.Equal(t, 1.25, f)
+
+	_, ok = chartFloat(nilPtr)
+	require.False(t, ok)
+
+	_, ok = chartFloat(time.Now())
+	require.False(t, ok)
+
+	_, ok = chartFloat("nyc")
+	require.False(t, ok)
+}
+
+func TestAI_Slack_NiceTicks(t *testing.T) {
+	t.Parallel()
+
+	ticks := niceTicks(0.3, 9.7, 5)
+	require.Equal(t, []float64{0, 2, 4, 6, 8, 10}, ticks)
+}
+
+func TestAI_Slack_SelectChartQuery(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	single := workflow.ExecutedQuery{
+		GeneratedQuery: workflow.GeneratedQuery{SQL: "SELECT count() AS n"},
+		Result:         workflow.QueryResult{Columns: []string{"n"}, Rows: []map[string]any{{"n": 1}}},
+	}
+	categories := workflow.ExecutedQuery{
+		GeneratedQuery: workflow.GeneratedQuery{SQL: "SELECT metro, count() AS n"},
+		Result: workflow.QueryResult{Columns: []string{"metro", "n"}, Rows: []map[string]any{
+			{"metro": "nyc", "n": uint64(1)},
+			{"metro": "lax", "n": uint64(2)},
+		}},
+	}
+	series := workflow.ExecutedQuery{
+		GeneratedQuery: workflow.GeneratedQuery{SQL: "SELECT ts, avg(rtt) AS rtt"},
+		Result: workflow.QueryResult{Columns: []string{"ts", "rtt"}, Rows: []map[string]any{
+			{"ts": ts, "rtt": 1.0},
+			{"ts": ts.Add(time.Minute), "rtt": 2.0},
+		}},
+	}
+	failed := workflow.ExecutedQuery{
+		GeneratedQuery: workflow.GeneratedQuery{SQL: "SELECT bad"},
+		Result:         workflow.QueryResult{Error: "unknown column"},
+	}
+	cypher := workflow.ExecutedQuery{
+		GeneratedQuery: workflow.GeneratedQuery{Cypher: "MATCH (n) RETURN n.code, n.degree"},
+		Result: workflow.QueryResult{Columns: []string{"code", "degree"}, Rows: []map[string]any{
+			{"code": "a", "degree": 1},
+			{"code": "b", "degree": 2},
+		}},
+	}
+
+	t.Run("prefers time series", func(t *testing.T) {
+		t.Parallel()
+		eq, ok := selectChartQuery([]workflow.ExecutedQuery{series, categories, failed})
+		require.True(t, ok)
+		require.Equal(t, series.GeneratedQuery.SQL, eq.GeneratedQuery.SQL)
+	})
+
+	t.Run("falls back to latest chartable", func(t *testing.T) {
+		t.Parallel()
+		eq, ok := selectChartQuery([]workflow.ExecutedQuery{categories, single, cypher})
+		require.True(t, ok)
+		require.Equal(t, categories.GeneratedQuery.SQL, eq.GeneratedQuery.SQL)
+	})
+
+	t.Run("nothing chartable", func(t *testing.T) {
+		t.Parallel()
+		_, ok := selectChartQuery([]workflow.ExecutedQuery{single, failed, cypher})
+		require.False(t, ok)
+	})
+}
@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log/slog"
@@ -190,6 +191,31 @@ func (c *Client) DeleteMessage(ctx context.Context, channelID, timestamp string)
 	return nil
 }
 
+// UploadImage uploads a PNG image into a thread.
+// Requires the files:write scope on the bot token.
+func (c *Client) UploadImage(ctx context.Context, channelID, threadTS, filename, title string, data []byte) error {
+	var err error
+	retryCfg := retry.DefaultConfig()
+	err = retry.Do(ctx, retryCfg, func() error {
+		_, err = c.api.UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+			Channel:         channelID,
+			ThreadTimestamp: threadTS,
+			Reader:          bytes.NewReader(data),
+			FileSize:        len(data),
+			Filename:        filename,
+			Title:           title,
+			AltTxt:          title,
+		})
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to upload image after retries: %w", err)
+	}
+
+	return nil
+}
+
 // RemoveBotMention removes bot mention from text for cleaner processing
 func (c *Client) RemoveBotMention(text string) string {
 	if c.botUserID == "" {
@@ -87,6 +87,14 @@ var (
 		[]string{"operation"},
 	)
 
+	ChartsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "doublezero_ai_slack_charts_total",
+			Help: "Total number of chart rendering attempts by outcome",
+		},
+		[]string{"status"},
+	)
+
 	ConversationHistoryErrorsTotal = promauto.NewCounter(
 		prometheus.CounterOpts{
 			Name: "doublezero_ai_slack_conversation_history_errors_total",
@@ -28,6 +28,10 @@ type Processor struct {
 	log         *slog.Logger
 	webBaseURL  string // Base URL for web UI (for query editor links)
 
+	// Optional chart recommender; when set, a rendered chart of the most
+	// relevant query result is uploaded alongside data analysis answers
+	chartRecommender ChartRecommender
+
 	// Track messages we've already responded to (by message timestamp) to prevent duplicate error messages
 	respondedMessages   map[string]time.Time
 	respondedMessagesMu sync.RWMutex
@@ -62,6 +66,11 @@ func NewProcessor(
 	}
 }
 
+// SetChartRecommender enables server-side chart rendering for data analysis answers.
+func (p *Processor) SetChartRecommender(r ChartRecommender) {
+	p.chartRecommender = r
+}
+
 // getThreadLock returns the mutex for a given thread, creating one if it doesn't exist
 func (p *Processor) getThreadLock(threadKey string) *sync.Mutex {
 	p.threadLocksMu.Lock()
@@ -195,7 +204,7 @@ func formatThinkingMessage(progress workflow.Progress) string {
 			sb.WriteString("_:mag: Answered by querying:_\n")
 			n := 0
 			for _, q := range progress.DataQuestions {
-				if q.Rationale == "doc_read" {
+				if !isQueryStep(q) {
 					continue
 				}
 				n++
@@ -214,14 +223,20 @@ func formatThinkingMessage(progress workflow.Progress) string {
 	return sb.String()
 }
 
-// writeStepsList writes the ordered list of steps (queries and doc reads) with status indicators.
-// Queries are numbered Q1, Q2, etc. Doc reads use bullet points.
+// isQueryStep returns false for steps that don't correspond to an executed
+// query (doc reads and analytics tool calls), which are shown as bullets.
+func isQueryStep(q workflow.DataQuestion) bool {
+	return q.Rationale != "doc_read" && q.Rationale != "analytics"
+}
+
+// writeStepsList writes the ordered list of steps (queries, doc reads, and
+// analytics) with status indicators. Queries are numbered Q1, Q2, etc. Other
+// steps use bullet points.
 func writeStepsList(sb *strings.Builder, progress workflow.Progress) {
 	qNum := 0
 	for i, q := range progress.DataQuestions {
-		isDocRead := q.Rationale == "doc_read"
 		prefix := "• "
-		if !isDocRead {
+		if isQueryStep(q) {
 			qNum++
 			prefix = fmt.Sprintf("Q%d. ", qNum)
 		}
@@ -244,7 +259,7 @@ func formatCompletionSummary(dataQuestions []workflow.DataQuestion, executedQuer
 
 	n := 0
 	for _, q := range dataQuestions {
-		if q.Rationale == "doc_read" {
+		if !isQueryStep(q) {
 			continue
 		}
 		n++
@@ -482,5 +497,63 @@ func (p *Processor) ProcessMessage(
 			workflow.ConversationMessage{Role: "assistant", Content: result.Answer, ExecutedQueries: executedSQL},
 		)
 		p.convManager.UpdateConversationHistory(threadKey, newHistory)
+
+		if result.Classification == workflow.ClassificationDataAnalysis {
+			p.postChart(ctx, client, ev.Channel, threadTS, txt, result.ExecutedQueries)
+		}
+	}
+}
+
+// postChart renders the recommended chart for the most relevant executed query
+// and uploads it into the thread. Failures are logged and otherwise ignored, since
+// the text answer has already been posted.
+func (p *Processor) postChart(
+	ctx context.Context,
+	client *Client,
+	channelID string,
+	threadTS string,
+	question string,
+	executedQueries []workflow.ExecutedQuery,
+) {
+	if p.chartRecommender == nil {
+		return
+	}
+
+	eq, ok := selectChartQuery(executedQueries)
+	if !ok {
+		return
 	}
+
+	title := eq.GeneratedQuery.DataQuestion.Question
+	if title == "" {
+		title = question
+	}
+
+	spec, ok, err := p.chartRecommender.RecommendChart(ctx, title, eq.Result)
+	if err != nil {
+		ChartsTotal.WithLabelValues("error").Inc()
+		p.log.Warn("failed to recommend chart", "error", err)
+		return
+	}
+	if !ok {
+		ChartsTotal.WithLabelValues("not_recommended").Inc()
+		return
+	}
+
+	img, err := RenderChartPNG(spec, eq.Result.Rows)
+	if err != nil {
+		ChartsTotal.WithLabelValues("error").Inc()
+		p.log.Warn("failed to render chart", "error", err, "chart_type", spec.Type, "x_axis", spec.XAxis, "y_axis", spec.YAxis)
+		return
+	}
+
+	if err := client.UploadImage(ctx, channelID, threadTS, "chart.png", TruncateString(title, 200), img); err != nil {
+		ChartsTotal.WithLabelValues("error").Inc()
+		SlackAPIErrorsTotal.WithLabelValues("upload_file").Inc()
+		p.log.Warn("failed to upload chart", "error", err)
+		return
+	}
+
+	ChartsTotal.WithLabelValues("posted").Inc()
+	p.log.Info("chart posted", "channel", channelID, "thread_ts", threadTS, "chart_type", spec.Type)
 }
@@ -125,6 +125,22 @@ func TestAI_Slack_FormatThinkingMessage(t *testing.T) {
 		require.Contains(t, result, "Q2. Average stake :hourglass_flowing_sand:")
 	})
 
+	t.Run("executing with analytics", func(t *testing.T) {
+		t.Parallel()
+		result := formatThinkingMessage(workflow.Progress{
+			Stage: workflow.StageExecuting,
+			DataQuestions: []workflow.DataQuestion{
+				{Question: "Running get_link_outages", Rationale: "analytics"},
+				{Question: "Validator count"},
+			},
+			QueriesTotal: 2,
+			QueriesDone:  1,
+		})
+		// Analytics calls use bullets and don't consume query numbers
+		require.Contains(t, result, "• Running get_link_outages ✓")
+		require.Contains(t, result, "Q1. Validator count :hourglass_flowing_sand:")
+	})
+
 	t.Run("synthesizing", func(t *testing.T) {
 		t.Parallel()
 		result := formatThinkingMessage(workflow.Progress{
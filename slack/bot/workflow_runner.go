@@ -87,8 +87,17 @@ func (r *WorkflowRunner) ChatStream(
 	// Add env context so agent knows about other databases for cross-querying
 	cfg.EnvContext = handlers.BuildEnvContext(handlers.EnvMainnet)
 
+	// Add cross-environment query support if more than one env is configured
+	cfg.EnvQuerier = handlers.NewEnvQuerier()
+
+	// Validate SQL against the schema and with EXPLAIN before executing it
+	cfg.SQLValidator = workflow.NewSchemaSQLValidator(schemaFetcher, querier)
+
+	// Add curated analytics tools
+	cfg.Analytics = handlers.NewAnalyticsProvider()
+
 	// Add Neo4j support if available
-	if config.Neo4jClient != nil {
+	if config.Neo4jClientForEnv(string(handlers.EnvMainnet)) != nil {
 		cfg.GraphQuerier = handlers.NewNeo4jQuerier()
 		cfg.GraphSchemaFetcher = handlers.NewNeo4jSchemaFetcher()
 	}
@@ -178,6 +187,28 @@ func (r *WorkflowRunner) ChatStream(
 				QueriesTotal:  queriesTotal,
 				QueriesDone:   queriesDone,
 			})
+
+		case workflow.StageAnalyticsStarted:
+			queriesTotal++
+			dataQuestions = append(dataQuestions, workflow.DataQuestion{
+				Question:  "Running " + progress.AnalyticsTool,
+				Rationale: "analytics",
+			})
+			onProgress(workflow.Progress{
+				Stage:         workflow.StageExecuting,
+				DataQuestions: dataQuestions,
+				QueriesTotal:  queriesTotal,
+				QueriesDone:   queriesDone,
+			})
+
+		case workflow.StageAnalyticsComplete:
+			queriesDone++
+			onProgress(workflow.Progress{
+				Stage:         workflow.StageExecuting,
+				DataQuestions: dataQuestions,
+				QueriesTotal:  queriesTotal,
+				QueriesDone:   queriesDone,
+			})
 		}
 	}
 
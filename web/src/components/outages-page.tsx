@@ -220,7 +220,7 @@ export function OutagesPage() {
   })
 
   const outages = useMemo(() => data?.outages || [], [data?.outages])
-  const summary = data?.summary || { total: 0, ongoing: 0, by_type: { status: 0, packet_loss: 0 } }
+  const summary = data?.summary || { total: 0, ongoing: 0, planned: 0, by_type: { status: 0, packet_loss: 0 } }
 
   // Sort state
   const [sortField, setSortField] = useState<'started_at' | 'duration'>('started_at')
@@ -458,6 +458,14 @@ export function OutagesPage() {
                       <div className="flex items-center gap-1.5">
                         <OutageTypeLabel type={outage.outage_type} />
                         <SeverityBadge severity={outage.severity} />
+                        {outage.planned && (
+                          <span
+                            className="inline-flex items-center px-2 py-0.5 rounded text-xs font-medium bg-blue-100 text-blue-800 dark:bg-blue-900 dark:text-blue-200"
+                            title="Started during a scheduled maintenance window"
+                          >
+                            Planned
+                          </span>
+                        )}
                       </div>
                     </td>
                     <td className="px-4 py-3">
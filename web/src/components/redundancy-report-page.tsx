@@ -31,6 +31,7 @@ const SEVERITY_COLORS = {
 const ISSUE_TYPE_LABELS: Record<string, string> = {
   leaf_device: 'Leaf Device',
   critical_link: 'Critical Link',
+  critical_device: 'Critical Device',
   single_exit_metro: 'Single-Exit Metro',
   no_backup_device: 'No Backup Device',
 }
@@ -325,7 +326,7 @@ function DegradedLinkRow({
   )
 }
 
-type FilterType = 'all' | 'leaf_device' | 'critical_link' | 'single_exit_metro'
+type FilterType = 'all' | 'leaf_device' | 'critical_link' | 'critical_device' | 'single_exit_metro'
 type FilterSeverity = 'all' | 'critical' | 'warning' | 'info'
 
 export function RedundancyReportPage() {
@@ -468,6 +469,7 @@ export function RedundancyReportPage() {
             <option value="all">All Types</option>
             <option value="leaf_device">Leaf Devices</option>
             <option value="critical_link">Critical Links</option>
+            <option value="critical_device">Critical Devices</option>
             <option value="single_exit_metro">Single-Exit Metros</option>
           </select>
         </div>
@@ -1730,6 +1730,125 @@ export async function fetchISISPaths(fromPK: string, toPK: string, k: number = 5
   return res.json()
 }
 
+// Segment routing types
+export interface SRSegment {
+  type: 'node' | 'adjacency'
+  fromPK: string
+  fromCode: string
+  toPK: string
+  toCode: string
+  sid: number    // node SID index, or the label of an adjacency SID
+  label: number
+}
+
+export interface SRPath {
+  path: string[]
+  pathPKs: string[]
+  metric: number
+  hops: number
+  ecmp: boolean
+  segments: SRSegment[]
+  labels: number[]  // top of stack first
+  error?: string
+}
+
+export interface SRPathsResponse {
+  fromPK: string
+  fromCode: string
+  toPK: string
+  toCode: string
+  shortest?: SRPath
+  explicit?: SRPath
+  alternates: SRPath[]
+  error?: string
+}
+
+export async function fetchSRPaths(fromPK: string, toPK: string, via: string[] = [], alternates: number = 3): Promise<SRPathsResponse> {
+  const params = new URLSearchParams({ from: fromPK, to: toPK, alternates: String(alternates) })
+  if (via.length > 0) params.set('via', via.join(','))
+  const res = await apiFetch(`/api/topology/sr-paths?${params}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch SR paths')
+  }
+  return res.json()
+}
+
+export interface SRSIDIssue {
+  type: 'missing_srgb' | 'missing_node_sid' | 'duplicate_prefix_sid' | 'sid_outside_srgb' | 'srgb_mismatch' | 'adj_sid_in_srgb' | 'duplicate_adj_sid'
+  devicePKs: string[]
+  deviceCodes: string[]
+  sid?: number
+  detail: string
+}
+
+export interface SRValidationResponse {
+  deviceCount: number
+  issues: SRSIDIssue[]
+  issueCount: number
+  error?: string
+}
+
+export async function fetchSRValidation(): Promise<SRValidationResponse> {
+  const res = await apiFetch('/api/topology/sr-validation')
+  if (!res.ok) {
+    throw new Error('Failed to fetch SR validation')
+  }
+  return res.json()
+}
+
+// Latency divergence types
+export interface LatencyDivergencePair {
+  fromMetroCode: string
+  toMetroCode: string
+  igpPath: string[]
+  igpMetric: number
+  igpMeasuredMs: number
+  bestPath: string[]
+  bestMeasuredMs: number
+  deltaMs: number
+  deltaPct: number
+  unmeasuredHops: number
+  divergent: boolean
+}
+
+export interface LatencyDivergenceLink {
+  linkPK: string
+  linkCode: string
+  sideACode: string
+  sideZCode: string
+  isisMetric: number
+  delayOverrideNs: number
+  measuredRttUs: number
+  sampleCount: number
+  deltaPct: number  // positive when the metric overstates the latency
+  source: 'metric' | 'delay_override'
+  suggestedMetric: number
+  divergentPairCount: number
+}
+
+export interface LatencyDivergenceResponse {
+  hours: number
+  pairs: LatencyDivergencePair[]
+  links: LatencyDivergenceLink[]
+  summary: {
+    totalPairs: number
+    divergentPairs: number
+    avgDeltaMs: number
+    maxDeltaMs: number
+    staleLinks: number
+  }
+  error?: string
+}
+
+export async function fetchLatencyDivergence(hours: number = 3, minDeltaMs: number = 1, tolerance: number = 0.25): Promise<LatencyDivergenceResponse> {
+  const params = new URLSearchParams({ hours: String(hours), min_delta_ms: String(minDeltaMs), tolerance: String(tolerance) })
+  const res = await apiFetch(`/api/topology/latency-divergence?${params}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch latency divergence')
+  }
+  return res.json()
+}
+
 // Metro device paths types
 export interface MetroDevicePairPath {
   sourceDevicePK: string
@@ -1808,10 +1927,53 @@ export interface MulticastMember {
   last_leader_slot: number | null
   next_leader_slot: number | null
   current_slot: number
+  delivery: MulticastMemberDelivery | null
+}
+
+export type MulticastDeliveryStatus =
+  | 'delivered'
+  | 'loss_in_tree'
+  | 'loss_at_edge'
+  | 'loss_unlocated'
+  | 'idle'
+  | 'no_data'
+
+export interface MulticastDeliveryHop {
+  link_pk: string
+  link_code: string
+  from_device_pk: string
+  from_device_code: string
+  to_device_pk: string
+  to_device_code: string
+  loss_pct: number
+}
+
+export interface MulticastMemberDelivery {
+  evaluated_at: string
+  status: MulticastDeliveryStatus
+  expected_pkts: number
+  received_pkts: number
+  delivery_ratio: number
+  loss_pct: number
+  exclusive: boolean
+  worst_hop: MulticastDeliveryHop | null
+  path_hops: number
+  lag_ms: number
+}
+
+export interface MulticastDeliverySummary {
+  evaluated_at: string
+  subscribers: number
+  delivered: number
+  lossy: number
+  avg_loss_pct: number
+  worst_loss_pct: number
+  max_lag_ms: number
 }
 
 export interface MulticastGroupDetail extends MulticastGroupListItem {
   members: MulticastMember[]
+  delivery: MulticastDeliverySummary | null
 }
 
 export async function fetchMulticastGroups(): Promise<MulticastGroupListItem[]> {
@@ -1889,6 +2051,26 @@ export async function fetchMulticastGroupTraffic(pkOrCode: string, timeRange?: s
   return res.json()
 }
 
+// Multicast group delivery types
+export interface MulticastDeliveryPoint {
+  time: string
+  subscriber_pk: string
+  status: MulticastDeliveryStatus
+  delivery_ratio: number
+  loss_pct: number
+  worst_hop_link_pk: string
+  worst_hop_loss_pct: number
+  lag_ms: number
+}
+
+export async function fetchMulticastGroupDelivery(pkOrCode: string, hours: number = 24): Promise<MulticastDeliveryPoint[]> {
+  const res = await apiFetch(`/api/dz/multicast-groups/${encodeURIComponent(pkOrCode)}/delivery?hours=${hours}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch multicast group delivery')
+  }
+  return res.json()
+}
+
 // Critical links types
 export interface CriticalLink {
   sourcePK: string
@@ -1914,7 +2096,7 @@ export async function fetchCriticalLinks(): Promise<CriticalLinksResponse> {
 
 // Redundancy report types
 export interface RedundancyIssue {
-  type: 'leaf_device' | 'critical_link' | 'single_exit_metro' | 'no_backup_device'
+  type: 'leaf_device' | 'critical_link' | 'critical_device' | 'single_exit_metro' | 'no_backup_device'
   severity: 'critical' | 'warning' | 'info'
   entityPK: string
   entityCode: string
@@ -1934,6 +2116,7 @@ export interface RedundancySummary {
   infoCount: number
   leafDevices: number
   criticalLinks: number
+  criticalDevices: number
   singleExitMetros: number
 }
 
@@ -2040,6 +2223,9 @@ export interface AffectedPath {
   afterHops: number
   afterMetric: number
   hasAlternate: boolean
+  beforePath?: string[]
+  afterPath?: string[]
+  metricDelta?: number
 }
 
 export interface SimulateLinkRemovalResponse {
@@ -2779,6 +2965,20 @@ export interface UserDetail extends User {
   vote_pubkey: string
   stake_sol: number
   stake_weight_pct: number
+  health: UserHealth | null
+}
+
+export type UserHealthStatus = 'healthy' | 'degraded' | 'at_risk'
+
+export interface UserHealth {
+  evaluated_at: string
+  score: number
+  status: UserHealthStatus
+  issues: string[]
+  coverage_pct: number
+  error_rate_pct: number
+  discard_rate_pct: number
+  carrier_transitions: number
 }
 
 export async function fetchUser(pk: string): Promise<UserDetail> {
@@ -2889,6 +3089,47 @@ export async function fetchUserTraffic(pk: string, timeRange?: string, bucket?:
   return res.json()
 }
 
+// User tunnel health types
+export interface UserHealthPoint extends Omit<UserHealth, 'evaluated_at'> {
+  time: string
+}
+
+export async function fetchUserHealth(pk: string, hours: number = 24): Promise<UserHealthPoint[]> {
+  const res = await apiFetch(`/api/dz/users/${encodeURIComponent(pk)}/health?hours=${hours}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch user health')
+  }
+  return res.json()
+}
+
+export interface UserAtRisk {
+  pk: string
+  owner_pubkey: string
+  kind: string
+  dz_ip: string
+  client_ip: string
+  tunnel_id: number
+  device_pk: string
+  device_code: string
+  metro_code: string
+  health: UserHealth
+  unhealthy_pct_24h: number
+}
+
+export interface UsersAtRiskResponse {
+  users: UserAtRisk[]
+  at_risk: number
+  degraded: number
+}
+
+export async function fetchUsersAtRisk(): Promise<UsersAtRiskResponse> {
+  const res = await apiFetch('/api/dz/users/at-risk')
+  if (!res.ok) {
+    throw new Error('Failed to fetch users at risk')
+  }
+  return res.json()
+}
+
 export interface GossipNode {
   pubkey: string
   gossip_ip: string
@@ -3267,6 +3508,66 @@ export async function fetchLatencyHistory(
   return res.json()
 }
 
+// DZ vs internet advantage per epoch types
+export interface InternetAdvantageEpoch {
+  epoch: number
+  start_ts: string
+  end_ts: string
+  dz_p50_rtt_ms: number
+  dz_p95_rtt_ms: number
+  dz_p99_rtt_ms: number
+  dz_sample_count: number
+  internet_p50_rtt_ms: number
+  internet_p95_rtt_ms: number
+  internet_p99_rtt_ms: number
+  internet_sample_count: number
+  median_improvement_ms: number
+  median_improvement_pct: number
+  p95_improvement_ms: number
+  p95_improvement_pct: number
+  p99_improvement_ms: number
+  p99_improvement_pct: number
+  compared_hours: number
+  dz_win_pct: number | null
+  improvement_stddev_ms: number | null
+}
+
+export interface InternetAdvantagePair {
+  origin_metro_code: string
+  target_metro_code: string
+  epochs: InternetAdvantageEpoch[]
+  median_improvement_pct: number
+  median_improvement_change_pct: number
+  trend: 'improving' | 'declining' | 'stable'
+}
+
+export interface InternetAdvantageResponse {
+  epochs: number
+  pairs: InternetAdvantagePair[]
+  summary: {
+    total_pairs: number
+    improving: number
+    declining: number
+  }
+}
+
+export async function fetchInternetAdvantage(
+  epochs: number = 30,
+  originCode?: string,
+  targetCode?: string
+): Promise<InternetAdvantageResponse> {
+  const params = new URLSearchParams({ epochs: String(epochs) })
+  if (originCode && targetCode) {
+    params.set('origin', originCode)
+    params.set('target', targetCode)
+  }
+  const res = await apiFetch(`/api/topology/internet-advantage?${params}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch internet advantage')
+  }
+  return res.json()
+}
+
 // Metro path latency types (path-based DZ vs Internet comparison)
 export type PathOptimizeMode = 'hops' | 'latency' | 'bandwidth'
 
@@ -3382,6 +3683,9 @@ export interface MaintenanceAffectedPath {
   hopsAfter: number
   metricBefore: number
   metricAfter: number
+  metricDelta?: number
+  beforePath?: string[]
+  afterPath?: string[]
   status: 'rerouted' | 'degraded' | 'disconnected'
 }
 
@@ -3422,17 +3726,40 @@ export interface MaintenanceImpactResponse {
   affectedPaths?: MaintenanceAffectedPath[]
   affectedMetros?: AffectedMetroPair[]
   disconnectedList?: string[]
+  traffic?: TrafficProjection
   error?: string
 }
 
+// Projected link load once failures are rerouted
+export interface ProjectedLinkLoad {
+  linkPK: string
+  linkCode: string
+  fromCode: string
+  toCode: string
+  bandwidthBps: number
+  currentBps: number
+  projectedBps: number
+  currentUtilization: number
+  projectedUtilization: number
+  overloaded: boolean
+}
+
+export interface TrafficProjection {
+  utilizationThreshold: number
+  links: ProjectedLinkLoad[]
+  overloadedCount: number
+  unroutedBps: number
+}
+
 export async function fetchMaintenanceImpact(
   devices: string[],
-  links: string[]
+  links: string[],
+  utilizationThreshold?: number
 ): Promise<MaintenanceImpactResponse> {
   const res = await apiFetch('/api/topology/maintenance-impact', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ devices, links }),
+    body: JSON.stringify({ devices, links, utilizationThreshold }),
   })
   if (!res.ok) {
     throw new Error('Failed to analyze maintenance impact')
@@ -3440,6 +3767,107 @@ export async function fetchMaintenanceImpact(
   return res.json()
 }
 
+// Maintenance window types
+export interface MaintenanceEntity {
+  type: 'device' | 'link'
+  pk: string
+  code?: string
+}
+
+export interface MaintenanceConflict {
+  window_id: string
+  title: string
+  owner: string
+  overlap_start: string
+  overlap_end: string
+  disconnected_devices: string[]
+}
+
+export interface MaintenanceWindow {
+  id: string
+  env: string
+  title: string
+  entities: MaintenanceEntity[]
+  starts_at: string
+  ends_at: string
+  owner: string
+  notes: string
+  status: 'upcoming' | 'active' | 'completed'
+  created_at: string
+  updated_at: string
+  conflicts?: MaintenanceConflict[]
+}
+
+export interface MaintenanceWindowListResponse {
+  windows: MaintenanceWindow[]
+  total: number
+  has_more: boolean
+}
+
+export interface MaintenanceWindowInput {
+  title: string
+  entities: MaintenanceEntity[]
+  starts_at: string
+  ends_at: string
+  owner?: string
+  notes?: string
+}
+
+export async function fetchMaintenanceWindows(
+  status?: MaintenanceWindow['status'],
+  limit = 50,
+  offset = 0
+): Promise<MaintenanceWindowListResponse> {
+  const params = new URLSearchParams({ limit: String(limit), offset: String(offset) })
+  if (status) params.set('status', status)
+  const res = await apiFetch(`/api/maintenance-windows?${params}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch maintenance windows')
+  }
+  return res.json()
+}
+
+export async function fetchMaintenanceWindow(id: string): Promise<MaintenanceWindow> {
+  const res = await apiFetch(`/api/maintenance-windows/${id}`)
+  if (!res.ok) {
+    throw new Error('Failed to fetch maintenance window')
+  }
+  return res.json()
+}
+
+export async function createMaintenanceWindow(input: MaintenanceWindowInput): Promise<MaintenanceWindow> {
+  const res = await apiFetch('/api/maintenance-windows', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(input),
+  })
+  if (!res.ok) {
+    throw new Error(await res.text() || 'Failed to create maintenance window')
+  }
+  return res.json()
+}
+
+export async function updateMaintenanceWindow(id: string, input: MaintenanceWindowInput): Promise<MaintenanceWindow> {
+  const res = await apiFetch(`/api/maintenance-windows/${id}`, {
+    method: 'PUT',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(input),
+  })
+  if (!res.ok) {
+    throw new Error(await res.text() || 'Failed to update maintenance window')
+  }
+  return res.json()
+}
+
+export async function deleteMaintenanceWindow(id: string): Promise<void> {
+  const res = await apiFetch(`/api/maintenance-windows/${id}`, {
+    method: 'DELETE',
+  })
+  if (!res.ok && res.status !== 404) {
+    throw new Error('Failed to delete maintenance window')
+  }
+}
+
 // What-if removal types (unified API for devices and links)
 export interface WhatIfAffectedPath {
   source: string
@@ -3450,6 +3878,9 @@ export interface WhatIfAffectedPath {
   metricBefore: number
   hopsAfter: number
   metricAfter: number
+  metricDelta?: number
+  beforePath?: string[]
+  afterPath?: string[]
   status: 'rerouted' | 'degraded' | 'disconnected'
 }
 
@@ -3470,17 +3901,19 @@ export interface WhatIfRemovalResponse {
   totalDisconnected: number
   affectedPaths?: WhatIfAffectedPath[]
   disconnectedList?: string[]
+  traffic?: TrafficProjection
   error?: string
 }
 
 export async function fetchWhatIfRemoval(
   devices: string[],
-  links: string[]
+  links: string[],
+  utilizationThreshold?: number
 ): Promise<WhatIfRemovalResponse> {
   const res = await apiFetch('/api/topology/whatif-removal', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ devices, links }),
+    body: JSON.stringify({ devices, links, utilizationThreshold }),
   })
   if (!res.ok) {
     throw new Error('Failed to analyze what-if removal impact')
@@ -3913,6 +4346,63 @@ export async function fetchDashboardBurstiness(
   return res.json()
 }
 
+export interface DashboardForecastPoint {
+  date: string
+  p95_util?: number
+  forecast_util: number
+}
+
+export interface DashboardForecastEntity {
+  pk: string
+  code: string
+  link_type?: string
+  metro_codes: string[]
+  contributor_code: string
+  bandwidth_bps: number
+  history_days: number
+  current_p95_util: number
+  trend_per_day: number
+  seasonal_amplitude: number
+  projected_p95_util: number
+  days_until_saturation?: number
+  saturation_date?: string
+  series: DashboardForecastPoint[]
+}
+
+export interface DashboardForecastResponse {
+  entity: 'link' | 'device'
+  threshold: number
+  history_days: number
+  horizon_days: number
+  entities: DashboardForecastEntity[]
+}
+
+export interface DashboardForecastParams {
+  entity?: 'link' | 'device'
+  history_days?: number
+  horizon_days?: number
+  threshold?: number
+  limit?: number
+  metro?: string
+  device?: string
+  link_type?: string
+  contributor?: string
+}
+
+export async function fetchDashboardForecast(
+  params: DashboardForecastParams = {}
+): Promise<DashboardForecastResponse> {
+  const searchParams = new URLSearchParams()
+  for (const [key, value] of Object.entries(params)) {
+    if (value !== undefined && value !== '') {
+      searchParams.set(key, String(value))
+    }
+  }
+  const res = await fetchWithRetry(`/api/traffic/dashboard/forecast?${searchParams}`)
+  if (!res.ok) throw new Error('Failed to fetch dashboard forecast data')
+  return res.json()
+}
+
 // Dashboard health types and functions
 
 export interface DashboardHealthEntity {
@@ -4038,11 +4528,15 @@ export interface LinkOutage {
   duration_seconds?: number
   is_ongoing: boolean
   severity?: 'degraded' | 'outage'
+  // Started during a maintenance window covering the link or its devices
+  planned: boolean
+  maintenance_window_id?: string
 }
 
 export interface LinkOutagesSummary {
   total: number
   ongoing: number
+  planned: number
   by_type: {
     status: number
     packet_loss: number